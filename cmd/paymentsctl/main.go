@@ -0,0 +1,485 @@
+// Command paymentsctl runs operational tasks against the payments database with the
+// same configuration and business rules as the server.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"payments_app/configs"
+	"payments_app/internal/app"
+	"payments_app/internal/domain"
+	"payments_app/internal/interfaces/bulk"
+	"payments_app/internal/interfaces/export"
+	"payments_app/internal/nacha"
+	"payments_app/internal/usecases"
+	"payments_app/pkg/logger"
+	"strings"
+	"syscall"
+)
+
+const usage = `usage: paymentsctl <command> [flags]
+
+commands:
+  import   create payments from a CSV or JSON Lines file
+  export   write payments as CSV, JSON Lines or Parquet
+  credit-transfer
+           write pending SEPA credit transfers to a pain.001 file and submit them
+  ach      write pending ACH transfers to a NACHA file and submit them
+  ach-returns
+           fail the payments returned in an ACH return file
+  risk-review
+           approve or reject a payment held for risk review
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var code int
+	switch os.Args[1] {
+	case "import":
+		code = runImport(ctx, os.Args[2:])
+	case "export":
+		code = runExport(ctx, os.Args[2:])
+	case "credit-transfer":
+		code = runCreditTransfer(ctx, os.Args[2:])
+	case "ach":
+		code = runACH(ctx, os.Args[2:])
+	case "ach-returns":
+		code = runACHReturns(ctx, os.Args[2:])
+	case "risk-review":
+		code = runRiskReview(ctx, os.Args[2:])
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(os.Stdout, usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		code = 2
+	}
+	os.Exit(code)
+}
+
+// runImport creates the payments of an import file and prints the JSON report to stdout.
+// It exits with 1 when the import fails or any row is not created.
+func runImport(ctx context.Context, args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: paymentsctl import [-format csv|jsonl] [-mode best-effort|all-or-nothing] <file|->")
+		flags.PrintDefaults()
+	}
+	formatName := flags.String("format", "", "file format, csv or jsonl (default: from the file extension)")
+	modeName := flags.String("mode", "best-effort", "best-effort creates every valid row; all-or-nothing creates none unless all are valid")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	path := flags.Arg(0)
+
+	log := logger.NewLoggerTo(os.Stderr)
+
+	format, err := importFormat(*formatName, path)
+	if err != nil {
+		log.Errorf("%v", err)
+		return 2
+	}
+	mode := usecases.BulkMode(strings.ToUpper(strings.ReplaceAll(*modeName, "-", "_")))
+
+	var input io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			log.Errorf("%v", err)
+			return 1
+		}
+		defer file.Close()
+		input = file
+	}
+
+	rows, err := bulk.Parse(input, format)
+	if err != nil {
+		log.Errorf("failed to read %s: %v", path, err)
+		return 1
+	}
+
+	application, err := app.New(configs.LoadConfig(), log)
+	if err != nil {
+		log.Errorf("%v", err)
+		return 1
+	}
+	defer application.Close()
+
+	result, err := application.PaymentUseCase.BulkCreatePayments(ctx, mode, rows)
+	if err != nil {
+		log.Errorf("import failed: %v", err)
+		return 1
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		log.Errorf("failed to write report: %v", err)
+		return 1
+	}
+
+	log.Infof("imported %s: %d created, %d failed, %d skipped", path, result.Created, result.Failed, result.Skipped)
+	if result.Created != result.Total {
+		return 1
+	}
+	return 0
+}
+
+// importFormat returns the named format, or infers it from the file extension
+func importFormat(name, path string) (bulk.Format, error) {
+	if name != "" {
+		return bulk.ParseFormat(name)
+	}
+	if path == "-" {
+		return "", fmt.Errorf("-format is required when reading from stdin")
+	}
+	return bulk.FormatFromFilename(path)
+}
+
+// runExport writes the payments matching the filter flags to -o, or stdout. The flags are
+// named like the parameters of the payments query and the /exports/payments endpoint.
+func runExport(ctx context.Context, args []string) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: paymentsctl export [-format csv|jsonl|parquet] [-o file] [filter flags]")
+		flags.PrintDefaults()
+	}
+	formatName := flags.String("format", "", "file format, csv, jsonl or parquet (default: from the -o extension, else csv)")
+	output := flags.String("o", "-", "output file, - for stdout")
+	filterFlags := url.Values{}
+	for _, name := range []string{"status", "currency", "payerId", "tenantId", "createdFrom", "createdTo"} {
+		flags.Func(name, exportFlagUsage[name], func(value string) error {
+			filterFlags.Add(name, value)
+			return nil
+		})
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return 2
+	}
+
+	log := logger.NewLoggerTo(os.Stderr)
+
+	format := export.FormatCSV
+	switch {
+	case *formatName != "":
+		parsed, err := export.ParseFormat(*formatName)
+		if err != nil {
+			log.Errorf("%v", err)
+			return 2
+		}
+		format = parsed
+	case *output != "-" && filepath.Ext(*output) != "":
+		parsed, err := export.ParseFormat(filepath.Ext(*output)[1:])
+		if err != nil {
+			log.Errorf("%v", err)
+			return 2
+		}
+		format = parsed
+	}
+	filter, err := export.ParseFilter(filterFlags)
+	if err != nil {
+		log.Errorf("%v", err)
+		return 2
+	}
+
+	application, err := app.New(configs.LoadConfig(), log)
+	if err != nil {
+		log.Errorf("%v", err)
+		return 1
+	}
+	defer application.Close()
+
+	var file *os.File
+	count, err := export.Run(ctx, application.PaymentUseCase, filter, format, func() (io.Writer, error) {
+		if *output == "-" {
+			return os.Stdout, nil
+		}
+		created, err := os.Create(*output)
+		if err != nil {
+			return nil, err
+		}
+		file = created
+		return file, nil
+	})
+	if file != nil {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(*output)
+		}
+	}
+	if err != nil {
+		log.Errorf("export failed: %v", err)
+		return 1
+	}
+
+	log.Infof("exported %d payments as %s", count, format)
+	return 0
+}
+
+// runCreditTransfer creates a pain.001 file of the pending credit transfers and writes it to
+// -o, or stdout. With -message-id it writes a file created before instead. It exits with 3
+// when there is nothing to submit, so scripts can tell that apart from a failure.
+func runCreditTransfer(ctx context.Context, args []string) int {
+	flags := flag.NewFlagSet("credit-transfer", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: paymentsctl credit-transfer [-o file] [-message-id id]")
+		flags.PrintDefaults()
+	}
+	output := flags.String("o", "-", "output file, - for stdout")
+	messageID := flags.String("message-id", "", "write the file with this message `id` again instead of creating one")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return 2
+	}
+
+	log := logger.NewLoggerTo(os.Stderr)
+
+	application, err := app.New(configs.LoadConfig(), log)
+	if err != nil {
+		log.Errorf("%v", err)
+		return 1
+	}
+	defer application.Close()
+
+	var file *domain.CreditTransferFile
+	if *messageID != "" {
+		file, err = application.PaymentUseCase.GetCreditTransferFile(ctx, *messageID)
+	} else {
+		file, err = application.PaymentUseCase.CreateCreditTransferFile(ctx)
+	}
+	if errors.Is(err, domain.ErrNoCreditTransfers) {
+		log.Infof("%v", err)
+		return 3
+	}
+	if err != nil {
+		log.Errorf("credit transfer file failed: %v", err)
+		return 1
+	}
+
+	if *output == "-" {
+		_, err = os.Stdout.Write(file.Document)
+	} else {
+		// The payments are submitted already; the file can be written again with -message-id
+		err = os.WriteFile(*output, file.Document, 0o600)
+	}
+	if err != nil {
+		log.Errorf("failed to write credit transfer file %s: %v (write it again with -message-id %s)", file.MessageID, err, file.MessageID)
+		return 1
+	}
+
+	log.Infof("credit transfer file %s: %d payments, control sum %s", file.MessageID, file.NumberOfTransactions, file.ControlSum)
+	return 0
+}
+
+// runACH creates a NACHA file of the pending ACH transfers and writes it to -o, or stdout.
+// With -file-id it writes a file created before instead. It exits with 3 when there is nothing
+// to submit, so scripts can tell that apart from a failure.
+func runACH(ctx context.Context, args []string) int {
+	flags := flag.NewFlagSet("ach", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: paymentsctl ach [-o file] [-file-id id]")
+		flags.PrintDefaults()
+	}
+	output := flags.String("o", "-", "output file, - for stdout")
+	fileID := flags.String("file-id", "", "write the file with this `id` again instead of creating one")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return 2
+	}
+
+	log := logger.NewLoggerTo(os.Stderr)
+
+	application, err := app.New(configs.LoadConfig(), log)
+	if err != nil {
+		log.Errorf("%v", err)
+		return 1
+	}
+	defer application.Close()
+
+	var file *domain.ACHFile
+	if *fileID != "" {
+		file, err = application.PaymentUseCase.GetACHFile(ctx, *fileID)
+	} else {
+		file, err = application.PaymentUseCase.CreateACHFile(ctx)
+	}
+	if errors.Is(err, domain.ErrNoACHTransfers) {
+		log.Infof("%v", err)
+		return 3
+	}
+	if err != nil {
+		log.Errorf("ACH file failed: %v", err)
+		return 1
+	}
+
+	if *output == "-" {
+		_, err = os.Stdout.Write(file.Document)
+	} else {
+		// The payments are submitted already; the file can be written again with -file-id
+		err = os.WriteFile(*output, file.Document, 0o600)
+	}
+	if err != nil {
+		log.Errorf("failed to write ACH file %s: %v (write it again with -file-id %s)", file.ID, err, file.ID)
+		return 1
+	}
+
+	log.Infof("ACH file %s: %d entries in %d batches, total credit %d cents", file.ID, file.EntryCount, file.BatchCount, file.TotalCredit)
+	return 0
+}
+
+// runACHReturns fails the payments returned in an ACH return file and prints the JSON report
+// to stdout. It exits with 1 when the file cannot be read or any return is unmatched or
+// rejected.
+func runACHReturns(ctx context.Context, args []string) int {
+	flags := flag.NewFlagSet("ach-returns", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: paymentsctl ach-returns <file|->")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	path := flags.Arg(0)
+
+	log := logger.NewLoggerTo(os.Stderr)
+
+	var input io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			log.Errorf("%v", err)
+			return 1
+		}
+		defer file.Close()
+		input = file
+	}
+
+	returns, err := nacha.ParseReturns(input)
+	if err != nil {
+		log.Errorf("failed to read %s: %v", path, err)
+		return 1
+	}
+
+	application, err := app.New(configs.LoadConfig(), log)
+	if err != nil {
+		log.Errorf("%v", err)
+		return 1
+	}
+	defer application.Close()
+
+	report, err := application.PaymentUseCase.ApplyACHReturns(ctx, returns)
+	if err != nil {
+		log.Errorf("ACH returns failed: %v", err)
+		return 1
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Errorf("failed to write report: %v", err)
+		return 1
+	}
+
+	log.Infof("processed %s: %d applied, %d duplicate, %d unmatched, %d rejected", path, report.Applied, report.Duplicate, report.Unmatched, report.Rejected)
+	if report.Unmatched > 0 || report.Rejected > 0 {
+		return 1
+	}
+	return 0
+}
+
+// runRiskReview records an analyst's decision on a payment held for risk review and prints
+// the payment as JSON to stdout. An approved payment is processed like a new one.
+func runRiskReview(ctx context.Context, args []string) int {
+	flags := flag.NewFlagSet("risk-review", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: paymentsctl risk-review -analyst name (-approve | -reject) [-note text] <payment-id>")
+		flags.PrintDefaults()
+	}
+	analyst := flags.String("analyst", "", "`name` of the analyst deciding the review")
+	approve := flags.Bool("approve", false, "approve the payment and process it")
+	reject := flags.Bool("reject", false, "reject the payment")
+	note := flags.String("note", "", "reason for the decision")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 || *approve == *reject {
+		flags.Usage()
+		return 2
+	}
+
+	log := logger.NewLoggerTo(os.Stderr)
+
+	application, err := app.New(configs.LoadConfig(), log)
+	if err != nil {
+		log.Errorf("%v", err)
+		return 1
+	}
+	defer application.Close()
+
+	payment, err := application.PaymentUseCase.ResolveRiskReview(ctx, usecases.ResolveRiskReviewInput{
+		PaymentID: flags.Arg(0),
+		Approve:   *approve,
+		Analyst:   *analyst,
+		Note:      *note,
+	})
+	var stored *usecases.StoredPaymentError
+	if err != nil && !errors.As(err, &stored) {
+		log.Errorf("risk review failed: %v", err)
+		return 1
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if encodeErr := encoder.Encode(payment); encodeErr != nil {
+		log.Errorf("failed to write payment: %v", encodeErr)
+		return 1
+	}
+	if err != nil {
+		log.Errorf("payment %s was reviewed, but processing failed: %v", payment.ID, err)
+		return 1
+	}
+	log.Infof("payment %s is %s", payment.ID, payment.Status)
+	return 0
+}
+
+var exportFlagUsage = map[string]string{
+	"status":      "payment `status` to include; repeat or comma-separate for several",
+	"currency":    "only payments in this `currency`",
+	"payerId":     "only payments of this payer `id`",
+	"tenantId":    "only payments of this tenant `id`",
+	"createdFrom": "only payments created at or after this RFC 3339 `time`",
+	"createdTo":   "only payments created before this RFC 3339 `time`",
+}
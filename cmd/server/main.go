@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"payments_app/configs"
+	"payments_app/graph/generated"
+	"payments_app/internal/app"
+	"payments_app/internal/interfaces/export"
+	"payments_app/internal/interfaces/graphql"
+	"payments_app/internal/interfaces/webhook"
+	"payments_app/pkg/logger"
+	"syscall"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/gorilla/handlers"
+	"github.com/gorilla/mux"
+)
+
+func main() {
+	log := logger.NewLogger()
+	cfg := configs.LoadConfig()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Initialize repository and use cases
+	application, err := app.New(cfg, log)
+	if err != nil {
+		log.Errorf("%v", err)
+		os.Exit(1)
+	}
+	defer application.Close()
+	application.StartBackground(ctx)
+	paymentUseCase := application.PaymentUseCase
+
+	// Initialize GraphQL resolver
+	resolver := graphql.NewResolver(paymentUseCase)
+	srv := handler.NewDefaultServer(generated.NewExecutableSchema(generated.Config{Resolvers: resolver}))
+
+	router := mux.NewRouter()
+	router.Handle("/", playground.Handler("Payments GraphQL", "/query"))
+	router.Handle("/query", graphql.Middleware(paymentUseCase, srv))
+	router.HandleFunc("/health", healthHandler).Methods(http.MethodGet)
+	router.Handle("/webhooks/processor/{name}", webhook.NewHandler(paymentUseCase, application.Verifiers, log)).Methods(http.MethodPost)
+	router.Handle("/exports/payments", export.NewHandler(paymentUseCase, log)).Methods(http.MethodGet)
+	creditTransfers := export.NewCreditTransferHandler(paymentUseCase, log)
+	router.Handle("/exports/credit-transfers", creditTransfers).Methods(http.MethodPost)
+	router.Handle("/exports/credit-transfers/{messageId}", creditTransfers).Methods(http.MethodGet)
+	achFiles := export.NewACHHandler(paymentUseCase, log)
+	router.Handle("/exports/ach-files", achFiles).Methods(http.MethodPost)
+	router.Handle("/exports/ach-files/{fileId}", achFiles).Methods(http.MethodGet)
+
+	cors := handlers.CORS(
+		handlers.AllowedOrigins([]string{"*"}),
+		handlers.AllowedMethods([]string{http.MethodGet, http.MethodPost, http.MethodOptions}),
+		handlers.AllowedHeaders([]string{"Content-Type", "Authorization"}),
+	)
+
+	server := &http.Server{
+		Addr:              net.JoinHostPort(cfg.Server.Host, cfg.Server.Port),
+		Handler:           cors(router),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Infof("server listening on http://%s", server.Addr)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Errorf("server error: %v", err)
+		os.Exit(1)
+	}
+}
+
+// healthHandler reports that the service is up
+func healthHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
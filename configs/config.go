@@ -3,12 +3,28 @@ package configs
 import (
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds application configuration
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
+	Server         ServerConfig
+	Database       DatabaseConfig
+	Risk           RiskConfig
+	Screening      ScreeningConfig
+	Vault          VaultConfig
+	Processor      ProcessorConfig
+	Disputes       DisputeConfig
+	Billing        BillingConfig
+	Reconciliation ReconciliationConfig
+	CreditTransfer CreditTransferConfig
+	ACH            ACHConfig
+	FX             FXConfig
+	Fees           FeesConfig
+	Tax            TaxConfig
+	Payouts        PayoutsConfig
+	Metadata       MetadataConfig
 }
 
 // ServerConfig holds server configuration
@@ -22,6 +38,119 @@ type DatabaseConfig struct {
 	Path string
 }
 
+// RiskConfig holds risk screening configuration
+type RiskConfig struct {
+	RulesPath             string
+	ReloadIntervalSeconds int
+}
+
+// ScreeningConfig holds sanctions screening configuration
+type ScreeningConfig struct {
+	ListPath  string
+	Threshold float64
+}
+
+// VaultConfig holds card vault configuration. Keys is a comma-separated list of
+// id:base64 AES-256 key-encryption keys; ActiveKeyID selects the key used for new cards.
+type VaultConfig struct {
+	Keys              string
+	ActiveKeyID       string
+	RotationBatchSize int
+}
+
+// ProcessorConfig holds payment processor configuration. Type is "simulator", "http" or
+// empty to leave payments PENDING until they are updated by hand. RoutingPath points to a
+// routing file defining several processors and replaces Type.
+type ProcessorConfig struct {
+	Type               string
+	RoutingPath        string
+	AutoCapture        bool
+	SimulatorLatencyMs int
+	HTTPURL            string
+	HTTPAPIKey         string
+	HTTPTimeoutSeconds int
+	WebhookSecret      string
+}
+
+// DisputeConfig holds dispute management configuration. Evidence files are stored under
+// EvidenceDir; EvidenceDays is the default time merchants get to submit evidence.
+type DisputeConfig struct {
+	EvidenceDir      string
+	EvidenceDays     int
+	MaxEvidenceBytes int
+}
+
+// BillingConfig holds subscription billing configuration. The scheduler bills due
+// subscriptions every SchedulerIntervalSeconds; DunningBackoff lists the waits between
+// retries of a failed payment.
+type BillingConfig struct {
+	SchedulerIntervalSeconds int
+	DunningBackoff           []time.Duration
+	// ScheduledIntervalSeconds is how often due future-dated payments are executed
+	ScheduledIntervalSeconds int
+}
+
+// ReconciliationConfig holds bank statement matching configuration. Lines whose value date is
+// within DateWindowDays of a payment score for the date; matches scoring at least
+// AutoMatchScore are accepted without review and those from ProposeScore on are proposed.
+type ReconciliationConfig struct {
+	DateWindowDays int
+	AutoMatchScore float64
+	ProposeScore   float64
+}
+
+// CreditTransferConfig holds the account pain.001 credit transfer files pay from. Files can
+// only be created when DebtorIBAN is set; DebtorBIC is optional.
+type CreditTransferConfig struct {
+	DebtorName string
+	DebtorIBAN string
+	DebtorBIC  string
+}
+
+// ACHConfig holds the originator of NACHA files. Files can only be created when BankRouting,
+// the routing number of the originating bank, is set.
+type ACHConfig struct {
+	BankRouting      string
+	BankName         string
+	CompanyName      string
+	CompanyID        string
+	EntryDescription string
+}
+
+// FXConfig holds the exchange rates file and the currency payments settle in. Conversion is
+// enabled when RatesPath is set; the file is reloaded when it changes.
+type FXConfig struct {
+	RatesPath             string
+	SettlementCurrency    string
+	ReloadIntervalSeconds int
+}
+
+// FeesConfig holds the fee schedules file; fees are charged when SchedulesPath is set and the
+// file is reloaded when it changes
+type FeesConfig struct {
+	SchedulesPath         string
+	ReloadIntervalSeconds int
+}
+
+// TaxConfig holds the tax rates file; tax can be calculated when RatesPath is set and the file
+// is reloaded when it changes
+type TaxConfig struct {
+	RatesPath             string
+	ReloadIntervalSeconds int
+}
+
+// PayoutsConfig holds merchant settlement configuration. Each day's batch is created by the
+// first run after midnight UTC; the job checks every SettlementIntervalSeconds.
+type PayoutsConfig struct {
+	SettlementIntervalSeconds int
+}
+
+// MetadataConfig holds the payment metadata keys that get an index, such as orderId; filters
+// on other keys still work but scan the payments
+type MetadataConfig struct {
+	IndexedKeys []string
+}
+
 // LoadConfig loads configuration from environment variables
 func LoadConfig() *Config {
 	return &Config{
@@ -32,6 +161,75 @@ func LoadConfig() *Config {
 		Database: DatabaseConfig{
 			Path: getEnv("DATABASE_PATH", "payments.db"),
 		},
+		Risk: RiskConfig{
+			RulesPath:             getEnv("RISK_RULES_PATH", ""),
+			ReloadIntervalSeconds: getEnvAsInt("RISK_RELOAD_INTERVAL_SECONDS", 10),
+		},
+		Screening: ScreeningConfig{
+			ListPath:  getEnv("SCREENING_LIST_PATH", ""),
+			Threshold: getEnvAsFloat("SCREENING_THRESHOLD", 0.92),
+		},
+		Vault: VaultConfig{
+			Keys:              getEnv("VAULT_KEYS", ""),
+			ActiveKeyID:       getEnv("VAULT_ACTIVE_KEY_ID", ""),
+			RotationBatchSize: getEnvAsInt("VAULT_ROTATION_BATCH_SIZE", 100),
+		},
+		Processor: ProcessorConfig{
+			Type:               getEnv("PROCESSOR", ""),
+			RoutingPath:        getEnv("PROCESSOR_ROUTING_PATH", ""),
+			AutoCapture:        getEnvAsBool("PROCESSOR_AUTO_CAPTURE", false),
+			SimulatorLatencyMs: getEnvAsInt("SIMULATOR_LATENCY_MS", 0),
+			HTTPURL:            getEnv("PROCESSOR_HTTP_URL", ""),
+			HTTPAPIKey:         getEnv("PROCESSOR_HTTP_API_KEY", ""),
+			HTTPTimeoutSeconds: getEnvAsInt("PROCESSOR_HTTP_TIMEOUT_SECONDS", 15),
+			WebhookSecret:      getEnv("PROCESSOR_WEBHOOK_SECRET", ""),
+		},
+		Disputes: DisputeConfig{
+			EvidenceDir:      getEnv("DISPUTE_EVIDENCE_DIR", "evidence"),
+			EvidenceDays:     getEnvAsInt("DISPUTE_EVIDENCE_DAYS", 7),
+			MaxEvidenceBytes: getEnvAsInt("DISPUTE_MAX_EVIDENCE_BYTES", 10<<20),
+		},
+		Billing: BillingConfig{
+			SchedulerIntervalSeconds: getEnvAsInt("SUBSCRIPTION_SCHEDULER_INTERVAL_SECONDS", 60),
+			ScheduledIntervalSeconds: getEnvAsInt("SCHEDULED_PAYMENTS_INTERVAL_SECONDS", 30),
+			DunningBackoff:           getEnvAsDurations("SUBSCRIPTION_DUNNING_BACKOFF", []time.Duration{24 * time.Hour, 72 * time.Hour, 168 * time.Hour}),
+		},
+		Reconciliation: ReconciliationConfig{
+			DateWindowDays: getEnvAsInt("RECONCILIATION_DATE_WINDOW_DAYS", 3),
+			AutoMatchScore: getEnvAsFloat("RECONCILIATION_AUTO_MATCH_SCORE", 0.9),
+			ProposeScore:   getEnvAsFloat("RECONCILIATION_PROPOSE_SCORE", 0.5),
+		},
+		CreditTransfer: CreditTransferConfig{
+			DebtorName: getEnv("CREDIT_TRANSFER_DEBTOR_NAME", ""),
+			DebtorIBAN: getEnv("CREDIT_TRANSFER_DEBTOR_IBAN", ""),
+			DebtorBIC:  getEnv("CREDIT_TRANSFER_DEBTOR_BIC", ""),
+		},
+		ACH: ACHConfig{
+			BankRouting:      getEnv("ACH_BANK_ROUTING_NUMBER", ""),
+			BankName:         getEnv("ACH_BANK_NAME", ""),
+			CompanyName:      getEnv("ACH_COMPANY_NAME", ""),
+			CompanyID:        getEnv("ACH_COMPANY_ID", ""),
+			EntryDescription: getEnv("ACH_ENTRY_DESCRIPTION", "PAYOUT"),
+		},
+		FX: FXConfig{
+			RatesPath:             getEnv("FX_RATES_PATH", ""),
+			SettlementCurrency:    getEnv("FX_SETTLEMENT_CURRENCY", ""),
+			ReloadIntervalSeconds: getEnvAsInt("FX_RELOAD_INTERVAL_SECONDS", 60),
+		},
+		Fees: FeesConfig{
+			SchedulesPath:         getEnv("FEE_SCHEDULES_PATH", ""),
+			ReloadIntervalSeconds: getEnvAsInt("FEE_RELOAD_INTERVAL_SECONDS", 10),
+		},
+		Tax: TaxConfig{
+			RatesPath:             getEnv("TAX_RATES_PATH", ""),
+			ReloadIntervalSeconds: getEnvAsInt("TAX_RELOAD_INTERVAL_SECONDS", 10),
+		},
+		Payouts: PayoutsConfig{
+			SettlementIntervalSeconds: getEnvAsInt("SETTLEMENT_INTERVAL_SECONDS", 3600),
+		},
+		Metadata: MetadataConfig{
+			IndexedKeys: getEnvAsList("PAYMENT_METADATA_INDEXED_KEYS"),
+		},
 	}
 }
 
@@ -52,3 +250,51 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// getEnvAsFloat gets an environment variable as float with a default value
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsBool gets an environment variable as boolean with a default value
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsList gets a comma-separated list such as "orderId,costCenter", skipping empty items
+func getEnvAsList(key string) []string {
+	var items []string
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		if item := strings.TrimSpace(part); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvAsDurations gets a comma-separated list of durations such as "1h,24h" with a default value
+func getEnvAsDurations(key string, defaultValue []time.Duration) []time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var durations []time.Duration
+	for _, part := range strings.Split(value, ",") {
+		duration, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil {
+			return defaultValue
+		}
+		durations = append(durations, duration)
+	}
+	return durations
+}
@@ -4,21 +4,53 @@ import "time"
 
 // Payment represents a payment transaction
 type Payment struct {
-	ID          string        `json:"id"`
-	Amount      float64       `json:"amount"`
-	Currency    string        `json:"currency"`
-	Description string        `json:"description"`
-	Status      PaymentStatus `json:"status"`
-	CreatedAt   time.Time     `json:"createdAt"`
-	UpdatedAt   time.Time     `json:"updatedAt"`
+	ID             string           `json:"id"`
+	Amount         float64          `json:"amount"`
+	Currency       string           `json:"currency"`
+	Description    string           `json:"description"`
+	Status         PaymentStatus    `json:"status"`
+	PayerID        *string          `json:"payerId,omitempty"`
+	TenantID       *string          `json:"tenantId,omitempty"`
+	CustomerID     *string          `json:"customerId,omitempty"`
+	Metadata       map[string]any   `json:"metadata,omitempty"`
+	Tags           []string         `json:"tags"`
+	SubscriptionID *string          `json:"subscriptionId,omitempty"`
+	ExecuteAt      *string          `json:"executeAt,omitempty"`
+	Payer          *Party           `json:"payer,omitempty"`
+	Payee          *Party           `json:"payee,omitempty"`
+	Method         PaymentMethod    `json:"method,omitempty"`
+	Risk           *RiskAssessment  `json:"risk,omitempty"`
+	Screening      *ScreeningResult `json:"screening,omitempty"`
+
+	Processor          *string         `json:"processor,omitempty"`
+	ProcessorReference *string         `json:"processorReference,omitempty"`
+	ProcessorResponse  *string         `json:"processorResponse,omitempty"`
+	RefundedAmount     float64         `json:"refundedAmount"`
+	Route              []*RouteAttempt `json:"route"`
+	SubmissionID       *string         `json:"submissionId,omitempty"`
+	Settlement         *Settlement     `json:"settlement,omitempty"`
+	Fees               []*Fee          `json:"fees"`
+	NetAmount          *Money          `json:"netAmount"`
+	Splits             []*Split        `json:"splits"`
+	Tax                *TaxBreakdown   `json:"tax,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
 }
 
 // PaymentStatus represents the status of a payment
 type PaymentStatus string
 
 const (
-	PaymentStatusPending   PaymentStatus = "PENDING"
-	PaymentStatusCompleted PaymentStatus = "COMPLETED"
-	PaymentStatusFailed    PaymentStatus = "FAILED"
-	PaymentStatusCancelled PaymentStatus = "CANCELLED"
+	PaymentStatusPending       PaymentStatus = "PENDING"
+	PaymentStatusAuthorized    PaymentStatus = "AUTHORIZED"
+	PaymentStatusCompleted     PaymentStatus = "COMPLETED"
+	PaymentStatusFailed        PaymentStatus = "FAILED"
+	PaymentStatusCancelled     PaymentStatus = "CANCELLED"
+	PaymentStatusRejected      PaymentStatus = "REJECTED"
+	PaymentStatusScreeningHold PaymentStatus = "SCREENING_HOLD"
+	PaymentStatusRiskReview    PaymentStatus = "RISK_REVIEW"
+	PaymentStatusScheduled     PaymentStatus = "SCHEDULED"
+	PaymentStatusSubmitted     PaymentStatus = "SUBMITTED"
+	PaymentStatusRefunded      PaymentStatus = "REFUNDED"
 )
@@ -2,22 +2,2254 @@
 
 package model
 
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+type PaymentMethod interface {
+	IsPaymentMethod()
+}
+
+type AchReturn struct {
+	TraceNumber         string          `json:"traceNumber"`
+	OriginalTraceNumber string          `json:"originalTraceNumber"`
+	Code                string          `json:"code"`
+	Reason              string          `json:"reason"`
+	Amount              *Money          `json:"amount"`
+	Information         *string         `json:"information,omitempty"`
+	Status              AchReturnStatus `json:"status"`
+	PaymentID           *string         `json:"paymentId,omitempty"`
+	Error               *string         `json:"error,omitempty"`
+}
+
+type AchReturnReport struct {
+	Total     int          `json:"total"`
+	Applied   int          `json:"applied"`
+	Duplicate int          `json:"duplicate"`
+	Unmatched int          `json:"unmatched"`
+	Rejected  int          `json:"rejected"`
+	Returns   []*AchReturn `json:"returns"`
+}
+
+type AgingBucket struct {
+	Label    string `json:"label"`
+	FromDays int    `json:"fromDays"`
+	ToDays   *int   `json:"toDays,omitempty"`
+	Amount   *Money `json:"amount"`
+	Count    int    `json:"count"`
+}
+
+type AmountStats struct {
+	Currency string `json:"currency"`
+	Count    int    `json:"count"`
+	Sum      *Money `json:"sum"`
+	Average  *Money `json:"average"`
+	Min      *Money `json:"min"`
+	Max      *Money `json:"max"`
+	P50      *Money `json:"p50"`
+	P90      *Money `json:"p90"`
+	P95      *Money `json:"p95"`
+	P99      *Money `json:"p99"`
+}
+
+type BalanceEntry struct {
+	ID        string           `json:"id"`
+	Recipient string           `json:"recipient"`
+	PaymentID string           `json:"paymentId"`
+	Kind      BalanceEntryKind `json:"kind"`
+	Amount    *Money           `json:"amount"`
+	CreatedAt string           `json:"createdAt"`
+}
+
+type BankAccountInput struct {
+	Scheme        BankScheme         `json:"scheme"`
+	Iban          *string            `json:"iban,omitempty"`
+	Bic           *string            `json:"bic,omitempty"`
+	RoutingNumber *string            `json:"routingNumber,omitempty"`
+	AccountNumber *string            `json:"accountNumber,omitempty"`
+	HolderName    *string            `json:"holderName,omitempty"`
+	AccountType   *BankAccountType   `json:"accountType,omitempty"`
+	HolderType    *AccountHolderType `json:"holderType,omitempty"`
+}
+
+type BankAccountPaymentMethod struct {
+	Scheme             BankScheme         `json:"scheme"`
+	Iban               *string            `json:"iban,omitempty"`
+	Bic                *string            `json:"bic,omitempty"`
+	RoutingNumber      *string            `json:"routingNumber,omitempty"`
+	AccountNumberLast4 *string            `json:"accountNumberLast4,omitempty"`
+	HolderName         *string            `json:"holderName,omitempty"`
+	AccountType        *BankAccountType   `json:"accountType,omitempty"`
+	HolderType         *AccountHolderType `json:"holderType,omitempty"`
+}
+
+func (BankAccountPaymentMethod) IsPaymentMethod() {}
+
+type BankStatement struct {
+	ID             string           `json:"id"`
+	Format         StatementFormat  `json:"format"`
+	Reference      string           `json:"reference"`
+	Account        *string          `json:"account,omitempty"`
+	Currency       string           `json:"currency"`
+	OpeningBalance *Money           `json:"openingBalance,omitempty"`
+	ClosingBalance *Money           `json:"closingBalance,omitempty"`
+	Sha256         string           `json:"sha256"`
+	Lines          []*StatementLine `json:"lines"`
+	ImportedAt     string           `json:"importedAt"`
+}
+
+type BulkImportReport struct {
+	Mode    BulkMode         `json:"mode"`
+	Total   int              `json:"total"`
+	Created int              `json:"created"`
+	Failed  int              `json:"failed"`
+	Skipped int              `json:"skipped"`
+	Rows    []*BulkRowResult `json:"rows"`
+}
+
+type BulkRowResult struct {
+	Line          int            `json:"line"`
+	Status        BulkRowStatus  `json:"status"`
+	PaymentID     *string        `json:"paymentId,omitempty"`
+	PaymentStatus *PaymentStatus `json:"paymentStatus,omitempty"`
+	ErrorCode     *string        `json:"errorCode,omitempty"`
+	Error         *string        `json:"error,omitempty"`
+}
+
+type CardInput struct {
+	Token       *string `json:"token,omitempty"`
+	Number      *string `json:"number,omitempty"`
+	ExpiryMonth *int    `json:"expiryMonth,omitempty"`
+	ExpiryYear  *int    `json:"expiryYear,omitempty"`
+	HolderName  *string `json:"holderName,omitempty"`
+}
+
+type CardPaymentMethod struct {
+	Token       *string `json:"token,omitempty"`
+	Brand       string  `json:"brand"`
+	Last4       string  `json:"last4"`
+	ExpiryMonth int     `json:"expiryMonth"`
+	ExpiryYear  int     `json:"expiryYear"`
+	HolderName  *string `json:"holderName,omitempty"`
+}
+
+func (CardPaymentMethod) IsPaymentMethod() {}
+
+type CardToken struct {
+	Token       string  `json:"token"`
+	Brand       string  `json:"brand"`
+	Last4       string  `json:"last4"`
+	ExpiryMonth int     `json:"expiryMonth"`
+	ExpiryYear  int     `json:"expiryYear"`
+	HolderName  *string `json:"holderName,omitempty"`
+	CreatedAt   string  `json:"createdAt"`
+}
+
 type CreatePaymentInput struct {
-	Amount      float64 `json:"amount"`
-	Currency    string  `json:"currency"`
+	Amount             float64             `json:"amount"`
+	Currency           string              `json:"currency"`
+	Description        string              `json:"description"`
+	PayerID            *string             `json:"payerId,omitempty"`
+	TenantID           *string             `json:"tenantId,omitempty"`
+	CustomerID         *string             `json:"customerId,omitempty"`
+	Metadata           map[string]any      `json:"metadata,omitempty"`
+	Tags               []string            `json:"tags,omitempty"`
+	Payer              *PartyInput         `json:"payer,omitempty"`
+	Payee              *PartyInput         `json:"payee,omitempty"`
+	Method             *PaymentMethodInput `json:"method,omitempty"`
+	ExecuteAt          *string             `json:"executeAt,omitempty"`
+	SettlementCurrency *string             `json:"settlementCurrency,omitempty"`
+	Splits             []*SplitInput       `json:"splits,omitempty"`
+	Tax                *TaxInput           `json:"tax,omitempty"`
+}
+
+type CreateSubscriptionInput struct {
+	Amount      float64               `json:"amount"`
+	Currency    string                `json:"currency"`
+	Description string                `json:"description"`
+	PayerID     *string               `json:"payerId,omitempty"`
+	TenantID    *string               `json:"tenantId,omitempty"`
+	Method      *PaymentMethodInput   `json:"method,omitempty"`
+	Schedule    *PaymentScheduleInput `json:"schedule"`
+}
+
+type Customer struct {
+	ID                string             `json:"id"`
+	Name              *string            `json:"name,omitempty"`
+	Email             *string            `json:"email,omitempty"`
+	ExternalReference *string            `json:"externalReference,omitempty"`
+	Metadata          map[string]any     `json:"metadata,omitempty"`
+	Erased            bool               `json:"erased"`
+	ErasedAt          *string            `json:"erasedAt,omitempty"`
+	CreatedAt         string             `json:"createdAt"`
+	UpdatedAt         string             `json:"updatedAt"`
+	Payments          *PaymentConnection `json:"payments"`
+}
+
+type CustomerInput struct {
+	Name              *string        `json:"name,omitempty"`
+	Email             *string        `json:"email,omitempty"`
+	ExternalReference *string        `json:"externalReference,omitempty"`
+	Metadata          map[string]any `json:"metadata,omitempty"`
+}
+
+type Dispute struct {
+	ID             string             `json:"id"`
+	PaymentID      string             `json:"paymentId"`
+	ReasonCode     string             `json:"reasonCode"`
+	Amount         float64            `json:"amount"`
+	Currency       string             `json:"currency"`
+	Status         DisputeStatus      `json:"status"`
+	EvidenceDueAt  string             `json:"evidenceDueAt"`
+	Evidence       []*DisputeEvidence `json:"evidence"`
+	ResolutionNote *string            `json:"resolutionNote,omitempty"`
+	ResolvedAt     *string            `json:"resolvedAt,omitempty"`
+	CreatedAt      string             `json:"createdAt"`
+	UpdatedAt      string             `json:"updatedAt"`
+}
+
+type DisputeEvidence struct {
+	ID          string          `json:"id"`
+	Text        *string         `json:"text,omitempty"`
+	Files       []*EvidenceFile `json:"files"`
+	SubmittedBy string          `json:"submittedBy"`
+	SubmittedAt string          `json:"submittedAt"`
+}
+
+type EvidenceFile struct {
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	Size        int    `json:"size"`
+	Sha256      string `json:"sha256"`
+}
+
+type Fee struct {
+	Name      string   `json:"name"`
+	Event     FeeEvent `json:"event"`
+	Amount    *Money   `json:"amount"`
+	Account   string   `json:"account"`
+	ChargedAt string   `json:"chargedAt"`
+}
+
+type FxRate struct {
+	Base   string  `json:"base"`
+	Quote  string  `json:"quote"`
+	Rate   string  `json:"rate"`
+	AsOf   string  `json:"asOf"`
+	Source *string `json:"source,omitempty"`
+}
+
+type Invoice struct {
+	ID           string               `json:"id"`
+	Number       string               `json:"number"`
+	CustomerID   *string              `json:"customerId,omitempty"`
+	Currency     string               `json:"currency"`
+	Lines        []*InvoiceLine       `json:"lines"`
+	Subtotal     *Money               `json:"subtotal"`
+	Tax          *Money               `json:"tax"`
+	Total        *Money               `json:"total"`
+	AmountPaid   *Money               `json:"amountPaid"`
+	AmountDue    *Money               `json:"amountDue"`
+	TaxBreakdown *TaxBreakdown        `json:"taxBreakdown,omitempty"`
+	Status       InvoiceStatus        `json:"status"`
+	DueDate      string               `json:"dueDate"`
+	DaysPastDue  int                  `json:"daysPastDue"`
+	Allocations  []*InvoiceAllocation `json:"allocations"`
+	CreatedAt    string               `json:"createdAt"`
+	UpdatedAt    string               `json:"updatedAt"`
+}
+
+type InvoiceAgingReport struct {
+	Currency string         `json:"currency"`
+	AsOf     string         `json:"asOf"`
+	Buckets  []*AgingBucket `json:"buckets"`
+	Total    *Money         `json:"total"`
+	Count    int            `json:"count"`
+}
+
+type InvoiceAllocation struct {
+	ID        string `json:"id"`
+	InvoiceID string `json:"invoiceId"`
+	PaymentID string `json:"paymentId"`
+	Amount    *Money `json:"amount"`
+	CreatedAt string `json:"createdAt"`
+}
+
+type InvoiceAllocationInput struct {
+	InvoiceID string `json:"invoiceId"`
+	Amount    string `json:"amount"`
+}
+
+type InvoiceInput struct {
+	Number     string              `json:"number"`
+	CustomerID *string             `json:"customerId,omitempty"`
+	Currency   string              `json:"currency"`
+	DueDate    string              `json:"dueDate"`
+	Lines      []*InvoiceLineInput `json:"lines"`
+	Tax        *TaxInput           `json:"tax,omitempty"`
+}
+
+type InvoiceLine struct {
 	Description string  `json:"description"`
+	Category    *string `json:"category,omitempty"`
+	Quantity    string  `json:"quantity"`
+	UnitPrice   *Money  `json:"unitPrice"`
+	Amount      *Money  `json:"amount"`
+	Tax         *Money  `json:"tax"`
+}
+
+type InvoiceLineInput struct {
+	Description string  `json:"description"`
+	Category    *string `json:"category,omitempty"`
+	Quantity    *string `json:"quantity,omitempty"`
+	UnitPrice   string  `json:"unitPrice"`
+	Tax         *string `json:"tax,omitempty"`
+}
+
+type JournalEntry struct {
+	ID          string     `json:"id"`
+	PaymentID   *string    `json:"paymentId,omitempty"`
+	Description string     `json:"description"`
+	Postings    []*Posting `json:"postings"`
+	CreatedAt   string     `json:"createdAt"`
+}
+
+type Money struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
 }
 
 type Mutation struct {
 }
 
+type OpenDisputeInput struct {
+	PaymentID     string   `json:"paymentId"`
+	ReasonCode    string   `json:"reasonCode"`
+	Amount        *float64 `json:"amount,omitempty"`
+	EvidenceDueAt *string  `json:"evidenceDueAt,omitempty"`
+}
+
+type PageInfo struct {
+	EndCursor   *string `json:"endCursor,omitempty"`
+	HasNextPage bool    `json:"hasNextPage"`
+}
+
+type Party struct {
+	Name    string  `json:"name"`
+	Account *string `json:"account,omitempty"`
+	Country *string `json:"country,omitempty"`
+}
+
+type PartyInput struct {
+	Name    string  `json:"name"`
+	Account *string `json:"account,omitempty"`
+	Country *string `json:"country,omitempty"`
+}
+
+type PaymentConnection struct {
+	Nodes      []*Payment `json:"nodes"`
+	PageInfo   *PageInfo  `json:"pageInfo"`
+	TotalCount int        `json:"totalCount"`
+}
+
+type PaymentFilter struct {
+	Statuses    []PaymentStatus `json:"statuses,omitempty"`
+	Currency    *string         `json:"currency,omitempty"`
+	PayerID     *string         `json:"payerId,omitempty"`
+	TenantID    *string         `json:"tenantId,omitempty"`
+	CustomerID  *string         `json:"customerId,omitempty"`
+	Metadata    map[string]any  `json:"metadata,omitempty"`
+	Tags        []string        `json:"tags,omitempty"`
+	CreatedFrom *string         `json:"createdFrom,omitempty"`
+	CreatedTo   *string         `json:"createdTo,omitempty"`
+}
+
+type PaymentMethodInput struct {
+	Type        PaymentMethodType `json:"type"`
+	Card        *CardInput        `json:"card,omitempty"`
+	BankAccount *BankAccountInput `json:"bankAccount,omitempty"`
+	Wallet      *WalletInput      `json:"wallet,omitempty"`
+}
+
+type PaymentSchedule struct {
+	Frequency Frequency `json:"frequency"`
+	Interval  int       `json:"interval"`
+	AnchorDay *int      `json:"anchorDay,omitempty"`
+	StartAt   string    `json:"startAt"`
+}
+
+type PaymentScheduleInput struct {
+	Frequency Frequency `json:"frequency"`
+	Interval  *int      `json:"interval,omitempty"`
+	AnchorDay *int      `json:"anchorDay,omitempty"`
+	StartAt   *string   `json:"startAt,omitempty"`
+}
+
+type PaymentSearchConnection struct {
+	Nodes      []*PaymentSearchResult `json:"nodes"`
+	PageInfo   *PageInfo              `json:"pageInfo"`
+	TotalCount int                    `json:"totalCount"`
+}
+
+type PaymentSearchResult struct {
+	Payment *Payment `json:"payment"`
+	Rank    float64  `json:"rank"`
+	Snippet string   `json:"snippet"`
+}
+
+type PaymentStatsGroup struct {
+	Currency *string        `json:"currency,omitempty"`
+	Status   *PaymentStatus `json:"status,omitempty"`
+	Day      *string        `json:"day,omitempty"`
+	Month    *string        `json:"month,omitempty"`
+	Count    int            `json:"count"`
+	Amounts  []*AmountStats `json:"amounts"`
+}
+
+type Payout struct {
+	ID            string        `json:"id"`
+	BatchID       string        `json:"batchId"`
+	MerchantID    string        `json:"merchantId"`
+	Currency      string        `json:"currency"`
+	Gross         *Money        `json:"gross"`
+	Refunds       *Money        `json:"refunds"`
+	Chargebacks   *Money        `json:"chargebacks"`
+	Fees          *Money        `json:"fees"`
+	Net           *Money        `json:"net"`
+	Status        PayoutStatus  `json:"status"`
+	Reference     *string       `json:"reference,omitempty"`
+	FailureReason *string       `json:"failureReason,omitempty"`
+	Items         []*PayoutItem `json:"items"`
+	CreatedAt     string        `json:"createdAt"`
+	UpdatedAt     string        `json:"updatedAt"`
+	SentAt        *string       `json:"sentAt,omitempty"`
+	PaidAt        *string       `json:"paidAt,omitempty"`
+}
+
+type PayoutItem struct {
+	PaymentID   string `json:"paymentId"`
+	Gross       *Money `json:"gross"`
+	Refunds     *Money `json:"refunds"`
+	Chargebacks *Money `json:"chargebacks"`
+	Fees        *Money `json:"fees"`
+	Net         *Money `json:"net"`
+}
+
+type Posting struct {
+	Account  string  `json:"account"`
+	Currency string  `json:"currency"`
+	Amount   float64 `json:"amount"`
+}
+
+type ProcessorCallback struct {
+	ID          string          `json:"id"`
+	Processor   string          `json:"processor"`
+	CallbackID  string          `json:"callbackId"`
+	EventType   string          `json:"eventType"`
+	Reference   string          `json:"reference"`
+	Status      *PaymentStatus  `json:"status,omitempty"`
+	PaymentID   *string         `json:"paymentId,omitempty"`
+	Result      *CallbackResult `json:"result,omitempty"`
+	Error       *string         `json:"error,omitempty"`
+	Payload     string          `json:"payload"`
+	ReceivedAt  string          `json:"receivedAt"`
+	ProcessedAt *string         `json:"processedAt,omitempty"`
+}
+
+type ProcessorStats struct {
+	Processor        string       `json:"processor"`
+	Calls            int          `json:"calls"`
+	Approvals        int          `json:"approvals"`
+	Declines         int          `json:"declines"`
+	Errors           int          `json:"errors"`
+	SuccessRate      float64      `json:"successRate"`
+	AverageLatencyMs int          `json:"averageLatencyMs"`
+	Circuit          CircuitState `json:"circuit"`
+}
+
 type Query struct {
 }
 
+type RecipientBalance struct {
+	Recipient string          `json:"recipient"`
+	Balances  []*Money        `json:"balances"`
+	Entries   []*BalanceEntry `json:"entries"`
+}
+
+type ReconciliationRun struct {
+	Matched   int `json:"matched"`
+	Proposed  int `json:"proposed"`
+	Unmatched int `json:"unmatched"`
+}
+
+type ResolveDisputeInput struct {
+	DisputeID string         `json:"disputeId"`
+	Outcome   DisputeOutcome `json:"outcome"`
+	Note      *string        `json:"note,omitempty"`
+}
+
+type ResolveScreeningHoldInput struct {
+	PaymentID string            `json:"paymentId"`
+	Decision  ScreeningDecision `json:"decision"`
+	Analyst   string            `json:"analyst"`
+	Note      *string           `json:"note,omitempty"`
+}
+
+type RiskAssessment struct {
+	Score      int          `json:"score"`
+	Decision   RiskDecision `json:"decision"`
+	Reasons    []string     `json:"reasons"`
+	ReviewedBy *string      `json:"reviewedBy,omitempty"`
+	ReviewNote *string      `json:"reviewNote,omitempty"`
+	ReviewedAt *string      `json:"reviewedAt,omitempty"`
+}
+
+type RouteAttempt struct {
+	Processor string  `json:"processor"`
+	Rule      *string `json:"rule,omitempty"`
+	Outcome   *string `json:"outcome,omitempty"`
+	Error     *string `json:"error,omitempty"`
+	LatencyMs int     `json:"latencyMs"`
+}
+
+type ScreeningHit struct {
+	Role        PartyRole `json:"role"`
+	Name        string    `json:"name"`
+	MatchedName string    `json:"matchedName"`
+	EntryID     string    `json:"entryId"`
+	List        string    `json:"list"`
+	Score       float64   `json:"score"`
+}
+
+type ScreeningResult struct {
+	Status     ScreeningStatus `json:"status"`
+	Hits       []*ScreeningHit `json:"hits"`
+	ReviewedBy *string         `json:"reviewedBy,omitempty"`
+	ReviewNote *string         `json:"reviewNote,omitempty"`
+	ReviewedAt *string         `json:"reviewedAt,omitempty"`
+}
+
+type Settlement struct {
+	Amount      *Money  `json:"amount"`
+	Rate        *FxRate `json:"rate"`
+	ConvertedAt string  `json:"convertedAt"`
+}
+
+type SettlementBatch struct {
+	ID        string    `json:"id"`
+	CutoffAt  string    `json:"cutoffAt"`
+	CreatedAt string    `json:"createdAt"`
+	Payouts   []*Payout `json:"payouts"`
+}
+
+type Split struct {
+	Recipient string  `json:"recipient"`
+	Amount    *Money  `json:"amount"`
+	Percent   *string `json:"percent,omitempty"`
+	Reversed  *Money  `json:"reversed"`
+}
+
+type SplitInput struct {
+	Recipient string  `json:"recipient"`
+	Amount    *string `json:"amount,omitempty"`
+	Percent   *string `json:"percent,omitempty"`
+}
+
+type StatementImportReport struct {
+	Statements []*BankStatement   `json:"statements"`
+	Run        *ReconciliationRun `json:"run"`
+}
+
+type StatementLine struct {
+	ID                  string              `json:"id"`
+	StatementID         string              `json:"statementId"`
+	Index               int                 `json:"index"`
+	BookingDate         string              `json:"bookingDate"`
+	ValueDate           string              `json:"valueDate"`
+	Amount              *Money              `json:"amount"`
+	Direction           EntryDirection      `json:"direction"`
+	Reference           *string             `json:"reference,omitempty"`
+	BankReference       *string             `json:"bankReference,omitempty"`
+	Counterparty        *string             `json:"counterparty,omitempty"`
+	CounterpartyAccount *string             `json:"counterpartyAccount,omitempty"`
+	Description         *string             `json:"description,omitempty"`
+	Status              StatementLineStatus `json:"status"`
+	PaymentID           *string             `json:"paymentId,omitempty"`
+	Confidence          float64             `json:"confidence"`
+	MatchMethod         *MatchMethod        `json:"matchMethod,omitempty"`
+	MatchedBy           *string             `json:"matchedBy,omitempty"`
+	MatchedAt           *string             `json:"matchedAt,omitempty"`
+}
+
+type SubmitDisputeEvidenceInput struct {
+	DisputeID   string            `json:"disputeId"`
+	Text        *string           `json:"text,omitempty"`
+	Files       []*graphql.Upload `json:"files,omitempty"`
+	SubmittedBy string            `json:"submittedBy"`
+}
+
+type Subscription struct {
+	ID            string             `json:"id"`
+	Amount        float64            `json:"amount"`
+	Currency      string             `json:"currency"`
+	Description   string             `json:"description"`
+	PayerID       *string            `json:"payerId,omitempty"`
+	TenantID      *string            `json:"tenantId,omitempty"`
+	Method        PaymentMethod      `json:"method,omitempty"`
+	Schedule      *PaymentSchedule   `json:"schedule"`
+	Status        SubscriptionStatus `json:"status"`
+	Cycle         int                `json:"cycle"`
+	NextRunAt     string             `json:"nextRunAt"`
+	RetryAttempt  int                `json:"retryAttempt"`
+	LastPaymentID *string            `json:"lastPaymentId,omitempty"`
+	LastError     *string            `json:"lastError,omitempty"`
+	CancelledAt   *string            `json:"cancelledAt,omitempty"`
+	CreatedAt     string             `json:"createdAt"`
+	UpdatedAt     string             `json:"updatedAt"`
+}
+
+type TaxBreakdown struct {
+	Country       string     `json:"country"`
+	Pricing       TaxPricing `json:"pricing"`
+	ReverseCharge bool       `json:"reverseCharge"`
+	VatNumber     *string    `json:"vatNumber,omitempty"`
+	Lines         []*TaxLine `json:"lines"`
+	Net           *Money     `json:"net"`
+	Tax           *Money     `json:"tax"`
+	Gross         *Money     `json:"gross"`
+}
+
+type TaxInput struct {
+	Country   *string     `json:"country,omitempty"`
+	Category  *string     `json:"category,omitempty"`
+	Pricing   *TaxPricing `json:"pricing,omitempty"`
+	VatNumber *string     `json:"vatNumber,omitempty"`
+}
+
+type TaxLine struct {
+	Category string `json:"category"`
+	Rate     string `json:"rate"`
+	Net      *Money `json:"net"`
+	Tax      *Money `json:"tax"`
+	Gross    *Money `json:"gross"`
+}
+
+type TokenizeCardInput struct {
+	Number      string  `json:"number"`
+	ExpiryMonth int     `json:"expiryMonth"`
+	ExpiryYear  int     `json:"expiryYear"`
+	HolderName  *string `json:"holderName,omitempty"`
+}
+
 type UpdatePaymentInput struct {
 	ID          string         `json:"id"`
 	Amount      *float64       `json:"amount,omitempty"`
 	Currency    *string        `json:"currency,omitempty"`
 	Description *string        `json:"description,omitempty"`
 	Status      *PaymentStatus `json:"status,omitempty"`
+	Metadata    map[string]any `json:"metadata,omitempty"`
+	Tags        []string       `json:"tags,omitempty"`
+}
+
+type WalletInput struct {
+	Provider string `json:"provider"`
+	Token    string `json:"token"`
+}
+
+type WalletPaymentMethod struct {
+	Provider   string `json:"provider"`
+	TokenLast4 string `json:"tokenLast4"`
+}
+
+func (WalletPaymentMethod) IsPaymentMethod() {}
+
+type AccountHolderType string
+
+const (
+	AccountHolderTypeIndividual AccountHolderType = "INDIVIDUAL"
+	AccountHolderTypeCompany    AccountHolderType = "COMPANY"
+)
+
+var AllAccountHolderType = []AccountHolderType{
+	AccountHolderTypeIndividual,
+	AccountHolderTypeCompany,
+}
+
+func (e AccountHolderType) IsValid() bool {
+	switch e {
+	case AccountHolderTypeIndividual, AccountHolderTypeCompany:
+		return true
+	}
+	return false
+}
+
+func (e AccountHolderType) String() string {
+	return string(e)
+}
+
+func (e *AccountHolderType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AccountHolderType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AccountHolderType", str)
+	}
+	return nil
+}
+
+func (e AccountHolderType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *AccountHolderType) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e AccountHolderType) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type AchReturnStatus string
+
+const (
+	AchReturnStatusApplied   AchReturnStatus = "APPLIED"
+	AchReturnStatusDuplicate AchReturnStatus = "DUPLICATE"
+	AchReturnStatusUnmatched AchReturnStatus = "UNMATCHED"
+	AchReturnStatusRejected  AchReturnStatus = "REJECTED"
+)
+
+var AllAchReturnStatus = []AchReturnStatus{
+	AchReturnStatusApplied,
+	AchReturnStatusDuplicate,
+	AchReturnStatusUnmatched,
+	AchReturnStatusRejected,
+}
+
+func (e AchReturnStatus) IsValid() bool {
+	switch e {
+	case AchReturnStatusApplied, AchReturnStatusDuplicate, AchReturnStatusUnmatched, AchReturnStatusRejected:
+		return true
+	}
+	return false
+}
+
+func (e AchReturnStatus) String() string {
+	return string(e)
+}
+
+func (e *AchReturnStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AchReturnStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AchReturnStatus", str)
+	}
+	return nil
+}
+
+func (e AchReturnStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *AchReturnStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e AchReturnStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type BalanceEntryKind string
+
+const (
+	BalanceEntryKindCredit   BalanceEntryKind = "CREDIT"
+	BalanceEntryKindReversal BalanceEntryKind = "REVERSAL"
+)
+
+var AllBalanceEntryKind = []BalanceEntryKind{
+	BalanceEntryKindCredit,
+	BalanceEntryKindReversal,
+}
+
+func (e BalanceEntryKind) IsValid() bool {
+	switch e {
+	case BalanceEntryKindCredit, BalanceEntryKindReversal:
+		return true
+	}
+	return false
+}
+
+func (e BalanceEntryKind) String() string {
+	return string(e)
+}
+
+func (e *BalanceEntryKind) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = BalanceEntryKind(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid BalanceEntryKind", str)
+	}
+	return nil
+}
+
+func (e BalanceEntryKind) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *BalanceEntryKind) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e BalanceEntryKind) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type BankAccountType string
+
+const (
+	BankAccountTypeChecking BankAccountType = "CHECKING"
+	BankAccountTypeSavings  BankAccountType = "SAVINGS"
+)
+
+var AllBankAccountType = []BankAccountType{
+	BankAccountTypeChecking,
+	BankAccountTypeSavings,
+}
+
+func (e BankAccountType) IsValid() bool {
+	switch e {
+	case BankAccountTypeChecking, BankAccountTypeSavings:
+		return true
+	}
+	return false
+}
+
+func (e BankAccountType) String() string {
+	return string(e)
+}
+
+func (e *BankAccountType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = BankAccountType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid BankAccountType", str)
+	}
+	return nil
+}
+
+func (e BankAccountType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *BankAccountType) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e BankAccountType) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type BankScheme string
+
+const (
+	BankSchemeSepa BankScheme = "SEPA"
+	BankSchemeAch  BankScheme = "ACH"
+)
+
+var AllBankScheme = []BankScheme{
+	BankSchemeSepa,
+	BankSchemeAch,
+}
+
+func (e BankScheme) IsValid() bool {
+	switch e {
+	case BankSchemeSepa, BankSchemeAch:
+		return true
+	}
+	return false
+}
+
+func (e BankScheme) String() string {
+	return string(e)
+}
+
+func (e *BankScheme) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = BankScheme(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid BankScheme", str)
+	}
+	return nil
+}
+
+func (e BankScheme) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *BankScheme) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e BankScheme) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type BulkFormat string
+
+const (
+	BulkFormatCSV   BulkFormat = "CSV"
+	BulkFormatJSONL BulkFormat = "JSONL"
+)
+
+var AllBulkFormat = []BulkFormat{
+	BulkFormatCSV,
+	BulkFormatJSONL,
+}
+
+func (e BulkFormat) IsValid() bool {
+	switch e {
+	case BulkFormatCSV, BulkFormatJSONL:
+		return true
+	}
+	return false
+}
+
+func (e BulkFormat) String() string {
+	return string(e)
+}
+
+func (e *BulkFormat) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = BulkFormat(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid BulkFormat", str)
+	}
+	return nil
+}
+
+func (e BulkFormat) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *BulkFormat) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e BulkFormat) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type BulkMode string
+
+const (
+	BulkModeAllOrNothing BulkMode = "ALL_OR_NOTHING"
+	BulkModeBestEffort   BulkMode = "BEST_EFFORT"
+)
+
+var AllBulkMode = []BulkMode{
+	BulkModeAllOrNothing,
+	BulkModeBestEffort,
+}
+
+func (e BulkMode) IsValid() bool {
+	switch e {
+	case BulkModeAllOrNothing, BulkModeBestEffort:
+		return true
+	}
+	return false
+}
+
+func (e BulkMode) String() string {
+	return string(e)
+}
+
+func (e *BulkMode) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = BulkMode(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid BulkMode", str)
+	}
+	return nil
+}
+
+func (e BulkMode) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *BulkMode) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e BulkMode) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type BulkRowStatus string
+
+const (
+	BulkRowStatusCreated BulkRowStatus = "CREATED"
+	BulkRowStatusFailed  BulkRowStatus = "FAILED"
+	BulkRowStatusSkipped BulkRowStatus = "SKIPPED"
+)
+
+var AllBulkRowStatus = []BulkRowStatus{
+	BulkRowStatusCreated,
+	BulkRowStatusFailed,
+	BulkRowStatusSkipped,
+}
+
+func (e BulkRowStatus) IsValid() bool {
+	switch e {
+	case BulkRowStatusCreated, BulkRowStatusFailed, BulkRowStatusSkipped:
+		return true
+	}
+	return false
+}
+
+func (e BulkRowStatus) String() string {
+	return string(e)
+}
+
+func (e *BulkRowStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = BulkRowStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid BulkRowStatus", str)
+	}
+	return nil
+}
+
+func (e BulkRowStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *BulkRowStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e BulkRowStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type CallbackResult string
+
+const (
+	CallbackResultApplied CallbackResult = "APPLIED"
+	CallbackResultIgnored CallbackResult = "IGNORED"
+	CallbackResultFailed  CallbackResult = "FAILED"
+)
+
+var AllCallbackResult = []CallbackResult{
+	CallbackResultApplied,
+	CallbackResultIgnored,
+	CallbackResultFailed,
+}
+
+func (e CallbackResult) IsValid() bool {
+	switch e {
+	case CallbackResultApplied, CallbackResultIgnored, CallbackResultFailed:
+		return true
+	}
+	return false
+}
+
+func (e CallbackResult) String() string {
+	return string(e)
+}
+
+func (e *CallbackResult) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = CallbackResult(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid CallbackResult", str)
+	}
+	return nil
+}
+
+func (e CallbackResult) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *CallbackResult) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e CallbackResult) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type CircuitState string
+
+const (
+	CircuitStateClosed   CircuitState = "CLOSED"
+	CircuitStateOpen     CircuitState = "OPEN"
+	CircuitStateHalfOpen CircuitState = "HALF_OPEN"
+)
+
+var AllCircuitState = []CircuitState{
+	CircuitStateClosed,
+	CircuitStateOpen,
+	CircuitStateHalfOpen,
+}
+
+func (e CircuitState) IsValid() bool {
+	switch e {
+	case CircuitStateClosed, CircuitStateOpen, CircuitStateHalfOpen:
+		return true
+	}
+	return false
+}
+
+func (e CircuitState) String() string {
+	return string(e)
+}
+
+func (e *CircuitState) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = CircuitState(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid CircuitState", str)
+	}
+	return nil
+}
+
+func (e CircuitState) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *CircuitState) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e CircuitState) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type DisputeOutcome string
+
+const (
+	DisputeOutcomeWon  DisputeOutcome = "WON"
+	DisputeOutcomeLost DisputeOutcome = "LOST"
+)
+
+var AllDisputeOutcome = []DisputeOutcome{
+	DisputeOutcomeWon,
+	DisputeOutcomeLost,
+}
+
+func (e DisputeOutcome) IsValid() bool {
+	switch e {
+	case DisputeOutcomeWon, DisputeOutcomeLost:
+		return true
+	}
+	return false
+}
+
+func (e DisputeOutcome) String() string {
+	return string(e)
+}
+
+func (e *DisputeOutcome) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = DisputeOutcome(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid DisputeOutcome", str)
+	}
+	return nil
+}
+
+func (e DisputeOutcome) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *DisputeOutcome) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e DisputeOutcome) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type DisputeStatus string
+
+const (
+	DisputeStatusOpen        DisputeStatus = "OPEN"
+	DisputeStatusUnderReview DisputeStatus = "UNDER_REVIEW"
+	DisputeStatusWon         DisputeStatus = "WON"
+	DisputeStatusLost        DisputeStatus = "LOST"
+)
+
+var AllDisputeStatus = []DisputeStatus{
+	DisputeStatusOpen,
+	DisputeStatusUnderReview,
+	DisputeStatusWon,
+	DisputeStatusLost,
+}
+
+func (e DisputeStatus) IsValid() bool {
+	switch e {
+	case DisputeStatusOpen, DisputeStatusUnderReview, DisputeStatusWon, DisputeStatusLost:
+		return true
+	}
+	return false
+}
+
+func (e DisputeStatus) String() string {
+	return string(e)
+}
+
+func (e *DisputeStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = DisputeStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid DisputeStatus", str)
+	}
+	return nil
+}
+
+func (e DisputeStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *DisputeStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e DisputeStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type EntryDirection string
+
+const (
+	EntryDirectionCredit EntryDirection = "CREDIT"
+	EntryDirectionDebit  EntryDirection = "DEBIT"
+)
+
+var AllEntryDirection = []EntryDirection{
+	EntryDirectionCredit,
+	EntryDirectionDebit,
+}
+
+func (e EntryDirection) IsValid() bool {
+	switch e {
+	case EntryDirectionCredit, EntryDirectionDebit:
+		return true
+	}
+	return false
+}
+
+func (e EntryDirection) String() string {
+	return string(e)
+}
+
+func (e *EntryDirection) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = EntryDirection(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid EntryDirection", str)
+	}
+	return nil
+}
+
+func (e EntryDirection) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *EntryDirection) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e EntryDirection) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type FeeEvent string
+
+const (
+	FeeEventCreated  FeeEvent = "CREATED"
+	FeeEventCaptured FeeEvent = "CAPTURED"
+)
+
+var AllFeeEvent = []FeeEvent{
+	FeeEventCreated,
+	FeeEventCaptured,
+}
+
+func (e FeeEvent) IsValid() bool {
+	switch e {
+	case FeeEventCreated, FeeEventCaptured:
+		return true
+	}
+	return false
+}
+
+func (e FeeEvent) String() string {
+	return string(e)
+}
+
+func (e *FeeEvent) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = FeeEvent(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid FeeEvent", str)
+	}
+	return nil
+}
+
+func (e FeeEvent) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *FeeEvent) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e FeeEvent) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type Frequency string
+
+const (
+	FrequencyDaily   Frequency = "DAILY"
+	FrequencyWeekly  Frequency = "WEEKLY"
+	FrequencyMonthly Frequency = "MONTHLY"
+)
+
+var AllFrequency = []Frequency{
+	FrequencyDaily,
+	FrequencyWeekly,
+	FrequencyMonthly,
+}
+
+func (e Frequency) IsValid() bool {
+	switch e {
+	case FrequencyDaily, FrequencyWeekly, FrequencyMonthly:
+		return true
+	}
+	return false
+}
+
+func (e Frequency) String() string {
+	return string(e)
+}
+
+func (e *Frequency) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Frequency(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Frequency", str)
+	}
+	return nil
+}
+
+func (e Frequency) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *Frequency) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e Frequency) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type InvoiceStatus string
+
+const (
+	InvoiceStatusOpen          InvoiceStatus = "OPEN"
+	InvoiceStatusPartiallyPaid InvoiceStatus = "PARTIALLY_PAID"
+	InvoiceStatusPaid          InvoiceStatus = "PAID"
+	InvoiceStatusOverdue       InvoiceStatus = "OVERDUE"
+)
+
+var AllInvoiceStatus = []InvoiceStatus{
+	InvoiceStatusOpen,
+	InvoiceStatusPartiallyPaid,
+	InvoiceStatusPaid,
+	InvoiceStatusOverdue,
+}
+
+func (e InvoiceStatus) IsValid() bool {
+	switch e {
+	case InvoiceStatusOpen, InvoiceStatusPartiallyPaid, InvoiceStatusPaid, InvoiceStatusOverdue:
+		return true
+	}
+	return false
+}
+
+func (e InvoiceStatus) String() string {
+	return string(e)
+}
+
+func (e *InvoiceStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = InvoiceStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid InvoiceStatus", str)
+	}
+	return nil
+}
+
+func (e InvoiceStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *InvoiceStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e InvoiceStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type MatchMethod string
+
+const (
+	MatchMethodAuto      MatchMethod = "AUTO"
+	MatchMethodConfirmed MatchMethod = "CONFIRMED"
+	MatchMethodManual    MatchMethod = "MANUAL"
+)
+
+var AllMatchMethod = []MatchMethod{
+	MatchMethodAuto,
+	MatchMethodConfirmed,
+	MatchMethodManual,
+}
+
+func (e MatchMethod) IsValid() bool {
+	switch e {
+	case MatchMethodAuto, MatchMethodConfirmed, MatchMethodManual:
+		return true
+	}
+	return false
+}
+
+func (e MatchMethod) String() string {
+	return string(e)
+}
+
+func (e *MatchMethod) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = MatchMethod(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid MatchMethod", str)
+	}
+	return nil
+}
+
+func (e MatchMethod) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *MatchMethod) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e MatchMethod) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type PartyRole string
+
+const (
+	PartyRolePayer PartyRole = "PAYER"
+	PartyRolePayee PartyRole = "PAYEE"
+)
+
+var AllPartyRole = []PartyRole{
+	PartyRolePayer,
+	PartyRolePayee,
+}
+
+func (e PartyRole) IsValid() bool {
+	switch e {
+	case PartyRolePayer, PartyRolePayee:
+		return true
+	}
+	return false
+}
+
+func (e PartyRole) String() string {
+	return string(e)
+}
+
+func (e *PartyRole) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = PartyRole(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid PartyRole", str)
+	}
+	return nil
+}
+
+func (e PartyRole) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *PartyRole) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e PartyRole) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type PaymentMethodType string
+
+const (
+	PaymentMethodTypeCard        PaymentMethodType = "CARD"
+	PaymentMethodTypeBankAccount PaymentMethodType = "BANK_ACCOUNT"
+	PaymentMethodTypeWallet      PaymentMethodType = "WALLET"
+)
+
+var AllPaymentMethodType = []PaymentMethodType{
+	PaymentMethodTypeCard,
+	PaymentMethodTypeBankAccount,
+	PaymentMethodTypeWallet,
+}
+
+func (e PaymentMethodType) IsValid() bool {
+	switch e {
+	case PaymentMethodTypeCard, PaymentMethodTypeBankAccount, PaymentMethodTypeWallet:
+		return true
+	}
+	return false
+}
+
+func (e PaymentMethodType) String() string {
+	return string(e)
+}
+
+func (e *PaymentMethodType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = PaymentMethodType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid PaymentMethodType", str)
+	}
+	return nil
+}
+
+func (e PaymentMethodType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *PaymentMethodType) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e PaymentMethodType) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type PaymentStatsGroupBy string
+
+const (
+	PaymentStatsGroupByCurrency PaymentStatsGroupBy = "CURRENCY"
+	PaymentStatsGroupByStatus   PaymentStatsGroupBy = "STATUS"
+	PaymentStatsGroupByDay      PaymentStatsGroupBy = "DAY"
+	PaymentStatsGroupByMonth    PaymentStatsGroupBy = "MONTH"
+)
+
+var AllPaymentStatsGroupBy = []PaymentStatsGroupBy{
+	PaymentStatsGroupByCurrency,
+	PaymentStatsGroupByStatus,
+	PaymentStatsGroupByDay,
+	PaymentStatsGroupByMonth,
+}
+
+func (e PaymentStatsGroupBy) IsValid() bool {
+	switch e {
+	case PaymentStatsGroupByCurrency, PaymentStatsGroupByStatus, PaymentStatsGroupByDay, PaymentStatsGroupByMonth:
+		return true
+	}
+	return false
+}
+
+func (e PaymentStatsGroupBy) String() string {
+	return string(e)
+}
+
+func (e *PaymentStatsGroupBy) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = PaymentStatsGroupBy(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid PaymentStatsGroupBy", str)
+	}
+	return nil
+}
+
+func (e PaymentStatsGroupBy) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *PaymentStatsGroupBy) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e PaymentStatsGroupBy) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type PayoutStatus string
+
+const (
+	PayoutStatusCreated PayoutStatus = "CREATED"
+	PayoutStatusSent    PayoutStatus = "SENT"
+	PayoutStatusPaid    PayoutStatus = "PAID"
+	PayoutStatusFailed  PayoutStatus = "FAILED"
+)
+
+var AllPayoutStatus = []PayoutStatus{
+	PayoutStatusCreated,
+	PayoutStatusSent,
+	PayoutStatusPaid,
+	PayoutStatusFailed,
+}
+
+func (e PayoutStatus) IsValid() bool {
+	switch e {
+	case PayoutStatusCreated, PayoutStatusSent, PayoutStatusPaid, PayoutStatusFailed:
+		return true
+	}
+	return false
+}
+
+func (e PayoutStatus) String() string {
+	return string(e)
+}
+
+func (e *PayoutStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = PayoutStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid PayoutStatus", str)
+	}
+	return nil
+}
+
+func (e PayoutStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *PayoutStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e PayoutStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type RiskDecision string
+
+const (
+	RiskDecisionAllow  RiskDecision = "ALLOW"
+	RiskDecisionReview RiskDecision = "REVIEW"
+	RiskDecisionDeny   RiskDecision = "DENY"
+)
+
+var AllRiskDecision = []RiskDecision{
+	RiskDecisionAllow,
+	RiskDecisionReview,
+	RiskDecisionDeny,
+}
+
+func (e RiskDecision) IsValid() bool {
+	switch e {
+	case RiskDecisionAllow, RiskDecisionReview, RiskDecisionDeny:
+		return true
+	}
+	return false
+}
+
+func (e RiskDecision) String() string {
+	return string(e)
+}
+
+func (e *RiskDecision) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = RiskDecision(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid RiskDecision", str)
+	}
+	return nil
+}
+
+func (e RiskDecision) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *RiskDecision) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e RiskDecision) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type ScreeningDecision string
+
+const (
+	ScreeningDecisionRelease ScreeningDecision = "RELEASE"
+	ScreeningDecisionDeny    ScreeningDecision = "DENY"
+)
+
+var AllScreeningDecision = []ScreeningDecision{
+	ScreeningDecisionRelease,
+	ScreeningDecisionDeny,
+}
+
+func (e ScreeningDecision) IsValid() bool {
+	switch e {
+	case ScreeningDecisionRelease, ScreeningDecisionDeny:
+		return true
+	}
+	return false
+}
+
+func (e ScreeningDecision) String() string {
+	return string(e)
+}
+
+func (e *ScreeningDecision) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ScreeningDecision(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ScreeningDecision", str)
+	}
+	return nil
+}
+
+func (e ScreeningDecision) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *ScreeningDecision) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e ScreeningDecision) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type ScreeningStatus string
+
+const (
+	ScreeningStatusClear    ScreeningStatus = "CLEAR"
+	ScreeningStatusHit      ScreeningStatus = "HIT"
+	ScreeningStatusReleased ScreeningStatus = "RELEASED"
+	ScreeningStatusDenied   ScreeningStatus = "DENIED"
+)
+
+var AllScreeningStatus = []ScreeningStatus{
+	ScreeningStatusClear,
+	ScreeningStatusHit,
+	ScreeningStatusReleased,
+	ScreeningStatusDenied,
+}
+
+func (e ScreeningStatus) IsValid() bool {
+	switch e {
+	case ScreeningStatusClear, ScreeningStatusHit, ScreeningStatusReleased, ScreeningStatusDenied:
+		return true
+	}
+	return false
+}
+
+func (e ScreeningStatus) String() string {
+	return string(e)
+}
+
+func (e *ScreeningStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ScreeningStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ScreeningStatus", str)
+	}
+	return nil
+}
+
+func (e ScreeningStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *ScreeningStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e ScreeningStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type StatementFormat string
+
+const (
+	StatementFormatCamt053 StatementFormat = "CAMT053"
+	StatementFormatMt940   StatementFormat = "MT940"
+)
+
+var AllStatementFormat = []StatementFormat{
+	StatementFormatCamt053,
+	StatementFormatMt940,
+}
+
+func (e StatementFormat) IsValid() bool {
+	switch e {
+	case StatementFormatCamt053, StatementFormatMt940:
+		return true
+	}
+	return false
+}
+
+func (e StatementFormat) String() string {
+	return string(e)
+}
+
+func (e *StatementFormat) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = StatementFormat(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid StatementFormat", str)
+	}
+	return nil
+}
+
+func (e StatementFormat) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *StatementFormat) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e StatementFormat) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type StatementLineStatus string
+
+const (
+	StatementLineStatusUnmatched StatementLineStatus = "UNMATCHED"
+	StatementLineStatusProposed  StatementLineStatus = "PROPOSED"
+	StatementLineStatusMatched   StatementLineStatus = "MATCHED"
+)
+
+var AllStatementLineStatus = []StatementLineStatus{
+	StatementLineStatusUnmatched,
+	StatementLineStatusProposed,
+	StatementLineStatusMatched,
+}
+
+func (e StatementLineStatus) IsValid() bool {
+	switch e {
+	case StatementLineStatusUnmatched, StatementLineStatusProposed, StatementLineStatusMatched:
+		return true
+	}
+	return false
+}
+
+func (e StatementLineStatus) String() string {
+	return string(e)
+}
+
+func (e *StatementLineStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = StatementLineStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid StatementLineStatus", str)
+	}
+	return nil
+}
+
+func (e StatementLineStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *StatementLineStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e StatementLineStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type SubscriptionStatus string
+
+const (
+	SubscriptionStatusActive    SubscriptionStatus = "ACTIVE"
+	SubscriptionStatusPastDue   SubscriptionStatus = "PAST_DUE"
+	SubscriptionStatusPaused    SubscriptionStatus = "PAUSED"
+	SubscriptionStatusUnpaid    SubscriptionStatus = "UNPAID"
+	SubscriptionStatusCancelled SubscriptionStatus = "CANCELLED"
+)
+
+var AllSubscriptionStatus = []SubscriptionStatus{
+	SubscriptionStatusActive,
+	SubscriptionStatusPastDue,
+	SubscriptionStatusPaused,
+	SubscriptionStatusUnpaid,
+	SubscriptionStatusCancelled,
+}
+
+func (e SubscriptionStatus) IsValid() bool {
+	switch e {
+	case SubscriptionStatusActive, SubscriptionStatusPastDue, SubscriptionStatusPaused, SubscriptionStatusUnpaid, SubscriptionStatusCancelled:
+		return true
+	}
+	return false
+}
+
+func (e SubscriptionStatus) String() string {
+	return string(e)
+}
+
+func (e *SubscriptionStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SubscriptionStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SubscriptionStatus", str)
+	}
+	return nil
+}
+
+func (e SubscriptionStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *SubscriptionStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e SubscriptionStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type TaxPricing string
+
+const (
+	TaxPricingInclusive TaxPricing = "INCLUSIVE"
+	TaxPricingExclusive TaxPricing = "EXCLUSIVE"
+)
+
+var AllTaxPricing = []TaxPricing{
+	TaxPricingInclusive,
+	TaxPricingExclusive,
+}
+
+func (e TaxPricing) IsValid() bool {
+	switch e {
+	case TaxPricingInclusive, TaxPricingExclusive:
+		return true
+	}
+	return false
+}
+
+func (e TaxPricing) String() string {
+	return string(e)
+}
+
+func (e *TaxPricing) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = TaxPricing(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid TaxPricing", str)
+	}
+	return nil
+}
+
+func (e TaxPricing) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *TaxPricing) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e TaxPricing) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
 }
@@ -6,11 +6,19 @@ package graph
 
 import (
 	"context"
+	"fmt"
 	"payments_app/graph/generated"
 	"payments_app/graph/model"
 	"time"
+
+	"github.com/99designs/gqlgen/graphql"
 )
 
+// Payments is the resolver for the payments field.
+func (r *customerResolver) Payments(ctx context.Context, obj *model.Customer, first *int, after *string) (*model.PaymentConnection, error) {
+	panic(fmt.Errorf("not implemented: Payments - payments"))
+}
+
 // CreatePayment is the resolver for the createPayment field.
 func (r *mutationResolver) CreatePayment(ctx context.Context, input model.CreatePaymentInput) (*model.Payment, error) {
 	return r.storage.CreatePayment(input)
@@ -27,6 +35,186 @@ func (r *mutationResolver) DeletePayment(ctx context.Context, id string) (bool,
 	return err == nil, err
 }
 
+// ResolveScreeningHold is the resolver for the resolveScreeningHold field.
+func (r *mutationResolver) ResolveScreeningHold(ctx context.Context, input model.ResolveScreeningHoldInput) (*model.Payment, error) {
+	panic(fmt.Errorf("not implemented: ResolveScreeningHold - resolveScreeningHold"))
+}
+
+// TokenizeCard is the resolver for the tokenizeCard field.
+func (r *mutationResolver) TokenizeCard(ctx context.Context, input model.TokenizeCardInput) (*model.CardToken, error) {
+	panic(fmt.Errorf("not implemented: TokenizeCard - tokenizeCard"))
+}
+
+// AuthorizePayment is the resolver for the authorizePayment field.
+func (r *mutationResolver) AuthorizePayment(ctx context.Context, id string) (*model.Payment, error) {
+	panic(fmt.Errorf("not implemented: AuthorizePayment - authorizePayment"))
+}
+
+// CapturePayment is the resolver for the capturePayment field.
+func (r *mutationResolver) CapturePayment(ctx context.Context, id string) (*model.Payment, error) {
+	panic(fmt.Errorf("not implemented: CapturePayment - capturePayment"))
+}
+
+// VoidPayment is the resolver for the voidPayment field.
+func (r *mutationResolver) VoidPayment(ctx context.Context, id string) (*model.Payment, error) {
+	panic(fmt.Errorf("not implemented: VoidPayment - voidPayment"))
+}
+
+// RefundPayment is the resolver for the refundPayment field.
+func (r *mutationResolver) RefundPayment(ctx context.Context, id string, amount *float64) (*model.Payment, error) {
+	panic(fmt.Errorf("not implemented: RefundPayment - refundPayment"))
+}
+
+// SyncPaymentStatus is the resolver for the syncPaymentStatus field.
+func (r *mutationResolver) SyncPaymentStatus(ctx context.Context, id string) (*model.Payment, error) {
+	panic(fmt.Errorf("not implemented: SyncPaymentStatus - syncPaymentStatus"))
+}
+
+// ReplayProcessorCallback is the resolver for the replayProcessorCallback field.
+func (r *mutationResolver) ReplayProcessorCallback(ctx context.Context, id string) (*model.ProcessorCallback, error) {
+	panic(fmt.Errorf("not implemented: ReplayProcessorCallback - replayProcessorCallback"))
+}
+
+// OpenDispute is the resolver for the openDispute field.
+func (r *mutationResolver) OpenDispute(ctx context.Context, input model.OpenDisputeInput) (*model.Dispute, error) {
+	panic(fmt.Errorf("not implemented: OpenDispute - openDispute"))
+}
+
+// SubmitDisputeEvidence is the resolver for the submitDisputeEvidence field.
+func (r *mutationResolver) SubmitDisputeEvidence(ctx context.Context, input model.SubmitDisputeEvidenceInput) (*model.Dispute, error) {
+	panic(fmt.Errorf("not implemented: SubmitDisputeEvidence - submitDisputeEvidence"))
+}
+
+// ResolveDispute is the resolver for the resolveDispute field.
+func (r *mutationResolver) ResolveDispute(ctx context.Context, input model.ResolveDisputeInput) (*model.Dispute, error) {
+	panic(fmt.Errorf("not implemented: ResolveDispute - resolveDispute"))
+}
+
+// CreateSubscription is the resolver for the createSubscription field.
+func (r *mutationResolver) CreateSubscription(ctx context.Context, input model.CreateSubscriptionInput) (*model.Subscription, error) {
+	panic(fmt.Errorf("not implemented: CreateSubscription - createSubscription"))
+}
+
+// PauseSubscription is the resolver for the pauseSubscription field.
+func (r *mutationResolver) PauseSubscription(ctx context.Context, id string) (*model.Subscription, error) {
+	panic(fmt.Errorf("not implemented: PauseSubscription - pauseSubscription"))
+}
+
+// ResumeSubscription is the resolver for the resumeSubscription field.
+func (r *mutationResolver) ResumeSubscription(ctx context.Context, id string) (*model.Subscription, error) {
+	panic(fmt.Errorf("not implemented: ResumeSubscription - resumeSubscription"))
+}
+
+// CancelSubscription is the resolver for the cancelSubscription field.
+func (r *mutationResolver) CancelSubscription(ctx context.Context, id string) (*model.Subscription, error) {
+	panic(fmt.Errorf("not implemented: CancelSubscription - cancelSubscription"))
+}
+
+// ReschedulePayment is the resolver for the reschedulePayment field.
+func (r *mutationResolver) ReschedulePayment(ctx context.Context, id string, executeAt string) (*model.Payment, error) {
+	panic(fmt.Errorf("not implemented: ReschedulePayment - reschedulePayment"))
+}
+
+// CancelScheduledPayment is the resolver for the cancelScheduledPayment field.
+func (r *mutationResolver) CancelScheduledPayment(ctx context.Context, id string) (*model.Payment, error) {
+	panic(fmt.Errorf("not implemented: CancelScheduledPayment - cancelScheduledPayment"))
+}
+
+// BulkCreatePayments is the resolver for the bulkCreatePayments field.
+func (r *mutationResolver) BulkCreatePayments(ctx context.Context, file graphql.Upload, format *model.BulkFormat, mode *model.BulkMode) (*model.BulkImportReport, error) {
+	panic(fmt.Errorf("not implemented: BulkCreatePayments - bulkCreatePayments"))
+}
+
+// ImportBankStatement is the resolver for the importBankStatement field.
+func (r *mutationResolver) ImportBankStatement(ctx context.Context, file graphql.Upload, format *model.StatementFormat) (*model.StatementImportReport, error) {
+	panic(fmt.Errorf("not implemented: ImportBankStatement - importBankStatement"))
+}
+
+// ReconcileStatements is the resolver for the reconcileStatements field.
+func (r *mutationResolver) ReconcileStatements(ctx context.Context) (*model.ReconciliationRun, error) {
+	panic(fmt.Errorf("not implemented: ReconcileStatements - reconcileStatements"))
+}
+
+// ConfirmStatementMatch is the resolver for the confirmStatementMatch field.
+func (r *mutationResolver) ConfirmStatementMatch(ctx context.Context, lineID string, confirmedBy string) (*model.StatementLine, error) {
+	panic(fmt.Errorf("not implemented: ConfirmStatementMatch - confirmStatementMatch"))
+}
+
+// RejectStatementMatch is the resolver for the rejectStatementMatch field.
+func (r *mutationResolver) RejectStatementMatch(ctx context.Context, lineID string) (*model.StatementLine, error) {
+	panic(fmt.Errorf("not implemented: RejectStatementMatch - rejectStatementMatch"))
+}
+
+// MatchStatementLine is the resolver for the matchStatementLine field.
+func (r *mutationResolver) MatchStatementLine(ctx context.Context, lineID string, paymentID string, matchedBy string) (*model.StatementLine, error) {
+	panic(fmt.Errorf("not implemented: MatchStatementLine - matchStatementLine"))
+}
+
+// ProcessAchReturns is the resolver for the processAchReturns field.
+func (r *mutationResolver) ProcessAchReturns(ctx context.Context, file graphql.Upload) (*model.AchReturnReport, error) {
+	panic(fmt.Errorf("not implemented: ProcessAchReturns - processAchReturns"))
+}
+
+// CreateCustomer is the resolver for the createCustomer field.
+func (r *mutationResolver) CreateCustomer(ctx context.Context, input model.CustomerInput) (*model.Customer, error) {
+	panic(fmt.Errorf("not implemented: CreateCustomer - createCustomer"))
+}
+
+// UpdateCustomer is the resolver for the updateCustomer field.
+func (r *mutationResolver) UpdateCustomer(ctx context.Context, id string, input model.CustomerInput) (*model.Customer, error) {
+	panic(fmt.Errorf("not implemented: UpdateCustomer - updateCustomer"))
+}
+
+// DeleteCustomer is the resolver for the deleteCustomer field.
+func (r *mutationResolver) DeleteCustomer(ctx context.Context, id string) (bool, error) {
+	panic(fmt.Errorf("not implemented: DeleteCustomer - deleteCustomer"))
+}
+
+// EraseCustomer is the resolver for the eraseCustomer field.
+func (r *mutationResolver) EraseCustomer(ctx context.Context, id string) (*model.Customer, error) {
+	panic(fmt.Errorf("not implemented: EraseCustomer - eraseCustomer"))
+}
+
+// RunSettlement is the resolver for the runSettlement field.
+func (r *mutationResolver) RunSettlement(ctx context.Context) (*model.SettlementBatch, error) {
+	panic(fmt.Errorf("not implemented: RunSettlement - runSettlement"))
+}
+
+// MarkPayoutSent is the resolver for the markPayoutSent field.
+func (r *mutationResolver) MarkPayoutSent(ctx context.Context, id string, reference string) (*model.Payout, error) {
+	panic(fmt.Errorf("not implemented: MarkPayoutSent - markPayoutSent"))
+}
+
+// MarkPayoutPaid is the resolver for the markPayoutPaid field.
+func (r *mutationResolver) MarkPayoutPaid(ctx context.Context, id string) (*model.Payout, error) {
+	panic(fmt.Errorf("not implemented: MarkPayoutPaid - markPayoutPaid"))
+}
+
+// MarkPayoutFailed is the resolver for the markPayoutFailed field.
+func (r *mutationResolver) MarkPayoutFailed(ctx context.Context, id string, reason string) (*model.Payout, error) {
+	panic(fmt.Errorf("not implemented: MarkPayoutFailed - markPayoutFailed"))
+}
+
+// CreateInvoice is the resolver for the createInvoice field.
+func (r *mutationResolver) CreateInvoice(ctx context.Context, input model.InvoiceInput) (*model.Invoice, error) {
+	panic(fmt.Errorf("not implemented: CreateInvoice - createInvoice"))
+}
+
+// ApplyPayment is the resolver for the applyPayment field.
+func (r *mutationResolver) ApplyPayment(ctx context.Context, paymentID string, allocations []*model.InvoiceAllocationInput) ([]*model.InvoiceAllocation, error) {
+	panic(fmt.Errorf("not implemented: ApplyPayment - applyPayment"))
+}
+
+// RemoveInvoiceAllocation is the resolver for the removeInvoiceAllocation field.
+func (r *mutationResolver) RemoveInvoiceAllocation(ctx context.Context, id string) (*model.InvoiceAllocation, error) {
+	panic(fmt.Errorf("not implemented: RemoveInvoiceAllocation - removeInvoiceAllocation"))
+}
+
+// Customer is the resolver for the customer field.
+func (r *paymentResolver) Customer(ctx context.Context, obj *model.Payment) (*model.Customer, error) {
+	panic(fmt.Errorf("not implemented: Customer - customer"))
+}
+
 // CreatedAt is the resolver for the createdAt field.
 func (r *paymentResolver) CreatedAt(ctx context.Context, obj *model.Payment) (string, error) {
 	return obj.CreatedAt.Format(time.RFC3339), nil
@@ -38,7 +226,10 @@ func (r *paymentResolver) UpdatedAt(ctx context.Context, obj *model.Payment) (st
 }
 
 // Payments is the resolver for the payments field.
-func (r *queryResolver) Payments(ctx context.Context) ([]*model.Payment, error) {
+func (r *queryResolver) Payments(ctx context.Context, filter *model.PaymentFilter) ([]*model.Payment, error) {
+	if filter != nil {
+		return nil, fmt.Errorf("not implemented: Payments - payments filter")
+	}
 	return r.storage.GetAllPayments()
 }
 
@@ -47,6 +238,119 @@ func (r *queryResolver) Payment(ctx context.Context, id string) (*model.Payment,
 	return r.storage.GetPayment(id)
 }
 
+// PaymentStats is the resolver for the paymentStats field.
+func (r *queryResolver) PaymentStats(ctx context.Context, filter *model.PaymentFilter, groupBy []model.PaymentStatsGroupBy, timezone *string) ([]*model.PaymentStatsGroup, error) {
+	panic(fmt.Errorf("not implemented: PaymentStats - paymentStats"))
+}
+
+// ProcessorStats is the resolver for the processorStats field.
+func (r *queryResolver) ProcessorStats(ctx context.Context) ([]*model.ProcessorStats, error) {
+	panic(fmt.Errorf("not implemented: ProcessorStats - processorStats"))
+}
+
+// ProcessorCallbacks is the resolver for the processorCallbacks field.
+func (r *queryResolver) ProcessorCallbacks(ctx context.Context, processor *string, limit *int) ([]*model.ProcessorCallback, error) {
+	panic(fmt.Errorf("not implemented: ProcessorCallbacks - processorCallbacks"))
+}
+
+// Dispute is the resolver for the dispute field.
+func (r *queryResolver) Dispute(ctx context.Context, id string) (*model.Dispute, error) {
+	panic(fmt.Errorf("not implemented: Dispute - dispute"))
+}
+
+// Disputes is the resolver for the disputes field.
+func (r *queryResolver) Disputes(ctx context.Context, paymentID *string, status *model.DisputeStatus) ([]*model.Dispute, error) {
+	panic(fmt.Errorf("not implemented: Disputes - disputes"))
+}
+
+// DisputesNearingDeadline is the resolver for the disputesNearingDeadline field.
+func (r *queryResolver) DisputesNearingDeadline(ctx context.Context, days *int) ([]*model.Dispute, error) {
+	panic(fmt.Errorf("not implemented: DisputesNearingDeadline - disputesNearingDeadline"))
+}
+
+// LedgerEntries is the resolver for the ledgerEntries field.
+func (r *queryResolver) LedgerEntries(ctx context.Context, paymentID string) ([]*model.JournalEntry, error) {
+	panic(fmt.Errorf("not implemented: LedgerEntries - ledgerEntries"))
+}
+
+// RecipientBalance is the resolver for the recipientBalance field.
+func (r *queryResolver) RecipientBalance(ctx context.Context, recipient string) (*model.RecipientBalance, error) {
+	panic(fmt.Errorf("not implemented: RecipientBalance - recipientBalance"))
+}
+
+// Customer is the resolver for the customer field.
+func (r *queryResolver) Customer(ctx context.Context, id string) (*model.Customer, error) {
+	panic(fmt.Errorf("not implemented: Customer - customer"))
+}
+
+// Customers is the resolver for the customers field.
+func (r *queryResolver) Customers(ctx context.Context, email *string, externalReference *string) ([]*model.Customer, error) {
+	panic(fmt.Errorf("not implemented: Customers - customers"))
+}
+
+// SearchPayments is the resolver for the searchPayments field.
+func (r *queryResolver) SearchPayments(ctx context.Context, query string, first *int, after *string) (*model.PaymentSearchConnection, error) {
+	panic(fmt.Errorf("not implemented: SearchPayments - searchPayments"))
+}
+
+// SettlementBatch is the resolver for the settlementBatch field.
+func (r *queryResolver) SettlementBatch(ctx context.Context, id string) (*model.SettlementBatch, error) {
+	panic(fmt.Errorf("not implemented: SettlementBatch - settlementBatch"))
+}
+
+// Payout is the resolver for the payout field.
+func (r *queryResolver) Payout(ctx context.Context, id string) (*model.Payout, error) {
+	panic(fmt.Errorf("not implemented: Payout - payout"))
+}
+
+// Payouts is the resolver for the payouts field.
+func (r *queryResolver) Payouts(ctx context.Context, merchantID *string, batchID *string, status *model.PayoutStatus) ([]*model.Payout, error) {
+	panic(fmt.Errorf("not implemented: Payouts - payouts"))
+}
+
+// Invoice is the resolver for the invoice field.
+func (r *queryResolver) Invoice(ctx context.Context, id string) (*model.Invoice, error) {
+	panic(fmt.Errorf("not implemented: Invoice - invoice"))
+}
+
+// Invoices is the resolver for the invoices field.
+func (r *queryResolver) Invoices(ctx context.Context, customerID *string, currency *string, status *model.InvoiceStatus) ([]*model.Invoice, error) {
+	panic(fmt.Errorf("not implemented: Invoices - invoices"))
+}
+
+// InvoiceAging is the resolver for the invoiceAging field.
+func (r *queryResolver) InvoiceAging(ctx context.Context, asOf *string, customerID *string) ([]*model.InvoiceAgingReport, error) {
+	panic(fmt.Errorf("not implemented: InvoiceAging - invoiceAging"))
+}
+
+// Subscription is the resolver for the subscription field.
+func (r *queryResolver) Subscription(ctx context.Context, id string) (*model.Subscription, error) {
+	panic(fmt.Errorf("not implemented: Subscription - subscription"))
+}
+
+// Subscriptions is the resolver for the subscriptions field.
+func (r *queryResolver) Subscriptions(ctx context.Context, payerID *string, status *model.SubscriptionStatus) ([]*model.Subscription, error) {
+	panic(fmt.Errorf("not implemented: Subscriptions - subscriptions"))
+}
+
+// BankStatement is the resolver for the bankStatement field.
+func (r *queryResolver) BankStatement(ctx context.Context, id string) (*model.BankStatement, error) {
+	panic(fmt.Errorf("not implemented: BankStatement - bankStatement"))
+}
+
+// UnreconciledStatementLines is the resolver for the unreconciledStatementLines field.
+func (r *queryResolver) UnreconciledStatementLines(ctx context.Context, statementID *string) ([]*model.StatementLine, error) {
+	panic(fmt.Errorf("not implemented: UnreconciledStatementLines - unreconciledStatementLines"))
+}
+
+// UnreconciledPayments is the resolver for the unreconciledPayments field.
+func (r *queryResolver) UnreconciledPayments(ctx context.Context) ([]*model.Payment, error) {
+	panic(fmt.Errorf("not implemented: UnreconciledPayments - unreconciledPayments"))
+}
+
+// Customer returns generated.CustomerResolver implementation.
+func (r *Resolver) Customer() generated.CustomerResolver { return &customerResolver{r} }
+
 // Mutation returns generated.MutationResolver implementation.
 func (r *Resolver) Mutation() generated.MutationResolver { return &mutationResolver{r} }
 
@@ -56,6 +360,7 @@ func (r *Resolver) Payment() generated.PaymentResolver { return &paymentResolver
 // Query returns generated.QueryResolver implementation.
 func (r *Resolver) Query() generated.QueryResolver { return &queryResolver{r} }
 
+type customerResolver struct{ *Resolver }
 type mutationResolver struct{ *Resolver }
 type paymentResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
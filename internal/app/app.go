@@ -0,0 +1,412 @@
+// Package app wires the payment use case and its optional features from configuration,
+// so the server and command-line tools run the same business rules.
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"payments_app/configs"
+	"payments_app/internal/domain"
+	"payments_app/internal/fees"
+	"payments_app/internal/fx"
+	"payments_app/internal/infrastructure/database"
+	"payments_app/internal/infrastructure/processor"
+	"payments_app/internal/infrastructure/storage"
+	"payments_app/internal/interfaces/webhook"
+	"payments_app/internal/iso20022"
+	"payments_app/internal/nacha"
+	"payments_app/internal/reconciliation"
+	"payments_app/internal/risk"
+	"payments_app/internal/routing"
+	"payments_app/internal/scheduler"
+	"payments_app/internal/screening"
+	"payments_app/internal/tax"
+	"payments_app/internal/usecases"
+	"payments_app/internal/vault"
+	"payments_app/pkg/logger"
+	"strings"
+	"time"
+)
+
+// App holds the wired payment use case and the resources behind it
+type App struct {
+	Repo           *database.PaymentRepository
+	PaymentUseCase *usecases.PaymentUseCase
+	// Verifiers authenticate callbacks of the processors that support webhooks
+	Verifiers map[string]webhook.Verifier
+
+	cfg        *configs.Config
+	log        *logger.Logger
+	riskEngine *risk.Engine
+	fxRates    *fx.FileProvider
+	feeEngine  *fees.Engine
+	taxEngine  *tax.Engine
+	cardVault  *vault.Vault
+	vaultKeys  *vault.KeyRing
+}
+
+// New opens the database and configures the payment use case with the features enabled in cfg
+func New(cfg *configs.Config, log *logger.Logger) (*App, error) {
+	repo, err := database.NewPaymentRepository(cfg.Database.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	a := &App{Repo: repo, cfg: cfg, log: log}
+	opts, err := a.options()
+	if err != nil {
+		repo.Close()
+		return nil, err
+	}
+	a.PaymentUseCase = usecases.NewPaymentUseCase(repo, opts...)
+	return a, nil
+}
+
+// Close releases the database connection
+func (a *App) Close() error {
+	return a.Repo.Close()
+}
+
+// StartBackground starts the long-running jobs of a server instance: risk rule, exchange rate,
+// fee schedule and tax rate reloads, vault key rotation, subscription billing, scheduled payment
+// execution and daily settlement
+func (a *App) StartBackground(ctx context.Context) {
+	cfg, log := a.cfg, a.log
+
+	if a.riskEngine != nil {
+		interval := time.Duration(cfg.Risk.ReloadIntervalSeconds) * time.Second
+		go a.riskEngine.Watch(ctx, cfg.Risk.RulesPath, interval, func(err error) {
+			log.Warnf("risk rules reload failed, keeping previous rules: %v", err)
+		})
+	}
+	if a.fxRates != nil {
+		interval := time.Duration(cfg.FX.ReloadIntervalSeconds) * time.Second
+		go a.fxRates.Watch(ctx, cfg.FX.RatesPath, interval, func(err error) {
+			log.Warnf("exchange rates reload failed, keeping previous rates: %v", err)
+		})
+	}
+	if a.feeEngine != nil {
+		interval := time.Duration(cfg.Fees.ReloadIntervalSeconds) * time.Second
+		go a.feeEngine.Watch(ctx, cfg.Fees.SchedulesPath, interval, func(err error) {
+			log.Warnf("fee schedules reload failed, keeping previous schedules: %v", err)
+		})
+	}
+	if a.taxEngine != nil {
+		interval := time.Duration(cfg.Tax.ReloadIntervalSeconds) * time.Second
+		go a.taxEngine.Watch(ctx, cfg.Tax.RatesPath, interval, func(err error) {
+			log.Warnf("tax rates reload failed, keeping previous rates: %v", err)
+		})
+	}
+
+	if a.cardVault != nil {
+		// Re-wrap data keys under the active key in the background; cards stay readable meanwhile
+		go func() {
+			rotated, err := a.cardVault.RotateKeys(ctx, cfg.Vault.RotationBatchSize)
+			if err != nil {
+				log.Errorf("vault key rotation stopped after %d records: %v", rotated, err)
+				return
+			}
+			if rotated > 0 {
+				log.Infof("vault key rotation re-wrapped %d data keys under %s", rotated, a.vaultKeys.ActiveID())
+			}
+		}()
+	}
+
+	// Bill due subscriptions in the background
+	go scheduler.Run(ctx, time.Duration(cfg.Billing.SchedulerIntervalSeconds)*time.Second, func(ctx context.Context, now time.Time) error {
+		billed, err := a.PaymentUseCase.RunDueSubscriptions(ctx, now)
+		if billed > 0 {
+			log.Infof("billed %d subscriptions", billed)
+		}
+		return err
+	}, func(err error) {
+		log.Warnf("subscription billing: %v", err)
+	})
+
+	// Execute future-dated payments once they fall due; leases keep instances from executing one twice
+	go scheduler.Run(ctx, time.Duration(cfg.Billing.ScheduledIntervalSeconds)*time.Second, func(ctx context.Context, now time.Time) error {
+		executed, err := a.PaymentUseCase.RunScheduledPayments(ctx, now)
+		if executed > 0 {
+			log.Infof("executed %d scheduled payments", executed)
+		}
+		return err
+	}, func(err error) {
+		log.Warnf("scheduled payments: %v", err)
+	})
+
+	// Settle the previous day once per day; later runs find the batch and do nothing
+	go scheduler.Run(ctx, time.Duration(cfg.Payouts.SettlementIntervalSeconds)*time.Second, func(ctx context.Context, now time.Time) error {
+		batch, err := a.PaymentUseCase.RunSettlement(ctx, now)
+		if errors.Is(err, domain.ErrSettlementBatchExists) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		log.Infof("settlement batch %s created with %d payouts", batch.ID, len(batch.Payouts))
+		return nil
+	}, func(err error) {
+		log.Warnf("settlement: %v", err)
+	})
+}
+
+// options builds the use case options for the configured features
+func (a *App) options() ([]usecases.Option, error) {
+	cfg, log, repo := a.cfg, a.log, a.Repo
+
+	// Initialize use cases with optional risk screening
+	var opts []usecases.Option
+	if cfg.Risk.RulesPath != "" {
+		engine := risk.NewEngine(repo, risk.Thresholds{})
+		if err := engine.Reload(cfg.Risk.RulesPath); err != nil {
+			return nil, fmt.Errorf("failed to load risk rules: %w", err)
+		}
+		a.riskEngine = engine
+		opts = append(opts, usecases.WithRiskScreener(engine))
+		log.Infof("risk screening enabled with rules %v", engine.Rules())
+	}
+	if cfg.Screening.ListPath != "" {
+		list, err := screening.LoadList(cfg.Screening.ListPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load screening list: %w", err)
+		}
+		opts = append(opts, usecases.WithSanctionsScreener(screening.NewScreener(list, cfg.Screening.Threshold)))
+		log.Infof("sanctions screening enabled with %d list entries", len(list.Entries))
+	}
+	if cfg.Vault.Keys != "" {
+		keys, err := vault.ParseKeyRing(cfg.Vault.Keys, cfg.Vault.ActiveKeyID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vault keys: %w", err)
+		}
+		vaultRepo, err := database.NewVaultRepository(repo.DB())
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize card vault: %w", err)
+		}
+		a.cardVault = vault.NewVault(vaultRepo, keys)
+		a.vaultKeys = keys
+		opts = append(opts, usecases.WithCardVault(a.cardVault))
+	}
+	var processors []domain.Processor
+	switch {
+	case cfg.Processor.RoutingPath != "":
+		routingCfg, err := routing.LoadConfig(cfg.Processor.RoutingPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load routing file: %w", err)
+		}
+		processors = make([]domain.Processor, len(routingCfg.Processors))
+		for i, processorCfg := range routingCfg.Processors {
+			apiKey, webhookSecret := os.Getenv(processorCfg.APIKeyEnv), os.Getenv(processorCfg.WebhookSecretEnv)
+			if processors[i], err = buildProcessor(processorCfg, apiKey, webhookSecret, a.cardVault); err != nil {
+				return nil, fmt.Errorf("invalid processor %s: %w", processorCfg.Name, err)
+			}
+		}
+		router, err := routing.NewRouter(*routingCfg, processors...)
+		if err != nil {
+			return nil, fmt.Errorf("invalid routing file: %w", err)
+		}
+		opts = append(opts, usecases.WithRouter(router), usecases.WithAutoCapture(cfg.Processor.AutoCapture))
+		log.Infof("payment routing enabled across %d processors (auto-capture: %t)", len(processors), cfg.Processor.AutoCapture)
+	case cfg.Processor.Type != "":
+		single, err := buildProcessor(routing.ProcessorConfig{
+			Type:    cfg.Processor.Type,
+			Latency: time.Duration(cfg.Processor.SimulatorLatencyMs) * time.Millisecond,
+			URL:     cfg.Processor.HTTPURL,
+			Timeout: time.Duration(cfg.Processor.HTTPTimeoutSeconds) * time.Second,
+		}, cfg.Processor.HTTPAPIKey, cfg.Processor.WebhookSecret, a.cardVault)
+		if err != nil {
+			return nil, fmt.Errorf("invalid processor configuration: %w", err)
+		}
+		processors = []domain.Processor{single}
+		opts = append(opts, usecases.WithProcessor(single), usecases.WithAutoCapture(cfg.Processor.AutoCapture))
+		log.Infof("payment processor %s enabled (auto-capture: %t)", cfg.Processor.Type, cfg.Processor.AutoCapture)
+	}
+
+	// Processors report asynchronous results through signed callbacks, once a secret is configured
+	a.Verifiers = make(map[string]webhook.Verifier)
+	for _, p := range processors {
+		verifier, ok := p.(webhook.Verifier)
+		if !ok {
+			continue
+		}
+		if !verifier.AcceptsWebhooks() {
+			log.Warnf("processor %s has no webhook secret; its callbacks are disabled", p.Name())
+			continue
+		}
+		a.Verifiers[p.Name()] = verifier
+	}
+	if len(a.Verifiers) > 0 {
+		callbackRepo, err := database.NewCallbackRepository(repo.DB())
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize callback store: %w", err)
+		}
+		opts = append(opts, usecases.WithCallbacks(callbackRepo, repo))
+	}
+
+	// Disputes keep their evidence files on local disk and post lost chargebacks to the ledger
+	disputeRepo, err := database.NewDisputeRepository(repo.DB())
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize dispute store: %w", err)
+	}
+	ledgerRepo, err := database.NewLedgerRepository(repo.DB())
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize ledger: %w", err)
+	}
+	evidenceStore, err := storage.NewLocalStore(cfg.Disputes.EvidenceDir, int64(cfg.Disputes.MaxEvidenceBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize evidence storage: %w", err)
+	}
+	evidenceWindow := time.Duration(cfg.Disputes.EvidenceDays) * 24 * time.Hour
+	opts = append(opts, usecases.WithDisputes(disputeRepo, evidenceStore, evidenceWindow), usecases.WithLedger(ledgerRepo))
+
+	// Split payments credit their recipients' balances
+	balanceRepo, err := database.NewRecipientBalanceRepository(repo.DB())
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize recipient balances: %w", err)
+	}
+	opts = append(opts, usecases.WithSplits(balanceRepo))
+
+	// Merchants are paid out by daily settlement batches
+	settlementRepo, err := database.NewSettlementRepository(repo.DB())
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize settlement store: %w", err)
+	}
+	opts = append(opts, usecases.WithPayouts(settlementRepo))
+
+	customerRepo, err := database.NewCustomerRepository(repo.DB())
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize customer store: %w", err)
+	}
+	opts = append(opts, usecases.WithCustomers(customerRepo))
+
+	// Declared metadata keys are indexed; indexes of keys no longer declared are dropped
+	if err := repo.IndexMetadataKeys(context.Background(), cfg.Metadata.IndexedKeys); err != nil {
+		return nil, fmt.Errorf("failed to index payment metadata: %w", err)
+	}
+
+	searchRepo, err := database.NewPaymentSearchRepository(repo.DB())
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize payment search: %w", err)
+	}
+	if !searchRepo.FullText() {
+		log.Warnf("SQLite was built without FTS5 (build tag sqlite_fts5); payment search scans all payments")
+	}
+	opts = append(opts, usecases.WithSearch(searchRepo))
+
+	invoiceRepo, err := database.NewInvoiceRepository(repo.DB())
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize invoice store: %w", err)
+	}
+	opts = append(opts, usecases.WithInvoices(invoiceRepo))
+
+	subscriptionRepo, err := database.NewSubscriptionRepository(repo.DB())
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize subscription store: %w", err)
+	}
+	opts = append(opts, usecases.WithSubscriptions(subscriptionRepo, cfg.Billing.DunningBackoff), usecases.WithScheduledPayments(repo))
+
+	reconciliationRepo, err := database.NewReconciliationRepository(repo.DB())
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize statement store: %w", err)
+	}
+	matcher := reconciliation.NewMatcher(reconciliation.Config{
+		DateWindow:     time.Duration(cfg.Reconciliation.DateWindowDays) * 24 * time.Hour,
+		AutoMatchScore: cfg.Reconciliation.AutoMatchScore,
+		ProposeScore:   cfg.Reconciliation.ProposeScore,
+	})
+	opts = append(opts, usecases.WithReconciliation(reconciliationRepo, matcher))
+
+	if cfg.CreditTransfer.DebtorIBAN != "" {
+		writer, err := iso20022.NewPain001Writer(iso20022.Account{
+			Name: cfg.CreditTransfer.DebtorName,
+			IBAN: cfg.CreditTransfer.DebtorIBAN,
+			BIC:  cfg.CreditTransfer.DebtorBIC,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid credit transfer debtor: %w", err)
+		}
+		creditTransferRepo, err := database.NewCreditTransferRepository(repo.DB())
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize credit transfer store: %w", err)
+		}
+		opts = append(opts, usecases.WithCreditTransfers(creditTransferRepo, writer))
+		log.Infof("credit transfer files enabled for debtor account %s", cfg.CreditTransfer.DebtorIBAN)
+	}
+
+	if cfg.ACH.BankRouting != "" {
+		writer, err := nacha.NewWriter(nacha.Originator{
+			CompanyName:      cfg.ACH.CompanyName,
+			CompanyID:        cfg.ACH.CompanyID,
+			BankRouting:      cfg.ACH.BankRouting,
+			BankName:         cfg.ACH.BankName,
+			EntryDescription: cfg.ACH.EntryDescription,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid ACH originator: %w", err)
+		}
+		achRepo, err := database.NewACHRepository(repo.DB())
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize ACH file store: %w", err)
+		}
+		opts = append(opts, usecases.WithACH(achRepo, writer))
+		log.Infof("ACH files enabled for company %s at routing number %s", cfg.ACH.CompanyID, cfg.ACH.BankRouting)
+	}
+	if cfg.FX.RatesPath != "" {
+		rates := fx.NewFileProvider()
+		if err := rates.Reload(cfg.FX.RatesPath); err != nil {
+			return nil, fmt.Errorf("failed to load exchange rates: %w", err)
+		}
+		settlementCurrency := strings.ToUpper(strings.TrimSpace(cfg.FX.SettlementCurrency))
+		if settlementCurrency != "" && len(settlementCurrency) != 3 {
+			return nil, fmt.Errorf("invalid settlement currency %q", cfg.FX.SettlementCurrency)
+		}
+		a.fxRates = rates
+		opts = append(opts, usecases.WithFX(rates, settlementCurrency))
+		log.Infof("currency conversion enabled with rates from %s, settling in %q", cfg.FX.RatesPath, settlementCurrency)
+	} else if cfg.FX.SettlementCurrency != "" {
+		return nil, errors.New("FX_SETTLEMENT_CURRENCY requires FX_RATES_PATH")
+	}
+	if cfg.Fees.SchedulesPath != "" {
+		engine := fees.NewEngine()
+		if err := engine.Reload(cfg.Fees.SchedulesPath); err != nil {
+			return nil, fmt.Errorf("failed to load fee schedules: %w", err)
+		}
+		a.feeEngine = engine
+		opts = append(opts, usecases.WithFees(engine))
+		log.Infof("fees enabled with %d schedules from %s", len(engine.Schedules()), cfg.Fees.SchedulesPath)
+	}
+	if cfg.Tax.RatesPath != "" {
+		engine := tax.NewEngine(nil)
+		if err := engine.Reload(cfg.Tax.RatesPath); err != nil {
+			return nil, fmt.Errorf("failed to load tax rates: %w", err)
+		}
+		a.taxEngine = engine
+		opts = append(opts, usecases.WithTax(engine))
+		log.Infof("tax calculation enabled for %d countries from %s", len(engine.Rates().Jurisdictions), cfg.Tax.RatesPath)
+	}
+
+	return opts, nil
+}
+
+// buildProcessor creates the processor described by cfg
+func buildProcessor(cfg routing.ProcessorConfig, apiKey, webhookSecret string, cardVault *vault.Vault) (domain.Processor, error) {
+	switch cfg.Type {
+	case "simulator":
+		return processor.NewSimulator(processor.SimulatorConfig{Name: cfg.Name, Latency: cfg.Latency, WebhookSecret: webhookSecret}), nil
+	case "http":
+		httpCfg := processor.HTTPConfig{
+			Name:          cfg.Name,
+			BaseURL:       cfg.URL,
+			APIKey:        apiKey,
+			Timeout:       cfg.Timeout,
+			WebhookSecret: webhookSecret,
+		}
+		if cardVault != nil {
+			httpCfg.Detokenizer = cardVault
+		}
+		return processor.NewHTTPConnector(httpCfg)
+	default:
+		return nil, fmt.Errorf("unknown processor type %q", cfg.Type)
+	}
+}
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrNoACHTransfers is returned when no payment is ready to be sent in an ACH file
+	ErrNoACHTransfers = errors.New("no payments are ready for an ACH file")
+	// ErrACHConflict is returned when a payment of a new file changed status, or a trace number
+	// was taken, before the file was stored; nothing is stored and the file can be created again
+	ErrACHConflict = errors.New("payments changed while the ACH file was created")
+	// ErrACHEntryNotFound is returned when no entry was sent with a trace number
+	ErrACHEntryNotFound = errors.New("ACH entry not found")
+)
+
+// ACHFile is a NACHA file of credit entries sent to the originating bank
+type ACHFile struct {
+	// ID identifies the file and is stored on its payments as their submission ID
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	// IDModifier tells apart the files created on the same day: A to Z, then 0 to 9
+	IDModifier string `json:"idModifier"`
+	BatchCount int    `json:"batchCount"`
+	EntryCount int    `json:"entryCount"`
+	// EntryHash is the sum of the receiving banks' 8-digit routing numbers, keeping 10 digits
+	EntryHash int64 `json:"entryHash"`
+	// TotalCredit is the total of all entries in cents
+	TotalCredit int64      `json:"totalCredit"`
+	Entries     []ACHEntry `json:"entries"`
+	// Document is the file content, kept so a lost file can be downloaded again
+	Document []byte `json:"-"`
+}
+
+// ACHEntry links the trace number of an entry to its payment, so returns can be matched
+type ACHEntry struct {
+	TraceNumber string `json:"traceNumber"`
+	PaymentID   string `json:"paymentId"`
+}
+
+// ACHReturn is a returned entry read from an ACH return file
+type ACHReturn struct {
+	// TraceNumber is the return entry's own trace number, assigned by the returning bank
+	TraceNumber string `json:"traceNumber"`
+	// OriginalTraceNumber is the trace number of the entry that was returned
+	OriginalTraceNumber string `json:"originalTraceNumber"`
+	// Code is the return reason code, such as R01, and Reason its description
+	Code   string `json:"code"`
+	Reason string `json:"reason"`
+	// Amount is the returned amount in cents
+	Amount int64 `json:"amount"`
+	// Information is the free text the returning bank added, if any
+	Information string `json:"information,omitempty"`
+}
+
+// ACHRepository stores ACH files and the trace numbers of their entries
+type ACHRepository interface {
+	// PendingACHTransfers returns pending USD bank account payments no processor handles, in
+	// creation order
+	PendingACHTransfers(ctx context.Context) ([]*Payment, error)
+	// FilesCreatedSince counts the files created at or after the given time
+	FilesCreatedSince(ctx context.Context, since time.Time) (int, error)
+	// LastTraceSequence returns the highest entry sequence number used in a trace number, or 0
+	LastTraceSequence(ctx context.Context) (int, error)
+	// CreateFile stores the file with its entries and moves its payments from PENDING to
+	// SUBMITTED in one transaction. It returns ErrACHConflict if any of them is no longer
+	// pending or a trace number is in use.
+	CreateFile(ctx context.Context, file *ACHFile) error
+	GetFile(ctx context.Context, id string) (*ACHFile, error)
+	// EntryByTraceNumber finds the entry sent with a trace number. It returns
+	// ErrACHEntryNotFound if there is none.
+	EntryByTraceNumber(ctx context.Context, traceNumber string) (*ACHEntry, error)
+}
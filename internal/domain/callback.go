@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ProcessorEventType distinguishes the kinds of asynchronous processor events
+type ProcessorEventType string
+
+const (
+	ProcessorEventStatus     ProcessorEventType = "STATUS"
+	ProcessorEventChargeback ProcessorEventType = "CHARGEBACK"
+)
+
+// ProcessorEvent is an authenticated, decoded callback from a processor
+type ProcessorEvent struct {
+	// CallbackID is the processor's ID for the callback, used to drop redelivered callbacks
+	CallbackID string
+	Type       ProcessorEventType
+	Reference  string
+	Status     PaymentStatus
+	Amount     float64
+	Code       string
+	Message    string
+}
+
+// CallbackResult records what processing a callback did
+type CallbackResult string
+
+const (
+	CallbackResultApplied CallbackResult = "APPLIED"
+	CallbackResultIgnored CallbackResult = "IGNORED"
+	CallbackResultFailed  CallbackResult = "FAILED"
+)
+
+// ErrDuplicateCallback is returned when a processor delivers a callback ID that was already received
+var ErrDuplicateCallback = errors.New("duplicate callback")
+
+// ProcessorCallback is a received callback, kept with its raw payload for replay and debugging
+type ProcessorCallback struct {
+	ID          string
+	Processor   string
+	Event       ProcessorEvent
+	Payload     string
+	PaymentID   string
+	Result      CallbackResult
+	Error       string
+	ReceivedAt  time.Time
+	ProcessedAt *time.Time
+}
+
+// CallbackRepository stores processor callbacks
+type CallbackRepository interface {
+	// Save stores a new callback, returning ErrDuplicateCallback if the processor already sent its ID
+	Save(ctx context.Context, callback *ProcessorCallback) error
+	Update(ctx context.Context, callback *ProcessorCallback) error
+	GetByID(ctx context.Context, id string) (*ProcessorCallback, error)
+	// List returns the most recent callbacks first, optionally for one processor
+	List(ctx context.Context, processor string, limit int) ([]*ProcessorCallback, error)
+}
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrNoCreditTransfers is returned when no payment is ready to be sent in a credit transfer file
+	ErrNoCreditTransfers = errors.New("no payments are ready for a credit transfer file")
+	// ErrCreditTransferConflict is returned when a payment of a new file changed status before the
+	// file was stored; nothing is stored and the file can be created again
+	ErrCreditTransferConflict = errors.New("payments changed while the credit transfer file was created")
+)
+
+// CreditTransferFile is an ISO 20022 pain.001 payment initiation file sent to the bank
+type CreditTransferFile struct {
+	// MessageID identifies the file towards the bank and is stored on its payments
+	MessageID            string    `json:"messageId"`
+	CreatedAt            time.Time `json:"createdAt"`
+	NumberOfTransactions int       `json:"numberOfTransactions"`
+	// ControlSum is the total of all amounts in the file as a decimal string
+	ControlSum string   `json:"controlSum"`
+	PaymentIDs []string `json:"paymentIds"`
+	// Document is the XML content, kept so a lost file can be downloaded again
+	Document []byte `json:"-"`
+}
+
+// CreditTransferRepository stores credit transfer files and submits their payments
+type CreditTransferRepository interface {
+	// PendingCreditTransfers returns pending euro bank account payments no processor handles and
+	// whose risk assessment, if any, is approved, in creation order
+	PendingCreditTransfers(ctx context.Context) ([]*Payment, error)
+	// CreateFile stores the file and moves its payments from PENDING to SUBMITTED in one
+	// transaction. It returns ErrCreditTransferConflict if any of them is no longer pending.
+	CreateFile(ctx context.Context, file *CreditTransferFile) error
+	GetFile(ctx context.Context, messageID string) (*CreditTransferFile, error)
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrCustomerNotFound is returned when a customer does not exist
+	ErrCustomerNotFound = errors.New("customer not found")
+	// ErrCustomerErased is returned when changing or charging a customer whose data was erased
+	ErrCustomerErased = errors.New("customer has been erased")
+	// ErrCustomerHasPayments is returned when deleting a customer that payments refer to
+	ErrCustomerHasPayments = errors.New("customer has payments and can only be erased")
+)
+
+// Customer is a saved payer profile that payments can refer to
+type Customer struct {
+	ID    string `json:"id"`
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+	// ExternalReference is the customer's ID in the merchant's own systems
+	ExternalReference string            `json:"externalReference,omitempty"`
+	Metadata          map[string]string `json:"metadata,omitempty"`
+	// ErasedAt is set when the customer's personal data was erased on request
+	ErasedAt  *time.Time `json:"erasedAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// Erase removes the customer's personal data. The customer keeps its ID so its payments still
+// refer to it.
+func (c *Customer) Erase() {
+	now := time.Now()
+	c.Name = ""
+	c.Email = ""
+	c.ExternalReference = ""
+	c.Metadata = nil
+	c.ErasedAt = &now
+	c.UpdatedAt = now
+}
+
+// CustomerFilter selects customers; zero fields are ignored
+type CustomerFilter struct {
+	Email             string
+	ExternalReference string
+}
+
+// CustomerRepository stores customers and reads their payments
+type CustomerRepository interface {
+	Create(ctx context.Context, customer *Customer) error
+	// GetByID returns ErrCustomerNotFound if the customer does not exist
+	GetByID(ctx context.Context, id string) (*Customer, error)
+	// GetByIDs returns the customers that exist among ids, in no particular order
+	GetByIDs(ctx context.Context, ids []string) ([]*Customer, error)
+	// List returns matching customers, oldest first
+	List(ctx context.Context, filter CustomerFilter) ([]*Customer, error)
+	Update(ctx context.Context, customer *Customer) error
+	// Erase saves an erased customer and erases the payer data of its payments in one transaction
+	Erase(ctx context.Context, customer *Customer) error
+	Delete(ctx context.Context, id string) error
+	// Payments returns one page of each customer's payments, newest first, by customer ID.
+	// Customers without payments map to an empty list.
+	Payments(ctx context.Context, customerIDs []string, page PaymentPage) (map[string]*PaymentList, error)
+}
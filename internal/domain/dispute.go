@@ -0,0 +1,144 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DisputeStatus represents the stage of a dispute
+type DisputeStatus string
+
+const (
+	DisputeStatusOpen        DisputeStatus = "OPEN"
+	DisputeStatusUnderReview DisputeStatus = "UNDER_REVIEW"
+	DisputeStatusWon         DisputeStatus = "WON"
+	DisputeStatusLost        DisputeStatus = "LOST"
+)
+
+// ErrDisputeClosed is returned when changing a dispute that has been resolved
+var ErrDisputeClosed = errors.New("dispute is already resolved")
+
+// EvidenceFile is an attachment stored on disk; Path is relative to the evidence directory
+type EvidenceFile struct {
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+	Path        string `json:"path"`
+}
+
+// DisputeEvidence is one submission of evidence
+type DisputeEvidence struct {
+	ID          string         `json:"id"`
+	Text        string         `json:"text,omitempty"`
+	Files       []EvidenceFile `json:"files,omitempty"`
+	SubmittedBy string         `json:"submittedBy"`
+	SubmittedAt time.Time      `json:"submittedAt"`
+}
+
+// Dispute is a chargeback or inquiry raised against a completed payment
+type Dispute struct {
+	ID             string            `json:"id"`
+	PaymentID      string            `json:"paymentId"`
+	ReasonCode     string            `json:"reasonCode"`
+	Amount         float64           `json:"amount"`
+	Currency       string            `json:"currency"`
+	Status         DisputeStatus     `json:"status"`
+	EvidenceDueAt  time.Time         `json:"evidenceDueAt"`
+	Evidence       []DisputeEvidence `json:"evidence,omitempty"`
+	ResolutionNote string            `json:"resolutionNote,omitempty"`
+	ResolvedAt     *time.Time        `json:"resolvedAt,omitempty"`
+	CreatedAt      time.Time         `json:"createdAt"`
+	UpdatedAt      time.Time         `json:"updatedAt"`
+}
+
+// DisputableAmount is the part of a payment that can be charged back or refunded: the amount
+// less refunds and the lost amount, the total of its lost disputes
+func (p *Payment) DisputableAmount(lost float64) float64 {
+	return p.Amount - p.RefundedAmount - lost
+}
+
+// NewDispute opens a dispute against a completed payment. Refunded or already charged back money
+// cannot be disputed, so a payment refunded or charged back in full cannot be disputed at all.
+// lost is the total of the payment's lost disputes.
+func NewDispute(payment *Payment, lost float64, reasonCode string, amount float64, evidenceDueAt time.Time) (*Dispute, error) {
+	if payment.Status != PaymentStatusCompleted && payment.Status != PaymentStatusRefunded {
+		return nil, fmt.Errorf("only completed payments can be disputed, payment is %s", payment.Status)
+	}
+	disputable := payment.DisputableAmount(lost)
+	if disputable < 0.005 {
+		return nil, errors.New("payment was refunded or charged back in full and cannot be disputed")
+	}
+	if amount <= 0 {
+		return nil, errors.New("dispute amount must be greater than 0")
+	}
+	if amount > disputable+0.005 {
+		return nil, fmt.Errorf("dispute amount exceeds the payment amount less refunds and lost disputes of %.2f", disputable)
+	}
+
+	now := time.Now()
+	return &Dispute{
+		ID:            uuid.New().String(),
+		PaymentID:     payment.ID,
+		ReasonCode:    reasonCode,
+		Amount:        amount,
+		Currency:      payment.Currency,
+		Status:        DisputeStatusOpen,
+		EvidenceDueAt: evidenceDueAt,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}, nil
+}
+
+// Active reports whether the dispute is still awaiting a decision
+func (d *Dispute) Active() bool {
+	return d.Status == DisputeStatusOpen || d.Status == DisputeStatusUnderReview
+}
+
+// AddEvidence records evidence and puts the dispute under review
+func (d *Dispute) AddEvidence(evidence DisputeEvidence) error {
+	if !d.Active() {
+		return ErrDisputeClosed
+	}
+	d.Evidence = append(d.Evidence, evidence)
+	d.Status = DisputeStatusUnderReview
+	d.UpdatedAt = time.Now()
+	return nil
+}
+
+// Resolve closes the dispute as won or lost
+func (d *Dispute) Resolve(won bool, note string) error {
+	if !d.Active() {
+		return ErrDisputeClosed
+	}
+	now := time.Now()
+	d.Status = DisputeStatusLost
+	if won {
+		d.Status = DisputeStatusWon
+	}
+	d.ResolutionNote = note
+	d.ResolvedAt = &now
+	d.UpdatedAt = now
+	return nil
+}
+
+// DisputeFilter selects disputes; zero fields are ignored
+type DisputeFilter struct {
+	PaymentID string
+	Statuses  []DisputeStatus
+	// DueBefore selects disputes whose evidence is due at or before the given time
+	DueBefore *time.Time
+}
+
+// DisputeRepository defines the interface for dispute data operations
+type DisputeRepository interface {
+	Create(ctx context.Context, dispute *Dispute) error
+	GetByID(ctx context.Context, id string) (*Dispute, error)
+	Update(ctx context.Context, dispute *Dispute) error
+	// List returns matching disputes ordered by evidence due date
+	List(ctx context.Context, filter DisputeFilter) ([]*Dispute, error)
+}
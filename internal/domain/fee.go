@@ -0,0 +1,56 @@
+package domain
+
+import "time"
+
+// Fee accounts
+const (
+	// AccountMerchantPayable holds what we owe merchants for their payments; fees reduce it
+	AccountMerchantPayable = "liabilities:merchant_payable"
+	// AccountFeeRevenue is the default revenue account for fees
+	AccountFeeRevenue = "revenue:fees"
+)
+
+// FeeEvent is the point in a payment's life at which a fee is charged
+type FeeEvent string
+
+const (
+	// FeeEventCreated charges the fee when the payment is created
+	FeeEventCreated FeeEvent = "CREATED"
+	// FeeEventCaptured charges the fee when the payment is captured
+	FeeEventCaptured FeeEvent = "CAPTURED"
+)
+
+// IsValid reports whether e is a known fee event
+func (e FeeEvent) IsValid() bool {
+	return e == FeeEventCreated || e == FeeEventCaptured
+}
+
+// Fee is one fee line item charged on a payment, in the payment's currency
+type Fee struct {
+	// Name identifies the fee schedule, such as "processing"
+	Name   string   `json:"name"`
+	Event  FeeEvent `json:"event"`
+	Amount Money    `json:"amount"`
+	// Account is the revenue account the fee is posted to
+	Account   string    `json:"account"`
+	ChargedAt time.Time `json:"chargedAt"`
+}
+
+// HasFees reports whether fees were charged on the payment for the event
+func (p *Payment) HasFees(event FeeEvent) bool {
+	for _, fee := range p.Fees {
+		if fee.Event == event {
+			return true
+		}
+	}
+	return false
+}
+
+// NetAmount is the payment amount less its fees
+func (p *Payment) NetAmount() Money {
+	net := MoneyFromFloat(p.Amount, p.Currency)
+	for _, fee := range p.Fees {
+		net.MinorUnits -= fee.Amount.MinorUnits
+	}
+	return net
+}
@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrRateNotFound is returned when no exchange rate is known for a currency pair
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+// FXRate is a snapshot of an exchange rate: one unit of Base buys Rate units of Quote
+type FXRate struct {
+	Base  string `json:"base"`
+	Quote string `json:"quote"`
+	// Rate is an exact decimal such as "1.0842", so a conversion can be repeated with the same result
+	Rate string `json:"rate"`
+	// AsOf is when the rate was published
+	AsOf time.Time `json:"asOf"`
+	// Source names where the rate came from, such as the rates file
+	Source string `json:"source,omitempty"`
+}
+
+// Settlement is the amount a payment settles for in another currency, with the rate used
+type Settlement struct {
+	Amount      Money     `json:"amount"`
+	Rate        FXRate    `json:"rate"`
+	ConvertedAt time.Time `json:"convertedAt"`
+}
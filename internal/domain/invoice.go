@@ -0,0 +1,199 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// InvoiceStatus is the payment state of an invoice. It is derived from the amount paid and the
+// due date, never stored.
+type InvoiceStatus string
+
+const (
+	// InvoiceStatusOpen marks an invoice nothing was paid on that is not yet overdue
+	InvoiceStatusOpen InvoiceStatus = "OPEN"
+	// InvoiceStatusPartiallyPaid marks an invoice paid in part that is not yet overdue
+	InvoiceStatusPartiallyPaid InvoiceStatus = "PARTIALLY_PAID"
+	// InvoiceStatusPaid marks an invoice paid in full
+	InvoiceStatusPaid InvoiceStatus = "PAID"
+	// InvoiceStatusOverdue marks an invoice with an amount due after its due date
+	InvoiceStatusOverdue InvoiceStatus = "OVERDUE"
+)
+
+// IsValid reports whether s is a known invoice status
+func (s InvoiceStatus) IsValid() bool {
+	switch s {
+	case InvoiceStatusOpen, InvoiceStatusPartiallyPaid, InvoiceStatusPaid, InvoiceStatusOverdue:
+		return true
+	}
+	return false
+}
+
+var (
+	// ErrInvoiceNotFound is returned when an invoice does not exist
+	ErrInvoiceNotFound = errors.New("invoice not found")
+	// ErrInvoiceNumberTaken is returned when creating an invoice with the number of another
+	ErrInvoiceNumberTaken = errors.New("invoice number is already used")
+	// ErrInvoiceAllocationNotFound is returned when an invoice allocation does not exist
+	ErrInvoiceAllocationNotFound = errors.New("invoice allocation not found")
+	// ErrInvoiceOverallocated is returned when an allocation exceeds the amount due of its invoice
+	ErrInvoiceOverallocated = errors.New("allocation exceeds the amount due of the invoice")
+	// ErrPaymentOverallocated is returned when the allocations of a payment exceed its amount
+	// less refunds
+	ErrPaymentOverallocated = errors.New("allocations exceed the unallocated amount of the payment")
+)
+
+// InvoiceLine is one item of an invoice
+type InvoiceLine struct {
+	Description string `json:"description"`
+	// Category is the product category the line was taxed as, if tax was calculated
+	Category string `json:"category,omitempty"`
+	// Quantity is a decimal such as "1" or "2.5"
+	Quantity  string `json:"quantity"`
+	UnitPrice Money  `json:"unitPrice"`
+	// Amount is Quantity times UnitPrice, rounded to the minor unit, less any tax the unit
+	// price includes
+	Amount Money `json:"amount"`
+	Tax    Money `json:"tax"`
+}
+
+// Invoice is a bill issued to a customer that payments are applied to. Amounts are in the
+// invoice's currency.
+type Invoice struct {
+	ID     string `json:"id"`
+	Number string `json:"number"`
+	// CustomerID refers to the saved customer the invoice was issued to, if any
+	CustomerID string        `json:"customerId,omitempty"`
+	Currency   string        `json:"currency"`
+	Lines      []InvoiceLine `json:"lines"`
+	Subtotal   Money         `json:"subtotal"`
+	Tax        Money         `json:"tax"`
+	Total      Money         `json:"total"`
+	// AmountPaid is the sum of the allocations applied to the invoice
+	AmountPaid Money `json:"amountPaid"`
+	// TaxBreakdown is set when the tax of the lines was calculated rather than given
+	TaxBreakdown *TaxBreakdown `json:"taxBreakdown,omitempty"`
+	// DueDate is the last day the invoice can be paid on time, at midnight UTC
+	DueDate     time.Time            `json:"dueDate"`
+	Allocations []*InvoiceAllocation `json:"allocations,omitempty"`
+	CreatedAt   time.Time            `json:"createdAt"`
+	UpdatedAt   time.Time            `json:"updatedAt"`
+}
+
+// AmountDue is the part of the total not paid yet
+func (i *Invoice) AmountDue() Money {
+	return Money{MinorUnits: i.Total.MinorUnits - i.AmountPaid.MinorUnits, Currency: i.Currency}
+}
+
+// DaysPastDue is the number of days since the due date at now; invoices not yet overdue have 0
+func (i *Invoice) DaysPastDue(now time.Time) int {
+	days := int(now.Sub(i.DueDate) / (24 * time.Hour))
+	return max(days, 0)
+}
+
+// Status derives the invoice's status at now. An invoice becomes overdue the day after its
+// due date.
+func (i *Invoice) Status(now time.Time) InvoiceStatus {
+	switch {
+	case i.AmountDue().MinorUnits <= 0:
+		return InvoiceStatusPaid
+	case i.DaysPastDue(now) > 0:
+		return InvoiceStatusOverdue
+	case i.AmountPaid.MinorUnits > 0:
+		return InvoiceStatusPartiallyPaid
+	default:
+		return InvoiceStatusOpen
+	}
+}
+
+// InvoiceAllocation applies part of a payment to an invoice
+type InvoiceAllocation struct {
+	ID        string    `json:"id"`
+	InvoiceID string    `json:"invoiceId"`
+	PaymentID string    `json:"paymentId"`
+	Amount    Money     `json:"amount"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// AgingBucket totals the amounts due of the invoices overdue by FromDays to ToDays days.
+// The first bucket holds the invoices not yet overdue and the last has no ToDays.
+type AgingBucket struct {
+	Label    string `json:"label"`
+	FromDays int    `json:"fromDays"`
+	ToDays   *int   `json:"toDays,omitempty"`
+	Amount   Money  `json:"amount"`
+	Count    int    `json:"count"`
+}
+
+// InvoiceAgingReport sorts the unpaid invoices of one currency into aging buckets
+type InvoiceAgingReport struct {
+	Currency string         `json:"currency"`
+	AsOf     time.Time      `json:"asOf"`
+	Buckets  []*AgingBucket `json:"buckets"`
+	Total    Money          `json:"total"`
+	Count    int            `json:"count"`
+}
+
+// NewInvoiceAgingReport creates an empty report with the buckets current, 1-30, 31-60, 61-90
+// and over 90 days past due
+func NewInvoiceAgingReport(currency string, asOf time.Time) *InvoiceAgingReport {
+	report := &InvoiceAgingReport{Currency: currency, AsOf: asOf, Total: Money{Currency: currency}}
+	bounds := []struct {
+		label    string
+		from, to int
+	}{{"current", 0, 0}, {"1-30", 1, 30}, {"31-60", 31, 60}, {"61-90", 61, 90}, {"90+", 91, -1}}
+	for _, bound := range bounds {
+		bucket := &AgingBucket{Label: bound.label, FromDays: bound.from, Amount: Money{Currency: currency}}
+		if bound.to >= 0 {
+			to := bound.to
+			bucket.ToDays = &to
+		}
+		report.Buckets = append(report.Buckets, bucket)
+	}
+	return report
+}
+
+// Add counts the amount due of an unpaid invoice in its bucket
+func (r *InvoiceAgingReport) Add(invoice *Invoice) {
+	days := invoice.DaysPastDue(r.AsOf)
+	for _, bucket := range r.Buckets {
+		if days >= bucket.FromDays && (bucket.ToDays == nil || days <= *bucket.ToDays) {
+			bucket.Amount.MinorUnits += invoice.AmountDue().MinorUnits
+			bucket.Count++
+			break
+		}
+	}
+	r.Total.MinorUnits += invoice.AmountDue().MinorUnits
+	r.Count++
+}
+
+// InvoiceFilter selects invoices; zero fields are ignored
+type InvoiceFilter struct {
+	CustomerID string
+	Currency   string
+	// Unpaid selects invoices with an amount due
+	Unpaid bool
+}
+
+// InvoiceRepository stores invoices and the payments applied to them
+type InvoiceRepository interface {
+	// Create returns ErrInvoiceNumberTaken if another invoice has the same number
+	Create(ctx context.Context, invoice *Invoice) error
+	// GetByID returns the invoice with its allocations, or ErrInvoiceNotFound
+	GetByID(ctx context.Context, id string) (*Invoice, error)
+	// List returns matching invoices with their allocations, earliest due first
+	List(ctx context.Context, filter InvoiceFilter) ([]*Invoice, error)
+	// Allocate stores allocations of one payment and adds them to the amounts paid of their
+	// invoices, all or none. It fails with ErrInvoiceOverallocated if an invoice would be paid
+	// more than its total, and with ErrPaymentOverallocated if all allocations of the payment
+	// would exceed available.
+	Allocate(ctx context.Context, allocations []*InvoiceAllocation, available Money) error
+	// Release shrinks or removes the newest allocations of a payment until they total at most
+	// available, and subtracts what was released from the amounts paid of their invoices
+	Release(ctx context.Context, paymentID string, available Money) error
+	// Deallocate removes an allocation and subtracts it from its invoice's amount paid
+	Deallocate(ctx context.Context, id string) (*InvoiceAllocation, error)
+	// PaymentAllocations returns the allocations of a payment, oldest first
+	PaymentAllocations(ctx context.Context, paymentID string) ([]*InvoiceAllocation, error)
+}
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Ledger accounts
+const (
+	// AccountProcessorBalance holds funds collected by processors on our behalf
+	AccountProcessorBalance = "assets:processor_balance"
+	// AccountChargebacks is contra-revenue for sales reversed by lost disputes
+	AccountChargebacks = "revenue:chargebacks"
+)
+
+// ErrDuplicateJournalEntry is returned when posting an entry whose ID was already posted
+var ErrDuplicateJournalEntry = errors.New("journal entry already posted")
+
+// Posting is one line of a journal entry; positive amounts are debits, negative amounts credits
+type Posting struct {
+	Account  string  `json:"account"`
+	Currency string  `json:"currency"`
+	Amount   float64 `json:"amount"`
+}
+
+// JournalEntry is a balanced set of postings. Its ID doubles as an idempotency key,
+// so an entry derived from a business event is posted at most once.
+type JournalEntry struct {
+	ID          string    `json:"id"`
+	PaymentID   string    `json:"paymentId,omitempty"`
+	Description string    `json:"description"`
+	Postings    []Posting `json:"postings"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// Validate checks that the entry has postings and balances in every currency
+func (e *JournalEntry) Validate() error {
+	if e.ID == "" {
+		return errors.New("journal entry ID is required")
+	}
+	if len(e.Postings) < 2 {
+		return errors.New("journal entry needs at least two postings")
+	}
+
+	totals := make(map[string]float64)
+	for _, posting := range e.Postings {
+		if posting.Account == "" || posting.Currency == "" {
+			return errors.New("postings need an account and a currency")
+		}
+		totals[posting.Currency] += posting.Amount
+	}
+	for currency, total := range totals {
+		if math.Abs(total) >= 0.005 {
+			return fmt.Errorf("journal entry does not balance in %s: off by %.2f", currency, total)
+		}
+	}
+	return nil
+}
+
+// LedgerRepository stores journal entries
+type LedgerRepository interface {
+	// Post stores a validated entry, returning ErrDuplicateJournalEntry if its ID exists
+	Post(ctx context.Context, entry *JournalEntry) error
+	EntriesForPayment(ctx context.Context, paymentID string) ([]*JournalEntry, error)
+	// Balance returns the sum of postings to an account in a currency
+	Balance(ctx context.Context, account, currency string) (float64, error)
+}
@@ -0,0 +1,195 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CurrencyExponents lists the ISO 4217 currencies whose minor unit is not a hundredth,
+// mapped to their number of decimal places; every other currency has two
+var CurrencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// CurrencyExponent returns the number of decimal places of a currency's minor unit
+func CurrencyExponent(currency string) int {
+	if exponent, ok := CurrencyExponents[currency]; ok {
+		return exponent
+	}
+	return 2
+}
+
+// MinorUnitScale returns how many minor units make one major unit of a currency
+func MinorUnitScale(currency string) int64 {
+	scale := int64(1)
+	for i := 0; i < CurrencyExponent(currency); i++ {
+		scale *= 10
+	}
+	return scale
+}
+
+// Money is an exact amount of a currency, held as an integer number of minor units such as cents
+type Money struct {
+	MinorUnits int64  `json:"minorUnits"`
+	Currency   string `json:"currency"`
+}
+
+// MoneyFromFloat rounds a floating point amount to the nearest minor unit of currency
+func MoneyFromFloat(amount float64, currency string) Money {
+	return Money{MinorUnits: int64(math.Round(amount * float64(MinorUnitScale(currency)))), Currency: currency}
+}
+
+// RoundMoney rounds an exact amount in major units half away from zero to a whole minor unit
+// of currency. It fails if the result does not fit.
+func RoundMoney(amount *big.Rat, currency string) (Money, error) {
+	units := new(big.Rat).Mul(amount, new(big.Rat).SetInt64(MinorUnitScale(currency)))
+	quotient, remainder := new(big.Int).QuoRem(new(big.Int).Abs(units.Num()), units.Denom(), new(big.Int))
+	if remainder.Lsh(remainder, 1).Cmp(units.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	if units.Sign() < 0 {
+		quotient.Neg(quotient)
+	}
+	if !quotient.IsInt64() {
+		return Money{}, fmt.Errorf("amount of %s is out of range", currency)
+	}
+	return Money{MinorUnits: quotient.Int64(), Currency: currency}, nil
+}
+
+// ParseMoney parses a decimal amount such as "1234.5" exactly. It fails if the amount has
+// more decimal places than the currency's minor unit.
+func ParseMoney(amount, currency string) (Money, error) {
+	value := strings.TrimSpace(amount)
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(strings.TrimPrefix(value, "-"), "+")
+	whole, fraction, _ := strings.Cut(value, ".")
+	exponent := CurrencyExponent(currency)
+	if trimmed := strings.TrimRight(fraction, "0"); len(trimmed) > exponent {
+		return Money{}, fmt.Errorf("amount %q has more than %d decimal places for %s", amount, exponent, currency)
+	}
+	if whole == "" && fraction == "" || strings.Trim(whole+fraction, "0123456789") != "" {
+		return Money{}, fmt.Errorf("invalid amount %q", amount)
+	}
+	if len(fraction) > exponent {
+		fraction = fraction[:exponent]
+	}
+	digits := strings.TrimLeft(whole+fraction+strings.Repeat("0", exponent-len(fraction)), "0")
+	if digits == "" {
+		return Money{Currency: currency}, nil
+	}
+	units, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("amount %q is out of range", amount)
+	}
+	if negative {
+		units = -units
+	}
+	return Money{MinorUnits: units, Currency: currency}, nil
+}
+
+// FormatDecimal prints a terminating decimal exactly, with no trailing zeros, such as "5.5"
+func FormatDecimal(value *big.Rat) string {
+	places := 0
+	scaled := new(big.Rat).Set(value)
+	for !scaled.IsInt() {
+		scaled.Mul(scaled, big.NewRat(10, 1))
+		places++
+	}
+	return value.FloatString(places)
+}
+
+// Decimal formats the amount with the currency's decimal places, such as "1234.50"
+func (m Money) Decimal() string {
+	exponent := CurrencyExponent(m.Currency)
+	sign, units := "", uint64(m.MinorUnits)
+	if m.MinorUnits < 0 {
+		sign, units = "-", uint64(-(m.MinorUnits+1))+1
+	}
+	digits := strconv.FormatUint(units, 10)
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
+
+// Float64 returns the amount in major units, for the float amounts of payments and postings
+func (m Money) Float64() float64 {
+	return float64(m.MinorUnits) / float64(MinorUnitScale(m.Currency))
+}
+
+// String formats the amount followed by its currency, such as "1234.50 EUR"
+func (m Money) String() string {
+	return fmt.Sprintf("%s %s", m.Decimal(), m.Currency)
+}
+
+// DivideRounded divides the amount by n, rounding half away from zero to a whole minor unit
+func (m Money) DivideRounded(n int64) Money {
+	if n <= 0 {
+		return Money{Currency: m.Currency}
+	}
+	quotient, remainder := m.MinorUnits/n, m.MinorUnits%n
+	if remainder < 0 {
+		remainder = -remainder
+	}
+	if remainder*2 >= n {
+		if m.MinorUnits < 0 {
+			quotient--
+		} else {
+			quotient++
+		}
+	}
+	return Money{MinorUnits: quotient, Currency: m.Currency}
+}
+
+// Allocate splits the amount into parts proportional to the weights, to the minor unit. The
+// parts add up to the amount exactly: minor units left over after rounding toward zero go to
+// the parts with the largest remainders, and ties go to the earlier part. Weights must not be
+// negative; if they are all zero, every part is zero.
+func (m Money) Allocate(weights ...*big.Rat) []Money {
+	if m.MinorUnits < 0 {
+		parts := Money{MinorUnits: -m.MinorUnits, Currency: m.Currency}.Allocate(weights...)
+		for i := range parts {
+			parts[i].MinorUnits = -parts[i].MinorUnits
+		}
+		return parts
+	}
+
+	parts := make([]Money, len(weights))
+	total := new(big.Rat)
+	for i, weight := range weights {
+		parts[i].Currency = m.Currency
+		total.Add(total, weight)
+	}
+	if total.Sign() == 0 {
+		return parts
+	}
+
+	remainders := make([]*big.Rat, len(weights))
+	order := make([]int, len(weights))
+	left := m.MinorUnits
+	for i, weight := range weights {
+		share := new(big.Rat).Mul(big.NewRat(m.MinorUnits, 1), weight)
+		share.Quo(share, total)
+		units := new(big.Int).Quo(share.Num(), share.Denom())
+		parts[i].MinorUnits = units.Int64()
+		remainders[i] = share.Sub(share, new(big.Rat).SetInt(units))
+		order[i] = i
+		left -= parts[i].MinorUnits
+	}
+
+	// The remainders add up to the units left, and each is below one unit
+	sort.SliceStable(order, func(a, b int) bool { return remainders[order[a]].Cmp(remainders[order[b]]) > 0 })
+	for i := int64(0); i < left; i++ {
+		parts[order[i]].MinorUnits++
+	}
+	return parts
+}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,12 +11,70 @@ import (
 type PaymentStatus string
 
 const (
-	PaymentStatusPending   PaymentStatus = "PENDING"
-	PaymentStatusCompleted PaymentStatus = "COMPLETED"
-	PaymentStatusFailed    PaymentStatus = "FAILED"
-	PaymentStatusCancelled PaymentStatus = "CANCELLED"
+	PaymentStatusPending    PaymentStatus = "PENDING"
+	PaymentStatusCompleted  PaymentStatus = "COMPLETED"
+	PaymentStatusFailed     PaymentStatus = "FAILED"
+	PaymentStatusCancelled  PaymentStatus = "CANCELLED"
+	PaymentStatusRejected   PaymentStatus = "REJECTED"
+	PaymentStatusAuthorized PaymentStatus = "AUTHORIZED"
+	PaymentStatusRefunded   PaymentStatus = "REFUNDED"
+	// PaymentStatusScreeningHold marks a payment held for sanctions review
+	PaymentStatusScreeningHold PaymentStatus = "SCREENING_HOLD"
+	// PaymentStatusRiskReview marks a payment held until an analyst reviews its risk assessment
+	PaymentStatusRiskReview PaymentStatus = "RISK_REVIEW"
+	// PaymentStatusScheduled marks a payment waiting for its execution date
+	PaymentStatusScheduled PaymentStatus = "SCHEDULED"
+	// PaymentStatusSubmitted marks a bank transfer sent to the bank in a credit transfer or ACH file
+	PaymentStatusSubmitted PaymentStatus = "SUBMITTED"
 )
 
+// IsValid reports whether s is a known payment status
+func (s PaymentStatus) IsValid() bool {
+	switch s {
+	case PaymentStatusPending, PaymentStatusCompleted, PaymentStatusFailed, PaymentStatusCancelled,
+		PaymentStatusRejected, PaymentStatusAuthorized, PaymentStatusRefunded,
+		PaymentStatusScreeningHold, PaymentStatusRiskReview, PaymentStatusScheduled, PaymentStatusSubmitted:
+		return true
+	}
+	return false
+}
+
+// RiskDecision represents the outcome of risk screening
+type RiskDecision string
+
+const (
+	RiskDecisionAllow  RiskDecision = "ALLOW"
+	RiskDecisionReview RiskDecision = "REVIEW"
+	RiskDecisionDeny   RiskDecision = "DENY"
+)
+
+// RiskAssessment holds the result of screening a payment against risk rules and any analyst
+// review of a REVIEW decision
+type RiskAssessment struct {
+	Score      int          `json:"score"`
+	Decision   RiskDecision `json:"decision"`
+	Reasons    []string     `json:"reasons"`
+	ReviewedBy string       `json:"reviewedBy,omitempty"`
+	ReviewNote string       `json:"reviewNote,omitempty"`
+	ReviewedAt *time.Time   `json:"reviewedAt,omitempty"`
+}
+
+// ErrNotOnRiskReview is returned when resolving the risk review of a payment that is not held
+var ErrNotOnRiskReview = errors.New("payment is not on risk review")
+
+// Approved reports whether the payment may proceed: the rules allowed it, or an analyst
+// approved it after review
+func (a *RiskAssessment) Approved() bool {
+	return a.Decision == RiskDecisionAllow || a.Decision == RiskDecisionReview && a.ReviewedAt != nil
+}
+
+// Party identifies a counterparty of a payment
+type Party struct {
+	Name    string `json:"name"`
+	Account string `json:"account,omitempty"`
+	Country string `json:"country,omitempty"`
+}
+
 // Payment represents a payment entity in the domain
 type Payment struct {
 	ID          string        `json:"id"`
@@ -23,8 +82,42 @@ type Payment struct {
 	Currency    string        `json:"currency"`
 	Description string        `json:"description"`
 	Status      PaymentStatus `json:"status"`
-	CreatedAt   time.Time     `json:"createdAt"`
-	UpdatedAt   time.Time     `json:"updatedAt"`
+	PayerID     string        `json:"payerId,omitempty"`
+	TenantID    string        `json:"tenantId,omitempty"`
+	// CustomerID refers to the saved customer who made the payment
+	CustomerID string `json:"customerId,omitempty"`
+	// Metadata and Tags are free-form labels, such as order IDs and campaign codes
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Tags     []string          `json:"tags,omitempty"`
+	// SubscriptionID is set on payments generated by a subscription
+	SubscriptionID string `json:"subscriptionId,omitempty"`
+	// ExecuteAt is when a scheduled payment is screened and processed
+	ExecuteAt *time.Time       `json:"executeAt,omitempty"`
+	Payer     *Party           `json:"payer,omitempty"`
+	Payee     *Party           `json:"payee,omitempty"`
+	Method    PaymentMethod    `json:"method,omitempty"`
+	Risk      *RiskAssessment  `json:"risk,omitempty"`
+	Screening *ScreeningResult `json:"screening,omitempty"`
+
+	Processor          string  `json:"processor,omitempty"`
+	ProcessorReference string  `json:"processorReference,omitempty"`
+	ProcessorResponse  string  `json:"processorResponse,omitempty"`
+	RefundedAmount     float64 `json:"refundedAmount"`
+	// Route lists the processors tried when the payment was authorized, in order
+	Route []RouteAttempt `json:"route,omitempty"`
+	// SubmissionID is the ID of the credit transfer or ACH file the payment was sent in
+	SubmissionID string `json:"submissionId,omitempty"`
+	// Settlement is set when the payment settles in a currency other than its own
+	Settlement *Settlement `json:"settlement,omitempty"`
+	// Fees are the fee line items charged on the payment so far
+	Fees []Fee `json:"fees,omitempty"`
+	// Splits divide the amount among recipients; their amounts add up to the payment amount
+	Splits []Split `json:"splits,omitempty"`
+	// Tax is the tax contained in the amount, when it was calculated
+	Tax *TaxBreakdown `json:"tax,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
 }
 
 // NewPayment creates a new payment with generated ID and timestamps
@@ -55,3 +148,68 @@ func (p *Payment) UpdateDetails(amount float64, currency, description string) {
 	p.Description = description
 	p.UpdatedAt = time.Now()
 }
+
+// ErasePayer removes the payer's personal data: the payer's name and account, and the holder
+// name and account number of the payment method. The card brand and last four digits are kept.
+func (p *Payment) ErasePayer() {
+	if p.Payer != nil {
+		p.Payer.Name = ""
+		p.Payer.Account = ""
+	}
+	switch method := p.Method.(type) {
+	case CardMethod:
+		method.HolderName = ""
+		p.Method = method
+	case BankAccountMethod:
+		method.HolderName = ""
+		method.IBAN = ""
+		method.AccountNumber = ""
+		p.Method = method
+	}
+}
+
+// ApplyRiskAssessment records a risk assessment. Denied payments are rejected, and payments
+// to review are held until an analyst resolves the review.
+func (p *Payment) ApplyRiskAssessment(assessment *RiskAssessment) {
+	p.Risk = assessment
+	if assessment != nil {
+		switch assessment.Decision {
+		case RiskDecisionDeny:
+			p.Status = PaymentStatusRejected
+		case RiskDecisionReview:
+			p.Status = PaymentStatusRiskReview
+		}
+	}
+	p.UpdatedAt = time.Now()
+}
+
+// ResolveRiskReview approves a payment held for risk review, returning it to PENDING, or
+// denies and rejects it
+func (p *Payment) ResolveRiskReview(approve bool, analyst, note string) error {
+	if p.Status != PaymentStatusRiskReview || p.Risk == nil {
+		return ErrNotOnRiskReview
+	}
+
+	now := time.Now()
+	p.Risk.ReviewedBy = analyst
+	p.Risk.ReviewNote = note
+	p.Risk.ReviewedAt = &now
+
+	if approve {
+		p.Status = PaymentStatusPending
+	} else {
+		p.Risk.Decision = RiskDecisionDeny
+		p.Status = PaymentStatusRejected
+	}
+	p.UpdatedAt = now
+	return nil
+}
+
+// releasedStatus is the status a payment returns to when a hold is released: PENDING, unless
+// its risk assessment still awaits review
+func (p *Payment) releasedStatus() PaymentStatus {
+	if p.Risk != nil && p.Risk.Decision == RiskDecisionReview && p.Risk.ReviewedAt == nil {
+		return PaymentStatusRiskReview
+	}
+	return PaymentStatusPending
+}
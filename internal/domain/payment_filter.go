@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// PaymentFilter selects payments; zero fields are ignored
+type PaymentFilter struct {
+	Statuses []PaymentStatus
+	Currency string
+	PayerID  string
+	TenantID string
+	// CustomerID selects the payments of a saved customer
+	CustomerID string
+	// Metadata selects payments having every given key and value; Tags those having every tag
+	Metadata map[string]string
+	Tags     []string
+	// CreatedFrom and CreatedTo bound the creation time; From is inclusive, To exclusive
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+}
+
+// Matches reports whether a payment passes the filter
+func (f PaymentFilter) Matches(payment *Payment) bool {
+	if len(f.Statuses) > 0 {
+		matched := false
+		for _, status := range f.Statuses {
+			if payment.Status == status {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if f.Currency != "" && payment.Currency != f.Currency {
+		return false
+	}
+	if f.PayerID != "" && payment.PayerID != f.PayerID {
+		return false
+	}
+	if f.TenantID != "" && payment.TenantID != f.TenantID {
+		return false
+	}
+	if f.CustomerID != "" && payment.CustomerID != f.CustomerID {
+		return false
+	}
+	for key, value := range f.Metadata {
+		if stored, ok := payment.Metadata[key]; !ok || stored != value {
+			return false
+		}
+	}
+	for _, tag := range f.Tags {
+		if !slices.Contains(payment.Tags, tag) {
+			return false
+		}
+	}
+	if f.CreatedFrom != nil && payment.CreatedAt.Before(*f.CreatedFrom) {
+		return false
+	}
+	if f.CreatedTo != nil && !payment.CreatedAt.Before(*f.CreatedTo) {
+		return false
+	}
+	return true
+}
+
+// PaymentQueryRepository reads filtered payments in creation order
+type PaymentQueryRepository interface {
+	List(ctx context.Context, filter PaymentFilter) ([]*Payment, error)
+	// Stream calls fn for every matching payment, reading one consistent snapshot in pages
+	// so memory use does not grow with the result. It stops at the first error fn returns.
+	Stream(ctx context.Context, filter PaymentFilter, fn func(*Payment) error) error
+}
+
+// PaymentCursor is a position in a list of payments ordered by creation time and ID
+type PaymentCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// PaymentPage selects up to First payments following After, or from the start without it
+type PaymentPage struct {
+	First int
+	After *PaymentCursor
+}
+
+// PaymentList is one page of payments
+type PaymentList struct {
+	Payments    []*Payment
+	HasNextPage bool
+	// TotalCount counts all payments of the list, not only those of the page
+	TotalCount int
+}
+
+// maxMetadataKey is the longest metadata key
+const maxMetadataKey = 40
+
+// ValidMetadataKey reports whether key has 1 to 40 letters, digits, '_', '-' or '.'. Keys are
+// written into JSON paths and index names, so no other characters are allowed.
+func ValidMetadataKey(key string) bool {
+	if key == "" || len(key) > maxMetadataKey {
+		return false
+	}
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,184 @@
+package domain
+
+import (
+	"strings"
+)
+
+// PaymentMethodType identifies how money moves for a payment
+type PaymentMethodType string
+
+const (
+	PaymentMethodTypeCard        PaymentMethodType = "CARD"
+	PaymentMethodTypeBankAccount PaymentMethodType = "BANK_ACCOUNT"
+	PaymentMethodTypeWallet      PaymentMethodType = "WALLET"
+)
+
+// BankScheme identifies the bank transfer scheme of a bank account method
+type BankScheme string
+
+const (
+	BankSchemeSEPA BankScheme = "SEPA"
+	BankSchemeACH  BankScheme = "ACH"
+)
+
+// BankAccountType is the kind of an ACH account, which selects the entry's transaction code
+type BankAccountType string
+
+const (
+	BankAccountTypeChecking BankAccountType = "CHECKING"
+	BankAccountTypeSavings  BankAccountType = "SAVINGS"
+)
+
+// AccountHolderType tells consumer from business ACH accounts, which are paid with different
+// standard entry class codes
+type AccountHolderType string
+
+const (
+	AccountHolderTypeIndividual AccountHolderType = "INDIVIDUAL"
+	AccountHolderTypeCompany    AccountHolderType = "COMPANY"
+)
+
+// PaymentMethod is implemented by the supported payment method kinds
+type PaymentMethod interface {
+	MethodType() PaymentMethodType
+}
+
+// CardMethod is a card payment; only the brand, last four digits and vault token of the PAN are kept
+type CardMethod struct {
+	Token       string `json:"token,omitempty"`
+	Brand       string `json:"brand"`
+	Last4       string `json:"last4"`
+	ExpiryMonth int    `json:"expiryMonth"`
+	ExpiryYear  int    `json:"expiryYear"`
+	HolderName  string `json:"holderName,omitempty"`
+}
+
+// MethodType returns CARD
+func (CardMethod) MethodType() PaymentMethodType { return PaymentMethodTypeCard }
+
+// BankAccountMethod is a SEPA (IBAN) or ACH (routing and account number) bank transfer
+type BankAccountMethod struct {
+	Scheme        BankScheme `json:"scheme"`
+	IBAN          string     `json:"iban,omitempty"`
+	BIC           string     `json:"bic,omitempty"`
+	RoutingNumber string     `json:"routingNumber,omitempty"`
+	AccountNumber string     `json:"accountNumber,omitempty"`
+	HolderName    string     `json:"holderName,omitempty"`
+	// AccountType and HolderType are set for ACH accounts only
+	AccountType BankAccountType   `json:"accountType,omitempty"`
+	HolderType  AccountHolderType `json:"holderType,omitempty"`
+}
+
+// MethodType returns BANK_ACCOUNT
+func (BankAccountMethod) MethodType() PaymentMethodType { return PaymentMethodTypeBankAccount }
+
+// WalletMethod is a digital wallet payment identified by a provider token
+type WalletMethod struct {
+	Provider string `json:"provider"`
+	Token    string `json:"token"`
+}
+
+// MethodType returns WALLET
+func (WalletMethod) MethodType() PaymentMethodType { return PaymentMethodTypeWallet }
+
+// ValidLuhn reports whether a digit string passes the Luhn (mod 10) checksum
+func ValidLuhn(number string) bool {
+	if len(number) < 2 {
+		return false
+	}
+
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		digit := int(number[i] - '0')
+		if digit < 0 || digit > 9 {
+			return false
+		}
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+
+	return sum%10 == 0
+}
+
+// ValidIBAN reports whether an IBAN has a valid structure and mod-97 checksum
+func ValidIBAN(iban string) bool {
+	iban = strings.ToUpper(strings.ReplaceAll(iban, " ", ""))
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+	for i, char := range iban {
+		switch {
+		case i < 2 && (char < 'A' || char > 'Z'):
+			return false
+		case i >= 2 && i < 4 && (char < '0' || char > '9'):
+			return false
+		case (char < '0' || char > '9') && (char < 'A' || char > 'Z'):
+			return false
+		}
+	}
+
+	// Move the country code and check digits to the end and compute the remainder piecewise
+	rearranged := iban[4:] + iban[:4]
+	remainder := 0
+	for _, char := range rearranged {
+		if char >= 'A' && char <= 'Z' {
+			remainder = (remainder*100 + int(char-'A'+10)) % 97
+		} else {
+			remainder = (remainder*10 + int(char-'0')) % 97
+		}
+	}
+
+	return remainder == 1
+}
+
+// ValidABARoutingNumber reports whether a US routing transit number passes the ABA checksum
+func ValidABARoutingNumber(routing string) bool {
+	if len(routing) != 9 {
+		return false
+	}
+
+	weights := [9]int{3, 7, 1, 3, 7, 1, 3, 7, 1}
+	sum := 0
+	for i, char := range routing {
+		if char < '0' || char > '9' {
+			return false
+		}
+		sum += int(char-'0') * weights[i]
+	}
+
+	return sum%10 == 0
+}
+
+// CardBrand detects the card network from the leading digits of a PAN
+func CardBrand(pan string) string {
+	prefix := func(n int) int {
+		if len(pan) < n {
+			return -1
+		}
+		value := 0
+		for _, char := range pan[:n] {
+			value = value*10 + int(char-'0')
+		}
+		return value
+	}
+
+	switch {
+	case strings.HasPrefix(pan, "4"):
+		return "VISA"
+	case prefix(2) >= 51 && prefix(2) <= 55, prefix(4) >= 2221 && prefix(4) <= 2720:
+		return "MASTERCARD"
+	case prefix(2) == 34 || prefix(2) == 37:
+		return "AMEX"
+	case prefix(4) == 6011 || prefix(2) == 65 || (prefix(3) >= 644 && prefix(3) <= 649):
+		return "DISCOVER"
+	default:
+		return "UNKNOWN"
+	}
+}
@@ -0,0 +1,33 @@
+package domain
+
+import "context"
+
+// PaymentSearch is a full-text search over payments. Every term must match the start of a
+// word in the payment's description, customer or references.
+type PaymentSearch struct {
+	Terms  []string
+	First  int
+	Offset int
+}
+
+// PaymentSearchHit is a payment found by a search
+type PaymentSearchHit struct {
+	Payment *Payment
+	// Rank is the relevance of the payment; higher ranks match better
+	Rank float64
+	// Snippet is an excerpt of the best matching text with matches wrapped in <mark> tags
+	Snippet string
+}
+
+// PaymentSearchResults is one page of search hits, best matches first
+type PaymentSearchResults struct {
+	Hits        []*PaymentSearchHit
+	HasNextPage bool
+	// TotalCount counts all matching payments, not only those of the page
+	TotalCount int
+}
+
+// PaymentSearchRepository searches payments by text
+type PaymentSearchRepository interface {
+	Search(ctx context.Context, search PaymentSearch) (*PaymentSearchResults, error)
+}
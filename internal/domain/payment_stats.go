@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// StatsDimension is a key payment statistics can be grouped by
+type StatsDimension string
+
+const (
+	StatsDimensionCurrency StatsDimension = "CURRENCY"
+	StatsDimensionStatus   StatsDimension = "STATUS"
+	// StatsDimensionDay and StatsDimensionMonth bucket payments by creation date in the query's time zone
+	StatsDimensionDay   StatsDimension = "DAY"
+	StatsDimensionMonth StatsDimension = "MONTH"
+)
+
+// IsValid reports whether the dimension is known
+func (d StatsDimension) IsValid() bool {
+	switch d {
+	case StatsDimensionCurrency, StatsDimensionStatus, StatsDimensionDay, StatsDimensionMonth:
+		return true
+	}
+	return false
+}
+
+// PaymentStatsQuery selects the payments to aggregate and how to group them
+type PaymentStatsQuery struct {
+	Filter  PaymentFilter
+	GroupBy []StatsDimension
+	// Location is the time zone in which days and months start
+	Location *time.Location
+}
+
+// Groups reports whether the query groups by dimension
+func (q PaymentStatsQuery) Groups(dimension StatsDimension) bool {
+	for _, d := range q.GroupBy {
+		if d == dimension {
+			return true
+		}
+	}
+	return false
+}
+
+// AmountStats aggregates the amounts of payments in one currency. Percentiles use the
+// nearest-rank method, so each is the amount of an actual payment.
+type AmountStats struct {
+	Currency string `json:"currency"`
+	Count    int64  `json:"count"`
+	Sum      Money  `json:"sum"`
+	// Average is rounded half away from zero to a whole minor unit
+	Average Money `json:"average"`
+	Min     Money `json:"min"`
+	Max     Money `json:"max"`
+	P50     Money `json:"p50"`
+	P90     Money `json:"p90"`
+	P95     Money `json:"p95"`
+	P99     Money `json:"p99"`
+}
+
+// PaymentStatsGroup is one group of a stats query. Keys the query does not group by are
+// empty; Day is formatted as 2006-01-02 and Month as 2006-01. Amounts holds one entry per
+// currency, since amounts of different currencies cannot be added up.
+type PaymentStatsGroup struct {
+	Currency string         `json:"currency,omitempty"`
+	Status   PaymentStatus  `json:"status,omitempty"`
+	Day      string         `json:"day,omitempty"`
+	Month    string         `json:"month,omitempty"`
+	Count    int64          `json:"count"`
+	Amounts  []*AmountStats `json:"amounts"`
+}
+
+// PaymentStatsRepository aggregates payments in the database
+type PaymentStatsRepository interface {
+	// PaymentStats returns the groups ordered by their keys, with amounts ordered by currency
+	PaymentStats(ctx context.Context, query PaymentStatsQuery) ([]*PaymentStatsGroup, error)
+}
@@ -0,0 +1,158 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PayoutStatus represents the stage of a payout to a merchant
+type PayoutStatus string
+
+const (
+	// PayoutStatusCreated marks a payout computed by a settlement batch and not yet sent
+	PayoutStatusCreated PayoutStatus = "CREATED"
+	// PayoutStatusSent marks a payout handed to the bank
+	PayoutStatusSent PayoutStatus = "SENT"
+	// PayoutStatusPaid marks a payout the merchant received
+	PayoutStatusPaid PayoutStatus = "PAID"
+	// PayoutStatusFailed marks a payout that will not arrive; its payments are settled again
+	PayoutStatusFailed PayoutStatus = "FAILED"
+)
+
+// IsValid reports whether s is a known payout status
+func (s PayoutStatus) IsValid() bool {
+	switch s {
+	case PayoutStatusCreated, PayoutStatusSent, PayoutStatusPaid, PayoutStatusFailed:
+		return true
+	}
+	return false
+}
+
+var (
+	// ErrSettlementBatchExists is returned when the batch for a cutoff was already created
+	ErrSettlementBatchExists = errors.New("settlement batch already exists")
+	// ErrPayoutNotFound is returned when a payout does not exist
+	ErrPayoutNotFound = errors.New("payout not found")
+	// ErrSettlementBatchNotFound is returned when a settlement batch does not exist
+	ErrSettlementBatchNotFound = errors.New("settlement batch not found")
+)
+
+// payoutTransitions lists the statuses a payout may move to from each status
+var payoutTransitions = map[PayoutStatus][]PayoutStatus{
+	PayoutStatusCreated: {PayoutStatusSent, PayoutStatusFailed},
+	PayoutStatusSent:    {PayoutStatusPaid, PayoutStatusFailed},
+}
+
+// SettlementBatch groups the payouts computed by one settlement run. Its ID is derived from
+// the cutoff day, so each day is settled at most once.
+type SettlementBatch struct {
+	ID string `json:"id"`
+	// CutoffAt is the end of the settled period; payments created before it are included
+	CutoffAt  time.Time `json:"cutoffAt"`
+	CreatedAt time.Time `json:"createdAt"`
+	Payouts   []*Payout `json:"payouts,omitempty"`
+}
+
+// SettlementBatchID is the ID of the batch settling payments created before cutoff
+func SettlementBatchID(cutoff time.Time) string {
+	return "SB" + cutoff.UTC().Format("20060102")
+}
+
+// Payout is what one merchant is paid in one currency by a settlement batch
+type Payout struct {
+	ID         string `json:"id"`
+	BatchID    string `json:"batchId"`
+	MerchantID string `json:"merchantId"`
+	Currency   string `json:"currency"`
+	// Gross is the total amount of the payments; Refunds, Chargebacks and Fees are deducted to give Net
+	Gross       Money        `json:"gross"`
+	Refunds     Money        `json:"refunds"`
+	Chargebacks Money        `json:"chargebacks"`
+	Fees        Money        `json:"fees"`
+	Net         Money        `json:"net"`
+	Status      PayoutStatus `json:"status"`
+	// Reference is the bank's reference for the transfer, set when the payout is sent
+	Reference     string       `json:"reference,omitempty"`
+	FailureReason string       `json:"failureReason,omitempty"`
+	Items         []PayoutItem `json:"items,omitempty"`
+	CreatedAt     time.Time    `json:"createdAt"`
+	UpdatedAt     time.Time    `json:"updatedAt"`
+	SentAt        *time.Time   `json:"sentAt,omitempty"`
+	PaidAt        *time.Time   `json:"paidAt,omitempty"`
+}
+
+// PayoutItem is one payment's contribution to a payout. A refund or lost dispute of a payment that
+// was already settled is deducted from a later payout by an item with only Refunds or Chargebacks set.
+type PayoutItem struct {
+	PayoutID    string `json:"payoutId"`
+	PaymentID   string `json:"paymentId"`
+	Gross       Money  `json:"gross"`
+	Refunds     Money  `json:"refunds"`
+	Chargebacks Money  `json:"chargebacks"`
+	Fees        Money  `json:"fees"`
+	Net         Money  `json:"net"`
+	// RefundedAmount is the payment's refunded amount when it was settled
+	RefundedAmount float64 `json:"refundedAmount"`
+	// ChargedBackAmount is the total of the payment's lost disputes when it was settled
+	ChargedBackAmount float64 `json:"chargedBackAmount"`
+}
+
+// Transition moves the payout to status, or fails if the lifecycle does not allow it
+func (p *Payout) Transition(status PayoutStatus) error {
+	for _, next := range payoutTransitions[p.Status] {
+		if next == status {
+			now := time.Now()
+			p.Status = status
+			p.UpdatedAt = now
+			switch status {
+			case PayoutStatusSent:
+				p.SentAt = &now
+			case PayoutStatusPaid:
+				p.PaidAt = &now
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("payout %s cannot move from %s to %s", p.ID, p.Status, status)
+}
+
+// SettlementCandidate is a payment due for settlement
+type SettlementCandidate struct {
+	Payment *Payment
+	// Settled reports whether the payment was included in a payout that has not failed
+	Settled bool
+	// SettledRefunds is the refunded amount already deducted from such payouts
+	SettledRefunds float64
+	// ChargedBack is the total of the payment's lost disputes, and SettledChargebacks the part
+	// of it already deducted from payouts that have not failed
+	ChargedBack        float64
+	SettledChargebacks float64
+}
+
+// PayoutFilter selects payouts; zero fields are ignored
+type PayoutFilter struct {
+	MerchantID string
+	BatchID    string
+	Statuses   []PayoutStatus
+}
+
+// SettlementRepository stores settlement batches and payouts
+type SettlementRepository interface {
+	// SettlementCandidates returns the completed or refunded payments of merchants created before
+	// cutoff that no payout covers yet, either because they were never in a payout that has not
+	// failed or because they were refunded or lost a dispute since. Oldest first.
+	SettlementCandidates(ctx context.Context, cutoff time.Time) ([]SettlementCandidate, error)
+	// CreateBatch stores the batch with its payouts and items in one transaction. It returns
+	// ErrSettlementBatchExists if a batch with the same ID exists.
+	CreateBatch(ctx context.Context, batch *SettlementBatch) error
+	// GetBatch returns the batch with its payouts and their items
+	GetBatch(ctx context.Context, id string) (*SettlementBatch, error)
+	// GetPayout returns the payout with its items
+	GetPayout(ctx context.Context, id string) (*Payout, error)
+	// UpdatePayout stores the status, reference and failure reason of a payout
+	UpdatePayout(ctx context.Context, payout *Payout) error
+	// ListPayouts returns matching payouts with their items, newest first
+	ListPayouts(ctx context.Context, filter PayoutFilter) ([]*Payout, error)
+}
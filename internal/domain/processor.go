@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"context"
+	"errors"
+)
+
+// ProcessorOutcome is the processor's answer to an operation
+type ProcessorOutcome string
+
+const (
+	ProcessorOutcomeApproved ProcessorOutcome = "APPROVED"
+	ProcessorOutcomeDeclined ProcessorOutcome = "DECLINED"
+	// ProcessorOutcomePending means the result will arrive asynchronously
+	ProcessorOutcomePending ProcessorOutcome = "PENDING"
+)
+
+// ErrProcessorUnavailable is returned by processors when the operation could not be attempted
+var ErrProcessorUnavailable = errors.New("payment processor unavailable")
+
+// AuthorizeRequest carries what a processor needs to authorize a payment
+type AuthorizeRequest struct {
+	PaymentID   string
+	Amount      float64
+	Currency    string
+	Description string
+	Method      PaymentMethod
+}
+
+// ProcessorResult is the response to a processor operation
+type ProcessorResult struct {
+	Reference string
+	Outcome   ProcessorOutcome
+	// Status is the processor's view of the payment, used by GetStatus
+	Status  PaymentStatus
+	Code    string
+	Message string
+}
+
+// Processor moves money through an acquirer or bank
+type Processor interface {
+	Name() string
+	Authorize(ctx context.Context, req AuthorizeRequest) (*ProcessorResult, error)
+	Capture(ctx context.Context, reference string, amount float64) (*ProcessorResult, error)
+	Refund(ctx context.Context, reference string, amount float64) (*ProcessorResult, error)
+	Void(ctx context.Context, reference string) (*ProcessorResult, error)
+	GetStatus(ctx context.Context, reference string) (*ProcessorResult, error)
+}
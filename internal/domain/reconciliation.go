@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// StatementFormat is the file format of a bank statement
+type StatementFormat string
+
+const (
+	// StatementFormatCAMT053 is an ISO 20022 camt.053 bank-to-customer statement
+	StatementFormatCAMT053 StatementFormat = "CAMT053"
+	// StatementFormatMT940 is a SWIFT MT940 customer statement message
+	StatementFormatMT940 StatementFormat = "MT940"
+)
+
+// EntryDirection tells whether a statement line added money to the account or took it out
+type EntryDirection string
+
+const (
+	EntryDirectionCredit EntryDirection = "CREDIT"
+	EntryDirectionDebit  EntryDirection = "DEBIT"
+)
+
+// StatementLineStatus is the reconciliation state of a statement line
+type StatementLineStatus string
+
+const (
+	StatementLineStatusUnmatched StatementLineStatus = "UNMATCHED"
+	// StatementLineStatusProposed marks a match suggested by the engine that awaits confirmation
+	StatementLineStatusProposed StatementLineStatus = "PROPOSED"
+	StatementLineStatusMatched  StatementLineStatus = "MATCHED"
+)
+
+// MatchMethod records how a statement line was matched to its payment
+type MatchMethod string
+
+const (
+	// MatchMethodAuto is a match the engine was confident enough to accept on its own
+	MatchMethodAuto      MatchMethod = "AUTO"
+	MatchMethodConfirmed MatchMethod = "CONFIRMED"
+	MatchMethodManual    MatchMethod = "MANUAL"
+)
+
+var (
+	// ErrDuplicateStatement is returned when importing a statement file that was imported before
+	ErrDuplicateStatement = errors.New("bank statement was already imported")
+	// ErrPaymentAlreadyReconciled is returned when matching a payment that another line is matched to
+	ErrPaymentAlreadyReconciled = errors.New("payment is already matched to another statement line")
+)
+
+// Statement is an imported bank statement
+type Statement struct {
+	ID     string          `json:"id"`
+	Format StatementFormat `json:"format"`
+	// Reference is the bank's identification of the statement
+	Reference string `json:"reference"`
+	Account   string `json:"account"`
+	Currency  string `json:"currency"`
+	// OpeningBalance and ClosingBalance are signed; a negative balance is a debit balance
+	OpeningBalance *Money `json:"openingBalance,omitempty"`
+	ClosingBalance *Money `json:"closingBalance,omitempty"`
+	// SHA256 is the checksum of the imported file; together with Reference it identifies
+	// the statement, so importing a file twice is rejected
+	SHA256     string           `json:"sha256"`
+	Lines      []*StatementLine `json:"lines"`
+	ImportedAt time.Time        `json:"importedAt"`
+}
+
+// StatementLine is one booked entry of a bank statement and its reconciliation state
+type StatementLine struct {
+	ID          string `json:"id"`
+	StatementID string `json:"statementId"`
+	// Index is the position of the line in its statement, starting at 1
+	Index       int            `json:"index"`
+	BookingDate time.Time      `json:"bookingDate"`
+	ValueDate   time.Time      `json:"valueDate"`
+	Amount      Money          `json:"amount"`
+	Direction   EntryDirection `json:"direction"`
+	// Reference is the end-to-end or customer reference the payer sent with the transfer
+	Reference           string `json:"reference,omitempty"`
+	BankReference       string `json:"bankReference,omitempty"`
+	Counterparty        string `json:"counterparty,omitempty"`
+	CounterpartyAccount string `json:"counterpartyAccount,omitempty"`
+	// Description holds the remittance information and other free text of the entry
+	Description string `json:"description,omitempty"`
+
+	Status    StatementLineStatus `json:"status"`
+	PaymentID string              `json:"paymentId,omitempty"`
+	// Confidence is the engine's score of the match between 0 and 1; manual matches have 1
+	Confidence  float64     `json:"confidence"`
+	MatchMethod MatchMethod `json:"matchMethod,omitempty"`
+	MatchedBy   string      `json:"matchedBy,omitempty"`
+	MatchedAt   *time.Time  `json:"matchedAt,omitempty"`
+	// RejectedPaymentIDs lists payments a reviewer rejected for this line; they are not proposed again
+	RejectedPaymentIDs []string `json:"rejectedPaymentIds,omitempty"`
+}
+
+// Rejected reports whether a reviewer rejected the payment as a match for the line
+func (l *StatementLine) Rejected(paymentID string) bool {
+	for _, id := range l.RejectedPaymentIDs {
+		if id == paymentID {
+			return true
+		}
+	}
+	return false
+}
+
+// StatementMatch pairs a statement line with the payment it most likely records
+type StatementMatch struct {
+	Line    *StatementLine
+	Payment *Payment
+	Score   float64
+	// Auto is set when the match is certain enough to be accepted without review
+	Auto bool
+}
+
+// StatementLineFilter selects statement lines; zero fields are ignored
+type StatementLineFilter struct {
+	StatementID string
+	Statuses    []StatementLineStatus
+}
+
+// ReconciliationRepository stores bank statements and the matches of their lines
+type ReconciliationRepository interface {
+	// CreateStatements stores the statements of one file with their lines in a transaction,
+	// returning ErrDuplicateStatement if any of them was stored before
+	CreateStatements(ctx context.Context, statements []*Statement) error
+	GetStatement(ctx context.Context, id string) (*Statement, error)
+	GetLine(ctx context.Context, id string) (*StatementLine, error)
+	// UpdateLine stores the reconciliation state of a line. It returns ErrPaymentAlreadyReconciled
+	// if the line's payment is proposed for or matched to another line.
+	UpdateLine(ctx context.Context, line *StatementLine) error
+	// ListLines returns matching lines ordered by booking date
+	ListLines(ctx context.Context, filter StatementLineFilter) ([]*StatementLine, error)
+	// UnmatchedPayments returns payments in the given statuses that no line is proposed for or
+	// matched to, in creation order
+	UnmatchedPayments(ctx context.Context, statuses []PaymentStatus) ([]*Payment, error)
+}
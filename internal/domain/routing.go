@@ -0,0 +1,34 @@
+package domain
+
+import "time"
+
+// RouteAttempt records one processor tried while routing a payment
+type RouteAttempt struct {
+	Processor string           `json:"processor"`
+	Rule      string           `json:"rule,omitempty"`
+	Outcome   ProcessorOutcome `json:"outcome,omitempty"`
+	// Error is set when the processor could not be used, e.g. during an outage or with an open circuit
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latencyMs"`
+}
+
+// CircuitState is the state of a processor's circuit breaker
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "CLOSED"
+	CircuitOpen     CircuitState = "OPEN"
+	CircuitHalfOpen CircuitState = "HALF_OPEN"
+)
+
+// ProcessorStats summarizes a processor's recent health
+type ProcessorStats struct {
+	Processor      string
+	Calls          int
+	Approvals      int
+	Declines       int
+	Errors         int
+	SuccessRate    float64
+	AverageLatency time.Duration
+	Circuit        CircuitState
+}
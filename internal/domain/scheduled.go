@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrPaymentNotScheduled is returned when rescheduling or cancelling a payment that is not SCHEDULED
+	ErrPaymentNotScheduled = errors.New("payment is not scheduled")
+	// ErrPaymentLeased is returned when changing a scheduled payment a runner is executing
+	ErrPaymentLeased = errors.New("scheduled payment is being executed")
+)
+
+// ScheduledPaymentRepository coordinates the execution of scheduled payments. A runner
+// leases due payments before executing them, so several instances never execute the same
+// payment and a payment leased by a runner that died is picked up once its lease expires.
+type ScheduledPaymentRepository interface {
+	// ClaimDue leases up to limit SCHEDULED payments due at now to owner until now+lease
+	ClaimDue(ctx context.Context, owner string, now time.Time, lease time.Duration, limit int) ([]*Payment, error)
+	// CompleteScheduled saves a claimed payment and releases its lease. It reports false,
+	// without saving, when owner no longer holds the lease.
+	CompleteScheduled(ctx context.Context, payment *Payment, owner string) (bool, error)
+	// UpdateScheduled saves a payment that is still SCHEDULED in storage and not leased at now.
+	// It returns ErrPaymentNotScheduled or ErrPaymentLeased otherwise.
+	UpdateScheduled(ctx context.Context, payment *Payment, now time.Time) error
+}
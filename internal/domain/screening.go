@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ScreeningStatus represents the outcome of sanctions screening
+type ScreeningStatus string
+
+const (
+	ScreeningStatusClear    ScreeningStatus = "CLEAR"
+	ScreeningStatusHit      ScreeningStatus = "HIT"
+	ScreeningStatusReleased ScreeningStatus = "RELEASED"
+	ScreeningStatusDenied   ScreeningStatus = "DENIED"
+)
+
+// PartyRole identifies which counterparty of a payment was screened
+type PartyRole string
+
+const (
+	PartyRolePayer PartyRole = "PAYER"
+	PartyRolePayee PartyRole = "PAYEE"
+)
+
+// ScreeningHit describes a counterparty name that matched a watchlist entry
+type ScreeningHit struct {
+	Role        PartyRole `json:"role"`
+	Name        string    `json:"name"`
+	MatchedName string    `json:"matchedName"`
+	EntryID     string    `json:"entryId"`
+	List        string    `json:"list"`
+	Score       float64   `json:"score"`
+}
+
+// ScreeningResult holds the screening outcome and any analyst review
+type ScreeningResult struct {
+	Status     ScreeningStatus `json:"status"`
+	Hits       []ScreeningHit  `json:"hits"`
+	ReviewedBy string          `json:"reviewedBy,omitempty"`
+	ReviewNote string          `json:"reviewNote,omitempty"`
+	ReviewedAt *time.Time      `json:"reviewedAt,omitempty"`
+}
+
+// ErrNotOnScreeningHold is returned when resolving a payment that is not held
+var ErrNotOnScreeningHold = errors.New("payment is not on screening hold")
+
+// ApplyScreening records a screening result and holds the payment when there are hits
+func (p *Payment) ApplyScreening(result *ScreeningResult) {
+	p.Screening = result
+	if result != nil && result.Status == ScreeningStatusHit {
+		p.Status = PaymentStatusScreeningHold
+	}
+	p.UpdatedAt = time.Now()
+}
+
+// ResolveScreeningHold releases a held payment back to PENDING, or to RISK_REVIEW if its risk
+// assessment still awaits review, or rejects it
+func (p *Payment) ResolveScreeningHold(release bool, analyst, note string) error {
+	if p.Status != PaymentStatusScreeningHold || p.Screening == nil {
+		return ErrNotOnScreeningHold
+	}
+
+	now := time.Now()
+	p.Screening.ReviewedBy = analyst
+	p.Screening.ReviewNote = note
+	p.Screening.ReviewedAt = &now
+
+	if release {
+		p.Screening.Status = ScreeningStatusReleased
+		p.Status = p.releasedStatus()
+	} else {
+		p.Screening.Status = ScreeningStatusDenied
+		p.Status = PaymentStatusRejected
+	}
+	p.UpdatedAt = now
+	return nil
+}
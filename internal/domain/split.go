@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Split is one recipient's share of a payment, such as a seller's proceeds or the platform's
+// commission
+type Split struct {
+	Recipient string `json:"recipient"`
+	Amount    Money  `json:"amount"`
+	// Percent is the requested percentage for percentage shares, as given
+	Percent string `json:"percent,omitempty"`
+	// Reversed is the part of the share taken back by refunds
+	Reversed Money `json:"reversed"`
+}
+
+// BalanceEntryKind is the reason a recipient's balance moved
+type BalanceEntryKind string
+
+const (
+	// BalanceEntryKindCredit credits a recipient's share of a captured payment
+	BalanceEntryKindCredit BalanceEntryKind = "CREDIT"
+	// BalanceEntryKindReversal takes back a recipient's part of a refund
+	BalanceEntryKindReversal BalanceEntryKind = "REVERSAL"
+)
+
+// BalanceEntry moves a recipient's balance. Its ID doubles as an idempotency key, so a movement
+// derived from a payment event is recorded at most once.
+type BalanceEntry struct {
+	ID        string           `json:"id"`
+	Recipient string           `json:"recipient"`
+	PaymentID string           `json:"paymentId"`
+	Kind      BalanceEntryKind `json:"kind"`
+	// Amount is positive for credits and negative for reversals
+	Amount    Money     `json:"amount"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// RecipientBalanceRepository tracks what split recipients are owed
+type RecipientBalanceRepository interface {
+	// Record stores the entries in one transaction; entries whose ID exists are skipped
+	Record(ctx context.Context, entries []BalanceEntry) error
+	// Balances returns the recipient's balance in each currency it has entries in, by currency
+	Balances(ctx context.Context, recipient string) ([]Money, error)
+	// Entries returns the recipient's entries, oldest first
+	Entries(ctx context.Context, recipient string) ([]BalanceEntry, error)
+}
@@ -0,0 +1,65 @@
+package domain
+
+import "fmt"
+
+// transitions lists the statuses each payment status may move to
+var transitions = map[PaymentStatus][]PaymentStatus{
+	PaymentStatusPending: {
+		PaymentStatusAuthorized,
+		PaymentStatusCompleted,
+		PaymentStatusFailed,
+		PaymentStatusCancelled,
+		PaymentStatusRejected,
+		PaymentStatusScreeningHold,
+		PaymentStatusRiskReview,
+		PaymentStatusSubmitted,
+	},
+	PaymentStatusScreeningHold: {PaymentStatusPending, PaymentStatusRiskReview, PaymentStatusRejected},
+	PaymentStatusRiskReview:    {PaymentStatusPending, PaymentStatusRejected},
+	// Scheduled payments are screened when they fall due, or cancelled before
+	PaymentStatusScheduled: {
+		PaymentStatusPending,
+		PaymentStatusRejected,
+		PaymentStatusScreeningHold,
+		PaymentStatusRiskReview,
+		PaymentStatusCancelled,
+	},
+	PaymentStatusAuthorized: {PaymentStatusCompleted, PaymentStatusFailed, PaymentStatusCancelled},
+	// Submitted bank transfers complete once the bank executes them, or fail if it rejects or returns them
+	PaymentStatusSubmitted: {PaymentStatusCompleted, PaymentStatusFailed},
+	// Completed payments can still be refunded or returned by the bank
+	PaymentStatusCompleted: {PaymentStatusRefunded, PaymentStatusFailed},
+}
+
+// CanTransition reports whether a payment may move from one status to another.
+// Staying in the same status is always allowed.
+func CanTransition(from, to PaymentStatus) bool {
+	if from == to {
+		return true
+	}
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// InvalidTransitionError is returned for a status change the state machine does not allow
+type InvalidTransitionError struct {
+	From PaymentStatus
+	To   PaymentStatus
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("invalid status transition from %s to %s", e.From, e.To)
+}
+
+// TransitionTo moves the payment to a new status if the state machine allows it
+func (p *Payment) TransitionTo(status PaymentStatus) error {
+	if !CanTransition(p.Status, status) {
+		return &InvalidTransitionError{From: p.Status, To: status}
+	}
+	p.UpdateStatus(status)
+	return nil
+}
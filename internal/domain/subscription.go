@@ -0,0 +1,253 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Frequency is the period a payment schedule repeats on
+type Frequency string
+
+const (
+	FrequencyDaily   Frequency = "DAILY"
+	FrequencyWeekly  Frequency = "WEEKLY"
+	FrequencyMonthly Frequency = "MONTHLY"
+)
+
+// PaymentSchedule describes when a recurring payment is due, similar to an RRULE:
+// every Interval days, weeks or months starting at StartAt. Monthly schedules bill on
+// AnchorDay, clamped to the last day of shorter months, so a schedule anchored on the
+// 31st bills on Jan 31, Feb 28 and Mar 31.
+type PaymentSchedule struct {
+	Frequency Frequency `json:"frequency"`
+	Interval  int       `json:"interval"`
+	AnchorDay int       `json:"anchorDay,omitempty"`
+	StartAt   time.Time `json:"startAt"`
+}
+
+// Validate checks the schedule and fills in the default interval and anchor day
+func (s *PaymentSchedule) Validate() error {
+	switch s.Frequency {
+	case FrequencyDaily, FrequencyWeekly, FrequencyMonthly:
+	default:
+		return fmt.Errorf("unsupported frequency %q", s.Frequency)
+	}
+	if s.Interval == 0 {
+		s.Interval = 1
+	}
+	if s.Interval < 0 {
+		return errors.New("interval must be greater than 0")
+	}
+	if s.StartAt.IsZero() {
+		return errors.New("schedule start is required")
+	}
+	if s.Frequency != FrequencyMonthly {
+		s.AnchorDay = 0
+		return nil
+	}
+	if s.AnchorDay == 0 {
+		s.AnchorDay = s.StartAt.Day()
+	}
+	if s.AnchorDay < 1 || s.AnchorDay > 31 {
+		return errors.New("anchor day must be between 1 and 31")
+	}
+	return nil
+}
+
+// Occurrence returns the due time of the n-th payment (counting from 0), never before StartAt
+func (s PaymentSchedule) Occurrence(n int) time.Time {
+	if s.Frequency == FrequencyMonthly && s.monthly(0).Before(s.StartAt) {
+		n++
+	}
+	return s.occurrence(n)
+}
+
+func (s PaymentSchedule) occurrence(n int) time.Time {
+	switch s.Frequency {
+	case FrequencyDaily:
+		return s.StartAt.AddDate(0, 0, n*s.Interval)
+	case FrequencyWeekly:
+		return s.StartAt.AddDate(0, 0, 7*n*s.Interval)
+	default:
+		return s.monthly(n)
+	}
+}
+
+// monthly returns the anchor day n intervals after the start month, clamped to the month's length
+func (s PaymentSchedule) monthly(n int) time.Time {
+	start := s.StartAt
+	first := time.Date(start.Year(), start.Month()+time.Month(n*s.Interval), 1, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+	day := s.AnchorDay
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// SubscriptionStatus represents the billing state of a subscription
+type SubscriptionStatus string
+
+const (
+	SubscriptionStatusActive    SubscriptionStatus = "ACTIVE"
+	SubscriptionStatusPastDue   SubscriptionStatus = "PAST_DUE"
+	SubscriptionStatusPaused    SubscriptionStatus = "PAUSED"
+	SubscriptionStatusUnpaid    SubscriptionStatus = "UNPAID"
+	SubscriptionStatusCancelled SubscriptionStatus = "CANCELLED"
+)
+
+// ErrSubscriptionCancelled is returned when changing a cancelled subscription
+var ErrSubscriptionCancelled = errors.New("subscription is cancelled")
+
+// ErrSubscriptionBilling is returned when changing a subscription a runner is billing
+var ErrSubscriptionBilling = errors.New("subscription is being billed")
+
+// Subscription bills the same payment on a schedule. Cycle counts the periods already
+// paid; while a payment is failing, RetryAttempt counts dunning retries of the current cycle.
+type Subscription struct {
+	ID            string             `json:"id"`
+	PayerID       string             `json:"payerId,omitempty"`
+	TenantID      string             `json:"tenantId,omitempty"`
+	Amount        float64            `json:"amount"`
+	Currency      string             `json:"currency"`
+	Description   string             `json:"description"`
+	Method        PaymentMethod      `json:"method,omitempty"`
+	Schedule      PaymentSchedule    `json:"schedule"`
+	Status        SubscriptionStatus `json:"status"`
+	Cycle         int                `json:"cycle"`
+	NextRunAt     time.Time          `json:"nextRunAt"`
+	RetryAttempt  int                `json:"retryAttempt"`
+	LastPaymentID string             `json:"lastPaymentId,omitempty"`
+	LastError     string             `json:"lastError,omitempty"`
+	CancelledAt   *time.Time         `json:"cancelledAt,omitempty"`
+	CreatedAt     time.Time          `json:"createdAt"`
+	UpdatedAt     time.Time          `json:"updatedAt"`
+}
+
+// NewSubscription creates an active subscription whose first payment is due at the schedule start
+func NewSubscription(amount float64, currency, description string, schedule PaymentSchedule) (*Subscription, error) {
+	if err := schedule.Validate(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &Subscription{
+		ID:          uuid.New().String(),
+		Amount:      amount,
+		Currency:    currency,
+		Description: description,
+		Schedule:    schedule,
+		Status:      SubscriptionStatusActive,
+		NextRunAt:   schedule.Occurrence(0),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}, nil
+}
+
+// Due reports whether a payment should be generated at the given time
+func (s *Subscription) Due(now time.Time) bool {
+	return (s.Status == SubscriptionStatusActive || s.Status == SubscriptionStatusPastDue) && !s.NextRunAt.After(now)
+}
+
+// RecordPayment advances the subscription to its next cycle after a successful payment
+func (s *Subscription) RecordPayment(paymentID string) {
+	s.Cycle++
+	s.RetryAttempt = 0
+	s.LastPaymentID = paymentID
+	s.LastError = ""
+	s.Status = SubscriptionStatusActive
+	s.NextRunAt = s.Schedule.Occurrence(s.Cycle)
+	s.UpdatedAt = time.Now()
+}
+
+// RecordFailure schedules the next dunning retry of the current cycle after the matching
+// backoff, or marks the subscription UNPAID once all retries are used up
+func (s *Subscription) RecordFailure(paymentID, reason string, now time.Time, backoff []time.Duration) {
+	s.LastPaymentID = paymentID
+	s.LastError = reason
+	s.UpdatedAt = time.Now()
+	if s.RetryAttempt >= len(backoff) {
+		s.Status = SubscriptionStatusUnpaid
+		return
+	}
+	s.NextRunAt = now.Add(backoff[s.RetryAttempt])
+	s.RetryAttempt++
+	s.Status = SubscriptionStatusPastDue
+}
+
+// Pause stops billing until the subscription is resumed
+func (s *Subscription) Pause() error {
+	switch s.Status {
+	case SubscriptionStatusCancelled:
+		return ErrSubscriptionCancelled
+	case SubscriptionStatusActive, SubscriptionStatusPastDue:
+		s.Status = SubscriptionStatusPaused
+		s.UpdatedAt = time.Now()
+		return nil
+	default:
+		return fmt.Errorf("cannot pause a %s subscription", s.Status)
+	}
+}
+
+// Resume restarts billing of a paused or unpaid subscription. Periods that fell due in the
+// meantime are skipped; billing continues with the next occurrence at or after now.
+func (s *Subscription) Resume(now time.Time) error {
+	switch s.Status {
+	case SubscriptionStatusCancelled:
+		return ErrSubscriptionCancelled
+	case SubscriptionStatusPaused, SubscriptionStatusUnpaid:
+	default:
+		return fmt.Errorf("cannot resume a %s subscription", s.Status)
+	}
+
+	for s.Schedule.Occurrence(s.Cycle).Before(now) {
+		s.Cycle++
+	}
+	s.NextRunAt = s.Schedule.Occurrence(s.Cycle)
+	s.RetryAttempt = 0
+	s.Status = SubscriptionStatusActive
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+// Cancel ends the subscription for good
+func (s *Subscription) Cancel() error {
+	if s.Status == SubscriptionStatusCancelled {
+		return ErrSubscriptionCancelled
+	}
+	now := time.Now()
+	s.Status = SubscriptionStatusCancelled
+	s.CancelledAt = &now
+	s.UpdatedAt = now
+	return nil
+}
+
+// SubscriptionFilter selects subscriptions; zero fields are ignored
+type SubscriptionFilter struct {
+	PayerID  string
+	Statuses []SubscriptionStatus
+	// DueBefore selects subscriptions whose next payment is due at or before the given time
+	DueBefore *time.Time
+	Limit     int
+}
+
+// SubscriptionRepository defines the interface for subscription data operations. Like
+// scheduled payments, due subscriptions are leased to a runner before they are billed, so
+// several instances never bill the same cycle twice.
+type SubscriptionRepository interface {
+	Create(ctx context.Context, subscription *Subscription) error
+	GetByID(ctx context.Context, id string) (*Subscription, error)
+	// Update saves a subscription that is not leased by a runner. It returns
+	// ErrSubscriptionBilling otherwise.
+	Update(ctx context.Context, subscription *Subscription) error
+	// List returns matching subscriptions ordered by next due time
+	List(ctx context.Context, filter SubscriptionFilter) ([]*Subscription, error)
+	// ClaimDue leases up to limit ACTIVE or PAST_DUE subscriptions due at now to owner until now+lease
+	ClaimDue(ctx context.Context, owner string, now time.Time, lease time.Duration, limit int) ([]*Subscription, error)
+	// CompleteBilling saves a claimed subscription and releases its lease. It reports false,
+	// without saving, when owner no longer holds the lease.
+	CompleteBilling(ctx context.Context, subscription *Subscription, owner string) (bool, error)
+}
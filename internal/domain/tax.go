@@ -0,0 +1,56 @@
+package domain
+
+// TaxPricing tells whether prices include tax
+type TaxPricing string
+
+const (
+	// TaxPricingInclusive prices include tax, which is taken out of them
+	TaxPricingInclusive TaxPricing = "INCLUSIVE"
+	// TaxPricingExclusive prices exclude tax, which is added on top
+	TaxPricingExclusive TaxPricing = "EXCLUSIVE"
+)
+
+// IsValid reports whether p is a known pricing
+func (p TaxPricing) IsValid() bool {
+	return p == TaxPricingInclusive || p == TaxPricingExclusive
+}
+
+// TaxRequest asks for the tax on the lines of a sale to a buyer in Country. An empty Pricing
+// uses the jurisdiction's default.
+type TaxRequest struct {
+	Country string
+	Pricing TaxPricing
+	// VATNumber is the buyer's VAT number for business sales, which may be reverse charged
+	VATNumber string
+	Lines     []TaxableLine
+}
+
+// TaxableLine is an amount of one product category; an empty category is the standard rate
+type TaxableLine struct {
+	Category string
+	Amount   Money
+}
+
+// TaxLine is the tax on one line
+type TaxLine struct {
+	Category string `json:"category"`
+	// Rate is a percentage such as "19" or "5.5"
+	Rate  string `json:"rate"`
+	Net   Money  `json:"net"`
+	Tax   Money  `json:"tax"`
+	Gross Money  `json:"gross"`
+}
+
+// TaxBreakdown is the tax calculated on a sale. Net plus Tax is Gross for every line and for
+// the totals.
+type TaxBreakdown struct {
+	Country string     `json:"country"`
+	Pricing TaxPricing `json:"pricing"`
+	// ReverseCharge marks a business sale whose tax the buyer accounts for; its rates are 0
+	ReverseCharge bool      `json:"reverseCharge,omitempty"`
+	VATNumber     string    `json:"vatNumber,omitempty"`
+	Lines         []TaxLine `json:"lines"`
+	Net           Money     `json:"net"`
+	Tax           Money     `json:"tax"`
+	Gross         Money     `json:"gross"`
+}
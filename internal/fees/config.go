@@ -0,0 +1,193 @@
+package fees
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"payments_app/internal/domain"
+	"payments_app/internal/scheduler"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the YAML representation of a fee schedules file
+type Config struct {
+	Schedules []ScheduleConfig `yaml:"schedules"`
+}
+
+// ScheduleConfig holds the settings of a single schedule. Amounts are decimals in the
+// payment's currency; a schedule without tiers charges Fixed plus Percent.
+type ScheduleConfig struct {
+	Name     string       `yaml:"name"`
+	Event    string       `yaml:"event"`
+	Method   string       `yaml:"method"`
+	Currency string       `yaml:"currency"`
+	Tenant   string       `yaml:"tenant"`
+	Account  string       `yaml:"account"`
+	Fixed    string       `yaml:"fixed"`
+	Percent  string       `yaml:"percent"`
+	Tiers    []TierConfig `yaml:"tiers"`
+	Min      string       `yaml:"min"`
+	Max      string       `yaml:"max"`
+}
+
+// TierConfig holds the settings of one tier; the last tier may leave UpTo empty
+type TierConfig struct {
+	UpTo    string `yaml:"up_to"`
+	Fixed   string `yaml:"fixed"`
+	Percent string `yaml:"percent"`
+}
+
+// LoadConfig reads and parses a YAML fee schedules file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid fee schedules file %s: %w", path, err)
+	}
+
+	return &cfg, nil
+}
+
+// BuildSchedules validates the configuration and builds its schedules
+func BuildSchedules(cfg *Config) ([]*Schedule, error) {
+	schedules := make([]*Schedule, 0, len(cfg.Schedules))
+	for i, scheduleCfg := range cfg.Schedules {
+		schedule, err := buildSchedule(scheduleCfg)
+		if err != nil {
+			name := scheduleCfg.Name
+			if name == "" {
+				name = fmt.Sprint(i + 1)
+			}
+			return nil, fmt.Errorf("schedule %s: %w", name, err)
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, nil
+}
+
+// buildSchedule validates one schedule
+func buildSchedule(cfg ScheduleConfig) (*Schedule, error) {
+	schedule := &Schedule{
+		Name:     strings.TrimSpace(cfg.Name),
+		Event:    domain.FeeEvent(strings.ToUpper(strings.TrimSpace(cfg.Event))),
+		Method:   domain.PaymentMethodType(strings.ToUpper(strings.TrimSpace(cfg.Method))),
+		Currency: strings.ToUpper(strings.TrimSpace(cfg.Currency)),
+		Tenant:   strings.TrimSpace(cfg.Tenant),
+		Account:  strings.TrimSpace(cfg.Account),
+	}
+	if schedule.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	if schedule.Event == "" {
+		schedule.Event = domain.FeeEventCaptured
+	}
+	if !schedule.Event.IsValid() {
+		return nil, errors.New("event must be CREATED or CAPTURED")
+	}
+	switch schedule.Method {
+	case "", domain.PaymentMethodTypeCard, domain.PaymentMethodTypeBankAccount, domain.PaymentMethodTypeWallet:
+	default:
+		return nil, fmt.Errorf("unknown method %q", cfg.Method)
+	}
+	if schedule.Currency != "" && len(schedule.Currency) != 3 {
+		return nil, fmt.Errorf("invalid currency %q", cfg.Currency)
+	}
+	if schedule.Account == "" {
+		schedule.Account = domain.AccountFeeRevenue
+	}
+
+	tiers := cfg.Tiers
+	if len(tiers) == 0 {
+		tiers = []TierConfig{{Fixed: cfg.Fixed, Percent: cfg.Percent}}
+	} else if cfg.Fixed != "" || cfg.Percent != "" {
+		return nil, errors.New("fixed and percent belong in the tiers when tiers are given")
+	}
+	for i, tierCfg := range tiers {
+		tier, err := buildTier(tierCfg)
+		if err != nil {
+			return nil, fmt.Errorf("tier %d: %w", i+1, err)
+		}
+		if tier.UpTo == nil && i < len(tiers)-1 {
+			return nil, fmt.Errorf("tier %d: only the last tier may leave up_to empty", i+1)
+		}
+		if i > 0 && tier.UpTo != nil && tier.UpTo.Cmp(schedule.Tiers[i-1].UpTo) <= 0 {
+			return nil, fmt.Errorf("tier %d: up_to must be larger than the previous tier's", i+1)
+		}
+		schedule.Tiers = append(schedule.Tiers, tier)
+	}
+
+	var err error
+	if schedule.Min, err = parseCap("min", cfg.Min); err != nil {
+		return nil, err
+	}
+	if schedule.Max, err = parseCap("max", cfg.Max); err != nil {
+		return nil, err
+	}
+	if schedule.Min != nil && schedule.Max != nil && schedule.Min.Cmp(schedule.Max) > 0 {
+		return nil, errors.New("min must not be larger than max")
+	}
+	return schedule, nil
+}
+
+// buildTier validates one tier
+func buildTier(cfg TierConfig) (Tier, error) {
+	fixed, err := parseDecimal("fixed", cfg.Fixed)
+	if err != nil {
+		return Tier{}, err
+	}
+	percent, err := parseDecimal("percent", cfg.Percent)
+	if err != nil {
+		return Tier{}, err
+	}
+	if percent.Cmp(big.NewRat(100, 1)) > 0 {
+		return Tier{}, errors.New("percent must not exceed 100")
+	}
+	tier := Tier{Fixed: fixed, Percent: percent}
+	if strings.TrimSpace(cfg.UpTo) != "" {
+		if tier.UpTo, err = parseDecimal("up_to", cfg.UpTo); err != nil {
+			return Tier{}, err
+		}
+	}
+	return tier, nil
+}
+
+// parseCap parses an optional min or max fee
+func parseCap(field, value string) (*big.Rat, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	return parseDecimal(field, value)
+}
+
+// Reload loads a fee schedules file and swaps it into the engine; the old schedules stay
+// active on error
+func (e *Engine) Reload(path string) error {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	schedules, err := BuildSchedules(cfg)
+	if err != nil {
+		return err
+	}
+
+	e.Replace(schedules)
+	return nil
+}
+
+// Watch polls a fee schedules file and reloads the engine whenever its modification time
+// changes. Reload errors are passed to onError and the previous schedules are kept. An
+// interval of zero or less disables watching.
+func (e *Engine) Watch(ctx context.Context, path string, interval time.Duration, onError func(error)) {
+	scheduler.WatchFile(ctx, path, interval, e.Reload, onError)
+}
@@ -0,0 +1,78 @@
+package fees
+
+import (
+	"payments_app/internal/domain"
+	"sync"
+	"time"
+)
+
+// Engine prices payments with a set of schedules that can be replaced at runtime
+type Engine struct {
+	mutex     sync.RWMutex
+	schedules []*Schedule
+}
+
+// NewEngine creates a fee engine with the given schedules
+func NewEngine(schedules ...*Schedule) *Engine {
+	engine := &Engine{}
+	engine.Replace(schedules)
+	return engine
+}
+
+// Replace atomically swaps the active schedules
+func (e *Engine) Replace(schedules []*Schedule) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.schedules = schedules
+}
+
+// Schedules returns the active schedules
+func (e *Engine) Schedules() []*Schedule {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return e.schedules
+}
+
+// Fees prices the fees charged on the payment at the event. Each fee name is charged once,
+// by the most specific schedule of that name that matches; fees that come to zero are left out.
+func (e *Engine) Fees(payment *domain.Payment, event domain.FeeEvent) ([]domain.Fee, error) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	var names []string
+	chosen := make(map[string]*Schedule)
+	for _, schedule := range e.schedules {
+		if !schedule.Matches(payment, event) {
+			continue
+		}
+		current, seen := chosen[schedule.Name]
+		if !seen {
+			names = append(names, schedule.Name)
+		}
+		if !seen || schedule.specificity() > current.specificity() {
+			chosen[schedule.Name] = schedule
+		}
+	}
+
+	amount := domain.MoneyFromFloat(payment.Amount, payment.Currency)
+	now := time.Now()
+	var fees []domain.Fee
+	for _, name := range names {
+		schedule := chosen[name]
+		fee, err := schedule.Calculate(amount)
+		if err != nil {
+			return nil, err
+		}
+		if fee.MinorUnits == 0 {
+			continue
+		}
+		fees = append(fees, domain.Fee{
+			Name:      name,
+			Event:     event,
+			Amount:    fee,
+			Account:   schedule.Account,
+			ChargedAt: now,
+		})
+	}
+	return fees, nil
+}
@@ -0,0 +1,112 @@
+// Package fees calculates payment fees from configurable schedules.
+package fees
+
+import (
+	"fmt"
+	"math/big"
+	"payments_app/internal/domain"
+	"strings"
+)
+
+// Tier is a fixed fee plus a percentage of the amount, for payments up to UpTo
+type Tier struct {
+	// UpTo is the largest amount the tier applies to, inclusive; nil means no limit
+	UpTo    *big.Rat
+	Fixed   *big.Rat
+	Percent *big.Rat
+}
+
+// Schedule prices one kind of fee for the payments it matches. Empty match fields match
+// every payment.
+type Schedule struct {
+	Name     string
+	Event    domain.FeeEvent
+	Method   domain.PaymentMethodType
+	Currency string
+	Tenant   string
+	Account  string
+	// Tiers are ordered by UpTo; the first tier covering the amount prices the whole amount
+	Tiers []Tier
+	// Min and Max cap the fee; nil means no cap
+	Min *big.Rat
+	Max *big.Rat
+}
+
+// Matches reports whether the schedule applies to the payment at the event
+func (s *Schedule) Matches(payment *domain.Payment, event domain.FeeEvent) bool {
+	if s.Event != event {
+		return false
+	}
+	if s.Currency != "" && s.Currency != payment.Currency {
+		return false
+	}
+	if s.Tenant != "" && s.Tenant != payment.TenantID {
+		return false
+	}
+	if s.Method != "" && (payment.Method == nil || payment.Method.MethodType() != s.Method) {
+		return false
+	}
+	return true
+}
+
+// specificity ranks matching schedules of the same name: a tenant match beats a method match,
+// which beats a currency match
+func (s *Schedule) specificity() int {
+	score := 0
+	if s.Tenant != "" {
+		score += 4
+	}
+	if s.Method != "" {
+		score += 2
+	}
+	if s.Currency != "" {
+		score++
+	}
+	return score
+}
+
+// Calculate prices the fee for an amount, rounded half away from zero to a minor unit
+func (s *Schedule) Calculate(amount domain.Money) (domain.Money, error) {
+	value := new(big.Rat).SetFrac64(amount.MinorUnits, domain.MinorUnitScale(amount.Currency))
+	tier := s.tierFor(value)
+	if tier == nil {
+		return domain.Money{Currency: amount.Currency}, nil
+	}
+
+	fee := new(big.Rat).Mul(value, tier.Percent)
+	fee.Quo(fee, big.NewRat(100, 1))
+	fee.Add(fee, tier.Fixed)
+	if s.Min != nil && fee.Cmp(s.Min) < 0 {
+		fee.Set(s.Min)
+	}
+	if s.Max != nil && fee.Cmp(s.Max) > 0 {
+		fee.Set(s.Max)
+	}
+	return domain.RoundMoney(fee, amount.Currency)
+}
+
+// tierFor returns the first tier covering the amount
+func (s *Schedule) tierFor(amount *big.Rat) *Tier {
+	for i := range s.Tiers {
+		if s.Tiers[i].UpTo == nil || amount.Cmp(s.Tiers[i].UpTo) <= 0 {
+			return &s.Tiers[i]
+		}
+	}
+	return nil
+}
+
+// parseDecimal parses a non-negative decimal; an empty value is zero
+func parseDecimal(field, value string) (*big.Rat, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return new(big.Rat), nil
+	}
+	if strings.Trim(value, "0123456789.") != "" || strings.Count(value, ".") > 1 {
+		return nil, fmt.Errorf("%s %q is not a non-negative decimal", field, value)
+	}
+	parsed, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return nil, fmt.Errorf("%s %q is not a non-negative decimal", field, value)
+	}
+	return parsed, nil
+}
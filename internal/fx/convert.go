@@ -0,0 +1,66 @@
+// Package fx converts amounts between currencies with exchange rates loaded from a file or
+// set up in code.
+package fx
+
+import (
+	"fmt"
+	"math/big"
+	"payments_app/internal/domain"
+	"strings"
+)
+
+// inverseRatePrecision is the number of decimal places kept when a rate is derived by inverting
+// the opposite pair
+const inverseRatePrecision = 10
+
+// ParseRate parses an exchange rate, which must be a positive decimal such as "1.0842"
+func ParseRate(rate string) (*big.Rat, error) {
+	value := strings.TrimSpace(rate)
+	if value == "" || strings.Trim(value, "0123456789.") != "" || strings.Count(value, ".") > 1 {
+		return nil, fmt.Errorf("exchange rate %q is not a decimal number", rate)
+	}
+	parsed, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return nil, fmt.Errorf("exchange rate %q is not a decimal number", rate)
+	}
+	if parsed.Sign() <= 0 {
+		return nil, fmt.Errorf("exchange rate %q must be greater than 0", rate)
+	}
+	return parsed, nil
+}
+
+// Convert converts an amount of the rate's base currency to its quote currency. The result is
+// computed exactly and rounded half away from zero to the quote currency's minor unit, so a
+// stored rate snapshot always reproduces the same amount.
+func Convert(amount domain.Money, rate domain.FXRate) (domain.Money, error) {
+	if amount.Currency != rate.Base {
+		return domain.Money{}, fmt.Errorf("cannot convert %s with a %s/%s rate", amount.Currency, rate.Base, rate.Quote)
+	}
+	value, err := ParseRate(rate.Rate)
+	if err != nil {
+		return domain.Money{}, err
+	}
+
+	// quote amount = base minor units / base scale * rate
+	value.Mul(value, big.NewRat(amount.MinorUnits, domain.MinorUnitScale(rate.Base)))
+	return domain.RoundMoney(value, rate.Quote)
+}
+
+// Invert returns the rate of the opposite pair, rounded to inverseRatePrecision decimal places
+func Invert(rate domain.FXRate) (domain.FXRate, error) {
+	value, err := ParseRate(rate.Rate)
+	if err != nil {
+		return domain.FXRate{}, err
+	}
+	inverse := strings.TrimRight(strings.TrimRight(new(big.Rat).Inv(value).FloatString(inverseRatePrecision), "0"), ".")
+	if inverse == "0" {
+		return domain.FXRate{}, fmt.Errorf("exchange rate %q is too large to invert", rate.Rate)
+	}
+	return domain.FXRate{
+		Base:   rate.Quote,
+		Quote:  rate.Base,
+		Rate:   inverse,
+		AsOf:   rate.AsOf,
+		Source: rate.Source,
+	}, nil
+}
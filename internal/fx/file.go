@@ -0,0 +1,131 @@
+package fx
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"payments_app/internal/domain"
+	"payments_app/internal/scheduler"
+	"strings"
+	"time"
+)
+
+// rateColumns are the columns a rates file must have; other columns are ignored
+var rateColumns = []string{"base", "quote", "rate", "as_of"}
+
+// LoadRates reads rates from CSV with a header row naming the base, quote, rate and as_of
+// columns. as_of is an RFC 3339 timestamp or a date, which means midnight UTC. Every rate is
+// attributed to source.
+func LoadRates(r io.Reader, source string) ([]domain.FXRate, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("rates file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]int, len(header))
+	for i, column := range header {
+		index[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	for _, column := range rateColumns {
+		if _, ok := index[column]; !ok {
+			return nil, fmt.Errorf("rates file has no %s column", column)
+		}
+	}
+	reader.FieldsPerRecord = len(header)
+
+	var rates []domain.FXRate
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rates, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		rate, err := parseRateRecord(record, index, source)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rates = append(rates, rate)
+	}
+}
+
+// parseRateRecord validates one row of a rates file
+func parseRateRecord(record []string, index map[string]int, source string) (domain.FXRate, error) {
+	base := strings.ToUpper(strings.TrimSpace(record[index["base"]]))
+	quote := strings.ToUpper(strings.TrimSpace(record[index["quote"]]))
+	for _, currency := range []string{base, quote} {
+		if len(currency) != 3 || strings.Trim(currency, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+			return domain.FXRate{}, fmt.Errorf("invalid currency code %q", currency)
+		}
+	}
+	if base == quote {
+		return domain.FXRate{}, fmt.Errorf("rate converts %s to itself", base)
+	}
+	rate := strings.TrimSpace(record[index["rate"]])
+	if _, err := ParseRate(rate); err != nil {
+		return domain.FXRate{}, err
+	}
+	asOf, err := parseAsOf(strings.TrimSpace(record[index["as_of"]]))
+	if err != nil {
+		return domain.FXRate{}, err
+	}
+	return domain.FXRate{Base: base, Quote: quote, Rate: rate, AsOf: asOf, Source: source}, nil
+}
+
+// parseAsOf parses an RFC 3339 timestamp or a date
+func parseAsOf(value string) (time.Time, error) {
+	if asOf, err := time.Parse(time.RFC3339, value); err == nil {
+		return asOf.UTC(), nil
+	}
+	if asOf, err := time.Parse("2006-01-02", value); err == nil {
+		return asOf, nil
+	}
+	return time.Time{}, fmt.Errorf("as_of %q must be an RFC 3339 timestamp or a YYYY-MM-DD date", value)
+}
+
+// FileProvider serves rates loaded from a CSV file that can be reloaded at runtime
+type FileProvider struct {
+	table table
+}
+
+// NewFileProvider creates a provider without rates; call Reload to load a file
+func NewFileProvider() *FileProvider {
+	return &FileProvider{}
+}
+
+// Rate implements Provider
+func (p *FileProvider) Rate(ctx context.Context, base, quote string, at time.Time) (domain.FXRate, error) {
+	return p.table.rate(base, quote, at)
+}
+
+// Reload loads a rates file and swaps it into the provider; the old rates stay active on error
+func (p *FileProvider) Reload(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	rates, err := LoadRates(file, filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("invalid rates file %s: %w", path, err)
+	}
+	p.table.replace(rates)
+	return nil
+}
+
+// Watch polls a rates file and reloads the provider whenever its modification time changes.
+// Reload errors are passed to onError and the previous rates are kept. An interval of zero
+// or less disables watching.
+func (p *FileProvider) Watch(ctx context.Context, path string, interval time.Duration, onError func(error)) {
+	scheduler.WatchFile(ctx, path, interval, p.Reload, onError)
+}
@@ -0,0 +1,87 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"payments_app/internal/domain"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Provider looks up exchange rates
+type Provider interface {
+	// Rate returns the latest rate for converting base to quote published at or before at
+	Rate(ctx context.Context, base, quote string, at time.Time) (domain.FXRate, error)
+}
+
+// pair identifies a currency pair in a rate table
+type pair struct {
+	base, quote string
+}
+
+// table holds rates by currency pair, each pair's rates ordered by publication time
+type table struct {
+	mutex sync.RWMutex
+	rates map[pair][]domain.FXRate
+}
+
+// replace swaps the table's rates
+func (t *table) replace(rates []domain.FXRate) {
+	byPair := make(map[pair][]domain.FXRate)
+	for _, rate := range rates {
+		key := pair{rate.Base, rate.Quote}
+		byPair[key] = append(byPair[key], rate)
+	}
+	for _, pairRates := range byPair {
+		sort.SliceStable(pairRates, func(i, j int) bool { return pairRates[i].AsOf.Before(pairRates[j].AsOf) })
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.rates = byPair
+}
+
+// rate finds the latest rate for a pair at a time. A pair without rates of its own is
+// converted with the inverse of the opposite pair's rate.
+func (t *table) rate(base, quote string, at time.Time) (domain.FXRate, error) {
+	if base == quote {
+		return domain.FXRate{Base: base, Quote: quote, Rate: "1", AsOf: at}, nil
+	}
+
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	if rate, ok := latest(t.rates[pair{base, quote}], at); ok {
+		return rate, nil
+	}
+	if rate, ok := latest(t.rates[pair{quote, base}], at); ok {
+		return Invert(rate)
+	}
+	return domain.FXRate{}, fmt.Errorf("%w for %s/%s at %s", domain.ErrRateNotFound, base, quote, at.UTC().Format(time.RFC3339))
+}
+
+// latest returns the last of the ordered rates published at or before at
+func latest(rates []domain.FXRate, at time.Time) (domain.FXRate, bool) {
+	i := sort.Search(len(rates), func(i int) bool { return rates[i].AsOf.After(at) })
+	if i == 0 {
+		return domain.FXRate{}, false
+	}
+	return rates[i-1], true
+}
+
+// StaticProvider serves a fixed set of rates, for tests and fixed-rate setups
+type StaticProvider struct {
+	table table
+}
+
+// NewStaticProvider creates a provider serving the given rates
+func NewStaticProvider(rates ...domain.FXRate) *StaticProvider {
+	provider := &StaticProvider{}
+	provider.table.replace(rates)
+	return provider
+}
+
+// Rate implements Provider
+func (p *StaticProvider) Rate(ctx context.Context, base, quote string, at time.Time) (domain.FXRate, error) {
+	return p.table.rate(base, quote, at)
+}
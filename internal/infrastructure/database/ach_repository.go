@@ -0,0 +1,188 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"payments_app/internal/domain"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ACHFileDB represents the database model for generated NACHA files
+type ACHFileDB struct {
+	ID          string       `gorm:"primaryKey;type:varchar(35)"`
+	CreatedAt   time.Time    `gorm:"not null;index"`
+	IDModifier  string       `gorm:"not null;type:varchar(1)"`
+	BatchCount  int          `gorm:"not null"`
+	EntryCount  int          `gorm:"not null"`
+	EntryHash   int64        `gorm:"not null"`
+	TotalCredit int64        `gorm:"not null"`
+	Document    []byte       `gorm:"not null"`
+	Entries     []ACHEntryDB `gorm:"foreignKey:FileID"`
+}
+
+// TableName specifies the table name for GORM
+func (ACHFileDB) TableName() string {
+	return "ach_files"
+}
+
+// ACHEntryDB maps the trace number of an entry to its payment
+type ACHEntryDB struct {
+	TraceNumber string `gorm:"primaryKey;type:varchar(15)"`
+	// Sequence is the entry number in the last seven digits of the trace number
+	Sequence  int    `gorm:"not null;index"`
+	FileID    string `gorm:"not null;index;type:varchar(35)"`
+	PaymentID string `gorm:"not null;index;type:varchar(36)"`
+}
+
+// TableName specifies the table name for GORM
+func (ACHEntryDB) TableName() string {
+	return "ach_entries"
+}
+
+// ACHRepository implements domain.ACHRepository
+type ACHRepository struct {
+	db *gorm.DB
+}
+
+// NewACHRepository creates an ACH file repository on an existing connection
+func NewACHRepository(db *gorm.DB) (*ACHRepository, error) {
+	if err := db.AutoMigrate(&ACHFileDB{}, &ACHEntryDB{}); err != nil {
+		return nil, err
+	}
+	return &ACHRepository{db: db}, nil
+}
+
+// PendingACHTransfers returns pending USD bank account payments no processor handles, oldest first
+func (r *ACHRepository) PendingACHTransfers(ctx context.Context) ([]*domain.Payment, error) {
+	var paymentsDB []PaymentDB
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND method_type = ? AND currency = ? AND processor = ''", domain.PaymentStatusPending, domain.PaymentMethodTypeBankAccount, "USD").
+		Order("created_at, id").
+		Find(&paymentsDB).Error
+	if err != nil {
+		return nil, err
+	}
+
+	payments := make([]*domain.Payment, len(paymentsDB))
+	for i := range paymentsDB {
+		payments[i] = paymentsDB[i].ToDomain()
+	}
+	return payments, nil
+}
+
+// FilesCreatedSince counts the files created at or after the given time
+func (r *ACHRepository) FilesCreatedSince(ctx context.Context, since time.Time) (int, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&ACHFileDB{}).Where("created_at >= ?", since).Count(&count).Error
+	return int(count), err
+}
+
+// LastTraceSequence returns the highest entry sequence number used so far, or 0
+func (r *ACHRepository) LastTraceSequence(ctx context.Context) (int, error) {
+	var sequence int
+	err := r.db.WithContext(ctx).Model(&ACHEntryDB{}).Select("COALESCE(MAX(sequence), 0)").Scan(&sequence).Error
+	return sequence, err
+}
+
+// CreateFile stores the file and its entries and submits its payments; a payment that is no
+// longer pending, or a trace number that is taken, rolls everything back
+func (r *ACHRepository) CreateFile(ctx context.Context, file *domain.ACHFile) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		traces := make([]string, len(file.Entries))
+		paymentIDs := make([]string, len(file.Entries))
+		for i, entry := range file.Entries {
+			traces[i] = entry.TraceNumber
+			paymentIDs[i] = entry.PaymentID
+		}
+		var taken int64
+		if err := tx.Model(&ACHEntryDB{}).Where("trace_number IN ?", traces).Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			return domain.ErrACHConflict
+		}
+
+		fileDB := &ACHFileDB{
+			ID:          file.ID,
+			CreatedAt:   file.CreatedAt,
+			IDModifier:  file.IDModifier,
+			BatchCount:  file.BatchCount,
+			EntryCount:  file.EntryCount,
+			EntryHash:   file.EntryHash,
+			TotalCredit: file.TotalCredit,
+			Document:    file.Document,
+		}
+		for _, entry := range file.Entries {
+			sequence, _ := strconv.Atoi(entry.TraceNumber[len(entry.TraceNumber)-7:])
+			fileDB.Entries = append(fileDB.Entries, ACHEntryDB{
+				TraceNumber: entry.TraceNumber,
+				Sequence:    sequence,
+				PaymentID:   entry.PaymentID,
+			})
+		}
+		if err := tx.Create(fileDB).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&PaymentDB{}).
+			Where("id IN ? AND status = ?", paymentIDs, domain.PaymentStatusPending).
+			Updates(map[string]interface{}{
+				"status":        domain.PaymentStatusSubmitted,
+				"submission_id": file.ID,
+				"updated_at":    file.CreatedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != int64(len(paymentIDs)) {
+			return domain.ErrACHConflict
+		}
+		return nil
+	})
+}
+
+// GetFile retrieves an ACH file with its entries by ID
+func (r *ACHRepository) GetFile(ctx context.Context, id string) (*domain.ACHFile, error) {
+	var fileDB ACHFileDB
+	result := r.db.WithContext(ctx).
+		Preload("Entries", func(db *gorm.DB) *gorm.DB { return db.Order("sequence") }).
+		First(&fileDB, "id = ?", id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("ACH file not found")
+		}
+		return nil, result.Error
+	}
+
+	file := &domain.ACHFile{
+		ID:          fileDB.ID,
+		CreatedAt:   fileDB.CreatedAt,
+		IDModifier:  fileDB.IDModifier,
+		BatchCount:  fileDB.BatchCount,
+		EntryCount:  fileDB.EntryCount,
+		EntryHash:   fileDB.EntryHash,
+		TotalCredit: fileDB.TotalCredit,
+		Entries:     make([]domain.ACHEntry, len(fileDB.Entries)),
+		Document:    fileDB.Document,
+	}
+	for i, entry := range fileDB.Entries {
+		file.Entries[i] = domain.ACHEntry{TraceNumber: entry.TraceNumber, PaymentID: entry.PaymentID}
+	}
+	return file, nil
+}
+
+// EntryByTraceNumber finds the entry sent with a trace number
+func (r *ACHRepository) EntryByTraceNumber(ctx context.Context, traceNumber string) (*domain.ACHEntry, error) {
+	var entryDB ACHEntryDB
+	result := r.db.WithContext(ctx).First(&entryDB, "trace_number = ?", traceNumber)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrACHEntryNotFound
+		}
+		return nil, result.Error
+	}
+	return &domain.ACHEntry{TraceNumber: entryDB.TraceNumber, PaymentID: entryDB.PaymentID}, nil
+}
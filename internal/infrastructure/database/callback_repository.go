@@ -0,0 +1,146 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"payments_app/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ProcessorCallbackDB represents the database model for processor callbacks
+type ProcessorCallbackDB struct {
+	ID          string    `gorm:"primaryKey;type:varchar(36)"`
+	Processor   string    `gorm:"not null;uniqueIndex:idx_callback_processor_id;type:varchar(50)"`
+	CallbackID  string    `gorm:"not null;uniqueIndex:idx_callback_processor_id;type:varchar(100)"`
+	EventType   string    `gorm:"not null;type:varchar(20)"`
+	Reference   string    `gorm:"index;type:varchar(100)"`
+	Status      string    `gorm:"type:varchar(20)"`
+	Amount      float64   `gorm:"not null;default:0"`
+	Code        string    `gorm:"type:varchar(50)"`
+	Message     string    `gorm:"type:text"`
+	Payload     string    `gorm:"not null;type:text"`
+	PaymentID   string    `gorm:"index;type:varchar(36)"`
+	Result      string    `gorm:"type:varchar(10)"`
+	Error       string    `gorm:"type:text"`
+	ReceivedAt  time.Time `gorm:"not null;index"`
+	ProcessedAt *time.Time
+}
+
+// TableName specifies the table name for GORM
+func (ProcessorCallbackDB) TableName() string {
+	return "processor_callbacks"
+}
+
+// toDomain converts ProcessorCallbackDB to a domain ProcessorCallback
+func (c *ProcessorCallbackDB) toDomain() *domain.ProcessorCallback {
+	return &domain.ProcessorCallback{
+		ID:        c.ID,
+		Processor: c.Processor,
+		Event: domain.ProcessorEvent{
+			CallbackID: c.CallbackID,
+			Type:       domain.ProcessorEventType(c.EventType),
+			Reference:  c.Reference,
+			Status:     domain.PaymentStatus(c.Status),
+			Amount:     c.Amount,
+			Code:       c.Code,
+			Message:    c.Message,
+		},
+		Payload:     c.Payload,
+		PaymentID:   c.PaymentID,
+		Result:      domain.CallbackResult(c.Result),
+		Error:       c.Error,
+		ReceivedAt:  c.ReceivedAt,
+		ProcessedAt: c.ProcessedAt,
+	}
+}
+
+// callbackFromDomain converts a domain ProcessorCallback to ProcessorCallbackDB
+func callbackFromDomain(callback *domain.ProcessorCallback) *ProcessorCallbackDB {
+	return &ProcessorCallbackDB{
+		ID:          callback.ID,
+		Processor:   callback.Processor,
+		CallbackID:  callback.Event.CallbackID,
+		EventType:   string(callback.Event.Type),
+		Reference:   callback.Event.Reference,
+		Status:      string(callback.Event.Status),
+		Amount:      callback.Event.Amount,
+		Code:        callback.Event.Code,
+		Message:     callback.Event.Message,
+		Payload:     callback.Payload,
+		PaymentID:   callback.PaymentID,
+		Result:      string(callback.Result),
+		Error:       callback.Error,
+		ReceivedAt:  callback.ReceivedAt,
+		ProcessedAt: callback.ProcessedAt,
+	}
+}
+
+// CallbackRepository implements domain.CallbackRepository
+type CallbackRepository struct {
+	db *gorm.DB
+}
+
+// NewCallbackRepository creates a callback repository on an existing connection
+func NewCallbackRepository(db *gorm.DB) (*CallbackRepository, error) {
+	if err := db.AutoMigrate(&ProcessorCallbackDB{}); err != nil {
+		return nil, err
+	}
+	return &CallbackRepository{db: db}, nil
+}
+
+// Save stores a new callback; the unique index on processor and callback ID rejects redeliveries
+func (r *CallbackRepository) Save(ctx context.Context, callback *domain.ProcessorCallback) error {
+	err := r.db.WithContext(ctx).Create(callbackFromDomain(callback)).Error
+	if err == nil {
+		return nil
+	}
+
+	var count int64
+	if countErr := r.db.WithContext(ctx).Model(&ProcessorCallbackDB{}).
+		Where("processor = ? AND callback_id = ?", callback.Processor, callback.Event.CallbackID).
+		Count(&count).Error; countErr == nil && count > 0 {
+		return domain.ErrDuplicateCallback
+	}
+	return err
+}
+
+// Update stores the processing result of a callback
+func (r *CallbackRepository) Update(ctx context.Context, callback *domain.ProcessorCallback) error {
+	return r.db.WithContext(ctx).Save(callbackFromDomain(callback)).Error
+}
+
+// GetByID retrieves a callback by ID
+func (r *CallbackRepository) GetByID(ctx context.Context, id string) (*domain.ProcessorCallback, error) {
+	var callbackDB ProcessorCallbackDB
+
+	result := r.db.WithContext(ctx).First(&callbackDB, "id = ?", id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("callback not found")
+		}
+		return nil, result.Error
+	}
+
+	return callbackDB.toDomain(), nil
+}
+
+// List returns the most recent callbacks first, optionally for one processor
+func (r *CallbackRepository) List(ctx context.Context, processor string, limit int) ([]*domain.ProcessorCallback, error) {
+	var callbacksDB []ProcessorCallbackDB
+
+	query := r.db.WithContext(ctx).Order("received_at DESC").Limit(limit)
+	if processor != "" {
+		query = query.Where("processor = ?", processor)
+	}
+	if err := query.Find(&callbacksDB).Error; err != nil {
+		return nil, err
+	}
+
+	callbacks := make([]*domain.ProcessorCallback, len(callbacksDB))
+	for i := range callbacksDB {
+		callbacks[i] = callbacksDB[i].toDomain()
+	}
+	return callbacks, nil
+}
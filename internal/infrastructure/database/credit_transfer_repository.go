@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"payments_app/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CreditTransferFileDB represents the database model for generated payment initiation files
+type CreditTransferFileDB struct {
+	MessageID            string    `gorm:"primaryKey;type:varchar(35)"`
+	CreatedAt            time.Time `gorm:"not null;index"`
+	NumberOfTransactions int       `gorm:"not null"`
+	ControlSum           string    `gorm:"not null;type:varchar(20)"`
+	PaymentIDs           []string  `gorm:"serializer:json;type:text"`
+	Document             []byte    `gorm:"not null"`
+}
+
+// TableName specifies the table name for GORM
+func (CreditTransferFileDB) TableName() string {
+	return "credit_transfer_files"
+}
+
+// CreditTransferRepository implements domain.CreditTransferRepository
+type CreditTransferRepository struct {
+	db *gorm.DB
+}
+
+// NewCreditTransferRepository creates a credit transfer repository on an existing connection
+func NewCreditTransferRepository(db *gorm.DB) (*CreditTransferRepository, error) {
+	if err := db.AutoMigrate(&CreditTransferFileDB{}); err != nil {
+		return nil, err
+	}
+	return &CreditTransferRepository{db: db}, nil
+}
+
+// PendingCreditTransfers returns pending euro bank account payments no processor handles and no
+// risk assessment holds back, oldest first
+func (r *CreditTransferRepository) PendingCreditTransfers(ctx context.Context) ([]*domain.Payment, error) {
+	var paymentsDB []PaymentDB
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND method_type = ? AND processor = '' AND currency = ?", domain.PaymentStatusPending, domain.PaymentMethodTypeBankAccount, "EUR").
+		Where("risk_score IS NULL OR risk_decision = ? OR (risk_decision = ? AND risk_reviewed_at IS NOT NULL)", domain.RiskDecisionAllow, domain.RiskDecisionReview).
+		Order("created_at, id").
+		Find(&paymentsDB).Error
+	if err != nil {
+		return nil, err
+	}
+
+	payments := make([]*domain.Payment, len(paymentsDB))
+	for i := range paymentsDB {
+		payments[i] = paymentsDB[i].ToDomain()
+	}
+	return payments, nil
+}
+
+// CreateFile stores the file and submits its payments; a payment that is no longer pending
+// rolls everything back
+func (r *CreditTransferRepository) CreateFile(ctx context.Context, file *domain.CreditTransferFile) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		fileDB := &CreditTransferFileDB{
+			MessageID:            file.MessageID,
+			CreatedAt:            file.CreatedAt,
+			NumberOfTransactions: file.NumberOfTransactions,
+			ControlSum:           file.ControlSum,
+			PaymentIDs:           file.PaymentIDs,
+			Document:             file.Document,
+		}
+		if err := tx.Create(fileDB).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&PaymentDB{}).
+			Where("id IN ? AND status = ?", file.PaymentIDs, domain.PaymentStatusPending).
+			Updates(map[string]interface{}{
+				"status":        domain.PaymentStatusSubmitted,
+				"submission_id": file.MessageID,
+				"updated_at":    file.CreatedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != int64(len(file.PaymentIDs)) {
+			return domain.ErrCreditTransferConflict
+		}
+		return nil
+	})
+}
+
+// GetFile retrieves a credit transfer file by message ID
+func (r *CreditTransferRepository) GetFile(ctx context.Context, messageID string) (*domain.CreditTransferFile, error) {
+	var fileDB CreditTransferFileDB
+	result := r.db.WithContext(ctx).First(&fileDB, "message_id = ?", messageID)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("credit transfer file not found")
+		}
+		return nil, result.Error
+	}
+
+	return &domain.CreditTransferFile{
+		MessageID:            fileDB.MessageID,
+		CreatedAt:            fileDB.CreatedAt,
+		NumberOfTransactions: fileDB.NumberOfTransactions,
+		ControlSum:           fileDB.ControlSum,
+		PaymentIDs:           fileDB.PaymentIDs,
+		Document:             fileDB.Document,
+	}, nil
+}
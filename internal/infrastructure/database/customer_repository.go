@@ -0,0 +1,220 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"payments_app/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CustomerDB represents the database model for customers
+type CustomerDB struct {
+	ID                string            `gorm:"primaryKey;type:varchar(36)"`
+	Name              string            `gorm:"type:varchar(200)"`
+	Email             string            `gorm:"index;type:varchar(254)"`
+	ExternalReference string            `gorm:"index;type:varchar(100)"`
+	Metadata          map[string]string `gorm:"serializer:json;type:text"`
+	ErasedAt          *time.Time
+	CreatedAt         time.Time `gorm:"not null;index"`
+	UpdatedAt         time.Time `gorm:"not null"`
+}
+
+// TableName specifies the table name for GORM
+func (CustomerDB) TableName() string {
+	return "customers"
+}
+
+// ToDomain converts the database model to a domain customer
+func (c *CustomerDB) ToDomain() *domain.Customer {
+	return &domain.Customer{
+		ID:                c.ID,
+		Name:              c.Name,
+		Email:             c.Email,
+		ExternalReference: c.ExternalReference,
+		Metadata:          c.Metadata,
+		ErasedAt:          c.ErasedAt,
+		CreatedAt:         c.CreatedAt,
+		UpdatedAt:         c.UpdatedAt,
+	}
+}
+
+// customerToDB converts a domain customer to its database model
+func customerToDB(customer *domain.Customer) *CustomerDB {
+	return &CustomerDB{
+		ID:                customer.ID,
+		Name:              customer.Name,
+		Email:             customer.Email,
+		ExternalReference: customer.ExternalReference,
+		Metadata:          customer.Metadata,
+		ErasedAt:          customer.ErasedAt,
+		CreatedAt:         customer.CreatedAt,
+		UpdatedAt:         customer.UpdatedAt,
+	}
+}
+
+// CustomerRepository implements domain.CustomerRepository
+type CustomerRepository struct {
+	db *gorm.DB
+}
+
+// NewCustomerRepository creates a customer repository on an existing connection
+func NewCustomerRepository(db *gorm.DB) (*CustomerRepository, error) {
+	if err := db.AutoMigrate(&CustomerDB{}); err != nil {
+		return nil, err
+	}
+	return &CustomerRepository{db: db}, nil
+}
+
+// Create saves a new customer
+func (r *CustomerRepository) Create(ctx context.Context, customer *domain.Customer) error {
+	return r.db.WithContext(ctx).Create(customerToDB(customer)).Error
+}
+
+// GetByID retrieves a customer by ID
+func (r *CustomerRepository) GetByID(ctx context.Context, id string) (*domain.Customer, error) {
+	var customerDB CustomerDB
+	result := r.db.WithContext(ctx).First(&customerDB, "id = ?", id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrCustomerNotFound
+		}
+		return nil, result.Error
+	}
+	return customerDB.ToDomain(), nil
+}
+
+// GetByIDs retrieves the customers with the given IDs in one query
+func (r *CustomerRepository) GetByIDs(ctx context.Context, ids []string) ([]*domain.Customer, error) {
+	var customersDB []CustomerDB
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&customersDB).Error; err != nil {
+		return nil, err
+	}
+
+	customers := make([]*domain.Customer, len(customersDB))
+	for i := range customersDB {
+		customers[i] = customersDB[i].ToDomain()
+	}
+	return customers, nil
+}
+
+// List returns matching customers, oldest first
+func (r *CustomerRepository) List(ctx context.Context, filter domain.CustomerFilter) ([]*domain.Customer, error) {
+	query := r.db.WithContext(ctx).Model(&CustomerDB{})
+	if filter.Email != "" {
+		query = query.Where("email = ?", filter.Email)
+	}
+	if filter.ExternalReference != "" {
+		query = query.Where("external_reference = ?", filter.ExternalReference)
+	}
+
+	var customersDB []CustomerDB
+	if err := query.Order("created_at, id").Find(&customersDB).Error; err != nil {
+		return nil, err
+	}
+	customers := make([]*domain.Customer, len(customersDB))
+	for i := range customersDB {
+		customers[i] = customersDB[i].ToDomain()
+	}
+	return customers, nil
+}
+
+// Update saves all fields of an existing customer
+func (r *CustomerRepository) Update(ctx context.Context, customer *domain.Customer) error {
+	return r.db.WithContext(ctx).Save(customerToDB(customer)).Error
+}
+
+// Erase saves an erased customer and erases the payer data of its payments, deleted ones
+// included, in one transaction. The search index triggers drop the erased names.
+func (r *CustomerRepository) Erase(ctx context.Context, customer *domain.Customer) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(customerToDB(customer)).Error; err != nil {
+			return err
+		}
+
+		var paymentsDB []PaymentDB
+		if err := tx.Unscoped().Where("customer_id = ?", customer.ID).Find(&paymentsDB).Error; err != nil {
+			return err
+		}
+		for i := range paymentsDB {
+			payment := paymentsDB[i].ToDomain()
+			payment.ErasePayer()
+			erased := &PaymentDB{}
+			erased.FromDomain(payment)
+			err := tx.Unscoped().Model(&PaymentDB{}).
+				Where("id = ?", payment.ID).
+				Select("payer_name", "payer_account", "method_details").
+				Updates(erased).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Delete removes a customer by ID
+func (r *CustomerRepository) Delete(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).Delete(&CustomerDB{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrCustomerNotFound
+	}
+	return nil
+}
+
+// Payments reads one page of payments for every customer in two queries: the rows are numbered
+// per customer with a window function, and the totals are counted per customer
+func (r *CustomerRepository) Payments(ctx context.Context, customerIDs []string, page domain.PaymentPage) (map[string]*domain.PaymentList, error) {
+	lists := make(map[string]*domain.PaymentList, len(customerIDs))
+	for _, id := range customerIDs {
+		lists[id] = &domain.PaymentList{Payments: []*domain.Payment{}}
+	}
+	if len(customerIDs) == 0 {
+		return lists, nil
+	}
+
+	var counts []struct {
+		CustomerID string
+		Total      int
+	}
+	err := r.db.WithContext(ctx).Model(&PaymentDB{}).
+		Select("customer_id, COUNT(*) AS total").
+		Where("customer_id IN ?", customerIDs).
+		Group("customer_id").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, count := range counts {
+		lists[count.CustomerID].TotalCount = count.Total
+	}
+
+	numbered := r.db.WithContext(ctx).Model(&PaymentDB{}).
+		Select("payments.*, ROW_NUMBER() OVER (PARTITION BY customer_id ORDER BY created_at DESC, id DESC) AS position").
+		Where("customer_id IN ?", customerIDs)
+	if page.After != nil {
+		numbered = numbered.Where("created_at < ? OR (created_at = ? AND id < ?)", page.After.CreatedAt, page.After.CreatedAt, page.After.ID)
+	}
+	var paymentsDB []PaymentDB
+	err = r.db.WithContext(ctx).Table("(?) AS numbered", numbered).
+		Where("position <= ?", page.First+1).
+		Order("customer_id, created_at DESC, id DESC").
+		Find(&paymentsDB).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range paymentsDB {
+		list := lists[paymentsDB[i].CustomerID]
+		if len(list.Payments) == page.First {
+			list.HasNextPage = true
+			continue
+		}
+		list.Payments = append(list.Payments, paymentsDB[i].ToDomain())
+	}
+	return lists, nil
+}
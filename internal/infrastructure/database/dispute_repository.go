@@ -0,0 +1,134 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"payments_app/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DisputeDB represents the database model for disputes
+type DisputeDB struct {
+	ID             string                   `gorm:"primaryKey;type:varchar(36)"`
+	PaymentID      string                   `gorm:"not null;index;type:varchar(36)"`
+	ReasonCode     string                   `gorm:"not null;type:varchar(20)"`
+	Amount         float64                  `gorm:"not null"`
+	Currency       string                   `gorm:"not null;type:varchar(3)"`
+	Status         string                   `gorm:"not null;index;type:varchar(20)"`
+	EvidenceDueAt  time.Time                `gorm:"not null;index"`
+	Evidence       []domain.DisputeEvidence `gorm:"serializer:json;type:text"`
+	ResolutionNote string                   `gorm:"type:text"`
+	ResolvedAt     *time.Time
+	CreatedAt      time.Time `gorm:"not null"`
+	UpdatedAt      time.Time `gorm:"not null"`
+}
+
+// TableName specifies the table name for GORM
+func (DisputeDB) TableName() string {
+	return "disputes"
+}
+
+// toDomain converts DisputeDB to a domain Dispute
+func (d *DisputeDB) toDomain() *domain.Dispute {
+	return &domain.Dispute{
+		ID:             d.ID,
+		PaymentID:      d.PaymentID,
+		ReasonCode:     d.ReasonCode,
+		Amount:         d.Amount,
+		Currency:       d.Currency,
+		Status:         domain.DisputeStatus(d.Status),
+		EvidenceDueAt:  d.EvidenceDueAt,
+		Evidence:       d.Evidence,
+		ResolutionNote: d.ResolutionNote,
+		ResolvedAt:     d.ResolvedAt,
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+	}
+}
+
+// disputeFromDomain converts a domain Dispute to DisputeDB
+func disputeFromDomain(dispute *domain.Dispute) *DisputeDB {
+	return &DisputeDB{
+		ID:             dispute.ID,
+		PaymentID:      dispute.PaymentID,
+		ReasonCode:     dispute.ReasonCode,
+		Amount:         dispute.Amount,
+		Currency:       dispute.Currency,
+		Status:         string(dispute.Status),
+		EvidenceDueAt:  dispute.EvidenceDueAt,
+		Evidence:       dispute.Evidence,
+		ResolutionNote: dispute.ResolutionNote,
+		ResolvedAt:     dispute.ResolvedAt,
+		CreatedAt:      dispute.CreatedAt,
+		UpdatedAt:      dispute.UpdatedAt,
+	}
+}
+
+// DisputeRepository implements domain.DisputeRepository
+type DisputeRepository struct {
+	db *gorm.DB
+}
+
+// NewDisputeRepository creates a dispute repository on an existing connection
+func NewDisputeRepository(db *gorm.DB) (*DisputeRepository, error) {
+	if err := db.AutoMigrate(&DisputeDB{}); err != nil {
+		return nil, err
+	}
+	return &DisputeRepository{db: db}, nil
+}
+
+// Create stores a new dispute
+func (r *DisputeRepository) Create(ctx context.Context, dispute *domain.Dispute) error {
+	return r.db.WithContext(ctx).Create(disputeFromDomain(dispute)).Error
+}
+
+// GetByID retrieves a dispute by ID
+func (r *DisputeRepository) GetByID(ctx context.Context, id string) (*domain.Dispute, error) {
+	var disputeDB DisputeDB
+
+	result := r.db.WithContext(ctx).First(&disputeDB, "id = ?", id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("dispute not found")
+		}
+		return nil, result.Error
+	}
+
+	return disputeDB.toDomain(), nil
+}
+
+// Update stores changes to a dispute
+func (r *DisputeRepository) Update(ctx context.Context, dispute *domain.Dispute) error {
+	return r.db.WithContext(ctx).Save(disputeFromDomain(dispute)).Error
+}
+
+// List returns matching disputes ordered by evidence due date
+func (r *DisputeRepository) List(ctx context.Context, filter domain.DisputeFilter) ([]*domain.Dispute, error) {
+	var disputesDB []DisputeDB
+
+	query := r.db.WithContext(ctx).Order("evidence_due_at")
+	if filter.PaymentID != "" {
+		query = query.Where("payment_id = ?", filter.PaymentID)
+	}
+	if len(filter.Statuses) > 0 {
+		statuses := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			statuses[i] = string(status)
+		}
+		query = query.Where("status IN ?", statuses)
+	}
+	if filter.DueBefore != nil {
+		query = query.Where("evidence_due_at <= ?", *filter.DueBefore)
+	}
+	if err := query.Find(&disputesDB).Error; err != nil {
+		return nil, err
+	}
+
+	disputes := make([]*domain.Dispute, len(disputesDB))
+	for i := range disputesDB {
+		disputes[i] = disputesDB[i].toDomain()
+	}
+	return disputes, nil
+}
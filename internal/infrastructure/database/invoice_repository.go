@@ -0,0 +1,316 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"payments_app/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// InvoiceDB represents the database model for invoices; amounts are in minor units
+type InvoiceDB struct {
+	ID           string                `gorm:"primaryKey;type:varchar(36)"`
+	Number       string                `gorm:"not null;uniqueIndex;type:varchar(50)"`
+	CustomerID   string                `gorm:"index;type:varchar(36)"`
+	Currency     string                `gorm:"not null;type:varchar(3)"`
+	Lines        []domain.InvoiceLine  `gorm:"serializer:json;type:text"`
+	Subtotal     int64                 `gorm:"not null"`
+	Tax          int64                 `gorm:"not null"`
+	Total        int64                 `gorm:"not null"`
+	AmountPaid   int64                 `gorm:"not null"`
+	TaxBreakdown *domain.TaxBreakdown  `gorm:"serializer:json;type:text"`
+	DueDate      time.Time             `gorm:"not null;index"`
+	CreatedAt    time.Time             `gorm:"not null"`
+	UpdatedAt    time.Time             `gorm:"not null"`
+	Allocations  []InvoiceAllocationDB `gorm:"foreignKey:InvoiceID"`
+}
+
+// TableName specifies the table name for GORM
+func (InvoiceDB) TableName() string {
+	return "invoices"
+}
+
+// InvoiceAllocationDB records the part of a payment applied to an invoice, in minor units
+type InvoiceAllocationDB struct {
+	ID        string    `gorm:"primaryKey;type:varchar(36)"`
+	InvoiceID string    `gorm:"not null;index;type:varchar(36)"`
+	PaymentID string    `gorm:"not null;index;type:varchar(36)"`
+	Amount    int64     `gorm:"not null"`
+	Currency  string    `gorm:"not null;type:varchar(3)"`
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+// TableName specifies the table name for GORM
+func (InvoiceAllocationDB) TableName() string {
+	return "invoice_allocations"
+}
+
+// InvoiceRepository implements domain.InvoiceRepository
+type InvoiceRepository struct {
+	db *gorm.DB
+}
+
+// NewInvoiceRepository creates an invoice repository on an existing connection
+func NewInvoiceRepository(db *gorm.DB) (*InvoiceRepository, error) {
+	if err := db.AutoMigrate(&InvoiceDB{}, &InvoiceAllocationDB{}); err != nil {
+		return nil, err
+	}
+	return &InvoiceRepository{db: db}, nil
+}
+
+// Create stores a new invoice
+func (r *InvoiceRepository) Create(ctx context.Context, invoice *domain.Invoice) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Model(&InvoiceDB{}).Where("number = ?", invoice.Number).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return domain.ErrInvoiceNumberTaken
+		}
+		return tx.Create(invoiceToDB(invoice)).Error
+	})
+}
+
+// GetByID retrieves an invoice with its allocations by ID
+func (r *InvoiceRepository) GetByID(ctx context.Context, id string) (*domain.Invoice, error) {
+	var invoiceDB InvoiceDB
+	result := r.db.WithContext(ctx).
+		Preload("Allocations", func(db *gorm.DB) *gorm.DB { return db.Order("created_at, id") }).
+		First(&invoiceDB, "id = ?", id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrInvoiceNotFound
+		}
+		return nil, result.Error
+	}
+	return invoiceDB.ToDomain(), nil
+}
+
+// List returns matching invoices with their allocations, earliest due first
+func (r *InvoiceRepository) List(ctx context.Context, filter domain.InvoiceFilter) ([]*domain.Invoice, error) {
+	query := r.db.WithContext(ctx).Model(&InvoiceDB{})
+	if filter.CustomerID != "" {
+		query = query.Where("customer_id = ?", filter.CustomerID)
+	}
+	if filter.Currency != "" {
+		query = query.Where("currency = ?", filter.Currency)
+	}
+	if filter.Unpaid {
+		query = query.Where("amount_paid < total")
+	}
+
+	var invoicesDB []InvoiceDB
+	err := query.Preload("Allocations", func(db *gorm.DB) *gorm.DB { return db.Order("created_at, id") }).
+		Order("due_date, number").
+		Find(&invoicesDB).Error
+	if err != nil {
+		return nil, err
+	}
+	invoices := make([]*domain.Invoice, len(invoicesDB))
+	for i := range invoicesDB {
+		invoices[i] = invoicesDB[i].ToDomain()
+	}
+	return invoices, nil
+}
+
+// Allocate stores the allocations of one payment in a transaction. Each invoice's amount paid
+// is raised only while it stays within the total, so concurrent allocations cannot overpay it.
+func (r *InvoiceRepository) Allocate(ctx context.Context, allocations []*domain.InvoiceAllocation, available domain.Money) error {
+	if len(allocations) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var allocated int64
+		err := tx.Model(&InvoiceAllocationDB{}).
+			Where("payment_id = ?", allocations[0].PaymentID).
+			Select("COALESCE(SUM(amount), 0)").
+			Scan(&allocated).Error
+		if err != nil {
+			return err
+		}
+		for _, allocation := range allocations {
+			allocated += allocation.Amount.MinorUnits
+		}
+		if allocated > available.MinorUnits {
+			return domain.ErrPaymentOverallocated
+		}
+
+		for _, allocation := range allocations {
+			result := tx.Model(&InvoiceDB{}).
+				Where("id = ? AND currency = ? AND amount_paid + ? <= total", allocation.InvoiceID, allocation.Amount.Currency, allocation.Amount.MinorUnits).
+				Updates(map[string]interface{}{
+					"amount_paid": gorm.Expr("amount_paid + ?", allocation.Amount.MinorUnits),
+					"updated_at":  allocation.CreatedAt,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				var existing int64
+				if err := tx.Model(&InvoiceDB{}).Where("id = ?", allocation.InvoiceID).Count(&existing).Error; err != nil {
+					return err
+				}
+				if existing == 0 {
+					return domain.ErrInvoiceNotFound
+				}
+				return domain.ErrInvoiceOverallocated
+			}
+			if err := tx.Create(allocationToDB(allocation)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Release takes back the newest allocations of a payment in a transaction, shrinking the last
+// one it reaches, until the payment's allocations fit within available
+func (r *InvoiceRepository) Release(ctx context.Context, paymentID string, available domain.Money) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var allocationsDB []InvoiceAllocationDB
+		if err := tx.Where("payment_id = ?", paymentID).Order("created_at DESC, id DESC").Find(&allocationsDB).Error; err != nil {
+			return err
+		}
+		var excess int64
+		for _, allocation := range allocationsDB {
+			excess += allocation.Amount
+		}
+		excess -= max(available.MinorUnits, 0)
+
+		now := time.Now()
+		for _, allocation := range allocationsDB {
+			if excess <= 0 {
+				break
+			}
+			released := min(allocation.Amount, excess)
+			excess -= released
+			var err error
+			if released == allocation.Amount {
+				err = tx.Delete(&InvoiceAllocationDB{}, "id = ?", allocation.ID).Error
+			} else {
+				err = tx.Model(&InvoiceAllocationDB{}).Where("id = ?", allocation.ID).
+					Update("amount", gorm.Expr("amount - ?", released)).Error
+			}
+			if err != nil {
+				return err
+			}
+			err = tx.Model(&InvoiceDB{}).Where("id = ?", allocation.InvoiceID).Updates(map[string]interface{}{
+				"amount_paid": gorm.Expr("amount_paid - ?", released),
+				"updated_at":  now,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Deallocate deletes an allocation and takes it off its invoice's amount paid
+func (r *InvoiceRepository) Deallocate(ctx context.Context, id string) (*domain.InvoiceAllocation, error) {
+	var allocationDB InvoiceAllocationDB
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&allocationDB, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domain.ErrInvoiceAllocationNotFound
+			}
+			return err
+		}
+		if err := tx.Delete(&InvoiceAllocationDB{}, "id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Model(&InvoiceDB{}).Where("id = ?", allocationDB.InvoiceID).Updates(map[string]interface{}{
+			"amount_paid": gorm.Expr("amount_paid - ?", allocationDB.Amount),
+			"updated_at":  time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return allocationDB.ToDomain(), nil
+}
+
+// PaymentAllocations returns the allocations of a payment, oldest first
+func (r *InvoiceRepository) PaymentAllocations(ctx context.Context, paymentID string) ([]*domain.InvoiceAllocation, error) {
+	var allocationsDB []InvoiceAllocationDB
+	err := r.db.WithContext(ctx).Where("payment_id = ?", paymentID).Order("created_at, id").Find(&allocationsDB).Error
+	if err != nil {
+		return nil, err
+	}
+	allocations := make([]*domain.InvoiceAllocation, len(allocationsDB))
+	for i := range allocationsDB {
+		allocations[i] = allocationsDB[i].ToDomain()
+	}
+	return allocations, nil
+}
+
+// invoiceToDB converts an invoice to its database model, leaving out allocations
+func invoiceToDB(invoice *domain.Invoice) *InvoiceDB {
+	return &InvoiceDB{
+		ID:           invoice.ID,
+		Number:       invoice.Number,
+		CustomerID:   invoice.CustomerID,
+		Currency:     invoice.Currency,
+		Lines:        invoice.Lines,
+		Subtotal:     invoice.Subtotal.MinorUnits,
+		Tax:          invoice.Tax.MinorUnits,
+		Total:        invoice.Total.MinorUnits,
+		AmountPaid:   invoice.AmountPaid.MinorUnits,
+		TaxBreakdown: invoice.TaxBreakdown,
+		DueDate:      invoice.DueDate,
+		CreatedAt:    invoice.CreatedAt,
+		UpdatedAt:    invoice.UpdatedAt,
+	}
+}
+
+// ToDomain converts the database model to a domain invoice
+func (i *InvoiceDB) ToDomain() *domain.Invoice {
+	money := func(minorUnits int64) domain.Money {
+		return domain.Money{MinorUnits: minorUnits, Currency: i.Currency}
+	}
+	invoice := &domain.Invoice{
+		ID:           i.ID,
+		Number:       i.Number,
+		CustomerID:   i.CustomerID,
+		Currency:     i.Currency,
+		Lines:        i.Lines,
+		Subtotal:     money(i.Subtotal),
+		Tax:          money(i.Tax),
+		Total:        money(i.Total),
+		AmountPaid:   money(i.AmountPaid),
+		TaxBreakdown: i.TaxBreakdown,
+		DueDate:      i.DueDate.UTC(),
+		CreatedAt:    i.CreatedAt,
+		UpdatedAt:    i.UpdatedAt,
+	}
+	for j := range i.Allocations {
+		invoice.Allocations = append(invoice.Allocations, i.Allocations[j].ToDomain())
+	}
+	return invoice
+}
+
+// allocationToDB converts an invoice allocation to its database model
+func allocationToDB(allocation *domain.InvoiceAllocation) *InvoiceAllocationDB {
+	return &InvoiceAllocationDB{
+		ID:        allocation.ID,
+		InvoiceID: allocation.InvoiceID,
+		PaymentID: allocation.PaymentID,
+		Amount:    allocation.Amount.MinorUnits,
+		Currency:  allocation.Amount.Currency,
+		CreatedAt: allocation.CreatedAt,
+	}
+}
+
+// ToDomain converts the database model to a domain invoice allocation
+func (a *InvoiceAllocationDB) ToDomain() *domain.InvoiceAllocation {
+	return &domain.InvoiceAllocation{
+		ID:        a.ID,
+		InvoiceID: a.InvoiceID,
+		PaymentID: a.PaymentID,
+		Amount:    domain.Money{MinorUnits: a.Amount, Currency: a.Currency},
+		CreatedAt: a.CreatedAt,
+	}
+}
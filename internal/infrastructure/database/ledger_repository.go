@@ -0,0 +1,127 @@
+package database
+
+import (
+	"context"
+	"payments_app/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// JournalEntryDB represents the database model for journal entries
+type JournalEntryDB struct {
+	ID          string      `gorm:"primaryKey;type:varchar(100)"`
+	PaymentID   string      `gorm:"index;type:varchar(36)"`
+	Description string      `gorm:"not null;type:text"`
+	Postings    []PostingDB `gorm:"foreignKey:EntryID"`
+	CreatedAt   time.Time   `gorm:"not null"`
+}
+
+// TableName specifies the table name for GORM
+func (JournalEntryDB) TableName() string {
+	return "journal_entries"
+}
+
+// PostingDB represents the database model for journal entry postings
+type PostingDB struct {
+	ID       uint    `gorm:"primaryKey"`
+	EntryID  string  `gorm:"not null;index;type:varchar(100)"`
+	Account  string  `gorm:"not null;index:idx_posting_account;type:varchar(100)"`
+	Currency string  `gorm:"not null;index:idx_posting_account;type:varchar(3)"`
+	Amount   float64 `gorm:"not null"`
+}
+
+// TableName specifies the table name for GORM
+func (PostingDB) TableName() string {
+	return "ledger_postings"
+}
+
+// toDomain converts JournalEntryDB to a domain JournalEntry
+func (e *JournalEntryDB) toDomain() *domain.JournalEntry {
+	entry := &domain.JournalEntry{
+		ID:          e.ID,
+		PaymentID:   e.PaymentID,
+		Description: e.Description,
+		Postings:    make([]domain.Posting, len(e.Postings)),
+		CreatedAt:   e.CreatedAt,
+	}
+	for i, posting := range e.Postings {
+		entry.Postings[i] = domain.Posting{Account: posting.Account, Currency: posting.Currency, Amount: posting.Amount}
+	}
+	return entry
+}
+
+// LedgerRepository implements domain.LedgerRepository
+type LedgerRepository struct {
+	db *gorm.DB
+}
+
+// NewLedgerRepository creates a ledger repository on an existing connection
+func NewLedgerRepository(db *gorm.DB) (*LedgerRepository, error) {
+	if err := db.AutoMigrate(&JournalEntryDB{}, &PostingDB{}); err != nil {
+		return nil, err
+	}
+	return &LedgerRepository{db: db}, nil
+}
+
+// Post stores a balanced entry and its postings in one transaction
+func (r *LedgerRepository) Post(ctx context.Context, entry *domain.JournalEntry) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&JournalEntryDB{}).Where("id = ?", entry.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return domain.ErrDuplicateJournalEntry
+		}
+
+		entryDB := &JournalEntryDB{
+			ID:          entry.ID,
+			PaymentID:   entry.PaymentID,
+			Description: entry.Description,
+			CreatedAt:   entry.CreatedAt,
+		}
+		for _, posting := range entry.Postings {
+			entryDB.Postings = append(entryDB.Postings, PostingDB{
+				Account:  posting.Account,
+				Currency: posting.Currency,
+				Amount:   posting.Amount,
+			})
+		}
+		return tx.Create(entryDB).Error
+	})
+}
+
+// EntriesForPayment returns the entries posted for a payment, oldest first
+func (r *LedgerRepository) EntriesForPayment(ctx context.Context, paymentID string) ([]*domain.JournalEntry, error) {
+	var entriesDB []JournalEntryDB
+
+	result := r.db.WithContext(ctx).Preload("Postings").
+		Where("payment_id = ?", paymentID).
+		Order("created_at").
+		Find(&entriesDB)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	entries := make([]*domain.JournalEntry, len(entriesDB))
+	for i := range entriesDB {
+		entries[i] = entriesDB[i].toDomain()
+	}
+	return entries, nil
+}
+
+// Balance returns the sum of postings to an account in a currency
+func (r *LedgerRepository) Balance(ctx context.Context, account, currency string) (float64, error) {
+	var balance float64
+
+	result := r.db.WithContext(ctx).Model(&PostingDB{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("account = ? AND currency = ?", account, currency).
+		Scan(&balance)
+	return balance, result.Error
+}
@@ -0,0 +1,147 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"payments_app/internal/domain"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// streamPageSize is how many payments Stream loads per query
+const streamPageSize = 500
+
+// List returns the payments matching filter in creation order
+func (r *PaymentRepository) List(ctx context.Context, filter domain.PaymentFilter) ([]*domain.Payment, error) {
+	var paymentsDB []PaymentDB
+
+	if err := filterPayments(r.db.WithContext(ctx), filter).Order("created_at, id").Find(&paymentsDB).Error; err != nil {
+		return nil, err
+	}
+
+	payments := make([]*domain.Payment, len(paymentsDB))
+	for i := range paymentsDB {
+		payments[i] = paymentsDB[i].ToDomain()
+	}
+	return payments, nil
+}
+
+// Stream pages through the payments matching filter in creation order with a keyset cursor
+// on (created_at, id). All pages are read in one transaction, so the result is a snapshot:
+// payments created, changed or deleted while streaming are neither missed nor repeated.
+func (r *PaymentRepository) Stream(ctx context.Context, filter domain.PaymentFilter, fn func(*domain.Payment) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var last *PaymentDB
+		for {
+			query := filterPayments(tx, filter)
+			if last != nil {
+				query = query.Where("created_at > ? OR (created_at = ? AND id > ?)", last.CreatedAt, last.CreatedAt, last.ID)
+			}
+			var page []PaymentDB
+			if err := query.Order("created_at, id").Limit(streamPageSize).Find(&page).Error; err != nil {
+				return err
+			}
+			for i := range page {
+				if err := fn(page[i].ToDomain()); err != nil {
+					return err
+				}
+			}
+			if len(page) < streamPageSize {
+				return nil
+			}
+			last = &page[len(page)-1]
+		}
+	})
+}
+
+// filterPayments adds the conditions of filter to query
+func filterPayments(query *gorm.DB, filter domain.PaymentFilter) *gorm.DB {
+	query = query.Model(&PaymentDB{})
+	if len(filter.Statuses) > 0 {
+		statuses := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			statuses[i] = string(status)
+		}
+		query = query.Where("status IN ?", statuses)
+	}
+	if filter.Currency != "" {
+		query = query.Where("currency = ?", filter.Currency)
+	}
+	if filter.PayerID != "" {
+		query = query.Where("payer_id = ?", filter.PayerID)
+	}
+	if filter.TenantID != "" {
+		query = query.Where("tenant_id = ?", filter.TenantID)
+	}
+	if filter.CustomerID != "" {
+		query = query.Where("customer_id = ?", filter.CustomerID)
+	}
+	keys := make([]string, 0, len(filter.Metadata))
+	for key := range filter.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !domain.ValidMetadataKey(key) {
+			query.AddError(fmt.Errorf("invalid metadata key %q", key))
+			return query
+		}
+		query = query.Where(metadataExpression(key)+" = ?", filter.Metadata[key])
+	}
+	for _, tag := range filter.Tags {
+		query = query.Where("EXISTS (SELECT 1 FROM json_each(payments.tags) WHERE json_each.value = ?)", tag)
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("created_at < ?", *filter.CreatedTo)
+	}
+	return query
+}
+
+// metadataIndexPrefix names the indexes created by IndexMetadataKeys
+const metadataIndexPrefix = "idx_payments_metadata_"
+
+// metadataExpression reads one metadata value. Filters and indexes use the same text, which
+// lets SQLite answer filters on indexed keys from the index. The key must be valid.
+func metadataExpression(key string) string {
+	return fmt.Sprintf(`json_extract(metadata, '$."%s"')`, key)
+}
+
+// IndexMetadataKeys makes the given metadata keys searchable through an expression index each,
+// and drops the indexes of keys that are no longer declared
+func (r *PaymentRepository) IndexMetadataKeys(ctx context.Context, keys []string) error {
+	declared := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if !domain.ValidMetadataKey(key) {
+			return fmt.Errorf("invalid metadata key %q", key)
+		}
+		declared[metadataIndexPrefix+key] = true
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing []string
+		err := tx.Raw("SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = 'payments' AND name LIKE ?", metadataIndexPrefix+"%").
+			Scan(&existing).Error
+		if err != nil {
+			return err
+		}
+		for _, name := range existing {
+			if !declared[name] && strings.HasPrefix(name, metadataIndexPrefix) {
+				if err := tx.Exec(fmt.Sprintf(`DROP INDEX IF EXISTS "%s"`, name)).Error; err != nil {
+					return err
+				}
+			}
+		}
+		for _, key := range keys {
+			statement := fmt.Sprintf(`CREATE INDEX IF NOT EXISTS "%s" ON payments (%s)`, metadataIndexPrefix+key, metadataExpression(key))
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
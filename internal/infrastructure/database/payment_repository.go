@@ -10,16 +10,83 @@ import (
 	"gorm.io/gorm"
 )
 
+// PartyDB represents a payment counterparty embedded in the payments table
+type PartyDB struct {
+	Name    string `gorm:"type:varchar(200)" json:"name"`
+	Account string `gorm:"type:varchar(100)" json:"account"`
+	Country string `gorm:"type:varchar(2)" json:"country"`
+}
+
+// PaymentMethodDB stores the details of a payment method as JSON; card PANs are never part of it
+type PaymentMethodDB struct {
+	Card        *domain.CardMethod        `json:"card,omitempty"`
+	BankAccount *domain.BankAccountMethod `json:"bankAccount,omitempty"`
+	Wallet      *domain.WalletMethod      `json:"wallet,omitempty"`
+}
+
 // PaymentDB represents the database model for payments
 type PaymentDB struct {
-	ID          string         `gorm:"primaryKey;type:varchar(36)" json:"id"`
-	Amount      float64        `gorm:"not null" json:"amount"`
-	Currency    string         `gorm:"not null;type:varchar(3)" json:"currency"`
-	Description string         `gorm:"not null;type:text" json:"description"`
-	Status      string         `gorm:"not null;type:varchar(20);default:'PENDING'" json:"status"`
-	CreatedAt   time.Time      `gorm:"not null" json:"createdAt"`
-	UpdatedAt   time.Time      `gorm:"not null" json:"updatedAt"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deletedAt,omitempty"`
+	ID             string     `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	Amount         float64    `gorm:"not null" json:"amount"`
+	Currency       string     `gorm:"not null;type:varchar(3)" json:"currency"`
+	Description    string     `gorm:"not null;type:text" json:"description"`
+	Status         string     `gorm:"not null;type:varchar(20);default:'PENDING'" json:"status"`
+	PayerID        string     `gorm:"index;type:varchar(100)" json:"payerId"`
+	TenantID       string     `gorm:"index;type:varchar(100)" json:"tenantId"`
+	CustomerID     string     `gorm:"index;type:varchar(36)" json:"customerId"`
+	SubscriptionID string     `gorm:"index;type:varchar(36)" json:"subscriptionId"`
+	ExecuteAt      *time.Time `gorm:"index" json:"executeAt"`
+	// LeaseOwner and LeaseExpiresAt mark a scheduled payment claimed by a runner
+	LeaseOwner     string     `gorm:"index;type:varchar(36)" json:"-"`
+	LeaseExpiresAt *time.Time `json:"-"`
+	RiskScore      *int       `json:"riskScore"`
+	RiskDecision   string     `gorm:"type:varchar(10)" json:"riskDecision"`
+	RiskReasons    []string   `gorm:"serializer:json;type:text" json:"riskReasons"`
+	RiskReviewedBy string     `gorm:"type:varchar(100)" json:"riskReviewedBy"`
+	RiskReviewNote string     `gorm:"type:text" json:"riskReviewNote"`
+	RiskReviewedAt *time.Time `json:"riskReviewedAt"`
+	Payer          PartyDB    `gorm:"embedded;embeddedPrefix:payer_" json:"payer"`
+	Payee          PartyDB    `gorm:"embedded;embeddedPrefix:payee_" json:"payee"`
+
+	MethodType    string           `gorm:"index;type:varchar(20)" json:"methodType"`
+	MethodDetails *PaymentMethodDB `gorm:"serializer:json;type:text" json:"methodDetails"`
+
+	ScreeningStatus     string                `gorm:"type:varchar(10)" json:"screeningStatus"`
+	ScreeningHits       []domain.ScreeningHit `gorm:"serializer:json;type:text" json:"screeningHits"`
+	ScreeningReviewedBy string                `gorm:"type:varchar(100)" json:"screeningReviewedBy"`
+	ScreeningReviewNote string                `gorm:"type:text" json:"screeningReviewNote"`
+	ScreeningReviewedAt *time.Time            `json:"screeningReviewedAt"`
+
+	Processor          string  `gorm:"type:varchar(50)" json:"processor"`
+	ProcessorReference string  `gorm:"index;type:varchar(100)" json:"processorReference"`
+	ProcessorResponse  string  `gorm:"type:text" json:"processorResponse"`
+	RefundedAmount     float64 `gorm:"not null;default:0" json:"refundedAmount"`
+
+	Route []domain.RouteAttempt `gorm:"serializer:json;type:text" json:"route"`
+	// SubmissionID is the ID of the credit transfer or ACH file that sent the payment
+	SubmissionID string `gorm:"index;type:varchar(35)" json:"submissionId"`
+
+	// Settlement columns hold the converted amount and the rate snapshot it was converted with;
+	// SettlementCurrency is empty when the payment settles in its own currency
+	SettlementAmount   int64      `gorm:"not null;default:0" json:"settlementAmount"`
+	SettlementCurrency string     `gorm:"index;type:varchar(3)" json:"settlementCurrency"`
+	FXRate             string     `gorm:"type:varchar(40)" json:"fxRate"`
+	FXRateAsOf         *time.Time `json:"fxRateAsOf"`
+	FXRateSource       string     `gorm:"type:varchar(100)" json:"fxRateSource"`
+	FXConvertedAt      *time.Time `json:"fxConvertedAt"`
+
+	Fees   []domain.Fee   `gorm:"serializer:json;type:text" json:"fees"`
+	Splits []domain.Split `gorm:"serializer:json;type:text" json:"splits"`
+
+	Tax *domain.TaxBreakdown `gorm:"serializer:json;type:text" json:"tax"`
+
+	// Metadata is queried with json_extract; declared keys are indexed by IndexMetadataKeys
+	Metadata map[string]string `gorm:"serializer:json;type:text" json:"metadata"`
+	Tags     []string          `gorm:"serializer:json;type:text" json:"tags"`
+
+	CreatedAt time.Time      `gorm:"not null" json:"createdAt"`
+	UpdatedAt time.Time      `gorm:"not null" json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deletedAt,omitempty"`
 }
 
 // TableName specifies the table name for GORM
@@ -29,15 +96,74 @@ func (PaymentDB) TableName() string {
 
 // ToDomain converts PaymentDB to domain Payment
 func (p *PaymentDB) ToDomain() *domain.Payment {
-	return &domain.Payment{
+	payment := &domain.Payment{
 		ID:          p.ID,
 		Amount:      p.Amount,
 		Currency:    p.Currency,
 		Description: p.Description,
 		Status:      domain.PaymentStatus(p.Status),
-		CreatedAt:   p.CreatedAt,
-		UpdatedAt:   p.UpdatedAt,
+		PayerID:     p.PayerID,
+		TenantID:    p.TenantID,
+		CustomerID:  p.CustomerID,
+		Metadata:    p.Metadata,
+		Tags:        p.Tags,
+
+		SubscriptionID: p.SubscriptionID,
+		ExecuteAt:      p.ExecuteAt,
+
+		Processor:          p.Processor,
+		ProcessorReference: p.ProcessorReference,
+		ProcessorResponse:  p.ProcessorResponse,
+		RefundedAmount:     p.RefundedAmount,
+		Route:              p.Route,
+		SubmissionID:       p.SubmissionID,
+		Fees:               p.Fees,
+		Splits:             p.Splits,
+		Tax:                p.Tax,
+
+		CreatedAt: p.CreatedAt,
+		UpdatedAt: p.UpdatedAt,
+	}
+	if p.RiskScore != nil {
+		payment.Risk = &domain.RiskAssessment{
+			Score:      *p.RiskScore,
+			Decision:   domain.RiskDecision(p.RiskDecision),
+			Reasons:    p.RiskReasons,
+			ReviewedBy: p.RiskReviewedBy,
+			ReviewNote: p.RiskReviewNote,
+			ReviewedAt: p.RiskReviewedAt,
+		}
+	}
+	payment.Method = p.methodToDomain()
+	payment.Payer = p.Payer.toDomain()
+	payment.Payee = p.Payee.toDomain()
+	if p.ScreeningStatus != "" {
+		payment.Screening = &domain.ScreeningResult{
+			Status:     domain.ScreeningStatus(p.ScreeningStatus),
+			Hits:       p.ScreeningHits,
+			ReviewedBy: p.ScreeningReviewedBy,
+			ReviewNote: p.ScreeningReviewNote,
+			ReviewedAt: p.ScreeningReviewedAt,
+		}
+	}
+	if p.SettlementCurrency != "" {
+		payment.Settlement = &domain.Settlement{
+			Amount: domain.Money{MinorUnits: p.SettlementAmount, Currency: p.SettlementCurrency},
+			Rate: domain.FXRate{
+				Base:   p.Currency,
+				Quote:  p.SettlementCurrency,
+				Rate:   p.FXRate,
+				Source: p.FXRateSource,
+			},
+		}
+		if p.FXRateAsOf != nil {
+			payment.Settlement.Rate.AsOf = *p.FXRateAsOf
+		}
+		if p.FXConvertedAt != nil {
+			payment.Settlement.ConvertedAt = *p.FXConvertedAt
+		}
 	}
+	return payment
 }
 
 // FromDomain converts domain Payment to PaymentDB
@@ -47,10 +173,120 @@ func (p *PaymentDB) FromDomain(payment *domain.Payment) {
 	p.Currency = payment.Currency
 	p.Description = payment.Description
 	p.Status = string(payment.Status)
+	p.PayerID = payment.PayerID
+	p.TenantID = payment.TenantID
+	p.CustomerID = payment.CustomerID
+	p.Metadata = payment.Metadata
+	p.Tags = payment.Tags
+	p.SubscriptionID = payment.SubscriptionID
+	if payment.ExecuteAt != nil {
+		// Stored in UTC so due times compare correctly as text
+		executeAt := payment.ExecuteAt.UTC()
+		p.ExecuteAt = &executeAt
+	}
+	if payment.Risk != nil {
+		score := payment.Risk.Score
+		p.RiskScore = &score
+		p.RiskDecision = string(payment.Risk.Decision)
+		p.RiskReasons = payment.Risk.Reasons
+		p.RiskReviewedBy = payment.Risk.ReviewedBy
+		p.RiskReviewNote = payment.Risk.ReviewNote
+		p.RiskReviewedAt = payment.Risk.ReviewedAt
+	}
+	p.methodFromDomain(payment.Method)
+	p.Payer = partyFromDomain(payment.Payer)
+	p.Payee = partyFromDomain(payment.Payee)
+	if payment.Screening != nil {
+		p.ScreeningStatus = string(payment.Screening.Status)
+		p.ScreeningHits = payment.Screening.Hits
+		p.ScreeningReviewedBy = payment.Screening.ReviewedBy
+		p.ScreeningReviewNote = payment.Screening.ReviewNote
+		p.ScreeningReviewedAt = payment.Screening.ReviewedAt
+	}
+	p.Processor = payment.Processor
+	p.ProcessorReference = payment.ProcessorReference
+	p.ProcessorResponse = payment.ProcessorResponse
+	p.RefundedAmount = payment.RefundedAmount
+	p.Route = payment.Route
+	p.SubmissionID = payment.SubmissionID
+	p.Fees = payment.Fees
+	p.Splits = payment.Splits
+	p.Tax = payment.Tax
+	if payment.Settlement != nil {
+		asOf, convertedAt := payment.Settlement.Rate.AsOf.UTC(), payment.Settlement.ConvertedAt.UTC()
+		p.SettlementAmount = payment.Settlement.Amount.MinorUnits
+		p.SettlementCurrency = payment.Settlement.Amount.Currency
+		p.FXRate = payment.Settlement.Rate.Rate
+		p.FXRateAsOf = &asOf
+		p.FXRateSource = payment.Settlement.Rate.Source
+		p.FXConvertedAt = &convertedAt
+	}
 	p.CreatedAt = payment.CreatedAt
 	p.UpdatedAt = payment.UpdatedAt
 }
 
+// methodToDomain converts the stored method details to a domain PaymentMethod
+func (p *PaymentDB) methodToDomain() domain.PaymentMethod {
+	return methodToDomain(p.MethodType, p.MethodDetails)
+}
+
+// methodFromDomain stores a domain PaymentMethod as its type and JSON details
+func (p *PaymentDB) methodFromDomain(method domain.PaymentMethod) {
+	p.MethodType, p.MethodDetails = methodFromDomain(method)
+}
+
+// methodToDomain converts a stored method type and details to a domain PaymentMethod
+func methodToDomain(methodType string, details *PaymentMethodDB) domain.PaymentMethod {
+	if details == nil {
+		return nil
+	}
+	switch domain.PaymentMethodType(methodType) {
+	case domain.PaymentMethodTypeCard:
+		if details.Card != nil {
+			return *details.Card
+		}
+	case domain.PaymentMethodTypeBankAccount:
+		if details.BankAccount != nil {
+			return *details.BankAccount
+		}
+	case domain.PaymentMethodTypeWallet:
+		if details.Wallet != nil {
+			return *details.Wallet
+		}
+	}
+	return nil
+}
+
+// methodFromDomain splits a domain PaymentMethod into its type and JSON details
+func methodFromDomain(method domain.PaymentMethod) (string, *PaymentMethodDB) {
+	switch m := method.(type) {
+	case domain.CardMethod:
+		return string(m.MethodType()), &PaymentMethodDB{Card: &m}
+	case domain.BankAccountMethod:
+		return string(m.MethodType()), &PaymentMethodDB{BankAccount: &m}
+	case domain.WalletMethod:
+		return string(m.MethodType()), &PaymentMethodDB{Wallet: &m}
+	default:
+		return "", nil
+	}
+}
+
+// toDomain converts PartyDB to a domain Party, returning nil when no party was stored
+func (p PartyDB) toDomain() *domain.Party {
+	if p.Name == "" {
+		return nil
+	}
+	return &domain.Party{Name: p.Name, Account: p.Account, Country: p.Country}
+}
+
+// partyFromDomain converts a domain Party to PartyDB
+func partyFromDomain(party *domain.Party) PartyDB {
+	if party == nil {
+		return PartyDB{}
+	}
+	return PartyDB{Name: party.Name, Account: party.Account, Country: party.Country}
+}
+
 // PaymentRepository implements domain.PaymentRepository
 type PaymentRepository struct {
 	db *gorm.DB
@@ -63,6 +299,11 @@ func NewPaymentRepository(dbPath string) (*PaymentRepository, error) {
 		return nil, err
 	}
 
+	// Write-ahead logging lets long reads, such as exports, keep their snapshot without blocking writers
+	if err := db.Exec("PRAGMA journal_mode=WAL").Error; err != nil {
+		return nil, err
+	}
+
 	// Auto-migrate the schema
 	err = db.AutoMigrate(&PaymentDB{})
 	if err != nil {
@@ -81,6 +322,20 @@ func (r *PaymentRepository) Create(ctx context.Context, payment *domain.Payment)
 	return result.Error
 }
 
+// CreateBatch saves several payments in one transaction; none is saved if any fails
+func (r *PaymentRepository) CreateBatch(ctx context.Context, payments []*domain.Payment) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, payment := range payments {
+			paymentDB := &PaymentDB{}
+			paymentDB.FromDomain(payment)
+			if err := tx.Create(paymentDB).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 // GetByID retrieves a payment by ID from the database
 func (r *PaymentRepository) GetByID(ctx context.Context, id string) (*domain.Payment, error) {
 	var paymentDB PaymentDB
@@ -113,6 +368,41 @@ func (r *PaymentRepository) GetAll(ctx context.Context) ([]*domain.Payment, erro
 	return payments, nil
 }
 
+// PayerPayments retrieves a payer's payments created at or after the given time
+func (r *PaymentRepository) PayerPayments(ctx context.Context, payerID string, since time.Time) ([]*domain.Payment, error) {
+	var paymentsDB []PaymentDB
+
+	result := r.db.WithContext(ctx).
+		Where("payer_id = ? AND created_at >= ?", payerID, since).
+		Order("created_at").
+		Find(&paymentsDB)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	payments := make([]*domain.Payment, len(paymentsDB))
+	for i, paymentDB := range paymentsDB {
+		payments[i] = paymentDB.ToDomain()
+	}
+
+	return payments, nil
+}
+
+// GetByProcessorReference retrieves the payment a processor knows by the given reference
+func (r *PaymentRepository) GetByProcessorReference(ctx context.Context, processor, reference string) (*domain.Payment, error) {
+	var paymentDB PaymentDB
+
+	result := r.db.WithContext(ctx).First(&paymentDB, "processor = ? AND processor_reference = ?", processor, reference)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("payment not found")
+		}
+		return nil, result.Error
+	}
+
+	return paymentDB.ToDomain(), nil
+}
+
 // Update updates an existing payment in the database
 func (r *PaymentRepository) Update(ctx context.Context, payment *domain.Payment) error {
 	paymentDB := &PaymentDB{}
@@ -136,6 +426,11 @@ func (r *PaymentRepository) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// DB returns the underlying connection so related repositories can share it
+func (r *PaymentRepository) DB() *gorm.DB {
+	return r.db
+}
+
 // Close closes the database connection
 func (r *PaymentRepository) Close() error {
 	sqlDB, err := r.db.DB()
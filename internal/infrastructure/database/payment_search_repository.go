@@ -0,0 +1,367 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"payments_app/internal/domain"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// Searchable text of a payment, as SQL over the payment row named by alias: its description,
+// the names of its customer and payer, and the references support staff are given (payment ID,
+// processor reference, customer reference, metadata values and tags)
+const (
+	searchDescriptionColumn = `%[1]s.description`
+	searchCustomerColumn    = `TRIM(COALESCE((SELECT c.name FROM customers c WHERE c.id = %[1]s.customer_id), '') || ' ' || COALESCE(%[1]s.payer_name, ''))`
+	searchReferenceColumn   = `TRIM(%[1]s.id || ' ' || COALESCE(%[1]s.processor_reference, '') || ' ' ||
+		COALESCE((SELECT c.external_reference FROM customers c WHERE c.id = %[1]s.customer_id), '') || ' ' ||
+		COALESCE((SELECT group_concat(value, ' ') FROM json_each(CASE WHEN json_valid(%[1]s.metadata) THEN %[1]s.metadata END)), '') || ' ' ||
+		COALESCE((SELECT group_concat(value, ' ') FROM json_each(CASE WHEN json_valid(%[1]s.tags) THEN %[1]s.tags END)), ''))`
+)
+
+// searchWeights weigh matches in the description, customer and reference columns; names and
+// references identify a payment better than words of its description
+var searchWeights = [3]float64{1, 2, 2}
+
+// Snippets show about snippetWords words around the first match
+const (
+	snippetWords = 12
+	markOpen     = "<mark>"
+	markClose    = "</mark>"
+	ellipsis     = "…"
+)
+
+// searchColumns returns the searchable columns of the payment row named by alias
+func searchColumns(alias string) string {
+	return fmt.Sprintf(searchDescriptionColumn+" AS description, "+searchCustomerColumn+" AS customer, "+
+		searchReferenceColumn+" AS reference", alias)
+}
+
+// PaymentSearchRepository implements domain.PaymentSearchRepository. When SQLite is built with
+// FTS5 (the sqlite_fts5 build tag), payments are indexed in the payments_fts table, which
+// triggers keep in sync with the payments and customers tables. Without FTS5 every search scans
+// the payments.
+type PaymentSearchRepository struct {
+	db       *gorm.DB
+	fullText bool
+}
+
+// NewPaymentSearchRepository creates the search index on an existing connection, indexing the
+// payments stored so far when the index is new
+func NewPaymentSearchRepository(db *gorm.DB) (*PaymentSearchRepository, error) {
+	// Customer names are part of the index
+	if err := db.AutoMigrate(&CustomerDB{}); err != nil {
+		return nil, err
+	}
+
+	var fullText bool
+	if err := db.Raw("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&fullText).Error; err != nil {
+		return nil, err
+	}
+	if !fullText {
+		return &PaymentSearchRepository{db: db}, nil
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'payments_fts'").Scan(&existing).Error; err != nil {
+			return err
+		}
+		err := tx.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS payments_fts USING fts5(
+			payment_id UNINDEXED, description, customer, reference,
+			tokenize = 'unicode61 remove_diacritics 2', prefix = '2 3')`).Error
+		if err != nil {
+			return err
+		}
+		for _, statement := range searchTriggers() {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		if existing == 0 {
+			return tx.Exec("INSERT INTO payments_fts (payment_id, description, customer, reference) SELECT p.id, " +
+				searchColumns("p") + " FROM payments p WHERE p.deleted_at IS NULL").Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &PaymentSearchRepository{db: db, fullText: true}, nil
+}
+
+// FullText reports whether searches use the FTS5 index
+func (r *PaymentSearchRepository) FullText() bool {
+	return r.fullText
+}
+
+// searchTriggers returns the statements that (re)create the triggers keeping payments_fts in sync
+func searchTriggers() []string {
+	// Rows are found by matching the payment ID, which is part of the reference column, so
+	// updates use the index instead of scanning payments_fts
+	remove := func(alias string) string {
+		return fmt.Sprintf(`DELETE FROM payments_fts WHERE payments_fts MATCH 'reference:"' || replace(%[1]s.id, '"', '""') || '"' AND payment_id = %[1]s.id;`, alias)
+	}
+	insert := "INSERT INTO payments_fts (payment_id, description, customer, reference) SELECT new.id, " + searchColumns("new") +
+		" WHERE new.deleted_at IS NULL;"
+	changed := `old.description IS NOT new.description OR old.customer_id IS NOT new.customer_id OR
+		old.payer_name IS NOT new.payer_name OR old.processor_reference IS NOT new.processor_reference OR
+		old.metadata IS NOT new.metadata OR old.tags IS NOT new.tags OR old.deleted_at IS NOT new.deleted_at`
+
+	return []string{
+		"DROP TRIGGER IF EXISTS payments_fts_insert",
+		"DROP TRIGGER IF EXISTS payments_fts_update",
+		"DROP TRIGGER IF EXISTS payments_fts_delete",
+		"DROP TRIGGER IF EXISTS payments_fts_customer",
+		"CREATE TRIGGER payments_fts_insert AFTER INSERT ON payments BEGIN " + insert + " END",
+		"CREATE TRIGGER payments_fts_update AFTER UPDATE ON payments WHEN " + changed + " BEGIN " + remove("old") + " " + insert + " END",
+		"CREATE TRIGGER payments_fts_delete AFTER DELETE ON payments BEGIN " + remove("old") + " END",
+		// Renaming or erasing a customer changes the text of all its payments
+		`CREATE TRIGGER payments_fts_customer AFTER UPDATE ON customers
+		WHEN old.name IS NOT new.name OR old.external_reference IS NOT new.external_reference BEGIN
+			DELETE FROM payments_fts WHERE payment_id IN (SELECT id FROM payments WHERE customer_id = new.id);
+			INSERT INTO payments_fts (payment_id, description, customer, reference) SELECT p.id, ` + searchColumns("p") + `
+				FROM payments p WHERE p.customer_id = new.id AND p.deleted_at IS NULL;
+		END`,
+	}
+}
+
+// searchHit is a matching payment ID with its rank and snippet
+type searchHit struct {
+	PaymentID string
+	Rank      float64
+	Snippet   string
+}
+
+// Search returns one page of the payments matching every term, best matches first
+func (r *PaymentSearchRepository) Search(ctx context.Context, search domain.PaymentSearch) (*domain.PaymentSearchResults, error) {
+	var (
+		hits  []searchHit
+		total int
+		err   error
+	)
+	if r.fullText {
+		hits, total, err = r.searchIndex(ctx, search)
+	} else {
+		hits, total, err = r.searchScan(ctx, search)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	results := &domain.PaymentSearchResults{Hits: []*domain.PaymentSearchHit{}, TotalCount: total}
+	if len(hits) > search.First {
+		results.HasNextPage = true
+		hits = hits[:search.First]
+	}
+	if len(hits) == 0 {
+		return results, nil
+	}
+
+	ids := make([]string, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.PaymentID
+	}
+	var paymentsDB []PaymentDB
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&paymentsDB).Error; err != nil {
+		return nil, err
+	}
+	payments := make(map[string]*domain.Payment, len(paymentsDB))
+	for i := range paymentsDB {
+		payments[paymentsDB[i].ID] = paymentsDB[i].ToDomain()
+	}
+	for _, hit := range hits {
+		if payment, ok := payments[hit.PaymentID]; ok {
+			results.Hits = append(results.Hits, &domain.PaymentSearchHit{Payment: payment, Rank: hit.Rank, Snippet: hit.Snippet})
+		}
+	}
+	return results, nil
+}
+
+// searchIndex searches payments_fts, ranking by BM25 with the column weights. It reads one hit
+// more than the page to tell whether another page follows.
+func (r *PaymentSearchRepository) searchIndex(ctx context.Context, search domain.PaymentSearch) ([]searchHit, int, error) {
+	match := matchExpression(search.Terms)
+	bm25 := fmt.Sprintf("bm25(payments_fts, 0, %g, %g, %g)", searchWeights[0], searchWeights[1], searchWeights[2])
+
+	var total int
+	if err := r.db.WithContext(ctx).Raw("SELECT COUNT(*) FROM payments_fts WHERE payments_fts MATCH ?", match).Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var hits []searchHit
+	err := r.db.WithContext(ctx).Raw(
+		"SELECT payment_id, -"+bm25+" AS rank, snippet(payments_fts, -1, ?, ?, ?, ?) AS snippet FROM payments_fts "+
+			"WHERE payments_fts MATCH ? ORDER BY "+bm25+", payment_id LIMIT ? OFFSET ?",
+		markOpen, markClose, ellipsis, snippetWords, match, search.First+1, search.Offset,
+	).Scan(&hits).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return hits, total, nil
+}
+
+// matchExpression builds an FTS5 query requiring every term as a prefix. Each term is quoted,
+// so its characters are never read as query syntax.
+func matchExpression(terms []string) string {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"*`
+	}
+	return strings.Join(quoted, " ")
+}
+
+// searchScan finds the payments containing every term with LIKE, and then ranks and excerpts
+// them like the index does: a term must start a word, and each column matching a term adds its
+// weight to the rank
+func (r *PaymentSearchRepository) searchScan(ctx context.Context, search domain.PaymentSearch) ([]searchHit, int, error) {
+	query := r.db.WithContext(ctx).Table("(SELECT p.id AS payment_id, " + searchColumns("p") +
+		" FROM payments p WHERE p.deleted_at IS NULL) AS searchable")
+	// LIKE narrows the rows down by one word of each term; words have no wildcard characters
+	for _, term := range search.Terms {
+		words := searchWords(term)
+		pattern := "%" + words[len(words)-1] + "%"
+		query = query.Where("(description LIKE ? OR customer LIKE ? OR reference LIKE ?)", pattern, pattern, pattern)
+	}
+	var rows []struct {
+		PaymentID   string
+		Description string
+		Customer    string
+		Reference   string
+	}
+	if err := query.Select("payment_id, description, customer, reference").Scan(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var hits []searchHit
+	for _, row := range rows {
+		if hit, ok := scanHit(row.PaymentID, [3]string{row.Description, row.Customer, row.Reference}, search.Terms); ok {
+			hits = append(hits, hit)
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Rank != hits[j].Rank {
+			return hits[i].Rank > hits[j].Rank
+		}
+		return hits[i].PaymentID < hits[j].PaymentID
+	})
+
+	total := len(hits)
+	if search.Offset >= len(hits) {
+		return nil, total, nil
+	}
+	hits = hits[search.Offset:]
+	if len(hits) > search.First+1 {
+		hits = hits[:search.First+1]
+	}
+	return hits, total, nil
+}
+
+// scanHit ranks the columns of one payment, reporting false unless every term starts a word
+func scanHit(paymentID string, columns [3]string, terms []string) (searchHit, bool) {
+	hit := searchHit{PaymentID: paymentID}
+	best, bestScore := 0, 0.0
+	for i, column := range columns {
+		score := 0.0
+		for _, term := range terms {
+			if containsPrefix(column, term) {
+				score += searchWeights[i]
+			}
+		}
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+		hit.Rank += score
+	}
+	for _, term := range terms {
+		found := false
+		for _, column := range columns {
+			if containsPrefix(column, term) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return hit, false
+		}
+	}
+	hit.Snippet = snippet(columns[best], terms)
+	return hit, true
+}
+
+// searchWords splits text into words the way the unicode61 tokenizer does, on every character
+// that is not a letter or digit
+func searchWords(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// containsPrefix reports whether every word of term starts a word of text, in order, ignoring case
+func containsPrefix(text, term string) bool {
+	words, termWords := searchWords(strings.ToLower(text)), searchWords(strings.ToLower(term))
+	if len(termWords) == 0 {
+		return false
+	}
+	for start := 0; start+len(termWords) <= len(words); start++ {
+		matched := true
+		for i, termWord := range termWords {
+			last := i == len(termWords)-1
+			if last && !strings.HasPrefix(words[start+i], termWord) || !last && words[start+i] != termWord {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// snippet excerpts up to snippetWords words of text from the first word matching a term, with
+// matching words marked
+func snippet(text string, terms []string) string {
+	fields := strings.Fields(text)
+	matches := func(field string) bool {
+		for _, word := range searchWords(strings.ToLower(field)) {
+			for _, term := range terms {
+				for _, termWord := range searchWords(strings.ToLower(term)) {
+					if strings.HasPrefix(word, termWord) {
+						return true
+					}
+				}
+			}
+		}
+		return false
+	}
+
+	first := 0
+	for i, field := range fields {
+		if matches(field) {
+			first = i
+			break
+		}
+	}
+	start := max(0, first-snippetWords/4)
+	end := min(len(fields), start+snippetWords)
+
+	excerpt := make([]string, 0, end-start)
+	for _, field := range fields[start:end] {
+		if matches(field) {
+			field = markOpen + field + markClose
+		}
+		excerpt = append(excerpt, field)
+	}
+	result := strings.Join(excerpt, " ")
+	if start > 0 {
+		result = ellipsis + result
+	}
+	if end < len(fields) {
+		result += ellipsis
+	}
+	return result
+}
@@ -0,0 +1,192 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"payments_app/internal/domain"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// createdEpoch is the creation time of a payment in Unix seconds; SQLite applies the
+// offset stored with the timestamp
+const createdEpoch = "CAST(strftime('%s', created_at) AS INTEGER)"
+
+// statsPercentiles are the percentiles computed for every group
+var statsPercentiles = []int{50, 90, 95, 99}
+
+type statsRow struct {
+	Month    string
+	Day      string
+	Status   string
+	Currency string
+	Count    int64
+	Sum      int64
+	Min      int64
+	Max      int64
+	P50      int64
+	P90      int64
+	P95      int64
+	P99      int64
+}
+
+// PaymentStats aggregates the payments matching the query in SQL. Amounts are converted to
+// integer minor units before they are added up, so sums are exact; percentiles are picked
+// by rank with window functions. Both queries run in one transaction.
+func (r *PaymentRepository) PaymentStats(ctx context.Context, query domain.PaymentStatsQuery) ([]*domain.PaymentStatsGroup, error) {
+	var rows []statsRow
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Groups are ordered by the keys in the order they were requested, then by currency
+		var keys, columns []string
+		var local string
+		for _, dimension := range query.GroupBy {
+			if (dimension == domain.StatsDimensionDay || dimension == domain.StatsDimensionMonth) && local == "" {
+				var err error
+				if local, err = localEpoch(tx, query); err != nil {
+					return err
+				}
+			}
+			switch dimension {
+			case domain.StatsDimensionCurrency:
+				keys = append(keys, "currency")
+			case domain.StatsDimensionStatus:
+				keys = append(keys, "status")
+				columns = append(columns, "status")
+			case domain.StatsDimensionDay:
+				keys = append(keys, "day")
+				columns = append(columns, fmt.Sprintf("strftime('%%Y-%%m-%%d', %s, 'unixepoch') AS day", local))
+			case domain.StatsDimensionMonth:
+				keys = append(keys, "month")
+				columns = append(columns, fmt.Sprintf("strftime('%%Y-%%m', %s, 'unixepoch') AS month", local))
+			}
+		}
+		// Amounts are always aggregated per currency
+		if !query.Groups(domain.StatsDimensionCurrency) {
+			keys = append(keys, "currency")
+		}
+		columns = append(columns, "currency", minorUnits()+" AS minor")
+		partition := strings.Join(keys, ", ")
+
+		grouped := filterPayments(tx, query.Filter).Select(strings.Join(columns, ", "))
+		ranked := tx.Table("(?) AS grouped", grouped).
+			Select(fmt.Sprintf("*, ROW_NUMBER() OVER (PARTITION BY %[1]s ORDER BY minor) AS position, COUNT(*) OVER (PARTITION BY %[1]s) AS total", partition))
+
+		aggregates := append(append([]string{}, keys...), "COUNT(*) AS count", "SUM(minor) AS sum", "MIN(minor) AS min", "MAX(minor) AS max")
+		for _, p := range statsPercentiles {
+			// Nearest rank: the smallest amount with at least p percent of the group at or below it
+			aggregates = append(aggregates, fmt.Sprintf("MAX(CASE WHEN position = (total * %[1]d + 99) / 100 THEN minor END) AS p%[1]d", p))
+		}
+		return tx.Table("(?) AS ranked", ranked).
+			Select(strings.Join(aggregates, ", ")).
+			Group(partition).
+			Order(partition).
+			Scan(&rows).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Rows are per currency; without currency grouping, consecutive rows with the same keys form one group
+	byCurrency := query.Groups(domain.StatsDimensionCurrency)
+	var groups []*domain.PaymentStatsGroup
+	for _, row := range rows {
+		var group *domain.PaymentStatsGroup
+		if last := len(groups) - 1; !byCurrency && last >= 0 &&
+			groups[last].Month == row.Month && groups[last].Day == row.Day && string(groups[last].Status) == row.Status {
+			group = groups[last]
+		}
+		if group == nil {
+			group = &domain.PaymentStatsGroup{Month: row.Month, Day: row.Day, Status: domain.PaymentStatus(row.Status)}
+			if byCurrency {
+				group.Currency = row.Currency
+			}
+			groups = append(groups, group)
+		}
+		group.Count += row.Count
+		group.Amounts = append(group.Amounts, row.toDomain())
+	}
+	return groups, nil
+}
+
+func (row statsRow) toDomain() *domain.AmountStats {
+	money := func(minorUnits int64) domain.Money {
+		return domain.Money{MinorUnits: minorUnits, Currency: row.Currency}
+	}
+	sum := money(row.Sum)
+	return &domain.AmountStats{
+		Currency: row.Currency,
+		Count:    row.Count,
+		Sum:      sum,
+		Average:  sum.DivideRounded(row.Count),
+		Min:      money(row.Min),
+		Max:      money(row.Max),
+		P50:      money(row.P50),
+		P90:      money(row.P90),
+		P95:      money(row.P95),
+		P99:      money(row.P99),
+	}
+}
+
+// minorUnits is the SQL expression converting a payment's amount to integer minor units
+func minorUnits() string {
+	currencies := make([]string, 0, len(domain.CurrencyExponents))
+	for currency := range domain.CurrencyExponents {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	var scale strings.Builder
+	scale.WriteString("CASE currency")
+	for _, currency := range currencies {
+		fmt.Fprintf(&scale, " WHEN '%s' THEN %d", currency, domain.MinorUnitScale(currency))
+	}
+	scale.WriteString(" ELSE 100 END")
+	return fmt.Sprintf("CAST(ROUND(amount * %s) AS INTEGER)", scale.String())
+}
+
+// localEpoch returns an SQL expression for the creation time shifted to the query's time zone,
+// in Unix seconds. SQLite knows no time zones, so the expression lists the UTC offset of every
+// period between the first and the last matching payment, following daylight saving changes.
+func localEpoch(tx *gorm.DB, query domain.PaymentStatsQuery) (string, error) {
+	location := query.Location
+	if location == nil {
+		location = time.UTC
+	}
+
+	var bounds struct {
+		First *int64
+		Last  *int64
+	}
+	err := filterPayments(tx, query.Filter).
+		Select(fmt.Sprintf("MIN(%[1]s) AS first, MAX(%[1]s) AS last", createdEpoch)).
+		Scan(&bounds).Error
+	if err != nil {
+		return "", err
+	}
+	if bounds.First == nil {
+		return createdEpoch, nil
+	}
+
+	var offsets strings.Builder
+	at := time.Unix(*bounds.First, 0).In(location)
+	for {
+		_, offset := at.Zone()
+		_, end := at.ZoneBounds()
+		if end.IsZero() || end.Unix() > *bounds.Last {
+			if offsets.Len() == 0 {
+				return fmt.Sprintf("(%s + %d)", createdEpoch, offset), nil
+			}
+			fmt.Fprintf(&offsets, " ELSE %d END", offset)
+			break
+		}
+		if offsets.Len() == 0 {
+			offsets.WriteString("CASE")
+		}
+		fmt.Fprintf(&offsets, " WHEN %s < %d THEN %d", createdEpoch, end.Unix(), offset)
+		at = end
+	}
+	return fmt.Sprintf("(%s + %s)", createdEpoch, offsets.String()), nil
+}
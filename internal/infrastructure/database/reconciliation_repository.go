@@ -0,0 +1,293 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"payments_app/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StatementDB represents the database model for imported bank statements
+type StatementDB struct {
+	ID        string `gorm:"primaryKey;type:varchar(36)"`
+	Format    string `gorm:"not null;type:varchar(10)"`
+	Reference string `gorm:"not null;type:varchar(100);uniqueIndex:idx_statement_file"`
+	Account   string `gorm:"index;type:varchar(50)"`
+	Currency  string `gorm:"type:varchar(3)"`
+	// Balances are stored in minor units of the statement currency
+	OpeningBalance *int64
+	ClosingBalance *int64
+	SHA256         string    `gorm:"not null;type:varchar(64);uniqueIndex:idx_statement_file"`
+	ImportedAt     time.Time `gorm:"not null"`
+}
+
+// TableName specifies the table name for GORM
+func (StatementDB) TableName() string {
+	return "bank_statements"
+}
+
+// StatementLineDB represents the database model for statement lines and their matches
+type StatementLineDB struct {
+	ID          string    `gorm:"primaryKey;type:varchar(36)"`
+	StatementID string    `gorm:"not null;index;type:varchar(36)"`
+	Index       int       `gorm:"column:line_index;not null"`
+	BookingDate time.Time `gorm:"not null;index"`
+	ValueDate   time.Time `gorm:"not null"`
+	// Amount is stored in minor units so it compares exactly
+	Amount              int64  `gorm:"not null"`
+	Currency            string `gorm:"not null;type:varchar(3)"`
+	Direction           string `gorm:"not null;type:varchar(10)"`
+	Reference           string `gorm:"type:varchar(140)"`
+	BankReference       string `gorm:"type:varchar(140)"`
+	Counterparty        string `gorm:"type:varchar(200)"`
+	CounterpartyAccount string `gorm:"type:varchar(50)"`
+	Description         string `gorm:"type:text"`
+
+	Status             string  `gorm:"not null;index;type:varchar(20)"`
+	PaymentID          string  `gorm:"index;type:varchar(36)"`
+	Confidence         float64 `gorm:"not null;default:0"`
+	MatchMethod        string  `gorm:"type:varchar(10)"`
+	MatchedBy          string  `gorm:"type:varchar(100)"`
+	MatchedAt          *time.Time
+	RejectedPaymentIDs []string `gorm:"serializer:json;type:text"`
+}
+
+// TableName specifies the table name for GORM
+func (StatementLineDB) TableName() string {
+	return "bank_statement_lines"
+}
+
+// toDomain converts StatementLineDB to a domain StatementLine
+func (l *StatementLineDB) toDomain() *domain.StatementLine {
+	return &domain.StatementLine{
+		ID:                  l.ID,
+		StatementID:         l.StatementID,
+		Index:               l.Index,
+		BookingDate:         l.BookingDate,
+		ValueDate:           l.ValueDate,
+		Amount:              domain.Money{MinorUnits: l.Amount, Currency: l.Currency},
+		Direction:           domain.EntryDirection(l.Direction),
+		Reference:           l.Reference,
+		BankReference:       l.BankReference,
+		Counterparty:        l.Counterparty,
+		CounterpartyAccount: l.CounterpartyAccount,
+		Description:         l.Description,
+		Status:              domain.StatementLineStatus(l.Status),
+		PaymentID:           l.PaymentID,
+		Confidence:          l.Confidence,
+		MatchMethod:         domain.MatchMethod(l.MatchMethod),
+		MatchedBy:           l.MatchedBy,
+		MatchedAt:           l.MatchedAt,
+		RejectedPaymentIDs:  l.RejectedPaymentIDs,
+	}
+}
+
+// statementLineFromDomain converts a domain StatementLine to StatementLineDB
+func statementLineFromDomain(line *domain.StatementLine) *StatementLineDB {
+	return &StatementLineDB{
+		ID:                  line.ID,
+		StatementID:         line.StatementID,
+		Index:               line.Index,
+		BookingDate:         line.BookingDate,
+		ValueDate:           line.ValueDate,
+		Amount:              line.Amount.MinorUnits,
+		Currency:            line.Amount.Currency,
+		Direction:           string(line.Direction),
+		Reference:           line.Reference,
+		BankReference:       line.BankReference,
+		Counterparty:        line.Counterparty,
+		CounterpartyAccount: line.CounterpartyAccount,
+		Description:         line.Description,
+		Status:              string(line.Status),
+		PaymentID:           line.PaymentID,
+		Confidence:          line.Confidence,
+		MatchMethod:         string(line.MatchMethod),
+		MatchedBy:           line.MatchedBy,
+		MatchedAt:           line.MatchedAt,
+		RejectedPaymentIDs:  line.RejectedPaymentIDs,
+	}
+}
+
+// ReconciliationRepository implements domain.ReconciliationRepository
+type ReconciliationRepository struct {
+	db *gorm.DB
+}
+
+// NewReconciliationRepository creates a reconciliation repository on an existing connection
+func NewReconciliationRepository(db *gorm.DB) (*ReconciliationRepository, error) {
+	if err := db.AutoMigrate(&StatementDB{}, &StatementLineDB{}); err != nil {
+		return nil, err
+	}
+	return &ReconciliationRepository{db: db}, nil
+}
+
+// CreateStatements stores the statements of one file with their lines in a transaction
+func (r *ReconciliationRepository) CreateStatements(ctx context.Context, statements []*domain.Statement) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, statement := range statements {
+			var count int64
+			if err := tx.Model(&StatementDB{}).Where("sha256 = ? AND reference = ?", statement.SHA256, statement.Reference).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return domain.ErrDuplicateStatement
+			}
+
+			statementDB := &StatementDB{
+				ID:         statement.ID,
+				Format:     string(statement.Format),
+				Reference:  statement.Reference,
+				Account:    statement.Account,
+				Currency:   statement.Currency,
+				SHA256:     statement.SHA256,
+				ImportedAt: statement.ImportedAt,
+			}
+			if statement.OpeningBalance != nil {
+				statementDB.OpeningBalance = &statement.OpeningBalance.MinorUnits
+			}
+			if statement.ClosingBalance != nil {
+				statementDB.ClosingBalance = &statement.ClosingBalance.MinorUnits
+			}
+			if err := tx.Create(statementDB).Error; err != nil {
+				return err
+			}
+			if len(statement.Lines) == 0 {
+				continue
+			}
+			lines := make([]*StatementLineDB, len(statement.Lines))
+			for i, line := range statement.Lines {
+				lines[i] = statementLineFromDomain(line)
+			}
+			if err := tx.CreateInBatches(lines, 100).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetStatement retrieves a statement with its lines in statement order
+func (r *ReconciliationRepository) GetStatement(ctx context.Context, id string) (*domain.Statement, error) {
+	var statementDB StatementDB
+	result := r.db.WithContext(ctx).First(&statementDB, "id = ?", id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("bank statement not found")
+		}
+		return nil, result.Error
+	}
+
+	var linesDB []StatementLineDB
+	if err := r.db.WithContext(ctx).Where("statement_id = ?", id).Order("line_index").Find(&linesDB).Error; err != nil {
+		return nil, err
+	}
+
+	statement := &domain.Statement{
+		ID:         statementDB.ID,
+		Format:     domain.StatementFormat(statementDB.Format),
+		Reference:  statementDB.Reference,
+		Account:    statementDB.Account,
+		Currency:   statementDB.Currency,
+		SHA256:     statementDB.SHA256,
+		ImportedAt: statementDB.ImportedAt,
+		Lines:      make([]*domain.StatementLine, len(linesDB)),
+	}
+	if statementDB.OpeningBalance != nil {
+		statement.OpeningBalance = &domain.Money{MinorUnits: *statementDB.OpeningBalance, Currency: statementDB.Currency}
+	}
+	if statementDB.ClosingBalance != nil {
+		statement.ClosingBalance = &domain.Money{MinorUnits: *statementDB.ClosingBalance, Currency: statementDB.Currency}
+	}
+	for i := range linesDB {
+		statement.Lines[i] = linesDB[i].toDomain()
+	}
+	return statement, nil
+}
+
+// GetLine retrieves a statement line by ID
+func (r *ReconciliationRepository) GetLine(ctx context.Context, id string) (*domain.StatementLine, error) {
+	var lineDB StatementLineDB
+	result := r.db.WithContext(ctx).First(&lineDB, "id = ?", id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("statement line not found")
+		}
+		return nil, result.Error
+	}
+	return lineDB.toDomain(), nil
+}
+
+// UpdateLine stores the reconciliation state of a line, keeping every payment on at most one line
+func (r *ReconciliationRepository) UpdateLine(ctx context.Context, line *domain.StatementLine) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if line.PaymentID != "" && line.Status != domain.StatementLineStatusUnmatched {
+			var count int64
+			err := tx.Model(&StatementLineDB{}).
+				Where("payment_id = ? AND id <> ? AND status IN ?", line.PaymentID, line.ID, reconciledLineStatuses).
+				Count(&count).Error
+			if err != nil {
+				return err
+			}
+			if count > 0 {
+				return domain.ErrPaymentAlreadyReconciled
+			}
+		}
+		return tx.Save(statementLineFromDomain(line)).Error
+	})
+}
+
+// ListLines returns matching lines ordered by booking date
+func (r *ReconciliationRepository) ListLines(ctx context.Context, filter domain.StatementLineFilter) ([]*domain.StatementLine, error) {
+	var linesDB []StatementLineDB
+
+	query := r.db.WithContext(ctx).Order("booking_date, statement_id, line_index")
+	if filter.StatementID != "" {
+		query = query.Where("statement_id = ?", filter.StatementID)
+	}
+	if len(filter.Statuses) > 0 {
+		statuses := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			statuses[i] = string(status)
+		}
+		query = query.Where("status IN ?", statuses)
+	}
+	if err := query.Find(&linesDB).Error; err != nil {
+		return nil, err
+	}
+
+	lines := make([]*domain.StatementLine, len(linesDB))
+	for i := range linesDB {
+		lines[i] = linesDB[i].toDomain()
+	}
+	return lines, nil
+}
+
+// UnmatchedPayments returns payments in the given statuses that no line is proposed for or matched to
+func (r *ReconciliationRepository) UnmatchedPayments(ctx context.Context, statuses []domain.PaymentStatus) ([]*domain.Payment, error) {
+	values := make([]string, len(statuses))
+	for i, status := range statuses {
+		values[i] = string(status)
+	}
+	reconciled := r.db.Model(&StatementLineDB{}).Select("payment_id").
+		Where("payment_id <> '' AND status IN ?", reconciledLineStatuses)
+
+	var paymentsDB []PaymentDB
+	err := r.db.WithContext(ctx).
+		Where("status IN ? AND id NOT IN (?)", values, reconciled).
+		Order("created_at, id").
+		Find(&paymentsDB).Error
+	if err != nil {
+		return nil, err
+	}
+
+	payments := make([]*domain.Payment, len(paymentsDB))
+	for i := range paymentsDB {
+		payments[i] = paymentsDB[i].ToDomain()
+	}
+	return payments, nil
+}
+
+// reconciledLineStatuses are the line states that hold on to their payment
+var reconciledLineStatuses = []string{string(domain.StatementLineStatusProposed), string(domain.StatementLineStatusMatched)}
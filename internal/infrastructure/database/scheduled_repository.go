@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"payments_app/internal/domain"
+	"time"
+)
+
+// ClaimDue leases up to limit SCHEDULED payments due at now to owner until now+lease.
+// Claiming is a single UPDATE, so concurrent runners never lease the same payment.
+func (r *PaymentRepository) ClaimDue(ctx context.Context, owner string, now time.Time, lease time.Duration, limit int) ([]*domain.Payment, error) {
+	now = now.UTC()
+	expires := now.Add(lease)
+
+	due := r.db.Model(&PaymentDB{}).
+		Select("id").
+		Where("status = ? AND execute_at <= ?", domain.PaymentStatusScheduled, now).
+		Where("lease_expires_at IS NULL OR lease_expires_at < ?", now).
+		Order("execute_at").
+		Limit(limit)
+	result := r.db.WithContext(ctx).Model(&PaymentDB{}).
+		Where("id IN (?)", due).
+		Updates(map[string]interface{}{"lease_owner": owner, "lease_expires_at": expires})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	var paymentsDB []PaymentDB
+	if err := r.db.WithContext(ctx).
+		Where("lease_owner = ? AND status = ?", owner, domain.PaymentStatusScheduled).
+		Order("execute_at").
+		Find(&paymentsDB).Error; err != nil {
+		return nil, err
+	}
+
+	payments := make([]*domain.Payment, len(paymentsDB))
+	for i := range paymentsDB {
+		payments[i] = paymentsDB[i].ToDomain()
+	}
+	return payments, nil
+}
+
+// CompleteScheduled saves a claimed payment and releases its lease if owner still holds it
+func (r *PaymentRepository) CompleteScheduled(ctx context.Context, payment *domain.Payment, owner string) (bool, error) {
+	paymentDB := &PaymentDB{}
+	paymentDB.FromDomain(payment)
+
+	result := r.db.WithContext(ctx).Model(&PaymentDB{}).
+		Where("id = ? AND status = ? AND lease_owner = ?", payment.ID, domain.PaymentStatusScheduled, owner).
+		Select("*").Omit("created_at", "deleted_at").
+		Updates(paymentDB)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// UpdateScheduled saves a payment that is still SCHEDULED and not leased by a runner
+func (r *PaymentRepository) UpdateScheduled(ctx context.Context, payment *domain.Payment, now time.Time) error {
+	now = now.UTC()
+	paymentDB := &PaymentDB{}
+	paymentDB.FromDomain(payment)
+
+	result := r.db.WithContext(ctx).Model(&PaymentDB{}).
+		Where("id = ? AND status = ?", payment.ID, domain.PaymentStatusScheduled).
+		Where("lease_expires_at IS NULL OR lease_expires_at < ?", now).
+		Select("*").Omit("created_at", "deleted_at").
+		Updates(paymentDB)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 1 {
+		return nil
+	}
+
+	stored, err := r.GetByID(ctx, payment.ID)
+	if err != nil {
+		return err
+	}
+	if stored.Status != domain.PaymentStatusScheduled {
+		return domain.ErrPaymentNotScheduled
+	}
+	return domain.ErrPaymentLeased
+}
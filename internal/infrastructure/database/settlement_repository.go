@@ -0,0 +1,327 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"payments_app/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SettlementBatchDB represents the database model for settlement batches
+type SettlementBatchDB struct {
+	ID        string     `gorm:"primaryKey;type:varchar(10)"`
+	CutoffAt  time.Time  `gorm:"not null"`
+	CreatedAt time.Time  `gorm:"not null"`
+	Payouts   []PayoutDB `gorm:"foreignKey:BatchID"`
+}
+
+// TableName specifies the table name for GORM
+func (SettlementBatchDB) TableName() string {
+	return "settlement_batches"
+}
+
+// PayoutDB represents the database model for merchant payouts; amounts are in minor units
+type PayoutDB struct {
+	ID            string         `gorm:"primaryKey;type:varchar(36)"`
+	BatchID       string         `gorm:"not null;index;type:varchar(10)"`
+	MerchantID    string         `gorm:"not null;index;type:varchar(100)"`
+	Currency      string         `gorm:"not null;type:varchar(3)"`
+	Gross         int64          `gorm:"not null"`
+	Refunds       int64          `gorm:"not null"`
+	Chargebacks   int64          `gorm:"not null;default:0"`
+	Fees          int64          `gorm:"not null"`
+	Net           int64          `gorm:"not null"`
+	Status        string         `gorm:"not null;index;type:varchar(10)"`
+	Reference     string         `gorm:"type:varchar(100)"`
+	FailureReason string         `gorm:"type:text"`
+	CreatedAt     time.Time      `gorm:"not null;index"`
+	UpdatedAt     time.Time      `gorm:"not null"`
+	SentAt        *time.Time     `gorm:""`
+	PaidAt        *time.Time     `gorm:""`
+	Items         []PayoutItemDB `gorm:"foreignKey:PayoutID"`
+}
+
+// TableName specifies the table name for GORM
+func (PayoutDB) TableName() string {
+	return "payouts"
+}
+
+// PayoutItemDB records which payment a payout settles; amounts are in minor units
+type PayoutItemDB struct {
+	PayoutID          string  `gorm:"primaryKey;type:varchar(36)"`
+	PaymentID         string  `gorm:"primaryKey;index;type:varchar(36)"`
+	Gross             int64   `gorm:"not null"`
+	Refunds           int64   `gorm:"not null"`
+	Chargebacks       int64   `gorm:"not null;default:0"`
+	Fees              int64   `gorm:"not null"`
+	Net               int64   `gorm:"not null"`
+	RefundedAmount    float64 `gorm:"not null"`
+	ChargedBackAmount float64 `gorm:"not null;default:0"`
+}
+
+// TableName specifies the table name for GORM
+func (PayoutItemDB) TableName() string {
+	return "payout_items"
+}
+
+// SettlementRepository implements domain.SettlementRepository
+type SettlementRepository struct {
+	db *gorm.DB
+}
+
+// NewSettlementRepository creates a settlement repository on an existing connection. Lost
+// disputes are read from the disputes table, which is created if missing.
+func NewSettlementRepository(db *gorm.DB) (*SettlementRepository, error) {
+	if err := db.AutoMigrate(&SettlementBatchDB{}, &PayoutDB{}, &PayoutItemDB{}, &DisputeDB{}); err != nil {
+		return nil, err
+	}
+	return &SettlementRepository{db: db}, nil
+}
+
+// settledItems selects the items of payouts that have not failed
+const settledItems = "SELECT payout_items.payment_id, payout_items.refunded_amount, payout_items.charged_back_amount FROM payout_items " +
+	"JOIN payouts ON payouts.id = payout_items.payout_id WHERE payouts.status <> 'FAILED'"
+
+// lostDisputes selects the total of each payment's lost disputes
+const lostDisputes = "SELECT payment_id, SUM(amount) AS amount FROM disputes WHERE status = 'LOST' GROUP BY payment_id"
+
+// SettlementCandidates returns the payments no payout that has not failed covers, with the
+// refunds and lost disputes already settled for them
+func (r *SettlementRepository) SettlementCandidates(ctx context.Context, cutoff time.Time) ([]domain.SettlementCandidate, error) {
+	var paymentsDB []PaymentDB
+	err := r.db.WithContext(ctx).
+		Where("status IN ? AND tenant_id <> '' AND created_at < ?", []domain.PaymentStatus{domain.PaymentStatusCompleted, domain.PaymentStatusRefunded}, cutoff).
+		Where("NOT EXISTS (SELECT 1 FROM (" + settledItems + ") settled WHERE settled.payment_id = payments.id AND settled.refunded_amount >= payments.refunded_amount" +
+			" AND settled.charged_back_amount + 0.005 >= COALESCE((SELECT lost.amount FROM (" + lostDisputes + ") lost WHERE lost.payment_id = payments.id), 0))").
+		Order("created_at, id").
+		Find(&paymentsDB).Error
+	if err != nil || len(paymentsDB) == 0 {
+		return nil, err
+	}
+
+	ids := make([]string, len(paymentsDB))
+	for i := range paymentsDB {
+		ids[i] = paymentsDB[i].ID
+	}
+	var settled []struct {
+		PaymentID         string
+		RefundedAmount    float64
+		ChargedBackAmount float64
+	}
+	err = r.db.WithContext(ctx).
+		Raw("SELECT payment_id, MAX(refunded_amount) AS refunded_amount, MAX(charged_back_amount) AS charged_back_amount FROM ("+settledItems+") settled WHERE payment_id IN ? GROUP BY payment_id", ids).
+		Scan(&settled).Error
+	if err != nil {
+		return nil, err
+	}
+	settledByPayment := make(map[string]domain.SettlementCandidate, len(settled))
+	for _, row := range settled {
+		settledByPayment[row.PaymentID] = domain.SettlementCandidate{Settled: true, SettledRefunds: row.RefundedAmount, SettledChargebacks: row.ChargedBackAmount}
+	}
+	var lost []struct {
+		PaymentID string
+		Amount    float64
+	}
+	err = r.db.WithContext(ctx).
+		Raw("SELECT payment_id, amount FROM ("+lostDisputes+") lost WHERE payment_id IN ?", ids).
+		Scan(&lost).Error
+	if err != nil {
+		return nil, err
+	}
+	chargedBack := make(map[string]float64, len(lost))
+	for _, row := range lost {
+		chargedBack[row.PaymentID] = row.Amount
+	}
+
+	candidates := make([]domain.SettlementCandidate, len(paymentsDB))
+	for i := range paymentsDB {
+		candidate := settledByPayment[paymentsDB[i].ID]
+		candidate.Payment = paymentsDB[i].ToDomain()
+		candidate.ChargedBack = chargedBack[paymentsDB[i].ID]
+		candidates[i] = candidate
+	}
+	return candidates, nil
+}
+
+// CreateBatch stores the batch, its payouts and their items in one transaction
+func (r *SettlementRepository) CreateBatch(ctx context.Context, batch *domain.SettlementBatch) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Model(&SettlementBatchDB{}).Where("id = ?", batch.ID).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return domain.ErrSettlementBatchExists
+		}
+
+		batchDB := &SettlementBatchDB{ID: batch.ID, CutoffAt: batch.CutoffAt, CreatedAt: batch.CreatedAt}
+		for _, payout := range batch.Payouts {
+			batchDB.Payouts = append(batchDB.Payouts, payoutToDB(payout))
+		}
+		return tx.Create(batchDB).Error
+	})
+}
+
+// GetBatch retrieves a settlement batch with its payouts and their items
+func (r *SettlementRepository) GetBatch(ctx context.Context, id string) (*domain.SettlementBatch, error) {
+	var batchDB SettlementBatchDB
+	result := r.db.WithContext(ctx).
+		Preload("Payouts", func(db *gorm.DB) *gorm.DB { return db.Order("merchant_id, currency") }).
+		Preload("Payouts.Items", func(db *gorm.DB) *gorm.DB { return db.Order("payment_id") }).
+		First(&batchDB, "id = ?", id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrSettlementBatchNotFound
+		}
+		return nil, result.Error
+	}
+
+	batch := &domain.SettlementBatch{
+		ID:        batchDB.ID,
+		CutoffAt:  batchDB.CutoffAt,
+		CreatedAt: batchDB.CreatedAt,
+		Payouts:   make([]*domain.Payout, len(batchDB.Payouts)),
+	}
+	for i := range batchDB.Payouts {
+		batch.Payouts[i] = batchDB.Payouts[i].ToDomain()
+	}
+	return batch, nil
+}
+
+// GetPayout retrieves a payout with its items by ID
+func (r *SettlementRepository) GetPayout(ctx context.Context, id string) (*domain.Payout, error) {
+	var payoutDB PayoutDB
+	result := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("payment_id") }).
+		First(&payoutDB, "id = ?", id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrPayoutNotFound
+		}
+		return nil, result.Error
+	}
+	return payoutDB.ToDomain(), nil
+}
+
+// UpdatePayout stores the lifecycle fields of a payout
+func (r *SettlementRepository) UpdatePayout(ctx context.Context, payout *domain.Payout) error {
+	result := r.db.WithContext(ctx).Model(&PayoutDB{}).Where("id = ?", payout.ID).Updates(map[string]interface{}{
+		"status":         string(payout.Status),
+		"reference":      payout.Reference,
+		"failure_reason": payout.FailureReason,
+		"updated_at":     payout.UpdatedAt,
+		"sent_at":        payout.SentAt,
+		"paid_at":        payout.PaidAt,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrPayoutNotFound
+	}
+	return nil
+}
+
+// ListPayouts returns matching payouts with their items, newest first
+func (r *SettlementRepository) ListPayouts(ctx context.Context, filter domain.PayoutFilter) ([]*domain.Payout, error) {
+	query := r.db.WithContext(ctx).Model(&PayoutDB{})
+	if filter.MerchantID != "" {
+		query = query.Where("merchant_id = ?", filter.MerchantID)
+	}
+	if filter.BatchID != "" {
+		query = query.Where("batch_id = ?", filter.BatchID)
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+
+	var payoutsDB []PayoutDB
+	err := query.Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("payment_id") }).
+		Order("created_at DESC, id").
+		Find(&payoutsDB).Error
+	if err != nil {
+		return nil, err
+	}
+	payouts := make([]*domain.Payout, len(payoutsDB))
+	for i := range payoutsDB {
+		payouts[i] = payoutsDB[i].ToDomain()
+	}
+	return payouts, nil
+}
+
+// payoutToDB converts a payout and its items to database models
+func payoutToDB(payout *domain.Payout) PayoutDB {
+	payoutDB := PayoutDB{
+		ID:            payout.ID,
+		BatchID:       payout.BatchID,
+		MerchantID:    payout.MerchantID,
+		Currency:      payout.Currency,
+		Gross:         payout.Gross.MinorUnits,
+		Refunds:       payout.Refunds.MinorUnits,
+		Chargebacks:   payout.Chargebacks.MinorUnits,
+		Fees:          payout.Fees.MinorUnits,
+		Net:           payout.Net.MinorUnits,
+		Status:        string(payout.Status),
+		Reference:     payout.Reference,
+		FailureReason: payout.FailureReason,
+		CreatedAt:     payout.CreatedAt,
+		UpdatedAt:     payout.UpdatedAt,
+		SentAt:        payout.SentAt,
+		PaidAt:        payout.PaidAt,
+	}
+	for _, item := range payout.Items {
+		payoutDB.Items = append(payoutDB.Items, PayoutItemDB{
+			PayoutID:          payout.ID,
+			PaymentID:         item.PaymentID,
+			Gross:             item.Gross.MinorUnits,
+			Refunds:           item.Refunds.MinorUnits,
+			Chargebacks:       item.Chargebacks.MinorUnits,
+			Fees:              item.Fees.MinorUnits,
+			Net:               item.Net.MinorUnits,
+			RefundedAmount:    item.RefundedAmount,
+			ChargedBackAmount: item.ChargedBackAmount,
+		})
+	}
+	return payoutDB
+}
+
+// ToDomain converts the database model to a domain payout
+func (p *PayoutDB) ToDomain() *domain.Payout {
+	money := func(minorUnits int64) domain.Money {
+		return domain.Money{MinorUnits: minorUnits, Currency: p.Currency}
+	}
+	payout := &domain.Payout{
+		ID:            p.ID,
+		BatchID:       p.BatchID,
+		MerchantID:    p.MerchantID,
+		Currency:      p.Currency,
+		Gross:         money(p.Gross),
+		Refunds:       money(p.Refunds),
+		Chargebacks:   money(p.Chargebacks),
+		Fees:          money(p.Fees),
+		Net:           money(p.Net),
+		Status:        domain.PayoutStatus(p.Status),
+		Reference:     p.Reference,
+		FailureReason: p.FailureReason,
+		CreatedAt:     p.CreatedAt,
+		UpdatedAt:     p.UpdatedAt,
+		SentAt:        p.SentAt,
+		PaidAt:        p.PaidAt,
+	}
+	for _, item := range p.Items {
+		payout.Items = append(payout.Items, domain.PayoutItem{
+			PayoutID:          item.PayoutID,
+			PaymentID:         item.PaymentID,
+			Gross:             money(item.Gross),
+			Refunds:           money(item.Refunds),
+			Chargebacks:       money(item.Chargebacks),
+			Fees:              money(item.Fees),
+			Net:               money(item.Net),
+			RefundedAmount:    item.RefundedAmount,
+			ChargedBackAmount: item.ChargedBackAmount,
+		})
+	}
+	return payout
+}
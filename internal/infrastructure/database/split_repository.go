@@ -0,0 +1,104 @@
+package database
+
+import (
+	"context"
+	"payments_app/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BalanceEntryDB represents the database model for split recipient balance entries
+type BalanceEntryDB struct {
+	ID        string    `gorm:"primaryKey;type:varchar(150)"`
+	Recipient string    `gorm:"not null;index:idx_balance_recipient;type:varchar(100)"`
+	Currency  string    `gorm:"not null;index:idx_balance_recipient;type:varchar(3)"`
+	PaymentID string    `gorm:"not null;index;type:varchar(36)"`
+	Kind      string    `gorm:"not null;type:varchar(10)"`
+	Amount    int64     `gorm:"not null"`
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+// TableName specifies the table name for GORM
+func (BalanceEntryDB) TableName() string {
+	return "recipient_balance_entries"
+}
+
+// RecipientBalanceRepository implements domain.RecipientBalanceRepository
+type RecipientBalanceRepository struct {
+	db *gorm.DB
+}
+
+// NewRecipientBalanceRepository creates a recipient balance repository on an existing connection
+func NewRecipientBalanceRepository(db *gorm.DB) (*RecipientBalanceRepository, error) {
+	if err := db.AutoMigrate(&BalanceEntryDB{}); err != nil {
+		return nil, err
+	}
+	return &RecipientBalanceRepository{db: db}, nil
+}
+
+// Record stores the entries in one transaction, skipping entries that were recorded before
+func (r *RecipientBalanceRepository) Record(ctx context.Context, entries []domain.BalanceEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	entriesDB := make([]BalanceEntryDB, len(entries))
+	for i, entry := range entries {
+		entriesDB[i] = BalanceEntryDB{
+			ID:        entry.ID,
+			Recipient: entry.Recipient,
+			Currency:  entry.Amount.Currency,
+			PaymentID: entry.PaymentID,
+			Kind:      string(entry.Kind),
+			Amount:    entry.Amount.MinorUnits,
+			CreatedAt: entry.CreatedAt,
+		}
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&entriesDB).Error
+}
+
+// Balances sums the recipient's entries per currency
+func (r *RecipientBalanceRepository) Balances(ctx context.Context, recipient string) ([]domain.Money, error) {
+	var rows []struct {
+		Currency string
+		Total    int64
+	}
+	err := r.db.WithContext(ctx).Model(&BalanceEntryDB{}).
+		Select("currency, SUM(amount) AS total").
+		Where("recipient = ?", recipient).
+		Group("currency").
+		Order("currency").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make([]domain.Money, len(rows))
+	for i, row := range rows {
+		balances[i] = domain.Money{MinorUnits: row.Total, Currency: row.Currency}
+	}
+	return balances, nil
+}
+
+// Entries returns the recipient's entries, oldest first
+func (r *RecipientBalanceRepository) Entries(ctx context.Context, recipient string) ([]domain.BalanceEntry, error) {
+	var entriesDB []BalanceEntryDB
+	err := r.db.WithContext(ctx).Where("recipient = ?", recipient).Order("created_at, id").Find(&entriesDB).Error
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]domain.BalanceEntry, len(entriesDB))
+	for i, entry := range entriesDB {
+		entries[i] = domain.BalanceEntry{
+			ID:        entry.ID,
+			Recipient: entry.Recipient,
+			PaymentID: entry.PaymentID,
+			Kind:      domain.BalanceEntryKind(entry.Kind),
+			Amount:    domain.Money{MinorUnits: entry.Amount, Currency: entry.Currency},
+			CreatedAt: entry.CreatedAt,
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,241 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"payments_app/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SubscriptionDB represents the database model for subscriptions
+type SubscriptionDB struct {
+	ID          string  `gorm:"primaryKey;type:varchar(36)"`
+	PayerID     string  `gorm:"index;type:varchar(100)"`
+	TenantID    string  `gorm:"index;type:varchar(100)"`
+	Amount      float64 `gorm:"not null"`
+	Currency    string  `gorm:"not null;type:varchar(3)"`
+	Description string  `gorm:"not null;type:text"`
+
+	MethodType    string           `gorm:"type:varchar(20)"`
+	MethodDetails *PaymentMethodDB `gorm:"serializer:json;type:text"`
+
+	Frequency string    `gorm:"not null;type:varchar(10)"`
+	Interval  int       `gorm:"not null;default:1"`
+	AnchorDay int       `gorm:"not null;default:0"`
+	StartAt   time.Time `gorm:"not null"`
+
+	Status        string    `gorm:"not null;index;type:varchar(20)"`
+	Cycle         int       `gorm:"not null;default:0"`
+	NextRunAt     time.Time `gorm:"not null;index"`
+	RetryAttempt  int       `gorm:"not null;default:0"`
+	LastPaymentID string    `gorm:"type:varchar(36)"`
+	LastError     string    `gorm:"type:text"`
+	CancelledAt   *time.Time
+	CreatedAt     time.Time `gorm:"not null"`
+	UpdatedAt     time.Time `gorm:"not null"`
+
+	// LeaseOwner and LeaseExpiresAt mark a due subscription claimed by a runner
+	LeaseOwner     string `gorm:"index;type:varchar(36)"`
+	LeaseExpiresAt *time.Time
+}
+
+// TableName specifies the table name for GORM
+func (SubscriptionDB) TableName() string {
+	return "subscriptions"
+}
+
+// toDomain converts SubscriptionDB to a domain Subscription
+func (s *SubscriptionDB) toDomain() *domain.Subscription {
+	return &domain.Subscription{
+		ID:          s.ID,
+		PayerID:     s.PayerID,
+		TenantID:    s.TenantID,
+		Amount:      s.Amount,
+		Currency:    s.Currency,
+		Description: s.Description,
+		Method:      methodToDomain(s.MethodType, s.MethodDetails),
+		Schedule: domain.PaymentSchedule{
+			Frequency: domain.Frequency(s.Frequency),
+			Interval:  s.Interval,
+			AnchorDay: s.AnchorDay,
+			StartAt:   s.StartAt,
+		},
+		Status:        domain.SubscriptionStatus(s.Status),
+		Cycle:         s.Cycle,
+		NextRunAt:     s.NextRunAt,
+		RetryAttempt:  s.RetryAttempt,
+		LastPaymentID: s.LastPaymentID,
+		LastError:     s.LastError,
+		CancelledAt:   s.CancelledAt,
+		CreatedAt:     s.CreatedAt,
+		UpdatedAt:     s.UpdatedAt,
+	}
+}
+
+// subscriptionFromDomain converts a domain Subscription to SubscriptionDB
+func subscriptionFromDomain(subscription *domain.Subscription) *SubscriptionDB {
+	methodType, methodDetails := methodFromDomain(subscription.Method)
+	return &SubscriptionDB{
+		ID:            subscription.ID,
+		PayerID:       subscription.PayerID,
+		TenantID:      subscription.TenantID,
+		Amount:        subscription.Amount,
+		Currency:      subscription.Currency,
+		Description:   subscription.Description,
+		MethodType:    methodType,
+		MethodDetails: methodDetails,
+		Frequency:     string(subscription.Schedule.Frequency),
+		Interval:      subscription.Schedule.Interval,
+		AnchorDay:     subscription.Schedule.AnchorDay,
+		StartAt:       subscription.Schedule.StartAt,
+		Status:        string(subscription.Status),
+		Cycle:         subscription.Cycle,
+		NextRunAt:     subscription.NextRunAt,
+		RetryAttempt:  subscription.RetryAttempt,
+		LastPaymentID: subscription.LastPaymentID,
+		LastError:     subscription.LastError,
+		CancelledAt:   subscription.CancelledAt,
+		CreatedAt:     subscription.CreatedAt,
+		UpdatedAt:     subscription.UpdatedAt,
+	}
+}
+
+// SubscriptionRepository implements domain.SubscriptionRepository
+type SubscriptionRepository struct {
+	db *gorm.DB
+}
+
+// NewSubscriptionRepository creates a subscription repository on an existing connection
+func NewSubscriptionRepository(db *gorm.DB) (*SubscriptionRepository, error) {
+	if err := db.AutoMigrate(&SubscriptionDB{}); err != nil {
+		return nil, err
+	}
+	return &SubscriptionRepository{db: db}, nil
+}
+
+// Create stores a new subscription
+func (r *SubscriptionRepository) Create(ctx context.Context, subscription *domain.Subscription) error {
+	return r.db.WithContext(ctx).Create(subscriptionFromDomain(subscription)).Error
+}
+
+// GetByID retrieves a subscription by ID
+func (r *SubscriptionRepository) GetByID(ctx context.Context, id string) (*domain.Subscription, error) {
+	var subscriptionDB SubscriptionDB
+
+	result := r.db.WithContext(ctx).First(&subscriptionDB, "id = ?", id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("subscription not found")
+		}
+		return nil, result.Error
+	}
+
+	return subscriptionDB.toDomain(), nil
+}
+
+// Update stores changes to a subscription that no runner is billing
+func (r *SubscriptionRepository) Update(ctx context.Context, subscription *domain.Subscription) error {
+	now := time.Now().UTC()
+	result := r.db.WithContext(ctx).Model(&SubscriptionDB{}).
+		Where("id = ?", subscription.ID).
+		Where("lease_expires_at IS NULL OR lease_expires_at < ?", now).
+		Select("*").Omit("created_at", "lease_owner", "lease_expires_at").
+		Updates(subscriptionFromDomain(subscription))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 1 {
+		return nil
+	}
+
+	if _, err := r.GetByID(ctx, subscription.ID); err != nil {
+		return err
+	}
+	return domain.ErrSubscriptionBilling
+}
+
+// List returns matching subscriptions ordered by next due time
+func (r *SubscriptionRepository) List(ctx context.Context, filter domain.SubscriptionFilter) ([]*domain.Subscription, error) {
+	var subscriptionsDB []SubscriptionDB
+
+	query := r.db.WithContext(ctx).Order("next_run_at")
+	if filter.PayerID != "" {
+		query = query.Where("payer_id = ?", filter.PayerID)
+	}
+	if len(filter.Statuses) > 0 {
+		statuses := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			statuses[i] = string(status)
+		}
+		query = query.Where("status IN ?", statuses)
+	}
+	if filter.DueBefore != nil {
+		query = query.Where("next_run_at <= ?", *filter.DueBefore)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if err := query.Find(&subscriptionsDB).Error; err != nil {
+		return nil, err
+	}
+
+	subscriptions := make([]*domain.Subscription, len(subscriptionsDB))
+	for i := range subscriptionsDB {
+		subscriptions[i] = subscriptionsDB[i].toDomain()
+	}
+	return subscriptions, nil
+}
+
+// ClaimDue leases up to limit ACTIVE or PAST_DUE subscriptions due at now to owner until
+// now+lease. Claiming is a single UPDATE, so concurrent runners never lease the same subscription.
+func (r *SubscriptionRepository) ClaimDue(ctx context.Context, owner string, now time.Time, lease time.Duration, limit int) ([]*domain.Subscription, error) {
+	now = now.UTC()
+	expires := now.Add(lease)
+	statuses := []string{string(domain.SubscriptionStatusActive), string(domain.SubscriptionStatusPastDue)}
+
+	due := r.db.Model(&SubscriptionDB{}).
+		Select("id").
+		Where("status IN ? AND next_run_at <= ?", statuses, now).
+		Where("lease_expires_at IS NULL OR lease_expires_at < ?", now).
+		Order("next_run_at").
+		Limit(limit)
+	result := r.db.WithContext(ctx).Model(&SubscriptionDB{}).
+		Where("id IN (?)", due).
+		Updates(map[string]interface{}{"lease_owner": owner, "lease_expires_at": expires})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	var subscriptionsDB []SubscriptionDB
+	if err := r.db.WithContext(ctx).
+		Where("lease_owner = ?", owner).
+		Order("next_run_at").
+		Find(&subscriptionsDB).Error; err != nil {
+		return nil, err
+	}
+
+	subscriptions := make([]*domain.Subscription, len(subscriptionsDB))
+	for i := range subscriptionsDB {
+		subscriptions[i] = subscriptionsDB[i].toDomain()
+	}
+	return subscriptions, nil
+}
+
+// CompleteBilling saves a claimed subscription and releases its lease if owner still holds it
+func (r *SubscriptionRepository) CompleteBilling(ctx context.Context, subscription *domain.Subscription, owner string) (bool, error) {
+	subscriptionDB := subscriptionFromDomain(subscription)
+
+	result := r.db.WithContext(ctx).Model(&SubscriptionDB{}).
+		Where("id = ? AND lease_owner = ?", subscription.ID, owner).
+		Select("*").Omit("created_at").
+		Updates(subscriptionDB)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"payments_app/internal/vault"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CardVaultDB represents the database model for vaulted cards
+type CardVaultDB struct {
+	Token       string    `gorm:"primaryKey;type:varchar(40)"`
+	KeyID       string    `gorm:"not null;index;type:varchar(50)"`
+	WrappedKey  []byte    `gorm:"not null"`
+	Ciphertext  []byte    `gorm:"not null"`
+	Brand       string    `gorm:"not null;type:varchar(20)"`
+	Last4       string    `gorm:"not null;type:varchar(4)"`
+	ExpiryMonth int       `gorm:"not null"`
+	ExpiryYear  int       `gorm:"not null"`
+	HolderName  string    `gorm:"type:varchar(200)"`
+	CreatedAt   time.Time `gorm:"not null"`
+	UpdatedAt   time.Time `gorm:"not null"`
+}
+
+// TableName specifies the table name for GORM
+func (CardVaultDB) TableName() string {
+	return "card_vault"
+}
+
+// toRecord converts CardVaultDB to a vault Record
+func (c *CardVaultDB) toRecord() *vault.Record {
+	return &vault.Record{
+		TokenizedCard: vault.TokenizedCard{
+			Token:       c.Token,
+			Brand:       c.Brand,
+			Last4:       c.Last4,
+			ExpiryMonth: c.ExpiryMonth,
+			ExpiryYear:  c.ExpiryYear,
+			HolderName:  c.HolderName,
+			CreatedAt:   c.CreatedAt,
+		},
+		KeyID:      c.KeyID,
+		WrappedKey: c.WrappedKey,
+		Ciphertext: c.Ciphertext,
+	}
+}
+
+// VaultRepository implements vault.Store
+type VaultRepository struct {
+	db *gorm.DB
+}
+
+// NewVaultRepository creates a vault repository on an existing connection
+func NewVaultRepository(db *gorm.DB) (*VaultRepository, error) {
+	if err := db.AutoMigrate(&CardVaultDB{}); err != nil {
+		return nil, err
+	}
+	return &VaultRepository{db: db}, nil
+}
+
+// Save stores a new vault record
+func (r *VaultRepository) Save(ctx context.Context, record *vault.Record) error {
+	recordDB := &CardVaultDB{
+		Token:       record.Token,
+		KeyID:       record.KeyID,
+		WrappedKey:  record.WrappedKey,
+		Ciphertext:  record.Ciphertext,
+		Brand:       record.Brand,
+		Last4:       record.Last4,
+		ExpiryMonth: record.ExpiryMonth,
+		ExpiryYear:  record.ExpiryYear,
+		HolderName:  record.HolderName,
+		CreatedAt:   record.CreatedAt,
+		UpdatedAt:   record.CreatedAt,
+	}
+	return r.db.WithContext(ctx).Create(recordDB).Error
+}
+
+// Get retrieves a vault record by token
+func (r *VaultRepository) Get(ctx context.Context, token string) (*vault.Record, error) {
+	var recordDB CardVaultDB
+
+	result := r.db.WithContext(ctx).First(&recordDB, "token = ?", token)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, vault.ErrTokenNotFound
+		}
+		return nil, result.Error
+	}
+
+	return recordDB.toRecord(), nil
+}
+
+// ListNotWrappedWith returns records whose data key is wrapped by a key other than keyID
+func (r *VaultRepository) ListNotWrappedWith(ctx context.Context, keyID string, limit int) ([]*vault.Record, error) {
+	var recordsDB []CardVaultDB
+
+	result := r.db.WithContext(ctx).Where("key_id <> ?", keyID).Order("token").Limit(limit).Find(&recordsDB)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	records := make([]*vault.Record, len(recordsDB))
+	for i := range recordsDB {
+		records[i] = recordsDB[i].toRecord()
+	}
+	return records, nil
+}
+
+// Rewrap replaces a record's wrapped data key if it is still wrapped by oldKeyID
+func (r *VaultRepository) Rewrap(ctx context.Context, token, oldKeyID, newKeyID string, wrappedKey []byte) error {
+	return r.db.WithContext(ctx).Model(&CardVaultDB{}).
+		Where("token = ? AND key_id = ?", token, oldKeyID).
+		Updates(map[string]interface{}{
+			"key_id":      newKeyID,
+			"wrapped_key": wrappedKey,
+			"updated_at":  time.Now(),
+		}).Error
+}
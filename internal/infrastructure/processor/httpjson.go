@@ -0,0 +1,253 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"payments_app/internal/domain"
+	"payments_app/internal/vault"
+	"strings"
+	"time"
+)
+
+// HTTPConfig configures a generic HTTP-JSON processor connector
+type HTTPConfig struct {
+	Name    string
+	BaseURL string
+	APIKey  string
+	Timeout time.Duration
+	// Detokenizer recovers card numbers for vaulted cards; without it only tokens are sent
+	Detokenizer vault.Detokenizer
+	Client      *http.Client
+	// WebhookSecret verifies callback signatures; callbacks are refused without it
+	WebhookSecret string
+}
+
+// HTTPConnector talks to a processor exposing a simple JSON API:
+//
+//	POST /authorizations            -> authorize
+//	POST /payments/{ref}/capture    -> capture
+//	POST /payments/{ref}/refund     -> refund
+//	POST /payments/{ref}/void       -> void
+//	GET  /payments/{ref}            -> status
+type HTTPConnector struct {
+	name        string
+	baseURL     string
+	apiKey      string
+	detokenizer vault.Detokenizer
+	client      *http.Client
+	webhookKey  string
+}
+
+// NewHTTPConnector creates a new HTTP-JSON connector
+func NewHTTPConnector(cfg HTTPConfig) (*HTTPConnector, error) {
+	if _, err := url.ParseRequestURI(cfg.BaseURL); err != nil {
+		return nil, fmt.Errorf("invalid processor URL: %w", err)
+	}
+	if cfg.Name == "" {
+		cfg.Name = "http"
+	}
+	client := cfg.Client
+	if client == nil {
+		timeout := cfg.Timeout
+		if timeout <= 0 {
+			timeout = 10 * time.Second
+		}
+		client = &http.Client{Timeout: timeout}
+	}
+
+	return &HTTPConnector{
+		name:        cfg.Name,
+		baseURL:     strings.TrimRight(cfg.BaseURL, "/"),
+		apiKey:      cfg.APIKey,
+		detokenizer: cfg.Detokenizer,
+		client:      client,
+		webhookKey:  cfg.WebhookSecret,
+	}, nil
+}
+
+// httpMethod is the wire format of a payment method
+type httpMethod struct {
+	Type        string `json:"type"`
+	CardNumber  string `json:"card_number,omitempty"`
+	CardToken   string `json:"card_token,omitempty"`
+	ExpiryMonth int    `json:"expiry_month,omitempty"`
+	ExpiryYear  int    `json:"expiry_year,omitempty"`
+	IBAN        string `json:"iban,omitempty"`
+	Routing     string `json:"routing_number,omitempty"`
+	Account     string `json:"account_number,omitempty"`
+	Wallet      string `json:"wallet_provider,omitempty"`
+	WalletToken string `json:"wallet_token,omitempty"`
+}
+
+// httpAuthorizeRequest is the wire format of an authorization
+type httpAuthorizeRequest struct {
+	PaymentID   string      `json:"payment_id"`
+	Amount      float64     `json:"amount"`
+	Currency    string      `json:"currency"`
+	Description string      `json:"description"`
+	Method      *httpMethod `json:"method,omitempty"`
+}
+
+// httpResponse is the wire format of every processor answer
+type httpResponse struct {
+	Reference string `json:"reference"`
+	Result    string `json:"result"`
+	Status    string `json:"status"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+}
+
+// externalStatuses maps processor status strings onto payment statuses
+var externalStatuses = map[string]domain.PaymentStatus{
+	"pending":    domain.PaymentStatusPending,
+	"authorized": domain.PaymentStatusAuthorized,
+	"captured":   domain.PaymentStatusCompleted,
+	"settled":    domain.PaymentStatusCompleted,
+	"refunded":   domain.PaymentStatusRefunded,
+	"voided":     domain.PaymentStatusCancelled,
+	"failed":     domain.PaymentStatusFailed,
+	"declined":   domain.PaymentStatusFailed,
+}
+
+// Name returns the processor name
+func (c *HTTPConnector) Name() string { return c.name }
+
+// Authorize sends an authorization request
+func (c *HTTPConnector) Authorize(ctx context.Context, req domain.AuthorizeRequest) (*domain.ProcessorResult, error) {
+	method, err := c.wireMethod(ctx, req.Method)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.do(ctx, http.MethodPost, "/authorizations", httpAuthorizeRequest{
+		PaymentID:   req.PaymentID,
+		Amount:      req.Amount,
+		Currency:    req.Currency,
+		Description: req.Description,
+		Method:      method,
+	})
+}
+
+// Capture settles an authorization
+func (c *HTTPConnector) Capture(ctx context.Context, reference string, amount float64) (*domain.ProcessorResult, error) {
+	return c.do(ctx, http.MethodPost, "/payments/"+url.PathEscape(reference)+"/capture", map[string]float64{"amount": amount})
+}
+
+// Refund returns captured funds
+func (c *HTTPConnector) Refund(ctx context.Context, reference string, amount float64) (*domain.ProcessorResult, error) {
+	return c.do(ctx, http.MethodPost, "/payments/"+url.PathEscape(reference)+"/refund", map[string]float64{"amount": amount})
+}
+
+// Void cancels an authorization
+func (c *HTTPConnector) Void(ctx context.Context, reference string) (*domain.ProcessorResult, error) {
+	return c.do(ctx, http.MethodPost, "/payments/"+url.PathEscape(reference)+"/void", struct{}{})
+}
+
+// GetStatus fetches the processor's status of a payment
+func (c *HTTPConnector) GetStatus(ctx context.Context, reference string) (*domain.ProcessorResult, error) {
+	return c.do(ctx, http.MethodGet, "/payments/"+url.PathEscape(reference), nil)
+}
+
+// AcceptsWebhooks reports whether a webhook secret is configured
+func (c *HTTPConnector) AcceptsWebhooks() bool {
+	return c.webhookKey != ""
+}
+
+// VerifyWebhook authenticates and decodes a callback sent by the processor
+func (c *HTTPConnector) VerifyWebhook(header http.Header, body []byte) (*domain.ProcessorEvent, error) {
+	if c.webhookKey == "" {
+		return nil, fmt.Errorf("%w: no webhook secret configured for %s", ErrInvalidSignature, c.name)
+	}
+	if err := verifySignature(c.webhookKey, header, body); err != nil {
+		return nil, err
+	}
+	return parseWebhook(body)
+}
+
+// wireMethod converts a payment method to the wire format, detokenizing vaulted cards when allowed
+func (c *HTTPConnector) wireMethod(ctx context.Context, method domain.PaymentMethod) (*httpMethod, error) {
+	switch m := method.(type) {
+	case domain.CardMethod:
+		wire := &httpMethod{Type: "card", CardToken: m.Token, ExpiryMonth: m.ExpiryMonth, ExpiryYear: m.ExpiryYear}
+		if m.Token != "" && c.detokenizer != nil {
+			card, err := c.detokenizer.Detokenize(ctx, m.Token)
+			if err != nil {
+				return nil, err
+			}
+			wire.CardNumber = card.PAN
+			wire.CardToken = ""
+		}
+		return wire, nil
+	case domain.BankAccountMethod:
+		return &httpMethod{Type: strings.ToLower(string(m.Scheme)), IBAN: m.IBAN, Routing: m.RoutingNumber, Account: m.AccountNumber}, nil
+	case domain.WalletMethod:
+		return &httpMethod{Type: "wallet", Wallet: m.Provider, WalletToken: m.Token}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// do performs a request and decodes the processor response.
+// Transport failures and 5xx responses are reported as ErrProcessorUnavailable.
+func (c *HTTPConnector) do(ctx context.Context, method, path string, body interface{}) (*domain.ProcessorResult, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrProcessorUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return nil, fmt.Errorf("%w: %s returned %d", domain.ErrProcessorUnavailable, c.name, resp.StatusCode)
+	}
+
+	var decoded httpResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("invalid response from %s: %w", c.name, err)
+	}
+	if resp.StatusCode >= 400 && decoded.Result == "" {
+		return nil, fmt.Errorf("%s rejected the request with status %d: %s", c.name, resp.StatusCode, decoded.Message)
+	}
+
+	result := &domain.ProcessorResult{
+		Reference: decoded.Reference,
+		Status:    externalStatuses[strings.ToLower(decoded.Status)],
+		Code:      decoded.Code,
+		Message:   decoded.Message,
+	}
+	switch strings.ToLower(decoded.Result) {
+	case "approved":
+		result.Outcome = domain.ProcessorOutcomeApproved
+	case "pending":
+		result.Outcome = domain.ProcessorOutcomePending
+	default:
+		result.Outcome = domain.ProcessorOutcomeDeclined
+	}
+
+	return result, nil
+}
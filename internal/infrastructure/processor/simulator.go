@@ -0,0 +1,212 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"payments_app/internal/domain"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Magic amount cents that make the simulator produce specific outcomes
+const (
+	SimulatorDoNotHonorCents        = 2  // x.02 declines with code 05
+	SimulatorInsufficientFundsCents = 5  // x.05 declines with code 51
+	SimulatorPendingCents           = 7  // x.07 answers PENDING; GetStatus later reports AUTHORIZED
+	SimulatorUnavailableCents       = 13 // x.13 fails with ErrProcessorUnavailable
+)
+
+// SimulatorDeclinedCardLast4 makes card payments ending in these digits decline
+const SimulatorDeclinedCardLast4 = "0002"
+
+// SimulatorConfig configures the in-process simulator
+type SimulatorConfig struct {
+	Name    string
+	Latency time.Duration
+	// WebhookSecret verifies callback signatures; callbacks are refused without it
+	WebhookSecret string
+}
+
+// simulatedPayment is the simulator's record of a payment
+type simulatedPayment struct {
+	status     domain.PaymentStatus
+	amount     float64
+	captured   float64
+	refunded   float64
+	settleNext bool
+}
+
+// Simulator is a deterministic in-process processor for development and tests
+type Simulator struct {
+	name       string
+	latency    time.Duration
+	webhookKey string
+	mutex      sync.Mutex
+	payments   map[string]*simulatedPayment
+}
+
+// NewSimulator creates a new simulator processor
+func NewSimulator(cfg SimulatorConfig) *Simulator {
+	if cfg.Name == "" {
+		cfg.Name = "simulator"
+	}
+	return &Simulator{
+		name:       cfg.Name,
+		latency:    cfg.Latency,
+		webhookKey: cfg.WebhookSecret,
+		payments:   make(map[string]*simulatedPayment),
+	}
+}
+
+// Name returns the processor name
+func (s *Simulator) Name() string { return s.name }
+
+// Authorize approves or declines based on magic amounts and card numbers
+func (s *Simulator) Authorize(ctx context.Context, req domain.AuthorizeRequest) (*domain.ProcessorResult, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	reference := "sim_" + uuid.New().String()
+	result := &domain.ProcessorResult{Reference: reference}
+
+	cents := int(math.Round(req.Amount*100)) % 100
+	card, isCard := req.Method.(domain.CardMethod)
+	switch {
+	case cents == SimulatorUnavailableCents:
+		return nil, fmt.Errorf("%w: simulated outage", domain.ErrProcessorUnavailable)
+	case cents == SimulatorDoNotHonorCents || (isCard && card.Last4 == SimulatorDeclinedCardLast4):
+		result.Outcome, result.Status, result.Code, result.Message = domain.ProcessorOutcomeDeclined, domain.PaymentStatusFailed, "05", "do not honor"
+	case cents == SimulatorInsufficientFundsCents:
+		result.Outcome, result.Status, result.Code, result.Message = domain.ProcessorOutcomeDeclined, domain.PaymentStatusFailed, "51", "insufficient funds"
+	case cents == SimulatorPendingCents:
+		result.Outcome, result.Status, result.Code, result.Message = domain.ProcessorOutcomePending, domain.PaymentStatusPending, "09", "authorization pending"
+	default:
+		result.Outcome, result.Status, result.Code, result.Message = domain.ProcessorOutcomeApproved, domain.PaymentStatusAuthorized, "00", "approved"
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.payments[reference] = &simulatedPayment{
+		status:     result.Status,
+		amount:     req.Amount,
+		settleNext: result.Outcome == domain.ProcessorOutcomePending,
+	}
+
+	return result, nil
+}
+
+// Capture settles an authorized payment
+func (s *Simulator) Capture(ctx context.Context, reference string, amount float64) (*domain.ProcessorResult, error) {
+	return s.operate(ctx, reference, func(p *simulatedPayment) *domain.ProcessorResult {
+		if p.status != domain.PaymentStatusAuthorized {
+			return declined(reference, p.status, "invalid state for capture")
+		}
+		if amount > p.amount {
+			return declined(reference, p.status, "capture exceeds authorized amount")
+		}
+		p.status = domain.PaymentStatusCompleted
+		p.captured = amount
+		return approved(reference, p.status)
+	})
+}
+
+// Refund returns captured funds, fully or partially
+func (s *Simulator) Refund(ctx context.Context, reference string, amount float64) (*domain.ProcessorResult, error) {
+	return s.operate(ctx, reference, func(p *simulatedPayment) *domain.ProcessorResult {
+		if p.status != domain.PaymentStatusCompleted {
+			return declined(reference, p.status, "invalid state for refund")
+		}
+		if p.refunded+amount > p.captured+0.005 {
+			return declined(reference, p.status, "refund exceeds captured amount")
+		}
+		p.refunded += amount
+		if p.refunded >= p.captured-0.005 {
+			p.status = domain.PaymentStatusRefunded
+		}
+		return approved(reference, p.status)
+	})
+}
+
+// Void cancels an authorization before capture
+func (s *Simulator) Void(ctx context.Context, reference string) (*domain.ProcessorResult, error) {
+	return s.operate(ctx, reference, func(p *simulatedPayment) *domain.ProcessorResult {
+		if p.status != domain.PaymentStatusAuthorized && p.status != domain.PaymentStatusPending {
+			return declined(reference, p.status, "invalid state for void")
+		}
+		p.status = domain.PaymentStatusCancelled
+		return approved(reference, p.status)
+	})
+}
+
+// GetStatus reports the simulator's status; pending authorizations settle on their first status check
+func (s *Simulator) GetStatus(ctx context.Context, reference string) (*domain.ProcessorResult, error) {
+	return s.operate(ctx, reference, func(p *simulatedPayment) *domain.ProcessorResult {
+		if p.settleNext {
+			p.settleNext = false
+			p.status = domain.PaymentStatusAuthorized
+		}
+		return approved(reference, p.status)
+	})
+}
+
+// AcceptsWebhooks reports whether a webhook secret is configured
+func (s *Simulator) AcceptsWebhooks() bool {
+	return s.webhookKey != ""
+}
+
+// VerifyWebhook authenticates and decodes a callback sent to the simulator
+func (s *Simulator) VerifyWebhook(header http.Header, body []byte) (*domain.ProcessorEvent, error) {
+	if s.webhookKey == "" {
+		return nil, fmt.Errorf("%w: no webhook secret configured for %s", ErrInvalidSignature, s.name)
+	}
+	if err := verifySignature(s.webhookKey, header, body); err != nil {
+		return nil, err
+	}
+	return parseWebhook(body)
+}
+
+// operate runs an operation against a known simulated payment
+func (s *Simulator) operate(ctx context.Context, reference string, op func(p *simulatedPayment) *domain.ProcessorResult) (*domain.ProcessorResult, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	payment, exists := s.payments[reference]
+	if !exists {
+		return nil, fmt.Errorf("unknown processor reference %s", reference)
+	}
+	return op(payment), nil
+}
+
+// wait injects the configured latency, honouring context cancellation
+func (s *Simulator) wait(ctx context.Context) error {
+	if s.latency <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(s.latency)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func approved(reference string, status domain.PaymentStatus) *domain.ProcessorResult {
+	return &domain.ProcessorResult{Reference: reference, Outcome: domain.ProcessorOutcomeApproved, Status: status, Code: "00", Message: "approved"}
+}
+
+func declined(reference string, status domain.PaymentStatus, message string) *domain.ProcessorResult {
+	return &domain.ProcessorResult{Reference: reference, Outcome: domain.ProcessorOutcomeDeclined, Status: status, Code: "12", Message: message}
+}
@@ -0,0 +1,97 @@
+package processor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"payments_app/internal/domain"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Webhook signature headers. The signature is "sha256=" followed by the hex HMAC-SHA256
+// of the timestamp, a dot and the raw body, keyed with the connector's webhook secret.
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+)
+
+// WebhookTolerance is how far a callback's timestamp may be from now, limiting replayed requests
+const WebhookTolerance = 5 * time.Minute
+
+// ErrInvalidSignature is returned for callbacks that fail signature verification
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// webhookPayload is the wire format of a processor callback
+type webhookPayload struct {
+	ID        string  `json:"id"`
+	Type      string  `json:"type"`
+	Reference string  `json:"reference"`
+	Status    string  `json:"status"`
+	Amount    float64 `json:"amount"`
+	Code      string  `json:"code"`
+	Message   string  `json:"message"`
+}
+
+// SignWebhook returns the signature header value for a callback body
+func SignWebhook(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifySignature checks the timestamp and HMAC signature headers of a callback
+func verifySignature(secret string, header http.Header, body []byte) error {
+	seconds, err := strconv.ParseInt(header.Get(WebhookTimestampHeader), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: missing or invalid timestamp", ErrInvalidSignature)
+	}
+	timestamp := time.Unix(seconds, 0)
+	if age := time.Since(timestamp); age > WebhookTolerance || age < -WebhookTolerance {
+		return fmt.Errorf("%w: timestamp outside tolerance", ErrInvalidSignature)
+	}
+
+	expected := SignWebhook(secret, timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(header.Get(WebhookSignatureHeader))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// parseWebhook decodes a callback body into a processor event
+func parseWebhook(body []byte) (*domain.ProcessorEvent, error) {
+	var payload webhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+	if payload.ID == "" || payload.Reference == "" {
+		return nil, errors.New("invalid webhook payload: id and reference are required")
+	}
+
+	event := &domain.ProcessorEvent{
+		CallbackID: payload.ID,
+		Type:       domain.ProcessorEventStatus,
+		Reference:  payload.Reference,
+		Amount:     payload.Amount,
+		Code:       payload.Code,
+		Message:    payload.Message,
+	}
+	if strings.EqualFold(payload.Type, "chargeback") {
+		event.Type = domain.ProcessorEventChargeback
+	}
+	if payload.Status != "" {
+		status, known := externalStatuses[strings.ToLower(payload.Status)]
+		if !known {
+			return nil, fmt.Errorf("invalid webhook payload: unknown status %q", payload.Status)
+		}
+		event.Status = status
+	}
+	return event, nil
+}
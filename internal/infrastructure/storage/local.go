@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"payments_app/internal/domain"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// DefaultMaxFileSize caps stored files when no limit is configured
+const DefaultMaxFileSize = 10 << 20
+
+// ErrFileTooLarge is returned when a file exceeds the store's size limit
+var ErrFileTooLarge = errors.New("file exceeds the maximum size")
+
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// LocalStore keeps files in a directory on local disk
+type LocalStore struct {
+	root    string
+	maxSize int64
+}
+
+// NewLocalStore creates a store rooted at root, creating the directory if needed
+func NewLocalStore(root string, maxSize int64) (*LocalStore, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxFileSize
+	}
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, err
+	}
+	return &LocalStore{root: root, maxSize: maxSize}, nil
+}
+
+// Save writes content below dir under a unique name derived from name. The file only
+// appears once completely written; the returned Path is relative to the store root.
+func (s *LocalStore) Save(ctx context.Context, dir, name, contentType string, content io.Reader) (*domain.EvidenceFile, error) {
+	cleanDir := filepath.Clean(dir)
+	if filepath.IsAbs(cleanDir) || cleanDir == ".." || strings.HasPrefix(cleanDir, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("invalid storage directory %q", dir)
+	}
+	target := filepath.Join(s.root, cleanDir)
+	if err := os.MkdirAll(target, 0o750); err != nil {
+		return nil, err
+	}
+
+	safeName := strings.Trim(unsafeNameChars.ReplaceAllString(filepath.Base(name), "_"), "._")
+	if safeName == "" {
+		safeName = "file"
+	}
+	relative := filepath.Join(cleanDir, uuid.New().String()+"-"+safeName)
+
+	tmp, err := os.CreateTemp(target, ".upload-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(content, s.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if size > s.maxSize {
+		return nil, fmt.Errorf("%w of %d bytes", ErrFileTooLarge, s.maxSize)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(s.root, relative)); err != nil {
+		return nil, err
+	}
+
+	return &domain.EvidenceFile{
+		Name:        name,
+		ContentType: contentType,
+		Size:        size,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+		Path:        relative,
+	}, nil
+}
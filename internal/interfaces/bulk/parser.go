@@ -0,0 +1,258 @@
+// Package bulk reads payment import files into rows for PaymentUseCase.BulkCreatePayments.
+package bulk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"payments_app/internal/domain"
+	"payments_app/internal/usecases"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxLineBytes caps the length of one JSON Lines record
+const MaxLineBytes = 1 << 20
+
+// Format is the encoding of an import file
+type Format string
+
+const (
+	FormatCSV   Format = "CSV"
+	FormatJSONL Format = "JSONL"
+)
+
+// ParseFormat converts a format name such as "csv" or "jsonl" to a Format
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "CSV":
+		return FormatCSV, nil
+	case "JSONL", "NDJSON":
+		return FormatJSONL, nil
+	default:
+		return "", fmt.Errorf("unsupported import format %q", name)
+	}
+}
+
+// FormatFromFilename infers the format from a file extension
+func FormatFromFilename(name string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".jsonl", ".ndjson":
+		return FormatJSONL, nil
+	default:
+		return "", fmt.Errorf("cannot infer import format from %q; specify csv or jsonl", name)
+	}
+}
+
+// Parse reads every payment of an import file. Rows that cannot be decoded are returned with
+// Err set so they show up in the report; only an unreadable file fails as a whole. Reading
+// stops one row past usecases.MaxBulkRows so oversized files are rejected without being read in full.
+func Parse(r io.Reader, format Format) ([]usecases.BulkPaymentRow, error) {
+	switch format {
+	case FormatCSV:
+		return parseCSV(r)
+	case FormatJSONL:
+		return parseJSONL(r)
+	default:
+		return nil, fmt.Errorf("unsupported import format %q", format)
+	}
+}
+
+// Columns lists the CSV header names; amount, currency and description are required
+var Columns = []string{
+	"amount", "currency", "description", "payer_id", "tenant_id", "customer_id",
+	"payer_name", "payer_account", "payer_country",
+	"payee_name", "payee_account", "payee_country",
+	"execute_at", "settlement_currency", "method", "card_token",
+	"bank_scheme", "iban", "bic", "routing_number", "account_number", "holder_name",
+	"account_type", "holder_type",
+	"wallet_provider", "wallet_token",
+}
+
+var requiredColumns = []string{"amount", "currency", "description"}
+
+// parseCSV reads a CSV file with a header row naming the columns
+func parseCSV(r io.Reader) ([]usecases.BulkPaymentRow, error) {
+	reader := csv.NewReader(bufio.NewReader(r))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("import file is empty")
+		}
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !isColumn(name) {
+			return nil, fmt.Errorf("unknown CSV column %q", name)
+		}
+		if _, exists := columns[name]; exists {
+			return nil, fmt.Errorf("duplicate CSV column %q", name)
+		}
+		columns[name] = i
+	}
+	for _, name := range requiredColumns {
+		if _, exists := columns[name]; !exists {
+			return nil, fmt.Errorf("missing required CSV column %q", name)
+		}
+	}
+
+	var rows []usecases.BulkPaymentRow
+	for len(rows) <= usecases.MaxBulkRows {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			rows = append(rows, usecases.BulkPaymentRow{Line: parseErr.StartLine, Err: parseErr.Err})
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+		row := usecases.BulkPaymentRow{Line: line}
+		row.Input, row.Err = csvInput(func(name string) string {
+			if i, exists := columns[name]; exists {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		})
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// csvInput builds the payment input of one CSV record
+func csvInput(field func(name string) string) (usecases.CreatePaymentInput, error) {
+	input := usecases.CreatePaymentInput{
+		Currency:    field("currency"),
+		Description: field("description"),
+		PayerID:     field("payer_id"),
+		TenantID:    field("tenant_id"),
+		CustomerID:  field("customer_id"),
+		Payer:       csvParty(field, "payer"),
+		Payee:       csvParty(field, "payee"),
+
+		SettlementCurrency: field("settlement_currency"),
+	}
+
+	amount, err := strconv.ParseFloat(field("amount"), 64)
+	if err != nil {
+		return input, fmt.Errorf("amount %q is not a number", field("amount"))
+	}
+	input.Amount = amount
+
+	if value := field("execute_at"); value != "" {
+		executeAt, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return input, fmt.Errorf("execute_at %q must be an RFC3339 timestamp", value)
+		}
+		input.ExecuteAt = &executeAt
+	}
+
+	switch method := domain.PaymentMethodType(strings.ToUpper(field("method"))); method {
+	case "":
+	case domain.PaymentMethodTypeCard:
+		input.Method = &usecases.PaymentMethodInput{Type: method, Card: &usecases.CardInput{
+			Token:      field("card_token"),
+			HolderName: field("holder_name"),
+		}}
+	case domain.PaymentMethodTypeBankAccount:
+		input.Method = &usecases.PaymentMethodInput{Type: method, BankAccount: &usecases.BankAccountInput{
+			Scheme:        domain.BankScheme(strings.ToUpper(field("bank_scheme"))),
+			IBAN:          field("iban"),
+			BIC:           field("bic"),
+			RoutingNumber: field("routing_number"),
+			AccountNumber: field("account_number"),
+			HolderName:    field("holder_name"),
+			AccountType:   domain.BankAccountType(field("account_type")),
+			HolderType:    domain.AccountHolderType(field("holder_type")),
+		}}
+	case domain.PaymentMethodTypeWallet:
+		input.Method = &usecases.PaymentMethodInput{Type: method, Wallet: &usecases.WalletInput{
+			Provider: field("wallet_provider"),
+			Token:    field("wallet_token"),
+		}}
+	default:
+		return input, fmt.Errorf("method %q must be CARD, BANK_ACCOUNT or WALLET", field("method"))
+	}
+
+	return input, nil
+}
+
+// csvParty returns the counterparty described by the role's columns, or nil when they are empty
+func csvParty(field func(name string) string, role string) *domain.Party {
+	party := domain.Party{
+		Name:    field(role + "_name"),
+		Account: field(role + "_account"),
+		Country: field(role + "_country"),
+	}
+	if party == (domain.Party{}) {
+		return nil
+	}
+	return &party
+}
+
+func isColumn(name string) bool {
+	for _, column := range Columns {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parseJSONL reads one createPayment input object per line; blank lines are skipped
+func parseJSONL(r io.Reader) ([]usecases.BulkPaymentRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxLineBytes)
+
+	var rows []usecases.BulkPaymentRow
+	line := 0
+	for len(rows) <= usecases.MaxBulkRows && scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if line == 1 {
+			text = bytes.TrimPrefix(text, []byte("\ufeff"))
+		}
+		if len(text) == 0 {
+			continue
+		}
+
+		row := usecases.BulkPaymentRow{Line: line}
+		decoder := json.NewDecoder(bytes.NewReader(text))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&row.Input); err != nil {
+			row.Err = fmt.Errorf("invalid JSON: %w", err)
+		} else if decoder.More() {
+			row.Err = errors.New("invalid JSON: one object per line expected")
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, fmt.Errorf("line %d is longer than %d bytes", line+1, MaxLineBytes)
+		}
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("import file is empty")
+	}
+	return rows, nil
+}
@@ -0,0 +1,70 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"payments_app/internal/domain"
+	"payments_app/internal/usecases"
+	"payments_app/pkg/logger"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// ACHHandler serves POST /exports/ach-files and GET /exports/ach-files/{fileId}
+type ACHHandler struct {
+	useCase *usecases.PaymentUseCase
+	log     *logger.Logger
+}
+
+// NewACHHandler creates an ACH file handler
+func NewACHHandler(useCase *usecases.PaymentUseCase, log *logger.Logger) *ACHHandler {
+	return &ACHHandler{useCase: useCase, log: log}
+}
+
+// ServeHTTP creates a NACHA file of the pending ACH transfers on POST, answering 204 if there
+// are none, and downloads a file created before on GET. Files are sent as attachments named
+// after their ID.
+func (h *ACHHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var file *domain.ACHFile
+	var err error
+	status := http.StatusOK
+	if r.Method == http.MethodPost {
+		file, err = h.useCase.CreateACHFile(r.Context())
+		status = http.StatusCreated
+	} else {
+		file, err = h.useCase.GetACHFile(r.Context(), mux.Vars(r)["fileId"])
+	}
+
+	switch {
+	case err == nil:
+	case errors.Is(err, domain.ErrNoACHTransfers):
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case errors.Is(err, usecases.ErrACHNotConfigured):
+		writeError(w, http.StatusNotImplemented, err)
+		return
+	case errors.Is(err, domain.ErrACHConflict):
+		writeError(w, http.StatusConflict, err)
+		return
+	case r.Method == http.MethodGet:
+		writeError(w, http.StatusNotFound, err)
+		return
+	default:
+		h.log.Errorf("ACH file failed: %v", err)
+		writeError(w, http.StatusInternalServerError, errors.New("ACH file failed"))
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		h.log.Infof("created ACH file %s with %d entries in %d batches, total credit %d cents", file.ID, file.EntryCount, file.BatchCount, file.TotalCredit)
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=us-ascii")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.ID+".ach"))
+	w.Header().Set("X-File-Id", file.ID)
+	w.Header().Set("X-Entry-Count", strconv.Itoa(file.EntryCount))
+	w.Header().Set("X-Total-Credit", strconv.FormatInt(file.TotalCredit, 10))
+	w.WriteHeader(status)
+	w.Write(file.Document)
+}
@@ -0,0 +1,71 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"payments_app/internal/domain"
+	"payments_app/internal/usecases"
+	"payments_app/pkg/logger"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// CreditTransferHandler serves POST /exports/credit-transfers and
+// GET /exports/credit-transfers/{messageId}
+type CreditTransferHandler struct {
+	useCase *usecases.PaymentUseCase
+	log     *logger.Logger
+}
+
+// NewCreditTransferHandler creates a credit transfer file handler
+func NewCreditTransferHandler(useCase *usecases.PaymentUseCase, log *logger.Logger) *CreditTransferHandler {
+	return &CreditTransferHandler{useCase: useCase, log: log}
+}
+
+// ServeHTTP creates a pain.001 file of the pending credit transfers on POST, answering 204 if
+// there are none, and downloads a file created before on GET. Files are sent as attachments
+// named after their message ID.
+func (h *CreditTransferHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var file *domain.CreditTransferFile
+	var err error
+	status := http.StatusOK
+	if r.Method == http.MethodPost {
+		file, err = h.useCase.CreateCreditTransferFile(r.Context())
+		status = http.StatusCreated
+	} else {
+		file, err = h.useCase.GetCreditTransferFile(r.Context(), mux.Vars(r)["messageId"])
+	}
+
+	switch {
+	case err == nil:
+	case errors.Is(err, domain.ErrNoCreditTransfers):
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case errors.Is(err, usecases.ErrCreditTransfersNotConfigured):
+		writeError(w, http.StatusNotImplemented, err)
+		return
+	case errors.Is(err, domain.ErrCreditTransferConflict):
+		writeError(w, http.StatusConflict, err)
+		return
+	case r.Method == http.MethodGet:
+		writeError(w, http.StatusNotFound, err)
+		return
+	default:
+		h.log.Errorf("credit transfer file failed: %v", err)
+		writeError(w, http.StatusInternalServerError, errors.New("credit transfer file failed"))
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		h.log.Infof("created credit transfer file %s with %d payments, control sum %s", file.MessageID, file.NumberOfTransactions, file.ControlSum)
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.MessageID+".xml"))
+	w.Header().Set("X-Message-Id", file.MessageID)
+	w.Header().Set("X-Number-Of-Transactions", strconv.Itoa(file.NumberOfTransactions))
+	w.Header().Set("X-Control-Sum", file.ControlSum)
+	w.WriteHeader(status)
+	w.Write(file.Document)
+}
@@ -0,0 +1,97 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"payments_app/internal/domain"
+	"payments_app/internal/usecases"
+	"strings"
+	"time"
+)
+
+// Run streams the payments matching filter to the writer returned by open. open is called
+// once, before the first payment is written or after an empty result, so an export that
+// fails validation produces no output at all. When Run fails after open was called, the
+// output is incomplete and must be discarded.
+func Run(ctx context.Context, useCase *usecases.PaymentUseCase, filter domain.PaymentFilter, format Format, open func() (io.Writer, error)) (int, error) {
+	var writer Writer
+	start := func() error {
+		out, err := open()
+		if err != nil {
+			return err
+		}
+		writer, err = NewWriter(out, format)
+		return err
+	}
+
+	count := 0
+	err := useCase.ExportPayments(ctx, filter, func(payment *domain.Payment) error {
+		if writer == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		count++
+		return writer.Write(payment)
+	})
+	if err != nil {
+		return count, err
+	}
+	if writer == nil {
+		if err := start(); err != nil {
+			return 0, err
+		}
+	}
+	return count, writer.Close()
+}
+
+// ParseFilter reads a payment filter from query parameters named like the fields of the
+// GraphQL PaymentFilter input. status may be repeated or comma-separated; createdFrom and
+// createdTo are RFC 3339 timestamps. Metadata values are given as metadata.<key> and tags as
+// repeated tag parameters.
+func ParseFilter(values url.Values) (domain.PaymentFilter, error) {
+	filter := domain.PaymentFilter{
+		Currency:   values.Get("currency"),
+		PayerID:    values.Get("payerId"),
+		TenantID:   values.Get("tenantId"),
+		CustomerID: values.Get("customerId"),
+		Tags:       values["tag"],
+	}
+	for name := range values {
+		if key, found := strings.CutPrefix(name, "metadata."); found {
+			if filter.Metadata == nil {
+				filter.Metadata = make(map[string]string)
+			}
+			filter.Metadata[key] = values.Get(name)
+		}
+	}
+	for _, value := range values["status"] {
+		for _, status := range strings.Split(value, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				filter.Statuses = append(filter.Statuses, domain.PaymentStatus(strings.ToUpper(status)))
+			}
+		}
+	}
+
+	var err error
+	if filter.CreatedFrom, err = parseTime("createdFrom", values.Get("createdFrom")); err != nil {
+		return filter, err
+	}
+	if filter.CreatedTo, err = parseTime("createdTo", values.Get("createdTo")); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+func parseTime(field, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC 3339 timestamp", field)
+	}
+	return &parsed, nil
+}
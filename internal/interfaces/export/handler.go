@@ -0,0 +1,73 @@
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"payments_app/internal/usecases"
+	"payments_app/pkg/logger"
+	"time"
+)
+
+// Handler serves GET /exports/payments
+type Handler struct {
+	useCase *usecases.PaymentUseCase
+	log     *logger.Logger
+}
+
+// NewHandler creates a payment export handler
+func NewHandler(useCase *usecases.PaymentUseCase, log *logger.Logger) *Handler {
+	return &Handler{useCase: useCase, log: log}
+}
+
+// ServeHTTP streams the payments matching the query filter as an attachment. The format
+// parameter selects csv (default), jsonl or parquet. Errors found before the first byte is
+// sent are answered with a JSON error; a failure mid-stream aborts the connection so the
+// client cannot mistake a truncated file for a complete one.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format := FormatCSV
+	if name := query.Get("format"); name != "" {
+		var err error
+		if format, err = ParseFormat(name); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	filter, err := ParseFilter(query)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	started := false
+	count, err := Run(r.Context(), h.useCase, filter, format, func() (io.Writer, error) {
+		started = true
+		filename := fmt.Sprintf("payments-%s.%s", time.Now().UTC().Format("20060102T150405Z"), format.Extension())
+		w.Header().Set("Content-Type", format.ContentType())
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		w.WriteHeader(http.StatusOK)
+		return w, nil
+	})
+	var input *usecases.InputError
+	switch {
+	case err == nil:
+		h.log.Infof("exported %d payments as %s", count, format)
+	case !started && errors.As(err, &input):
+		writeError(w, http.StatusBadRequest, err)
+	case !started:
+		h.log.Errorf("payment export failed: %v", err)
+		writeError(w, http.StatusInternalServerError, errors.New("export failed"))
+	default:
+		h.log.Errorf("payment export failed after %d payments: %v", count, err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
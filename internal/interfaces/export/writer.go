@@ -0,0 +1,236 @@
+// Package export streams payments as CSV, JSON Lines or Parquet files.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"payments_app/internal/domain"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetRowGroupSize bounds how many rows the Parquet writer buffers before flushing a row group
+const parquetRowGroupSize = 10000
+
+// Format is the encoding of an export file
+type Format string
+
+const (
+	FormatCSV     Format = "CSV"
+	FormatJSONL   Format = "JSONL"
+	FormatParquet Format = "PARQUET"
+)
+
+// ParseFormat converts a format name such as "csv", "jsonl" or "parquet" to a Format
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "CSV":
+		return FormatCSV, nil
+	case "JSONL", "NDJSON":
+		return FormatJSONL, nil
+	case "PARQUET":
+		return FormatParquet, nil
+	default:
+		return "", fmt.Errorf("unsupported export format %q", name)
+	}
+}
+
+// ContentType returns the MIME type of the format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatJSONL:
+		return "application/x-ndjson"
+	case FormatParquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// Extension returns the file extension of the format, without the dot
+func (f Format) Extension() string {
+	return strings.ToLower(string(f))
+}
+
+// Record is one exported payment. Payment method details other than the type are left out.
+type Record struct {
+	ID                 string     `parquet:"id" json:"id"`
+	Amount             float64    `parquet:"amount" json:"amount"`
+	Currency           string     `parquet:"currency" json:"currency"`
+	Description        string     `parquet:"description" json:"description"`
+	Status             string     `parquet:"status" json:"status"`
+	PayerID            string     `parquet:"payer_id" json:"payer_id"`
+	TenantID           string     `parquet:"tenant_id" json:"tenant_id"`
+	SubscriptionID     string     `parquet:"subscription_id" json:"subscription_id"`
+	PayerName          string     `parquet:"payer_name" json:"payer_name"`
+	PayerAccount       string     `parquet:"payer_account" json:"payer_account"`
+	PayerCountry       string     `parquet:"payer_country" json:"payer_country"`
+	PayeeName          string     `parquet:"payee_name" json:"payee_name"`
+	PayeeAccount       string     `parquet:"payee_account" json:"payee_account"`
+	PayeeCountry       string     `parquet:"payee_country" json:"payee_country"`
+	MethodType         string     `parquet:"method_type" json:"method_type"`
+	Processor          string     `parquet:"processor" json:"processor"`
+	ProcessorReference string     `parquet:"processor_reference" json:"processor_reference"`
+	RefundedAmount     float64    `parquet:"refunded_amount" json:"refunded_amount"`
+	RiskScore          *int64     `parquet:"risk_score,optional" json:"risk_score"`
+	RiskDecision       string     `parquet:"risk_decision" json:"risk_decision"`
+	ScreeningStatus    string     `parquet:"screening_status" json:"screening_status"`
+	ExecuteAt          *time.Time `parquet:"execute_at,optional" json:"execute_at"`
+	// SettlementAmount is an exact decimal; it and the rate are empty for payments settled in their own currency
+	SettlementAmount   string    `parquet:"settlement_amount" json:"settlement_amount"`
+	SettlementCurrency string    `parquet:"settlement_currency" json:"settlement_currency"`
+	FXRate             string    `parquet:"fx_rate" json:"fx_rate"`
+	CreatedAt          time.Time `parquet:"created_at" json:"created_at"`
+	UpdatedAt          time.Time `parquet:"updated_at" json:"updated_at"`
+}
+
+// Columns lists the exported fields in file order; they name the CSV header and the Parquet columns
+var Columns = []string{
+	"id", "amount", "currency", "description", "status", "payer_id", "tenant_id", "subscription_id",
+	"payer_name", "payer_account", "payer_country", "payee_name", "payee_account", "payee_country",
+	"method_type", "processor", "processor_reference", "refunded_amount",
+	"risk_score", "risk_decision", "screening_status", "execute_at",
+	"settlement_amount", "settlement_currency", "fx_rate", "created_at", "updated_at",
+}
+
+// NewRecord flattens a payment into an export record
+func NewRecord(payment *domain.Payment) Record {
+	record := Record{
+		ID:                 payment.ID,
+		Amount:             payment.Amount,
+		Currency:           payment.Currency,
+		Description:        payment.Description,
+		Status:             string(payment.Status),
+		PayerID:            payment.PayerID,
+		TenantID:           payment.TenantID,
+		SubscriptionID:     payment.SubscriptionID,
+		Processor:          payment.Processor,
+		ProcessorReference: payment.ProcessorReference,
+		RefundedAmount:     payment.RefundedAmount,
+		CreatedAt:          payment.CreatedAt.UTC(),
+		UpdatedAt:          payment.UpdatedAt.UTC(),
+	}
+	if payment.Payer != nil {
+		record.PayerName, record.PayerAccount, record.PayerCountry = payment.Payer.Name, payment.Payer.Account, payment.Payer.Country
+	}
+	if payment.Payee != nil {
+		record.PayeeName, record.PayeeAccount, record.PayeeCountry = payment.Payee.Name, payment.Payee.Account, payment.Payee.Country
+	}
+	if payment.Method != nil {
+		record.MethodType = string(payment.Method.MethodType())
+	}
+	if payment.Risk != nil {
+		score := int64(payment.Risk.Score)
+		record.RiskScore = &score
+		record.RiskDecision = string(payment.Risk.Decision)
+	}
+	if payment.Screening != nil {
+		record.ScreeningStatus = string(payment.Screening.Status)
+	}
+	if payment.ExecuteAt != nil {
+		executeAt := payment.ExecuteAt.UTC()
+		record.ExecuteAt = &executeAt
+	}
+	if payment.Settlement != nil {
+		record.SettlementAmount = payment.Settlement.Amount.Decimal()
+		record.SettlementCurrency = payment.Settlement.Amount.Currency
+		record.FXRate = payment.Settlement.Rate.Rate
+	}
+	return record
+}
+
+// Writer encodes payments one at a time; Close must be called to complete the file
+type Writer interface {
+	Write(payment *domain.Payment) error
+	Close() error
+}
+
+// NewWriter creates a writer encoding payments to w in the given format
+func NewWriter(w io.Writer, format Format) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w)
+	case FormatJSONL:
+		return &jsonlWriter{encoder: json.NewEncoder(w)}, nil
+	case FormatParquet:
+		return &parquetWriter{writer: parquet.NewGenericWriter[Record](w,
+			parquet.Compression(&parquet.Snappy),
+			parquet.MaxRowsPerRowGroup(parquetRowGroupSize),
+		)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+	row    []string
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(Columns); err != nil {
+		return nil, err
+	}
+	return &csvWriter{writer: writer, row: make([]string, 0, len(Columns))}, nil
+}
+
+func (c *csvWriter) Write(payment *domain.Payment) error {
+	record := NewRecord(payment)
+	riskScore, executeAt := "", ""
+	if record.RiskScore != nil {
+		riskScore = strconv.FormatInt(*record.RiskScore, 10)
+	}
+	if record.ExecuteAt != nil {
+		executeAt = record.ExecuteAt.Format(time.RFC3339)
+	}
+	c.row = append(c.row[:0],
+		record.ID, strconv.FormatFloat(record.Amount, 'f', -1, 64), record.Currency, record.Description, record.Status,
+		record.PayerID, record.TenantID, record.SubscriptionID,
+		record.PayerName, record.PayerAccount, record.PayerCountry,
+		record.PayeeName, record.PayeeAccount, record.PayeeCountry,
+		record.MethodType, record.Processor, record.ProcessorReference,
+		strconv.FormatFloat(record.RefundedAmount, 'f', -1, 64),
+		riskScore, record.RiskDecision, record.ScreeningStatus,
+		executeAt, record.SettlementAmount, record.SettlementCurrency, record.FXRate,
+		record.CreatedAt.Format(time.RFC3339), record.UpdatedAt.Format(time.RFC3339),
+	)
+	return c.writer.Write(c.row)
+}
+
+func (c *csvWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+type jsonlWriter struct {
+	encoder *json.Encoder
+}
+
+func (j *jsonlWriter) Write(payment *domain.Payment) error {
+	return j.encoder.Encode(NewRecord(payment))
+}
+
+func (j *jsonlWriter) Close() error {
+	return nil
+}
+
+type parquetWriter struct {
+	writer *parquet.GenericWriter[Record]
+	row    [1]Record
+}
+
+func (p *parquetWriter) Write(payment *domain.Payment) error {
+	p.row[0] = NewRecord(payment)
+	_, err := p.writer.Write(p.row[:])
+	return err
+}
+
+func (p *parquetWriter) Close() error {
+	return p.writer.Close()
+}
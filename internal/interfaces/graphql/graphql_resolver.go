@@ -2,11 +2,21 @@ package graphql
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"payments_app/graph/generated"
 	"payments_app/graph/model"
 	"payments_app/internal/domain"
+	"payments_app/internal/interfaces/bulk"
+	"payments_app/internal/nacha"
+	"payments_app/internal/reconciliation"
 	"payments_app/internal/usecases"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/99designs/gqlgen/graphql"
 )
 
 // Resolver implements the generated GraphQL resolver interface
@@ -36,6 +46,11 @@ func (r *Resolver) Payment() generated.PaymentResolver {
 	return &paymentResolver{r}
 }
 
+// Customer returns the customer resolver
+func (r *Resolver) Customer() generated.CustomerResolver {
+	return &customerResolver{r}
+}
+
 // mutationResolver handles mutation operations
 type mutationResolver struct{ *Resolver }
 
@@ -46,6 +61,37 @@ func (r *mutationResolver) CreatePayment(ctx context.Context, input model.Create
 		Currency:    input.Currency,
 		Description: input.Description,
 	}
+	if input.PayerID != nil {
+		useCaseInput.PayerID = *input.PayerID
+	}
+	if input.TenantID != nil {
+		useCaseInput.TenantID = *input.TenantID
+	}
+	useCaseInput.CustomerID = derefString(input.CustomerID)
+	useCaseInput.Payer = partyInputToDomain(input.Payer)
+	useCaseInput.Payee = partyInputToDomain(input.Payee)
+	useCaseInput.Method = methodInputToUseCase(input.Method)
+	useCaseInput.SettlementCurrency = derefString(input.SettlementCurrency)
+	for _, split := range input.Splits {
+		useCaseInput.Splits = append(useCaseInput.Splits, usecases.SplitInput{
+			Recipient: split.Recipient,
+			Amount:    derefString(split.Amount),
+			Percent:   derefString(split.Percent),
+		})
+	}
+	useCaseInput.Tax = taxInputToUseCase(input.Tax)
+	if input.ExecuteAt != nil {
+		executeAt, err := parseTimestamp("executeAt", *input.ExecuteAt)
+		if err != nil {
+			return nil, err
+		}
+		useCaseInput.ExecuteAt = &executeAt
+	}
+	metadata, err := metadataFromModel(input.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	useCaseInput.Metadata, useCaseInput.Tags = metadata, input.Tags
 
 	payment, err := r.paymentUseCase.CreatePayment(ctx, useCaseInput)
 	if err != nil {
@@ -62,12 +108,18 @@ func (r *mutationResolver) UpdatePayment(ctx context.Context, input model.Update
 		Amount:      input.Amount,
 		Currency:    input.Currency,
 		Description: input.Description,
+		Tags:        input.Tags,
 	}
 
 	if input.Status != nil {
 		status := domain.PaymentStatus(*input.Status)
 		useCaseInput.Status = &status
 	}
+	metadata, err := metadataFromModel(input.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	useCaseInput.Metadata = metadata
 
 	payment, err := r.paymentUseCase.UpdatePayment(ctx, useCaseInput)
 	if err != nil {
@@ -87,27 +139,38 @@ func (r *mutationResolver) DeletePayment(ctx context.Context, id string) (bool,
 	return true, nil
 }
 
-// queryResolver handles query operations
-type queryResolver struct{ *Resolver }
+// ResolveScreeningHold releases or rejects a payment held by sanctions screening
+func (r *mutationResolver) ResolveScreeningHold(ctx context.Context, input model.ResolveScreeningHoldInput) (*model.Payment, error) {
+	useCaseInput := usecases.ResolveScreeningHoldInput{
+		PaymentID: input.PaymentID,
+		Release:   input.Decision == model.ScreeningDecisionRelease,
+		Analyst:   input.Analyst,
+	}
+	if input.Note != nil {
+		useCaseInput.Note = *input.Note
+	}
 
-// Payments retrieves all payments
-func (r *queryResolver) Payments(ctx context.Context) ([]*model.Payment, error) {
-	payments, err := r.paymentUseCase.GetAllPayments(ctx)
+	payment, err := r.paymentUseCase.ResolveScreeningHold(ctx, useCaseInput)
 	if err != nil {
 		return nil, err
 	}
 
-	result := make([]*model.Payment, len(payments))
-	for i, payment := range payments {
-		result[i] = r.domainToModel(payment)
+	return r.domainToModel(payment), nil
+}
+
+// AuthorizePayment sends a pending payment to the processor for authorization
+func (r *mutationResolver) AuthorizePayment(ctx context.Context, id string) (*model.Payment, error) {
+	payment, err := r.paymentUseCase.AuthorizePayment(ctx, id)
+	if err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	return r.domainToModel(payment), nil
 }
 
-// Payment retrieves a payment by ID
-func (r *queryResolver) Payment(ctx context.Context, id string) (*model.Payment, error) {
-	payment, err := r.paymentUseCase.GetPayment(ctx, id)
+// CapturePayment captures an authorized payment
+func (r *mutationResolver) CapturePayment(ctx context.Context, id string) (*model.Payment, error) {
+	payment, err := r.paymentUseCase.CapturePayment(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -115,28 +178,1778 @@ func (r *queryResolver) Payment(ctx context.Context, id string) (*model.Payment,
 	return r.domainToModel(payment), nil
 }
 
-// paymentResolver handles payment field resolvers
-type paymentResolver struct{ *Resolver }
+// VoidPayment cancels an authorized payment before capture
+func (r *mutationResolver) VoidPayment(ctx context.Context, id string) (*model.Payment, error) {
+	payment, err := r.paymentUseCase.VoidPayment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
 
-// CreatedAt returns the created at timestamp as string
-func (r *paymentResolver) CreatedAt(ctx context.Context, obj *model.Payment) (string, error) {
-	return obj.CreatedAt.Format(time.RFC3339), nil
+	return r.domainToModel(payment), nil
 }
 
-// UpdatedAt returns the updated at timestamp as string
-func (r *paymentResolver) UpdatedAt(ctx context.Context, obj *model.Payment) (string, error) {
-	return obj.UpdatedAt.Format(time.RFC3339), nil
+// RefundPayment refunds a completed payment in full or in part
+func (r *mutationResolver) RefundPayment(ctx context.Context, id string, amount *float64) (*model.Payment, error) {
+	payment, err := r.paymentUseCase.RefundPayment(ctx, id, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.domainToModel(payment), nil
 }
 
-// domainToModel converts domain Payment to GraphQL model Payment
-func (r *Resolver) domainToModel(payment *domain.Payment) *model.Payment {
-	return &model.Payment{
-		ID:          payment.ID,
-		Amount:      payment.Amount,
-		Currency:    payment.Currency,
-		Description: payment.Description,
-		Status:      model.PaymentStatus(payment.Status),
-		CreatedAt:   payment.CreatedAt,
-		UpdatedAt:   payment.UpdatedAt,
+// SyncPaymentStatus refreshes a payment's status from its processor
+func (r *mutationResolver) SyncPaymentStatus(ctx context.Context, id string) (*model.Payment, error) {
+	payment, err := r.paymentUseCase.SyncPaymentStatus(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.domainToModel(payment), nil
+}
+
+// ReplayProcessorCallback applies a stored processor callback again
+func (r *mutationResolver) ReplayProcessorCallback(ctx context.Context, id string) (*model.ProcessorCallback, error) {
+	callback, err := r.paymentUseCase.ReplayProcessorCallback(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return callbackToModel(callback), nil
+}
+
+// OpenDispute opens a dispute against a completed payment
+func (r *mutationResolver) OpenDispute(ctx context.Context, input model.OpenDisputeInput) (*model.Dispute, error) {
+	useCaseInput := usecases.OpenDisputeInput{
+		PaymentID:  input.PaymentID,
+		ReasonCode: input.ReasonCode,
+		Amount:     input.Amount,
+	}
+	if input.EvidenceDueAt != nil {
+		dueAt, err := parseTimestamp("evidenceDueAt", *input.EvidenceDueAt)
+		if err != nil {
+			return nil, err
+		}
+		useCaseInput.EvidenceDueAt = &dueAt
+	}
+
+	dispute, err := r.paymentUseCase.OpenDispute(ctx, useCaseInput)
+	if err != nil {
+		return nil, err
+	}
+
+	return disputeToModel(dispute), nil
+}
+
+// SubmitDisputeEvidence attaches evidence text and uploaded files to a dispute
+func (r *mutationResolver) SubmitDisputeEvidence(ctx context.Context, input model.SubmitDisputeEvidenceInput) (*model.Dispute, error) {
+	useCaseInput := usecases.SubmitDisputeEvidenceInput{
+		DisputeID:   input.DisputeID,
+		Text:        derefString(input.Text),
+		SubmittedBy: input.SubmittedBy,
+	}
+	for _, upload := range input.Files {
+		useCaseInput.Files = append(useCaseInput.Files, usecases.EvidenceUpload{
+			Name:        upload.Filename,
+			ContentType: upload.ContentType,
+			Content:     upload.File,
+		})
+	}
+
+	dispute, err := r.paymentUseCase.SubmitDisputeEvidence(ctx, useCaseInput)
+	if err != nil {
+		return nil, err
+	}
+
+	return disputeToModel(dispute), nil
+}
+
+// ResolveDispute records the outcome of a dispute
+func (r *mutationResolver) ResolveDispute(ctx context.Context, input model.ResolveDisputeInput) (*model.Dispute, error) {
+	dispute, err := r.paymentUseCase.ResolveDispute(ctx, usecases.ResolveDisputeInput{
+		DisputeID: input.DisputeID,
+		Won:       input.Outcome == model.DisputeOutcomeWon,
+		Note:      derefString(input.Note),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return disputeToModel(dispute), nil
+}
+
+// CreateSubscription creates a recurring payment schedule
+func (r *mutationResolver) CreateSubscription(ctx context.Context, input model.CreateSubscriptionInput) (*model.Subscription, error) {
+	useCaseInput := usecases.CreateSubscriptionInput{
+		Amount:      input.Amount,
+		Currency:    input.Currency,
+		Description: input.Description,
+		PayerID:     derefString(input.PayerID),
+		TenantID:    derefString(input.TenantID),
+		Method:      methodInputToUseCase(input.Method),
+		Frequency:   domain.Frequency(input.Schedule.Frequency),
+		Interval:    derefInt(input.Schedule.Interval),
+		AnchorDay:   derefInt(input.Schedule.AnchorDay),
+	}
+	if input.Schedule.StartAt != nil {
+		startAt, err := parseTimestamp("startAt", *input.Schedule.StartAt)
+		if err != nil {
+			return nil, err
+		}
+		useCaseInput.StartAt = &startAt
+	}
+
+	subscription, err := r.paymentUseCase.CreateSubscription(ctx, useCaseInput)
+	if err != nil {
+		return nil, err
+	}
+
+	return subscriptionToModel(subscription), nil
+}
+
+// PauseSubscription stops billing a subscription
+func (r *mutationResolver) PauseSubscription(ctx context.Context, id string) (*model.Subscription, error) {
+	subscription, err := r.paymentUseCase.PauseSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return subscriptionToModel(subscription), nil
+}
+
+// ResumeSubscription restarts billing of a paused or unpaid subscription
+func (r *mutationResolver) ResumeSubscription(ctx context.Context, id string) (*model.Subscription, error) {
+	subscription, err := r.paymentUseCase.ResumeSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return subscriptionToModel(subscription), nil
+}
+
+// CancelSubscription ends a subscription
+func (r *mutationResolver) CancelSubscription(ctx context.Context, id string) (*model.Subscription, error) {
+	subscription, err := r.paymentUseCase.CancelSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return subscriptionToModel(subscription), nil
+}
+
+// ReschedulePayment moves the execution date of a scheduled payment
+func (r *mutationResolver) ReschedulePayment(ctx context.Context, id string, executeAt string) (*model.Payment, error) {
+	at, err := parseTimestamp("executeAt", executeAt)
+	if err != nil {
+		return nil, err
+	}
+
+	payment, err := r.paymentUseCase.ReschedulePayment(ctx, id, at)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.domainToModel(payment), nil
+}
+
+// CancelScheduledPayment cancels a scheduled payment before it executes
+func (r *mutationResolver) CancelScheduledPayment(ctx context.Context, id string) (*model.Payment, error) {
+	payment, err := r.paymentUseCase.CancelScheduledPayment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.domainToModel(payment), nil
+}
+
+// BulkCreatePayments imports payments from an uploaded CSV or JSON Lines file
+func (r *mutationResolver) BulkCreatePayments(ctx context.Context, file graphql.Upload, format *model.BulkFormat, mode *model.BulkMode) (*model.BulkImportReport, error) {
+	var fileFormat bulk.Format
+	var err error
+	if format != nil {
+		fileFormat, err = bulk.ParseFormat(string(*format))
+	} else {
+		fileFormat, err = bulk.FormatFromFilename(file.Filename)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := bulk.Parse(file.File, fileFormat)
+	if err != nil {
+		return nil, err
+	}
+	importMode := usecases.BulkModeBestEffort
+	if mode != nil {
+		importMode = usecases.BulkMode(*mode)
+	}
+
+	result, err := r.paymentUseCase.BulkCreatePayments(ctx, importMode, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return bulkResultToModel(result), nil
+}
+
+// ImportBankStatement imports an uploaded camt.053 or MT940 statement and matches its lines
+func (r *mutationResolver) ImportBankStatement(ctx context.Context, file graphql.Upload, format *model.StatementFormat) (*model.StatementImportReport, error) {
+	var statementFormat domain.StatementFormat
+	if format != nil {
+		statementFormat = domain.StatementFormat(*format)
+	}
+	statements, err := reconciliation.Parse(file.File, statementFormat)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file.Filename, err)
+	}
+
+	result, err := r.paymentUseCase.ImportStatements(ctx, statements)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.StatementImportReport{
+		Statements: make([]*model.BankStatement, len(result.Statements)),
+		Run:        reconciliationRunToModel(&result.Run),
+	}
+	for i, statement := range result.Statements {
+		report.Statements[i] = statementToModel(statement)
+	}
+	return report, nil
+}
+
+// ReconcileStatements matches unmatched statement lines against payments completed since the last run
+func (r *mutationResolver) ReconcileStatements(ctx context.Context) (*model.ReconciliationRun, error) {
+	run, err := r.paymentUseCase.Reconcile(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return reconciliationRunToModel(run), nil
+}
+
+// ConfirmStatementMatch accepts a proposed match
+func (r *mutationResolver) ConfirmStatementMatch(ctx context.Context, lineID string, confirmedBy string) (*model.StatementLine, error) {
+	line, err := r.paymentUseCase.ConfirmStatementMatch(ctx, lineID, confirmedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	return statementLineToModel(line), nil
+}
+
+// RejectStatementMatch returns a line to the unmatched queue
+func (r *mutationResolver) RejectStatementMatch(ctx context.Context, lineID string) (*model.StatementLine, error) {
+	line, err := r.paymentUseCase.RejectStatementMatch(ctx, lineID)
+	if err != nil {
+		return nil, err
+	}
+
+	return statementLineToModel(line), nil
+}
+
+// MatchStatementLine matches a line to a payment by hand
+func (r *mutationResolver) MatchStatementLine(ctx context.Context, lineID string, paymentID string, matchedBy string) (*model.StatementLine, error) {
+	line, err := r.paymentUseCase.MatchStatementLine(ctx, lineID, paymentID, matchedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	return statementLineToModel(line), nil
+}
+
+// ProcessAchReturns fails the payments returned in an uploaded ACH return file
+func (r *mutationResolver) ProcessAchReturns(ctx context.Context, file graphql.Upload) (*model.AchReturnReport, error) {
+	returns, err := nacha.ParseReturns(file.File)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file.Filename, err)
+	}
+
+	report, err := r.paymentUseCase.ApplyACHReturns(ctx, returns)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.AchReturnReport{
+		Total:     report.Total,
+		Applied:   report.Applied,
+		Duplicate: report.Duplicate,
+		Unmatched: report.Unmatched,
+		Rejected:  report.Rejected,
+		Returns:   make([]*model.AchReturn, len(report.Returns)),
+	}
+	for i, returned := range report.Returns {
+		result.Returns[i] = &model.AchReturn{
+			TraceNumber:         returned.Return.TraceNumber,
+			OriginalTraceNumber: returned.Return.OriginalTraceNumber,
+			Code:                returned.Return.Code,
+			Reason:              returned.Return.Reason,
+			Amount:              moneyToModel(domain.Money{MinorUnits: returned.Return.Amount, Currency: "USD"}),
+			Information:         optionalString(returned.Return.Information),
+			Status:              model.AchReturnStatus(returned.Status),
+			PaymentID:           optionalString(returned.PaymentID),
+			Error:               optionalString(returned.Error),
+		}
+	}
+	return result, nil
+}
+
+// CreateCustomer saves a new customer
+func (r *mutationResolver) CreateCustomer(ctx context.Context, input model.CustomerInput) (*model.Customer, error) {
+	customerInput, err := customerInputFromModel(input)
+	if err != nil {
+		return nil, err
+	}
+	customer, err := r.paymentUseCase.CreateCustomer(ctx, customerInput)
+	if err != nil {
+		return nil, err
+	}
+
+	return customerToModel(customer), nil
+}
+
+// UpdateCustomer changes the given fields of a customer
+func (r *mutationResolver) UpdateCustomer(ctx context.Context, id string, input model.CustomerInput) (*model.Customer, error) {
+	customerInput, err := customerInputFromModel(input)
+	if err != nil {
+		return nil, err
+	}
+	customer, err := r.paymentUseCase.UpdateCustomer(ctx, id, customerInput)
+	if err != nil {
+		return nil, err
+	}
+
+	return customerToModel(customer), nil
+}
+
+// DeleteCustomer deletes a customer without payments
+func (r *mutationResolver) DeleteCustomer(ctx context.Context, id string) (bool, error) {
+	if err := r.paymentUseCase.DeleteCustomer(ctx, id); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// EraseCustomer removes a customer's personal data and keeps its payments
+func (r *mutationResolver) EraseCustomer(ctx context.Context, id string) (*model.Customer, error) {
+	customer, err := r.paymentUseCase.EraseCustomer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return customerToModel(customer), nil
+}
+
+// RunSettlement creates today's settlement batch of merchant payouts
+func (r *mutationResolver) RunSettlement(ctx context.Context) (*model.SettlementBatch, error) {
+	batch, err := r.paymentUseCase.RunSettlement(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return settlementBatchToModel(batch), nil
+}
+
+// MarkPayoutSent records that a payout was handed to the bank
+func (r *mutationResolver) MarkPayoutSent(ctx context.Context, id string, reference string) (*model.Payout, error) {
+	payout, err := r.paymentUseCase.MarkPayoutSent(ctx, id, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	return payoutToModel(payout), nil
+}
+
+// MarkPayoutPaid records that the merchant received a payout
+func (r *mutationResolver) MarkPayoutPaid(ctx context.Context, id string) (*model.Payout, error) {
+	payout, err := r.paymentUseCase.MarkPayoutPaid(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return payoutToModel(payout), nil
+}
+
+// MarkPayoutFailed records that a payout will not arrive so its payments are settled again
+func (r *mutationResolver) MarkPayoutFailed(ctx context.Context, id string, reason string) (*model.Payout, error) {
+	payout, err := r.paymentUseCase.MarkPayoutFailed(ctx, id, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	return payoutToModel(payout), nil
+}
+
+// CreateInvoice issues an invoice that payments can be applied to
+func (r *mutationResolver) CreateInvoice(ctx context.Context, input model.InvoiceInput) (*model.Invoice, error) {
+	dueDate, err := parseDate("dueDate", input.DueDate)
+	if err != nil {
+		return nil, err
+	}
+	invoiceInput := usecases.InvoiceInput{
+		Number:     input.Number,
+		CustomerID: derefString(input.CustomerID),
+		Currency:   input.Currency,
+		DueDate:    dueDate,
+		Lines:      make([]usecases.InvoiceLineInput, len(input.Lines)),
+		Tax:        taxInputToUseCase(input.Tax),
+	}
+	for i, line := range input.Lines {
+		invoiceInput.Lines[i] = usecases.InvoiceLineInput{
+			Description: line.Description,
+			Category:    derefString(line.Category),
+			Quantity:    derefString(line.Quantity),
+			UnitPrice:   line.UnitPrice,
+			Tax:         derefString(line.Tax),
+		}
+	}
+
+	invoice, err := r.paymentUseCase.CreateInvoice(ctx, invoiceInput)
+	if err != nil {
+		return nil, err
+	}
+
+	return invoiceToModel(invoice, time.Now()), nil
+}
+
+// ApplyPayment allocates parts of a completed payment to invoices
+func (r *mutationResolver) ApplyPayment(ctx context.Context, paymentID string, allocations []*model.InvoiceAllocationInput) ([]*model.InvoiceAllocation, error) {
+	inputs := make([]usecases.InvoiceAllocationInput, len(allocations))
+	for i, allocation := range allocations {
+		inputs[i] = usecases.InvoiceAllocationInput{InvoiceID: allocation.InvoiceID, Amount: allocation.Amount}
+	}
+
+	applied, err := r.paymentUseCase.ApplyPayment(ctx, paymentID, inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.InvoiceAllocation, len(applied))
+	for i, allocation := range applied {
+		result[i] = invoiceAllocationToModel(allocation)
+	}
+	return result, nil
+}
+
+// RemoveInvoiceAllocation takes back the part of a payment applied to an invoice
+func (r *mutationResolver) RemoveInvoiceAllocation(ctx context.Context, id string) (*model.InvoiceAllocation, error) {
+	allocation, err := r.paymentUseCase.RemoveInvoiceAllocation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return invoiceAllocationToModel(allocation), nil
+}
+
+// TokenizeCard stores a card in the vault and returns its token
+func (r *mutationResolver) TokenizeCard(ctx context.Context, input model.TokenizeCardInput) (*model.CardToken, error) {
+	card, err := r.paymentUseCase.TokenizeCard(ctx, usecases.CardInput{
+		Number:      input.Number,
+		ExpiryMonth: input.ExpiryMonth,
+		ExpiryYear:  input.ExpiryYear,
+		HolderName:  derefString(input.HolderName),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.CardToken{
+		Token:       card.Token,
+		Brand:       card.Brand,
+		Last4:       card.Last4,
+		ExpiryMonth: card.ExpiryMonth,
+		ExpiryYear:  card.ExpiryYear,
+		HolderName:  optionalString(card.HolderName),
+		CreatedAt:   card.CreatedAt.Format(time.RFC3339),
+	}, nil
+}
+
+// queryResolver handles query operations
+type queryResolver struct{ *Resolver }
+
+// Payments retrieves the payments matching the optional filter in creation order
+func (r *queryResolver) Payments(ctx context.Context, filter *model.PaymentFilter) ([]*model.Payment, error) {
+	paymentFilter, err := filterFromModel(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := r.paymentUseCase.ListPayments(ctx, paymentFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.Payment, len(payments))
+	for i, payment := range payments {
+		result[i] = r.domainToModel(payment)
+	}
+
+	return result, nil
+}
+
+// Payment retrieves a payment by ID
+func (r *queryResolver) Payment(ctx context.Context, id string) (*model.Payment, error) {
+	payment, err := r.paymentUseCase.GetPayment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.domainToModel(payment), nil
+}
+
+// PaymentStats aggregates the payments matching filter, grouped by the requested keys
+func (r *queryResolver) PaymentStats(ctx context.Context, filter *model.PaymentFilter, groupBy []model.PaymentStatsGroupBy, timezone *string) ([]*model.PaymentStatsGroup, error) {
+	paymentFilter, err := filterFromModel(filter)
+	if err != nil {
+		return nil, err
+	}
+	dimensions := make([]domain.StatsDimension, len(groupBy))
+	for i, dimension := range groupBy {
+		dimensions[i] = domain.StatsDimension(dimension)
+	}
+
+	groups, err := r.paymentUseCase.PaymentStats(ctx, paymentFilter, dimensions, derefString(timezone))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.PaymentStatsGroup, len(groups))
+	for i, group := range groups {
+		result[i] = statsGroupToModel(group)
+	}
+	return result, nil
+}
+
+// ProcessorStats reports success rate, latency and circuit state per processor
+func (r *queryResolver) ProcessorStats(ctx context.Context) ([]*model.ProcessorStats, error) {
+	stats := r.paymentUseCase.ProcessorStats()
+	result := make([]*model.ProcessorStats, len(stats))
+	for i, s := range stats {
+		result[i] = &model.ProcessorStats{
+			Processor:        s.Processor,
+			Calls:            s.Calls,
+			Approvals:        s.Approvals,
+			Declines:         s.Declines,
+			Errors:           s.Errors,
+			SuccessRate:      s.SuccessRate,
+			AverageLatencyMs: int(s.AverageLatency.Milliseconds()),
+			Circuit:          model.CircuitState(s.Circuit),
+		}
+	}
+	return result, nil
+}
+
+// ProcessorCallbacks lists recent processor callbacks for debugging
+func (r *queryResolver) ProcessorCallbacks(ctx context.Context, processor *string, limit *int) ([]*model.ProcessorCallback, error) {
+	callbacks, err := r.paymentUseCase.ListProcessorCallbacks(ctx, derefString(processor), derefInt(limit))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.ProcessorCallback, len(callbacks))
+	for i, callback := range callbacks {
+		result[i] = callbackToModel(callback)
+	}
+	return result, nil
+}
+
+// Dispute retrieves a dispute by ID
+func (r *queryResolver) Dispute(ctx context.Context, id string) (*model.Dispute, error) {
+	dispute, err := r.paymentUseCase.GetDispute(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return disputeToModel(dispute), nil
+}
+
+// Disputes lists disputes, optionally for one payment or in one status
+func (r *queryResolver) Disputes(ctx context.Context, paymentID *string, status *model.DisputeStatus) ([]*model.Dispute, error) {
+	filter := domain.DisputeFilter{PaymentID: derefString(paymentID)}
+	if status != nil {
+		filter.Statuses = []domain.DisputeStatus{domain.DisputeStatus(*status)}
+	}
+
+	disputes, err := r.paymentUseCase.ListDisputes(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return disputesToModel(disputes), nil
+}
+
+// DisputesNearingDeadline lists open disputes whose evidence is due within the given days (default 3)
+func (r *queryResolver) DisputesNearingDeadline(ctx context.Context, days *int) ([]*model.Dispute, error) {
+	within := 3
+	if days != nil {
+		within = *days
+	}
+
+	disputes, err := r.paymentUseCase.DisputesNearingDeadline(ctx, time.Duration(within)*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	return disputesToModel(disputes), nil
+}
+
+// LedgerEntries lists the journal entries posted for a payment
+func (r *queryResolver) LedgerEntries(ctx context.Context, paymentID string) ([]*model.JournalEntry, error) {
+	entries, err := r.paymentUseCase.LedgerEntries(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.JournalEntry, len(entries))
+	for i, entry := range entries {
+		result[i] = &model.JournalEntry{
+			ID:          entry.ID,
+			PaymentID:   optionalString(entry.PaymentID),
+			Description: entry.Description,
+			CreatedAt:   entry.CreatedAt.Format(time.RFC3339),
+		}
+		for _, posting := range entry.Postings {
+			result[i].Postings = append(result[i].Postings, &model.Posting{
+				Account:  posting.Account,
+				Currency: posting.Currency,
+				Amount:   posting.Amount,
+			})
+		}
+	}
+	return result, nil
+}
+
+// RecipientBalance returns what a split recipient is owed and the entries behind it
+func (r *queryResolver) RecipientBalance(ctx context.Context, recipient string) (*model.RecipientBalance, error) {
+	balances, err := r.paymentUseCase.RecipientBalances(ctx, recipient)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := r.paymentUseCase.RecipientBalanceEntries(ctx, recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.RecipientBalance{
+		Recipient: recipient,
+		Balances:  make([]*model.Money, len(balances)),
+		Entries:   make([]*model.BalanceEntry, len(entries)),
+	}
+	for i, balance := range balances {
+		result.Balances[i] = moneyToModel(balance)
+	}
+	for i, entry := range entries {
+		result.Entries[i] = &model.BalanceEntry{
+			ID:        entry.ID,
+			Recipient: entry.Recipient,
+			PaymentID: entry.PaymentID,
+			Kind:      model.BalanceEntryKind(entry.Kind),
+			Amount:    moneyToModel(entry.Amount),
+			CreatedAt: entry.CreatedAt.UTC().Format(time.RFC3339),
+		}
+	}
+	return result, nil
+}
+
+// Customer retrieves a customer by ID
+func (r *queryResolver) Customer(ctx context.Context, id string) (*model.Customer, error) {
+	customer, err := r.paymentUseCase.GetCustomer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return customerToModel(customer), nil
+}
+
+// Customers lists customers, optionally by email or external reference
+func (r *queryResolver) Customers(ctx context.Context, email *string, externalReference *string) ([]*model.Customer, error) {
+	customers, err := r.paymentUseCase.ListCustomers(ctx, domain.CustomerFilter{
+		Email:             derefString(email),
+		ExternalReference: derefString(externalReference),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.Customer, len(customers))
+	for i, customer := range customers {
+		result[i] = customerToModel(customer)
+	}
+	return result, nil
+}
+
+// SearchPayments finds payments by words of their description, customer or references. The
+// cursor of a result page is the position of its last hit.
+func (r *queryResolver) SearchPayments(ctx context.Context, query string, first *int, after *string) (*model.PaymentSearchConnection, error) {
+	size, offset := 0, 0
+	if first != nil {
+		size = *first
+		if size <= 0 {
+			return nil, errors.New("first must be greater than 0")
+		}
+	}
+	if after != nil {
+		position, err := decodeSearchCursor(*after)
+		if err != nil {
+			return nil, err
+		}
+		offset = position
+	}
+
+	results, err := r.paymentUseCase.SearchPayments(ctx, query, size, offset)
+	if err != nil {
+		return nil, err
+	}
+	result := &model.PaymentSearchConnection{
+		Nodes:      make([]*model.PaymentSearchResult, len(results.Hits)),
+		PageInfo:   &model.PageInfo{HasNextPage: results.HasNextPage},
+		TotalCount: results.TotalCount,
+	}
+	for i, hit := range results.Hits {
+		result.Nodes[i] = &model.PaymentSearchResult{
+			Payment: r.domainToModel(hit.Payment),
+			Rank:    hit.Rank,
+			Snippet: hit.Snippet,
+		}
+	}
+	if len(results.Hits) > 0 {
+		endCursor := encodeSearchCursor(offset + len(results.Hits))
+		result.PageInfo.EndCursor = &endCursor
+	}
+	return result, nil
+}
+
+// SettlementBatch is the settlement report of a batch: its payouts and the payments each contains
+func (r *queryResolver) SettlementBatch(ctx context.Context, id string) (*model.SettlementBatch, error) {
+	batch, err := r.paymentUseCase.GetSettlementBatch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return settlementBatchToModel(batch), nil
+}
+
+// Payout retrieves a payout with its payments by ID
+func (r *queryResolver) Payout(ctx context.Context, id string) (*model.Payout, error) {
+	payout, err := r.paymentUseCase.GetPayout(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return payoutToModel(payout), nil
+}
+
+// Payouts lists payouts, optionally for one merchant, batch or status
+func (r *queryResolver) Payouts(ctx context.Context, merchantID *string, batchID *string, status *model.PayoutStatus) ([]*model.Payout, error) {
+	filter := domain.PayoutFilter{MerchantID: derefString(merchantID), BatchID: derefString(batchID)}
+	if status != nil {
+		filter.Statuses = []domain.PayoutStatus{domain.PayoutStatus(*status)}
+	}
+
+	payouts, err := r.paymentUseCase.ListPayouts(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.Payout, len(payouts))
+	for i, payout := range payouts {
+		result[i] = payoutToModel(payout)
+	}
+	return result, nil
+}
+
+// Invoice retrieves an invoice with the payments applied to it by ID
+func (r *queryResolver) Invoice(ctx context.Context, id string) (*model.Invoice, error) {
+	invoice, err := r.paymentUseCase.GetInvoice(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return invoiceToModel(invoice, time.Now()), nil
+}
+
+// Invoices lists invoices, optionally for one customer, currency or status
+func (r *queryResolver) Invoices(ctx context.Context, customerID *string, currency *string, status *model.InvoiceStatus) ([]*model.Invoice, error) {
+	filter := domain.InvoiceFilter{CustomerID: derefString(customerID), Currency: derefString(currency)}
+	var statuses []domain.InvoiceStatus
+	if status != nil {
+		statuses = []domain.InvoiceStatus{domain.InvoiceStatus(*status)}
+	}
+
+	now := time.Now()
+	invoices, err := r.paymentUseCase.ListInvoices(ctx, filter, statuses, now)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.Invoice, len(invoices))
+	for i, invoice := range invoices {
+		result[i] = invoiceToModel(invoice, now)
+	}
+	return result, nil
+}
+
+// InvoiceAging reports the amounts due of unpaid invoices by days past due, per currency
+func (r *queryResolver) InvoiceAging(ctx context.Context, asOf *string, customerID *string) ([]*model.InvoiceAgingReport, error) {
+	at := time.Now()
+	if asOf != nil {
+		date, err := parseDate("asOf", *asOf)
+		if err != nil {
+			return nil, err
+		}
+		at = date
+	}
+
+	reports, err := r.paymentUseCase.InvoiceAging(ctx, at, derefString(customerID))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.InvoiceAgingReport, len(reports))
+	for i, report := range reports {
+		result[i] = &model.InvoiceAgingReport{
+			Currency: report.Currency,
+			AsOf:     report.AsOf.UTC().Format(time.RFC3339),
+			Buckets:  make([]*model.AgingBucket, len(report.Buckets)),
+			Total:    moneyToModel(report.Total),
+			Count:    report.Count,
+		}
+		for j, bucket := range report.Buckets {
+			result[i].Buckets[j] = &model.AgingBucket{
+				Label:    bucket.Label,
+				FromDays: bucket.FromDays,
+				ToDays:   bucket.ToDays,
+				Amount:   moneyToModel(bucket.Amount),
+				Count:    bucket.Count,
+			}
+		}
+	}
+	return result, nil
+}
+
+// Subscription retrieves a subscription by ID
+func (r *queryResolver) Subscription(ctx context.Context, id string) (*model.Subscription, error) {
+	subscription, err := r.paymentUseCase.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return subscriptionToModel(subscription), nil
+}
+
+// Subscriptions lists subscriptions, optionally for one payer or in one status
+func (r *queryResolver) Subscriptions(ctx context.Context, payerID *string, status *model.SubscriptionStatus) ([]*model.Subscription, error) {
+	filter := domain.SubscriptionFilter{PayerID: derefString(payerID)}
+	if status != nil {
+		filter.Statuses = []domain.SubscriptionStatus{domain.SubscriptionStatus(*status)}
+	}
+
+	subscriptions, err := r.paymentUseCase.ListSubscriptions(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.Subscription, len(subscriptions))
+	for i, subscription := range subscriptions {
+		result[i] = subscriptionToModel(subscription)
+	}
+	return result, nil
+}
+
+// BankStatement retrieves an imported statement with its lines
+func (r *queryResolver) BankStatement(ctx context.Context, id string) (*model.BankStatement, error) {
+	statement, err := r.paymentUseCase.GetStatement(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return statementToModel(statement), nil
+}
+
+// UnreconciledStatementLines lists unmatched lines and proposed matches awaiting review
+func (r *queryResolver) UnreconciledStatementLines(ctx context.Context, statementID *string) ([]*model.StatementLine, error) {
+	lines, err := r.paymentUseCase.UnreconciledStatementLines(ctx, derefString(statementID))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.StatementLine, len(lines))
+	for i, line := range lines {
+		result[i] = statementLineToModel(line)
+	}
+	return result, nil
+}
+
+// UnreconciledPayments lists completed, refunded and submitted payments not found on any statement
+func (r *queryResolver) UnreconciledPayments(ctx context.Context) ([]*model.Payment, error) {
+	payments, err := r.paymentUseCase.UnreconciledPayments(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.Payment, len(payments))
+	for i, payment := range payments {
+		result[i] = r.domainToModel(payment)
+	}
+	return result, nil
+}
+
+// paymentResolver handles payment field resolvers
+type paymentResolver struct{ *Resolver }
+
+// CreatedAt returns the created at timestamp as string
+func (r *paymentResolver) CreatedAt(ctx context.Context, obj *model.Payment) (string, error) {
+	return obj.CreatedAt.Format(time.RFC3339), nil
+}
+
+// UpdatedAt returns the updated at timestamp as string
+func (r *paymentResolver) UpdatedAt(ctx context.Context, obj *model.Payment) (string, error) {
+	return obj.UpdatedAt.Format(time.RFC3339), nil
+}
+
+// Customer resolves the payment's customer through the request's dataloader, so a list of
+// payments reads its customers in one query
+func (r *paymentResolver) Customer(ctx context.Context, obj *model.Payment) (*model.Customer, error) {
+	if obj.CustomerID == nil {
+		return nil, nil
+	}
+	customer, err := r.loadersFor(ctx).customers.Load(ctx, *obj.CustomerID)
+	if err != nil || customer == nil {
+		return nil, err
+	}
+
+	return customerToModel(customer), nil
+}
+
+// customerResolver handles customer field resolvers
+type customerResolver struct{ *Resolver }
+
+// Payments returns a page of the customer's payments, newest first. Customers of a list read
+// their pages together through the request's dataloader.
+func (r *customerResolver) Payments(ctx context.Context, obj *model.Customer, first *int, after *string) (*model.PaymentConnection, error) {
+	key := customerPaymentsKey{customerID: obj.ID}
+	if first != nil {
+		key.first = *first
+		if key.first <= 0 {
+			return nil, errors.New("first must be greater than 0")
+		}
+	}
+	if after != nil {
+		if _, err := decodeCursor(*after); err != nil {
+			return nil, err
+		}
+		key.after = *after
+	}
+
+	list, err := r.loadersFor(ctx).customerPayments.Load(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	result := &model.PaymentConnection{
+		Nodes:      make([]*model.Payment, len(list.Payments)),
+		PageInfo:   &model.PageInfo{HasNextPage: list.HasNextPage},
+		TotalCount: list.TotalCount,
+	}
+	for i, payment := range list.Payments {
+		result.Nodes[i] = r.domainToModel(payment)
+	}
+	if len(list.Payments) > 0 {
+		last := list.Payments[len(list.Payments)-1]
+		endCursor := encodeCursor(domain.PaymentCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+		result.PageInfo.EndCursor = &endCursor
+	}
+	return result, nil
+}
+
+// domainToModel converts domain Payment to GraphQL model Payment
+func (r *Resolver) domainToModel(payment *domain.Payment) *model.Payment {
+	result := &model.Payment{
+		ID:          payment.ID,
+		Amount:      payment.Amount,
+		Currency:    payment.Currency,
+		Description: payment.Description,
+		Status:      model.PaymentStatus(payment.Status),
+		CreatedAt:   payment.CreatedAt,
+		UpdatedAt:   payment.UpdatedAt,
+
+		Processor:          optionalString(payment.Processor),
+		ProcessorReference: optionalString(payment.ProcessorReference),
+		ProcessorResponse:  optionalString(payment.ProcessorResponse),
+		RefundedAmount:     payment.RefundedAmount,
+	}
+	result.PayerID = optionalString(payment.PayerID)
+	result.TenantID = optionalString(payment.TenantID)
+	result.CustomerID = optionalString(payment.CustomerID)
+	result.Metadata = metadataToModel(payment.Metadata)
+	result.Tags = payment.Tags
+	if result.Tags == nil {
+		result.Tags = []string{}
+	}
+	result.SubscriptionID = optionalString(payment.SubscriptionID)
+	result.SubmissionID = optionalString(payment.SubmissionID)
+	if payment.ExecuteAt != nil {
+		executeAt := payment.ExecuteAt.Format(time.RFC3339)
+		result.ExecuteAt = &executeAt
+	}
+	result.Route = make([]*model.RouteAttempt, len(payment.Route))
+	for i, attempt := range payment.Route {
+		result.Route[i] = &model.RouteAttempt{
+			Processor: attempt.Processor,
+			Rule:      optionalString(attempt.Rule),
+			Outcome:   optionalString(string(attempt.Outcome)),
+			Error:     optionalString(attempt.Error),
+			LatencyMs: int(attempt.LatencyMs),
+		}
+	}
+	if payment.Risk != nil {
+		result.Risk = &model.RiskAssessment{
+			Score:      payment.Risk.Score,
+			Decision:   model.RiskDecision(payment.Risk.Decision),
+			Reasons:    payment.Risk.Reasons,
+			ReviewedBy: optionalString(payment.Risk.ReviewedBy),
+			ReviewNote: optionalString(payment.Risk.ReviewNote),
+		}
+		if result.Risk.Reasons == nil {
+			result.Risk.Reasons = []string{}
+		}
+		if payment.Risk.ReviewedAt != nil {
+			reviewedAt := payment.Risk.ReviewedAt.Format(time.RFC3339)
+			result.Risk.ReviewedAt = &reviewedAt
+		}
+	}
+	result.Payer = partyToModel(payment.Payer)
+	result.Payee = partyToModel(payment.Payee)
+	result.Method = methodToModel(payment.Method)
+	if payment.Screening != nil {
+		result.Screening = screeningToModel(payment.Screening)
+	}
+	if payment.Settlement != nil {
+		result.Settlement = settlementToModel(payment.Settlement)
+	}
+	result.Fees = make([]*model.Fee, len(payment.Fees))
+	for i, fee := range payment.Fees {
+		result.Fees[i] = &model.Fee{
+			Name:      fee.Name,
+			Event:     model.FeeEvent(fee.Event),
+			Amount:    moneyToModel(fee.Amount),
+			Account:   fee.Account,
+			ChargedAt: fee.ChargedAt.UTC().Format(time.RFC3339),
+		}
+	}
+	result.NetAmount = moneyToModel(payment.NetAmount())
+	result.Splits = make([]*model.Split, len(payment.Splits))
+	for i, split := range payment.Splits {
+		result.Splits[i] = &model.Split{
+			Recipient: split.Recipient,
+			Amount:    moneyToModel(split.Amount),
+			Percent:   optionalString(split.Percent),
+			Reversed:  moneyToModel(split.Reversed),
+		}
+	}
+	if payment.Tax != nil {
+		result.Tax = taxBreakdownToModel(payment.Tax)
+	}
+	return result
+}
+
+// taxInputToUseCase converts an optional GraphQL tax request to its use case input
+func taxInputToUseCase(input *model.TaxInput) *usecases.TaxInput {
+	if input == nil {
+		return nil
+	}
+	result := &usecases.TaxInput{
+		Country:   derefString(input.Country),
+		Category:  derefString(input.Category),
+		VATNumber: derefString(input.VatNumber),
+	}
+	if input.Pricing != nil {
+		result.Pricing = domain.TaxPricing(*input.Pricing)
+	}
+	return result
+}
+
+// taxBreakdownToModel converts a domain TaxBreakdown to its GraphQL model
+func taxBreakdownToModel(breakdown *domain.TaxBreakdown) *model.TaxBreakdown {
+	result := &model.TaxBreakdown{
+		Country:       breakdown.Country,
+		Pricing:       model.TaxPricing(breakdown.Pricing),
+		ReverseCharge: breakdown.ReverseCharge,
+		VatNumber:     optionalString(breakdown.VATNumber),
+		Lines:         make([]*model.TaxLine, len(breakdown.Lines)),
+		Net:           moneyToModel(breakdown.Net),
+		Tax:           moneyToModel(breakdown.Tax),
+		Gross:         moneyToModel(breakdown.Gross),
+	}
+	for i, line := range breakdown.Lines {
+		result.Lines[i] = &model.TaxLine{
+			Category: line.Category,
+			Rate:     line.Rate,
+			Net:      moneyToModel(line.Net),
+			Tax:      moneyToModel(line.Tax),
+			Gross:    moneyToModel(line.Gross),
+		}
+	}
+	return result
+}
+
+// settlementToModel converts a payment's settlement and its rate snapshot to the GraphQL model
+func settlementToModel(settlement *domain.Settlement) *model.Settlement {
+	return &model.Settlement{
+		Amount: moneyToModel(settlement.Amount),
+		Rate: &model.FxRate{
+			Base:   settlement.Rate.Base,
+			Quote:  settlement.Rate.Quote,
+			Rate:   settlement.Rate.Rate,
+			AsOf:   settlement.Rate.AsOf.UTC().Format(time.RFC3339),
+			Source: optionalString(settlement.Rate.Source),
+		},
+		ConvertedAt: settlement.ConvertedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// partyInputToDomain converts a GraphQL party input to a domain Party
+func partyInputToDomain(input *model.PartyInput) *domain.Party {
+	if input == nil {
+		return nil
+	}
+	return &domain.Party{
+		Name:    input.Name,
+		Account: derefString(input.Account),
+		Country: derefString(input.Country),
+	}
+}
+
+// methodInputToUseCase converts a GraphQL payment method input to the use case input
+func methodInputToUseCase(input *model.PaymentMethodInput) *usecases.PaymentMethodInput {
+	if input == nil {
+		return nil
+	}
+
+	method := &usecases.PaymentMethodInput{Type: domain.PaymentMethodType(input.Type)}
+	if input.Card != nil {
+		method.Card = &usecases.CardInput{
+			Token:       derefString(input.Card.Token),
+			Number:      derefString(input.Card.Number),
+			ExpiryMonth: derefInt(input.Card.ExpiryMonth),
+			ExpiryYear:  derefInt(input.Card.ExpiryYear),
+			HolderName:  derefString(input.Card.HolderName),
+		}
+	}
+	if input.BankAccount != nil {
+		method.BankAccount = &usecases.BankAccountInput{
+			Scheme:        domain.BankScheme(input.BankAccount.Scheme),
+			IBAN:          derefString(input.BankAccount.Iban),
+			BIC:           derefString(input.BankAccount.Bic),
+			RoutingNumber: derefString(input.BankAccount.RoutingNumber),
+			AccountNumber: derefString(input.BankAccount.AccountNumber),
+			HolderName:    derefString(input.BankAccount.HolderName),
+		}
+		if input.BankAccount.AccountType != nil {
+			method.BankAccount.AccountType = domain.BankAccountType(*input.BankAccount.AccountType)
+		}
+		if input.BankAccount.HolderType != nil {
+			method.BankAccount.HolderType = domain.AccountHolderType(*input.BankAccount.HolderType)
+		}
+	}
+	if input.Wallet != nil {
+		method.Wallet = &usecases.WalletInput{Provider: input.Wallet.Provider, Token: input.Wallet.Token}
+	}
+	return method
+}
+
+// methodToModel converts a domain PaymentMethod to the GraphQL union, masking account numbers and tokens
+func methodToModel(method domain.PaymentMethod) model.PaymentMethod {
+	switch m := method.(type) {
+	case domain.CardMethod:
+		return &model.CardPaymentMethod{
+			Token:       optionalString(m.Token),
+			Brand:       m.Brand,
+			Last4:       m.Last4,
+			ExpiryMonth: m.ExpiryMonth,
+			ExpiryYear:  m.ExpiryYear,
+			HolderName:  optionalString(m.HolderName),
+		}
+	case domain.BankAccountMethod:
+		result := &model.BankAccountPaymentMethod{
+			Scheme:             model.BankScheme(m.Scheme),
+			Iban:               optionalString(m.IBAN),
+			Bic:                optionalString(m.BIC),
+			RoutingNumber:      optionalString(m.RoutingNumber),
+			AccountNumberLast4: optionalString(lastFour(m.AccountNumber)),
+			HolderName:         optionalString(m.HolderName),
+		}
+		if m.AccountType != "" {
+			accountType := model.BankAccountType(m.AccountType)
+			result.AccountType = &accountType
+		}
+		if m.HolderType != "" {
+			holderType := model.AccountHolderType(m.HolderType)
+			result.HolderType = &holderType
+		}
+		return result
+	case domain.WalletMethod:
+		return &model.WalletPaymentMethod{Provider: m.Provider, TokenLast4: lastFour(m.Token)}
+	default:
+		return nil
+	}
+}
+
+// lastFour returns the last four characters of a value
+func lastFour(value string) string {
+	if len(value) <= 4 {
+		return value
+	}
+	return value[len(value)-4:]
+}
+
+// derefString returns the value of an optional string or an empty string
+func derefString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// derefInt returns the value of an optional int or zero
+func derefInt(value *int) int {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
+
+// partyToModel converts a domain Party to the GraphQL model
+func partyToModel(party *domain.Party) *model.Party {
+	if party == nil {
+		return nil
+	}
+	return &model.Party{
+		Name:    party.Name,
+		Account: optionalString(party.Account),
+		Country: optionalString(party.Country),
+	}
+}
+
+// screeningToModel converts a domain ScreeningResult to the GraphQL model
+func screeningToModel(screening *domain.ScreeningResult) *model.ScreeningResult {
+	result := &model.ScreeningResult{
+		Status:     model.ScreeningStatus(screening.Status),
+		Hits:       make([]*model.ScreeningHit, len(screening.Hits)),
+		ReviewedBy: optionalString(screening.ReviewedBy),
+		ReviewNote: optionalString(screening.ReviewNote),
+	}
+	for i, hit := range screening.Hits {
+		result.Hits[i] = &model.ScreeningHit{
+			Role:        model.PartyRole(hit.Role),
+			Name:        hit.Name,
+			MatchedName: hit.MatchedName,
+			EntryID:     hit.EntryID,
+			List:        hit.List,
+			Score:       hit.Score,
+		}
+	}
+	if screening.ReviewedAt != nil {
+		reviewedAt := screening.ReviewedAt.Format(time.RFC3339)
+		result.ReviewedAt = &reviewedAt
+	}
+	return result
+}
+
+// callbackToModel converts a domain ProcessorCallback to its GraphQL model
+func callbackToModel(callback *domain.ProcessorCallback) *model.ProcessorCallback {
+	result := &model.ProcessorCallback{
+		ID:         callback.ID,
+		Processor:  callback.Processor,
+		CallbackID: callback.Event.CallbackID,
+		EventType:  string(callback.Event.Type),
+		Reference:  callback.Event.Reference,
+		PaymentID:  optionalString(callback.PaymentID),
+		Error:      optionalString(callback.Error),
+		Payload:    callback.Payload,
+		ReceivedAt: callback.ReceivedAt.Format(time.RFC3339),
+	}
+	if callback.Event.Status != "" {
+		status := model.PaymentStatus(callback.Event.Status)
+		result.Status = &status
+	}
+	if callback.Result != "" {
+		callbackResult := model.CallbackResult(callback.Result)
+		result.Result = &callbackResult
+	}
+	if callback.ProcessedAt != nil {
+		processedAt := callback.ProcessedAt.Format(time.RFC3339)
+		result.ProcessedAt = &processedAt
+	}
+	return result
+}
+
+// filterFromModel converts the GraphQL payment filter to the domain filter
+func filterFromModel(filter *model.PaymentFilter) (domain.PaymentFilter, error) {
+	var result domain.PaymentFilter
+	if filter == nil {
+		return result, nil
+	}
+	result.Currency = derefString(filter.Currency)
+	result.PayerID = derefString(filter.PayerID)
+	result.TenantID = derefString(filter.TenantID)
+	result.CustomerID = derefString(filter.CustomerID)
+	metadata, err := metadataFromModel(filter.Metadata)
+	if err != nil {
+		return result, err
+	}
+	result.Metadata, result.Tags = metadata, filter.Tags
+	for _, status := range filter.Statuses {
+		result.Statuses = append(result.Statuses, domain.PaymentStatus(status))
+	}
+	if filter.CreatedFrom != nil {
+		createdFrom, err := parseTimestamp("createdFrom", *filter.CreatedFrom)
+		if err != nil {
+			return result, err
+		}
+		result.CreatedFrom = &createdFrom
+	}
+	if filter.CreatedTo != nil {
+		createdTo, err := parseTimestamp("createdTo", *filter.CreatedTo)
+		if err != nil {
+			return result, err
+		}
+		result.CreatedTo = &createdTo
+	}
+	return result, nil
+}
+
+// statsGroupToModel converts a stats group to its GraphQL model
+func statsGroupToModel(group *domain.PaymentStatsGroup) *model.PaymentStatsGroup {
+	result := &model.PaymentStatsGroup{
+		Currency: optionalString(group.Currency),
+		Day:      optionalString(group.Day),
+		Month:    optionalString(group.Month),
+		Count:    int(group.Count),
+		Amounts:  make([]*model.AmountStats, len(group.Amounts)),
+	}
+	if group.Status != "" {
+		status := model.PaymentStatus(group.Status)
+		result.Status = &status
+	}
+	for i, amounts := range group.Amounts {
+		result.Amounts[i] = &model.AmountStats{
+			Currency: amounts.Currency,
+			Count:    int(amounts.Count),
+			Sum:      moneyToModel(amounts.Sum),
+			Average:  moneyToModel(amounts.Average),
+			Min:      moneyToModel(amounts.Min),
+			Max:      moneyToModel(amounts.Max),
+			P50:      moneyToModel(amounts.P50),
+			P90:      moneyToModel(amounts.P90),
+			P95:      moneyToModel(amounts.P95),
+			P99:      moneyToModel(amounts.P99),
+		}
+	}
+	return result
+}
+
+// moneyToModel converts an exact amount to its GraphQL model, formatted as a decimal string
+func moneyToModel(money domain.Money) *model.Money {
+	return &model.Money{Amount: money.Decimal(), Currency: money.Currency}
+}
+
+// bulkResultToModel converts an import report to its GraphQL model
+func bulkResultToModel(result *usecases.BulkResult) *model.BulkImportReport {
+	rows := make([]*model.BulkRowResult, len(result.Rows))
+	for i, row := range result.Rows {
+		rows[i] = &model.BulkRowResult{
+			Line:      row.Line,
+			Status:    model.BulkRowStatus(row.Status),
+			PaymentID: optionalString(row.PaymentID),
+			ErrorCode: optionalString(string(row.ErrorCode)),
+			Error:     optionalString(row.Error),
+		}
+		if row.PaymentStatus != "" {
+			status := model.PaymentStatus(row.PaymentStatus)
+			rows[i].PaymentStatus = &status
+		}
+	}
+	return &model.BulkImportReport{
+		Mode:    model.BulkMode(result.Mode),
+		Total:   result.Total,
+		Created: result.Created,
+		Failed:  result.Failed,
+		Skipped: result.Skipped,
+		Rows:    rows,
+	}
+}
+
+// subscriptionToModel converts a domain Subscription to its GraphQL model
+func subscriptionToModel(subscription *domain.Subscription) *model.Subscription {
+	result := &model.Subscription{
+		ID:          subscription.ID,
+		Amount:      subscription.Amount,
+		Currency:    subscription.Currency,
+		Description: subscription.Description,
+		PayerID:     optionalString(subscription.PayerID),
+		TenantID:    optionalString(subscription.TenantID),
+		Method:      methodToModel(subscription.Method),
+		Schedule: &model.PaymentSchedule{
+			Frequency: model.Frequency(subscription.Schedule.Frequency),
+			Interval:  subscription.Schedule.Interval,
+			StartAt:   subscription.Schedule.StartAt.Format(time.RFC3339),
+		},
+		Status:        model.SubscriptionStatus(subscription.Status),
+		Cycle:         subscription.Cycle,
+		NextRunAt:     subscription.NextRunAt.Format(time.RFC3339),
+		RetryAttempt:  subscription.RetryAttempt,
+		LastPaymentID: optionalString(subscription.LastPaymentID),
+		LastError:     optionalString(subscription.LastError),
+		CreatedAt:     subscription.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:     subscription.UpdatedAt.Format(time.RFC3339),
+	}
+	if subscription.Schedule.AnchorDay != 0 {
+		anchorDay := subscription.Schedule.AnchorDay
+		result.Schedule.AnchorDay = &anchorDay
+	}
+	if subscription.CancelledAt != nil {
+		cancelledAt := subscription.CancelledAt.Format(time.RFC3339)
+		result.CancelledAt = &cancelledAt
+	}
+	return result
+}
+
+// statementToModel converts a domain Statement to its GraphQL model
+func statementToModel(statement *domain.Statement) *model.BankStatement {
+	result := &model.BankStatement{
+		ID:         statement.ID,
+		Format:     model.StatementFormat(statement.Format),
+		Reference:  statement.Reference,
+		Account:    optionalString(statement.Account),
+		Currency:   statement.Currency,
+		Sha256:     statement.SHA256,
+		Lines:      make([]*model.StatementLine, len(statement.Lines)),
+		ImportedAt: statement.ImportedAt.Format(time.RFC3339),
+	}
+	if statement.OpeningBalance != nil {
+		result.OpeningBalance = moneyToModel(*statement.OpeningBalance)
+	}
+	if statement.ClosingBalance != nil {
+		result.ClosingBalance = moneyToModel(*statement.ClosingBalance)
+	}
+	for i, line := range statement.Lines {
+		result.Lines[i] = statementLineToModel(line)
+	}
+	return result
+}
+
+// statementLineToModel converts a domain StatementLine to its GraphQL model; dates have no time of day
+func statementLineToModel(line *domain.StatementLine) *model.StatementLine {
+	result := &model.StatementLine{
+		ID:                  line.ID,
+		StatementID:         line.StatementID,
+		Index:               line.Index,
+		BookingDate:         line.BookingDate.Format("2006-01-02"),
+		ValueDate:           line.ValueDate.Format("2006-01-02"),
+		Amount:              moneyToModel(line.Amount),
+		Direction:           model.EntryDirection(line.Direction),
+		Reference:           optionalString(line.Reference),
+		BankReference:       optionalString(line.BankReference),
+		Counterparty:        optionalString(line.Counterparty),
+		CounterpartyAccount: optionalString(line.CounterpartyAccount),
+		Description:         optionalString(line.Description),
+		Status:              model.StatementLineStatus(line.Status),
+		PaymentID:           optionalString(line.PaymentID),
+		Confidence:          line.Confidence,
+		MatchedBy:           optionalString(line.MatchedBy),
+	}
+	if line.MatchMethod != "" {
+		method := model.MatchMethod(line.MatchMethod)
+		result.MatchMethod = &method
+	}
+	if line.MatchedAt != nil {
+		matchedAt := line.MatchedAt.Format(time.RFC3339)
+		result.MatchedAt = &matchedAt
+	}
+	return result
+}
+
+func reconciliationRunToModel(run *usecases.ReconciliationRun) *model.ReconciliationRun {
+	return &model.ReconciliationRun{Matched: run.Matched, Proposed: run.Proposed, Unmatched: run.Unmatched}
+}
+
+// disputeToModel converts a domain Dispute to its GraphQL model; stored file paths are not exposed
+func disputeToModel(dispute *domain.Dispute) *model.Dispute {
+	result := &model.Dispute{
+		ID:             dispute.ID,
+		PaymentID:      dispute.PaymentID,
+		ReasonCode:     dispute.ReasonCode,
+		Amount:         dispute.Amount,
+		Currency:       dispute.Currency,
+		Status:         model.DisputeStatus(dispute.Status),
+		EvidenceDueAt:  dispute.EvidenceDueAt.Format(time.RFC3339),
+		Evidence:       make([]*model.DisputeEvidence, len(dispute.Evidence)),
+		ResolutionNote: optionalString(dispute.ResolutionNote),
+		CreatedAt:      dispute.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:      dispute.UpdatedAt.Format(time.RFC3339),
+	}
+	for i, evidence := range dispute.Evidence {
+		result.Evidence[i] = &model.DisputeEvidence{
+			ID:          evidence.ID,
+			Text:        optionalString(evidence.Text),
+			Files:       make([]*model.EvidenceFile, len(evidence.Files)),
+			SubmittedBy: evidence.SubmittedBy,
+			SubmittedAt: evidence.SubmittedAt.Format(time.RFC3339),
+		}
+		for j, file := range evidence.Files {
+			result.Evidence[i].Files[j] = &model.EvidenceFile{
+				Name:        file.Name,
+				ContentType: file.ContentType,
+				Size:        int(file.Size),
+				Sha256:      file.SHA256,
+			}
+		}
+	}
+	if dispute.ResolvedAt != nil {
+		resolvedAt := dispute.ResolvedAt.Format(time.RFC3339)
+		result.ResolvedAt = &resolvedAt
+	}
+	return result
+}
+
+// disputesToModel converts a list of domain Disputes to GraphQL models
+func disputesToModel(disputes []*domain.Dispute) []*model.Dispute {
+	result := make([]*model.Dispute, len(disputes))
+	for i, dispute := range disputes {
+		result[i] = disputeToModel(dispute)
+	}
+	return result
+}
+
+// settlementBatchToModel converts a domain SettlementBatch to its GraphQL model
+func settlementBatchToModel(batch *domain.SettlementBatch) *model.SettlementBatch {
+	result := &model.SettlementBatch{
+		ID:        batch.ID,
+		CutoffAt:  batch.CutoffAt.UTC().Format(time.RFC3339),
+		CreatedAt: batch.CreatedAt.UTC().Format(time.RFC3339),
+		Payouts:   make([]*model.Payout, len(batch.Payouts)),
+	}
+	for i, payout := range batch.Payouts {
+		result.Payouts[i] = payoutToModel(payout)
+	}
+	return result
+}
+
+// payoutToModel converts a domain Payout and its items to GraphQL models
+func payoutToModel(payout *domain.Payout) *model.Payout {
+	result := &model.Payout{
+		ID:            payout.ID,
+		BatchID:       payout.BatchID,
+		MerchantID:    payout.MerchantID,
+		Currency:      payout.Currency,
+		Gross:         moneyToModel(payout.Gross),
+		Refunds:       moneyToModel(payout.Refunds),
+		Chargebacks:   moneyToModel(payout.Chargebacks),
+		Fees:          moneyToModel(payout.Fees),
+		Net:           moneyToModel(payout.Net),
+		Status:        model.PayoutStatus(payout.Status),
+		Reference:     optionalString(payout.Reference),
+		FailureReason: optionalString(payout.FailureReason),
+		Items:         make([]*model.PayoutItem, len(payout.Items)),
+		CreatedAt:     payout.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:     payout.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	for i, item := range payout.Items {
+		result.Items[i] = &model.PayoutItem{
+			PaymentID:   item.PaymentID,
+			Gross:       moneyToModel(item.Gross),
+			Refunds:     moneyToModel(item.Refunds),
+			Chargebacks: moneyToModel(item.Chargebacks),
+			Fees:        moneyToModel(item.Fees),
+			Net:         moneyToModel(item.Net),
+		}
+	}
+	if payout.SentAt != nil {
+		sentAt := payout.SentAt.UTC().Format(time.RFC3339)
+		result.SentAt = &sentAt
+	}
+	if payout.PaidAt != nil {
+		paidAt := payout.PaidAt.UTC().Format(time.RFC3339)
+		result.PaidAt = &paidAt
+	}
+	return result
+}
+
+// invoiceToModel converts a domain Invoice to its GraphQL model, deriving its status at now
+func invoiceToModel(invoice *domain.Invoice, now time.Time) *model.Invoice {
+	result := &model.Invoice{
+		ID:          invoice.ID,
+		Number:      invoice.Number,
+		CustomerID:  optionalString(invoice.CustomerID),
+		Currency:    invoice.Currency,
+		Lines:       make([]*model.InvoiceLine, len(invoice.Lines)),
+		Subtotal:    moneyToModel(invoice.Subtotal),
+		Tax:         moneyToModel(invoice.Tax),
+		Total:       moneyToModel(invoice.Total),
+		AmountPaid:  moneyToModel(invoice.AmountPaid),
+		AmountDue:   moneyToModel(invoice.AmountDue()),
+		Status:      model.InvoiceStatus(invoice.Status(now)),
+		DueDate:     invoice.DueDate.Format("2006-01-02"),
+		DaysPastDue: invoice.DaysPastDue(now),
+		Allocations: make([]*model.InvoiceAllocation, len(invoice.Allocations)),
+		CreatedAt:   invoice.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:   invoice.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	for i, line := range invoice.Lines {
+		result.Lines[i] = &model.InvoiceLine{
+			Description: line.Description,
+			Category:    optionalString(line.Category),
+			Quantity:    line.Quantity,
+			UnitPrice:   moneyToModel(line.UnitPrice),
+			Amount:      moneyToModel(line.Amount),
+			Tax:         moneyToModel(line.Tax),
+		}
+	}
+	for i, allocation := range invoice.Allocations {
+		result.Allocations[i] = invoiceAllocationToModel(allocation)
+	}
+	if invoice.TaxBreakdown != nil {
+		result.TaxBreakdown = taxBreakdownToModel(invoice.TaxBreakdown)
+	}
+	return result
+}
+
+// invoiceAllocationToModel converts a domain InvoiceAllocation to its GraphQL model
+func invoiceAllocationToModel(allocation *domain.InvoiceAllocation) *model.InvoiceAllocation {
+	return &model.InvoiceAllocation{
+		ID:        allocation.ID,
+		InvoiceID: allocation.InvoiceID,
+		PaymentID: allocation.PaymentID,
+		Amount:    moneyToModel(allocation.Amount),
+		CreatedAt: allocation.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// customerToModel converts a domain Customer to its GraphQL model
+func customerToModel(customer *domain.Customer) *model.Customer {
+	result := &model.Customer{
+		ID:                customer.ID,
+		Name:              optionalString(customer.Name),
+		Email:             optionalString(customer.Email),
+		ExternalReference: optionalString(customer.ExternalReference),
+		Erased:            customer.ErasedAt != nil,
+		CreatedAt:         customer.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:         customer.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	result.Metadata = metadataToModel(customer.Metadata)
+	if customer.ErasedAt != nil {
+		erasedAt := customer.ErasedAt.UTC().Format(time.RFC3339)
+		result.ErasedAt = &erasedAt
+	}
+	return result
+}
+
+// customerInputFromModel converts the GraphQL customer input
+func customerInputFromModel(input model.CustomerInput) (usecases.CustomerInput, error) {
+	metadata, err := metadataFromModel(input.Metadata)
+	if err != nil {
+		return usecases.CustomerInput{}, err
+	}
+	return usecases.CustomerInput{
+		Name:              input.Name,
+		Email:             input.Email,
+		ExternalReference: input.ExternalReference,
+		Metadata:          metadata,
+	}, nil
+}
+
+// metadataToModel converts metadata to a JSON object, or nil when there is none
+func metadataToModel(metadata map[string]string) map[string]any {
+	if len(metadata) == 0 {
+		return nil
+	}
+	result := make(map[string]any, len(metadata))
+	for key, value := range metadata {
+		result[key] = value
+	}
+	return result
+}
+
+// metadataFromModel converts a JSON metadata object; values must be strings
+func metadataFromModel(metadata map[string]any) (map[string]string, error) {
+	if metadata == nil {
+		return nil, nil
+	}
+	result := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		text, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("metadata value of %q must be a string", key)
+		}
+		result[key] = text
+	}
+	return result, nil
+}
+
+// encodeCursor encodes a position in a payment list as an opaque cursor. The time keeps its
+// zone offset so it compares equal to the stored value.
+func encodeCursor(cursor domain.PaymentCursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursor.CreatedAt.Format(time.RFC3339Nano) + "|" + cursor.ID))
+}
+
+// decodeCursor decodes a cursor returned by encodeCursor
+func decodeCursor(value string) (domain.PaymentCursor, error) {
+	invalid := fmt.Errorf("invalid cursor %q", value)
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return domain.PaymentCursor{}, invalid
+	}
+	timestamp, id, found := strings.Cut(string(decoded), "|")
+	createdAt, err := time.Parse(time.RFC3339Nano, timestamp)
+	if !found || err != nil || id == "" {
+		return domain.PaymentCursor{}, invalid
+	}
+	return domain.PaymentCursor{CreatedAt: createdAt, ID: id}, nil
+}
+
+// encodeSearchCursor encodes the number of search hits read so far as an opaque cursor
+func encodeSearchCursor(position int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("search|" + strconv.Itoa(position)))
+}
+
+// decodeSearchCursor decodes a cursor returned by encodeSearchCursor
+func decodeSearchCursor(value string) (int, error) {
+	invalid := fmt.Errorf("invalid cursor %q", value)
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return 0, invalid
+	}
+	text, found := strings.CutPrefix(string(decoded), "search|")
+	position, err := strconv.Atoi(text)
+	if !found || err != nil || position < 0 {
+		return 0, invalid
+	}
+	return position, nil
+}
+
+// parseTimestamp parses an RFC 3339 timestamp argument, naming the field on error
+func parseTimestamp(field, value string) (time.Time, error) {
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be an RFC 3339 timestamp: %w", field, err)
+	}
+	return parsed, nil
+}
+
+// parseDate parses a YYYY-MM-DD date argument as midnight UTC, naming the field on error
+func parseDate(field, value string) (time.Time, error) {
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be a date like 2006-01-02: %w", field, err)
+	}
+	return parsed, nil
+}
+
+// optionalString returns nil for empty strings so optional GraphQL fields resolve to null
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
 	}
+	return &value
 }
@@ -0,0 +1,153 @@
+package graphql
+
+import (
+	"context"
+	"net/http"
+	"payments_app/internal/domain"
+	"payments_app/internal/usecases"
+	"sync"
+	"time"
+)
+
+// Batching settings: keys requested within loaderWait of the first one are fetched together,
+// up to loaderMaxBatch keys per fetch
+const (
+	loaderWait     = 2 * time.Millisecond
+	loaderMaxBatch = 100
+)
+
+// loader batches and caches lookups by key for the duration of one request. Resolvers of a
+// list run concurrently, so a field resolved for every element costs one fetch instead of one
+// query per element.
+type loader[K comparable, V any] struct {
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu    sync.Mutex
+	batch *loaderBatch[K, V]
+	cache map[K]*loaderBatch[K, V]
+}
+
+// loaderBatch is one fetch; done is closed when results and err are set
+type loaderBatch[K comparable, V any] struct {
+	keys    []K
+	done    chan struct{}
+	results map[K]V
+	err     error
+}
+
+func newLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{fetch: fetch, cache: make(map[K]*loaderBatch[K, V])}
+}
+
+// Load returns the value for key, or the zero value if the fetch did not return one
+func (l *loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	batch, cached := l.cache[key]
+	if !cached {
+		if l.batch == nil {
+			l.batch = &loaderBatch[K, V]{done: make(chan struct{})}
+			go l.run(ctx, l.batch)
+		}
+		batch = l.batch
+		batch.keys = append(batch.keys, key)
+		l.cache[key] = batch
+		if len(batch.keys) >= loaderMaxBatch {
+			l.batch = nil
+		}
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-batch.done:
+		return batch.results[key], batch.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// run waits for more keys to join the batch and fetches them
+func (l *loader[K, V]) run(ctx context.Context, batch *loaderBatch[K, V]) {
+	time.Sleep(loaderWait)
+
+	l.mu.Lock()
+	if l.batch == batch {
+		l.batch = nil
+	}
+	keys := batch.keys
+	l.mu.Unlock()
+
+	batch.results, batch.err = l.fetch(ctx, keys)
+	close(batch.done)
+}
+
+// customerPaymentsKey selects one page of a customer's payments; after is an encoded cursor
+type customerPaymentsKey struct {
+	customerID string
+	first      int
+	after      string
+}
+
+// Loaders holds the dataloaders of one request
+type Loaders struct {
+	customers        *loader[string, *domain.Customer]
+	customerPayments *loader[customerPaymentsKey, *domain.PaymentList]
+}
+
+// NewLoaders creates the dataloaders for one request
+func NewLoaders(paymentUseCase *usecases.PaymentUseCase) *Loaders {
+	return &Loaders{
+		customers: newLoader(paymentUseCase.CustomersByIDs),
+		customerPayments: newLoader(func(ctx context.Context, keys []customerPaymentsKey) (map[customerPaymentsKey]*domain.PaymentList, error) {
+			// Customers asking for the same page are read together
+			type pageKey struct {
+				first int
+				after string
+			}
+			groups := make(map[pageKey][]string)
+			for _, key := range keys {
+				page := pageKey{key.first, key.after}
+				groups[page] = append(groups[page], key.customerID)
+			}
+
+			results := make(map[customerPaymentsKey]*domain.PaymentList, len(keys))
+			for page, customerIDs := range groups {
+				request := domain.PaymentPage{First: page.first}
+				if page.after != "" {
+					after, err := decodeCursor(page.after)
+					if err != nil {
+						return nil, err
+					}
+					request.After = &after
+				}
+				lists, err := paymentUseCase.CustomerPayments(ctx, customerIDs, request)
+				if err != nil {
+					return nil, err
+				}
+				for _, id := range customerIDs {
+					results[customerPaymentsKey{id, page.first, page.after}] = lists[id]
+				}
+			}
+			return results, nil
+		}),
+	}
+}
+
+type loadersKey struct{}
+
+// Middleware gives every request its own dataloaders, so cached values never outlive a request
+func Middleware(paymentUseCase *usecases.PaymentUseCase, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), loadersKey{}, NewLoaders(paymentUseCase))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// loadersFor returns the request's dataloaders, or new ones when the handler is not wrapped
+// in Middleware; lookups are then not shared between fields
+func (r *Resolver) loadersFor(ctx context.Context) *Loaders {
+	if loaders, ok := ctx.Value(loadersKey{}).(*Loaders); ok {
+		return loaders
+	}
+	return NewLoaders(r.paymentUseCase)
+}
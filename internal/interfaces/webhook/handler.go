@@ -0,0 +1,96 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"payments_app/internal/domain"
+	"payments_app/internal/usecases"
+	"payments_app/pkg/logger"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// MaxBodyBytes caps the size of a callback body
+const MaxBodyBytes = 1 << 20
+
+// Verifier authenticates and decodes the callbacks of one processor connector
+type Verifier interface {
+	VerifyWebhook(header http.Header, body []byte) (*domain.ProcessorEvent, error)
+	// AcceptsWebhooks reports whether the connector has a secret to verify callbacks with
+	AcceptsWebhooks() bool
+}
+
+// Handler serves /webhooks/processor/{name}
+type Handler struct {
+	useCase   *usecases.PaymentUseCase
+	verifiers map[string]Verifier
+	log       *logger.Logger
+}
+
+// NewHandler creates a webhook handler for the given processors' verifiers, keyed by processor name
+func NewHandler(useCase *usecases.PaymentUseCase, verifiers map[string]Verifier, log *logger.Logger) *Handler {
+	return &Handler{useCase: useCase, verifiers: verifiers, log: log}
+}
+
+// response is the JSON body returned to processors
+type response struct {
+	Status     string `json:"status"`
+	CallbackID string `json:"callbackId,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ServeHTTP verifies, stores and applies a processor callback. Every stored callback is
+// acknowledged with 200, including ones that could not be applied: they are kept for replay,
+// and redelivery would only be dropped as a duplicate.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	verifier, exists := h.verifiers[name]
+	if !exists {
+		writeJSON(w, http.StatusNotFound, response{Status: "error", Error: "unknown processor"})
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodyBytes))
+	if err != nil {
+		writeJSON(w, http.StatusRequestEntityTooLarge, response{Status: "error", Error: "body too large"})
+		return
+	}
+
+	event, err := verifier.VerifyWebhook(r.Header, body)
+	if err != nil {
+		h.log.Warnf("rejected callback from %s: %v", name, err)
+		writeJSON(w, http.StatusUnauthorized, response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	callback, err := h.useCase.HandleProcessorCallback(r.Context(), name, *event, body)
+	switch {
+	case errors.Is(err, domain.ErrDuplicateCallback):
+		writeJSON(w, http.StatusOK, response{Status: "duplicate"})
+		return
+	case err != nil && callback == nil:
+		h.log.Errorf("failed to store callback %s from %s: %v", event.CallbackID, name, err)
+		writeJSON(w, http.StatusInternalServerError, response{Status: "error", Error: "callback could not be stored"})
+		return
+	case err != nil:
+		h.log.Errorf("failed to record result of callback %s from %s: %v", event.CallbackID, name, err)
+	}
+
+	if callback.Result == domain.CallbackResultFailed {
+		h.log.Warnf("callback %s from %s could not be applied: %s", event.CallbackID, name, callback.Error)
+	}
+	writeJSON(w, http.StatusOK, response{
+		Status:     strings.ToLower(string(callback.Result)),
+		CallbackID: callback.ID,
+		Error:      callback.Error,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
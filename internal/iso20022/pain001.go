@@ -0,0 +1,296 @@
+// Package iso20022 writes ISO 20022 payment initiation messages for the bank.
+package iso20022
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math/big"
+	"payments_app/internal/domain"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Pain001Namespace is the XML namespace of the customer credit transfer initiation version written
+const Pain001Namespace = "urn:iso:std:iso:20022:tech:xsd:pain.001.001.09"
+
+// Account is a party with the bank account credit transfers are paid from
+type Account struct {
+	Name string
+	IBAN string
+	// BIC is optional; SEPA banks find the agent from the IBAN
+	BIC string
+}
+
+// Pain001Writer builds pain.001.001.09 documents for SEPA credit transfers
+type Pain001Writer struct {
+	debtor Account
+}
+
+// NewPain001Writer creates a writer paying every transfer from the given debtor account. A
+// payer's account is never debited: it is not an account the bank lets us draw on.
+func NewPain001Writer(debtor Account) (*Pain001Writer, error) {
+	debtor.IBAN = strings.ToUpper(strings.ReplaceAll(debtor.IBAN, " ", ""))
+	if strings.TrimSpace(debtor.Name) == "" {
+		return nil, errors.New("debtor name is required")
+	}
+	if !domain.ValidIBAN(debtor.IBAN) {
+		return nil, fmt.Errorf("debtor IBAN %q is invalid", debtor.IBAN)
+	}
+	return &Pain001Writer{debtor: debtor}, nil
+}
+
+// Transferable reports whether a payment can be sent as a SEPA credit transfer: it needs to be in
+// euros with a SEPA bank account method, which is the creditor's account, a creditor name from the
+// payee or the account holder, and no risk assessment short of an approval
+func (w *Pain001Writer) Transferable(payment *domain.Payment) bool {
+	if payment.Currency != "EUR" || (payment.Risk != nil && !payment.Risk.Approved()) {
+		return false
+	}
+	account, ok := payment.Method.(domain.BankAccountMethod)
+	return ok && account.Scheme == domain.BankSchemeSEPA && account.IBAN != "" && creditorName(payment, account) != ""
+}
+
+// Encode writes the document for the payments and fills in the file's totals. Payments are
+// grouped into one payment information block per requested execution date, which is the
+// payment's execution date or, if that has passed, the file's creation date.
+func (w *Pain001Writer) Encode(file *domain.CreditTransferFile, payments []*domain.Payment) ([]byte, error) {
+	if len(payments) == 0 {
+		return nil, errors.New("a credit transfer file needs at least one payment")
+	}
+
+	groups := make(map[string]*paymentInstruction)
+	var dates []string
+	total := new(big.Rat)
+	for _, payment := range payments {
+		if !w.Transferable(payment) {
+			return nil, fmt.Errorf("payment %s is not a SEPA credit transfer", payment.ID)
+		}
+		date := executionDate(payment, file.CreatedAt)
+		group, ok := groups[date]
+		if !ok {
+			group = newPaymentInstruction(w.debtor, date)
+			groups[date] = group
+			dates = append(dates, date)
+		}
+		transaction, amount, err := newTransaction(payment)
+		if err != nil {
+			return nil, err
+		}
+		group.Transactions = append(group.Transactions, transaction)
+		group.sum.Add(group.sum, amount)
+		total.Add(total, amount)
+	}
+
+	sort.Strings(dates)
+	document := pain001Document{
+		Namespace: Pain001Namespace,
+		Initiation: customerCreditTransferInitiation{
+			GroupHeader: groupHeader{
+				MessageID:            file.MessageID,
+				CreationDateTime:     file.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+				NumberOfTransactions: fmt.Sprint(len(payments)),
+				ControlSum:           decimal(total),
+				InitiatingParty:      party{Name: truncate(w.debtor.Name, 140)},
+			},
+		},
+	}
+	for i, date := range dates {
+		group := groups[date]
+		group.ID = fmt.Sprintf("%s-%d", file.MessageID, i+1)
+		group.NumberOfTransactions = fmt.Sprint(len(group.Transactions))
+		group.ControlSum = decimal(group.sum)
+		document.Initiation.PaymentInstructions = append(document.Initiation.PaymentInstructions, *group)
+	}
+
+	content, err := xml.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	file.NumberOfTransactions = len(payments)
+	file.ControlSum = decimal(total)
+	return append([]byte(xml.Header), append(content, '\n')...), nil
+}
+
+func executionDate(payment *domain.Payment, created time.Time) string {
+	date := created.UTC().Format("2006-01-02")
+	if payment.ExecuteAt != nil {
+		if requested := payment.ExecuteAt.UTC().Format("2006-01-02"); requested > date {
+			return requested
+		}
+	}
+	return date
+}
+
+func creditorName(payment *domain.Payment, account domain.BankAccountMethod) string {
+	if payment.Payee != nil && strings.TrimSpace(payment.Payee.Name) != "" {
+		return strings.TrimSpace(payment.Payee.Name)
+	}
+	return strings.TrimSpace(account.HolderName)
+}
+
+func newPaymentInstruction(debtor Account, date string) *paymentInstruction {
+	return &paymentInstruction{
+		PaymentMethod: "TRF",
+		PaymentType:   &paymentTypeInformation{ServiceLevel: &serviceLevel{Code: "SEPA"}},
+		ExecutionDate: dateChoice{Date: date},
+		Debtor:        party{Name: truncate(debtor.Name, 140)},
+		DebtorAccount: cashAccount{IBAN: debtor.IBAN},
+		DebtorAgent:   agentFor(debtor.BIC),
+		ChargeBearer:  "SLEV",
+		sum:           new(big.Rat),
+	}
+}
+
+// newTransaction converts a payment to a credit transfer transaction and returns its amount
+func newTransaction(payment *domain.Payment) (creditTransferTransaction, *big.Rat, error) {
+	account := payment.Method.(domain.BankAccountMethod)
+	money := domain.MoneyFromFloat(payment.Amount, payment.Currency)
+	if money.MinorUnits <= 0 {
+		return creditTransferTransaction{}, nil, fmt.Errorf("payment %s has no positive amount", payment.ID)
+	}
+	amount, ok := new(big.Rat).SetString(money.Decimal())
+	if !ok {
+		return creditTransferTransaction{}, nil, fmt.Errorf("payment %s has an invalid amount", payment.ID)
+	}
+
+	transaction := creditTransferTransaction{
+		// UUIDs without hyphens fit the 35 characters of an end-to-end ID and come back on
+		// bank statements, where reconciliation matches them
+		PaymentID: paymentIdentification{
+			EndToEndID: strings.ReplaceAll(payment.ID, "-", ""),
+		},
+		Amount:          amountChoice{Instructed: currencyAmount{Currency: payment.Currency, Value: money.Decimal()}},
+		Creditor:        party{Name: truncate(creditorName(payment, account), 140)},
+		CreditorAccount: cashAccount{IBAN: account.IBAN},
+	}
+	if account.BIC != "" {
+		agent := agentFor(account.BIC)
+		transaction.CreditorAgent = &agent
+	}
+	if description := strings.TrimSpace(payment.Description); description != "" {
+		transaction.Remittance = &remittanceInformation{Unstructured: truncate(description, 140)}
+	}
+	return transaction, amount, nil
+}
+
+// agentFor identifies a bank by BIC, or as NOTPROVIDED, which SEPA banks accept
+func agentFor(bic string) agent {
+	if bic == "" {
+		return agent{Other: &genericIdentification{ID: "NOTPROVIDED"}}
+	}
+	return agent{BIC: strings.ToUpper(bic)}
+}
+
+// decimal formats an amount with two decimal places, or more if it has them
+func decimal(amount *big.Rat) string {
+	text := amount.FloatString(5)
+	trimmed := strings.TrimRight(text, "0")
+	if dot := strings.IndexByte(text, '.'); len(trimmed)-dot-1 < 2 {
+		return text[:dot+3]
+	}
+	return trimmed
+}
+
+// truncate shortens text to at most n characters
+func truncate(text string, n int) string {
+	if utf8.RuneCountInString(text) <= n {
+		return text
+	}
+	return string([]rune(text)[:n])
+}
+
+// The types below follow the element order of the pain.001.001.09 schema; optional elements
+// that are never written are left out
+
+type pain001Document struct {
+	XMLName    xml.Name                         `xml:"Document"`
+	Namespace  string                           `xml:"xmlns,attr"`
+	Initiation customerCreditTransferInitiation `xml:"CstmrCdtTrfInitn"`
+}
+
+type customerCreditTransferInitiation struct {
+	GroupHeader         groupHeader          `xml:"GrpHdr"`
+	PaymentInstructions []paymentInstruction `xml:"PmtInf"`
+}
+
+type groupHeader struct {
+	MessageID            string `xml:"MsgId"`
+	CreationDateTime     string `xml:"CreDtTm"`
+	NumberOfTransactions string `xml:"NbOfTxs"`
+	ControlSum           string `xml:"CtrlSum"`
+	InitiatingParty      party  `xml:"InitgPty"`
+}
+
+type paymentInstruction struct {
+	ID                   string                      `xml:"PmtInfId"`
+	PaymentMethod        string                      `xml:"PmtMtd"`
+	NumberOfTransactions string                      `xml:"NbOfTxs"`
+	ControlSum           string                      `xml:"CtrlSum"`
+	PaymentType          *paymentTypeInformation     `xml:"PmtTpInf,omitempty"`
+	ExecutionDate        dateChoice                  `xml:"ReqdExctnDt"`
+	Debtor               party                       `xml:"Dbtr"`
+	DebtorAccount        cashAccount                 `xml:"DbtrAcct"`
+	DebtorAgent          agent                       `xml:"DbtrAgt"`
+	ChargeBearer         string                      `xml:"ChrgBr"`
+	Transactions         []creditTransferTransaction `xml:"CdtTrfTxInf"`
+
+	sum *big.Rat
+}
+
+type paymentTypeInformation struct {
+	ServiceLevel *serviceLevel `xml:"SvcLvl,omitempty"`
+}
+
+type serviceLevel struct {
+	Code string `xml:"Cd"`
+}
+
+type dateChoice struct {
+	Date string `xml:"Dt"`
+}
+
+type party struct {
+	Name string `xml:"Nm,omitempty"`
+}
+
+type cashAccount struct {
+	IBAN string `xml:"Id>IBAN"`
+}
+
+type agent struct {
+	BIC   string                 `xml:"FinInstnId>BICFI,omitempty"`
+	Other *genericIdentification `xml:"FinInstnId>Othr,omitempty"`
+}
+
+type genericIdentification struct {
+	ID string `xml:"Id"`
+}
+
+type creditTransferTransaction struct {
+	PaymentID       paymentIdentification  `xml:"PmtId"`
+	Amount          amountChoice           `xml:"Amt"`
+	CreditorAgent   *agent                 `xml:"CdtrAgt,omitempty"`
+	Creditor        party                  `xml:"Cdtr"`
+	CreditorAccount cashAccount            `xml:"CdtrAcct"`
+	Remittance      *remittanceInformation `xml:"RmtInf,omitempty"`
+}
+
+type paymentIdentification struct {
+	EndToEndID string `xml:"EndToEndId"`
+}
+
+type amountChoice struct {
+	Instructed currencyAmount `xml:"InstdAmt"`
+}
+
+type currencyAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type remittanceInformation struct {
+	Unstructured string `xml:"Ustrd"`
+}
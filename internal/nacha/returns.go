@@ -0,0 +1,139 @@
+package nacha
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"payments_app/internal/domain"
+	"strconv"
+	"strings"
+)
+
+// returnReasons describes the return reason codes banks send for credit entries
+var returnReasons = map[string]string{
+	"R01": "Insufficient funds",
+	"R02": "Account closed",
+	"R03": "No account or unable to locate account",
+	"R04": "Invalid account number structure",
+	"R05": "Unauthorized debit to consumer account using corporate SEC code",
+	"R06": "Returned per ODFI's request",
+	"R07": "Authorization revoked by customer",
+	"R08": "Payment stopped",
+	"R09": "Uncollected funds",
+	"R10": "Customer advises originator is not known or not authorized",
+	"R11": "Customer advises entry not in accordance with the terms of the authorization",
+	"R12": "Account sold to another DFI",
+	"R13": "Invalid ACH routing number",
+	"R14": "Representative payee deceased or unable to continue in that capacity",
+	"R15": "Beneficiary or account holder deceased",
+	"R16": "Account frozen or entry returned per OFAC instruction",
+	"R17": "File record edit criteria",
+	"R20": "Non-transaction account",
+	"R21": "Invalid company identification",
+	"R22": "Invalid individual ID number",
+	"R23": "Credit entry refused by receiver",
+	"R24": "Duplicate entry",
+	"R29": "Corporate customer advises not authorized",
+	"R31": "Permissible return entry",
+	"R61": "Misrouted return",
+	"R67": "Duplicate return",
+	"R68": "Untimely return",
+	"R69": "Field error",
+	"R70": "Permissible return entry not accepted or return not requested by ODFI",
+}
+
+// ReturnReason describes a return reason code such as R01
+func ReturnReason(code string) string {
+	if reason, ok := returnReasons[code]; ok {
+		return reason
+	}
+	return "Unknown return reason"
+}
+
+// ParseReturns reads the returned entries of an ACH return file. Records may be separated by
+// line breaks or not at all. Notifications of change are skipped, since they do not return
+// the entry's funds.
+func ParseReturns(r io.Reader) ([]domain.ACHReturn, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	content = bytes.ReplaceAll(bytes.ReplaceAll(content, []byte("\r"), nil), []byte("\n"), nil)
+	if len(content) == 0 {
+		return nil, errors.New("ACH file is empty")
+	}
+	if len(content)%RecordLength != 0 {
+		return nil, fmt.Errorf("ACH records must be %d characters long", RecordLength)
+	}
+
+	var returns []domain.ACHReturn
+	var entry string
+	for i := 0; i < len(content)/RecordLength; i++ {
+		line := string(content[i*RecordLength : (i+1)*RecordLength])
+		number := i + 1
+		if i == 0 && line[0] != '1' {
+			return nil, errors.New("ACH file must start with a file header record")
+		}
+
+		switch line[0] {
+		case '6':
+			if entry != "" {
+				return nil, fmt.Errorf("record %d: entry %s has no return addenda", number-1, entry[79:])
+			}
+			entry = line
+		case '7':
+			if entry == "" {
+				return nil, fmt.Errorf("record %d: addenda without an entry", number)
+			}
+			switch line[1:3] {
+			case "99":
+				returned, err := parseReturn(entry, line)
+				if err != nil {
+					return nil, fmt.Errorf("record %d: %w", number, err)
+				}
+				returns = append(returns, returned)
+			case "98":
+				// notification of change
+			default:
+				return nil, fmt.Errorf("record %d: addenda type %s is not a return", number, line[1:3])
+			}
+			entry = ""
+		case '1', '5', '8', '9':
+			if entry != "" {
+				return nil, fmt.Errorf("record %d: entry %s has no return addenda", number-1, entry[79:])
+			}
+		default:
+			return nil, fmt.Errorf("record %d has unknown type %q", number, line[0])
+		}
+	}
+	if entry != "" {
+		return nil, fmt.Errorf("entry %s has no return addenda", entry[79:])
+	}
+	return returns, nil
+}
+
+// parseReturn reads a return entry and its return addenda record
+func parseReturn(entry, addenda string) (domain.ACHReturn, error) {
+	amount, err := strconv.ParseInt(entry[29:39], 10, 64)
+	if err != nil {
+		return domain.ACHReturn{}, fmt.Errorf("amount %q is not a number", entry[29:39])
+	}
+	code := addenda[3:6]
+	if code[0] != 'R' || strings.Trim(code[1:], "0123456789") != "" {
+		return domain.ACHReturn{}, fmt.Errorf("return reason code %q is invalid", code)
+	}
+	original := strings.TrimSpace(addenda[6:21])
+	if original == "" {
+		return domain.ACHReturn{}, errors.New("original entry trace number is missing")
+	}
+
+	return domain.ACHReturn{
+		TraceNumber:         strings.TrimSpace(entry[79:94]),
+		OriginalTraceNumber: original,
+		Code:                code,
+		Reason:              ReturnReason(code),
+		Amount:              amount,
+		Information:         strings.TrimSpace(addenda[35:79]),
+	}, nil
+}
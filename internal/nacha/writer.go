@@ -0,0 +1,304 @@
+// Package nacha writes NACHA ACH files for US bank transfers and reads ACH return files.
+package nacha
+
+import (
+	"errors"
+	"fmt"
+	"payments_app/internal/domain"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// RecordLength is the length of every record in a NACHA file
+	RecordLength = 94
+	// BlockingFactor is the number of records per block; files are padded to whole blocks
+	BlockingFactor = 10
+
+	// serviceClassCredits marks batches that contain credit entries only
+	serviceClassCredits = "220"
+	// maxAmount is the largest amount in cents the 10-digit amount field holds
+	maxAmount = 9999999999
+	// maxSequence is the largest entry number the seven digits of a trace number hold
+	maxSequence = 9999999
+)
+
+// Standard entry class codes
+const (
+	// SECPPD is used for entries to consumer accounts
+	SECPPD = "PPD"
+	// SECCCD is used for entries to business accounts
+	SECCCD = "CCD"
+)
+
+// Originator is the company that sends the entries, and the bank it sends them through
+type Originator struct {
+	// CompanyName appears on the receivers' bank statements
+	CompanyName string
+	// CompanyID is the identification the bank assigned to the company, up to 10 characters,
+	// usually "1" followed by the EIN
+	CompanyID string
+	// BankRouting is the routing number of the originating bank the file is sent to
+	BankRouting string
+	BankName    string
+	// EntryDescription describes the entries on the receivers' statements; it defaults to PAYOUT
+	EntryDescription string
+}
+
+// Writer builds NACHA files of credit entries for ACH payments
+type Writer struct {
+	originator Originator
+}
+
+// NewWriter creates a writer sending entries for the given originator
+func NewWriter(originator Originator) (*Writer, error) {
+	originator.CompanyName = strings.TrimSpace(originator.CompanyName)
+	originator.CompanyID = strings.ToUpper(strings.TrimSpace(originator.CompanyID))
+	originator.BankRouting = strings.TrimSpace(originator.BankRouting)
+	originator.EntryDescription = strings.TrimSpace(originator.EntryDescription)
+	if originator.EntryDescription == "" {
+		originator.EntryDescription = "PAYOUT"
+	}
+
+	if originator.CompanyName == "" {
+		return nil, errors.New("company name is required")
+	}
+	if originator.CompanyID == "" || len(originator.CompanyID) > 10 || strings.Trim(originator.CompanyID, "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return nil, errors.New("company ID must be 1 to 10 letters or digits")
+	}
+	if !domain.ValidABARoutingNumber(originator.BankRouting) {
+		return nil, fmt.Errorf("bank routing number %q is invalid", originator.BankRouting)
+	}
+	if len(originator.EntryDescription) > 10 {
+		return nil, errors.New("entry description must be at most 10 characters")
+	}
+	return &Writer{originator: originator}, nil
+}
+
+// Transferable reports whether a payment can be sent as an ACH credit: it needs a USD amount,
+// an ACH bank account method, which is the receiver's account, and a receiver name from the
+// payee or the account holder
+func (w *Writer) Transferable(payment *domain.Payment) bool {
+	account, ok := payment.Method.(domain.BankAccountMethod)
+	return ok && account.Scheme == domain.BankSchemeACH && payment.Currency == "USD" &&
+		domain.ValidABARoutingNumber(account.RoutingNumber) && account.AccountNumber != "" &&
+		receiverName(payment, account) != ""
+}
+
+// Encode writes the file for the payments and fills in its totals and entries. Entries are
+// grouped into one batch per standard entry class code and effective entry date, which is the
+// payment's execution date or, if that is earlier, the next weekday after the file's creation.
+// Trace numbers continue from the given sequence number.
+func (w *Writer) Encode(file *domain.ACHFile, payments []*domain.Payment, sequence int) ([]byte, error) {
+	if len(payments) == 0 {
+		return nil, errors.New("an ACH file needs at least one payment")
+	}
+	if len(file.IDModifier) != 1 {
+		return nil, errors.New("file ID modifier must be one character")
+	}
+	if sequence+len(payments) > maxSequence {
+		return nil, errors.New("trace number sequence is exhausted")
+	}
+
+	type batchKey struct {
+		date string
+		sec  string
+	}
+	batches := make(map[batchKey][]*domain.Payment)
+	var keys []batchKey
+	for _, payment := range payments {
+		if !w.Transferable(payment) {
+			return nil, fmt.Errorf("payment %s is not an ACH credit transfer", payment.ID)
+		}
+		account := payment.Method.(domain.BankAccountMethod)
+		key := batchKey{date: effectiveDate(payment, file.CreatedAt), sec: secCode(account)}
+		if _, ok := batches[key]; !ok {
+			keys = append(keys, key)
+		}
+		batches[key] = append(batches[key], payment)
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		if keys[i].date != keys[j].date {
+			return keys[i].date < keys[j].date
+		}
+		return keys[i].sec < keys[j].sec
+	})
+
+	odfi := w.originator.BankRouting[:8]
+	created := file.CreatedAt.UTC()
+	records := []string{record(
+		"1", "01",
+		" "+w.originator.BankRouting,
+		fmt.Sprintf("%10s", w.originator.CompanyID),
+		created.Format("060102"), created.Format("1504"),
+		file.IDModifier, "094", fmt.Sprint(BlockingFactor), "1",
+		alpha(w.originator.BankName, 23),
+		alpha(w.originator.CompanyName, 23),
+		alpha("", 8),
+	)}
+
+	file.Entries = file.Entries[:0]
+	file.EntryCount, file.EntryHash, file.TotalCredit = 0, 0, 0
+	entryAndAddendaCount := 0
+	for i, key := range keys {
+		batchNumber := numeric(int64(i+1), 7)
+		records = append(records, record(
+			"5", serviceClassCredits,
+			alpha(w.originator.CompanyName, 16),
+			alpha("", 20),
+			alpha(w.originator.CompanyID, 10),
+			key.sec,
+			alpha(w.originator.EntryDescription, 10),
+			alpha("", 6),
+			key.date, "   ", "1", odfi, batchNumber,
+		))
+
+		var count int
+		var hash, credit int64
+		for _, payment := range batches[key] {
+			account := payment.Method.(domain.BankAccountMethod)
+			amount := domain.MoneyFromFloat(payment.Amount, payment.Currency).MinorUnits
+			if amount <= 0 || amount > maxAmount {
+				return nil, fmt.Errorf("payment %s amount is out of range for an ACH entry", payment.ID)
+			}
+			sequence++
+			trace := odfi + numeric(int64(sequence), 7)
+			description := strings.TrimSpace(payment.Description)
+			addenda := "0"
+			if description != "" {
+				addenda = "1"
+			}
+
+			records = append(records, record(
+				"6", transactionCode(account),
+				account.RoutingNumber[:8], account.RoutingNumber[8:],
+				alpha(account.AccountNumber, 17),
+				numeric(amount, 10),
+				alpha(strings.ReplaceAll(payment.ID, "-", ""), 15),
+				alpha(receiverName(payment, account), 22),
+				"  ", addenda, trace,
+			))
+			count++
+			if description != "" {
+				records = append(records, record("7", "05", alpha(description, 80), "0001", trace[8:]))
+				count++
+			}
+
+			routing, _ := strconv.ParseInt(account.RoutingNumber[:8], 10, 64)
+			hash += routing
+			credit += amount
+			file.Entries = append(file.Entries, domain.ACHEntry{TraceNumber: trace, PaymentID: payment.ID})
+		}
+
+		records = append(records, record(
+			"8", serviceClassCredits,
+			numeric(int64(count), 6),
+			numeric(hash%10000000000, 10),
+			numeric(0, 12), numeric(credit, 12),
+			alpha(w.originator.CompanyID, 10),
+			alpha("", 19), alpha("", 6),
+			odfi, batchNumber,
+		))
+		entryAndAddendaCount += count
+		file.EntryHash += hash
+		file.TotalCredit += credit
+	}
+
+	file.BatchCount = len(keys)
+	file.EntryCount = len(file.Entries)
+	file.EntryHash %= 10000000000
+	blocks := (len(records) + 1 + BlockingFactor - 1) / BlockingFactor
+	records = append(records, record(
+		"9",
+		numeric(int64(file.BatchCount), 6),
+		numeric(int64(blocks), 6),
+		numeric(int64(entryAndAddendaCount), 8),
+		numeric(file.EntryHash, 10),
+		numeric(0, 12), numeric(file.TotalCredit, 12),
+		alpha("", 39),
+	))
+	for len(records)%BlockingFactor != 0 {
+		records = append(records, strings.Repeat("9", RecordLength))
+	}
+
+	var b strings.Builder
+	for _, line := range records {
+		if len(line) != RecordLength {
+			return nil, fmt.Errorf("record %q is not %d characters", line, RecordLength)
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return []byte(b.String()), nil
+}
+
+// effectiveDate returns the payment's execution date, or the next weekday after the file was
+// created if that is later, as YYMMDD; banks move dates that fall on a holiday
+func effectiveDate(payment *domain.Payment, created time.Time) string {
+	date := created.UTC().AddDate(0, 0, 1)
+	for date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+		date = date.AddDate(0, 0, 1)
+	}
+	if payment.ExecuteAt != nil && payment.ExecuteAt.UTC().Format("060102") > date.Format("060102") {
+		return payment.ExecuteAt.UTC().Format("060102")
+	}
+	return date.Format("060102")
+}
+
+// secCode pays business accounts with CCD and consumer accounts with PPD entries
+func secCode(account domain.BankAccountMethod) string {
+	if account.HolderType == domain.AccountHolderTypeCompany {
+		return SECCCD
+	}
+	return SECPPD
+}
+
+// transactionCode returns the code of a credit to a checking or savings account
+func transactionCode(account domain.BankAccountMethod) string {
+	if account.AccountType == domain.BankAccountTypeSavings {
+		return "32"
+	}
+	return "22"
+}
+
+func receiverName(payment *domain.Payment, account domain.BankAccountMethod) string {
+	if payment.Payee != nil && strings.TrimSpace(payment.Payee.Name) != "" {
+		return strings.TrimSpace(payment.Payee.Name)
+	}
+	return strings.TrimSpace(account.HolderName)
+}
+
+func record(fields ...string) string {
+	return strings.Join(fields, "")
+}
+
+// alpha formats text for an alphanumeric field: upper case ASCII without accents, left
+// justified and padded with spaces to n characters, or cut to n characters
+func alpha(text string, n int) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(strings.ToUpper(text)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// drop accents left over from decomposition
+		case r >= ' ' && r <= '~':
+			b.WriteRune(r)
+		default:
+			b.WriteByte(' ')
+		}
+	}
+	value := b.String()
+	if len(value) > n {
+		return value[:n]
+	}
+	return value + strings.Repeat(" ", n-len(value))
+}
+
+// numeric formats a number right justified and padded with zeros to n digits
+func numeric(value int64, n int) string {
+	return fmt.Sprintf("%0*d", n, value)
+}
@@ -0,0 +1,243 @@
+package reconciliation
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"payments_app/internal/domain"
+	"strings"
+	"unicode/utf8"
+)
+
+// camt.053 elements are matched by local name, so every message version from .001.02 on decodes
+type camtDocument struct {
+	Statements []camtStatement `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtStatement struct {
+	ID       string        `xml:"Id"`
+	IBAN     string        `xml:"Acct>Id>IBAN"`
+	Other    string        `xml:"Acct>Id>Othr>Id"`
+	Currency string        `xml:"Acct>Ccy"`
+	Balances []camtBalance `xml:"Bal"`
+	Entries  []camtEntry   `xml:"Ntry"`
+}
+
+type camtBalance struct {
+	Code      string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amount    camtAmount `xml:"Amt"`
+	Indicator string     `xml:"CdtDbtInd"`
+}
+
+type camtAmount struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"Ccy,attr"`
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+func (d camtDate) value() string {
+	if d.Date != "" {
+		return d.Date
+	}
+	return d.DateTime
+}
+
+// camtStatus is a plain code up to version .001.04 and a Cd element from .001.05 on
+type camtStatus struct {
+	Text string `xml:",chardata"`
+	Code string `xml:"Cd"`
+}
+
+type camtEntry struct {
+	Amount         camtAmount        `xml:"Amt"`
+	Indicator      string            `xml:"CdtDbtInd"`
+	Status         camtStatus        `xml:"Sts"`
+	BookingDate    camtDate          `xml:"BookgDt"`
+	ValueDate      camtDate          `xml:"ValDt"`
+	BankReference  string            `xml:"AcctSvcrRef"`
+	AdditionalInfo string            `xml:"AddtlNtryInf"`
+	Transactions   []camtTransaction `xml:"NtryDtls>TxDtls"`
+}
+
+type camtTransaction struct {
+	EndToEndID      string     `xml:"Refs>EndToEndId"`
+	BankReference   string     `xml:"Refs>AcctSvcrRef"`
+	Amount          camtAmount `xml:"Amt"`
+	TxAmount        camtAmount `xml:"AmtDtls>TxAmt>Amt"`
+	Indicator       string     `xml:"CdtDbtInd"`
+	DebtorName      string     `xml:"RltdPties>Dbtr>Nm"`
+	DebtorPtyName   string     `xml:"RltdPties>Dbtr>Pty>Nm"`
+	DebtorIBAN      string     `xml:"RltdPties>DbtrAcct>Id>IBAN"`
+	CreditorName    string     `xml:"RltdPties>Cdtr>Nm"`
+	CreditorPtyName string     `xml:"RltdPties>Cdtr>Pty>Nm"`
+	CreditorIBAN    string     `xml:"RltdPties>CdtrAcct>Id>IBAN"`
+	Unstructured    []string   `xml:"RmtInf>Ustrd"`
+	CreditorRef     string     `xml:"RmtInf>Strd>CdtrRefInf>Ref"`
+	AdditionalInfo  string     `xml:"AddtlTxInf"`
+}
+
+// parseCAMT053 reads the booked entries of every statement in a camt.053 document. An entry
+// with several transaction details, such as a batch booking, becomes one line per transaction.
+func parseCAMT053(content []byte) ([]*domain.Statement, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	decoder.CharsetReader = latin1Reader
+	var document camtDocument
+	if err := decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("invalid camt.053 document: %w", err)
+	}
+
+	statements := make([]*domain.Statement, 0, len(document.Statements))
+	for _, stmt := range document.Statements {
+		statement := &domain.Statement{
+			Reference: strings.TrimSpace(stmt.ID),
+			Account:   firstNonEmpty(stmt.IBAN, stmt.Other),
+			Currency:  strings.TrimSpace(stmt.Currency),
+		}
+		for _, balance := range stmt.Balances {
+			if statement.Currency == "" {
+				statement.Currency = balance.Amount.Currency
+			}
+			money, err := camtMoney(balance.Amount, balance.Indicator)
+			if err != nil {
+				return nil, fmt.Errorf("statement %s: balance %s: %w", statement.Reference, balance.Code, err)
+			}
+			switch balance.Code {
+			case "OPBD", "PRCD":
+				if statement.OpeningBalance == nil {
+					statement.OpeningBalance = &money
+				}
+			case "CLBD":
+				statement.ClosingBalance = &money
+			}
+		}
+
+		for i, entry := range stmt.Entries {
+			status := firstNonEmpty(entry.Status.Code, entry.Status.Text)
+			if status != "" && status != "BOOK" {
+				// Pending and informational entries have not moved money yet
+				continue
+			}
+			lines, err := camtLines(entry)
+			if err != nil {
+				return nil, fmt.Errorf("statement %s: entry %d: %w", statement.Reference, i+1, err)
+			}
+			statement.Lines = append(statement.Lines, lines...)
+		}
+		statements = append(statements, statement)
+	}
+	return statements, nil
+}
+
+// camtLines converts an entry to statement lines, one per transaction detail
+func camtLines(entry camtEntry) ([]*domain.StatementLine, error) {
+	bookingDate, err := parseDate(entry.BookingDate.value())
+	if err != nil {
+		return nil, fmt.Errorf("invalid booking date: %w", err)
+	}
+	valueDate := bookingDate
+	if entry.ValueDate.value() != "" {
+		if valueDate, err = parseDate(entry.ValueDate.value()); err != nil {
+			return nil, fmt.Errorf("invalid value date: %w", err)
+		}
+	}
+
+	transactions := entry.Transactions
+	if len(transactions) == 0 {
+		transactions = []camtTransaction{{}}
+	}
+	lines := make([]*domain.StatementLine, 0, len(transactions))
+	for _, tx := range transactions {
+		amount, indicator := entry.Amount, entry.Indicator
+		if len(entry.Transactions) > 1 {
+			// Each transaction of a batch booking carries its own amount
+			amount = tx.Amount
+			if amount.Value == "" {
+				amount = tx.TxAmount
+			}
+			indicator = firstNonEmpty(tx.Indicator, indicator)
+		}
+		money, err := camtMoney(amount, "")
+		if err != nil {
+			return nil, err
+		}
+		line := &domain.StatementLine{
+			BookingDate:   bookingDate,
+			ValueDate:     valueDate,
+			Amount:        money,
+			Direction:     domain.EntryDirectionCredit,
+			Reference:     tx.CreditorRef,
+			BankReference: firstNonEmpty(tx.BankReference, entry.BankReference),
+			Description:   strings.Join(append(nonEmpty(tx.Unstructured...), nonEmpty(tx.AdditionalInfo, entry.AdditionalInfo)...), " "),
+		}
+		if endToEnd := strings.TrimSpace(tx.EndToEndID); endToEnd != "" && endToEnd != "NOTPROVIDED" {
+			line.Reference = endToEnd
+		}
+		switch indicator {
+		case "CRDT":
+			line.Counterparty = firstNonEmpty(tx.DebtorName, tx.DebtorPtyName)
+			line.CounterpartyAccount = tx.DebtorIBAN
+		case "DBIT":
+			line.Direction = domain.EntryDirectionDebit
+			line.Counterparty = firstNonEmpty(tx.CreditorName, tx.CreditorPtyName)
+			line.CounterpartyAccount = tx.CreditorIBAN
+		default:
+			return nil, fmt.Errorf("invalid credit/debit indicator %q", indicator)
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// camtMoney reads an amount; a DBIT indicator makes it negative
+func camtMoney(amount camtAmount, indicator string) (domain.Money, error) {
+	money, err := domain.ParseMoney(amount.Value, strings.TrimSpace(amount.Currency))
+	if err != nil {
+		return money, err
+	}
+	if indicator == "DBIT" {
+		money.MinorUnits = -money.MinorUnits
+	}
+	return money, nil
+}
+
+// latin1Reader decodes ISO-8859-1 documents, which some banks still send
+func latin1Reader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "iso-8859-1", "iso8859-1", "latin1", "latin-1":
+	default:
+		return nil, fmt.Errorf("unsupported charset %q", charset)
+	}
+	content, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	decoded := make([]byte, 0, len(content))
+	for _, b := range content {
+		decoded = utf8.AppendRune(decoded, rune(b))
+	}
+	return bytes.NewReader(decoded), nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func nonEmpty(values ...string) []string {
+	result := values[:0:0]
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
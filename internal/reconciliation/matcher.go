@@ -0,0 +1,190 @@
+package reconciliation
+
+import (
+	"math"
+	"payments_app/internal/domain"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Weights of the match criteria; a line matching on all three scores 1
+const (
+	amountWeight    = 0.4
+	referenceWeight = 0.4
+	dateWeight      = 0.2
+)
+
+// Config tunes the matcher; zero values select the defaults
+type Config struct {
+	// DateWindow is how far the value date may be from the payment date to count, default 3 days
+	DateWindow time.Duration
+	// AutoMatchScore is the score from which matches are accepted without review, default 0.9
+	AutoMatchScore float64
+	// ProposeScore is the lowest score proposed for review, default 0.5
+	ProposeScore float64
+}
+
+// Matcher scores statement lines against payments and pairs them one to one
+type Matcher struct {
+	cfg Config
+}
+
+// NewMatcher creates a matcher with the given settings
+func NewMatcher(cfg Config) *Matcher {
+	if cfg.DateWindow <= 0 {
+		cfg.DateWindow = 3 * 24 * time.Hour
+	}
+	if cfg.AutoMatchScore <= 0 {
+		cfg.AutoMatchScore = 0.9
+	}
+	if cfg.ProposeScore <= 0 {
+		cfg.ProposeScore = 0.5
+	}
+	return &Matcher{cfg: cfg}
+}
+
+// Score rates how likely a line records a payment, between 0 and 1. The currency and the
+// direction must fit the payment, and the amount or the reference must match:
+//   - the exact amount adds 0.4,
+//   - the payment ID or processor reference as a word of the line's reference, bank reference
+//     or description adds 0.4; case, hyphens and underscores are ignored,
+//   - a value date within the date window adds up to 0.2: all of it on the payment date, less
+//     for every day in between.
+func (m *Matcher) Score(line *domain.StatementLine, payment *domain.Payment) float64 {
+	return m.score(line, words(line), payment)
+}
+
+func (m *Matcher) score(line *domain.StatementLine, lineWords map[string]bool, payment *domain.Payment) float64 {
+	if line.Amount.Currency != payment.Currency || line.Direction != direction(payment) {
+		return 0
+	}
+	score := 0.0
+	if domain.MoneyFromFloat(payment.Amount, payment.Currency).MinorUnits == line.Amount.MinorUnits {
+		score += amountWeight
+	}
+	for _, key := range paymentKeys(payment) {
+		if lineWords[key] {
+			score += referenceWeight
+			break
+		}
+	}
+	if score == 0 {
+		return 0
+	}
+	days := math.Min(dayDistance(line.ValueDate, payment.CreatedAt), dayDistance(line.ValueDate, payment.UpdatedAt))
+	if window := m.cfg.DateWindow.Hours() / 24; days <= window {
+		score += dateWeight * (1 - days/(window+1))
+	}
+	return math.Round(score*1000) / 1000
+}
+
+// Match pairs lines with payments, best scores first, using every line and payment at most
+// once. Lines a reviewer rejected a payment for are not paired with it again. A match scoring
+// at least the auto-match score is marked Auto unless another pairing of its line or its
+// payment scores the same, since the engine cannot tell those apart.
+func (m *Matcher) Match(lines []*domain.StatementLine, payments []*domain.Payment) []domain.StatementMatch {
+	byCurrency := make(map[string][]*domain.Payment)
+	for _, payment := range payments {
+		byCurrency[payment.Currency] = append(byCurrency[payment.Currency], payment)
+	}
+
+	type candidate struct {
+		line    int
+		payment *domain.Payment
+		score   float64
+	}
+	var candidates []candidate
+	for i, line := range lines {
+		lineWords := words(line)
+		for _, payment := range byCurrency[line.Amount.Currency] {
+			if line.Rejected(payment.ID) {
+				continue
+			}
+			if score := m.score(line, lineWords, payment); score >= m.cfg.ProposeScore {
+				candidates = append(candidates, candidate{line: i, payment: payment, score: score})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	type lineScore struct {
+		line  int
+		score float64
+	}
+	type paymentScore struct {
+		payment string
+		score   float64
+	}
+	lineScores := make(map[lineScore]int)
+	paymentScores := make(map[paymentScore]int)
+	for _, c := range candidates {
+		lineScores[lineScore{c.line, c.score}]++
+		paymentScores[paymentScore{c.payment.ID, c.score}]++
+	}
+
+	usedLines := make(map[int]bool)
+	usedPayments := make(map[string]bool)
+	var matches []domain.StatementMatch
+	for _, c := range candidates {
+		if usedLines[c.line] || usedPayments[c.payment.ID] {
+			continue
+		}
+		usedLines[c.line], usedPayments[c.payment.ID] = true, true
+		matches = append(matches, domain.StatementMatch{
+			Line:    lines[c.line],
+			Payment: c.payment,
+			Score:   c.score,
+			Auto: c.score >= m.cfg.AutoMatchScore &&
+				lineScores[lineScore{c.line, c.score}] == 1 && paymentScores[paymentScore{c.payment.ID, c.score}] == 1,
+		})
+	}
+	return matches
+}
+
+// direction is the way a payment's money moves on the account: payments sent by credit transfer
+// or ACH file and refunded payments leave it as debits, collected payments arrive as credits
+func direction(payment *domain.Payment) domain.EntryDirection {
+	if payment.Status == domain.PaymentStatusSubmitted || payment.Status == domain.PaymentStatusRefunded || payment.SubmissionID != "" {
+		return domain.EntryDirectionDebit
+	}
+	return domain.EntryDirectionCredit
+}
+
+// words returns the normalized words of a line's references and description
+func words(line *domain.StatementLine) map[string]bool {
+	result := make(map[string]bool)
+	for _, text := range []string{line.Reference, line.BankReference, line.Description} {
+		for _, word := range strings.FieldsFunc(normalize(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			result[word] = true
+		}
+	}
+	return result
+}
+
+// paymentKeys returns the normalized identifiers a bank line may quote for a payment
+func paymentKeys(payment *domain.Payment) []string {
+	keys := []string{normalize(payment.ID)}
+	if payment.ProcessorReference != "" {
+		keys = append(keys, normalize(payment.ProcessorReference))
+	}
+	return keys
+}
+
+// normalize upper-cases text and drops hyphens and underscores, which banks often strip
+// from references (a UUID without hyphens also fits the 35 characters of an end-to-end ID)
+func normalize(text string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", "_", "").Replace(text))
+}
+
+// dayDistance is the number of calendar days between two dates
+func dayDistance(a, b time.Time) float64 {
+	dateA := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	dateB := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return math.Abs(dateA.Sub(dateB).Hours() / 24)
+}
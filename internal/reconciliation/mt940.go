@@ -0,0 +1,232 @@
+package reconciliation
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"payments_app/internal/domain"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	mt940Field   = regexp.MustCompile(`^:(\d{2}[A-Z]?):(.*)$`)
+	mt940Balance = regexp.MustCompile(`^([CD])(\d{6})([A-Z]{3})(\d+,\d*)$`)
+	// :61: value date, optional entry date, mark, optional funds code, amount and transaction type
+	mt940Entry = regexp.MustCompile(`^(\d{6})(\d{4})?(RC|RD|C|D)([A-Z])?(\d+,\d*)([NFS][A-Z0-9]{3})`)
+	// mt940Subfield splits structured :86: information such as "166?00GUTSCHRIFT?20EREF+..."
+	mt940Subfield = regexp.MustCompile(`\?(\d{2})`)
+	// endToEndReference finds the payer's reference in SEPA ("EREF+") and SWIFT ("/EREF/") remittance text
+	endToEndReference = regexp.MustCompile(`EREF\+(.+?)(?:(?:KREF|MREF|CRED|DEBT|SVWZ|ABWA|ABWE|COAM|OAMT|IBAN|BIC)\+|$)|/EREF/([^/]+)`)
+)
+
+// parseMT940 reads every statement message of an MT940 file. SWIFT block headers are
+// skipped, so both bare tag files and full FIN messages are accepted.
+func parseMT940(content []byte) ([]*domain.Statement, error) {
+	var (
+		statements []*domain.Statement
+		statement  *domain.Statement
+		tag        string
+		value      strings.Builder
+		lineNo     int
+		fieldLine  int
+		last       string // the tag of the previous field, so :86: can attach to its :61:
+	)
+
+	flush := func() error {
+		if tag == "" {
+			return nil
+		}
+		defer func() { last, tag = tag, "" }()
+		if tag != "20" && statement == nil {
+			return fmt.Errorf("line %d: field :%s: before :20:", fieldLine, tag)
+		}
+		if err := applyMT940Field(&statements, &statement, tag, value.String(), last); err != nil {
+			return fmt.Errorf("line %d: field :%s: %w", fieldLine, tag, err)
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(bytes.TrimPrefix(content, []byte("\ufeff"))))
+	scanner.Buffer(make([]byte, 64*1024), MaxStatementBytes)
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimRight(scanner.Text(), "\r ")
+		if i := strings.Index(line, "{4:"); i >= 0 {
+			line = line[i+len("{4:"):]
+		}
+		switch {
+		case line == "":
+			continue
+		case line == "-" || strings.HasPrefix(line, "-}"):
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			continue
+		case strings.HasPrefix(line, "{"):
+			continue
+		}
+		if match := mt940Field.FindStringSubmatch(line); match != nil {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			tag, fieldLine = match[1], lineNo
+			value.Reset()
+			value.WriteString(match[2])
+			continue
+		}
+		if tag == "" {
+			return nil, fmt.Errorf("line %d: text outside of a field", lineNo)
+		}
+		value.WriteString("\n" + line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return statements, nil
+}
+
+// applyMT940Field adds one field to the statement being read
+func applyMT940Field(statements *[]*domain.Statement, current **domain.Statement, tag, value, previous string) error {
+	statement := *current
+	switch tag {
+	case "20":
+		statement = &domain.Statement{Reference: strings.TrimSpace(value)}
+		*statements = append(*statements, statement)
+		*current = statement
+	case "25":
+		statement.Account = strings.TrimSpace(value)
+	case "28C":
+		statement.Reference += "/" + strings.TrimSpace(value)
+	case "60F", "60M", "62F", "62M":
+		balance, err := parseMT940Balance(value)
+		if err != nil {
+			return err
+		}
+		if statement.Currency == "" {
+			statement.Currency = balance.Currency
+		}
+		if tag[:2] == "60" && statement.OpeningBalance == nil {
+			statement.OpeningBalance = &balance
+		} else if tag[:2] == "62" {
+			statement.ClosingBalance = &balance
+		}
+	case "61":
+		if statement.Currency == "" {
+			return fmt.Errorf("statement line before the opening balance")
+		}
+		line, err := parseMT940Entry(value, statement.Currency)
+		if err != nil {
+			return err
+		}
+		statement.Lines = append(statement.Lines, line)
+	case "86":
+		if previous == "61" && len(statement.Lines) > 0 {
+			applyMT940Information(statement.Lines[len(statement.Lines)-1], value)
+		}
+	}
+	return nil
+}
+
+// parseMT940Balance reads a balance such as "C260301EUR1234,56"
+func parseMT940Balance(value string) (domain.Money, error) {
+	match := mt940Balance.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return domain.Money{}, fmt.Errorf("invalid balance %q", value)
+	}
+	money, err := domain.ParseMoney(strings.Replace(match[4], ",", ".", 1), match[3])
+	if err != nil {
+		return money, err
+	}
+	if match[1] == "D" {
+		money.MinorUnits = -money.MinorUnits
+	}
+	return money, nil
+}
+
+// parseMT940Entry reads a :61: statement line such as "2603020302C1234,56NTRFINV-1001//BANKREF"
+func parseMT940Entry(value, currency string) (*domain.StatementLine, error) {
+	first, supplementary, _ := strings.Cut(value, "\n")
+	match := mt940Entry.FindStringSubmatch(first)
+	if match == nil {
+		return nil, fmt.Errorf("invalid statement line %q", first)
+	}
+	valueDate, err := time.Parse("060102", match[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid value date %q", match[1])
+	}
+	bookingDate := valueDate
+	if match[2] != "" {
+		entry, err := time.Parse("0102", match[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid entry date %q", match[2])
+		}
+		// The entry date has no year; it may fall in the year before or after the value date
+		bookingDate = time.Date(valueDate.Year(), entry.Month(), entry.Day(), 0, 0, 0, 0, time.UTC)
+		if months := int(entry.Month()) - int(valueDate.Month()); months > 6 {
+			bookingDate = bookingDate.AddDate(-1, 0, 0)
+		} else if months < -6 {
+			bookingDate = bookingDate.AddDate(1, 0, 0)
+		}
+	}
+	amount, err := domain.ParseMoney(strings.Replace(match[5], ",", ".", 1), currency)
+	if err != nil {
+		return nil, err
+	}
+
+	line := &domain.StatementLine{
+		BookingDate: bookingDate,
+		ValueDate:   valueDate,
+		Amount:      amount,
+		Direction:   domain.EntryDirectionCredit,
+		Description: strings.TrimSpace(strings.ReplaceAll(supplementary, "\n", " ")),
+	}
+	// A reversal of a credit takes money out of the account and a reversal of a debit puts it back
+	if match[3] == "D" || match[3] == "RC" {
+		line.Direction = domain.EntryDirectionDebit
+	}
+	customerRef, bankRef, _ := strings.Cut(first[len(match[0]):], "//")
+	if customerRef = strings.TrimSpace(customerRef); customerRef != "NONREF" {
+		line.Reference = customerRef
+	}
+	line.BankReference = strings.TrimSpace(bankRef)
+	return line, nil
+}
+
+// applyMT940Information adds the :86: information of a statement line. Structured German
+// information is split into remittance text, counterparty name and account; other formats
+// are kept as text. An end-to-end reference in the text replaces the customer reference.
+func applyMT940Information(line *domain.StatementLine, value string) {
+	text := strings.ReplaceAll(value, "\n", "")
+	if indexes := mt940Subfield.FindAllStringSubmatchIndex(text, -1); len(indexes) > 0 && indexes[0][0] == 3 {
+		var remittance, name strings.Builder
+		for i, index := range indexes {
+			end := len(text)
+			if i+1 < len(indexes) {
+				end = indexes[i+1][0]
+			}
+			content := text[index[1]:end]
+			switch code := text[index[2]:index[3]]; {
+			case code >= "20" && code <= "29", code >= "60" && code <= "63":
+				remittance.WriteString(content)
+			case code == "31":
+				line.CounterpartyAccount = content
+			case code == "32", code == "33":
+				name.WriteString(content)
+			}
+		}
+		text = remittance.String()
+		line.Counterparty = strings.TrimSpace(name.String())
+	}
+	line.Description = strings.TrimSpace(strings.Join(nonEmpty(text, line.Description), " "))
+
+	if match := endToEndReference.FindStringSubmatch(text); match != nil {
+		if reference := strings.TrimSpace(firstNonEmpty(match[1], match[2])); reference != "" && reference != "NOTPROVIDED" {
+			line.Reference = reference
+		}
+	}
+}
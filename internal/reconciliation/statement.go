@@ -0,0 +1,103 @@
+// Package reconciliation reads bank statements and matches their lines to payments.
+package reconciliation
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"payments_app/internal/domain"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxStatementBytes caps the size of a statement file
+const MaxStatementBytes = 32 << 20
+
+// ParseFormat converts a format name such as "camt053", "camt.053" or "mt940" to a StatementFormat
+func ParseFormat(name string) (domain.StatementFormat, error) {
+	switch strings.ToUpper(strings.NewReplacer(".", "", "_", "", "-", "").Replace(strings.TrimSpace(name))) {
+	case "CAMT053":
+		return domain.StatementFormatCAMT053, nil
+	case "MT940":
+		return domain.StatementFormatMT940, nil
+	default:
+		return "", fmt.Errorf("unsupported statement format %q", name)
+	}
+}
+
+// DetectFormat tells camt.053 XML from MT940 by the content of the file
+func DetectFormat(content []byte) (domain.StatementFormat, error) {
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(content, []byte("\ufeff")), " \t\r\n")
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		return domain.StatementFormatCAMT053, nil
+	case bytes.HasPrefix(trimmed, []byte("{1:")), bytes.HasPrefix(trimmed, []byte(":20:")), bytes.Contains(trimmed, []byte("\n:20:")):
+		return domain.StatementFormatMT940, nil
+	default:
+		return "", errors.New("cannot detect the statement format; specify camt053 or mt940")
+	}
+}
+
+// Parse reads the statements of a camt.053 or MT940 file; an empty format is detected from
+// the content. The statements get new IDs, the file checksum and UNMATCHED lines.
+func Parse(r io.Reader, format domain.StatementFormat) ([]*domain.Statement, error) {
+	content, err := io.ReadAll(io.LimitReader(r, MaxStatementBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > MaxStatementBytes {
+		return nil, fmt.Errorf("statement file exceeds %d bytes", MaxStatementBytes)
+	}
+	if format == "" {
+		if format, err = DetectFormat(content); err != nil {
+			return nil, err
+		}
+	}
+
+	var statements []*domain.Statement
+	switch format {
+	case domain.StatementFormatCAMT053:
+		statements, err = parseCAMT053(content)
+	case domain.StatementFormatMT940:
+		statements, err = parseMT940(content)
+	default:
+		return nil, fmt.Errorf("unsupported statement format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(statements) == 0 {
+		return nil, errors.New("file contains no statements")
+	}
+
+	sum := sha256.Sum256(content)
+	checksum := hex.EncodeToString(sum[:])
+	now := time.Now()
+	for _, statement := range statements {
+		statement.ID = uuid.New().String()
+		statement.Format = format
+		statement.SHA256 = checksum
+		statement.ImportedAt = now
+		for i, line := range statement.Lines {
+			line.ID = uuid.New().String()
+			line.StatementID = statement.ID
+			line.Index = i + 1
+			line.Status = domain.StatementLineStatusUnmatched
+		}
+	}
+	return statements, nil
+}
+
+// parseDate reads an ISO date or date-time, keeping only the calendar date
+func parseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if len(value) > len("2006-01-02") {
+		value = value[:len("2006-01-02")]
+	}
+	return time.Parse("2006-01-02", value)
+}
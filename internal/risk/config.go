@@ -0,0 +1,168 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"payments_app/internal/scheduler"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the YAML representation of a rules file
+type Config struct {
+	Thresholds Thresholds   `yaml:"thresholds"`
+	Rules      []RuleConfig `yaml:"rules"`
+}
+
+// RuleConfig holds the settings of a single rule; fields are interpreted per rule type
+type RuleConfig struct {
+	Name       string        `yaml:"name"`
+	Type       string        `yaml:"type"`
+	Score      int           `yaml:"score"`
+	Action     string        `yaml:"action"`
+	MaxCount   int           `yaml:"max_count"`
+	Window     time.Duration `yaml:"window"`
+	Multiplier float64       `yaml:"multiplier"`
+	Lookback   time.Duration `yaml:"lookback"`
+	MinHistory int           `yaml:"min_history"`
+	Currencies []string      `yaml:"currencies"`
+	Keywords   []string      `yaml:"keywords"`
+}
+
+// RuleFactory builds a rule from its configuration
+type RuleFactory func(cfg RuleConfig) (Rule, error)
+
+var (
+	factoriesMutex sync.RWMutex
+	factories      = map[string]RuleFactory{
+		"velocity":         newVelocityRule,
+		"amount_spike":     newAmountSpikeRule,
+		"blocked_currency": newBlockedCurrencyRule,
+		"keywords":         newKeywordRule,
+	}
+)
+
+// RegisterRuleType makes a custom rule type available to rules files
+func RegisterRuleType(ruleType string, factory RuleFactory) {
+	factoriesMutex.Lock()
+	defer factoriesMutex.Unlock()
+	factories[ruleType] = factory
+}
+
+// LoadConfig reads and parses a YAML rules file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid risk rules file %s: %w", path, err)
+	}
+	if err := cfg.Thresholds.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid risk rules file %s: %w", path, err)
+	}
+
+	return &cfg, nil
+}
+
+// BuildRules instantiates the rules described by the configuration
+func BuildRules(cfg *Config) ([]Rule, error) {
+	factoriesMutex.RLock()
+	defer factoriesMutex.RUnlock()
+
+	rules := make([]Rule, 0, len(cfg.Rules))
+	for i, ruleCfg := range cfg.Rules {
+		factory, exists := factories[ruleCfg.Type]
+		if !exists {
+			return nil, fmt.Errorf("rule %d: unknown rule type %q", i+1, ruleCfg.Type)
+		}
+		if ruleCfg.Name == "" {
+			ruleCfg.Name = ruleCfg.Type
+		}
+		if ruleCfg.Action != "" && ruleCfg.Action != "score" && ruleCfg.Action != "deny" {
+			return nil, fmt.Errorf("rule %s: action must be score or deny", ruleCfg.Name)
+		}
+
+		rule, err := factory(ruleCfg)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", ruleCfg.Name, err)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// Reload loads a rules file and swaps it into the engine; the old rules stay active on error
+func (e *Engine) Reload(path string) error {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	rules, err := BuildRules(cfg)
+	if err != nil {
+		return err
+	}
+
+	e.Replace(cfg.Thresholds, rules)
+	return nil
+}
+
+// Watch polls a rules file and reloads the engine whenever its modification time changes.
+// Reload errors are passed to onError and the previous rules are kept. An interval of zero or
+// less disables watching.
+func (e *Engine) Watch(ctx context.Context, path string, interval time.Duration, onError func(error)) {
+	scheduler.WatchFile(ctx, path, interval, e.Reload, onError)
+}
+
+func newVelocityRule(cfg RuleConfig) (Rule, error) {
+	if cfg.MaxCount <= 0 || cfg.Window <= 0 {
+		return nil, errors.New("velocity rule requires max_count and window")
+	}
+	return &VelocityRule{RuleName: cfg.Name, MaxCount: cfg.MaxCount, Window: cfg.Window, Score: cfg.Score}, nil
+}
+
+func newAmountSpikeRule(cfg RuleConfig) (Rule, error) {
+	if cfg.Multiplier <= 1 || cfg.Lookback <= 0 {
+		return nil, errors.New("amount_spike rule requires multiplier above 1 and lookback")
+	}
+	return &AmountSpikeRule{
+		RuleName:   cfg.Name,
+		Multiplier: cfg.Multiplier,
+		Lookback:   cfg.Lookback,
+		MinHistory: cfg.MinHistory,
+		Score:      cfg.Score,
+	}, nil
+}
+
+func newBlockedCurrencyRule(cfg RuleConfig) (Rule, error) {
+	if len(cfg.Currencies) == 0 {
+		return nil, errors.New("blocked_currency rule requires currencies")
+	}
+	currencies := make(map[string]bool, len(cfg.Currencies))
+	for _, currency := range cfg.Currencies {
+		currencies[strings.ToUpper(strings.TrimSpace(currency))] = true
+	}
+	return &BlockedCurrencyRule{RuleName: cfg.Name, Currencies: currencies, Score: cfg.Score, Deny: cfg.Action == "deny"}, nil
+}
+
+func newKeywordRule(cfg RuleConfig) (Rule, error) {
+	if len(cfg.Keywords) == 0 {
+		return nil, errors.New("keywords rule requires keywords")
+	}
+	keywords := make([]string, 0, len(cfg.Keywords))
+	for _, keyword := range cfg.Keywords {
+		if keyword = strings.ToLower(strings.TrimSpace(keyword)); keyword != "" {
+			keywords = append(keywords, keyword)
+		}
+	}
+	return &KeywordRule{RuleName: cfg.Name, Keywords: keywords, Score: cfg.Score, Deny: cfg.Action == "deny"}, nil
+}
@@ -0,0 +1,157 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payments_app/internal/domain"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Default score thresholds used when a rules file does not set them
+const (
+	DefaultReviewThreshold = 50
+	DefaultDenyThreshold   = 80
+)
+
+// History provides access to previous payments for behavioural rules
+type History interface {
+	PayerPayments(ctx context.Context, payerID string, since time.Time) ([]*domain.Payment, error)
+}
+
+// Hit describes a single rule match
+type Hit struct {
+	Rule   string
+	Score  int
+	Deny   bool
+	Reason string
+}
+
+// Rule evaluates a payment and returns a hit, or nil when the rule does not match
+type Rule interface {
+	Name() string
+	Evaluate(ctx context.Context, payment *domain.Payment, history History) (*Hit, error)
+}
+
+// Thresholds map an accumulated score onto a decision
+type Thresholds struct {
+	Review int `yaml:"review"`
+	Deny   int `yaml:"deny"`
+}
+
+// withDefaults fills in the default for each threshold that is not set
+func (t Thresholds) withDefaults() Thresholds {
+	if t.Review <= 0 {
+		t.Review = DefaultReviewThreshold
+	}
+	if t.Deny <= 0 {
+		t.Deny = DefaultDenyThreshold
+	}
+	return t
+}
+
+// Validate checks that scores reach review before deny, defaults included
+func (t Thresholds) Validate() error {
+	if t.Review < 0 || t.Deny < 0 {
+		return errors.New("thresholds must not be negative")
+	}
+	if effective := t.withDefaults(); effective.Review >= effective.Deny {
+		return fmt.Errorf("review threshold %d must be below deny threshold %d", effective.Review, effective.Deny)
+	}
+	return nil
+}
+
+// Engine screens payments against a set of rules that can be replaced at runtime
+type Engine struct {
+	history    History
+	mutex      sync.RWMutex
+	rules      []Rule
+	thresholds Thresholds
+}
+
+// NewEngine creates a new risk engine with the given rules
+func NewEngine(history History, thresholds Thresholds, rules ...Rule) *Engine {
+	engine := &Engine{history: history}
+	engine.Replace(thresholds, rules)
+	return engine
+}
+
+// Replace atomically swaps the active rules and thresholds
+func (e *Engine) Replace(thresholds Thresholds, rules []Rule) {
+	thresholds = thresholds.withDefaults()
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.rules = rules
+	e.thresholds = thresholds
+}
+
+// Rules returns the names of the active rules
+func (e *Engine) Rules() []string {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	names := make([]string, len(e.rules))
+	for i, rule := range e.rules {
+		names[i] = rule.Name()
+	}
+	return names
+}
+
+// Assess evaluates every active rule and combines the hits into an assessment
+func (e *Engine) Assess(ctx context.Context, payment *domain.Payment) (*domain.RiskAssessment, error) {
+	e.mutex.RLock()
+	rules := e.rules
+	thresholds := e.thresholds
+	e.mutex.RUnlock()
+
+	var hits []*Hit
+	for _, rule := range rules {
+		hit, err := rule.Evaluate(ctx, payment, e.history)
+		if err != nil {
+			return nil, err
+		}
+		if hit != nil {
+			hit.Rule = rule.Name()
+			hits = append(hits, hit)
+		}
+	}
+
+	return decide(hits, thresholds), nil
+}
+
+// decide turns rule hits into a scored decision
+func decide(hits []*Hit, thresholds Thresholds) *domain.RiskAssessment {
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Score > hits[j].Score
+	})
+
+	assessment := &domain.RiskAssessment{
+		Decision: domain.RiskDecisionAllow,
+		Reasons:  make([]string, 0, len(hits)),
+	}
+
+	forceDeny := false
+	for _, hit := range hits {
+		assessment.Score += hit.Score
+		assessment.Reasons = append(assessment.Reasons, hit.Rule+": "+hit.Reason)
+		if hit.Deny {
+			forceDeny = true
+		}
+	}
+
+	if assessment.Score > 100 {
+		assessment.Score = 100
+	}
+
+	switch {
+	case forceDeny || assessment.Score >= thresholds.Deny:
+		assessment.Decision = domain.RiskDecisionDeny
+	case assessment.Score >= thresholds.Review:
+		assessment.Decision = domain.RiskDecisionReview
+	}
+
+	return assessment
+}
@@ -0,0 +1,147 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"payments_app/internal/domain"
+	"strings"
+	"time"
+)
+
+// VelocityRule flags payers that create too many payments within a time window
+type VelocityRule struct {
+	RuleName string
+	MaxCount int
+	Window   time.Duration
+	Score    int
+}
+
+// Name returns the rule name
+func (r *VelocityRule) Name() string { return r.RuleName }
+
+// Evaluate counts the payer's recent payments, including the one being screened
+func (r *VelocityRule) Evaluate(ctx context.Context, payment *domain.Payment, history History) (*Hit, error) {
+	if payment.PayerID == "" || history == nil {
+		return nil, nil
+	}
+
+	recent, err := history.PayerPayments(ctx, payment.PayerID, payment.CreatedAt.Add(-r.Window))
+	if err != nil {
+		return nil, err
+	}
+
+	count := len(recent) + 1
+	if count <= r.MaxCount {
+		return nil, nil
+	}
+
+	return &Hit{
+		Score:  r.Score,
+		Reason: fmt.Sprintf("%d payments by payer within %s (limit %d)", count, r.Window, r.MaxCount),
+	}, nil
+}
+
+// AmountSpikeRule flags payments far above the payer's rolling average amount
+type AmountSpikeRule struct {
+	RuleName   string
+	Multiplier float64
+	Lookback   time.Duration
+	MinHistory int
+	Score      int
+}
+
+// Name returns the rule name
+func (r *AmountSpikeRule) Name() string { return r.RuleName }
+
+// Evaluate compares the amount with the average of same-currency payments in the lookback window
+func (r *AmountSpikeRule) Evaluate(ctx context.Context, payment *domain.Payment, history History) (*Hit, error) {
+	if payment.PayerID == "" || history == nil {
+		return nil, nil
+	}
+
+	recent, err := history.PayerPayments(ctx, payment.PayerID, payment.CreatedAt.Add(-r.Lookback))
+	if err != nil {
+		return nil, err
+	}
+
+	var total float64
+	var count int
+	for _, previous := range recent {
+		if previous.Currency != payment.Currency {
+			continue
+		}
+		total += previous.Amount
+		count++
+	}
+
+	if count == 0 || count < r.MinHistory {
+		return nil, nil
+	}
+
+	average := total / float64(count)
+	if payment.Amount <= average*r.Multiplier {
+		return nil, nil
+	}
+
+	return &Hit{
+		Score:  r.Score,
+		Reason: fmt.Sprintf("amount %.2f exceeds %.1fx rolling average %.2f", payment.Amount, r.Multiplier, average),
+	}, nil
+}
+
+// BlockedCurrencyRule flags payments in currencies that must not be processed
+type BlockedCurrencyRule struct {
+	RuleName   string
+	Currencies map[string]bool
+	Score      int
+	Deny       bool
+}
+
+// Name returns the rule name
+func (r *BlockedCurrencyRule) Name() string { return r.RuleName }
+
+// Evaluate checks the payment currency against the block list
+func (r *BlockedCurrencyRule) Evaluate(ctx context.Context, payment *domain.Payment, history History) (*Hit, error) {
+	if !r.Currencies[payment.Currency] {
+		return nil, nil
+	}
+
+	return &Hit{
+		Score:  r.Score,
+		Deny:   r.Deny,
+		Reason: fmt.Sprintf("currency %s is blocked", payment.Currency),
+	}, nil
+}
+
+// KeywordRule flags payments whose description contains listed keywords
+type KeywordRule struct {
+	RuleName string
+	Keywords []string
+	Score    int
+	Deny     bool
+}
+
+// Name returns the rule name
+func (r *KeywordRule) Name() string { return r.RuleName }
+
+// Evaluate performs a case-insensitive keyword search over the description
+func (r *KeywordRule) Evaluate(ctx context.Context, payment *domain.Payment, history History) (*Hit, error) {
+	description := strings.ToLower(payment.Description)
+
+	var matched []string
+	for _, keyword := range r.Keywords {
+		if strings.Contains(description, keyword) {
+			matched = append(matched, keyword)
+		}
+	}
+
+	if len(matched) == 0 {
+		return nil, nil
+	}
+
+	return &Hit{
+		Score:  r.Score,
+		Deny:   r.Deny,
+		Reason: "description contains " + strings.Join(matched, ", "),
+	}, nil
+}
@@ -0,0 +1,147 @@
+package routing
+
+import (
+	"payments_app/internal/domain"
+	"sync"
+	"time"
+)
+
+// BreakerSettings configures when a processor's circuit trips
+type BreakerSettings struct {
+	// Window is the number of recent calls considered
+	Window int `yaml:"window"`
+	// MinCalls is the number of calls in the window needed before the circuit can trip
+	MinCalls int `yaml:"min_calls"`
+	// ErrorRate is the share of failed calls in the window that trips the circuit
+	ErrorRate float64 `yaml:"error_rate"`
+	// Cooldown is how long the circuit stays open before a trial call is allowed
+	Cooldown time.Duration `yaml:"cooldown"`
+}
+
+// DefaultBreakerSettings are used for settings left at zero
+var DefaultBreakerSettings = BreakerSettings{
+	Window:    20,
+	MinCalls:  5,
+	ErrorRate: 0.5,
+	Cooldown:  30 * time.Second,
+}
+
+// withDefaults fills zero settings from DefaultBreakerSettings
+func (s BreakerSettings) withDefaults() BreakerSettings {
+	if s.Window <= 0 {
+		s.Window = DefaultBreakerSettings.Window
+	}
+	if s.MinCalls <= 0 {
+		s.MinCalls = DefaultBreakerSettings.MinCalls
+	}
+	if s.ErrorRate <= 0 {
+		s.ErrorRate = DefaultBreakerSettings.ErrorRate
+	}
+	if s.Cooldown <= 0 {
+		s.Cooldown = DefaultBreakerSettings.Cooldown
+	}
+	return s
+}
+
+// Breaker is a circuit breaker over a sliding window of call results.
+// Once open it rejects calls until the cooldown has passed, then lets a single
+// trial call through: success closes the circuit, failure opens it again.
+type Breaker struct {
+	settings BreakerSettings
+
+	mutex    sync.Mutex
+	state    domain.CircuitState
+	results  []bool
+	next     int
+	filled   int
+	openedAt time.Time
+	trialing bool
+}
+
+// NewBreaker creates a closed circuit breaker
+func NewBreaker(settings BreakerSettings) *Breaker {
+	settings = settings.withDefaults()
+	return &Breaker{
+		settings: settings,
+		state:    domain.CircuitClosed,
+		results:  make([]bool, settings.Window),
+	}
+}
+
+// Allow reports whether a call may be attempted now
+func (b *Breaker) Allow() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch b.state {
+	case domain.CircuitOpen:
+		if time.Now().Sub(b.openedAt) < b.settings.Cooldown {
+			return false
+		}
+		b.state = domain.CircuitHalfOpen
+		b.trialing = true
+		return true
+	case domain.CircuitHalfOpen:
+		if b.trialing {
+			return false
+		}
+		b.trialing = true
+		return true
+	}
+	return true
+}
+
+// Record adds the result of a call; failed means the processor errored, not that it declined
+func (b *Breaker) Record(failed bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.state == domain.CircuitHalfOpen {
+		b.trialing = false
+		if failed {
+			b.trip()
+		} else {
+			b.reset()
+		}
+		return
+	}
+
+	b.results[b.next] = failed
+	b.next = (b.next + 1) % len(b.results)
+	if b.filled < len(b.results) {
+		b.filled++
+	}
+
+	if b.state == domain.CircuitClosed && b.filled >= b.settings.MinCalls {
+		failures := 0
+		for i := 0; i < b.filled; i++ {
+			if b.results[i] {
+				failures++
+			}
+		}
+		if float64(failures)/float64(b.filled) >= b.settings.ErrorRate {
+			b.trip()
+		}
+	}
+}
+
+// State returns the current circuit state
+func (b *Breaker) State() domain.CircuitState {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.state == domain.CircuitOpen && time.Now().Sub(b.openedAt) >= b.settings.Cooldown {
+		return domain.CircuitHalfOpen
+	}
+	return b.state
+}
+
+func (b *Breaker) trip() {
+	b.state = domain.CircuitOpen
+	b.openedAt = time.Now()
+}
+
+func (b *Breaker) reset() {
+	b.state = domain.CircuitClosed
+	b.next, b.filled = 0, 0
+}
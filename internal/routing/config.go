@@ -0,0 +1,48 @@
+package routing
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the YAML representation of a routing file
+type Config struct {
+	Processors []ProcessorConfig `yaml:"processors"`
+	Default    []string          `yaml:"default"`
+	Breaker    BreakerSettings   `yaml:"breaker"`
+	Rules      []Rule            `yaml:"rules"`
+}
+
+// ProcessorConfig describes a processor connection; fields are interpreted per type
+type ProcessorConfig struct {
+	Name    string        `yaml:"name"`
+	Type    string        `yaml:"type"`
+	Latency time.Duration `yaml:"latency"`
+	URL     string        `yaml:"url"`
+	// APIKeyEnv names the environment variable holding the API key, keeping secrets out of the file
+	APIKeyEnv string `yaml:"api_key_env"`
+	// WebhookSecretEnv names the environment variable holding the callback signing secret
+	WebhookSecretEnv string        `yaml:"webhook_secret_env"`
+	Timeout          time.Duration `yaml:"timeout"`
+}
+
+// LoadConfig reads and parses a YAML routing file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid routing file %s: %w", path, err)
+	}
+	if len(cfg.Processors) == 0 {
+		return nil, fmt.Errorf("routing file %s defines no processors", path)
+	}
+
+	return &cfg, nil
+}
@@ -0,0 +1,270 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payments_app/internal/domain"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rule sends matching payments to an ordered list of processors.
+// Empty criteria match everything; MaxAmount of 0 means no upper bound.
+type Rule struct {
+	Name       string                     `yaml:"name"`
+	Currencies []string                   `yaml:"currencies"`
+	Methods    []domain.PaymentMethodType `yaml:"methods"`
+	Tenants    []string                   `yaml:"tenants"`
+	MinAmount  float64                    `yaml:"min_amount"`
+	MaxAmount  float64                    `yaml:"max_amount"`
+	Processors []string                   `yaml:"processors"`
+}
+
+// Matches reports whether the payment falls under the rule
+func (r Rule) Matches(payment *domain.Payment) bool {
+	if len(r.Currencies) > 0 && !containsFold(r.Currencies, payment.Currency) {
+		return false
+	}
+	if len(r.Tenants) > 0 && !containsFold(r.Tenants, payment.TenantID) {
+		return false
+	}
+	if len(r.Methods) > 0 {
+		if payment.Method == nil {
+			return false
+		}
+		found := false
+		for _, method := range r.Methods {
+			if method == payment.Method.MethodType() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if payment.Amount < r.MinAmount {
+		return false
+	}
+	if r.MaxAmount > 0 && payment.Amount >= r.MaxAmount {
+		return false
+	}
+	return true
+}
+
+// Router picks a processor per payment from the first matching rule and fails over
+// to the next eligible processor when one is unavailable or its circuit is open
+type Router struct {
+	mutex      sync.RWMutex
+	processors map[string]*trackedProcessor
+	order      []string
+	defaults   []string
+	rules      []Rule
+}
+
+// NewRouter creates a router over the given processors. Payments matching no rule use
+// cfg.Default, or every processor in the order given when no default is configured.
+func NewRouter(cfg Config, processors ...domain.Processor) (*Router, error) {
+	if len(processors) == 0 {
+		return nil, errors.New("router needs at least one processor")
+	}
+
+	router := &Router{processors: make(map[string]*trackedProcessor)}
+	for _, processor := range processors {
+		name := processor.Name()
+		if _, exists := router.processors[name]; exists {
+			return nil, fmt.Errorf("duplicate processor name %q", name)
+		}
+		router.processors[name] = &trackedProcessor{Processor: processor, breaker: NewBreaker(cfg.Breaker)}
+		router.order = append(router.order, name)
+	}
+
+	router.defaults = cfg.Default
+	if len(router.defaults) == 0 {
+		router.defaults = router.order
+	}
+	if err := router.checkNames("default route", router.defaults); err != nil {
+		return nil, err
+	}
+	for i, rule := range cfg.Rules {
+		if len(rule.Processors) == 0 {
+			return nil, fmt.Errorf("routing rule %d (%s) lists no processors", i+1, rule.Name)
+		}
+		if err := router.checkNames("routing rule "+rule.Name, rule.Processors); err != nil {
+			return nil, err
+		}
+	}
+	router.rules = cfg.Rules
+
+	return router, nil
+}
+
+// Processors returns the router's processors, instrumented so every call counts towards their statistics
+func (r *Router) Processors() []domain.Processor {
+	processors := make([]domain.Processor, len(r.order))
+	for i, name := range r.order {
+		processors[i] = r.processors[name]
+	}
+	return processors
+}
+
+// Authorize tries the eligible processors in order until one answers. Only unavailability
+// causes a failover: declines are final and other errors may mean the request got through.
+func (r *Router) Authorize(ctx context.Context, payment *domain.Payment, req domain.AuthorizeRequest) (domain.Processor, *domain.ProcessorResult, []domain.RouteAttempt, error) {
+	ruleName, candidates := r.candidates(payment)
+
+	var attempts []domain.RouteAttempt
+	for _, name := range candidates {
+		processor := r.processors[name]
+		attempt := domain.RouteAttempt{Processor: name, Rule: ruleName}
+
+		if !processor.breaker.Allow() {
+			attempt.Error = "circuit open"
+			attempts = append(attempts, attempt)
+			continue
+		}
+
+		start := time.Now()
+		result, err := processor.authorize(ctx, req)
+		attempt.LatencyMs = time.Since(start).Milliseconds()
+		if err != nil {
+			attempt.Error = err.Error()
+			attempts = append(attempts, attempt)
+			if errors.Is(err, domain.ErrProcessorUnavailable) && ctx.Err() == nil {
+				continue
+			}
+			return nil, nil, attempts, err
+		}
+
+		attempt.Outcome = result.Outcome
+		attempts = append(attempts, attempt)
+		return processor, result, attempts, nil
+	}
+
+	return nil, nil, attempts, fmt.Errorf("%w: no eligible processor could take the payment", domain.ErrProcessorUnavailable)
+}
+
+// Stats returns the health of every processor
+func (r *Router) Stats() []domain.ProcessorStats {
+	stats := make([]domain.ProcessorStats, len(r.order))
+	for i, name := range r.order {
+		stats[i] = r.processors[name].stats()
+	}
+	return stats
+}
+
+// candidates returns the matching rule's name and its processors, or the default route
+func (r *Router) candidates(payment *domain.Payment) (string, []string) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, rule := range r.rules {
+		if rule.Matches(payment) {
+			return rule.Name, rule.Processors
+		}
+	}
+	return "", r.defaults
+}
+
+// checkNames verifies that every name refers to a known processor
+func (r *Router) checkNames(context string, names []string) error {
+	for _, name := range names {
+		if _, exists := r.processors[name]; !exists {
+			return fmt.Errorf("%s refers to unknown processor %q", context, name)
+		}
+	}
+	return nil
+}
+
+// trackedProcessor wraps a processor with its circuit breaker and call statistics
+type trackedProcessor struct {
+	domain.Processor
+	breaker *Breaker
+
+	mutex        sync.Mutex
+	calls        int
+	approvals    int
+	declines     int
+	errors       int
+	totalLatency time.Duration
+}
+
+func (p *trackedProcessor) Authorize(ctx context.Context, req domain.AuthorizeRequest) (*domain.ProcessorResult, error) {
+	return p.authorize(ctx, req)
+}
+
+func (p *trackedProcessor) Capture(ctx context.Context, reference string, amount float64) (*domain.ProcessorResult, error) {
+	return p.track(func() (*domain.ProcessorResult, error) { return p.Processor.Capture(ctx, reference, amount) })
+}
+
+func (p *trackedProcessor) Refund(ctx context.Context, reference string, amount float64) (*domain.ProcessorResult, error) {
+	return p.track(func() (*domain.ProcessorResult, error) { return p.Processor.Refund(ctx, reference, amount) })
+}
+
+func (p *trackedProcessor) Void(ctx context.Context, reference string) (*domain.ProcessorResult, error) {
+	return p.track(func() (*domain.ProcessorResult, error) { return p.Processor.Void(ctx, reference) })
+}
+
+func (p *trackedProcessor) GetStatus(ctx context.Context, reference string) (*domain.ProcessorResult, error) {
+	return p.track(func() (*domain.ProcessorResult, error) { return p.Processor.GetStatus(ctx, reference) })
+}
+
+func (p *trackedProcessor) authorize(ctx context.Context, req domain.AuthorizeRequest) (*domain.ProcessorResult, error) {
+	return p.track(func() (*domain.ProcessorResult, error) { return p.Processor.Authorize(ctx, req) })
+}
+
+// track times a call and records its result in the statistics and the circuit breaker
+func (p *trackedProcessor) track(call func() (*domain.ProcessorResult, error)) (*domain.ProcessorResult, error) {
+	start := time.Now()
+	result, err := call()
+	latency := time.Since(start)
+
+	failed := errors.Is(err, domain.ErrProcessorUnavailable)
+	p.breaker.Record(failed)
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.calls++
+	p.totalLatency += latency
+	switch {
+	case err != nil:
+		p.errors++
+	case result.Outcome == domain.ProcessorOutcomeDeclined:
+		p.declines++
+	default:
+		p.approvals++
+	}
+
+	return result, err
+}
+
+// stats returns a snapshot of the processor's statistics
+func (p *trackedProcessor) stats() domain.ProcessorStats {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	stats := domain.ProcessorStats{
+		Processor: p.Name(),
+		Calls:     p.calls,
+		Approvals: p.approvals,
+		Declines:  p.declines,
+		Errors:    p.errors,
+		Circuit:   p.breaker.State(),
+	}
+	if p.calls > 0 {
+		stats.SuccessRate = float64(p.approvals) / float64(p.calls)
+		stats.AverageLatency = p.totalLatency / time.Duration(p.calls)
+	}
+	return stats
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+// Package scheduler runs periodic background jobs
+package scheduler
+
+import (
+	"context"
+	"time"
+)
+
+// Job is one run of a periodic task; now is the time the run was started
+type Job func(ctx context.Context, now time.Time) error
+
+// Run calls job immediately and then every interval until ctx is cancelled. Errors are
+// passed to onError and do not stop the schedule; runs never overlap.
+func Run(ctx context.Context, interval time.Duration, job Job, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := job(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
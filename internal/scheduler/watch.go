@@ -0,0 +1,44 @@
+package scheduler
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// WatchFile polls a file every interval and calls reload whenever its modification time
+// changes, until ctx is cancelled. Stat and reload errors are passed to onError and do not
+// stop the watch. An interval of zero or less disables watching.
+func WatchFile(ctx context.Context, path string, interval time.Duration, reload func(path string) error, onError func(error)) {
+	if interval <= 0 {
+		return
+	}
+
+	var lastModified time.Time
+	if info, err := os.Stat(path); err == nil {
+		lastModified = info.ModTime()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(path)
+			if err != nil {
+				onError(err)
+				continue
+			}
+			if !info.ModTime().After(lastModified) {
+				continue
+			}
+			lastModified = info.ModTime()
+			if err := reload(path); err != nil {
+				onError(err)
+			}
+		}
+	}
+}
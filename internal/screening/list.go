@@ -0,0 +1,140 @@
+package screening
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Entry is a sanctioned person or organisation with its known names
+type Entry struct {
+	ID      string
+	List    string
+	Program string
+	Names   []string
+}
+
+// List is an in-memory watchlist
+type List struct {
+	Entries []Entry
+}
+
+// LoadList reads a watchlist file; the format is chosen by the .csv or .xml extension
+func LoadList(path string) (*List, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return ParseCSV(file)
+	case ".xml":
+		return ParseXML(file)
+	default:
+		return nil, fmt.Errorf("unsupported watchlist format %q", filepath.Ext(path))
+	}
+}
+
+// ParseCSV parses a watchlist with the header id,name,aliases,list,program.
+// Aliases are separated by semicolons.
+func ParseCSV(r io.Reader) (*List, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("watchlist header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	if _, ok := columns["id"]; !ok {
+		return nil, errors.New("watchlist is missing the id column")
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, errors.New("watchlist is missing the name column")
+	}
+
+	field := func(record []string, column string) string {
+		if i, ok := columns[column]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	list := &List{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		entry := Entry{
+			ID:      field(record, "id"),
+			List:    field(record, "list"),
+			Program: field(record, "program"),
+		}
+		if name := field(record, "name"); name != "" {
+			entry.Names = append(entry.Names, name)
+		}
+		for _, alias := range strings.Split(field(record, "aliases"), ";") {
+			if alias = strings.TrimSpace(alias); alias != "" {
+				entry.Names = append(entry.Names, alias)
+			}
+		}
+		if entry.ID == "" || len(entry.Names) == 0 {
+			continue
+		}
+		list.Entries = append(list.Entries, entry)
+	}
+
+	return list, nil
+}
+
+// xmlWatchlist mirrors <watchlist name="..."><entry id="..." program="..."><name/><alias/></entry></watchlist>
+type xmlWatchlist struct {
+	Name    string `xml:"name,attr"`
+	Entries []struct {
+		ID      string   `xml:"id,attr"`
+		Program string   `xml:"program,attr"`
+		Names   []string `xml:"name"`
+		Aliases []string `xml:"alias"`
+	} `xml:"entry"`
+}
+
+// ParseXML parses a watchlist XML document
+func ParseXML(r io.Reader) (*List, error) {
+	var doc xmlWatchlist
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid watchlist XML: %w", err)
+	}
+
+	list := &List{}
+	for _, e := range doc.Entries {
+		entry := Entry{ID: strings.TrimSpace(e.ID), List: doc.Name, Program: e.Program}
+		for _, name := range append(e.Names, e.Aliases...) {
+			if name = strings.TrimSpace(name); name != "" {
+				entry.Names = append(entry.Names, name)
+			}
+		}
+		if entry.ID == "" || len(entry.Names) == 0 {
+			continue
+		}
+		list.Entries = append(list.Entries, entry)
+	}
+
+	return list, nil
+}
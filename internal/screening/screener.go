@@ -0,0 +1,116 @@
+package screening
+
+import (
+	"context"
+	"payments_app/internal/domain"
+	"sync"
+)
+
+// DefaultThreshold is the minimum Jaro-Winkler similarity reported as a hit
+const DefaultThreshold = 0.92
+
+// indexedName is a watchlist name prepared for matching
+type indexedName struct {
+	entry      *Entry
+	original   string
+	normalized string
+	sorted     string
+}
+
+// Screener matches payment counterparties against a watchlist
+type Screener struct {
+	threshold float64
+	mutex     sync.RWMutex
+	names     []indexedName
+}
+
+// NewScreener creates a screener over the given list
+func NewScreener(list *List, threshold float64) *Screener {
+	if threshold <= 0 || threshold > 1 {
+		threshold = DefaultThreshold
+	}
+	s := &Screener{threshold: threshold}
+	s.SetList(list)
+	return s
+}
+
+// SetList replaces the watchlist used for screening
+func (s *Screener) SetList(list *List) {
+	var names []indexedName
+	for i := range list.Entries {
+		entry := &list.Entries[i]
+		for _, name := range entry.Names {
+			normalized := Normalize(name)
+			if normalized == "" {
+				continue
+			}
+			names = append(names, indexedName{
+				entry:      entry,
+				original:   name,
+				normalized: normalized,
+				sorted:     sortedTokens(normalized),
+			})
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.names = names
+}
+
+// Match returns the best watchlist hit for a name, or nil when nothing reaches the threshold
+func (s *Screener) Match(name string) *domain.ScreeningHit {
+	normalized := Normalize(name)
+	if normalized == "" {
+		return nil
+	}
+	sorted := sortedTokens(normalized)
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var best *domain.ScreeningHit
+	for _, candidate := range s.names {
+		score := max(JaroWinkler(normalized, candidate.normalized), JaroWinkler(sorted, candidate.sorted))
+		if score < s.threshold || (best != nil && score <= best.Score) {
+			continue
+		}
+		best = &domain.ScreeningHit{
+			Name:        name,
+			MatchedName: candidate.original,
+			EntryID:     candidate.entry.ID,
+			List:        candidate.entry.List,
+			Score:       score,
+		}
+	}
+
+	return best
+}
+
+// Screen checks the payer and payee names of a payment
+func (s *Screener) Screen(ctx context.Context, payment *domain.Payment) (*domain.ScreeningResult, error) {
+	result := &domain.ScreeningResult{Status: domain.ScreeningStatusClear, Hits: []domain.ScreeningHit{}}
+
+	parties := []struct {
+		role  domain.PartyRole
+		party *domain.Party
+	}{
+		{domain.PartyRolePayer, payment.Payer},
+		{domain.PartyRolePayee, payment.Payee},
+	}
+	for _, p := range parties {
+		if p.party == nil {
+			continue
+		}
+		if hit := s.Match(p.party.Name); hit != nil {
+			hit.Role = p.role
+			result.Hits = append(result.Hits, *hit)
+		}
+	}
+
+	if len(result.Hits) > 0 {
+		result.Status = domain.ScreeningStatusHit
+	}
+
+	return result, nil
+}
@@ -0,0 +1,171 @@
+package tax
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"payments_app/internal/domain"
+	"payments_app/internal/scheduler"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the YAML representation of a tax rates file
+type Config struct {
+	// SellerCountry is where the seller is established; sales to businesses in other countries
+	// can be reverse charged
+	SellerCountry string               `yaml:"seller_country"`
+	Jurisdictions []JurisdictionConfig `yaml:"jurisdictions"`
+}
+
+// JurisdictionConfig holds the rules of one country. Rates are percentages by product
+// category; lines without a category use "standard".
+type JurisdictionConfig struct {
+	Country       string            `yaml:"country"`
+	Pricing       string            `yaml:"pricing"`
+	Rounding      string            `yaml:"rounding"`
+	RoundPer      string            `yaml:"round_per"`
+	ReverseCharge bool              `yaml:"reverse_charge"`
+	Rates         map[string]string `yaml:"rates"`
+}
+
+// LoadConfig reads and parses a YAML tax rates file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid tax rates file %s: %w", path, err)
+	}
+
+	return &cfg, nil
+}
+
+// BuildRates validates the configuration and builds its rate tables
+func BuildRates(cfg *Config) (*Rates, error) {
+	rates := &Rates{
+		SellerCountry: strings.ToUpper(strings.TrimSpace(cfg.SellerCountry)),
+		Jurisdictions: make(map[string]*Jurisdiction, len(cfg.Jurisdictions)),
+	}
+	if rates.SellerCountry != "" && !isCountryCode(rates.SellerCountry) {
+		return nil, fmt.Errorf("invalid seller country %q", cfg.SellerCountry)
+	}
+	for i, jurisdictionCfg := range cfg.Jurisdictions {
+		jurisdiction, err := buildJurisdiction(jurisdictionCfg)
+		if err != nil {
+			name := jurisdictionCfg.Country
+			if name == "" {
+				name = fmt.Sprint(i + 1)
+			}
+			return nil, fmt.Errorf("jurisdiction %s: %w", name, err)
+		}
+		if _, exists := rates.Jurisdictions[jurisdiction.Country]; exists {
+			return nil, fmt.Errorf("jurisdiction %s is configured twice", jurisdiction.Country)
+		}
+		if jurisdiction.ReverseCharge && rates.SellerCountry == "" {
+			return nil, fmt.Errorf("jurisdiction %s: reverse_charge requires seller_country", jurisdiction.Country)
+		}
+		rates.Jurisdictions[jurisdiction.Country] = jurisdiction
+	}
+	return rates, nil
+}
+
+// buildJurisdiction validates one jurisdiction
+func buildJurisdiction(cfg JurisdictionConfig) (*Jurisdiction, error) {
+	jurisdiction := &Jurisdiction{
+		Country:       strings.ToUpper(strings.TrimSpace(cfg.Country)),
+		Pricing:       domain.TaxPricing(strings.ToUpper(strings.TrimSpace(cfg.Pricing))),
+		Rounding:      Rounding(strings.ToUpper(strings.TrimSpace(cfg.Rounding))),
+		RoundPer:      RoundingLevel(strings.ToUpper(strings.TrimSpace(cfg.RoundPer))),
+		ReverseCharge: cfg.ReverseCharge,
+		Rates:         make(map[string]*big.Rat, len(cfg.Rates)),
+	}
+	if !isCountryCode(jurisdiction.Country) {
+		return nil, errors.New("country must be a 2-letter ISO code")
+	}
+	if jurisdiction.Pricing == "" {
+		jurisdiction.Pricing = domain.TaxPricingExclusive
+	}
+	if !jurisdiction.Pricing.IsValid() {
+		return nil, errors.New("pricing must be inclusive or exclusive")
+	}
+	switch jurisdiction.Rounding {
+	case "":
+		jurisdiction.Rounding = RoundHalfUp
+	case RoundHalfUp, RoundHalfEven, RoundDown:
+	default:
+		return nil, errors.New("rounding must be half_up, half_even or down")
+	}
+	switch jurisdiction.RoundPer {
+	case "":
+		jurisdiction.RoundPer = RoundPerLine
+	case RoundPerLine, RoundPerTotal:
+	default:
+		return nil, errors.New("round_per must be line or total")
+	}
+
+	for category, value := range cfg.Rates {
+		category = strings.ToLower(strings.TrimSpace(category))
+		rate, err := parseRate(value)
+		if err != nil {
+			return nil, fmt.Errorf("rate %s: %w", category, err)
+		}
+		jurisdiction.Rates[category] = rate
+	}
+	if _, ok := jurisdiction.Rates[StandardCategory]; !ok {
+		return nil, errors.New("a standard rate is required")
+	}
+	return jurisdiction, nil
+}
+
+// parseRate parses a percentage from 0 to 100
+func parseRate(value string) (*big.Rat, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.Trim(value, "0123456789.") != "" || strings.Count(value, ".") > 1 {
+		return nil, fmt.Errorf("%q is not a non-negative decimal", value)
+	}
+	rate, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a non-negative decimal", value)
+	}
+	if rate.Cmp(big.NewRat(100, 1)) > 0 {
+		return nil, errors.New("rate must not exceed 100")
+	}
+	return rate, nil
+}
+
+// isCountryCode reports whether code is two uppercase letters
+func isCountryCode(code string) bool {
+	return len(code) == 2 && strings.Trim(code, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") == ""
+}
+
+// Reload loads a tax rates file and swaps it into the engine; the old rates stay active on
+// error
+func (e *Engine) Reload(path string) error {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	rates, err := BuildRates(cfg)
+	if err != nil {
+		return err
+	}
+
+	e.Replace(rates)
+	return nil
+}
+
+// Watch polls a tax rates file and reloads the engine whenever its modification time changes.
+// Reload errors are passed to onError and the previous rates are kept. An interval of zero
+// or less disables watching.
+func (e *Engine) Watch(ctx context.Context, path string, interval time.Duration, onError func(error)) {
+	scheduler.WatchFile(ctx, path, interval, e.Reload, onError)
+}
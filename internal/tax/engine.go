@@ -0,0 +1,121 @@
+package tax
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"payments_app/internal/domain"
+	"strings"
+	"sync"
+)
+
+// Rates are the tax rules of every configured country
+type Rates struct {
+	SellerCountry string
+	Jurisdictions map[string]*Jurisdiction
+}
+
+// Engine calculates tax with rate tables that can be replaced at runtime
+type Engine struct {
+	mutex sync.RWMutex
+	rates *Rates
+}
+
+// NewEngine creates a tax engine with the given rates
+func NewEngine(rates *Rates) *Engine {
+	engine := &Engine{}
+	engine.Replace(rates)
+	return engine
+}
+
+// Replace atomically swaps the active rates
+func (e *Engine) Replace(rates *Rates) {
+	if rates == nil {
+		rates = &Rates{}
+	}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.rates = rates
+}
+
+// Rates returns the active rates
+func (e *Engine) Rates() *Rates {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return e.rates
+}
+
+// Calculate taxes the lines of a sale by the rules of the buyer's country. A sale to a
+// business whose VAT number is of a reverse charge country other than the seller's is zero
+// rated and marked as reverse charged.
+func (e *Engine) Calculate(request domain.TaxRequest) (*domain.TaxBreakdown, error) {
+	rates := e.Rates()
+	country := strings.ToUpper(strings.TrimSpace(request.Country))
+	if country == "" {
+		return nil, errors.New("tax country is required")
+	}
+	jurisdiction, ok := rates.Jurisdictions[country]
+	if !ok {
+		return nil, fmt.Errorf("no tax rates are configured for %s", country)
+	}
+
+	breakdown := &domain.TaxBreakdown{Country: country, Pricing: request.Pricing}
+	if breakdown.Pricing == "" {
+		breakdown.Pricing = jurisdiction.Pricing
+	}
+	if !breakdown.Pricing.IsValid() {
+		return nil, fmt.Errorf("invalid tax pricing %q", request.Pricing)
+	}
+	if strings.TrimSpace(request.VATNumber) != "" {
+		vatCountry, normalized, err := ValidateVATNumber(request.VATNumber)
+		if err != nil {
+			return nil, err
+		}
+		if vatCountry != country {
+			return nil, fmt.Errorf("VAT number %s was not issued in %s", normalized, country)
+		}
+		breakdown.VATNumber = normalized
+		breakdown.ReverseCharge = jurisdiction.ReverseCharge && country != rates.SellerCountry
+	}
+
+	if len(request.Lines) == 0 {
+		return nil, errors.New("at least one line is required")
+	}
+	currency := request.Lines[0].Amount.Currency
+	lines := make([]domain.TaxableLine, len(request.Lines))
+	lineRates := make([]*big.Rat, len(request.Lines))
+	for i, line := range request.Lines {
+		if line.Amount.Currency != currency {
+			return nil, errors.New("all lines must be in the same currency")
+		}
+		if line.Amount.MinorUnits < 0 {
+			return nil, errors.New("taxable amounts must not be negative")
+		}
+		category := strings.ToLower(strings.TrimSpace(line.Category))
+		if category == "" {
+			category = StandardCategory
+		}
+		rate, ok := jurisdiction.Rates[category]
+		if !ok {
+			return nil, fmt.Errorf("no %s tax rate is configured for %s", category, country)
+		}
+		if breakdown.ReverseCharge {
+			rate = new(big.Rat)
+		}
+		lines[i] = domain.TaxableLine{Category: category, Amount: line.Amount}
+		lineRates[i] = rate
+	}
+
+	taxLines, err := jurisdiction.calculate(lines, lineRates, breakdown.Pricing)
+	if err != nil {
+		return nil, err
+	}
+	breakdown.Lines = taxLines
+	breakdown.Net, breakdown.Tax, breakdown.Gross = domain.Money{Currency: currency}, domain.Money{Currency: currency}, domain.Money{Currency: currency}
+	for _, line := range taxLines {
+		breakdown.Net.MinorUnits += line.Net.MinorUnits
+		breakdown.Tax.MinorUnits += line.Tax.MinorUnits
+		breakdown.Gross.MinorUnits += line.Gross.MinorUnits
+	}
+	return breakdown, nil
+}
@@ -0,0 +1,142 @@
+// Package tax calculates VAT and GST from configurable rate tables.
+package tax
+
+import (
+	"fmt"
+	"math/big"
+	"payments_app/internal/domain"
+)
+
+// StandardCategory is the product category of lines that name none
+const StandardCategory = "standard"
+
+// Rounding is how a jurisdiction rounds tax to the minor unit
+type Rounding string
+
+const (
+	// RoundHalfUp rounds halves away from zero
+	RoundHalfUp Rounding = "HALF_UP"
+	// RoundHalfEven rounds halves to the even minor unit
+	RoundHalfEven Rounding = "HALF_EVEN"
+	// RoundDown truncates toward zero
+	RoundDown Rounding = "DOWN"
+)
+
+// RoundingLevel is where a jurisdiction rounds tax
+type RoundingLevel string
+
+const (
+	// RoundPerLine rounds the tax of each line
+	RoundPerLine RoundingLevel = "LINE"
+	// RoundPerTotal rounds the tax of all lines with the same rate once and shares it among them
+	RoundPerTotal RoundingLevel = "TOTAL"
+)
+
+// Jurisdiction holds the tax rules of one country
+type Jurisdiction struct {
+	Country string
+	// Pricing is used for requests that do not choose one
+	Pricing  domain.TaxPricing
+	Rounding Rounding
+	RoundPer RoundingLevel
+	// ReverseCharge zero-rates sales to businesses with a VAT number of this country when the
+	// seller is established elsewhere
+	ReverseCharge bool
+	// Rates are percentages by product category
+	Rates map[string]*big.Rat
+}
+
+// round rounds an exact amount in major units to a whole minor unit of currency
+func (r Rounding) round(amount *big.Rat, currency string) (domain.Money, error) {
+	units := new(big.Rat).Mul(amount, new(big.Rat).SetInt64(domain.MinorUnitScale(currency)))
+	quotient, remainder := new(big.Int).QuoRem(new(big.Int).Abs(units.Num()), units.Denom(), new(big.Int))
+	half := remainder.Lsh(remainder, 1).Cmp(units.Denom())
+	switch r {
+	case RoundHalfUp:
+		if half >= 0 {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	case RoundHalfEven:
+		if half > 0 || half == 0 && quotient.Bit(0) == 1 {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+	if units.Sign() < 0 {
+		quotient.Neg(quotient)
+	}
+	if !quotient.IsInt64() {
+		return domain.Money{}, fmt.Errorf("tax in %s is out of range", currency)
+	}
+	return domain.Money{MinorUnits: quotient.Int64(), Currency: currency}, nil
+}
+
+// taxOn is the exact tax in an amount at rate percent: added to a net amount, or contained
+// in a gross one
+func taxOn(amount domain.Money, rate *big.Rat, pricing domain.TaxPricing) *big.Rat {
+	tax := new(big.Rat).SetFrac64(amount.MinorUnits, domain.MinorUnitScale(amount.Currency))
+	tax.Mul(tax, rate)
+	if pricing == domain.TaxPricingInclusive {
+		return tax.Quo(tax, new(big.Rat).Add(rate, big.NewRat(100, 1)))
+	}
+	return tax.Quo(tax, big.NewRat(100, 1))
+}
+
+// calculate taxes one or more lines of one currency at the given rates, rounding by the
+// jurisdiction's rules
+func (j *Jurisdiction) calculate(lines []domain.TaxableLine, rates []*big.Rat, pricing domain.TaxPricing) ([]domain.TaxLine, error) {
+	currency := lines[0].Amount.Currency
+	taxes := make([]domain.Money, len(lines))
+	if j.RoundPer == RoundPerTotal {
+		// Lines with the same rate share their rounded total tax in proportion to their amounts
+		groups := make(map[string][]int)
+		var order []string
+		for i, rate := range rates {
+			key := rate.RatString()
+			if _, seen := groups[key]; !seen {
+				order = append(order, key)
+			}
+			groups[key] = append(groups[key], i)
+		}
+		for _, key := range order {
+			members := groups[key]
+			total := domain.Money{Currency: currency}
+			weights := make([]*big.Rat, len(members))
+			for k, i := range members {
+				total.MinorUnits += lines[i].Amount.MinorUnits
+				weights[k] = big.NewRat(lines[i].Amount.MinorUnits, 1)
+			}
+			tax, err := j.Rounding.round(taxOn(total, rates[members[0]], pricing), currency)
+			if err != nil {
+				return nil, err
+			}
+			for k, share := range tax.Allocate(weights...) {
+				taxes[members[k]] = share
+			}
+		}
+	} else {
+		for i, line := range lines {
+			tax, err := j.Rounding.round(taxOn(line.Amount, rates[i], pricing), currency)
+			if err != nil {
+				return nil, err
+			}
+			taxes[i] = tax
+		}
+	}
+
+	result := make([]domain.TaxLine, len(lines))
+	for i, line := range lines {
+		result[i] = domain.TaxLine{
+			Category: line.Category,
+			Rate:     domain.FormatDecimal(rates[i]),
+			Tax:      taxes[i],
+		}
+		if pricing == domain.TaxPricingInclusive {
+			result[i].Gross = line.Amount
+			result[i].Net = domain.Money{MinorUnits: line.Amount.MinorUnits - taxes[i].MinorUnits, Currency: currency}
+		} else {
+			result[i].Net = line.Amount
+			result[i].Gross = domain.Money{MinorUnits: line.Amount.MinorUnits + taxes[i].MinorUnits, Currency: currency}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,76 @@
+package tax
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// vatFormats are the formats of VAT numbers after the country prefix. The prefix is the ISO
+// country code except for Greece (EL) and Northern Ireland (XI).
+var vatFormats = map[string]*regexp.Regexp{
+	"AT": regexp.MustCompile(`^U\d{8}$`),
+	"BE": regexp.MustCompile(`^[01]\d{9}$`),
+	"BG": regexp.MustCompile(`^\d{9,10}$`),
+	"CY": regexp.MustCompile(`^\d{8}[A-Z]$`),
+	"CZ": regexp.MustCompile(`^\d{8,10}$`),
+	"DE": regexp.MustCompile(`^\d{9}$`),
+	"DK": regexp.MustCompile(`^\d{8}$`),
+	"EE": regexp.MustCompile(`^\d{9}$`),
+	"EL": regexp.MustCompile(`^\d{9}$`),
+	"ES": regexp.MustCompile(`^[A-Z0-9]\d{7}[A-Z0-9]$`),
+	"FI": regexp.MustCompile(`^\d{8}$`),
+	"FR": regexp.MustCompile(`^[A-HJ-NP-Z0-9]{2}\d{9}$`),
+	"HR": regexp.MustCompile(`^\d{11}$`),
+	"HU": regexp.MustCompile(`^\d{8}$`),
+	"IE": regexp.MustCompile(`^(\d{7}[A-W][A-I]?|\d[A-Z+*]\d{5}[A-W])$`),
+	"IT": regexp.MustCompile(`^\d{11}$`),
+	"LT": regexp.MustCompile(`^(\d{9}|\d{12})$`),
+	"LU": regexp.MustCompile(`^\d{8}$`),
+	"LV": regexp.MustCompile(`^\d{11}$`),
+	"MT": regexp.MustCompile(`^\d{8}$`),
+	"NL": regexp.MustCompile(`^\d{9}B\d{2}$`),
+	"PL": regexp.MustCompile(`^\d{10}$`),
+	"PT": regexp.MustCompile(`^\d{9}$`),
+	"RO": regexp.MustCompile(`^[1-9]\d{1,9}$`),
+	"SE": regexp.MustCompile(`^\d{10}01$`),
+	"SI": regexp.MustCompile(`^\d{8}$`),
+	"SK": regexp.MustCompile(`^\d{10}$`),
+	"XI": regexp.MustCompile(`^(\d{9}|\d{12}|GD[0-4]\d{2}|HA[5-9]\d{2})$`),
+	"GB": regexp.MustCompile(`^(\d{9}|\d{12}|GD[0-4]\d{2}|HA[5-9]\d{2})$`),
+	"CH": regexp.MustCompile(`^E\d{9}(MWST|TVA|IVA)?$`),
+	"NO": regexp.MustCompile(`^\d{9}(MVA)?$`),
+}
+
+// vatCountries maps VAT prefixes that are not ISO country codes to the country
+var vatCountries = map[string]string{"EL": "GR", "XI": "GB"}
+
+// ValidateVATNumber checks the format of a VAT number such as "DE 123 456 789", without
+// asking the tax authority whether it is registered. It returns the ISO code of the issuing
+// country and the number without spaces, dots or dashes.
+func ValidateVATNumber(number string) (country, normalized string, err error) {
+	normalized = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '.', '-':
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(number)))
+	if len(normalized) < 4 {
+		return "", "", fmt.Errorf("VAT number %q is too short", number)
+	}
+
+	prefix := normalized[:2]
+	format, ok := vatFormats[prefix]
+	if !ok {
+		return "", "", fmt.Errorf("VAT number %q does not start with a supported country prefix", number)
+	}
+	if !format.MatchString(normalized[2:]) {
+		return "", "", fmt.Errorf("VAT number %q is not in the format of %s", number, prefix)
+	}
+	country = prefix
+	if iso, ok := vatCountries[prefix]; ok {
+		country = iso
+	}
+	return country, normalized, nil
+}
@@ -0,0 +1,221 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payments_app/internal/domain"
+	"strings"
+	"time"
+)
+
+// ErrACHNotConfigured is returned when ACH files are used without an originator
+var ErrACHNotConfigured = errors.New("ACH files are not enabled")
+
+// fileIDModifiers are the file ID modifiers of the files created on one day, in order
+const fileIDModifiers = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// ACHEncoder writes NACHA files
+type ACHEncoder interface {
+	// Transferable reports whether the encoder can send the payment as an ACH entry
+	Transferable(payment *domain.Payment) bool
+	// Encode writes the file for the payments, numbering trace numbers on from sequence, and
+	// fills in the file's totals and entries
+	Encode(file *domain.ACHFile, payments []*domain.Payment, sequence int) ([]byte, error)
+}
+
+// WithACH enables NACHA files for pending ACH transfers and the processing of their returns
+func WithACH(repo domain.ACHRepository, encoder ACHEncoder) Option {
+	return func(uc *PaymentUseCase) {
+		uc.ach = repo
+		uc.achEncoder = encoder
+	}
+}
+
+// CreateACHFile batches the pending ACH transfers into one NACHA file. The file is stored,
+// and its payments become SUBMITTED with the file's ID.
+func (uc *PaymentUseCase) CreateACHFile(ctx context.Context) (*domain.ACHFile, error) {
+	if uc.ach == nil {
+		return nil, ErrACHNotConfigured
+	}
+	pending, err := uc.ach.PendingACHTransfers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var payments []*domain.Payment
+	for _, payment := range pending {
+		if uc.achEncoder.Transferable(payment) {
+			payments = append(payments, payment)
+		}
+	}
+	if len(payments) == 0 {
+		return nil, domain.ErrNoACHTransfers
+	}
+
+	now := time.Now()
+	day := time.Date(now.UTC().Year(), now.UTC().Month(), now.UTC().Day(), 0, 0, 0, 0, time.UTC)
+	filesToday, err := uc.ach.FilesCreatedSince(ctx, day)
+	if err != nil {
+		return nil, err
+	}
+	if filesToday >= len(fileIDModifiers) {
+		return nil, fmt.Errorf("at most %d ACH files can be created per day", len(fileIDModifiers))
+	}
+	sequence, err := uc.ach.LastTraceSequence(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &domain.ACHFile{
+		ID:         newFileID("ACH", now),
+		CreatedAt:  now,
+		IDModifier: string(fileIDModifiers[filesToday]),
+	}
+	if file.Document, err = uc.achEncoder.Encode(file, payments, sequence); err != nil {
+		return nil, err
+	}
+	if err := uc.ach.CreateFile(ctx, file); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// GetACHFile retrieves a stored ACH file by ID
+func (uc *PaymentUseCase) GetACHFile(ctx context.Context, id string) (*domain.ACHFile, error) {
+	if uc.ach == nil {
+		return nil, ErrACHNotConfigured
+	}
+	if id == "" {
+		return nil, errors.New("file ID is required")
+	}
+	return uc.ach.GetFile(ctx, id)
+}
+
+// ACHReturnStatus is the outcome of one returned entry
+type ACHReturnStatus string
+
+const (
+	// ACHReturnStatusApplied means the payment failed with the return reason
+	ACHReturnStatusApplied ACHReturnStatus = "APPLIED"
+	// ACHReturnStatusDuplicate means the payment had failed already, for example because the
+	// return file was processed before
+	ACHReturnStatusDuplicate ACHReturnStatus = "DUPLICATE"
+	// ACHReturnStatusUnmatched means no entry was sent with the original trace number
+	ACHReturnStatusUnmatched ACHReturnStatus = "UNMATCHED"
+	// ACHReturnStatusRejected means the return does not fit its payment's amount or status
+	ACHReturnStatusRejected ACHReturnStatus = "REJECTED"
+)
+
+// ACHReturnResult reports what happened to one returned entry
+type ACHReturnResult struct {
+	Return    domain.ACHReturn `json:"return"`
+	Status    ACHReturnStatus  `json:"status"`
+	PaymentID string           `json:"paymentId,omitempty"`
+	Error     string           `json:"error,omitempty"`
+}
+
+// ACHReturnReport is the report of a return file, with one result per return in file order
+type ACHReturnReport struct {
+	Total     int               `json:"total"`
+	Applied   int               `json:"applied"`
+	Duplicate int               `json:"duplicate"`
+	Unmatched int               `json:"unmatched"`
+	Rejected  int               `json:"rejected"`
+	Returns   []ACHReturnResult `json:"returns"`
+}
+
+// ApplyACHReturns fails the payments of returned entries, found by the trace numbers they were
+// sent with. A payment that had completed is refunded instead, so payouts that settled it deduct
+// the returned amount. The return code and reason are recorded as the payment's processor
+// response. Returns that were applied before are reported as duplicates, so a file can be
+// processed again.
+func (uc *PaymentUseCase) ApplyACHReturns(ctx context.Context, returns []domain.ACHReturn) (*ACHReturnReport, error) {
+	if uc.ach == nil {
+		return nil, ErrACHNotConfigured
+	}
+
+	report := &ACHReturnReport{Total: len(returns), Returns: make([]ACHReturnResult, len(returns))}
+	for i, returned := range returns {
+		result, err := uc.applyACHReturn(ctx, returned)
+		if err != nil {
+			return nil, err
+		}
+		switch result.Status {
+		case ACHReturnStatusApplied:
+			report.Applied++
+		case ACHReturnStatusDuplicate:
+			report.Duplicate++
+		case ACHReturnStatusUnmatched:
+			report.Unmatched++
+		case ACHReturnStatusRejected:
+			report.Rejected++
+		}
+		report.Returns[i] = result
+	}
+	return report, nil
+}
+
+// applyACHReturn applies one return; only storage failures are returned as errors
+func (uc *PaymentUseCase) applyACHReturn(ctx context.Context, returned domain.ACHReturn) (ACHReturnResult, error) {
+	result := ACHReturnResult{Return: returned}
+	entry, err := uc.ach.EntryByTraceNumber(ctx, returned.OriginalTraceNumber)
+	if errors.Is(err, domain.ErrACHEntryNotFound) {
+		result.Status = ACHReturnStatusUnmatched
+		result.Error = fmt.Sprintf("no entry was sent with trace number %s", returned.OriginalTraceNumber)
+		return result, nil
+	}
+	if err != nil {
+		return result, err
+	}
+	result.PaymentID = entry.PaymentID
+
+	payment, err := uc.repo.GetByID(ctx, entry.PaymentID)
+	if err != nil {
+		return result, err
+	}
+	response := &domain.ProcessorResult{Code: returned.Code, Message: returned.Reason}
+	if payment.Status == domain.PaymentStatusFailed ||
+		(payment.Status == domain.PaymentStatusRefunded && payment.ProcessorResponse == strings.TrimSpace(response.Code+" "+response.Message)) {
+		result.Status = ACHReturnStatusDuplicate
+		return result, nil
+	}
+	if amount := domain.MoneyFromFloat(payment.Amount, payment.Currency).MinorUnits; amount != returned.Amount {
+		result.Status = ACHReturnStatusRejected
+		result.Error = fmt.Sprintf("returned amount %d differs from the payment's %d cents", returned.Amount, amount)
+		return result, nil
+	}
+
+	// A completed payment was paid out already; the return gives the money back like a refund
+	target := domain.PaymentStatusFailed
+	var reversals []domain.BalanceEntry
+	if payment.Status == domain.PaymentStatusCompleted {
+		if payment.RefundedAmount > 0 {
+			result.Status = ACHReturnStatusRejected
+			result.Error = fmt.Sprintf("payment %s was partly refunded before it was returned", payment.ID)
+			return result, nil
+		}
+		target = domain.PaymentStatusRefunded
+	}
+	if err := payment.TransitionTo(target); err != nil {
+		result.Status = ACHReturnStatusRejected
+		result.Error = err.Error()
+		return result, nil
+	}
+	if target == domain.PaymentStatusRefunded {
+		payment.RefundedAmount = payment.Amount
+		reversals = reverseSplits(payment)
+	}
+
+	recordResponse(payment, response)
+	if err := uc.repo.Update(ctx, payment); err != nil {
+		return result, err
+	}
+	if err := uc.recordReversals(ctx, payment, reversals); err != nil {
+		return result, err
+	}
+	if err := uc.releaseInvoiceAllocations(ctx, payment); err != nil {
+		return result, err
+	}
+	result.Status = ACHReturnStatusApplied
+	return result, nil
+}
@@ -0,0 +1,275 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payments_app/internal/domain"
+)
+
+// MaxBulkRows caps how many payments one import may contain
+const MaxBulkRows = 5000
+
+// ErrorCode classifies rejected input and the failed rows of an import
+type ErrorCode string
+
+const (
+	ErrorCodeInvalidAmount      ErrorCode = "INVALID_AMOUNT"
+	ErrorCodeInvalidCurrency    ErrorCode = "INVALID_CURRENCY"
+	ErrorCodeInvalidDescription ErrorCode = "INVALID_DESCRIPTION"
+	ErrorCodeInvalidParty       ErrorCode = "INVALID_PARTY"
+	ErrorCodeInvalidMethod      ErrorCode = "INVALID_METHOD"
+	ErrorCodeInvalidExecuteAt   ErrorCode = "INVALID_EXECUTE_AT"
+	// ErrorCodeInvalidSettlementCurrency marks a settlement currency that cannot be converted to
+	ErrorCodeInvalidSettlementCurrency ErrorCode = "INVALID_SETTLEMENT_CURRENCY"
+	ErrorCodeInvalidSplits             ErrorCode = "INVALID_SPLITS"
+	ErrorCodeInvalidCustomer           ErrorCode = "INVALID_CUSTOMER"
+	ErrorCodeInvalidMetadata           ErrorCode = "INVALID_METADATA"
+	ErrorCodeInvalidTax                ErrorCode = "INVALID_TAX"
+	ErrorCodeInvalidFilter             ErrorCode = "INVALID_FILTER"
+	ErrorCodeInvalidGroupBy            ErrorCode = "INVALID_GROUP_BY"
+	ErrorCodeInvalidTimezone           ErrorCode = "INVALID_TIMEZONE"
+	// ErrorCodeInvalidRow marks a row that could not be parsed from the import file
+	ErrorCodeInvalidRow       ErrorCode = "INVALID_ROW"
+	ErrorCodeScreeningFailed  ErrorCode = "SCREENING_FAILED"
+	ErrorCodeCreateFailed     ErrorCode = "CREATE_FAILED"
+	ErrorCodeProcessingFailed ErrorCode = "PROCESSING_FAILED"
+	// ErrorCodeNotAttempted marks a valid row skipped because another row of an all-or-nothing import failed
+	ErrorCodeNotAttempted ErrorCode = "NOT_ATTEMPTED"
+)
+
+// InputError is a rejected input value; its message is the plain validation message
+type InputError struct {
+	Code ErrorCode
+	Err  error
+}
+
+func (e *InputError) Error() string { return e.Err.Error() }
+
+func (e *InputError) Unwrap() error { return e.Err }
+
+func inputError(code ErrorCode, err error) error {
+	return &InputError{Code: code, Err: err}
+}
+
+// BulkMode selects how an import treats invalid rows
+type BulkMode string
+
+const (
+	// BulkModeAllOrNothing creates no payment unless every row is valid
+	BulkModeAllOrNothing BulkMode = "ALL_OR_NOTHING"
+	// BulkModeBestEffort creates every valid row and reports the others
+	BulkModeBestEffort BulkMode = "BEST_EFFORT"
+)
+
+// BatchCreator stores several payments atomically; all-or-nothing imports need it
+type BatchCreator interface {
+	CreateBatch(ctx context.Context, payments []*domain.Payment) error
+}
+
+// BulkPaymentRow is one payment of an import file
+type BulkPaymentRow struct {
+	// Line is the line number in the import file, reported back with the row's result
+	Line  int
+	Input CreatePaymentInput
+	// Err is set when the row could not be parsed; Input is then ignored
+	Err error
+}
+
+// BulkRowStatus is the outcome of one imported row
+type BulkRowStatus string
+
+const (
+	BulkRowStatusCreated BulkRowStatus = "CREATED"
+	BulkRowStatusFailed  BulkRowStatus = "FAILED"
+	BulkRowStatusSkipped BulkRowStatus = "SKIPPED"
+)
+
+// BulkRowResult reports what happened to one imported row. A created row may still carry
+// PROCESSING_FAILED when the payment was stored but could not be handed to the processor.
+type BulkRowResult struct {
+	Line          int                  `json:"line"`
+	Status        BulkRowStatus        `json:"status"`
+	PaymentID     string               `json:"paymentId,omitempty"`
+	PaymentStatus domain.PaymentStatus `json:"paymentStatus,omitempty"`
+	ErrorCode     ErrorCode            `json:"errorCode,omitempty"`
+	Error         string               `json:"error,omitempty"`
+}
+
+// BulkResult is the report of an import, with one result per row in file order
+type BulkResult struct {
+	Mode    BulkMode        `json:"mode"`
+	Total   int             `json:"total"`
+	Created int             `json:"created"`
+	Failed  int             `json:"failed"`
+	Skipped int             `json:"skipped"`
+	Rows    []BulkRowResult `json:"rows"`
+}
+
+// BulkCreatePayments validates every row with the same rules as CreatePayment and creates
+// the valid ones. In ALL_OR_NOTHING mode a single invalid row leaves every other row
+// SKIPPED, and the payments are stored in one transaction.
+func (uc *PaymentUseCase) BulkCreatePayments(ctx context.Context, mode BulkMode, rows []BulkPaymentRow) (*BulkResult, error) {
+	if mode == "" {
+		mode = BulkModeBestEffort
+	}
+	if mode != BulkModeAllOrNothing && mode != BulkModeBestEffort {
+		return nil, fmt.Errorf("unsupported import mode %q", mode)
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("import contains no payments")
+	}
+	if len(rows) > MaxBulkRows {
+		return nil, fmt.Errorf("import contains %d payments; at most %d are allowed", len(rows), MaxBulkRows)
+	}
+	batch, canBatch := uc.repo.(BatchCreator)
+	if mode == BulkModeAllOrNothing && !canBatch {
+		return nil, errors.New("all-or-nothing imports are not supported by this store")
+	}
+
+	result := &BulkResult{Mode: mode, Total: len(rows), Rows: make([]BulkRowResult, len(rows))}
+	payments := make([]*domain.Payment, len(rows))
+	for i, row := range rows {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		result.Rows[i].Line = row.Line
+		if row.Err != nil {
+			result.Rows[i].fail(ErrorCodeInvalidRow, row.Err)
+			continue
+		}
+		payment, err := uc.preparePayment(ctx, row.Input)
+		if err != nil {
+			result.Rows[i].fail(errorCode(err, ErrorCodeInvalidRow), err)
+			continue
+		}
+		payments[i] = payment
+	}
+
+	if mode == BulkModeBestEffort {
+		for i, payment := range payments {
+			if payment == nil {
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			uc.importPayment(ctx, payment, &result.Rows[i])
+		}
+		return result.count(), nil
+	}
+
+	if result.count().Failed == 0 {
+		for i, payment := range payments {
+			if payment.Status != domain.PaymentStatusScheduled {
+				if err := uc.screenPayment(ctx, payment); err != nil {
+					result.Rows[i].fail(ErrorCodeScreeningFailed, err)
+					continue
+				}
+			}
+			// Charged before the batch is stored so the fees are saved with the payments
+			if err := uc.chargeFees(payment, domain.FeeEventCreated); err != nil {
+				result.Rows[i].fail(ErrorCodeCreateFailed, err)
+			}
+		}
+	}
+	if result.count().Failed > 0 {
+		for i, payment := range payments {
+			if payment != nil && result.Rows[i].Status == "" {
+				result.Rows[i].Status = BulkRowStatusSkipped
+				result.Rows[i].ErrorCode = ErrorCodeNotAttempted
+			}
+		}
+		return result.count(), nil
+	}
+
+	if err := batch.CreateBatch(ctx, payments); err != nil {
+		return nil, fmt.Errorf("failed to store payments: %w", err)
+	}
+	for i, payment := range payments {
+		result.Rows[i].created(payment)
+		if err := uc.postFees(ctx, payment, domain.FeeEventCreated); err != nil {
+			result.Rows[i].processingFailed(err)
+			continue
+		}
+		if payment.Status == domain.PaymentStatusScheduled {
+			continue
+		}
+		if err := uc.processNewPayment(ctx, payment); err != nil {
+			result.Rows[i].processingFailed(err)
+		}
+		result.Rows[i].PaymentStatus = payment.Status
+	}
+	return result.count(), nil
+}
+
+// importPayment stores one prepared payment of a best-effort import and records the outcome
+func (uc *PaymentUseCase) importPayment(ctx context.Context, payment *domain.Payment, row *BulkRowResult) {
+	if payment.Status != domain.PaymentStatusScheduled {
+		if err := uc.screenPayment(ctx, payment); err != nil {
+			row.fail(ErrorCodeScreeningFailed, err)
+			return
+		}
+	}
+	if err := uc.createPayment(ctx, payment); err != nil {
+		var stored *StoredPaymentError
+		if !errors.As(err, &stored) {
+			row.fail(ErrorCodeCreateFailed, err)
+			return
+		}
+		row.created(payment)
+		row.processingFailed(err)
+		return
+	}
+	row.created(payment)
+	if payment.Status == domain.PaymentStatusScheduled {
+		return
+	}
+	if err := uc.processNewPayment(ctx, payment); err != nil {
+		row.processingFailed(err)
+	}
+	row.PaymentStatus = payment.Status
+}
+
+func (r *BulkRowResult) fail(code ErrorCode, err error) {
+	r.Status = BulkRowStatusFailed
+	r.ErrorCode = code
+	r.Error = err.Error()
+}
+
+func (r *BulkRowResult) created(payment *domain.Payment) {
+	r.Status = BulkRowStatusCreated
+	r.PaymentID = payment.ID
+	r.PaymentStatus = payment.Status
+}
+
+// processingFailed marks a created row whose payment was stored but not fully processed
+func (r *BulkRowResult) processingFailed(err error) {
+	r.ErrorCode = ErrorCodeProcessingFailed
+	r.Error = err.Error()
+}
+
+// count recomputes the totals from the row results
+func (r *BulkResult) count() *BulkResult {
+	r.Created, r.Failed, r.Skipped = 0, 0, 0
+	for _, row := range r.Rows {
+		switch row.Status {
+		case BulkRowStatusCreated:
+			r.Created++
+		case BulkRowStatusFailed:
+			r.Failed++
+		case BulkRowStatusSkipped:
+			r.Skipped++
+		}
+	}
+	return r
+}
+
+// errorCode returns the code of an input error, or fallback for other errors
+func errorCode(err error, fallback ErrorCode) ErrorCode {
+	var input *InputError
+	if errors.As(err, &input) {
+		return input.Code
+	}
+	return fallback
+}
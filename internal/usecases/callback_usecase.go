@@ -0,0 +1,164 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payments_app/internal/domain"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultCallbackListLimit caps callback listings when no limit is given
+const DefaultCallbackListLimit = 50
+
+// ErrCallbacksNotConfigured is returned when callbacks are received without a callback store
+var ErrCallbacksNotConfigured = errors.New("processor callbacks are not enabled")
+
+// PaymentReferenceLookup finds payments by the reference their processor gave them
+type PaymentReferenceLookup interface {
+	GetByProcessorReference(ctx context.Context, processor, reference string) (*domain.Payment, error)
+}
+
+// WithCallbacks enables processor callbacks, persisting them in store
+func WithCallbacks(store domain.CallbackRepository, lookup PaymentReferenceLookup) Option {
+	return func(uc *PaymentUseCase) {
+		uc.callbacks = store
+		uc.references = lookup
+	}
+}
+
+// HandleProcessorCallback persists a verified processor callback and applies it to its payment.
+// Redelivered callbacks return ErrDuplicateCallback; callbacks that cannot be applied are
+// stored as FAILED so they can be replayed.
+func (uc *PaymentUseCase) HandleProcessorCallback(ctx context.Context, processor string, event domain.ProcessorEvent, payload []byte) (*domain.ProcessorCallback, error) {
+	if uc.callbacks == nil {
+		return nil, ErrCallbacksNotConfigured
+	}
+	if event.CallbackID == "" {
+		return nil, errors.New("callback ID is required")
+	}
+
+	callback := &domain.ProcessorCallback{
+		ID:         uuid.New().String(),
+		Processor:  processor,
+		Event:      event,
+		Payload:    string(payload),
+		ReceivedAt: time.Now(),
+	}
+	if err := uc.callbacks.Save(ctx, callback); err != nil {
+		return nil, err
+	}
+
+	return callback, uc.applyCallback(ctx, callback)
+}
+
+// ReplayProcessorCallback applies a stored callback again, e.g. after fixing the cause of a failure
+func (uc *PaymentUseCase) ReplayProcessorCallback(ctx context.Context, id string) (*domain.ProcessorCallback, error) {
+	if uc.callbacks == nil {
+		return nil, ErrCallbacksNotConfigured
+	}
+	if id == "" {
+		return nil, errors.New("callback ID is required")
+	}
+
+	callback, err := uc.callbacks.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if callback.Result == domain.CallbackResultApplied {
+		return nil, errors.New("callback has already been applied")
+	}
+
+	return callback, uc.applyCallback(ctx, callback)
+}
+
+// ListProcessorCallbacks returns recent callbacks, optionally for one processor
+func (uc *PaymentUseCase) ListProcessorCallbacks(ctx context.Context, processor string, limit int) ([]*domain.ProcessorCallback, error) {
+	if uc.callbacks == nil {
+		return nil, ErrCallbacksNotConfigured
+	}
+	if limit <= 0 {
+		limit = DefaultCallbackListLimit
+	}
+	return uc.callbacks.List(ctx, processor, limit)
+}
+
+// applyCallback moves the callback's payment to the reported status and records the outcome.
+// The returned error only reports failures to store the outcome.
+func (uc *PaymentUseCase) applyCallback(ctx context.Context, callback *domain.ProcessorCallback) error {
+	result, paymentID, applyErr := uc.applyEvent(ctx, callback.Processor, callback.Event)
+
+	now := time.Now()
+	callback.Result = result
+	callback.PaymentID = paymentID
+	callback.Error = ""
+	if applyErr != nil {
+		callback.Error = applyErr.Error()
+	}
+	callback.ProcessedAt = &now
+
+	return uc.callbacks.Update(ctx, callback)
+}
+
+// applyEvent applies a processor event to its payment through the state machine
+func (uc *PaymentUseCase) applyEvent(ctx context.Context, processor string, event domain.ProcessorEvent) (domain.CallbackResult, string, error) {
+	payment, err := uc.references.GetByProcessorReference(ctx, processor, event.Reference)
+	if err != nil {
+		return domain.CallbackResultFailed, "", fmt.Errorf("reference %s: %w", event.Reference, err)
+	}
+
+	if event.Type == domain.ProcessorEventChargeback {
+		if uc.disputes == nil {
+			return domain.CallbackResultIgnored, payment.ID, ErrDisputesNotConfigured
+		}
+		input := OpenDisputeInput{PaymentID: payment.ID, ReasonCode: event.Code}
+		if input.ReasonCode == "" {
+			input.ReasonCode = "chargeback"
+		}
+		if event.Amount > 0 {
+			input.Amount = &event.Amount
+		}
+		if _, err := uc.OpenDispute(ctx, input); err != nil {
+			return domain.CallbackResultFailed, payment.ID, err
+		}
+		return domain.CallbackResultApplied, payment.ID, nil
+	}
+	if event.Status == "" || event.Status == payment.Status {
+		return domain.CallbackResultIgnored, payment.ID, nil
+	}
+
+	from := payment.Status
+	if err := payment.TransitionTo(event.Status); err != nil {
+		return domain.CallbackResultFailed, payment.ID, err
+	}
+	captured, err := uc.chargeCapture(payment, from)
+	if err != nil {
+		return domain.CallbackResultFailed, payment.ID, err
+	}
+	var reversals []domain.BalanceEntry
+	if event.Status == domain.PaymentStatusRefunded {
+		payment.RefundedAmount = payment.Amount
+		reversals = reverseSplits(payment)
+	}
+	if event.Code != "" || event.Message != "" {
+		recordResponse(payment, &domain.ProcessorResult{Code: event.Code, Message: event.Message})
+	}
+
+	if err := uc.repo.Update(ctx, payment); err != nil {
+		return domain.CallbackResultFailed, payment.ID, err
+	}
+	if captured {
+		if err := uc.postCapture(ctx, payment); err != nil {
+			return domain.CallbackResultFailed, payment.ID, err
+		}
+	}
+	if err := uc.recordReversals(ctx, payment, reversals); err != nil {
+		return domain.CallbackResultFailed, payment.ID, err
+	}
+	if err := uc.releaseInvoiceAllocations(ctx, payment); err != nil {
+		return domain.CallbackResultFailed, payment.ID, err
+	}
+	return domain.CallbackResultApplied, payment.ID, nil
+}
@@ -0,0 +1,86 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"payments_app/internal/domain"
+	"time"
+)
+
+// ErrCreditTransfersNotConfigured is returned when credit transfer files are used without a debtor account
+var ErrCreditTransfersNotConfigured = errors.New("credit transfer files are not enabled")
+
+// CreditTransferEncoder writes payment initiation files
+type CreditTransferEncoder interface {
+	// Transferable reports whether the encoder can send the payment as a credit transfer
+	Transferable(payment *domain.Payment) bool
+	// Encode writes the document for the payments and fills in the file's totals
+	Encode(file *domain.CreditTransferFile, payments []*domain.Payment) ([]byte, error)
+}
+
+// WithCreditTransfers enables payment initiation files for pending bank transfers
+func WithCreditTransfers(repo domain.CreditTransferRepository, encoder CreditTransferEncoder) Option {
+	return func(uc *PaymentUseCase) {
+		uc.creditTransfers = repo
+		uc.transferEncoder = encoder
+	}
+}
+
+// CreateCreditTransferFile batches the pending credit transfers into one payment initiation file.
+// The file is stored, and its payments become SUBMITTED with the file's message ID.
+func (uc *PaymentUseCase) CreateCreditTransferFile(ctx context.Context) (*domain.CreditTransferFile, error) {
+	if uc.creditTransfers == nil {
+		return nil, ErrCreditTransfersNotConfigured
+	}
+	pending, err := uc.creditTransfers.PendingCreditTransfers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var payments []*domain.Payment
+	for _, payment := range pending {
+		if uc.transferEncoder.Transferable(payment) {
+			payments = append(payments, payment)
+		}
+	}
+	if len(payments) == 0 {
+		return nil, domain.ErrNoCreditTransfers
+	}
+
+	now := time.Now()
+	file := &domain.CreditTransferFile{
+		MessageID:  newFileID("CT", now),
+		CreatedAt:  now,
+		PaymentIDs: make([]string, len(payments)),
+	}
+	for i, payment := range payments {
+		file.PaymentIDs[i] = payment.ID
+	}
+	if file.Document, err = uc.transferEncoder.Encode(file, payments); err != nil {
+		return nil, err
+	}
+	if err := uc.creditTransfers.CreateFile(ctx, file); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// GetCreditTransferFile retrieves a stored credit transfer file by message ID
+func (uc *PaymentUseCase) GetCreditTransferFile(ctx context.Context, messageID string) (*domain.CreditTransferFile, error) {
+	if uc.creditTransfers == nil {
+		return nil, ErrCreditTransfersNotConfigured
+	}
+	if messageID == "" {
+		return nil, errors.New("message ID is required")
+	}
+	return uc.creditTransfers.GetFile(ctx, messageID)
+}
+
+// newFileID returns a file ID such as "CT20260302T101500-1a2b3c4d", unique per file and
+// within the 35 characters banks accept for message IDs
+func newFileID(prefix string, now time.Time) string {
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return prefix + now.UTC().Format("20060102T150405") + "-" + hex.EncodeToString(suffix)
+}
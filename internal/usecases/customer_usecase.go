@@ -0,0 +1,224 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"payments_app/internal/domain"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrCustomersNotConfigured is returned when customers are used without a customer store
+var ErrCustomersNotConfigured = errors.New("customers are not enabled")
+
+// Customer limits
+const (
+	maxCustomerName      = 200
+	maxExternalReference = 100
+
+	// DefaultPaymentPageSize and MaxPaymentPageSize bound the payments read per page
+	DefaultPaymentPageSize = 20
+	MaxPaymentPageSize     = 100
+)
+
+// CustomerInput holds the fields of a new or changed customer. On update, nil fields are left
+// unchanged and Metadata replaces the previous metadata.
+type CustomerInput struct {
+	Name              *string           `json:"name,omitempty"`
+	Email             *string           `json:"email,omitempty"`
+	ExternalReference *string           `json:"externalReference,omitempty"`
+	Metadata          map[string]string `json:"metadata,omitempty"`
+}
+
+// WithCustomers enables saved customers that payments can refer to
+func WithCustomers(repo domain.CustomerRepository) Option {
+	return func(uc *PaymentUseCase) {
+		uc.customers = repo
+	}
+}
+
+// CreateCustomer validates and stores a new customer
+func (uc *PaymentUseCase) CreateCustomer(ctx context.Context, input CustomerInput) (*domain.Customer, error) {
+	if uc.customers == nil {
+		return nil, ErrCustomersNotConfigured
+	}
+
+	now := time.Now()
+	customer := &domain.Customer{ID: uuid.New().String(), CreatedAt: now, UpdatedAt: now}
+	if err := uc.applyCustomerInput(ctx, customer, input); err != nil {
+		return nil, err
+	}
+	if err := uc.customers.Create(ctx, customer); err != nil {
+		return nil, err
+	}
+	return customer, nil
+}
+
+// UpdateCustomer changes the given fields of a customer
+func (uc *PaymentUseCase) UpdateCustomer(ctx context.Context, id string, input CustomerInput) (*domain.Customer, error) {
+	customer, err := uc.GetCustomer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if customer.ErasedAt != nil {
+		return nil, domain.ErrCustomerErased
+	}
+
+	if err := uc.applyCustomerInput(ctx, customer, input); err != nil {
+		return nil, err
+	}
+	customer.UpdatedAt = time.Now()
+	if err := uc.customers.Update(ctx, customer); err != nil {
+		return nil, err
+	}
+	return customer, nil
+}
+
+// DeleteCustomer removes a customer no payment refers to; customers with payments can only be
+// erased
+func (uc *PaymentUseCase) DeleteCustomer(ctx context.Context, id string) error {
+	customer, err := uc.GetCustomer(ctx, id)
+	if err != nil {
+		return err
+	}
+	lists, err := uc.customers.Payments(ctx, []string{customer.ID}, domain.PaymentPage{First: 1})
+	if err != nil {
+		return err
+	}
+	if lists[customer.ID].TotalCount > 0 {
+		return domain.ErrCustomerHasPayments
+	}
+	return uc.customers.Delete(ctx, customer.ID)
+}
+
+// EraseCustomer removes a customer's personal data on request, along with the payer's name,
+// account and card holder on its payments. The customer record and its payments are kept, so
+// payment history and totals stay intact. Erasing twice is harmless.
+func (uc *PaymentUseCase) EraseCustomer(ctx context.Context, id string) (*domain.Customer, error) {
+	customer, err := uc.GetCustomer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if customer.ErasedAt != nil {
+		return customer, nil
+	}
+
+	customer.Erase()
+	if err := uc.customers.Erase(ctx, customer); err != nil {
+		return nil, err
+	}
+	return customer, nil
+}
+
+// GetCustomer retrieves a customer by ID
+func (uc *PaymentUseCase) GetCustomer(ctx context.Context, id string) (*domain.Customer, error) {
+	if uc.customers == nil {
+		return nil, ErrCustomersNotConfigured
+	}
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, errors.New("customer ID is required")
+	}
+	return uc.customers.GetByID(ctx, id)
+}
+
+// CustomersByIDs retrieves several customers in one query, by ID; unknown IDs are left out
+func (uc *PaymentUseCase) CustomersByIDs(ctx context.Context, ids []string) (map[string]*domain.Customer, error) {
+	if uc.customers == nil {
+		return nil, ErrCustomersNotConfigured
+	}
+	customers, err := uc.customers.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]*domain.Customer, len(customers))
+	for _, customer := range customers {
+		result[customer.ID] = customer
+	}
+	return result, nil
+}
+
+// ListCustomers returns customers, optionally by email or external reference
+func (uc *PaymentUseCase) ListCustomers(ctx context.Context, filter domain.CustomerFilter) ([]*domain.Customer, error) {
+	if uc.customers == nil {
+		return nil, ErrCustomersNotConfigured
+	}
+	filter.Email = strings.ToLower(strings.TrimSpace(filter.Email))
+	filter.ExternalReference = strings.TrimSpace(filter.ExternalReference)
+	return uc.customers.List(ctx, filter)
+}
+
+// CustomerPayments returns one page of payments, newest first, for each of several customers
+func (uc *PaymentUseCase) CustomerPayments(ctx context.Context, customerIDs []string, page domain.PaymentPage) (map[string]*domain.PaymentList, error) {
+	if uc.customers == nil {
+		return nil, ErrCustomersNotConfigured
+	}
+	switch {
+	case page.First == 0:
+		page.First = DefaultPaymentPageSize
+	case page.First < 0 || page.First > MaxPaymentPageSize:
+		return nil, fmt.Errorf("first must be between 1 and %d", MaxPaymentPageSize)
+	}
+	return uc.customers.Payments(ctx, customerIDs, page)
+}
+
+// customerForPayment checks that a new payment can refer to the customer
+func (uc *PaymentUseCase) customerForPayment(ctx context.Context, id string) (string, error) {
+	customer, err := uc.GetCustomer(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if customer.ErasedAt != nil {
+		return "", domain.ErrCustomerErased
+	}
+	return customer.ID, nil
+}
+
+// applyCustomerInput validates input and sets the given fields on customer
+func (uc *PaymentUseCase) applyCustomerInput(ctx context.Context, customer *domain.Customer, input CustomerInput) error {
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		if len(name) > maxCustomerName {
+			return fmt.Errorf("name must be at most %d characters", maxCustomerName)
+		}
+		customer.Name = name
+	}
+	if input.Email != nil {
+		email := strings.ToLower(strings.TrimSpace(*input.Email))
+		if email != "" {
+			address, err := mail.ParseAddress(email)
+			if err != nil || address.Address != email {
+				return fmt.Errorf("email %q is not a valid address", *input.Email)
+			}
+		}
+		customer.Email = email
+	}
+	if input.ExternalReference != nil {
+		reference := strings.TrimSpace(*input.ExternalReference)
+		if len(reference) > maxExternalReference {
+			return fmt.Errorf("external reference must be at most %d characters", maxExternalReference)
+		}
+		if reference != "" && reference != customer.ExternalReference {
+			existing, err := uc.customers.List(ctx, domain.CustomerFilter{ExternalReference: reference})
+			if err != nil {
+				return err
+			}
+			if len(existing) > 0 {
+				return fmt.Errorf("external reference %q is used by customer %s", reference, existing[0].ID)
+			}
+		}
+		customer.ExternalReference = reference
+	}
+	if input.Metadata != nil {
+		metadata, err := normalizeMetadata(input.Metadata)
+		if err != nil {
+			return err
+		}
+		customer.Metadata = metadata
+	}
+	return nil
+}
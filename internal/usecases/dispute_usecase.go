@@ -0,0 +1,283 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"payments_app/internal/domain"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultEvidenceWindow is how long merchants have to submit evidence when no due date is given
+const DefaultEvidenceWindow = 7 * 24 * time.Hour
+
+// ErrDisputesNotConfigured is returned when dispute operations are used without a dispute store
+var ErrDisputesNotConfigured = errors.New("dispute management is not enabled")
+
+// EvidenceStore keeps dispute evidence files
+type EvidenceStore interface {
+	Save(ctx context.Context, dir, name, contentType string, content io.Reader) (*domain.EvidenceFile, error)
+}
+
+// WithDisputes enables dispute management; evidenceWindow is the default time allowed for evidence
+func WithDisputes(repo domain.DisputeRepository, files EvidenceStore, evidenceWindow time.Duration) Option {
+	return func(uc *PaymentUseCase) {
+		if evidenceWindow <= 0 {
+			evidenceWindow = DefaultEvidenceWindow
+		}
+		uc.disputes = repo
+		uc.evidence = files
+		uc.evidenceWindow = evidenceWindow
+	}
+}
+
+// WithLedger enables journal postings for money movements such as lost disputes and fees
+func WithLedger(ledger domain.LedgerRepository) Option {
+	return func(uc *PaymentUseCase) {
+		uc.ledger = ledger
+	}
+}
+
+// OpenDisputeInput represents a new dispute against a payment
+type OpenDisputeInput struct {
+	PaymentID  string `json:"paymentId"`
+	ReasonCode string `json:"reasonCode"`
+	// Amount defaults to the full payment amount
+	Amount *float64 `json:"amount,omitempty"`
+	// EvidenceDueAt defaults to now plus the configured evidence window
+	EvidenceDueAt *time.Time `json:"evidenceDueAt,omitempty"`
+}
+
+// EvidenceUpload is a file submitted as dispute evidence
+type EvidenceUpload struct {
+	Name        string
+	ContentType string
+	Content     io.Reader
+}
+
+// SubmitDisputeEvidenceInput represents evidence submitted for a dispute
+type SubmitDisputeEvidenceInput struct {
+	DisputeID   string           `json:"disputeId"`
+	Text        string           `json:"text,omitempty"`
+	Files       []EvidenceUpload `json:"-"`
+	SubmittedBy string           `json:"submittedBy"`
+}
+
+// ResolveDisputeInput represents the outcome of a dispute
+type ResolveDisputeInput struct {
+	DisputeID string `json:"disputeId"`
+	Won       bool   `json:"won"`
+	Note      string `json:"note,omitempty"`
+}
+
+// OpenDispute opens a dispute against a completed payment
+func (uc *PaymentUseCase) OpenDispute(ctx context.Context, input OpenDisputeInput) (*domain.Dispute, error) {
+	if uc.disputes == nil {
+		return nil, ErrDisputesNotConfigured
+	}
+	if input.PaymentID == "" {
+		return nil, errors.New("payment ID is required")
+	}
+	reasonCode := strings.TrimSpace(input.ReasonCode)
+	if reasonCode == "" {
+		return nil, errors.New("reason code is required")
+	}
+
+	payment, err := uc.repo.GetByID(ctx, input.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := uc.disputes.List(ctx, domain.DisputeFilter{
+		PaymentID: payment.ID,
+		Statuses:  []domain.DisputeStatus{domain.DisputeStatusOpen, domain.DisputeStatusUnderReview},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(active) > 0 {
+		return nil, fmt.Errorf("payment already has an active dispute %s", active[0].ID)
+	}
+
+	lost, err := uc.lostDisputeAmount(ctx, payment)
+	if err != nil {
+		return nil, err
+	}
+	amount := payment.DisputableAmount(lost)
+	if input.Amount != nil {
+		amount = *input.Amount
+	}
+	dueAt := time.Now().Add(uc.evidenceWindow)
+	if input.EvidenceDueAt != nil {
+		dueAt = *input.EvidenceDueAt
+	}
+
+	dispute, err := domain.NewDispute(payment, lost, reasonCode, amount, dueAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.disputes.Create(ctx, dispute); err != nil {
+		return nil, err
+	}
+
+	return dispute, nil
+}
+
+// SubmitDisputeEvidence stores evidence text and files and puts the dispute under review
+func (uc *PaymentUseCase) SubmitDisputeEvidence(ctx context.Context, input SubmitDisputeEvidenceInput) (*domain.Dispute, error) {
+	dispute, err := uc.activeDispute(ctx, input.DisputeID)
+	if err != nil {
+		return nil, err
+	}
+	submittedBy := strings.TrimSpace(input.SubmittedBy)
+	if submittedBy == "" {
+		return nil, errors.New("submitter is required")
+	}
+	text := strings.TrimSpace(input.Text)
+	if text == "" && len(input.Files) == 0 {
+		return nil, errors.New("evidence needs text or files")
+	}
+
+	evidence := domain.DisputeEvidence{
+		ID:          uuid.New().String(),
+		Text:        text,
+		SubmittedBy: submittedBy,
+		SubmittedAt: time.Now(),
+	}
+	for _, upload := range input.Files {
+		file, err := uc.evidence.Save(ctx, "disputes/"+dispute.ID, upload.Name, upload.ContentType, upload.Content)
+		if err != nil {
+			return nil, fmt.Errorf("storing %s: %w", upload.Name, err)
+		}
+		evidence.Files = append(evidence.Files, *file)
+	}
+
+	if err := dispute.AddEvidence(evidence); err != nil {
+		return nil, err
+	}
+	if err := uc.disputes.Update(ctx, dispute); err != nil {
+		return nil, err
+	}
+
+	return dispute, nil
+}
+
+// ResolveDispute closes a dispute. A lost dispute reverses the disputed amount in the ledger
+// and releases invoice allocations the payment no longer covers.
+func (uc *PaymentUseCase) ResolveDispute(ctx context.Context, input ResolveDisputeInput) (*domain.Dispute, error) {
+	dispute, err := uc.activeDispute(ctx, input.DisputeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := dispute.Resolve(input.Won, strings.TrimSpace(input.Note)); err != nil {
+		return nil, err
+	}
+
+	// Post before saving the outcome; the entry ID makes a retried resolution post only once
+	if dispute.Status == domain.DisputeStatusLost && uc.ledger != nil {
+		entry := &domain.JournalEntry{
+			ID:          "dispute-lost:" + dispute.ID,
+			PaymentID:   dispute.PaymentID,
+			Description: fmt.Sprintf("Chargeback reversal for dispute %s (reason %s)", dispute.ID, dispute.ReasonCode),
+			Postings: []domain.Posting{
+				{Account: domain.AccountChargebacks, Currency: dispute.Currency, Amount: dispute.Amount},
+				{Account: domain.AccountProcessorBalance, Currency: dispute.Currency, Amount: -dispute.Amount},
+			},
+			CreatedAt: time.Now(),
+		}
+		if err := uc.ledger.Post(ctx, entry); err != nil && !errors.Is(err, domain.ErrDuplicateJournalEntry) {
+			return nil, err
+		}
+	}
+
+	if err := uc.disputes.Update(ctx, dispute); err != nil {
+		return nil, err
+	}
+	if dispute.Status == domain.DisputeStatusLost && uc.invoices != nil {
+		payment, err := uc.repo.GetByID(ctx, dispute.PaymentID)
+		if err != nil {
+			return nil, err
+		}
+		if err := uc.releaseInvoiceAllocations(ctx, payment); err != nil {
+			return nil, err
+		}
+	}
+
+	return dispute, nil
+}
+
+// GetDispute retrieves a dispute by ID
+func (uc *PaymentUseCase) GetDispute(ctx context.Context, id string) (*domain.Dispute, error) {
+	if uc.disputes == nil {
+		return nil, ErrDisputesNotConfigured
+	}
+	if id == "" {
+		return nil, errors.New("dispute ID is required")
+	}
+	return uc.disputes.GetByID(ctx, id)
+}
+
+// ListDisputes returns disputes matching the filter
+func (uc *PaymentUseCase) ListDisputes(ctx context.Context, filter domain.DisputeFilter) ([]*domain.Dispute, error) {
+	if uc.disputes == nil {
+		return nil, ErrDisputesNotConfigured
+	}
+	return uc.disputes.List(ctx, filter)
+}
+
+// DisputesNearingDeadline returns open disputes whose evidence is due within the given time,
+// including overdue ones, soonest first
+func (uc *PaymentUseCase) DisputesNearingDeadline(ctx context.Context, within time.Duration) ([]*domain.Dispute, error) {
+	if uc.disputes == nil {
+		return nil, ErrDisputesNotConfigured
+	}
+	dueBefore := time.Now().Add(within)
+	return uc.disputes.List(ctx, domain.DisputeFilter{
+		Statuses:  []domain.DisputeStatus{domain.DisputeStatusOpen},
+		DueBefore: &dueBefore,
+	})
+}
+
+// LedgerEntries returns the journal entries posted for a payment
+func (uc *PaymentUseCase) LedgerEntries(ctx context.Context, paymentID string) ([]*domain.JournalEntry, error) {
+	if uc.ledger == nil {
+		return nil, errors.New("ledger is not enabled")
+	}
+	if paymentID == "" {
+		return nil, errors.New("payment ID is required")
+	}
+	return uc.ledger.EntriesForPayment(ctx, paymentID)
+}
+
+// activeDispute loads a dispute that can still be changed
+func (uc *PaymentUseCase) activeDispute(ctx context.Context, id string) (*domain.Dispute, error) {
+	dispute, err := uc.GetDispute(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !dispute.Active() {
+		return nil, domain.ErrDisputeClosed
+	}
+	return dispute, nil
+}
+
+// lostDisputeAmount is the total charged back by a payment's lost disputes
+func (uc *PaymentUseCase) lostDisputeAmount(ctx context.Context, payment *domain.Payment) (float64, error) {
+	if uc.disputes == nil {
+		return 0, nil
+	}
+	lost, err := uc.disputes.List(ctx, domain.DisputeFilter{PaymentID: payment.ID, Statuses: []domain.DisputeStatus{domain.DisputeStatusLost}})
+	if err != nil {
+		return 0, err
+	}
+	total := 0.0
+	for _, dispute := range lost {
+		total += dispute.Amount
+	}
+	return total, nil
+}
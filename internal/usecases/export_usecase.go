@@ -0,0 +1,82 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payments_app/internal/domain"
+	"strings"
+)
+
+// ErrExportNotSupported is returned when the payment store cannot stream a snapshot
+var ErrExportNotSupported = errors.New("payment export is not supported by this store")
+
+// ListPayments returns the payments matching filter in creation order
+func (uc *PaymentUseCase) ListPayments(ctx context.Context, filter domain.PaymentFilter) ([]*domain.Payment, error) {
+	filter, err := normalizeFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	if queries, ok := uc.repo.(domain.PaymentQueryRepository); ok {
+		return queries.List(ctx, filter)
+	}
+
+	payments, err := uc.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matching := payments[:0]
+	for _, payment := range payments {
+		if filter.Matches(payment) {
+			matching = append(matching, payment)
+		}
+	}
+	return matching, nil
+}
+
+// ExportPayments calls fn for every payment matching filter in creation order. The payments
+// come from one consistent snapshot and are streamed, so exports of any size use constant memory.
+func (uc *PaymentUseCase) ExportPayments(ctx context.Context, filter domain.PaymentFilter, fn func(*domain.Payment) error) error {
+	filter, err := normalizeFilter(filter)
+	if err != nil {
+		return err
+	}
+	queries, ok := uc.repo.(domain.PaymentQueryRepository)
+	if !ok {
+		return ErrExportNotSupported
+	}
+	return queries.Stream(ctx, filter, fn)
+}
+
+// normalizeFilter validates filter values and normalizes the currency code. Invalid
+// values are returned as *InputError with ErrorCodeInvalidFilter.
+func normalizeFilter(filter domain.PaymentFilter) (domain.PaymentFilter, error) {
+	for _, status := range filter.Statuses {
+		if !status.IsValid() {
+			return filter, inputError(ErrorCodeInvalidFilter, fmt.Errorf("unknown payment status %q", status))
+		}
+	}
+	if filter.Currency != "" {
+		currency, err := validateAndNormalizeCurrency(filter.Currency)
+		if err != nil {
+			return filter, inputError(ErrorCodeInvalidFilter, err)
+		}
+		filter.Currency = currency
+	}
+	filter.PayerID = strings.TrimSpace(filter.PayerID)
+	filter.TenantID = strings.TrimSpace(filter.TenantID)
+	filter.CustomerID = strings.TrimSpace(filter.CustomerID)
+	metadata, err := normalizeMetadata(filter.Metadata)
+	if err != nil {
+		return filter, inputError(ErrorCodeInvalidFilter, err)
+	}
+	tags, err := normalizeTags(filter.Tags)
+	if err != nil {
+		return filter, inputError(ErrorCodeInvalidFilter, err)
+	}
+	filter.Metadata, filter.Tags = metadata, tags
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && !filter.CreatedFrom.Before(*filter.CreatedTo) {
+		return filter, inputError(ErrorCodeInvalidFilter, errors.New("createdFrom must be before createdTo"))
+	}
+	return filter, nil
+}
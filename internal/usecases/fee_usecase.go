@@ -0,0 +1,84 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payments_app/internal/domain"
+	"strings"
+	"time"
+)
+
+// FeeCalculator prices the fees charged on a payment
+type FeeCalculator interface {
+	Fees(payment *domain.Payment, event domain.FeeEvent) ([]domain.Fee, error)
+}
+
+// WithFees charges fees on payments when they are created and captured. With a ledger, the
+// fees are also posted to their revenue accounts.
+func WithFees(calculator FeeCalculator) Option {
+	return func(uc *PaymentUseCase) {
+		uc.fees = calculator
+	}
+}
+
+// chargeFees adds the fees for the event to the payment without saving it. Fees are charged
+// once per event, and never on rejected payments.
+func (uc *PaymentUseCase) chargeFees(payment *domain.Payment, event domain.FeeEvent) error {
+	if uc.fees == nil || payment.Status == domain.PaymentStatusRejected || payment.HasFees(event) {
+		return nil
+	}
+	fees, err := uc.fees.Fees(payment, event)
+	if err != nil {
+		return fmt.Errorf("fee calculation failed: %w", err)
+	}
+	payment.Fees = append(payment.Fees, fees...)
+	return nil
+}
+
+// postFees posts the payment's fees for the event, moving them from what the merchant is owed
+// to revenue. The entry ID makes a retried post count once.
+func (uc *PaymentUseCase) postFees(ctx context.Context, payment *domain.Payment, event domain.FeeEvent) error {
+	if uc.ledger == nil {
+		return nil
+	}
+
+	entry := &domain.JournalEntry{
+		ID:          "fees:" + strings.ToLower(string(event)) + ":" + payment.ID,
+		PaymentID:   payment.ID,
+		Description: fmt.Sprintf("Fees on payment %s (%s)", payment.ID, strings.ToLower(string(event))),
+		CreatedAt:   time.Now(),
+	}
+	total := domain.Money{Currency: payment.Currency}
+	for _, fee := range payment.Fees {
+		if fee.Event != event {
+			continue
+		}
+		total.MinorUnits += fee.Amount.MinorUnits
+		entry.Postings = append(entry.Postings, domain.Posting{Account: fee.Account, Currency: fee.Amount.Currency, Amount: -fee.Amount.Float64()})
+	}
+	if len(entry.Postings) == 0 {
+		return nil
+	}
+	entry.Postings = append(entry.Postings, domain.Posting{Account: domain.AccountMerchantPayable, Currency: total.Currency, Amount: total.Float64()})
+
+	if err := uc.ledger.Post(ctx, entry); err != nil && !errors.Is(err, domain.ErrDuplicateJournalEntry) {
+		return fmt.Errorf("payment %s was saved, but its fees could not be posted: %w", payment.ID, err)
+	}
+	return nil
+}
+
+// createPayment charges the fees due on creation and stores the new payment. A failure to post
+// the fees is returned as *StoredPaymentError.
+func (uc *PaymentUseCase) createPayment(ctx context.Context, payment *domain.Payment) error {
+	if err := uc.chargeFees(payment, domain.FeeEventCreated); err != nil {
+		return err
+	}
+	if err := uc.repo.Create(ctx, payment); err != nil {
+		return err
+	}
+	if err := uc.postFees(ctx, payment, domain.FeeEventCreated); err != nil {
+		return &StoredPaymentError{PaymentID: payment.ID, Err: err}
+	}
+	return nil
+}
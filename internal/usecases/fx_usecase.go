@@ -0,0 +1,56 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payments_app/internal/domain"
+	"payments_app/internal/fx"
+	"time"
+)
+
+// ErrFXNotConfigured is returned when a payment asks for a settlement currency without rates
+var ErrFXNotConfigured = errors.New("currency conversion is not enabled")
+
+// RateProvider looks up exchange rates
+type RateProvider interface {
+	// Rate returns the latest rate for converting base to quote published at or before at
+	Rate(ctx context.Context, base, quote string, at time.Time) (domain.FXRate, error)
+}
+
+// WithFX enables settlement in another currency. Payments settle in settlementCurrency unless
+// they ask for another one; an empty settlementCurrency settles payments in their own currency.
+func WithFX(rates RateProvider, settlementCurrency string) Option {
+	return func(uc *PaymentUseCase) {
+		uc.rates = rates
+		uc.settlementCurrency = settlementCurrency
+	}
+}
+
+// settle converts the payment's amount to the settlement currency and records the result with
+// the rate used. An empty currency means the default settlement currency. A payment settling in
+// its own currency has no settlement.
+func (uc *PaymentUseCase) settle(ctx context.Context, payment *domain.Payment, currency string) error {
+	if currency == "" {
+		currency = uc.settlementCurrency
+	}
+	if currency == "" || currency == payment.Currency {
+		payment.Settlement = nil
+		return nil
+	}
+	if uc.rates == nil {
+		return ErrFXNotConfigured
+	}
+
+	now := time.Now()
+	rate, err := uc.rates.Rate(ctx, payment.Currency, currency, now)
+	if err != nil {
+		return err
+	}
+	amount, err := fx.Convert(domain.MoneyFromFloat(payment.Amount, payment.Currency), rate)
+	if err != nil {
+		return fmt.Errorf("cannot convert %s to %s: %w", payment.Currency, currency, err)
+	}
+	payment.Settlement = &domain.Settlement{Amount: amount, Rate: rate, ConvertedAt: now}
+	return nil
+}
@@ -0,0 +1,403 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"payments_app/internal/domain"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvoicesNotConfigured is returned when invoices are used without an invoice store
+var ErrInvoicesNotConfigured = errors.New("invoices are not enabled")
+
+// Invoice limits
+const (
+	maxInvoiceNumber      = 50
+	maxInvoiceLines       = 100
+	maxInvoiceDescription = 200
+	maxQuantityDecimals   = 6
+	maxAllocations        = 100
+)
+
+// InvoiceInput holds the fields of a new invoice. Amounts are decimals such as "12.50" in the
+// invoice's currency.
+type InvoiceInput struct {
+	Number     string             `json:"number"`
+	CustomerID string             `json:"customerId,omitempty"`
+	Currency   string             `json:"currency"`
+	DueDate    time.Time          `json:"dueDate"`
+	Lines      []InvoiceLineInput `json:"lines"`
+	// Tax calculates the tax of the lines, which then must not give one
+	Tax *TaxInput `json:"tax,omitempty"`
+}
+
+// InvoiceLineInput is one item of a new invoice. Quantity defaults to 1 and Tax to 0.
+// Category is the product category for calculated tax.
+type InvoiceLineInput struct {
+	Description string `json:"description"`
+	Category    string `json:"category,omitempty"`
+	Quantity    string `json:"quantity,omitempty"`
+	UnitPrice   string `json:"unitPrice"`
+	Tax         string `json:"tax,omitempty"`
+}
+
+// InvoiceAllocationInput applies Amount, a decimal in the payment's currency, to an invoice
+type InvoiceAllocationInput struct {
+	InvoiceID string `json:"invoiceId"`
+	Amount    string `json:"amount"`
+}
+
+// WithInvoices enables invoices that payments can be applied to
+func WithInvoices(repo domain.InvoiceRepository) Option {
+	return func(uc *PaymentUseCase) {
+		uc.invoices = repo
+	}
+}
+
+// CreateInvoice validates and stores a new invoice. Line amounts are quantity times unit price,
+// rounded half away from zero to the currency's minor unit.
+func (uc *PaymentUseCase) CreateInvoice(ctx context.Context, input InvoiceInput) (*domain.Invoice, error) {
+	if uc.invoices == nil {
+		return nil, ErrInvoicesNotConfigured
+	}
+
+	number := strings.TrimSpace(input.Number)
+	if number == "" {
+		return nil, errors.New("invoice number is required")
+	}
+	if len(number) > maxInvoiceNumber {
+		return nil, fmt.Errorf("invoice number must be at most %d characters", maxInvoiceNumber)
+	}
+	currency, err := validateAndNormalizeCurrency(input.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if input.DueDate.IsZero() {
+		return nil, errors.New("due date is required")
+	}
+	if len(input.Lines) == 0 {
+		return nil, errors.New("invoice must have at least one line")
+	}
+	if len(input.Lines) > maxInvoiceLines {
+		return nil, fmt.Errorf("invoice can have at most %d lines", maxInvoiceLines)
+	}
+
+	now := time.Now()
+	due := input.DueDate
+	invoice := &domain.Invoice{
+		ID:         uuid.New().String(),
+		Number:     number,
+		Currency:   currency,
+		Subtotal:   domain.Money{Currency: currency},
+		Tax:        domain.Money{Currency: currency},
+		AmountPaid: domain.Money{Currency: currency},
+		DueDate:    time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, time.UTC),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if customerID := strings.TrimSpace(input.CustomerID); customerID != "" {
+		if invoice.CustomerID, err = uc.customerForPayment(ctx, customerID); err != nil {
+			return nil, err
+		}
+	}
+	for i, lineInput := range input.Lines {
+		if input.Tax != nil && strings.TrimSpace(lineInput.Tax) != "" {
+			return nil, fmt.Errorf("line %d: tax is calculated and cannot be given", i+1)
+		}
+		line, err := buildInvoiceLine(lineInput, currency)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		invoice.Lines = append(invoice.Lines, line)
+	}
+	if input.Tax != nil {
+		if err := uc.taxInvoice(invoice, input); err != nil {
+			return nil, err
+		}
+	}
+	for _, line := range invoice.Lines {
+		invoice.Subtotal.MinorUnits += line.Amount.MinorUnits
+		invoice.Tax.MinorUnits += line.Tax.MinorUnits
+	}
+	invoice.Total = domain.Money{MinorUnits: invoice.Subtotal.MinorUnits + invoice.Tax.MinorUnits, Currency: currency}
+	if invoice.Total.MinorUnits <= 0 {
+		return nil, errors.New("invoice total must be greater than 0")
+	}
+
+	if err := uc.invoices.Create(ctx, invoice); err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+// taxInvoice calculates the tax of the invoice's lines. Lines without a category use the
+// input's. With inclusive pricing the tax is taken out of the line amounts.
+func (uc *PaymentUseCase) taxInvoice(invoice *domain.Invoice, input InvoiceInput) error {
+	lines := make([]domain.TaxableLine, len(invoice.Lines))
+	for i, line := range invoice.Lines {
+		lines[i] = domain.TaxableLine{Category: input.Lines[i].Category, Amount: line.Amount}
+		if strings.TrimSpace(lines[i].Category) == "" {
+			lines[i].Category = input.Tax.Category
+		}
+	}
+	breakdown, err := uc.calculateTax(*input.Tax, "", lines)
+	if err != nil {
+		return err
+	}
+	for i, taxLine := range breakdown.Lines {
+		invoice.Lines[i].Category = taxLine.Category
+		invoice.Lines[i].Amount = taxLine.Net
+		invoice.Lines[i].Tax = taxLine.Tax
+	}
+	invoice.TaxBreakdown = breakdown
+	return nil
+}
+
+// buildInvoiceLine validates a line and computes its amount exactly
+func buildInvoiceLine(input InvoiceLineInput, currency string) (domain.InvoiceLine, error) {
+	line := domain.InvoiceLine{Description: strings.TrimSpace(input.Description)}
+	if line.Description == "" {
+		return line, errors.New("description is required")
+	}
+	if len(line.Description) > maxInvoiceDescription {
+		return line, fmt.Errorf("description must be at most %d characters", maxInvoiceDescription)
+	}
+
+	quantity, err := parseQuantity(input.Quantity)
+	if err != nil {
+		return line, err
+	}
+	line.Quantity = domain.FormatDecimal(quantity)
+	if line.UnitPrice, err = domain.ParseMoney(input.UnitPrice, currency); err != nil {
+		return line, err
+	}
+	if line.UnitPrice.MinorUnits < 0 {
+		return line, errors.New("unit price must not be negative")
+	}
+	line.Tax = domain.Money{Currency: currency}
+	if tax := strings.TrimSpace(input.Tax); tax != "" {
+		if line.Tax, err = domain.ParseMoney(tax, currency); err != nil {
+			return line, err
+		}
+		if line.Tax.MinorUnits < 0 {
+			return line, errors.New("tax must not be negative")
+		}
+	}
+
+	price := new(big.Rat).SetFrac64(line.UnitPrice.MinorUnits, domain.MinorUnitScale(currency))
+	line.Amount, err = domain.RoundMoney(price.Mul(price, quantity), currency)
+	return line, err
+}
+
+// parseQuantity parses a positive decimal quantity; empty means 1
+func parseQuantity(value string) (*big.Rat, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return big.NewRat(1, 1), nil
+	}
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole+fraction == "" || strings.Trim(whole+fraction, "0123456789") != "" {
+		return nil, fmt.Errorf("invalid quantity %q", value)
+	}
+	if len(strings.TrimRight(fraction, "0")) > maxQuantityDecimals {
+		return nil, fmt.Errorf("quantity %q has more than %d decimal places", value, maxQuantityDecimals)
+	}
+	quantity, ok := new(big.Rat).SetString(value)
+	if !ok || quantity.Sign() <= 0 {
+		return nil, fmt.Errorf("quantity %q must be greater than 0", value)
+	}
+	return quantity, nil
+}
+
+// GetInvoice retrieves an invoice with the payments applied to it
+func (uc *PaymentUseCase) GetInvoice(ctx context.Context, id string) (*domain.Invoice, error) {
+	if uc.invoices == nil {
+		return nil, ErrInvoicesNotConfigured
+	}
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, errors.New("invoice ID is required")
+	}
+	return uc.invoices.GetByID(ctx, id)
+}
+
+// ListInvoices returns matching invoices, earliest due first. Statuses are derived at now.
+func (uc *PaymentUseCase) ListInvoices(ctx context.Context, filter domain.InvoiceFilter, statuses []domain.InvoiceStatus, now time.Time) ([]*domain.Invoice, error) {
+	if uc.invoices == nil {
+		return nil, ErrInvoicesNotConfigured
+	}
+	for _, status := range statuses {
+		if !status.IsValid() {
+			return nil, fmt.Errorf("invalid invoice status %q", status)
+		}
+	}
+	filter.CustomerID = strings.TrimSpace(filter.CustomerID)
+	if filter.Currency != "" {
+		currency, err := validateAndNormalizeCurrency(filter.Currency)
+		if err != nil {
+			return nil, err
+		}
+		filter.Currency = currency
+	}
+
+	invoices, err := uc.invoices.List(ctx, filter)
+	if err != nil || len(statuses) == 0 {
+		return invoices, err
+	}
+	matching := invoices[:0]
+	for _, invoice := range invoices {
+		for _, status := range statuses {
+			if invoice.Status(now) == status {
+				matching = append(matching, invoice)
+				break
+			}
+		}
+	}
+	return matching, nil
+}
+
+// ApplyPayment allocates parts of a completed payment to invoices in the payment's currency.
+// The allocations of a payment cannot exceed its amount less refunds and lost disputes, nor
+// can an allocation exceed the amount due of its invoice. Either all allocations are stored or
+// none.
+func (uc *PaymentUseCase) ApplyPayment(ctx context.Context, paymentID string, inputs []InvoiceAllocationInput) ([]*domain.InvoiceAllocation, error) {
+	if uc.invoices == nil {
+		return nil, ErrInvoicesNotConfigured
+	}
+	if len(inputs) == 0 {
+		return nil, errors.New("at least one allocation is required")
+	}
+	if len(inputs) > maxAllocations {
+		return nil, fmt.Errorf("at most %d allocations can be applied at once", maxAllocations)
+	}
+	payment, err := uc.GetPayment(ctx, strings.TrimSpace(paymentID))
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != domain.PaymentStatusCompleted {
+		return nil, fmt.Errorf("only completed payments can be applied to invoices, payment is %s", payment.Status)
+	}
+
+	now := time.Now()
+	allocations := make([]*domain.InvoiceAllocation, len(inputs))
+	for i, input := range inputs {
+		invoice, err := uc.GetInvoice(ctx, input.InvoiceID)
+		if err != nil {
+			return nil, err
+		}
+		if invoice.Currency != payment.Currency {
+			return nil, fmt.Errorf("invoice %s is in %s, not in the payment's currency %s", invoice.Number, invoice.Currency, payment.Currency)
+		}
+		if invoice.CustomerID != "" && payment.CustomerID != "" && invoice.CustomerID != payment.CustomerID {
+			return nil, fmt.Errorf("invoice %s was issued to another customer than the payment's", invoice.Number)
+		}
+		amount, err := domain.ParseMoney(input.Amount, payment.Currency)
+		if err != nil {
+			return nil, err
+		}
+		if amount.MinorUnits <= 0 {
+			return nil, errors.New("allocation amount must be greater than 0")
+		}
+		allocations[i] = &domain.InvoiceAllocation{
+			ID:        uuid.New().String(),
+			InvoiceID: invoice.ID,
+			PaymentID: payment.ID,
+			Amount:    amount,
+			CreatedAt: now,
+		}
+	}
+
+	available, err := uc.allocatableAmount(ctx, payment)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.invoices.Allocate(ctx, allocations, available); err != nil {
+		return nil, err
+	}
+	return allocations, nil
+}
+
+// releaseInvoiceAllocations takes back the newest allocations of a payment that no longer fit
+// within what the payment still holds after a refund, return, failure or lost dispute. The
+// released amounts are due on their invoices again.
+func (uc *PaymentUseCase) releaseInvoiceAllocations(ctx context.Context, payment *domain.Payment) error {
+	if uc.invoices == nil {
+		return nil
+	}
+	available, err := uc.allocatableAmount(ctx, payment)
+	if err != nil {
+		return err
+	}
+	if err := uc.invoices.Release(ctx, payment.ID, available); err != nil {
+		return fmt.Errorf("payment %s was saved, but its invoice allocations could not be released: %w", payment.ID, err)
+	}
+	return nil
+}
+
+// allocatableAmount is what a payment holds to pay invoices with: its amount less refunds and
+// lost disputes while it is completed or partly refunded, and nothing otherwise
+func (uc *PaymentUseCase) allocatableAmount(ctx context.Context, payment *domain.Payment) (domain.Money, error) {
+	available := domain.Money{Currency: payment.Currency}
+	if payment.Status != domain.PaymentStatusCompleted && payment.Status != domain.PaymentStatusRefunded {
+		return available, nil
+	}
+	lost, err := uc.lostDisputeAmount(ctx, payment)
+	if err != nil {
+		return available, err
+	}
+	available.MinorUnits = domain.MoneyFromFloat(payment.Amount, payment.Currency).MinorUnits -
+		domain.MoneyFromFloat(payment.RefundedAmount, payment.Currency).MinorUnits -
+		domain.MoneyFromFloat(lost, payment.Currency).MinorUnits
+	return available, nil
+}
+
+// RemoveInvoiceAllocation takes an allocation back, so the amount is due on the invoice again
+// and can be applied elsewhere
+func (uc *PaymentUseCase) RemoveInvoiceAllocation(ctx context.Context, id string) (*domain.InvoiceAllocation, error) {
+	if uc.invoices == nil {
+		return nil, ErrInvoicesNotConfigured
+	}
+	return uc.invoices.Deallocate(ctx, strings.TrimSpace(id))
+}
+
+// PaymentInvoiceAllocations returns the invoices a payment was applied to, oldest first
+func (uc *PaymentUseCase) PaymentInvoiceAllocations(ctx context.Context, paymentID string) ([]*domain.InvoiceAllocation, error) {
+	if uc.invoices == nil {
+		return nil, ErrInvoicesNotConfigured
+	}
+	return uc.invoices.PaymentAllocations(ctx, strings.TrimSpace(paymentID))
+}
+
+// InvoiceAging sorts the amounts due of unpaid invoices into buckets by days past due at asOf,
+// one report per currency. customerID optionally limits it to one customer's invoices.
+func (uc *PaymentUseCase) InvoiceAging(ctx context.Context, asOf time.Time, customerID string) ([]*domain.InvoiceAgingReport, error) {
+	if uc.invoices == nil {
+		return nil, ErrInvoicesNotConfigured
+	}
+	invoices, err := uc.invoices.List(ctx, domain.InvoiceFilter{CustomerID: strings.TrimSpace(customerID), Unpaid: true})
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make(map[string]*domain.InvoiceAgingReport)
+	for _, invoice := range invoices {
+		report := reports[invoice.Currency]
+		if report == nil {
+			report = domain.NewInvoiceAgingReport(invoice.Currency, asOf)
+			reports[invoice.Currency] = report
+		}
+		report.Add(invoice)
+	}
+	result := make([]*domain.InvoiceAgingReport, 0, len(reports))
+	for _, report := range reports {
+		result = append(result, report)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Currency < result[j].Currency })
+	return result, nil
+}
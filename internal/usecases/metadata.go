@@ -0,0 +1,60 @@
+package usecases
+
+import (
+	"fmt"
+	"payments_app/internal/domain"
+	"strings"
+)
+
+// Metadata and tag limits
+const (
+	MaxMetadataKeys  = 50
+	maxMetadataValue = 500
+	MaxTags          = 20
+	maxTag           = 50
+)
+
+// normalizeMetadata validates metadata and trims its keys; nil stays nil
+func normalizeMetadata(metadata map[string]string) (map[string]string, error) {
+	if metadata == nil {
+		return nil, nil
+	}
+	if len(metadata) > MaxMetadataKeys {
+		return nil, fmt.Errorf("metadata can have at most %d keys", MaxMetadataKeys)
+	}
+	result := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		key = strings.TrimSpace(key)
+		if !domain.ValidMetadataKey(key) {
+			return nil, fmt.Errorf("metadata key %q must have 1 to 40 letters, digits, '_', '-' or '.'", key)
+		}
+		if len(value) > maxMetadataValue {
+			return nil, fmt.Errorf("metadata value of %q must be at most %d characters", key, maxMetadataValue)
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+// normalizeTags validates tags, trimming them and dropping duplicates; nil stays nil
+func normalizeTags(tags []string) ([]string, error) {
+	if tags == nil {
+		return nil, nil
+	}
+	result := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || len(tag) > maxTag {
+			return nil, fmt.Errorf("tags must have 1 to %d characters", maxTag)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			result = append(result, tag)
+		}
+	}
+	if len(result) > MaxTags {
+		return nil, fmt.Errorf("a payment can have at most %d tags", MaxTags)
+	}
+	return result, nil
+}
@@ -0,0 +1,232 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"payments_app/internal/domain"
+	"payments_app/internal/vault"
+	"strings"
+	"time"
+)
+
+// PaymentMethodInput describes how a payment is funded; exactly one detail block must match Type
+type PaymentMethodInput struct {
+	Type        domain.PaymentMethodType `json:"type"`
+	Card        *CardInput               `json:"card,omitempty"`
+	BankAccount *BankAccountInput        `json:"bankAccount,omitempty"`
+	Wallet      *WalletInput             `json:"wallet,omitempty"`
+}
+
+// CardInput carries either raw card details or a vault token; a raw PAN is validated and then discarded
+type CardInput struct {
+	Token       string `json:"token,omitempty"`
+	Number      string `json:"number,omitempty"`
+	ExpiryMonth int    `json:"expiryMonth"`
+	ExpiryYear  int    `json:"expiryYear"`
+	HolderName  string `json:"holderName,omitempty"`
+}
+
+// BankAccountInput carries SEPA or ACH account details
+type BankAccountInput struct {
+	Scheme        domain.BankScheme `json:"scheme"`
+	IBAN          string            `json:"iban,omitempty"`
+	BIC           string            `json:"bic,omitempty"`
+	RoutingNumber string            `json:"routingNumber,omitempty"`
+	AccountNumber string            `json:"accountNumber,omitempty"`
+	HolderName    string            `json:"holderName,omitempty"`
+	// AccountType defaults to CHECKING and HolderType to INDIVIDUAL; both apply to ACH only
+	AccountType domain.BankAccountType   `json:"accountType,omitempty"`
+	HolderType  domain.AccountHolderType `json:"holderType,omitempty"`
+}
+
+// WalletInput carries a wallet provider token
+type WalletInput struct {
+	Provider string `json:"provider"`
+	Token    string `json:"token"`
+}
+
+// buildPaymentMethod validates method input against the payment currency and converts it to the domain
+func (uc *PaymentUseCase) buildPaymentMethod(ctx context.Context, input *PaymentMethodInput, currency string) (domain.PaymentMethod, error) {
+	if input == nil {
+		return nil, nil
+	}
+
+	switch input.Type {
+	case domain.PaymentMethodTypeCard:
+		if input.Card == nil || input.BankAccount != nil || input.Wallet != nil {
+			return nil, errors.New("card details are required for CARD payment method")
+		}
+		return uc.buildCardMethod(ctx, input.Card)
+	case domain.PaymentMethodTypeBankAccount:
+		if input.BankAccount == nil || input.Card != nil || input.Wallet != nil {
+			return nil, errors.New("bank account details are required for BANK_ACCOUNT payment method")
+		}
+		return buildBankAccountMethod(input.BankAccount, currency)
+	case domain.PaymentMethodTypeWallet:
+		if input.Wallet == nil || input.Card != nil || input.BankAccount != nil {
+			return nil, errors.New("wallet details are required for WALLET payment method")
+		}
+		return buildWalletMethod(input.Wallet)
+	default:
+		return nil, errors.New("unsupported payment method type")
+	}
+}
+
+// buildCardMethod resolves a vault token, or validates a raw PAN and keeps only brand and last four
+// digits. When a vault is configured a raw PAN is tokenized so it can be recovered for processing.
+func (uc *PaymentUseCase) buildCardMethod(ctx context.Context, input *CardInput) (domain.PaymentMethod, error) {
+	if token := strings.TrimSpace(input.Token); token != "" {
+		if input.Number != "" {
+			return nil, errors.New("card token and card number are mutually exclusive")
+		}
+		if uc.vault == nil {
+			return nil, ErrVaultNotConfigured
+		}
+		card, err := uc.vault.Lookup(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		if err := validateCardExpiry(card.ExpiryMonth, card.ExpiryYear); err != nil {
+			return nil, err
+		}
+		return domain.CardMethod{
+			Token:       card.Token,
+			Brand:       card.Brand,
+			Last4:       card.Last4,
+			ExpiryMonth: card.ExpiryMonth,
+			ExpiryYear:  card.ExpiryYear,
+			HolderName:  card.HolderName,
+		}, nil
+	}
+
+	card, err := validateCardInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	method := domain.CardMethod{
+		Brand:       domain.CardBrand(card.PAN),
+		Last4:       card.PAN[len(card.PAN)-4:],
+		ExpiryMonth: card.ExpiryMonth,
+		ExpiryYear:  card.ExpiryYear,
+		HolderName:  card.HolderName,
+	}
+	if uc.vault != nil {
+		tokenized, err := uc.vault.Tokenize(ctx, card)
+		if err != nil {
+			return nil, err
+		}
+		method.Token = tokenized.Token
+	}
+
+	return method, nil
+}
+
+// validateCardInput checks a raw PAN with the Luhn checksum and the expiry date
+func validateCardInput(input *CardInput) (vault.Card, error) {
+	pan := strings.NewReplacer(" ", "", "-", "").Replace(input.Number)
+	if len(pan) < 12 || len(pan) > 19 || !domain.ValidLuhn(pan) {
+		return vault.Card{}, errors.New("card number is invalid")
+	}
+	if err := validateCardExpiry(input.ExpiryMonth, input.ExpiryYear); err != nil {
+		return vault.Card{}, err
+	}
+
+	return vault.Card{
+		PAN:         pan,
+		ExpiryMonth: input.ExpiryMonth,
+		ExpiryYear:  input.ExpiryYear,
+		HolderName:  strings.TrimSpace(input.HolderName),
+	}, nil
+}
+
+// validateCardExpiry rejects malformed and expired card expiry dates
+func validateCardExpiry(month, year int) error {
+	if month < 1 || month > 12 {
+		return errors.New("card expiry month must be between 1 and 12")
+	}
+	if year < 2000 || year > 2100 {
+		return errors.New("card expiry year must be a 4-digit year")
+	}
+
+	// Cards are valid through the last day of their expiry month
+	now := time.Now()
+	if year < now.Year() || (year == now.Year() && month < int(now.Month())) {
+		return errors.New("card is expired")
+	}
+	return nil
+}
+
+// buildBankAccountMethod validates SEPA accounts by IBAN checksum and ACH accounts by routing checksum
+func buildBankAccountMethod(input *BankAccountInput, currency string) (domain.PaymentMethod, error) {
+	method := domain.BankAccountMethod{
+		Scheme:     input.Scheme,
+		HolderName: strings.TrimSpace(input.HolderName),
+	}
+
+	switch input.Scheme {
+	case domain.BankSchemeSEPA:
+		if currency != "EUR" {
+			return nil, errors.New("SEPA transfers must be in EUR")
+		}
+		iban := strings.ToUpper(strings.ReplaceAll(input.IBAN, " ", ""))
+		if !domain.ValidIBAN(iban) {
+			return nil, errors.New("IBAN is invalid")
+		}
+		bic := strings.ToUpper(strings.TrimSpace(input.BIC))
+		if bic != "" && len(bic) != 8 && len(bic) != 11 {
+			return nil, errors.New("BIC must be 8 or 11 characters")
+		}
+		method.IBAN = iban
+		method.BIC = bic
+	case domain.BankSchemeACH:
+		if currency != "USD" {
+			return nil, errors.New("ACH transfers must be in USD")
+		}
+		routing := strings.TrimSpace(input.RoutingNumber)
+		if !domain.ValidABARoutingNumber(routing) {
+			return nil, errors.New("routing number is invalid")
+		}
+		account := strings.TrimSpace(input.AccountNumber)
+		if len(account) < 4 || len(account) > 17 || strings.Trim(account, "0123456789") != "" {
+			return nil, errors.New("account number must be 4 to 17 digits")
+		}
+		method.RoutingNumber = routing
+		method.AccountNumber = account
+
+		method.AccountType = domain.BankAccountType(strings.ToUpper(strings.TrimSpace(string(input.AccountType))))
+		switch method.AccountType {
+		case "":
+			method.AccountType = domain.BankAccountTypeChecking
+		case domain.BankAccountTypeChecking, domain.BankAccountTypeSavings:
+		default:
+			return nil, errors.New("account type must be CHECKING or SAVINGS")
+		}
+		method.HolderType = domain.AccountHolderType(strings.ToUpper(strings.TrimSpace(string(input.HolderType))))
+		switch method.HolderType {
+		case "":
+			method.HolderType = domain.AccountHolderTypeIndividual
+		case domain.AccountHolderTypeIndividual, domain.AccountHolderTypeCompany:
+		default:
+			return nil, errors.New("holder type must be INDIVIDUAL or COMPANY")
+		}
+	default:
+		return nil, errors.New("bank scheme must be SEPA or ACH")
+	}
+
+	return method, nil
+}
+
+// buildWalletMethod validates wallet provider and token presence
+func buildWalletMethod(input *WalletInput) (domain.PaymentMethod, error) {
+	provider := strings.ToUpper(strings.TrimSpace(input.Provider))
+	token := strings.TrimSpace(input.Token)
+	if provider == "" {
+		return nil, errors.New("wallet provider is required")
+	}
+	if token == "" {
+		return nil, errors.New("wallet token is required")
+	}
+
+	return domain.WalletMethod{Provider: provider, Token: token}, nil
+}
@@ -4,25 +4,124 @@ import (
 	"context"
 	"errors"
 	"payments_app/internal/domain"
+	"payments_app/internal/vault"
 	"strings"
 	"time"
 )
 
+// RiskScreener assesses a payment before it is stored
+type RiskScreener interface {
+	Assess(ctx context.Context, payment *domain.Payment) (*domain.RiskAssessment, error)
+}
+
+// SanctionsScreener screens payment counterparties against watchlists
+type SanctionsScreener interface {
+	Screen(ctx context.Context, payment *domain.Payment) (*domain.ScreeningResult, error)
+}
+
 // PaymentUseCase handles payment business logic
 type PaymentUseCase struct {
-	repo domain.PaymentRepository
+	repo      domain.PaymentRepository
+	risk      RiskScreener
+	sanctions SanctionsScreener
+	vault     vault.Tokenizer
+
+	processors       map[string]domain.Processor
+	defaultProcessor string
+	router           ProcessorRouter
+
+	callbacks   domain.CallbackRepository
+	references  PaymentReferenceLookup
+	autoCapture bool
+
+	disputes       domain.DisputeRepository
+	evidence       EvidenceStore
+	evidenceWindow time.Duration
+	ledger         domain.LedgerRepository
+
+	subscriptions  domain.SubscriptionRepository
+	dunningBackoff []time.Duration
+
+	scheduled domain.ScheduledPaymentRepository
+
+	statements domain.ReconciliationRepository
+	matcher    StatementMatcher
+
+	creditTransfers domain.CreditTransferRepository
+	transferEncoder CreditTransferEncoder
+
+	ach        domain.ACHRepository
+	achEncoder ACHEncoder
+
+	rates              RateProvider
+	settlementCurrency string
+
+	fees     FeeCalculator
+	balances domain.RecipientBalanceRepository
+
+	settlements domain.SettlementRepository
+	customers   domain.CustomerRepository
+	search      domain.PaymentSearchRepository
+	invoices    domain.InvoiceRepository
+	taxes       TaxCalculator
+}
+
+// Option configures optional PaymentUseCase dependencies
+type Option func(*PaymentUseCase)
+
+// WithRiskScreener enables risk screening of new payments
+func WithRiskScreener(screener RiskScreener) Option {
+	return func(uc *PaymentUseCase) {
+		uc.risk = screener
+	}
+}
+
+// WithSanctionsScreener enables watchlist screening of payer and payee names
+func WithSanctionsScreener(screener SanctionsScreener) Option {
+	return func(uc *PaymentUseCase) {
+		uc.sanctions = screener
+	}
+}
+
+// WithCardVault enables card tokenization; raw card numbers are vaulted instead of discarded
+func WithCardVault(tokenizer vault.Tokenizer) Option {
+	return func(uc *PaymentUseCase) {
+		uc.vault = tokenizer
+	}
 }
 
 // NewPaymentUseCase creates a new payment use case
-func NewPaymentUseCase(repo domain.PaymentRepository) *PaymentUseCase {
-	return &PaymentUseCase{repo: repo}
+func NewPaymentUseCase(repo domain.PaymentRepository, opts ...Option) *PaymentUseCase {
+	uc := &PaymentUseCase{repo: repo}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	return uc
 }
 
 // CreatePaymentInput represents input for creating a payment
 type CreatePaymentInput struct {
-	Amount      float64 `json:"amount"`
-	Currency    string  `json:"currency"`
-	Description string  `json:"description"`
+	Amount      float64             `json:"amount"`
+	Currency    string              `json:"currency"`
+	Description string              `json:"description"`
+	PayerID     string              `json:"payerId,omitempty"`
+	TenantID    string              `json:"tenantId,omitempty"`
+	Payer       *domain.Party       `json:"payer,omitempty"`
+	Payee       *domain.Party       `json:"payee,omitempty"`
+	Method      *PaymentMethodInput `json:"method,omitempty"`
+	// CustomerID refers the payment to a saved customer
+	CustomerID string `json:"customerId,omitempty"`
+	// Metadata and Tags label the payment for search and reporting
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Tags     []string          `json:"tags,omitempty"`
+	// ExecuteAt schedules the payment for a future date; past times execute immediately
+	ExecuteAt *time.Time `json:"executeAt,omitempty"`
+	// SettlementCurrency overrides the configured settlement currency
+	SettlementCurrency string `json:"settlementCurrency,omitempty"`
+	// Splits divide the payment among recipients
+	Splits []SplitInput `json:"splits,omitempty"`
+	// Tax calculates the tax contained in the payment
+	Tax *TaxInput `json:"tax,omitempty"`
 }
 
 // UpdatePaymentInput represents input for updating a payment
@@ -32,40 +131,166 @@ type UpdatePaymentInput struct {
 	Currency    *string               `json:"currency,omitempty"`
 	Description *string               `json:"description,omitempty"`
 	Status      *domain.PaymentStatus `json:"status,omitempty"`
+	// Metadata and Tags replace the previous values when not nil
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Tags     []string          `json:"tags,omitempty"`
 }
 
 // CreatePayment creates a new payment
 func (uc *PaymentUseCase) CreatePayment(ctx context.Context, input CreatePaymentInput) (*domain.Payment, error) {
+	payment, err := uc.preparePayment(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.storePayment(ctx, payment); err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// preparePayment validates input and builds the payment without storing it. Validation
+// failures are returned as *InputError carrying the code of the rejected field.
+func (uc *PaymentUseCase) preparePayment(ctx context.Context, input CreatePaymentInput) (*domain.Payment, error) {
 	// Validate input
 	if input.Amount <= 0 {
-		return nil, errors.New("amount must be greater than 0")
+		return nil, inputError(ErrorCodeInvalidAmount, errors.New("amount must be greater than 0"))
 	}
 
 	// Validate and normalize currency
 	currency, currencyErr := validateAndNormalizeCurrency(input.Currency)
 	if currencyErr != nil {
-		return nil, currencyErr
+		return nil, inputError(ErrorCodeInvalidCurrency, currencyErr)
 
 	}
 	if strings.TrimSpace(input.Description) == "" {
-		return nil, errors.New("description is required")
+		return nil, inputError(ErrorCodeInvalidDescription, errors.New("description is required"))
+	}
+	payer, err := normalizeParty("payer", input.Payer)
+	if err != nil {
+		return nil, inputError(ErrorCodeInvalidParty, err)
+	}
+	payee, err := normalizeParty("payee", input.Payee)
+	if err != nil {
+		return nil, inputError(ErrorCodeInvalidParty, err)
+	}
+	method, err := uc.buildPaymentMethod(ctx, input.Method, currency)
+	if err != nil {
+		return nil, inputError(ErrorCodeInvalidMethod, err)
+	}
+	customerID := ""
+	if strings.TrimSpace(input.CustomerID) != "" {
+		if customerID, err = uc.customerForPayment(ctx, input.CustomerID); err != nil {
+			return nil, inputError(ErrorCodeInvalidCustomer, err)
+		}
+	}
+	metadata, err := normalizeMetadata(input.Metadata)
+	if err != nil {
+		return nil, inputError(ErrorCodeInvalidMetadata, err)
+	}
+	tags, err := normalizeTags(input.Tags)
+	if err != nil {
+		return nil, inputError(ErrorCodeInvalidMetadata, err)
 	}
-
 
 	// Create payment entity with normalized data
 	// Note: Domain layer expects pre-normalized data (trimmed, validated)
 	payment := domain.NewPayment(input.Amount, currency, strings.TrimSpace(input.Description))
+	payment.PayerID = strings.TrimSpace(input.PayerID)
+	payment.TenantID = strings.TrimSpace(input.TenantID)
+	payment.CustomerID = customerID
+	payment.Metadata = metadata
+	payment.Tags = tags
+	payment.Payer = payer
+	payment.Payee = payee
+	payment.Method = method
+
+	// Tax comes first, as exclusive pricing changes the amount that is split and converted
+	if input.Tax != nil {
+		if err := uc.taxPayment(payment, *input.Tax); err != nil {
+			return nil, inputError(ErrorCodeInvalidTax, err)
+		}
+	}
 
+	if len(input.Splits) > 0 {
+		if uc.balances == nil {
+			return nil, inputError(ErrorCodeInvalidSplits, ErrSplitsNotConfigured)
+		}
+		if payment.Splits, err = buildSplits(input.Splits, domain.MoneyFromFloat(payment.Amount, payment.Currency)); err != nil {
+			return nil, inputError(ErrorCodeInvalidSplits, err)
+		}
+	}
 
-	// Save to repository
-	err := uc.repo.Create(ctx, payment)
-	if err != nil {
-		return nil, err
+	// The amount is converted when the payment is created, also for scheduled payments
+	settlementCurrency := ""
+	if input.SettlementCurrency != "" {
+		if settlementCurrency, err = validateAndNormalizeCurrency(input.SettlementCurrency); err != nil {
+			return nil, inputError(ErrorCodeInvalidSettlementCurrency, err)
+		}
+	}
+	if err := uc.settle(ctx, payment, settlementCurrency); err != nil {
+		return nil, inputError(ErrorCodeInvalidSettlementCurrency, err)
+	}
+
+	// Future-dated payments are only stored; screening and processing happen when they fall due
+	if input.ExecuteAt != nil && input.ExecuteAt.After(time.Now()) {
+		if uc.scheduled == nil {
+			return nil, inputError(ErrorCodeInvalidExecuteAt, ErrSchedulingNotConfigured)
+		}
+		payment.Status = domain.PaymentStatusScheduled
+		payment.ExecuteAt = input.ExecuteAt
 	}
 
 	return payment, nil
 }
 
+// storePayment saves a prepared payment; payments due now are screened and processed as well
+func (uc *PaymentUseCase) storePayment(ctx context.Context, payment *domain.Payment) error {
+	if payment.Status == domain.PaymentStatusScheduled {
+		return uc.createPayment(ctx, payment)
+	}
+	return uc.submitPayment(ctx, payment)
+}
+
+// submitPayment screens, stores and processes a new payment
+func (uc *PaymentUseCase) submitPayment(ctx context.Context, payment *domain.Payment) error {
+	if err := uc.screenPayment(ctx, payment); err != nil {
+		return err
+	}
+
+	// Save to repository
+	if err := uc.createPayment(ctx, payment); err != nil {
+		return err
+	}
+
+	// Hand the payment to the processor once it is safely stored
+	return uc.processNewPayment(ctx, payment)
+}
+
+// screenPayment runs risk and sanctions screening without saving the payment
+func (uc *PaymentUseCase) screenPayment(ctx context.Context, payment *domain.Payment) error {
+	// Screen the payment before it is stored; denied payments are kept as REJECTED
+	if uc.risk != nil {
+		assessment, err := uc.risk.Assess(ctx, payment)
+		if err != nil {
+			return err
+		}
+		payment.ApplyRiskAssessment(assessment)
+	}
+
+	// Screen counterparties unless the payment was already rejected; hits are held for review
+	if uc.sanctions != nil && payment.Status != domain.PaymentStatusRejected {
+		result, err := uc.sanctions.Screen(ctx, payment)
+		if err != nil {
+			return err
+		}
+		payment.ApplyScreening(result)
+	}
+
+	return nil
+}
+
 // GetPayment retrieves a payment by ID
 func (uc *PaymentUseCase) GetPayment(ctx context.Context, id string) (*domain.Payment, error) {
 	if id == "" {
@@ -101,6 +326,23 @@ func (uc *PaymentUseCase) UpdatePayment(ctx context.Context, input UpdatePayment
 	if err != nil {
 		return nil, err
 	}
+	from := payment.Status
+
+	if len(payment.Splits) > 0 && (input.Amount != nil || input.Currency != nil) {
+		return nil, errors.New("the amount and currency of a split payment cannot change")
+	}
+	if payment.Tax != nil && (input.Amount != nil || input.Currency != nil) {
+		return nil, errors.New("the amount and currency of a taxed payment cannot change")
+	}
+	if payment.Method != nil && (input.Amount != nil || input.Currency != nil) {
+		return nil, errors.New("the amount and currency of a payment with a payment method cannot change")
+	}
+	if len(payment.Fees) > 0 && (input.Amount != nil || input.Currency != nil) {
+		return nil, errors.New("the amount and currency of a payment with fees cannot change")
+	}
+	if payment.Risk != nil && (input.Amount != nil || input.Currency != nil) {
+		return nil, errors.New("the amount and currency of a risk-screened payment cannot change")
+	}
 
 	// Update fields if provided
 	if input.Amount != nil {
@@ -128,17 +370,61 @@ func (uc *PaymentUseCase) UpdatePayment(ctx context.Context, input UpdatePayment
 		payment.Description = strings.TrimSpace(*input.Description)
 
 	}
+	if input.Metadata != nil {
+		if payment.Metadata, err = normalizeMetadata(input.Metadata); err != nil {
+			return nil, err
+		}
+	}
+	if input.Tags != nil {
+		if payment.Tags, err = normalizeTags(input.Tags); err != nil {
+			return nil, err
+		}
+	}
+	// A new amount or currency is converted again at the current rate
+	if input.Amount != nil || input.Currency != nil {
+		settlementCurrency := ""
+		if payment.Settlement != nil {
+			settlementCurrency = payment.Settlement.Amount.Currency
+		}
+		if err := uc.settle(ctx, payment, settlementCurrency); err != nil {
+			return nil, err
+		}
+	}
 	if input.Status != nil {
-		payment.UpdateStatus(*input.Status)
+		if payment.Status == domain.PaymentStatusScreeningHold && *input.Status != domain.PaymentStatusScreeningHold {
+			return nil, errors.New("payment is on screening hold; use resolveScreeningHold")
+		}
+		if payment.Status == domain.PaymentStatusRiskReview && *input.Status != domain.PaymentStatusRiskReview {
+			return nil, errors.New("payment is on risk review; use paymentsctl risk-review")
+		}
+		if payment.Status == domain.PaymentStatusScheduled && *input.Status != domain.PaymentStatusScheduled {
+			return nil, errors.New("payment is scheduled; use reschedulePayment or cancelScheduledPayment")
+		}
+		if *input.Status == domain.PaymentStatusSubmitted && payment.Status != domain.PaymentStatusSubmitted {
+			return nil, errors.New("payments are submitted by creating a credit transfer or ACH file")
+		}
+		if err := payment.TransitionTo(*input.Status); err != nil {
+			return nil, err
+		}
 	} else {
 		payment.UpdatedAt = time.Now() // Update timestamp
 	}
+	// A payment marked completed, such as a settled bank transfer, was captured
+	captured, err := uc.chargeCapture(payment, from)
+	if err != nil {
+		return nil, err
+	}
 
 	// Save updated payment
 	err = uc.repo.Update(ctx, payment)
 	if err != nil {
 		return nil, err
 	}
+	if captured {
+		if err := uc.postCapture(ctx, payment); err != nil {
+			return payment, err
+		}
+	}
 
 	return payment, nil
 }
@@ -181,6 +467,27 @@ func validateAndNormalizeCurrency(currency string) (string, error) {
 	return currency, nil
 }
 
+// normalizeParty trims counterparty fields and validates them
+func normalizeParty(role string, party *domain.Party) (*domain.Party, error) {
+	if party == nil {
+		return nil, nil
+	}
+
+	normalized := &domain.Party{
+		Name:    strings.TrimSpace(party.Name),
+		Account: strings.TrimSpace(party.Account),
+		Country: strings.ToUpper(strings.TrimSpace(party.Country)),
+	}
+	if normalized.Name == "" {
+		return nil, errors.New(role + " name is required")
+	}
+	if normalized.Country != "" && !isValidCountryCode(normalized.Country) {
+		return nil, errors.New(role + " country must be a 2-letter ISO code")
+	}
+
+	return normalized, nil
+}
+
 // isValidCurrencyCode validates that a currency code contains only letters
 func isValidCurrencyCode(currency string) bool {
 	for _, char := range currency {
@@ -190,3 +497,16 @@ func isValidCurrencyCode(currency string) bool {
 	}
 	return true
 }
+
+// isValidCountryCode validates that a country code is two uppercase letters
+func isValidCountryCode(country string) bool {
+	if len(country) != 2 {
+		return false
+	}
+	for _, char := range country {
+		if char < 'A' || char > 'Z' {
+			return false
+		}
+	}
+	return true
+}
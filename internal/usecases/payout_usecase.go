@@ -0,0 +1,247 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payments_app/internal/domain"
+	"payments_app/internal/fx"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrPayoutsNotConfigured is returned when settlement is used without a settlement store
+var ErrPayoutsNotConfigured = errors.New("payouts are not enabled")
+
+// WithPayouts enables daily settlement batches that pay merchants out
+func WithPayouts(repo domain.SettlementRepository) Option {
+	return func(uc *PaymentUseCase) {
+		uc.settlements = repo
+	}
+}
+
+// RunSettlement creates the settlement batch for the day before now (UTC). Completed payments
+// of each merchant created before midnight are netted per settlement currency: their amounts
+// less split shares, refunds, lost disputes and fees. Refunds and lost disputes of payments settled
+// by earlier batches are deducted as well. A merchant
+// whose net total is not positive is not paid and the payments are carried over to the next
+// batch. It returns domain.ErrSettlementBatchExists if the day was settled already.
+func (uc *PaymentUseCase) RunSettlement(ctx context.Context, now time.Time) (*domain.SettlementBatch, error) {
+	if uc.settlements == nil {
+		return nil, ErrPayoutsNotConfigured
+	}
+
+	utc := now.UTC()
+	cutoff := time.Date(utc.Year(), utc.Month(), utc.Day(), 0, 0, 0, 0, time.UTC)
+	batch := &domain.SettlementBatch{
+		ID:        domain.SettlementBatchID(cutoff),
+		CutoffAt:  cutoff,
+		CreatedAt: now,
+	}
+	candidates, err := uc.settlements.SettlementCandidates(ctx, cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	type merchantCurrency struct{ merchant, currency string }
+	payouts := make(map[merchantCurrency]*domain.Payout)
+	for _, candidate := range candidates {
+		item, err := settlementItem(candidate)
+		if err != nil {
+			return nil, err
+		}
+		merchant, currency := candidate.Payment.TenantID, item.Net.Currency
+		key := merchantCurrency{merchant, currency}
+		payout := payouts[key]
+		if payout == nil {
+			zero := domain.Money{Currency: currency}
+			payout = &domain.Payout{
+				ID:          uuid.New().String(),
+				BatchID:     batch.ID,
+				MerchantID:  merchant,
+				Currency:    currency,
+				Gross:       zero,
+				Refunds:     zero,
+				Chargebacks: zero,
+				Fees:        zero,
+				Net:         zero,
+				Status:      domain.PayoutStatusCreated,
+				CreatedAt:   now,
+				UpdatedAt:   now,
+			}
+			payouts[key] = payout
+		}
+
+		item.PayoutID = payout.ID
+		payout.Items = append(payout.Items, item)
+		payout.Gross.MinorUnits += item.Gross.MinorUnits
+		payout.Refunds.MinorUnits += item.Refunds.MinorUnits
+		payout.Chargebacks.MinorUnits += item.Chargebacks.MinorUnits
+		payout.Fees.MinorUnits += item.Fees.MinorUnits
+		payout.Net.MinorUnits += item.Net.MinorUnits
+	}
+
+	for _, payout := range payouts {
+		if payout.Net.MinorUnits > 0 {
+			batch.Payouts = append(batch.Payouts, payout)
+		}
+	}
+	sort.Slice(batch.Payouts, func(i, j int) bool {
+		a, b := batch.Payouts[i], batch.Payouts[j]
+		if a.MerchantID != b.MerchantID {
+			return a.MerchantID < b.MerchantID
+		}
+		return a.Currency < b.Currency
+	})
+
+	if err := uc.settlements.CreateBatch(ctx, batch); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// settlementItem nets one payment in the currency it settles in: the amounts converted at the
+// payment's settlement rate when it has one, its own currency otherwise. Split shares are
+// credited to their recipients, whose shares also bear the refunds, so only the rest of the
+// amount is paid to the merchant. Lost disputes are not shared, so the merchant bears them in
+// full. A payment that was settled before contributes only the refunds and lost disputes since.
+func settlementItem(candidate domain.SettlementCandidate) (domain.PayoutItem, error) {
+	payment := candidate.Payment
+	gross := domain.Money{Currency: payment.Currency}
+	refunds := domain.MoneyFromFloat(payment.RefundedAmount, payment.Currency)
+	chargebacks := domain.MoneyFromFloat(candidate.ChargedBack, payment.Currency)
+	fees := domain.Money{Currency: payment.Currency}
+	if candidate.Settled {
+		refunds.MinorUnits -= domain.MoneyFromFloat(candidate.SettledRefunds, payment.Currency).MinorUnits
+		chargebacks.MinorUnits -= domain.MoneyFromFloat(candidate.SettledChargebacks, payment.Currency).MinorUnits
+	} else {
+		gross = domain.MoneyFromFloat(payment.Amount, payment.Currency)
+		for _, split := range payment.Splits {
+			gross.MinorUnits -= split.Amount.MinorUnits
+		}
+		for _, fee := range payment.Fees {
+			fees.MinorUnits += fee.Amount.MinorUnits
+		}
+	}
+	if len(payment.Splits) > 0 {
+		refunds.MinorUnits = 0
+	}
+
+	item := domain.PayoutItem{
+		PaymentID:         payment.ID,
+		Gross:             gross,
+		Refunds:           refunds,
+		Chargebacks:       chargebacks,
+		Fees:              fees,
+		RefundedAmount:    payment.RefundedAmount,
+		ChargedBackAmount: candidate.ChargedBack,
+	}
+	if payment.Settlement != nil {
+		var err error
+		for _, amount := range []*domain.Money{&item.Gross, &item.Refunds, &item.Chargebacks, &item.Fees} {
+			if *amount, err = fx.Convert(*amount, payment.Settlement.Rate); err != nil {
+				return domain.PayoutItem{}, fmt.Errorf("payment %s: %w", payment.ID, err)
+			}
+		}
+	}
+	item.Net = domain.Money{
+		MinorUnits: item.Gross.MinorUnits - item.Refunds.MinorUnits - item.Chargebacks.MinorUnits - item.Fees.MinorUnits,
+		Currency:   item.Gross.Currency,
+	}
+	return item, nil
+}
+
+// GetSettlementBatch returns a settlement batch with its payouts and the payments each contains
+func (uc *PaymentUseCase) GetSettlementBatch(ctx context.Context, id string) (*domain.SettlementBatch, error) {
+	if uc.settlements == nil {
+		return nil, ErrPayoutsNotConfigured
+	}
+	return uc.settlements.GetBatch(ctx, strings.TrimSpace(id))
+}
+
+// GetPayout returns a payout with the payments it contains
+func (uc *PaymentUseCase) GetPayout(ctx context.Context, id string) (*domain.Payout, error) {
+	if uc.settlements == nil {
+		return nil, ErrPayoutsNotConfigured
+	}
+	return uc.settlements.GetPayout(ctx, strings.TrimSpace(id))
+}
+
+// ListPayouts returns matching payouts, newest first
+func (uc *PaymentUseCase) ListPayouts(ctx context.Context, filter domain.PayoutFilter) ([]*domain.Payout, error) {
+	if uc.settlements == nil {
+		return nil, ErrPayoutsNotConfigured
+	}
+	for _, status := range filter.Statuses {
+		if !status.IsValid() {
+			return nil, fmt.Errorf("invalid payout status %q", status)
+		}
+	}
+	return uc.settlements.ListPayouts(ctx, filter)
+}
+
+// MarkPayoutSent records that a payout was handed to the bank under the given reference
+func (uc *PaymentUseCase) MarkPayoutSent(ctx context.Context, id, reference string) (*domain.Payout, error) {
+	return uc.transitionPayout(ctx, id, domain.PayoutStatusSent, func(payout *domain.Payout) {
+		payout.Reference = strings.TrimSpace(reference)
+	})
+}
+
+// MarkPayoutPaid records that the merchant received a payout and posts it to the ledger
+func (uc *PaymentUseCase) MarkPayoutPaid(ctx context.Context, id string) (*domain.Payout, error) {
+	payout, err := uc.transitionPayout(ctx, id, domain.PayoutStatusPaid, nil)
+	if err != nil {
+		return nil, err
+	}
+	if uc.ledger != nil {
+		entry := &domain.JournalEntry{
+			ID:          "payout:" + payout.ID,
+			Description: fmt.Sprintf("Payout %s to merchant %s", payout.ID, payout.MerchantID),
+			Postings: []domain.Posting{
+				{Account: domain.AccountMerchantPayable, Currency: payout.Currency, Amount: payout.Net.Float64()},
+				{Account: domain.AccountProcessorBalance, Currency: payout.Currency, Amount: -payout.Net.Float64()},
+			},
+			CreatedAt: time.Now(),
+		}
+		if err := uc.ledger.Post(ctx, entry); err != nil && !errors.Is(err, domain.ErrDuplicateJournalEntry) {
+			return nil, fmt.Errorf("payout %s was marked paid, but could not be posted: %w", payout.ID, err)
+		}
+	}
+	return payout, nil
+}
+
+// MarkPayoutFailed records that a payout will not arrive. Its payments are settled again by
+// the next batch.
+func (uc *PaymentUseCase) MarkPayoutFailed(ctx context.Context, id, reason string) (*domain.Payout, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("failure reason is required")
+	}
+	return uc.transitionPayout(ctx, id, domain.PayoutStatusFailed, func(payout *domain.Payout) {
+		payout.FailureReason = reason
+	})
+}
+
+// transitionPayout moves a payout to status, applies update and stores it
+func (uc *PaymentUseCase) transitionPayout(ctx context.Context, id string, status domain.PayoutStatus, update func(*domain.Payout)) (*domain.Payout, error) {
+	if uc.settlements == nil {
+		return nil, ErrPayoutsNotConfigured
+	}
+	payout, err := uc.settlements.GetPayout(ctx, strings.TrimSpace(id))
+	if err != nil {
+		return nil, err
+	}
+	if err := payout.Transition(status); err != nil {
+		return nil, err
+	}
+	if update != nil {
+		update(payout)
+	}
+	if err := uc.settlements.UpdatePayout(ctx, payout); err != nil {
+		return nil, err
+	}
+	return payout, nil
+}
@@ -0,0 +1,412 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payments_app/internal/domain"
+	"strings"
+)
+
+// ErrNoProcessor is returned when a processor operation is requested without a configured processor
+var ErrNoProcessor = errors.New("no payment processor configured")
+
+// ProcessorDeclinedError is returned when a processor declines a capture, refund or void
+type ProcessorDeclinedError struct {
+	Operation string
+	Code      string
+	Message   string
+}
+
+func (e *ProcessorDeclinedError) Error() string {
+	return fmt.Sprintf("processor declined %s: %s (%s)", e.Operation, e.Message, e.Code)
+}
+
+// StoredPaymentError is returned when a new payment was stored but a later step failed. The
+// payment exists with the state it reached, so callers must not create it again.
+type StoredPaymentError struct {
+	PaymentID string
+	Err       error
+}
+
+func (e *StoredPaymentError) Error() string { return e.Err.Error() }
+
+func (e *StoredPaymentError) Unwrap() error { return e.Err }
+
+// ProcessorRouter chooses processors for new payments and fails over between them
+type ProcessorRouter interface {
+	Processors() []domain.Processor
+	Authorize(ctx context.Context, payment *domain.Payment, req domain.AuthorizeRequest) (domain.Processor, *domain.ProcessorResult, []domain.RouteAttempt, error)
+	Stats() []domain.ProcessorStats
+}
+
+// WithProcessor registers a processor; the first one registered handles new payments
+func WithProcessor(processor domain.Processor) Option {
+	return func(uc *PaymentUseCase) {
+		if uc.processors == nil {
+			uc.processors = make(map[string]domain.Processor)
+		}
+		uc.processors[processor.Name()] = processor
+		if uc.defaultProcessor == "" {
+			uc.defaultProcessor = processor.Name()
+		}
+	}
+}
+
+// WithRouter routes new payments across the router's processors instead of a single default processor
+func WithRouter(router ProcessorRouter) Option {
+	return func(uc *PaymentUseCase) {
+		if uc.processors == nil {
+			uc.processors = make(map[string]domain.Processor)
+		}
+		for _, processor := range router.Processors() {
+			uc.processors[processor.Name()] = processor
+		}
+		uc.router = router
+	}
+}
+
+// WithAutoCapture captures payments immediately after a successful authorization
+func WithAutoCapture(enabled bool) Option {
+	return func(uc *PaymentUseCase) {
+		uc.autoCapture = enabled
+	}
+}
+
+// AuthorizePayment sends a pending payment to the processor for authorization
+func (uc *PaymentUseCase) AuthorizePayment(ctx context.Context, id string) (*domain.Payment, error) {
+	payment, err := uc.paymentInStatus(ctx, id, domain.PaymentStatusPending)
+	if err != nil {
+		return nil, err
+	}
+	if payment.ProcessorReference != "" {
+		return nil, errors.New("payment authorization is already in progress")
+	}
+
+	if err := uc.authorize(ctx, payment); err != nil {
+		return nil, err
+	}
+	if err := uc.repo.Update(ctx, payment); err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// CapturePayment captures the full amount of an authorized payment
+func (uc *PaymentUseCase) CapturePayment(ctx context.Context, id string) (*domain.Payment, error) {
+	payment, err := uc.paymentInStatus(ctx, id, domain.PaymentStatusAuthorized)
+	if err != nil {
+		return nil, err
+	}
+
+	return payment, uc.runOperation(ctx, payment, "capture", domain.PaymentStatusCompleted,
+		func(processor domain.Processor) (*domain.ProcessorResult, error) {
+			return processor.Capture(ctx, payment.ProcessorReference, payment.Amount)
+		})
+}
+
+// VoidPayment cancels an authorization that has not been captured
+func (uc *PaymentUseCase) VoidPayment(ctx context.Context, id string) (*domain.Payment, error) {
+	payment, err := uc.paymentInStatus(ctx, id, domain.PaymentStatusAuthorized)
+	if err != nil {
+		return nil, err
+	}
+
+	return payment, uc.runOperation(ctx, payment, "void", domain.PaymentStatusCancelled,
+		func(processor domain.Processor) (*domain.ProcessorResult, error) {
+			return processor.Void(ctx, payment.ProcessorReference)
+		})
+}
+
+// RefundPayment refunds a completed payment; a nil amount refunds the remaining balance
+func (uc *PaymentUseCase) RefundPayment(ctx context.Context, id string, amount *float64) (*domain.Payment, error) {
+	payment, err := uc.paymentInStatus(ctx, id, domain.PaymentStatusCompleted)
+	if err != nil {
+		return nil, err
+	}
+
+	// Money lost to a chargeback has already gone back to the payer
+	lost, err := uc.lostDisputeAmount(ctx, payment)
+	if err != nil {
+		return nil, err
+	}
+	remaining := payment.DisputableAmount(lost)
+	refund := remaining
+	if amount != nil {
+		refund = *amount
+	}
+	if refund <= 0 {
+		return nil, errors.New("refund amount must be greater than 0")
+	}
+	if refund > remaining+0.005 {
+		return nil, fmt.Errorf("refund amount exceeds refundable balance of %.2f", remaining)
+	}
+
+	fullRefund := refund >= remaining-0.005
+	target := domain.PaymentStatusCompleted
+	if fullRefund {
+		target = domain.PaymentStatusRefunded
+	}
+
+	err = uc.runOperation(ctx, payment, "refund", target,
+		func(processor domain.Processor) (*domain.ProcessorResult, error) {
+			result, err := processor.Refund(ctx, payment.ProcessorReference, refund)
+			if err == nil && result.Outcome == domain.ProcessorOutcomeApproved {
+				payment.RefundedAmount += refund
+			}
+			return result, err
+		})
+	return payment, err
+}
+
+// SyncPaymentStatus asks the processor for the payment's current status and applies it
+func (uc *PaymentUseCase) SyncPaymentStatus(ctx context.Context, id string) (*domain.Payment, error) {
+	if id == "" {
+		return nil, errors.New("payment ID is required")
+	}
+	payment, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	processor, err := uc.processorFor(payment)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := processor.GetStatus(ctx, payment.ProcessorReference)
+	if err != nil {
+		return nil, err
+	}
+	from := payment.Status
+	if result.Status != "" && result.Status != payment.Status {
+		if err := payment.TransitionTo(result.Status); err != nil {
+			return nil, err
+		}
+	}
+	captured, err := uc.chargeCapture(payment, from)
+	if err != nil {
+		return nil, err
+	}
+	recordResponse(payment, result)
+
+	if err := uc.repo.Update(ctx, payment); err != nil {
+		return nil, err
+	}
+	if captured {
+		if err := uc.postCapture(ctx, payment); err != nil {
+			return payment, err
+		}
+	}
+	if err := uc.releaseInvoiceAllocations(ctx, payment); err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// processNewPayment authorizes, and optionally captures, a freshly stored payment.
+// Processor outages leave the payment PENDING so it can be authorized later. Other failures
+// are returned as *StoredPaymentError after the state the payment reached is saved.
+func (uc *PaymentUseCase) processNewPayment(ctx context.Context, payment *domain.Payment) error {
+	if (uc.defaultProcessor == "" && uc.router == nil) || payment.Status != domain.PaymentStatusPending {
+		return nil
+	}
+
+	saved, err := uc.authorizeNewPayment(ctx, payment)
+	if !saved || err != nil {
+		// Keep what the processor did, such as an authorization, even when a later step failed
+		if updateErr := uc.repo.Update(ctx, payment); updateErr != nil {
+			err = errors.Join(err, updateErr)
+		}
+	}
+	if err != nil {
+		return &StoredPaymentError{PaymentID: payment.ID, Err: fmt.Errorf("payment %s was saved, but processing it failed: %w", payment.ID, err)}
+	}
+	return nil
+}
+
+// authorizeNewPayment authorizes a new payment and captures it under auto capture. It
+// reports whether the capture saved the payment.
+func (uc *PaymentUseCase) authorizeNewPayment(ctx context.Context, payment *domain.Payment) (bool, error) {
+	if err := uc.authorize(ctx, payment); err != nil {
+		if !errors.Is(err, domain.ErrProcessorUnavailable) {
+			return false, err
+		}
+		payment.ProcessorResponse = err.Error()
+	}
+	if !uc.autoCapture || payment.Status != domain.PaymentStatusAuthorized {
+		return false, nil
+	}
+
+	err := uc.runOperation(ctx, payment, "capture", domain.PaymentStatusCompleted,
+		func(processor domain.Processor) (*domain.ProcessorResult, error) {
+			return processor.Capture(ctx, payment.ProcessorReference, payment.Amount)
+		})
+	var declined *ProcessorDeclinedError
+	if err == nil || errors.As(err, &declined) {
+		return true, nil
+	}
+	if !errors.Is(err, domain.ErrProcessorUnavailable) {
+		return false, err
+	}
+	// The authorization stands; the capture can be retried with capturePayment
+	payment.ProcessorResponse = err.Error()
+	return false, nil
+}
+
+// ProcessorStats reports the health of the routed processors
+func (uc *PaymentUseCase) ProcessorStats() []domain.ProcessorStats {
+	if uc.router == nil {
+		return nil
+	}
+	return uc.router.Stats()
+}
+
+// authorize calls the router, or the default processor, and applies the outcome without saving
+func (uc *PaymentUseCase) authorize(ctx context.Context, payment *domain.Payment) error {
+	req := domain.AuthorizeRequest{
+		PaymentID:   payment.ID,
+		Amount:      payment.Amount,
+		Currency:    payment.Currency,
+		Description: payment.Description,
+		Method:      payment.Method,
+	}
+
+	processor, result, err := uc.sendAuthorization(ctx, payment, req)
+	if err != nil {
+		return err
+	}
+
+	payment.Processor = processor.Name()
+	payment.ProcessorReference = result.Reference
+	recordResponse(payment, result)
+
+	switch result.Outcome {
+	case domain.ProcessorOutcomeApproved:
+		return payment.TransitionTo(domain.PaymentStatusAuthorized)
+	case domain.ProcessorOutcomeDeclined:
+		return payment.TransitionTo(domain.PaymentStatusFailed)
+	}
+	return nil
+}
+
+// sendAuthorization authorizes through the router when configured, recording the route taken,
+// and through the default processor otherwise
+func (uc *PaymentUseCase) sendAuthorization(ctx context.Context, payment *domain.Payment, req domain.AuthorizeRequest) (domain.Processor, *domain.ProcessorResult, error) {
+	if uc.router != nil {
+		processor, result, route, err := uc.router.Authorize(ctx, payment, req)
+		payment.Route = append(payment.Route, route...)
+		return processor, result, err
+	}
+
+	processor, exists := uc.processors[uc.defaultProcessor]
+	if !exists {
+		return nil, nil, ErrNoProcessor
+	}
+	result, err := processor.Authorize(ctx, req)
+	return processor, result, err
+}
+
+// runOperation calls the payment's processor, applies an approved outcome and saves the payment.
+// Declines are saved with the processor response and reported as ProcessorDeclinedError.
+func (uc *PaymentUseCase) runOperation(ctx context.Context, payment *domain.Payment, operation string, target domain.PaymentStatus,
+	call func(processor domain.Processor) (*domain.ProcessorResult, error)) error {
+	processor, err := uc.processorFor(payment)
+	if err != nil {
+		return err
+	}
+
+	result, err := call(processor)
+	if err != nil {
+		return err
+	}
+	recordResponse(payment, result)
+
+	var declined error
+	captured, refunded := false, false
+	var reversals []domain.BalanceEntry
+	switch result.Outcome {
+	case domain.ProcessorOutcomeApproved:
+		from := payment.Status
+		if err := payment.TransitionTo(target); err != nil {
+			return err
+		}
+		if captured, err = uc.chargeCapture(payment, from); err != nil {
+			return err
+		}
+		if operation == "refund" {
+			reversals = reverseSplits(payment)
+			refunded = true
+		}
+	case domain.ProcessorOutcomeDeclined:
+		declined = &ProcessorDeclinedError{Operation: operation, Code: result.Code, Message: result.Message}
+	}
+
+	if err := uc.repo.Update(ctx, payment); err != nil {
+		return err
+	}
+	if captured {
+		if err := uc.postCapture(ctx, payment); err != nil {
+			return err
+		}
+	}
+	if err := uc.recordReversals(ctx, payment, reversals); err != nil {
+		return err
+	}
+	if refunded {
+		if err := uc.releaseInvoiceAllocations(ctx, payment); err != nil {
+			return err
+		}
+	}
+	return declined
+}
+
+// chargeCapture charges the capture fees of a payment that a transition from the given status
+// moved to COMPLETED, without saving it. It reports whether the payment was captured, in which
+// case postCapture is due once the payment is saved.
+func (uc *PaymentUseCase) chargeCapture(payment *domain.Payment, from domain.PaymentStatus) (bool, error) {
+	if from == domain.PaymentStatusCompleted || payment.Status != domain.PaymentStatusCompleted {
+		return false, nil
+	}
+	return true, uc.chargeFees(payment, domain.FeeEventCaptured)
+}
+
+// postCapture posts the capture fees of a saved, captured payment and credits its recipients
+func (uc *PaymentUseCase) postCapture(ctx context.Context, payment *domain.Payment) error {
+	if err := uc.postFees(ctx, payment, domain.FeeEventCaptured); err != nil {
+		return err
+	}
+	return uc.creditSplits(ctx, payment)
+}
+
+// processorFor returns the processor that handled a payment
+func (uc *PaymentUseCase) processorFor(payment *domain.Payment) (domain.Processor, error) {
+	if payment.Processor == "" || payment.ProcessorReference == "" {
+		return nil, errors.New("payment has not been sent to a processor")
+	}
+	processor, exists := uc.processors[payment.Processor]
+	if !exists {
+		return nil, fmt.Errorf("processor %s is not configured", payment.Processor)
+	}
+	return processor, nil
+}
+
+// paymentInStatus loads a payment and checks it is in the expected status
+func (uc *PaymentUseCase) paymentInStatus(ctx context.Context, id string, status domain.PaymentStatus) (*domain.Payment, error) {
+	if id == "" {
+		return nil, errors.New("payment ID is required")
+	}
+	payment, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != status {
+		return nil, fmt.Errorf("payment must be %s, but is %s", status, payment.Status)
+	}
+	return payment, nil
+}
+
+// recordResponse stores the processor's latest code and message on the payment
+func recordResponse(payment *domain.Payment, result *domain.ProcessorResult) {
+	payment.ProcessorResponse = strings.TrimSpace(result.Code + " " + result.Message)
+}
@@ -0,0 +1,277 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payments_app/internal/domain"
+	"strings"
+	"time"
+)
+
+// ErrReconciliationNotConfigured is returned when reconciliation is used without a statement store
+var ErrReconciliationNotConfigured = errors.New("bank reconciliation is not enabled")
+
+// reconcilableStatuses are the payment states whose money is expected on a bank statement;
+// submitted bank transfers show up as debits once the bank executes them
+var reconcilableStatuses = []domain.PaymentStatus{domain.PaymentStatusCompleted, domain.PaymentStatusRefunded, domain.PaymentStatusSubmitted}
+
+// StatementMatcher pairs statement lines with the payments they most likely record
+type StatementMatcher interface {
+	Match(lines []*domain.StatementLine, payments []*domain.Payment) []domain.StatementMatch
+}
+
+// WithReconciliation enables bank statement import and matching
+func WithReconciliation(repo domain.ReconciliationRepository, matcher StatementMatcher) Option {
+	return func(uc *PaymentUseCase) {
+		uc.statements = repo
+		uc.matcher = matcher
+	}
+}
+
+// ReconciliationRun counts the outcome of a matching run over unmatched statement lines
+type ReconciliationRun struct {
+	// Matched lines were accepted without review
+	Matched int `json:"matched"`
+	// Proposed lines await confirmation or rejection
+	Proposed  int `json:"proposed"`
+	Unmatched int `json:"unmatched"`
+}
+
+// StatementImport is the result of importing a statement file
+type StatementImport struct {
+	Statements []*domain.Statement `json:"statements"`
+	// Run covers every unmatched line, since new payments may match lines imported earlier
+	Run ReconciliationRun `json:"run"`
+}
+
+// ImportStatements stores parsed statements and matches their lines against payments
+func (uc *PaymentUseCase) ImportStatements(ctx context.Context, statements []*domain.Statement) (*StatementImport, error) {
+	if uc.statements == nil {
+		return nil, ErrReconciliationNotConfigured
+	}
+	if len(statements) == 0 {
+		return nil, errors.New("no statements to import")
+	}
+	for _, statement := range statements {
+		if len(statement.Currency) != 3 || !isValidCurrencyCode(statement.Currency) {
+			return nil, fmt.Errorf("statement %s: invalid currency %q", statement.Reference, statement.Currency)
+		}
+	}
+
+	if err := uc.statements.CreateStatements(ctx, statements); err != nil {
+		return nil, err
+	}
+	run, err := uc.Reconcile(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &StatementImport{Run: *run}
+	for _, statement := range statements {
+		stored, err := uc.statements.GetStatement(ctx, statement.ID)
+		if err != nil {
+			return nil, err
+		}
+		result.Statements = append(result.Statements, stored)
+	}
+	return result, nil
+}
+
+// Reconcile matches unmatched statement lines against payments not yet reconciled. Certain
+// matches are accepted; the others are proposed for review.
+func (uc *PaymentUseCase) Reconcile(ctx context.Context) (*ReconciliationRun, error) {
+	if uc.statements == nil {
+		return nil, ErrReconciliationNotConfigured
+	}
+	lines, err := uc.statements.ListLines(ctx, domain.StatementLineFilter{
+		Statuses: []domain.StatementLineStatus{domain.StatementLineStatusUnmatched},
+	})
+	if err != nil {
+		return nil, err
+	}
+	payments, err := uc.statements.UnmatchedPayments(ctx, reconcilableStatuses)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &ReconciliationRun{Unmatched: len(lines)}
+	now := time.Now()
+	for _, match := range uc.matcher.Match(lines, payments) {
+		line := match.Line
+		line.PaymentID = match.Payment.ID
+		line.Confidence = match.Score
+		line.Status = domain.StatementLineStatusProposed
+		if match.Auto {
+			line.Status = domain.StatementLineStatusMatched
+			line.MatchMethod = domain.MatchMethodAuto
+			line.MatchedAt = &now
+		}
+		if err := uc.statements.UpdateLine(ctx, line); err != nil {
+			// A reviewer matched the payment meanwhile; the line stays for the next run
+			if errors.Is(err, domain.ErrPaymentAlreadyReconciled) {
+				continue
+			}
+			return nil, err
+		}
+		run.Unmatched--
+		if match.Auto {
+			run.Matched++
+		} else {
+			run.Proposed++
+		}
+	}
+	return run, nil
+}
+
+// ConfirmStatementMatch accepts the payment the engine proposed for a line
+func (uc *PaymentUseCase) ConfirmStatementMatch(ctx context.Context, lineID, confirmedBy string) (*domain.StatementLine, error) {
+	line, err := uc.statementLine(ctx, lineID)
+	if err != nil {
+		return nil, err
+	}
+	confirmedBy = strings.TrimSpace(confirmedBy)
+	if confirmedBy == "" {
+		return nil, errors.New("reviewer is required")
+	}
+	if line.Status != domain.StatementLineStatusProposed {
+		return nil, fmt.Errorf("statement line is %s, only proposed matches can be confirmed", line.Status)
+	}
+
+	now := time.Now()
+	line.Status = domain.StatementLineStatusMatched
+	line.MatchMethod = domain.MatchMethodConfirmed
+	line.MatchedBy = confirmedBy
+	line.MatchedAt = &now
+	if err := uc.statements.UpdateLine(ctx, line); err != nil {
+		return nil, err
+	}
+	return line, nil
+}
+
+// RejectStatementMatch returns a proposed or matched line to the unmatched queue; the
+// payment is not proposed for the line again
+func (uc *PaymentUseCase) RejectStatementMatch(ctx context.Context, lineID string) (*domain.StatementLine, error) {
+	line, err := uc.statementLine(ctx, lineID)
+	if err != nil {
+		return nil, err
+	}
+	if line.Status == domain.StatementLineStatusUnmatched {
+		return nil, errors.New("statement line has no match to reject")
+	}
+
+	if !line.Rejected(line.PaymentID) {
+		line.RejectedPaymentIDs = append(line.RejectedPaymentIDs, line.PaymentID)
+	}
+	clearStatementMatch(line)
+	if err := uc.statements.UpdateLine(ctx, line); err != nil {
+		return nil, err
+	}
+	return line, nil
+}
+
+// MatchStatementLine matches a line to a payment chosen by a reviewer. A line matched
+// already must be rejected first.
+func (uc *PaymentUseCase) MatchStatementLine(ctx context.Context, lineID, paymentID, matchedBy string) (*domain.StatementLine, error) {
+	line, err := uc.statementLine(ctx, lineID)
+	if err != nil {
+		return nil, err
+	}
+	matchedBy = strings.TrimSpace(matchedBy)
+	if matchedBy == "" {
+		return nil, errors.New("reviewer is required")
+	}
+	if line.Status == domain.StatementLineStatusMatched {
+		return nil, errors.New("statement line is already matched; reject the match first")
+	}
+	payment, err := uc.GetPayment(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Currency != line.Amount.Currency {
+		return nil, fmt.Errorf("payment currency %s does not match statement line currency %s", payment.Currency, line.Amount.Currency)
+	}
+	if !isReconcilable(payment.Status) {
+		return nil, fmt.Errorf("payment is %s, only completed, refunded or submitted payments can be reconciled", payment.Status)
+	}
+
+	now := time.Now()
+	line.Status = domain.StatementLineStatusMatched
+	line.PaymentID = payment.ID
+	line.Confidence = 1
+	line.MatchMethod = domain.MatchMethodManual
+	line.MatchedBy = matchedBy
+	line.MatchedAt = &now
+	// A reviewer choosing the payment overrides an earlier rejection
+	for i, id := range line.RejectedPaymentIDs {
+		if id == payment.ID {
+			line.RejectedPaymentIDs = append(line.RejectedPaymentIDs[:i:i], line.RejectedPaymentIDs[i+1:]...)
+			break
+		}
+	}
+	if err := uc.statements.UpdateLine(ctx, line); err != nil {
+		return nil, err
+	}
+	return line, nil
+}
+
+// GetStatement retrieves an imported statement with its lines
+func (uc *PaymentUseCase) GetStatement(ctx context.Context, id string) (*domain.Statement, error) {
+	if uc.statements == nil {
+		return nil, ErrReconciliationNotConfigured
+	}
+	if id == "" {
+		return nil, errors.New("statement ID is required")
+	}
+	return uc.statements.GetStatement(ctx, id)
+}
+
+// UnreconciledStatementLines returns the lines awaiting review: unmatched lines and proposed
+// matches, optionally of one statement
+func (uc *PaymentUseCase) UnreconciledStatementLines(ctx context.Context, statementID string) ([]*domain.StatementLine, error) {
+	if uc.statements == nil {
+		return nil, ErrReconciliationNotConfigured
+	}
+	return uc.statements.ListLines(ctx, domain.StatementLineFilter{
+		StatementID: statementID,
+		Statuses:    []domain.StatementLineStatus{domain.StatementLineStatusUnmatched, domain.StatementLineStatusProposed},
+	})
+}
+
+// UnreconciledPayments returns completed, refunded and submitted payments no statement line accounts for
+func (uc *PaymentUseCase) UnreconciledPayments(ctx context.Context) ([]*domain.Payment, error) {
+	if uc.statements == nil {
+		return nil, ErrReconciliationNotConfigured
+	}
+	return uc.statements.UnmatchedPayments(ctx, reconcilableStatuses)
+}
+
+// statementLine loads a line for review
+func (uc *PaymentUseCase) statementLine(ctx context.Context, id string) (*domain.StatementLine, error) {
+	if uc.statements == nil {
+		return nil, ErrReconciliationNotConfigured
+	}
+	if id == "" {
+		return nil, errors.New("statement line ID is required")
+	}
+	return uc.statements.GetLine(ctx, id)
+}
+
+// clearStatementMatch returns a line to the unmatched state
+func clearStatementMatch(line *domain.StatementLine) {
+	line.Status = domain.StatementLineStatusUnmatched
+	line.PaymentID = ""
+	line.Confidence = 0
+	line.MatchMethod = ""
+	line.MatchedBy = ""
+	line.MatchedAt = nil
+}
+
+func isReconcilable(status domain.PaymentStatus) bool {
+	for _, s := range reconcilableStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payments_app/internal/domain"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// scheduledLease is how long a runner may take to execute a claimed payment before
+	// another runner may claim it again
+	scheduledLease = 5 * time.Minute
+	// scheduledBatchSize caps how many due payments one run executes
+	scheduledBatchSize = 100
+)
+
+// ErrSchedulingNotConfigured is returned when a payment is scheduled without a scheduled payment store
+var ErrSchedulingNotConfigured = errors.New("scheduled payments are not enabled")
+
+// WithScheduledPayments enables future-dated payments executed by RunScheduledPayments
+func WithScheduledPayments(store domain.ScheduledPaymentRepository) Option {
+	return func(uc *PaymentUseCase) {
+		uc.scheduled = store
+	}
+}
+
+// ReschedulePayment moves the execution date of a payment that has not been executed yet
+func (uc *PaymentUseCase) ReschedulePayment(ctx context.Context, id string, executeAt time.Time) (*domain.Payment, error) {
+	now := time.Now()
+	if !executeAt.After(now) {
+		return nil, errors.New("execution time must be in the future")
+	}
+	payment, err := uc.scheduledPayment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	payment.ExecuteAt = &executeAt
+	payment.UpdatedAt = now
+	if err := uc.scheduled.UpdateScheduled(ctx, payment, now); err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// CancelScheduledPayment cancels a payment that has not been executed yet
+func (uc *PaymentUseCase) CancelScheduledPayment(ctx context.Context, id string) (*domain.Payment, error) {
+	payment, err := uc.scheduledPayment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := payment.TransitionTo(domain.PaymentStatusCancelled); err != nil {
+		return nil, err
+	}
+	if err := uc.scheduled.UpdateScheduled(ctx, payment, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// RunScheduledPayments executes the scheduled payments due at now and returns how many were
+// executed. Each payment is leased first and saved only while the lease is held, so a payment
+// is promoted exactly once even when several instances run or one restarts midway.
+func (uc *PaymentUseCase) RunScheduledPayments(ctx context.Context, now time.Time) (int, error) {
+	if uc.scheduled == nil {
+		return 0, ErrSchedulingNotConfigured
+	}
+
+	owner := uuid.New().String()
+	due, err := uc.scheduled.ClaimDue(ctx, owner, now, scheduledLease, scheduledBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	executed := 0
+	var errs []error
+	for _, payment := range due {
+		if err := ctx.Err(); err != nil {
+			return executed, err
+		}
+		ok, err := uc.executeScheduled(ctx, payment, owner)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("payment %s: %w", payment.ID, err))
+			continue
+		}
+		if ok {
+			executed++
+		}
+	}
+	return executed, errors.Join(errs...)
+}
+
+// executeScheduled screens a claimed payment, saves it while the lease is held and then
+// hands it to the processor; it reports false when the lease was lost to another runner
+func (uc *PaymentUseCase) executeScheduled(ctx context.Context, payment *domain.Payment, owner string) (bool, error) {
+	if err := payment.TransitionTo(domain.PaymentStatusPending); err != nil {
+		return false, err
+	}
+	if err := uc.screenPayment(ctx, payment); err != nil {
+		return false, err
+	}
+
+	ok, err := uc.scheduled.CompleteScheduled(ctx, payment, owner)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	return true, uc.processNewPayment(ctx, payment)
+}
+
+// scheduledPayment loads a payment that is still waiting for execution
+func (uc *PaymentUseCase) scheduledPayment(ctx context.Context, id string) (*domain.Payment, error) {
+	if uc.scheduled == nil {
+		return nil, ErrSchedulingNotConfigured
+	}
+	payment, err := uc.GetPayment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != domain.PaymentStatusScheduled {
+		return nil, domain.ErrPaymentNotScheduled
+	}
+	return payment, nil
+}
@@ -0,0 +1,78 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"payments_app/internal/domain"
+	"strings"
+)
+
+// ResolveScreeningHoldInput represents an analyst decision on a held payment
+type ResolveScreeningHoldInput struct {
+	PaymentID string `json:"paymentId"`
+	Release   bool   `json:"release"`
+	Analyst   string `json:"analyst"`
+	Note      string `json:"note,omitempty"`
+}
+
+// ResolveScreeningHold releases a payment held by sanctions screening, which is then processed
+// like a new payment, or rejects it. A processing failure is returned as *StoredPaymentError.
+func (uc *PaymentUseCase) ResolveScreeningHold(ctx context.Context, input ResolveScreeningHoldInput) (*domain.Payment, error) {
+	if input.PaymentID == "" {
+		return nil, errors.New("payment ID is required")
+	}
+	analyst := strings.TrimSpace(input.Analyst)
+	if analyst == "" {
+		return nil, errors.New("analyst is required")
+	}
+
+	payment, err := uc.repo.GetByID(ctx, input.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := payment.ResolveScreeningHold(input.Release, analyst, strings.TrimSpace(input.Note)); err != nil {
+		return nil, err
+	}
+
+	if err := uc.repo.Update(ctx, payment); err != nil {
+		return nil, err
+	}
+
+	return payment, uc.processNewPayment(ctx, payment)
+}
+
+// ResolveRiskReviewInput represents an analyst decision on a payment held for risk review
+type ResolveRiskReviewInput struct {
+	PaymentID string `json:"paymentId"`
+	Approve   bool   `json:"approve"`
+	Analyst   string `json:"analyst"`
+	Note      string `json:"note,omitempty"`
+}
+
+// ResolveRiskReview approves a payment held for risk review, which is then processed like a
+// new payment, or rejects it. A processing failure is returned as *StoredPaymentError.
+func (uc *PaymentUseCase) ResolveRiskReview(ctx context.Context, input ResolveRiskReviewInput) (*domain.Payment, error) {
+	if input.PaymentID == "" {
+		return nil, errors.New("payment ID is required")
+	}
+	analyst := strings.TrimSpace(input.Analyst)
+	if analyst == "" {
+		return nil, errors.New("analyst is required")
+	}
+
+	payment, err := uc.repo.GetByID(ctx, input.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := payment.ResolveRiskReview(input.Approve, analyst, strings.TrimSpace(input.Note)); err != nil {
+		return nil, err
+	}
+
+	if err := uc.repo.Update(ctx, payment); err != nil {
+		return nil, err
+	}
+
+	return payment, uc.processNewPayment(ctx, payment)
+}
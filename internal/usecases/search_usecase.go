@@ -0,0 +1,63 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payments_app/internal/domain"
+	"strings"
+	"unicode"
+)
+
+// ErrSearchNotConfigured is returned when payments are searched without a search index
+var ErrSearchNotConfigured = errors.New("payment search is not enabled")
+
+// Search query limits
+const (
+	maxSearchQuery = 200
+	maxSearchTerms = 10
+)
+
+// WithSearch enables full-text search over payments
+func WithSearch(repo domain.PaymentSearchRepository) Option {
+	return func(uc *PaymentUseCase) {
+		uc.search = repo
+	}
+}
+
+// SearchPayments finds payments whose description, customer or references contain words
+// starting with every word of query, best matches first. offset skips the hits of earlier pages.
+func (uc *PaymentUseCase) SearchPayments(ctx context.Context, query string, first, offset int) (*domain.PaymentSearchResults, error) {
+	if uc.search == nil {
+		return nil, ErrSearchNotConfigured
+	}
+	switch {
+	case first == 0:
+		first = DefaultPaymentPageSize
+	case first < 0 || first > MaxPaymentPageSize:
+		return nil, fmt.Errorf("first must be between 1 and %d", MaxPaymentPageSize)
+	}
+	if offset < 0 {
+		return nil, errors.New("offset must not be negative")
+	}
+
+	query = strings.TrimSpace(query)
+	if len(query) > maxSearchQuery {
+		return nil, fmt.Errorf("query must be at most %d characters", maxSearchQuery)
+	}
+	// Terms without a letter or digit match nothing and are left out
+	var terms []string
+	for _, term := range strings.Fields(query) {
+		if strings.IndexFunc(term, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsNumber(r) }) >= 0 {
+			terms = append(terms, term)
+		}
+	}
+	if len(terms) == 0 {
+		return nil, errors.New("query must contain a word to search for")
+	}
+	if len(terms) > maxSearchTerms {
+		return nil, fmt.Errorf("query can have at most %d words", maxSearchTerms)
+	}
+
+	return uc.search.Search(ctx, domain.PaymentSearch{Terms: terms, First: first, Offset: offset})
+}
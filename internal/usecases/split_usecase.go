@@ -0,0 +1,202 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"payments_app/internal/domain"
+	"strings"
+	"time"
+)
+
+// ErrSplitsNotConfigured is returned when split payments are used without a balance store
+var ErrSplitsNotConfigured = errors.New("split payments are not enabled")
+
+// MaxSplits limits the recipients of one payment
+const MaxSplits = 50
+
+// SplitInput is one recipient's share of a new payment: either a fixed Amount, or a Percent of
+// what is left after the fixed shares
+type SplitInput struct {
+	Recipient string `json:"recipient"`
+	// Amount is a decimal such as "12.50" in the payment's currency
+	Amount string `json:"amount,omitempty"`
+	// Percent is a decimal such as "12.5"
+	Percent string `json:"percent,omitempty"`
+}
+
+// WithSplits enables split payments. Recipients are credited with their shares when a payment
+// is captured, and refunds take the shares back in proportion.
+func WithSplits(balances domain.RecipientBalanceRepository) Option {
+	return func(uc *PaymentUseCase) {
+		uc.balances = balances
+	}
+}
+
+// buildSplits validates split instructions and divides the amount. Fixed shares are taken
+// first; the percentage shares divide the rest and must add up to exactly 100. Without
+// percentage shares, the fixed shares must add up to the amount.
+func buildSplits(inputs []SplitInput, amount domain.Money) ([]domain.Split, error) {
+	if len(inputs) > MaxSplits {
+		return nil, fmt.Errorf("a payment can be split among at most %d recipients", MaxSplits)
+	}
+
+	splits := make([]domain.Split, len(inputs))
+	seen := make(map[string]bool, len(inputs))
+	rest := amount
+	percentTotal := new(big.Rat)
+	var percents []*big.Rat
+	var percentSplits []int
+	for i, input := range inputs {
+		recipient := strings.TrimSpace(input.Recipient)
+		if recipient == "" {
+			return nil, fmt.Errorf("split %d: recipient is required", i+1)
+		}
+		if seen[recipient] {
+			return nil, fmt.Errorf("split %d: recipient %s appears more than once", i+1, recipient)
+		}
+		seen[recipient] = true
+		splits[i] = domain.Split{Recipient: recipient, Reversed: domain.Money{Currency: amount.Currency}}
+
+		fixed, percent := strings.TrimSpace(input.Amount), strings.TrimSpace(input.Percent)
+		switch {
+		case (fixed == "") == (percent == ""):
+			return nil, fmt.Errorf("split %d: give either an amount or a percent", i+1)
+		case fixed != "":
+			share, err := domain.ParseMoney(fixed, amount.Currency)
+			if err != nil {
+				return nil, fmt.Errorf("split %d: %w", i+1, err)
+			}
+			if share.MinorUnits <= 0 {
+				return nil, fmt.Errorf("split %d: amount must be greater than 0", i+1)
+			}
+			splits[i].Amount = share
+			rest.MinorUnits -= share.MinorUnits
+		default:
+			value, ok := new(big.Rat).SetString(percent)
+			if !ok || strings.Trim(percent, "0123456789.") != "" || value.Sign() <= 0 {
+				return nil, fmt.Errorf("split %d: percent %q must be a decimal greater than 0", i+1, input.Percent)
+			}
+			splits[i].Percent = percent
+			percentTotal.Add(percentTotal, value)
+			percents = append(percents, value)
+			percentSplits = append(percentSplits, i)
+		}
+	}
+
+	if rest.MinorUnits < 0 {
+		return nil, fmt.Errorf("split amounts exceed the payment amount of %s", amount)
+	}
+	if len(percents) == 0 {
+		if rest.MinorUnits != 0 {
+			return nil, fmt.Errorf("split amounts must add up to the payment amount of %s, %s is not allocated", amount, rest)
+		}
+		return splits, nil
+	}
+	if percentTotal.Cmp(big.NewRat(100, 1)) != 0 {
+		return nil, fmt.Errorf("split percentages must add up to 100, not %s", percentTotal.FloatString(2))
+	}
+	if rest.MinorUnits == 0 {
+		return nil, errors.New("fixed split amounts leave nothing for the percentage shares")
+	}
+	for i, share := range rest.Allocate(percents...) {
+		if share.MinorUnits == 0 {
+			return nil, fmt.Errorf("split %d: share rounds to zero", percentSplits[i]+1)
+		}
+		splits[percentSplits[i]].Amount = share
+	}
+	return splits, nil
+}
+
+// creditSplits credits the recipients of a captured payment with their shares
+func (uc *PaymentUseCase) creditSplits(ctx context.Context, payment *domain.Payment) error {
+	if uc.balances == nil || len(payment.Splits) == 0 {
+		return nil
+	}
+	now := time.Now()
+	entries := make([]domain.BalanceEntry, len(payment.Splits))
+	for i, split := range payment.Splits {
+		entries[i] = domain.BalanceEntry{
+			ID:        "credit:" + payment.ID + ":" + split.Recipient,
+			Recipient: split.Recipient,
+			PaymentID: payment.ID,
+			Kind:      domain.BalanceEntryKindCredit,
+			Amount:    split.Amount,
+			CreatedAt: now,
+		}
+	}
+	if err := uc.balances.Record(ctx, entries); err != nil {
+		return fmt.Errorf("payment %s was saved, but its splits could not be credited: %w", payment.ID, err)
+	}
+	return nil
+}
+
+// reverseSplits takes back the recipients' parts of the payment's refunds, without saving the
+// payment. The refunded total is divided in proportion to the shares, so the reversals of a
+// full refund add up to every share exactly. It returns the balance entries to record.
+func reverseSplits(payment *domain.Payment) []domain.BalanceEntry {
+	if len(payment.Splits) == 0 {
+		return nil
+	}
+	refunded := domain.MoneyFromFloat(payment.RefundedAmount, payment.Currency)
+	weights := make([]*big.Rat, len(payment.Splits))
+	for i, split := range payment.Splits {
+		weights[i] = big.NewRat(split.Amount.MinorUnits, 1)
+	}
+
+	now := time.Now()
+	var entries []domain.BalanceEntry
+	for i, reversed := range refunded.Allocate(weights...) {
+		split := &payment.Splits[i]
+		delta := reversed.MinorUnits - split.Reversed.MinorUnits
+		if delta == 0 {
+			continue
+		}
+		split.Reversed = reversed
+		entries = append(entries, domain.BalanceEntry{
+			ID:        fmt.Sprintf("reversal:%s:%s:%d", payment.ID, split.Recipient, refunded.MinorUnits),
+			Recipient: split.Recipient,
+			PaymentID: payment.ID,
+			Kind:      domain.BalanceEntryKindReversal,
+			Amount:    domain.Money{MinorUnits: -delta, Currency: payment.Currency},
+			CreatedAt: now,
+		})
+	}
+	return entries
+}
+
+// recordReversals records the balance entries of reversed splits
+func (uc *PaymentUseCase) recordReversals(ctx context.Context, payment *domain.Payment, entries []domain.BalanceEntry) error {
+	if uc.balances == nil || len(entries) == 0 {
+		return nil
+	}
+	if err := uc.balances.Record(ctx, entries); err != nil {
+		return fmt.Errorf("payment %s was saved, but its split reversals could not be recorded: %w", payment.ID, err)
+	}
+	return nil
+}
+
+// RecipientBalances returns what a split recipient is owed, per currency
+func (uc *PaymentUseCase) RecipientBalances(ctx context.Context, recipient string) ([]domain.Money, error) {
+	if uc.balances == nil {
+		return nil, ErrSplitsNotConfigured
+	}
+	recipient = strings.TrimSpace(recipient)
+	if recipient == "" {
+		return nil, errors.New("recipient is required")
+	}
+	return uc.balances.Balances(ctx, recipient)
+}
+
+// RecipientBalanceEntries returns the credits and reversals of a split recipient, oldest first
+func (uc *PaymentUseCase) RecipientBalanceEntries(ctx context.Context, recipient string) ([]domain.BalanceEntry, error) {
+	if uc.balances == nil {
+		return nil, ErrSplitsNotConfigured
+	}
+	recipient = strings.TrimSpace(recipient)
+	if recipient == "" {
+		return nil, errors.New("recipient is required")
+	}
+	return uc.balances.Entries(ctx, recipient)
+}
@@ -0,0 +1,48 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payments_app/internal/domain"
+	"strings"
+	"time"
+
+	// Embedded so day bucketing does not depend on the zone database of the host
+	_ "time/tzdata"
+)
+
+// ErrStatsNotSupported is returned when the payment store cannot aggregate payments
+var ErrStatsNotSupported = errors.New("payment statistics are not supported by this store")
+
+// PaymentStats counts and sums the payments matching filter, grouped by the given dimensions.
+// Days and months start at midnight in timezone, an IANA zone name that defaults to UTC.
+// Amounts are reported per currency as exact money values.
+func (uc *PaymentUseCase) PaymentStats(ctx context.Context, filter domain.PaymentFilter, groupBy []domain.StatsDimension, timezone string) ([]*domain.PaymentStatsGroup, error) {
+	filter, err := normalizeFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	query := domain.PaymentStatsQuery{Filter: filter, Location: time.UTC}
+	for _, dimension := range groupBy {
+		if !dimension.IsValid() {
+			return nil, inputError(ErrorCodeInvalidGroupBy, fmt.Errorf("unknown stats dimension %q", dimension))
+		}
+		if !query.Groups(dimension) {
+			query.GroupBy = append(query.GroupBy, dimension)
+		}
+	}
+	if timezone = strings.TrimSpace(timezone); timezone != "" {
+		location, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, inputError(ErrorCodeInvalidTimezone, fmt.Errorf("unknown time zone %q", timezone))
+		}
+		query.Location = location
+	}
+
+	stats, ok := uc.repo.(domain.PaymentStatsRepository)
+	if !ok {
+		return nil, ErrStatsNotSupported
+	}
+	return stats.PaymentStats(ctx, query)
+}
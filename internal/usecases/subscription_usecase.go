@@ -0,0 +1,229 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payments_app/internal/domain"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultDunningBackoff is the wait before each retry of a failed subscription payment
+var DefaultDunningBackoff = []time.Duration{24 * time.Hour, 3 * 24 * time.Hour, 7 * 24 * time.Hour}
+
+const (
+	// subscriptionLease is how long a runner may take to bill a claimed subscription before
+	// another runner may claim it again
+	subscriptionLease = 5 * time.Minute
+	// subscriptionBatchSize caps how many due subscriptions one scheduler run bills
+	subscriptionBatchSize = 100
+)
+
+// ErrSubscriptionsNotConfigured is returned when subscription operations are used without a store
+var ErrSubscriptionsNotConfigured = errors.New("subscriptions are not enabled")
+
+// WithSubscriptions enables recurring payments; backoff lists the waits between dunning
+// retries and defaults to DefaultDunningBackoff when nil
+func WithSubscriptions(repo domain.SubscriptionRepository, backoff []time.Duration) Option {
+	return func(uc *PaymentUseCase) {
+		if backoff == nil {
+			backoff = DefaultDunningBackoff
+		}
+		uc.subscriptions = repo
+		uc.dunningBackoff = backoff
+	}
+}
+
+// CreateSubscriptionInput represents input for creating a subscription
+type CreateSubscriptionInput struct {
+	Amount      float64             `json:"amount"`
+	Currency    string              `json:"currency"`
+	Description string              `json:"description"`
+	PayerID     string              `json:"payerId,omitempty"`
+	TenantID    string              `json:"tenantId,omitempty"`
+	Method      *PaymentMethodInput `json:"method,omitempty"`
+	Frequency   domain.Frequency    `json:"frequency"`
+	Interval    int                 `json:"interval,omitempty"`
+	// AnchorDay is the day of month monthly payments are due; defaults to the start day
+	AnchorDay int `json:"anchorDay,omitempty"`
+	// StartAt is when the first payment is due; defaults to now
+	StartAt *time.Time `json:"startAt,omitempty"`
+}
+
+// CreateSubscription creates a subscription whose payments are generated by the scheduler
+func (uc *PaymentUseCase) CreateSubscription(ctx context.Context, input CreateSubscriptionInput) (*domain.Subscription, error) {
+	if uc.subscriptions == nil {
+		return nil, ErrSubscriptionsNotConfigured
+	}
+	if input.Amount <= 0 {
+		return nil, errors.New("amount must be greater than 0")
+	}
+	currency, err := validateAndNormalizeCurrency(input.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(input.Description) == "" {
+		return nil, errors.New("description is required")
+	}
+	method, err := uc.buildPaymentMethod(ctx, input.Method, currency)
+	if err != nil {
+		return nil, err
+	}
+	if card, ok := method.(domain.CardMethod); ok && card.Token == "" {
+		return nil, errors.New("recurring card payments need a vaulted card token")
+	}
+
+	startAt := time.Now()
+	if input.StartAt != nil {
+		startAt = *input.StartAt
+	}
+	subscription, err := domain.NewSubscription(input.Amount, currency, strings.TrimSpace(input.Description), domain.PaymentSchedule{
+		Frequency: input.Frequency,
+		Interval:  input.Interval,
+		AnchorDay: input.AnchorDay,
+		StartAt:   startAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	subscription.PayerID = strings.TrimSpace(input.PayerID)
+	subscription.TenantID = strings.TrimSpace(input.TenantID)
+	subscription.Method = method
+
+	if err := uc.subscriptions.Create(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+// GetSubscription retrieves a subscription by ID
+func (uc *PaymentUseCase) GetSubscription(ctx context.Context, id string) (*domain.Subscription, error) {
+	if uc.subscriptions == nil {
+		return nil, ErrSubscriptionsNotConfigured
+	}
+	if id == "" {
+		return nil, errors.New("subscription ID is required")
+	}
+	return uc.subscriptions.GetByID(ctx, id)
+}
+
+// ListSubscriptions returns subscriptions matching the filter
+func (uc *PaymentUseCase) ListSubscriptions(ctx context.Context, filter domain.SubscriptionFilter) ([]*domain.Subscription, error) {
+	if uc.subscriptions == nil {
+		return nil, ErrSubscriptionsNotConfigured
+	}
+	return uc.subscriptions.List(ctx, filter)
+}
+
+// PauseSubscription stops billing a subscription until it is resumed
+func (uc *PaymentUseCase) PauseSubscription(ctx context.Context, id string) (*domain.Subscription, error) {
+	return uc.changeSubscription(ctx, id, func(subscription *domain.Subscription) error {
+		return subscription.Pause()
+	})
+}
+
+// ResumeSubscription restarts billing with the next occurrence; periods missed while paused are not billed
+func (uc *PaymentUseCase) ResumeSubscription(ctx context.Context, id string) (*domain.Subscription, error) {
+	return uc.changeSubscription(ctx, id, func(subscription *domain.Subscription) error {
+		return subscription.Resume(time.Now())
+	})
+}
+
+// CancelSubscription ends a subscription
+func (uc *PaymentUseCase) CancelSubscription(ctx context.Context, id string) (*domain.Subscription, error) {
+	return uc.changeSubscription(ctx, id, func(subscription *domain.Subscription) error {
+		return subscription.Cancel()
+	})
+}
+
+// RunDueSubscriptions generates the payments of all subscriptions due at now and returns
+// how many were billed. Each subscription is leased first and saved only while the lease is
+// held, so a cycle is billed once even when several instances run. A failing subscription
+// does not stop the others.
+func (uc *PaymentUseCase) RunDueSubscriptions(ctx context.Context, now time.Time) (int, error) {
+	if uc.subscriptions == nil {
+		return 0, ErrSubscriptionsNotConfigured
+	}
+
+	owner := uuid.New().String()
+	due, err := uc.subscriptions.ClaimDue(ctx, owner, now, subscriptionLease, subscriptionBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	billed := 0
+	var errs []error
+	for _, subscription := range due {
+		if err := ctx.Err(); err != nil {
+			return billed, err
+		}
+		ok, err := uc.billSubscription(ctx, subscription, owner, now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("subscription %s: %w", subscription.ID, err))
+		}
+		if ok {
+			billed++
+		}
+	}
+	return billed, errors.Join(errs...)
+}
+
+// billSubscription creates the payment for the claimed subscription's current cycle and
+// reports whether the cycle was billed. Payments that cannot be created, or end up FAILED or
+// REJECTED, start or continue dunning. A payment that was stored before a later step failed
+// still bills the cycle, so the next run does not charge the payer again.
+func (uc *PaymentUseCase) billSubscription(ctx context.Context, subscription *domain.Subscription, owner string, now time.Time) (bool, error) {
+	payment := domain.NewPayment(subscription.Amount, subscription.Currency,
+		fmt.Sprintf("%s (period %d)", subscription.Description, subscription.Cycle+1))
+	payment.PayerID = subscription.PayerID
+	payment.TenantID = subscription.TenantID
+	payment.Method = subscription.Method
+	payment.SubscriptionID = subscription.ID
+
+	err := uc.settle(ctx, payment, "")
+	if err == nil {
+		err = uc.submitPayment(ctx, payment)
+	}
+	var stored *StoredPaymentError
+	billed := false
+	switch {
+	case err != nil && !errors.As(err, &stored):
+		// Nothing was stored, so dunning retries the cycle
+		subscription.RecordFailure("", err.Error(), now, uc.dunningBackoff)
+		err = nil
+	case payment.Status == domain.PaymentStatusFailed || payment.Status == domain.PaymentStatusRejected:
+		reason := payment.ProcessorResponse
+		if reason == "" {
+			reason = "payment " + strings.ToLower(string(payment.Status))
+		}
+		subscription.RecordFailure(payment.ID, reason, now, uc.dunningBackoff)
+	default:
+		subscription.RecordPayment(payment.ID)
+		billed = true
+	}
+
+	ok, saveErr := uc.subscriptions.CompleteBilling(ctx, subscription, owner)
+	if saveErr == nil && !ok {
+		saveErr = fmt.Errorf("lease on subscription %s expired before payment %s was recorded", subscription.ID, payment.ID)
+	}
+	return billed, errors.Join(err, saveErr)
+}
+
+// changeSubscription loads a subscription, applies change and saves it
+func (uc *PaymentUseCase) changeSubscription(ctx context.Context, id string, change func(*domain.Subscription) error) (*domain.Subscription, error) {
+	subscription, err := uc.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := change(subscription); err != nil {
+		return nil, err
+	}
+	if err := uc.subscriptions.Update(ctx, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
@@ -0,0 +1,79 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"payments_app/internal/domain"
+	"strings"
+)
+
+// ErrTaxNotConfigured is returned when tax is requested without tax rates
+var ErrTaxNotConfigured = errors.New("tax calculation is not enabled")
+
+// TaxCalculator calculates the tax on the lines of a sale
+type TaxCalculator interface {
+	Calculate(request domain.TaxRequest) (*domain.TaxBreakdown, error)
+}
+
+// TaxInput asks for tax on a payment or invoice. Country defaults to the payer's country and
+// Pricing to the country's default. Category is the product category of a payment, or of the
+// invoice lines that name none.
+type TaxInput struct {
+	Country   string            `json:"country,omitempty"`
+	Category  string            `json:"category,omitempty"`
+	Pricing   domain.TaxPricing `json:"pricing,omitempty"`
+	VATNumber string            `json:"vatNumber,omitempty"`
+}
+
+// WithTax enables tax calculation on payments and invoices
+func WithTax(calculator TaxCalculator) Option {
+	return func(uc *PaymentUseCase) {
+		uc.taxes = calculator
+	}
+}
+
+// calculateTax taxes lines by the rules of the input's country, or of fallbackCountry when
+// the input names none
+func (uc *PaymentUseCase) calculateTax(input TaxInput, fallbackCountry string, lines []domain.TaxableLine) (*domain.TaxBreakdown, error) {
+	if uc.taxes == nil {
+		return nil, ErrTaxNotConfigured
+	}
+	country := strings.ToUpper(strings.TrimSpace(input.Country))
+	if country == "" {
+		country = fallbackCountry
+	}
+	if country == "" {
+		return nil, errors.New("tax country is required")
+	}
+	if !isValidCountryCode(country) {
+		return nil, errors.New("tax country must be a 2-letter ISO code")
+	}
+	pricing := domain.TaxPricing(strings.ToUpper(strings.TrimSpace(string(input.Pricing))))
+	if pricing != "" && !pricing.IsValid() {
+		return nil, fmt.Errorf("invalid tax pricing %q", input.Pricing)
+	}
+
+	return uc.taxes.Calculate(domain.TaxRequest{
+		Country:   country,
+		Pricing:   pricing,
+		VATNumber: input.VATNumber,
+		Lines:     lines,
+	})
+}
+
+// taxPayment calculates the tax on a new payment. With exclusive pricing the amount is net and
+// the tax is added to it, so the payment is for the gross amount.
+func (uc *PaymentUseCase) taxPayment(payment *domain.Payment, input TaxInput) error {
+	payerCountry := ""
+	if payment.Payer != nil {
+		payerCountry = payment.Payer.Country
+	}
+	line := domain.TaxableLine{Category: input.Category, Amount: domain.MoneyFromFloat(payment.Amount, payment.Currency)}
+	breakdown, err := uc.calculateTax(input, payerCountry, []domain.TaxableLine{line})
+	if err != nil {
+		return err
+	}
+	payment.Tax = breakdown
+	payment.Amount = breakdown.Gross.Float64()
+	return nil
+}
@@ -0,0 +1,27 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"payments_app/internal/vault"
+)
+
+// ErrVaultNotConfigured is returned when card tokenization is used without a vault
+var ErrVaultNotConfigured = errors.New("card vault is not configured")
+
+// TokenizeCard validates raw card details and stores them in the vault, returning an opaque token
+func (uc *PaymentUseCase) TokenizeCard(ctx context.Context, input CardInput) (*vault.TokenizedCard, error) {
+	if uc.vault == nil {
+		return nil, ErrVaultNotConfigured
+	}
+	if input.Token != "" {
+		return nil, errors.New("card is already tokenized")
+	}
+
+	card, err := validateCardInput(&input)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.vault.Tokenize(ctx, card)
+}
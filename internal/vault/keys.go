@@ -0,0 +1,119 @@
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeyRing holds the key-encryption keys (KEKs) by ID and the ID used for new records
+type KeyRing struct {
+	keys     map[string][]byte
+	activeID string
+}
+
+// ParseKeyRing parses "id:base64key,id:base64key" into a key ring; each key must be 32 bytes (AES-256)
+func ParseKeyRing(spec, activeID string) (*KeyRing, error) {
+	ring := &KeyRing{keys: make(map[string][]byte), activeID: strings.TrimSpace(activeID)}
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(part, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("key %q must be in the form id:base64key", part)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %s is not valid base64: %w", id, err)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("key %s must be 32 bytes, got %d", id, len(key))
+		}
+		ring.keys[id] = key
+	}
+
+	if len(ring.keys) == 0 {
+		return nil, errors.New("at least one key-encryption key is required")
+	}
+	if ring.activeID == "" {
+		if len(ring.keys) > 1 {
+			return nil, errors.New("active key ID is required when several keys are configured")
+		}
+		for id := range ring.keys {
+			ring.activeID = id
+		}
+	}
+	if _, ok := ring.keys[ring.activeID]; !ok {
+		return nil, fmt.Errorf("active key %s is not configured", ring.activeID)
+	}
+
+	return ring, nil
+}
+
+// ActiveID returns the ID of the key used to wrap new data keys
+func (k *KeyRing) ActiveID() string {
+	return k.activeID
+}
+
+// wrap encrypts a data key with the given KEK
+func (k *KeyRing) wrap(keyID string, dataKey, aad []byte) ([]byte, error) {
+	kek, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("key %s is not configured", keyID)
+	}
+	return seal(kek, dataKey, aad)
+}
+
+// unwrap decrypts a data key with the given KEK
+func (k *KeyRing) unwrap(keyID string, wrapped, aad []byte) ([]byte, error) {
+	kek, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("key %s is not configured", keyID)
+	}
+	return open(kek, wrapped, aad)
+}
+
+// seal encrypts plaintext with AES-GCM and prepends the random nonce
+func seal(key, plaintext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+// open decrypts a nonce-prefixed AES-GCM ciphertext
+func open(key, ciphertext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+
+	return gcm.Open(nil, nonce, sealed, aad)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,209 @@
+package vault
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"payments_app/internal/domain"
+	"time"
+)
+
+// ErrTokenNotFound is returned when a token does not exist in the vault
+var ErrTokenNotFound = errors.New("card token not found")
+
+// Card holds raw card data; it only exists in memory
+type Card struct {
+	PAN         string
+	ExpiryMonth int
+	ExpiryYear  int
+	HolderName  string
+}
+
+// TokenizedCard is the non-sensitive view of a vaulted card
+type TokenizedCard struct {
+	Token       string
+	Brand       string
+	Last4       string
+	ExpiryMonth int
+	ExpiryYear  int
+	HolderName  string
+	CreatedAt   time.Time
+}
+
+// Record is a vaulted card as persisted: the PAN is encrypted with a per-record data key,
+// which is itself wrapped by a key-encryption key identified by KeyID
+type Record struct {
+	TokenizedCard
+	KeyID      string
+	WrappedKey []byte
+	Ciphertext []byte
+}
+
+// Store persists vault records
+type Store interface {
+	Save(ctx context.Context, record *Record) error
+	Get(ctx context.Context, token string) (*Record, error)
+	ListNotWrappedWith(ctx context.Context, keyID string, limit int) ([]*Record, error)
+	Rewrap(ctx context.Context, token, oldKeyID, newKeyID string, wrappedKey []byte) error
+}
+
+// Tokenizer is the public side of the vault: it stores cards and exposes only metadata
+type Tokenizer interface {
+	Tokenize(ctx context.Context, card Card) (*TokenizedCard, error)
+	Lookup(ctx context.Context, token string) (*TokenizedCard, error)
+}
+
+// Detokenizer recovers raw card data; it must only be handed to internal components
+// such as processor connectors and never exposed through the API
+type Detokenizer interface {
+	Detokenize(ctx context.Context, token string) (*Card, error)
+}
+
+// Vault implements envelope encryption of card numbers
+type Vault struct {
+	store Store
+	keys  *KeyRing
+}
+
+// NewVault creates a vault backed by the given store and key ring
+func NewVault(store Store, keys *KeyRing) *Vault {
+	return &Vault{store: store, keys: keys}
+}
+
+// Tokenize encrypts a card under a fresh data key and returns an opaque token
+func (v *Vault) Tokenize(ctx context.Context, card Card) (*TokenizedCard, error) {
+	if len(card.PAN) < 4 {
+		return nil, errors.New("card number is invalid")
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	defer clear(dataKey)
+
+	aad := []byte(token)
+	ciphertext, err := seal(dataKey, []byte(card.PAN), aad)
+	if err != nil {
+		return nil, err
+	}
+	wrappedKey, err := v.keys.wrap(v.keys.ActiveID(), dataKey, aad)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &Record{
+		TokenizedCard: TokenizedCard{
+			Token:       token,
+			Brand:       domain.CardBrand(card.PAN),
+			Last4:       card.PAN[len(card.PAN)-4:],
+			ExpiryMonth: card.ExpiryMonth,
+			ExpiryYear:  card.ExpiryYear,
+			HolderName:  card.HolderName,
+			CreatedAt:   time.Now(),
+		},
+		KeyID:      v.keys.ActiveID(),
+		WrappedKey: wrappedKey,
+		Ciphertext: ciphertext,
+	}
+	if err := v.store.Save(ctx, record); err != nil {
+		return nil, err
+	}
+
+	tokenized := record.TokenizedCard
+	return &tokenized, nil
+}
+
+// Lookup returns the non-sensitive metadata of a vaulted card
+func (v *Vault) Lookup(ctx context.Context, token string) (*TokenizedCard, error) {
+	record, err := v.store.Get(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	tokenized := record.TokenizedCard
+	return &tokenized, nil
+}
+
+// Detokenize unwraps the record's data key and decrypts the card number
+func (v *Vault) Detokenize(ctx context.Context, token string) (*Card, error) {
+	record, err := v.store.Get(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	aad := []byte(record.Token)
+	dataKey, err := v.keys.unwrap(record.KeyID, record.WrappedKey, aad)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(dataKey)
+
+	pan, err := open(dataKey, record.Ciphertext, aad)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Card{
+		PAN:         string(pan),
+		ExpiryMonth: record.ExpiryMonth,
+		ExpiryYear:  record.ExpiryYear,
+		HolderName:  record.HolderName,
+	}, nil
+}
+
+// RotateKeys re-wraps the data keys of records not yet under the active KEK, in batches.
+// Card ciphertexts are untouched and every key stays readable during rotation, so it runs online.
+func (v *Vault) RotateKeys(ctx context.Context, batchSize int) (int, error) {
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+
+	activeID := v.keys.ActiveID()
+	rotated := 0
+	for {
+		records, err := v.store.ListNotWrappedWith(ctx, activeID, batchSize)
+		if err != nil {
+			return rotated, err
+		}
+		if len(records) == 0 {
+			return rotated, nil
+		}
+
+		for _, record := range records {
+			if err := ctx.Err(); err != nil {
+				return rotated, err
+			}
+
+			aad := []byte(record.Token)
+			dataKey, err := v.keys.unwrap(record.KeyID, record.WrappedKey, aad)
+			if err != nil {
+				return rotated, err
+			}
+			wrappedKey, err := v.keys.wrap(activeID, dataKey, aad)
+			clear(dataKey)
+			if err != nil {
+				return rotated, err
+			}
+
+			if err := v.store.Rewrap(ctx, record.Token, record.KeyID, activeID, wrappedKey); err != nil {
+				return rotated, err
+			}
+			rotated++
+		}
+	}
+}
+
+// newToken generates an opaque, random card token
+func newToken() (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return "tok_" + hex.EncodeToString(random), nil
+}
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"io"
 	"log"
 	"os"
 )
@@ -21,6 +22,15 @@ func NewLogger() *Logger {
 	}
 }
 
+// NewLoggerTo creates a logger writing every level to w, for tools whose stdout carries output
+func NewLoggerTo(w io.Writer) *Logger {
+	return &Logger{
+		infoLogger:  log.New(w, "INFO: ", log.Ldate|log.Ltime|log.Lshortfile),
+		errorLogger: log.New(w, "ERROR: ", log.Ldate|log.Ltime|log.Lshortfile),
+		warnLogger:  log.New(w, "WARN: ", log.Ldate|log.Ltime|log.Lshortfile),
+	}
+}
+
 // Info logs info level messages
 func (l *Logger) Info(v ...interface{}) {
 	l.infoLogger.Println(v...)
@@ -10,6 +10,9 @@ YELLOW='\033[1;33m'
 BLUE='\033[0;34m'
 NC='\033[0m' # No Color
 
+# sqlite_fts5 compiles SQLite with FTS5, which indexes payment search
+GO_TAGS=${GO_TAGS:-sqlite_fts5}
+
 # Function to run tests and show results
 run_test_suite() {
     local test_type=$1
@@ -20,7 +23,7 @@ run_test_suite() {
     echo -e "${BLUE}📊 Running $description...${NC}"
     echo "----------------------------------------"
     
-    if go test -v "$test_path"; then
+    if go test -tags "$GO_TAGS" -v "$test_path"; then
         echo -e "${GREEN}✅ $description passed!${NC}"
         return 0
     else
@@ -39,7 +42,7 @@ run_test_suite_with_coverage() {
     echo -e "${BLUE}📊 Running $description with coverage...${NC}"
     echo "----------------------------------------"
     
-    if go test -v -cover "$test_path"; then
+    if go test -tags "$GO_TAGS" -v -cover "$test_path"; then
         echo -e "${GREEN}✅ $description passed!${NC}"
         return 0
     else
@@ -76,8 +79,8 @@ echo ""
 echo -e "${YELLOW}📈 COVERAGE REPORT${NC}"
 echo "=================="
 echo "Generating coverage report for all tests..."
-go test -v -cover ./tests/unit/... ./tests/integration/... ./tests/e2e/...
-go test -coverprofile=coverage.out ./tests/unit/... ./tests/integration/... ./tests/e2e/...
+go test -tags "$GO_TAGS" -v -cover ./tests/unit/... ./tests/integration/... ./tests/e2e/...
+go test -tags "$GO_TAGS" -coverprofile=coverage.out ./tests/unit/... ./tests/integration/... ./tests/e2e/...
 go tool cover -html=coverage.out -o coverage.html
 
 # Summary
@@ -0,0 +1,81 @@
+package graphql_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// postUpload sends a GraphQL multipart request with one file bound to the "file" variable
+func postUpload(t *testing.T, url, query, filename, content string) map[string]interface{} {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	operations, err := json.Marshal(map[string]interface{}{
+		"query":     query,
+		"variables": map[string]interface{}{"file": nil},
+	})
+	require.NoError(t, err)
+	require.NoError(t, form.WriteField("operations", string(operations)))
+	require.NoError(t, form.WriteField("map", `{"0": ["variables.file"]}`))
+	part, err := form.CreateFormFile("0", filename)
+	require.NoError(t, err)
+	_, err = part.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	resp, err := http.Post(url, form.FormDataContentType(), &body)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	return result
+}
+
+func TestGraphQLIntegration_BulkCreatePayments(t *testing.T) {
+	ts, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+
+	query := `
+		mutation($file: Upload!) {
+			bulkCreatePayments(file: $file) {
+				mode
+				total
+				created
+				failed
+				rows { line status paymentId paymentStatus errorCode error }
+			}
+		}
+	`
+	csv := "amount,currency,description\n42,USD,Supplier A\n-1,USD,Supplier B\n"
+
+	result := postUpload(t, ts.URL, query, "payments.csv", csv)
+	if errors, exists := result["errors"]; exists {
+		t.Fatalf("GraphQL errors: %v", errors)
+	}
+
+	report := result["data"].(map[string]interface{})["bulkCreatePayments"].(map[string]interface{})
+	assert.Equal(t, "BEST_EFFORT", report["mode"])
+	assert.Equal(t, float64(2), report["total"])
+	assert.Equal(t, float64(1), report["created"])
+	assert.Equal(t, float64(1), report["failed"])
+
+	rows := report["rows"].([]interface{})
+	created := rows[0].(map[string]interface{})
+	assert.Equal(t, float64(2), created["line"])
+	assert.Equal(t, "CREATED", created["status"])
+	assert.NotEmpty(t, created["paymentId"])
+	assert.Equal(t, "PENDING", created["paymentStatus"])
+
+	failed := rows[1].(map[string]interface{})
+	assert.Equal(t, float64(3), failed["line"])
+	assert.Equal(t, "FAILED", failed["status"])
+	assert.Equal(t, "INVALID_AMOUNT", failed["errorCode"])
+	assert.Nil(t, failed["paymentId"])
+}
@@ -11,6 +11,7 @@ import (
 	"payments_app/internal/interfaces/graphql"
 	"payments_app/internal/usecases"
 	"testing"
+	"time"
 
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/stretchr/testify/assert"
@@ -184,3 +185,123 @@ func TestGraphQLIntegration_GetAllPayments(t *testing.T) {
 	assert.Equal(t, "Test payment for query", payment["description"])
 	assert.Equal(t, "PENDING", payment["status"])
 }
+
+func TestGraphQLIntegration_CardPaymentMethod(t *testing.T) {
+	ts, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+
+	query := `
+		mutation {
+			createPayment(input: {
+				amount: 42.0
+				currency: "USD"
+				description: "Card payment"
+				method: { type: CARD, card: { number: "5555555555554444", expiryMonth: 12, expiryYear: 2099 } }
+			}) {
+				id
+				method {
+					__typename
+					... on CardPaymentMethod { brand last4 expiryMonth expiryYear }
+				}
+			}
+		}
+	`
+
+	jsonBody, err := json.Marshal(map[string]interface{}{"query": query})
+	require.NoError(t, err)
+
+	resp, err := http.Post(ts.URL, "application/json", bytes.NewBuffer(jsonBody))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var result map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	if errors, exists := result["errors"]; exists {
+		t.Fatalf("GraphQL errors: %v", errors)
+	}
+
+	payment := result["data"].(map[string]interface{})["createPayment"].(map[string]interface{})
+	method := payment["method"].(map[string]interface{})
+	assert.Equal(t, "CardPaymentMethod", method["__typename"])
+	assert.Equal(t, "MASTERCARD", method["brand"])
+	assert.Equal(t, "4444", method["last4"])
+
+	// The full PAN must never reach the database
+	raw, err := os.ReadFile("integration_test.db")
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "5555555555554444")
+}
+
+func TestGraphQLIntegration_PaymentsFilter(t *testing.T) {
+	ts, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+
+	post := func(query string) map[string]interface{} {
+		jsonBody, err := json.Marshal(map[string]interface{}{"query": query})
+		require.NoError(t, err)
+		resp, err := http.Post(ts.URL, "application/json", bytes.NewBuffer(jsonBody))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var result map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		return result
+	}
+	for _, currency := range []string{"EUR", "USD", "EUR"} {
+		result := post(`mutation { createPayment(input: {amount: 10, currency: "` + currency + `", description: "Filtered"}) { id } }`)
+		require.Nil(t, result["errors"])
+	}
+
+	result := post(`query { payments(filter: {currency: "eur", statuses: [PENDING]}) { currency } }`)
+	if errors, exists := result["errors"]; exists {
+		t.Fatalf("GraphQL errors: %v", errors)
+	}
+	payments := result["data"].(map[string]interface{})["payments"].([]interface{})
+	assert.Len(t, payments, 2)
+
+	result = post(`query { payments(filter: {createdFrom: "not a time"}) { id } }`)
+	assert.NotNil(t, result["errors"])
+}
+
+func TestGraphQLIntegration_PaymentStats(t *testing.T) {
+	ts, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+
+	post := func(query string) map[string]interface{} {
+		jsonBody, err := json.Marshal(map[string]interface{}{"query": query})
+		require.NoError(t, err)
+		resp, err := http.Post(ts.URL, "application/json", bytes.NewBuffer(jsonBody))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var result map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		return result
+	}
+	for _, input := range []string{`amount: 10.10, currency: "EUR"`, `amount: 0.2, currency: "EUR"`, `amount: 5, currency: "USD"`} {
+		result := post(`mutation { createPayment(input: {` + input + `, description: "Stats"}) { id } }`)
+		require.Nil(t, result["errors"])
+	}
+
+	result := post(`query { paymentStats(groupBy: [CURRENCY, DAY], timezone: "Europe/Berlin") {
+		currency day count amounts { sum { amount currency } average { amount } p50 { amount } }
+	} }`)
+	if errors, exists := result["errors"]; exists {
+		t.Fatalf("GraphQL errors: %v", errors)
+	}
+	groups := result["data"].(map[string]interface{})["paymentStats"].([]interface{})
+	require.Len(t, groups, 2)
+	eur := groups[0].(map[string]interface{})
+	assert.Equal(t, "EUR", eur["currency"])
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	assert.Equal(t, time.Now().In(berlin).Format("2006-01-02"), eur["day"])
+	assert.Equal(t, float64(2), eur["count"])
+	amounts := eur["amounts"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"amount": "10.30", "currency": "EUR"}, amounts["sum"])
+	assert.Equal(t, map[string]interface{}{"amount": "5.15"}, amounts["average"])
+	assert.Equal(t, map[string]interface{}{"amount": "0.20"}, amounts["p50"])
+
+	result = post(`query { paymentStats(timezone: "Nowhere/Nothing") { count } }`)
+	assert.NotNil(t, result["errors"])
+}
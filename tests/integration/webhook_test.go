@@ -0,0 +1,180 @@
+package graphql_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"payments_app/internal/domain"
+	"payments_app/internal/infrastructure/database"
+	"payments_app/internal/infrastructure/processor"
+	"payments_app/internal/infrastructure/storage"
+	"payments_app/internal/interfaces/webhook"
+	"payments_app/internal/usecases"
+	"payments_app/pkg/logger"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const webhookSecret = "whsec_test"
+
+type webhookFixture struct {
+	server  *httptest.Server
+	repo    *database.PaymentRepository
+	useCase *usecases.PaymentUseCase
+}
+
+func setupWebhookTest(t *testing.T) *webhookFixture {
+	repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "webhooks.db"))
+	require.NoError(t, err)
+	callbacks, err := database.NewCallbackRepository(repo.DB())
+	require.NoError(t, err)
+
+	disputes, err := database.NewDisputeRepository(repo.DB())
+	require.NoError(t, err)
+	evidence, err := storage.NewLocalStore(filepath.Join(t.TempDir(), "evidence"), 0)
+	require.NoError(t, err)
+
+	sim := processor.NewSimulator(processor.SimulatorConfig{WebhookSecret: webhookSecret})
+	useCase := usecases.NewPaymentUseCase(repo,
+		usecases.WithProcessor(sim),
+		usecases.WithCallbacks(callbacks, repo),
+		usecases.WithDisputes(disputes, evidence, 0),
+	)
+
+	router := mux.NewRouter()
+	router.Handle("/webhooks/processor/{name}", webhook.NewHandler(useCase, map[string]webhook.Verifier{sim.Name(): sim}, logger.NewLogger())).Methods(http.MethodPost)
+	server := httptest.NewServer(router)
+
+	t.Cleanup(func() {
+		server.Close()
+		repo.Close()
+	})
+	return &webhookFixture{server: server, repo: repo, useCase: useCase}
+}
+
+// send posts a callback signed with secret and returns the status code and decoded body
+func (f *webhookFixture) send(t *testing.T, processorName, secret string, payload map[string]interface{}) (int, map[string]string) {
+	body, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	now := time.Now()
+	req, err := http.NewRequest(http.MethodPost, f.server.URL+"/webhooks/processor/"+processorName, bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set(processor.WebhookTimestampHeader, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(processor.WebhookSignatureHeader, processor.SignWebhook(secret, now, body))
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var decoded map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&decoded))
+	return resp.StatusCode, decoded
+}
+
+func TestProcessorWebhook_AppliesStatus(t *testing.T) {
+	f := setupWebhookTest(t)
+	ctx := context.Background()
+
+	payment, err := f.useCase.CreatePayment(ctx, usecases.CreatePaymentInput{Amount: 30, Currency: "USD", Description: "Webhook"})
+	require.NoError(t, err)
+	require.Equal(t, domain.PaymentStatusAuthorized, payment.Status)
+
+	callback := map[string]interface{}{"id": "evt_1", "type": "payment.updated", "reference": payment.ProcessorReference, "status": "settled"}
+	status, body := f.send(t, "simulator", webhookSecret, callback)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "applied", body["status"])
+
+	stored, err := f.repo.GetByID(ctx, payment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusCompleted, stored.Status)
+
+	// Redelivery of the same callback ID is acknowledged but not applied again
+	status, body = f.send(t, "simulator", webhookSecret, callback)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "duplicate", body["status"])
+
+	// Transitions the state machine forbids are stored as failed
+	status, body = f.send(t, "simulator", webhookSecret, map[string]interface{}{"id": "evt_2", "reference": payment.ProcessorReference, "status": "authorized"})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "failed", body["status"])
+	assert.Contains(t, body["error"], "invalid status transition")
+
+	callbacks, err := f.useCase.ListProcessorCallbacks(ctx, "simulator", 0)
+	require.NoError(t, err)
+	require.Len(t, callbacks, 2)
+	assert.Equal(t, payment.ID, callbacks[1].PaymentID)
+	assert.Contains(t, callbacks[1].Payload, "evt_1")
+}
+
+func TestProcessorWebhook_ChargebackOpensDispute(t *testing.T) {
+	f := setupWebhookTest(t)
+	ctx := context.Background()
+
+	payment := domain.NewPayment(75, "USD", "Chargeback")
+	payment.Status = domain.PaymentStatusCompleted
+	payment.Processor = "simulator"
+	payment.ProcessorReference = "sim_cb"
+	require.NoError(t, f.repo.Create(ctx, payment))
+
+	status, body := f.send(t, "simulator", webhookSecret, map[string]interface{}{"id": "evt_cb", "type": "chargeback", "reference": "sim_cb", "amount": 25, "code": "10.4"})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "applied", body["status"])
+
+	disputes, err := f.useCase.ListDisputes(ctx, domain.DisputeFilter{PaymentID: payment.ID})
+	require.NoError(t, err)
+	require.Len(t, disputes, 1)
+	assert.Equal(t, "10.4", disputes[0].ReasonCode)
+	assert.Equal(t, 25.0, disputes[0].Amount)
+	assert.Equal(t, domain.DisputeStatusOpen, disputes[0].Status)
+}
+
+func TestProcessorWebhook_RejectsUnverifiedCallbacks(t *testing.T) {
+	f := setupWebhookTest(t)
+	payload := map[string]interface{}{"id": "evt_1", "reference": "sim_1", "status": "settled"}
+
+	status, _ := f.send(t, "simulator", "wrong-secret", payload)
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	status, _ = f.send(t, "unknown", webhookSecret, payload)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	callbacks, err := f.useCase.ListProcessorCallbacks(context.Background(), "", 0)
+	require.NoError(t, err)
+	assert.Empty(t, callbacks)
+}
+
+func TestProcessorWebhook_Replay(t *testing.T) {
+	f := setupWebhookTest(t)
+	ctx := context.Background()
+
+	// The callback arrives before the payment carrying its reference is stored
+	status, body := f.send(t, "simulator", webhookSecret, map[string]interface{}{"id": "evt_early", "reference": "sim_late", "status": "failed", "code": "91", "message": "issuer unavailable"})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "failed", body["status"])
+
+	payment := domain.NewPayment(10, "USD", "Late reference")
+	payment.Processor = "simulator"
+	payment.ProcessorReference = "sim_late"
+	require.NoError(t, f.repo.Create(ctx, payment))
+
+	callback, err := f.useCase.ReplayProcessorCallback(ctx, body["callbackId"])
+	require.NoError(t, err)
+	assert.Equal(t, domain.CallbackResultApplied, callback.Result)
+
+	stored, err := f.repo.GetByID(ctx, payment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusFailed, stored.Status)
+	assert.Equal(t, "91 issuer unavailable", stored.ProcessorResponse)
+
+	_, err = f.useCase.ReplayProcessorCallback(ctx, body["callbackId"])
+	assert.Error(t, err, "applied callbacks cannot be replayed")
+}
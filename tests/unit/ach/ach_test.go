@@ -0,0 +1,393 @@
+package ach_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"payments_app/internal/domain"
+	"payments_app/internal/infrastructure/database"
+	"payments_app/internal/nacha"
+	"payments_app/internal/usecases"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const bankRouting = "021000021"
+
+type fixture struct {
+	repo    *database.PaymentRepository
+	useCase *usecases.PaymentUseCase
+}
+
+func setup(t *testing.T) *fixture {
+	repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "ach.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+
+	files, err := database.NewACHRepository(repo.DB())
+	require.NoError(t, err)
+	writer, err := nacha.NewWriter(nacha.Originator{
+		CompanyName: "Example Payments Inc",
+		CompanyID:   "1234567890",
+		BankRouting: bankRouting,
+		BankName:    "JPMorgan Chase",
+	})
+	require.NoError(t, err)
+
+	useCase := usecases.NewPaymentUseCase(repo, usecases.WithACH(files, writer))
+	return &fixture{repo: repo, useCase: useCase}
+}
+
+// transfer creates a pending ACH payout to the given account
+func (f *fixture) transfer(t *testing.T, amount float64, routing string, account usecases.BankAccountInput, description string) *domain.Payment {
+	account.Scheme = domain.BankSchemeACH
+	account.RoutingNumber = routing
+	if account.AccountNumber == "" {
+		account.AccountNumber = "123456789"
+	}
+	payment, err := f.useCase.CreatePayment(context.Background(), usecases.CreatePaymentInput{
+		Amount:      amount,
+		Currency:    "USD",
+		Description: description,
+		Payee:       &domain.Party{Name: "Jane Müller"},
+		Method:      &usecases.PaymentMethodInput{Type: domain.PaymentMethodTypeBankAccount, BankAccount: &account},
+	})
+	require.NoError(t, err)
+	require.Equal(t, domain.PaymentStatusPending, payment.Status)
+	return payment
+}
+
+func records(t *testing.T, document []byte) []string {
+	lines := strings.Split(strings.TrimSuffix(string(document), "\n"), "\n")
+	for i, line := range lines {
+		require.Len(t, line, nacha.RecordLength, "record %d", i+1)
+	}
+	require.Zero(t, len(lines)%nacha.BlockingFactor, "records are padded to whole blocks")
+	return lines
+}
+
+func TestCreateACHFileBatchesBySECCodeAndDate(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	consumer := f.transfer(t, 120.5, "011000015", usecases.BankAccountInput{}, "Payout March")
+	savings := f.transfer(t, 30, "122105278", usecases.BankAccountInput{AccountType: domain.BankAccountTypeSavings}, "Payout")
+	business := f.transfer(t, 1000, "026009593", usecases.BankAccountInput{HolderType: domain.AccountHolderTypeCompany, AccountNumber: "9876543210"}, "Invoice 42")
+
+	later := time.Now().AddDate(0, 0, 10)
+	scheduled := f.transfer(t, 5.25, "011000015", usecases.BankAccountInput{}, "Payout")
+	scheduled.ExecuteAt = &later
+	require.NoError(t, f.repo.Update(ctx, scheduled))
+
+	file, err := f.useCase.CreateACHFile(ctx)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(file.ID, "ACH"))
+	assert.Equal(t, "A", file.IDModifier)
+	assert.Equal(t, 3, file.BatchCount)
+	assert.Equal(t, 4, file.EntryCount)
+	assert.Equal(t, int64(115575), file.TotalCredit)
+	// 02600959 + 01100001 + 12210527 + 01100001
+	assert.Equal(t, int64(17011488), file.EntryHash)
+
+	lines := records(t, file.Document)
+	header := lines[0]
+	assert.Equal(t, "101 021000021", header[:13])
+	assert.Equal(t, "1234567890", header[13:23])
+	assert.Equal(t, "A09410", header[33:39])
+	assert.Equal(t, "JPMORGAN CHASE         ", header[40:63])
+	assert.Equal(t, "EXAMPLE PAYMENTS INC   ", header[63:86])
+
+	// The header, three batches of a header, entries with their addenda and a control, the
+	// file control, and padding to two blocks
+	require.Len(t, lines, 20)
+	for _, padding := range lines[16:] {
+		assert.Equal(t, strings.Repeat("9", nacha.RecordLength), padding)
+	}
+
+	ccd := lines[1]
+	assert.Equal(t, "5220EXAMPLE PAYMENT", ccd[:19])
+	assert.Equal(t, "1234567890CCDPAYOUT    ", ccd[40:63])
+	assert.Equal(t, "1021000020000001", ccd[78:94])
+	effective := ccd[69:75]
+	assert.Equal(t, "622026009593", lines[2][:12])
+	assert.Equal(t, "9876543210", strings.TrimSpace(lines[2][12:29]))
+	assert.Equal(t, "021000020000001", lines[2][79:94])
+	assert.Equal(t, "705INVOICE 42", lines[3][:13])
+
+	ppd := lines[5]
+	assert.Equal(t, "1234567890PPDPAYOUT    ", ppd[40:63])
+	assert.Equal(t, effective, ppd[69:75])
+	assert.Equal(t, "0000002", ppd[87:94])
+
+	entry := lines[6]
+	assert.Equal(t, "622011000015123456789        0000012050", entry[:39])
+	assert.Equal(t, strings.ToUpper(strings.ReplaceAll(consumer.ID, "-", ""))[:15], entry[39:54])
+	assert.Equal(t, "JANE MULLER           ", entry[54:76])
+	assert.Equal(t, "1021000020000002", entry[78:94])
+	addenda := lines[7]
+	assert.Equal(t, "705PAYOUT MARCH", addenda[:15])
+	assert.Equal(t, "00010000002", addenda[83:])
+	assert.Equal(t, "632122105278", lines[8][:12])
+	assert.Equal(t, "021000020000003", lines[8][79:94])
+
+	control := lines[10]
+	assert.Equal(t, "8220000004", control[:10])
+	assert.Equal(t, "0013310528", control[10:20])
+	assert.Equal(t, "000000000000000000015050", control[20:44])
+	assert.Equal(t, "1234567890", control[44:54])
+	assert.Equal(t, "021000020000002", control[79:94])
+
+	future := lines[11]
+	assert.Equal(t, "PPD", future[50:53])
+	assert.Equal(t, later.UTC().Format("060102"), future[69:75])
+	assert.Equal(t, "021000020000004", lines[12][79:94])
+
+	fileControl := lines[15]
+	assert.Equal(t, "9000003000002000000080017011488", fileControl[:31])
+	assert.Equal(t, "000000000000000000115575", fileControl[31:55])
+
+	for _, payment := range []*domain.Payment{consumer, savings, business, scheduled} {
+		stored, err := f.repo.GetByID(ctx, payment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.PaymentStatusSubmitted, stored.Status)
+		assert.Equal(t, file.ID, stored.SubmissionID)
+	}
+
+	stored, err := f.useCase.GetACHFile(ctx, file.ID)
+	require.NoError(t, err)
+	assert.Equal(t, file.Document, stored.Document)
+	assert.Equal(t, file.Entries, stored.Entries)
+
+	_, err = f.useCase.CreateACHFile(ctx)
+	assert.ErrorIs(t, err, domain.ErrNoACHTransfers)
+
+	next := f.transfer(t, 1, "011000015", usecases.BankAccountInput{}, "Payout")
+	second, err := f.useCase.CreateACHFile(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "B", second.IDModifier)
+	assert.Equal(t, []domain.ACHEntry{{TraceNumber: "021000020000005", PaymentID: next.ID}}, second.Entries)
+}
+
+func TestCreateACHFileSkipsOtherPayments(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	included := f.transfer(t, 10, "011000015", usecases.BankAccountInput{}, "Payout")
+
+	sepa := domain.NewPayment(20, "EUR", "SEPA transfer")
+	sepa.Payee = &domain.Party{Name: "Supplier"}
+	sepa.Method = domain.BankAccountMethod{Scheme: domain.BankSchemeSEPA, IBAN: "DE89370400440532013000"}
+	require.NoError(t, f.repo.Create(ctx, sepa))
+
+	card := domain.NewPayment(30, "USD", "Card payment")
+	card.Method = domain.CardMethod{Brand: "VISA", Last4: "4242", ExpiryMonth: 12, ExpiryYear: 2030}
+	require.NoError(t, f.repo.Create(ctx, card))
+
+	nameless := domain.NewPayment(40, "USD", "No receiver name")
+	nameless.Method = domain.BankAccountMethod{Scheme: domain.BankSchemeACH, RoutingNumber: "011000015", AccountNumber: "1234"}
+	require.NoError(t, f.repo.Create(ctx, nameless))
+
+	processed := f.transfer(t, 50, "011000015", usecases.BankAccountInput{}, "Payout")
+	processed.Processor = "stripe"
+	require.NoError(t, f.repo.Update(ctx, processed))
+
+	file, err := f.useCase.CreateACHFile(ctx)
+	require.NoError(t, err)
+	require.Len(t, file.Entries, 1)
+	assert.Equal(t, included.ID, file.Entries[0].PaymentID)
+	assert.Equal(t, int64(1000), file.TotalCredit)
+
+	for _, payment := range []*domain.Payment{sepa, card, nameless, processed} {
+		stored, err := f.repo.GetByID(ctx, payment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.PaymentStatusPending, stored.Status, payment.Description)
+	}
+}
+
+// record joins fields and pads them with spaces to a full record
+func record(fields ...string) string {
+	line := strings.Join(fields, "")
+	return line + strings.Repeat(" ", nacha.RecordLength-len(line))
+}
+
+// returnFile builds an ACH return file with one return per trace number and amount, and a
+// notification of change
+func returnFile(returns map[string][2]string) string {
+	lines := []string{
+		record("101 021000021 0110000152603050800A094101JPMORGAN CHASE         EXAMPLE PAYMENTS INC"),
+		record("5220EXAMPLE PAYMENTS                    1234567890PPDPAYOUT          260305   1011000010000001"),
+	}
+	sequence := 0
+	for trace, detail := range returns {
+		sequence++
+		returnTrace := "01100001" + strings.Repeat("0", 6) + string(rune('0'+sequence))
+		lines = append(lines,
+			record("621021000021123456789        ", detail[1], "               JANE MULLER             1", returnTrace),
+			record("799", detail[0], trace, "      02100002ACCOUNT HOLDER NOTIFIED", strings.Repeat(" ", 21), returnTrace),
+		)
+	}
+	lines = append(lines,
+		record("621021000021123456789        0000000000               JANE MULLER             1011000010000099"),
+		record("798C01021000020000009      02100002987654321                      ", "011000010000099"),
+		record("8220"),
+		record("9"),
+	)
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
+
+func TestParseReturns(t *testing.T) {
+	returns, err := nacha.ParseReturns(strings.NewReader(returnFile(map[string][2]string{"021000020000001": {"R03", "0000012050"}})))
+	require.NoError(t, err)
+	require.Len(t, returns, 1)
+	assert.Equal(t, domain.ACHReturn{
+		TraceNumber:         "011000010000001",
+		OriginalTraceNumber: "021000020000001",
+		Code:                "R03",
+		Reason:              "No account or unable to locate account",
+		Amount:              12050,
+		Information:         "ACCOUNT HOLDER NOTIFIED",
+	}, returns[0])
+
+	// Records without line breaks are read as well
+	unbroken := strings.ReplaceAll(returnFile(map[string][2]string{"021000020000001": {"R99", "0000000100"}}), "\r\n", "")
+	returns, err = nacha.ParseReturns(strings.NewReader(unbroken))
+	require.NoError(t, err)
+	assert.Equal(t, "Unknown return reason", returns[0].Reason)
+}
+
+func TestParseReturnsRejectsInvalidFiles(t *testing.T) {
+	tests := map[string]string{
+		"empty":            "",
+		"short record":     "101 021000021\n",
+		"no file header":   record("5220") + "\n",
+		"entry no addenda": record("1") + "\n" + record("6210210000211") + "\n" + record("8") + "\n",
+		"stray addenda":    record("1") + "\n" + record("799R01021000020000001") + "\n",
+		"bad reason code":  record("1") + "\n" + record("621021000021123456789        0000000100") + "\n" + record("799X01021000020000001") + "\n",
+		"unknown record":   record("1") + "\n" + record("X") + "\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := nacha.ParseReturns(strings.NewReader(content))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestApplyACHReturns(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	returned := f.transfer(t, 120.5, "011000015", usecases.BankAccountInput{}, "Payout")
+	completed := f.transfer(t, 30, "011000015", usecases.BankAccountInput{}, "Payout")
+	mismatched := f.transfer(t, 40, "011000015", usecases.BankAccountInput{}, "Payout")
+	file, err := f.useCase.CreateACHFile(ctx)
+	require.NoError(t, err)
+	require.Len(t, file.Entries, 3)
+
+	status := domain.PaymentStatusCompleted
+	_, err = f.useCase.UpdatePayment(ctx, usecases.UpdatePaymentInput{ID: completed.ID, Status: &status})
+	require.NoError(t, err)
+
+	content := returnFile(map[string][2]string{
+		file.Entries[0].TraceNumber: {"R01", "0000012050"},
+		file.Entries[1].TraceNumber: {"R02", "0000003000"},
+		file.Entries[2].TraceNumber: {"R03", "0000001000"},
+		"021000029999999":           {"R04", "0000000100"},
+	})
+	returns, err := nacha.ParseReturns(strings.NewReader(content))
+	require.NoError(t, err)
+
+	report, err := f.useCase.ApplyACHReturns(ctx, returns)
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Total)
+	assert.Equal(t, 2, report.Applied)
+	assert.Equal(t, 1, report.Unmatched)
+	assert.Equal(t, 1, report.Rejected)
+
+	results := make(map[string]usecases.ACHReturnResult)
+	for _, result := range report.Returns {
+		results[result.Return.OriginalTraceNumber] = result
+	}
+	assert.Equal(t, usecases.ACHReturnStatusApplied, results[file.Entries[0].TraceNumber].Status)
+	assert.Equal(t, returned.ID, results[file.Entries[0].TraceNumber].PaymentID)
+	assert.Equal(t, usecases.ACHReturnStatusRejected, results[file.Entries[2].TraceNumber].Status)
+	assert.Contains(t, results[file.Entries[2].TraceNumber].Error, "differs")
+	assert.Equal(t, usecases.ACHReturnStatusUnmatched, results["021000029999999"].Status)
+
+	stored, err := f.repo.GetByID(ctx, returned.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusFailed, stored.Status)
+	assert.Equal(t, "R01 Insufficient funds", stored.ProcessorResponse)
+	// The completed payment was paid out, so the return refunds it
+	stored, err = f.repo.GetByID(ctx, completed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusRefunded, stored.Status)
+	assert.Equal(t, 30.0, stored.RefundedAmount)
+	assert.Equal(t, "R02 Account closed", stored.ProcessorResponse)
+	stored, err = f.repo.GetByID(ctx, mismatched.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusSubmitted, stored.Status)
+
+	again, err := f.useCase.ApplyACHReturns(ctx, returns)
+	require.NoError(t, err)
+	assert.Equal(t, 2, again.Duplicate)
+	assert.Zero(t, again.Applied)
+}
+
+// brokenEntries fails every trace number lookup
+type brokenEntries struct {
+	*database.ACHRepository
+}
+
+func (brokenEntries) EntryByTraceNumber(ctx context.Context, traceNumber string) (*domain.ACHEntry, error) {
+	return nil, errors.New("database is locked")
+}
+
+func TestApplyACHReturnsReportsStorageFailures(t *testing.T) {
+	repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "ach.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	files, err := database.NewACHRepository(repo.DB())
+	require.NoError(t, err)
+	writer, err := nacha.NewWriter(nacha.Originator{CompanyName: "Example", CompanyID: "1234567890", BankRouting: bankRouting})
+	require.NoError(t, err)
+	useCase := usecases.NewPaymentUseCase(repo, usecases.WithACH(brokenEntries{files}, writer))
+
+	_, err = useCase.ApplyACHReturns(context.Background(), []domain.ACHReturn{{OriginalTraceNumber: "021000020000001", Code: "R01", Amount: 100}})
+	assert.EqualError(t, err, "database is locked", "lookup failures are not reported as unmatched returns")
+}
+
+func TestACHAccountDetails(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	payment := f.transfer(t, 10, "011000015", usecases.BankAccountInput{}, "Payout")
+	account := payment.Method.(domain.BankAccountMethod)
+	assert.Equal(t, domain.BankAccountTypeChecking, account.AccountType)
+	assert.Equal(t, domain.AccountHolderTypeIndividual, account.HolderType)
+
+	_, err := f.useCase.CreatePayment(ctx, usecases.CreatePaymentInput{
+		Amount: 10, Currency: "USD", Description: "Payout",
+		Method: &usecases.PaymentMethodInput{Type: domain.PaymentMethodTypeBankAccount, BankAccount: &usecases.BankAccountInput{
+			Scheme: domain.BankSchemeACH, RoutingNumber: "011000015", AccountNumber: "123456789", AccountType: "MONEY_MARKET",
+		}},
+	})
+	assert.EqualError(t, err, "account type must be CHECKING or SAVINGS")
+
+	_, err = nacha.NewWriter(nacha.Originator{CompanyName: "Example", CompanyID: "1234567890", BankRouting: "021000022"})
+	assert.Error(t, err)
+	_, err = nacha.NewWriter(nacha.Originator{CompanyName: "Example", CompanyID: "12-3456789", BankRouting: bankRouting})
+	assert.Error(t, err)
+}
+
+func TestACHNotConfigured(t *testing.T) {
+	repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "ach.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	useCase := usecases.NewPaymentUseCase(repo)
+
+	_, err = useCase.CreateACHFile(context.Background())
+	assert.ErrorIs(t, err, usecases.ErrACHNotConfigured)
+	_, err = useCase.ApplyACHReturns(context.Background(), nil)
+	assert.ErrorIs(t, err, usecases.ErrACHNotConfigured)
+}
@@ -0,0 +1,255 @@
+package bulk_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"payments_app/internal/domain"
+	"payments_app/internal/fees"
+	"payments_app/internal/infrastructure/database"
+	"payments_app/internal/infrastructure/processor"
+	"payments_app/internal/interfaces/bulk"
+	"payments_app/internal/usecases"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fixture struct {
+	repo    *database.PaymentRepository
+	useCase *usecases.PaymentUseCase
+}
+
+func setup(t *testing.T) *fixture {
+	repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "bulk.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	return &fixture{repo: repo, useCase: usecases.NewPaymentUseCase(repo,
+		usecases.WithProcessor(processor.NewSimulator(processor.SimulatorConfig{})),
+		usecases.WithAutoCapture(true),
+		usecases.WithScheduledPayments(repo),
+	)}
+}
+
+const mixedCSV = `amount,currency,description,payee_name,payee_country,method,bank_scheme,iban,bic,execute_at
+120.50,eur,Supplier A,ACME GmbH,DE,BANK_ACCOUNT,SEPA,DE89370400440532013000,COBADEFFXXX,
+abc,EUR,Supplier B,,,,,,,
+75,USD,Supplier C,,,,,,,2999-01-01T00:00:00Z
+0,EUR,Supplier D,,,,,,,
+10,EUR,Supplier E,ACME GmbH,DEU,,,,,
+`
+
+func (f *fixture) payments(t *testing.T) []*domain.Payment {
+	payments, err := f.repo.GetAll(context.Background())
+	require.NoError(t, err)
+	return payments
+}
+
+func TestParseCSV_MapsColumnsAndReportsLineNumbers(t *testing.T) {
+	rows, err := bulk.Parse(strings.NewReader(mixedCSV), bulk.FormatCSV)
+	require.NoError(t, err)
+	require.Len(t, rows, 5)
+
+	first := rows[0]
+	assert.Equal(t, 2, first.Line)
+	require.NoError(t, first.Err)
+	assert.Equal(t, 120.50, first.Input.Amount)
+	require.NotNil(t, first.Input.Payee)
+	assert.Equal(t, "ACME GmbH", first.Input.Payee.Name)
+	assert.Nil(t, first.Input.Payer, "empty party columns leave the party unset")
+	require.NotNil(t, first.Input.Method)
+	assert.Equal(t, domain.BankSchemeSEPA, first.Input.Method.BankAccount.Scheme)
+
+	assert.Equal(t, 3, rows[1].Line)
+	assert.ErrorContains(t, rows[1].Err, "not a number")
+	require.NotNil(t, rows[2].Input.ExecuteAt)
+	assert.Equal(t, 2999, rows[2].Input.ExecuteAt.Year())
+}
+
+func TestParseCSV_RejectsUnknownAndMissingColumns(t *testing.T) {
+	_, err := bulk.Parse(strings.NewReader("amount,currency,description,colour\n1,EUR,x,red\n"), bulk.FormatCSV)
+	assert.ErrorContains(t, err, `unknown CSV column "colour"`)
+
+	_, err = bulk.Parse(strings.NewReader("amount,currency\n1,EUR\n"), bulk.FormatCSV)
+	assert.ErrorContains(t, err, `missing required CSV column "description"`)
+}
+
+func TestParseCSV_QuotedFieldsAndWrongFieldCount(t *testing.T) {
+	input := "\ufeffamount,currency,description\n" +
+		"1,EUR,\"Invoice 1, line\nbreak\"\n" +
+		"2,EUR\n" +
+		"3,EUR,Invoice 3\n"
+
+	rows, err := bulk.Parse(strings.NewReader(input), bulk.FormatCSV)
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, "Invoice 1, line\nbreak", rows[0].Input.Description)
+	assert.Equal(t, 4, rows[1].Line)
+	assert.Error(t, rows[1].Err)
+	assert.Equal(t, 5, rows[2].Line)
+	assert.NoError(t, rows[2].Err)
+}
+
+func TestParseJSONL_SkipsBlankLinesAndRejectsUnknownFields(t *testing.T) {
+	input := `{"amount":10,"currency":"EUR","description":"First","method":{"type":"WALLET","wallet":{"provider":"APPLE_PAY","token":"tok"}}}
+
+{"amount":20,"currency":"EUR","description":"Second","colour":"red"}
+not json
+`
+	rows, err := bulk.Parse(strings.NewReader(input), bulk.FormatJSONL)
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, 1, rows[0].Line)
+	require.NoError(t, rows[0].Err)
+	assert.Equal(t, "APPLE_PAY", rows[0].Input.Method.Wallet.Provider)
+	assert.Equal(t, 3, rows[1].Line)
+	assert.ErrorContains(t, rows[1].Err, "unknown field")
+	assert.Equal(t, 4, rows[2].Line)
+	assert.Error(t, rows[2].Err)
+}
+
+func TestFormatFromFilename(t *testing.T) {
+	format, err := bulk.FormatFromFilename("payments.CSV")
+	require.NoError(t, err)
+	assert.Equal(t, bulk.FormatCSV, format)
+
+	format, err = bulk.FormatFromFilename("payments.ndjson")
+	require.NoError(t, err)
+	assert.Equal(t, bulk.FormatJSONL, format)
+
+	_, err = bulk.FormatFromFilename("payments.xlsx")
+	assert.Error(t, err)
+}
+
+func TestBulkCreatePayments_BestEffortCreatesValidRows(t *testing.T) {
+	f := setup(t)
+	rows, err := bulk.Parse(strings.NewReader(mixedCSV), bulk.FormatCSV)
+	require.NoError(t, err)
+
+	result, err := f.useCase.BulkCreatePayments(context.Background(), usecases.BulkModeBestEffort, rows)
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, result.Total)
+	assert.Equal(t, 2, result.Created)
+	assert.Equal(t, 3, result.Failed)
+
+	assert.Equal(t, usecases.BulkRowStatusCreated, result.Rows[0].Status)
+	assert.Equal(t, domain.PaymentStatusCompleted, result.Rows[0].PaymentStatus)
+	assert.Equal(t, usecases.ErrorCodeInvalidRow, result.Rows[1].ErrorCode)
+	assert.Equal(t, domain.PaymentStatusScheduled, result.Rows[2].PaymentStatus)
+	assert.Equal(t, 5, result.Rows[3].Line)
+	assert.Equal(t, usecases.ErrorCodeInvalidAmount, result.Rows[3].ErrorCode)
+	assert.Equal(t, usecases.ErrorCodeInvalidParty, result.Rows[4].ErrorCode)
+	assert.Equal(t, "payee country must be a 2-letter ISO code", result.Rows[4].Error)
+
+	payments := f.payments(t)
+	assert.Len(t, payments, 2)
+	stored, err := f.repo.GetByID(context.Background(), result.Rows[0].PaymentID)
+	require.NoError(t, err)
+	assert.Equal(t, "EUR", stored.Currency, "rows go through the same normalization as createPayment")
+}
+
+func TestBulkCreatePayments_AllOrNothingCreatesNothingOnInvalidRow(t *testing.T) {
+	f := setup(t)
+	rows, err := bulk.Parse(strings.NewReader(mixedCSV), bulk.FormatCSV)
+	require.NoError(t, err)
+
+	result, err := f.useCase.BulkCreatePayments(context.Background(), usecases.BulkModeAllOrNothing, rows)
+	require.NoError(t, err)
+
+	assert.Zero(t, result.Created)
+	assert.Equal(t, 3, result.Failed)
+	assert.Equal(t, 2, result.Skipped)
+	assert.Equal(t, usecases.BulkRowStatusSkipped, result.Rows[0].Status)
+	assert.Equal(t, usecases.ErrorCodeNotAttempted, result.Rows[0].ErrorCode)
+	assert.Empty(t, f.payments(t))
+}
+
+func TestBulkCreatePayments_AllOrNothingCreatesEveryValidRow(t *testing.T) {
+	f := setup(t)
+	input := "amount,currency,description\n10,EUR,First\n20,USD,Second\n"
+	rows, err := bulk.Parse(strings.NewReader(input), bulk.FormatCSV)
+	require.NoError(t, err)
+
+	result, err := f.useCase.BulkCreatePayments(context.Background(), usecases.BulkModeAllOrNothing, rows)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, result.Created)
+	for _, row := range result.Rows {
+		assert.Equal(t, usecases.BulkRowStatusCreated, row.Status)
+		assert.Equal(t, domain.PaymentStatusCompleted, row.PaymentStatus)
+		assert.Empty(t, row.ErrorCode)
+	}
+	assert.Len(t, f.payments(t), 2)
+}
+
+func TestBulkCreatePayments_ChargeAndPostCreationFees(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fees.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("schedules:\n  - name: import\n    event: created\n    fixed: 0.50\n    account: revenue:import_fees\n"), 0o600))
+	engine := fees.NewEngine()
+	require.NoError(t, engine.Reload(path))
+
+	for _, mode := range []usecases.BulkMode{usecases.BulkModeBestEffort, usecases.BulkModeAllOrNothing} {
+		t.Run(string(mode), func(t *testing.T) {
+			repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "bulk.db"))
+			require.NoError(t, err)
+			t.Cleanup(func() { repo.Close() })
+			ledger, err := database.NewLedgerRepository(repo.DB())
+			require.NoError(t, err)
+			useCase := usecases.NewPaymentUseCase(repo,
+				usecases.WithProcessor(processor.NewSimulator(processor.SimulatorConfig{})),
+				usecases.WithScheduledPayments(repo),
+				usecases.WithFees(engine),
+				usecases.WithLedger(ledger))
+			ctx := context.Background()
+
+			input := "amount,currency,description,execute_at\n10,EUR,Now,\n20,EUR,Later,2999-01-01T00:00:00Z\n"
+			rows, err := bulk.Parse(strings.NewReader(input), bulk.FormatCSV)
+			require.NoError(t, err)
+			result, err := useCase.BulkCreatePayments(ctx, mode, rows)
+			require.NoError(t, err)
+			require.Equal(t, 2, result.Created)
+
+			for _, row := range result.Rows {
+				assert.Empty(t, row.ErrorCode)
+				stored, err := repo.GetByID(ctx, row.PaymentID)
+				require.NoError(t, err)
+				require.Len(t, stored.Fees, 1)
+				assert.Equal(t, domain.FeeEventCreated, stored.Fees[0].Event)
+				assert.Equal(t, domain.Money{MinorUnits: 50, Currency: "EUR"}, stored.Fees[0].Amount)
+				entries, err := ledger.EntriesForPayment(ctx, row.PaymentID)
+				require.NoError(t, err)
+				assert.Len(t, entries, 1)
+			}
+			revenue, err := ledger.Balance(ctx, "revenue:import_fees", "EUR")
+			require.NoError(t, err)
+			assert.InDelta(t, -1.00, revenue, 1e-9)
+		})
+	}
+}
+
+func TestBulkCreatePayments_RejectsEmptyAndOversizedImports(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+
+	_, err := f.useCase.BulkCreatePayments(ctx, usecases.BulkModeBestEffort, nil)
+	assert.Error(t, err)
+
+	_, err = f.useCase.BulkCreatePayments(ctx, "SOMETIMES", []usecases.BulkPaymentRow{{Line: 1}})
+	assert.ErrorContains(t, err, "unsupported import mode")
+
+	var csv strings.Builder
+	csv.WriteString("amount,currency,description\n")
+	for i := 0; i < usecases.MaxBulkRows+10; i++ {
+		csv.WriteString("1,EUR,x\n")
+	}
+	rows, err := bulk.Parse(strings.NewReader(csv.String()), bulk.FormatCSV)
+	require.NoError(t, err)
+	assert.Len(t, rows, usecases.MaxBulkRows+1, "parsing stops once the limit is exceeded")
+
+	_, err = f.useCase.BulkCreatePayments(ctx, usecases.BulkModeBestEffort, rows)
+	assert.ErrorContains(t, err, "at most")
+	assert.Empty(t, f.payments(t))
+}
@@ -0,0 +1,324 @@
+package credittransfer_test
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"payments_app/internal/domain"
+	"payments_app/internal/infrastructure/database"
+	"payments_app/internal/iso20022"
+	"payments_app/internal/usecases"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	xsdvalidate "github.com/terminalstatic/go-xsd-validate"
+)
+
+// pain001SchemaPath is where the published pain.001.001.09.xsd is placed, unchanged, to validate
+// generated files against it
+const pain001SchemaPath = "testdata/pain.001.001.09.xsd"
+
+const debtorIBAN = "DE89370400440532013000"
+
+type fixture struct {
+	repo      *database.PaymentRepository
+	transfers *database.CreditTransferRepository
+	useCase   *usecases.PaymentUseCase
+}
+
+func setup(t *testing.T) *fixture {
+	repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "credittransfer.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+
+	transfers, err := database.NewCreditTransferRepository(repo.DB())
+	require.NoError(t, err)
+	writer, err := iso20022.NewPain001Writer(iso20022.Account{Name: "Example Payments Ltd", IBAN: debtorIBAN, BIC: "COBADEFFXXX"})
+	require.NoError(t, err)
+
+	useCase := usecases.NewPaymentUseCase(repo, usecases.WithCreditTransfers(transfers, writer))
+	return &fixture{repo: repo, transfers: transfers, useCase: useCase}
+}
+
+// transfer stores a pending SEPA credit transfer to the given creditor account
+func (f *fixture) transfer(t *testing.T, amount float64, iban string, created time.Time) *domain.Payment {
+	payment := domain.NewPayment(amount, "EUR", "Invoice "+iban[len(iban)-4:])
+	payment.Payee = &domain.Party{Name: "Supplier " + iban[:2]}
+	payment.Method = domain.BankAccountMethod{Scheme: domain.BankSchemeSEPA, IBAN: iban}
+	payment.CreatedAt, payment.UpdatedAt = created, created
+	require.NoError(t, f.repo.Create(context.Background(), payment))
+	return payment
+}
+
+func validate(t *testing.T, schema, document []byte) {
+	require.NoError(t, xsdvalidate.Init())
+	defer xsdvalidate.Cleanup()
+	handler, err := xsdvalidate.NewXsdHandlerMem(schema, xsdvalidate.ParsErrDefault)
+	require.NoError(t, err)
+	defer handler.Free()
+	assert.NoError(t, handler.ValidateMem(document, xsdvalidate.ValidErrDefault))
+}
+
+type document struct {
+	Header struct {
+		MessageID            string `xml:"MsgId"`
+		NumberOfTransactions int    `xml:"NbOfTxs"`
+		ControlSum           string `xml:"CtrlSum"`
+	} `xml:"CstmrCdtTrfInitn>GrpHdr"`
+	Instructions []struct {
+		ID                   string `xml:"PmtInfId"`
+		NumberOfTransactions int    `xml:"NbOfTxs"`
+		ControlSum           string `xml:"CtrlSum"`
+		ExecutionDate        string `xml:"ReqdExctnDt>Dt"`
+		DebtorName           string `xml:"Dbtr>Nm"`
+		DebtorIBAN           string `xml:"DbtrAcct>Id>IBAN"`
+		DebtorBIC            string `xml:"DbtrAgt>FinInstnId>BICFI"`
+		Transactions         []struct {
+			EndToEndID string `xml:"PmtId>EndToEndId"`
+			Amount     string `xml:"Amt>InstdAmt"`
+			IBAN       string `xml:"CdtrAcct>Id>IBAN"`
+			Agent      string `xml:"CdtrAgt>FinInstnId>Othr>Id"`
+		} `xml:"CdtTrfTxInf"`
+	} `xml:"CstmrCdtTrfInitn>PmtInf"`
+}
+
+func parse(t *testing.T, content []byte) document {
+	var doc document
+	require.NoError(t, xml.Unmarshal(content, &doc))
+	return doc
+}
+
+func TestCreateCreditTransferFileGroupsByDate(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	created := time.Now().Add(-time.Hour)
+	first := f.transfer(t, 120.5, "DE02100100109307118603", created)
+	second := f.transfer(t, 79.5, "FR1420041010050500013M02606", created.Add(time.Minute))
+
+	later := time.Now().AddDate(0, 0, 3)
+	scheduled := f.transfer(t, 10, "NL91ABNA0417164300", created)
+	scheduled.ExecuteAt = &later
+	require.NoError(t, f.repo.Update(ctx, scheduled))
+
+	payerFunded := f.transfer(t, 0.01, "GB29NWBK60161331926819", created.Add(2*time.Minute))
+	payerFunded.Payer = &domain.Party{Name: "Acme GmbH", Account: "DE02 1001 0010 9307 1186 03"}
+	require.NoError(t, f.repo.Update(ctx, payerFunded))
+
+	file, err := f.useCase.CreateCreditTransferFile(ctx)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(file.MessageID, "CT"))
+	assert.LessOrEqual(t, len(file.MessageID), 35)
+	assert.Equal(t, 4, file.NumberOfTransactions)
+	assert.Equal(t, "210.01", file.ControlSum)
+
+	doc := parse(t, file.Document)
+	assert.Equal(t, file.MessageID, doc.Header.MessageID)
+	assert.Equal(t, 4, doc.Header.NumberOfTransactions)
+	assert.Equal(t, "210.01", doc.Header.ControlSum)
+
+	today := file.CreatedAt.UTC().Format("2006-01-02")
+	require.Len(t, doc.Instructions, 2)
+	configured := doc.Instructions[0]
+	assert.Equal(t, file.MessageID+"-1", configured.ID)
+	assert.Equal(t, today, configured.ExecutionDate)
+	assert.Equal(t, debtorIBAN, configured.DebtorIBAN, "a payer's IBAN is never debited")
+	assert.Equal(t, "COBADEFFXXX", configured.DebtorBIC)
+	assert.Equal(t, 3, configured.NumberOfTransactions)
+	assert.Equal(t, "200.01", configured.ControlSum)
+	require.Len(t, configured.Transactions, 3)
+	assert.Equal(t, strings.ReplaceAll(first.ID, "-", ""), configured.Transactions[0].EndToEndID)
+	assert.Equal(t, "120.50", configured.Transactions[0].Amount)
+	assert.Empty(t, configured.Transactions[0].Agent, "the creditor agent is left out without a BIC")
+	assert.Equal(t, "FR1420041010050500013M02606", configured.Transactions[1].IBAN)
+	assert.Equal(t, "GB29NWBK60161331926819", configured.Transactions[2].IBAN)
+
+	future := doc.Instructions[1]
+	assert.Equal(t, later.UTC().Format("2006-01-02"), future.ExecutionDate)
+	assert.Equal(t, debtorIBAN, future.DebtorIBAN)
+	assert.Equal(t, "10.00", future.ControlSum)
+
+	for _, payment := range []*domain.Payment{first, second, scheduled, payerFunded} {
+		stored, err := f.repo.GetByID(ctx, payment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.PaymentStatusSubmitted, stored.Status)
+		assert.Equal(t, file.MessageID, stored.SubmissionID)
+	}
+
+	stored, err := f.useCase.GetCreditTransferFile(ctx, file.MessageID)
+	require.NoError(t, err)
+	assert.Equal(t, file.Document, stored.Document)
+	assert.Equal(t, "210.01", stored.ControlSum)
+	assert.ElementsMatch(t, file.PaymentIDs, stored.PaymentIDs)
+
+	_, err = f.useCase.CreateCreditTransferFile(ctx)
+	assert.ErrorIs(t, err, domain.ErrNoCreditTransfers)
+}
+
+func TestCreateCreditTransferFileSkipsOtherPayments(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	created := time.Now()
+	included := f.transfer(t, 25, "DE02100100109307118603", created)
+
+	card := domain.NewPayment(30, "EUR", "Card payment")
+	card.Method = domain.CardMethod{Brand: "VISA", Last4: "4242", ExpiryMonth: 12, ExpiryYear: 2030}
+	require.NoError(t, f.repo.Create(ctx, card))
+
+	ach := domain.NewPayment(40, "USD", "ACH payment")
+	ach.Payee = &domain.Party{Name: "Vendor Inc"}
+	ach.Method = domain.BankAccountMethod{Scheme: domain.BankSchemeACH, RoutingNumber: "011000015", AccountNumber: "123456789"}
+	require.NoError(t, f.repo.Create(ctx, ach))
+
+	nameless := f.transfer(t, 50, "NL91ABNA0417164300", created)
+	nameless.Payee = nil
+	require.NoError(t, f.repo.Update(ctx, nameless))
+
+	processed := f.transfer(t, 60, "FR1420041010050500013M02606", created)
+	processed.Processor = "stripe"
+	require.NoError(t, f.repo.Update(ctx, processed))
+
+	completed := f.transfer(t, 70, "GB29NWBK60161331926819", created)
+	completed.Status = domain.PaymentStatusCompleted
+	require.NoError(t, f.repo.Update(ctx, completed))
+
+	francs := f.transfer(t, 80, "CH9300762011623852957", created)
+	francs.Currency = "CHF"
+	require.NoError(t, f.repo.Update(ctx, francs))
+
+	// A payment to review is sent only once an analyst approved it
+	unreviewed := f.transfer(t, 90, "AT611904300234573201", created)
+	unreviewed.Risk = &domain.RiskAssessment{Score: 60, Decision: domain.RiskDecisionReview}
+	require.NoError(t, f.repo.Update(ctx, unreviewed))
+	reviewed := f.transfer(t, 10, "BE68539007547034", created.Add(time.Second))
+	reviewedAt := created
+	reviewed.Risk = &domain.RiskAssessment{Score: 60, Decision: domain.RiskDecisionReview, ReviewedBy: "jane@example.com", ReviewedAt: &reviewedAt}
+	require.NoError(t, f.repo.Update(ctx, reviewed))
+
+	file, err := f.useCase.CreateCreditTransferFile(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{included.ID, reviewed.ID}, file.PaymentIDs)
+	assert.Equal(t, "35.00", file.ControlSum)
+
+	for _, payment := range []*domain.Payment{card, ach, nameless, processed, francs, unreviewed} {
+		stored, err := f.repo.GetByID(ctx, payment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.PaymentStatusPending, stored.Status, payment.Description)
+		assert.Empty(t, stored.SubmissionID)
+	}
+}
+
+func TestPain001WriterTransferable(t *testing.T) {
+	writer, err := iso20022.NewPain001Writer(iso20022.Account{Name: "Example Payments Ltd", IBAN: debtorIBAN})
+	require.NoError(t, err)
+	payment := domain.NewPayment(25, "EUR", "Invoice 1")
+	payment.Payee = &domain.Party{Name: "Supplier DE"}
+	payment.Method = domain.BankAccountMethod{Scheme: domain.BankSchemeSEPA, IBAN: "DE02100100109307118603"}
+	assert.True(t, writer.Transferable(payment))
+
+	payment.Currency = "USD"
+	assert.False(t, writer.Transferable(payment), "SEPA credit transfers are in euros")
+	payment.Currency = "EUR"
+
+	for decision, transferable := range map[domain.RiskDecision]bool{
+		domain.RiskDecisionAllow:  true,
+		domain.RiskDecisionReview: false,
+		domain.RiskDecisionDeny:   false,
+	} {
+		payment.Risk = &domain.RiskAssessment{Decision: decision}
+		assert.Equal(t, transferable, writer.Transferable(payment), decision)
+	}
+}
+
+func TestCreditTransferFileMatchesPublishedSchema(t *testing.T) {
+	schema, err := os.ReadFile(pain001SchemaPath)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Skipf("%s is missing; add the published schema from the ISO 20022 message catalogue, unchanged", pain001SchemaPath)
+	}
+	require.NoError(t, err)
+
+	f := setup(t)
+	ctx := context.Background()
+	created := time.Now().Add(-time.Hour)
+	f.transfer(t, 120.5, "DE02100100109307118603", created)
+	withAgent := f.transfer(t, 79.5, "FR1420041010050500013M02606", created)
+	withAgent.Method = domain.BankAccountMethod{Scheme: domain.BankSchemeSEPA, IBAN: "FR1420041010050500013M02606", BIC: "BNPAFRPPXXX"}
+	require.NoError(t, f.repo.Update(ctx, withAgent))
+	later := time.Now().AddDate(0, 0, 3)
+	scheduled := f.transfer(t, 10, "NL91ABNA0417164300", created)
+	scheduled.ExecuteAt = &later
+	require.NoError(t, f.repo.Update(ctx, scheduled))
+
+	file, err := f.useCase.CreateCreditTransferFile(ctx)
+	require.NoError(t, err)
+	validate(t, schema, file.Document)
+}
+
+func TestCreateFileConflictKeepsPayments(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	pending := f.transfer(t, 25, "DE02100100109307118603", time.Now())
+	changed := f.transfer(t, 30, "NL91ABNA0417164300", time.Now())
+	changed.Status = domain.PaymentStatusFailed
+	require.NoError(t, f.repo.Update(ctx, changed))
+
+	file := &domain.CreditTransferFile{
+		MessageID:  "CT-CONFLICT",
+		CreatedAt:  time.Now(),
+		PaymentIDs: []string{pending.ID, changed.ID},
+		Document:   []byte("<Document/>"),
+	}
+	err := f.transfers.CreateFile(ctx, file)
+	assert.ErrorIs(t, err, domain.ErrCreditTransferConflict)
+
+	stored, err := f.repo.GetByID(ctx, pending.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusPending, stored.Status)
+	assert.Empty(t, stored.SubmissionID)
+	_, err = f.transfers.GetFile(ctx, "CT-CONFLICT")
+	assert.Error(t, err)
+}
+
+func TestSubmittedPaymentLifecycle(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	payment := f.transfer(t, 25, "DE02100100109307118603", time.Now())
+
+	submitted := domain.PaymentStatusSubmitted
+	_, err := f.useCase.UpdatePayment(ctx, usecases.UpdatePaymentInput{ID: payment.ID, Status: &submitted})
+	assert.EqualError(t, err, "payments are submitted by creating a credit transfer or ACH file")
+
+	_, err = f.useCase.CreateCreditTransferFile(ctx)
+	require.NoError(t, err)
+
+	completed := domain.PaymentStatusCompleted
+	updated, err := f.useCase.UpdatePayment(ctx, usecases.UpdatePaymentInput{ID: payment.ID, Status: &completed})
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusCompleted, updated.Status)
+	assert.NotEmpty(t, updated.SubmissionID)
+}
+
+func TestNewPain001WriterValidatesDebtor(t *testing.T) {
+	_, err := iso20022.NewPain001Writer(iso20022.Account{IBAN: debtorIBAN})
+	assert.Error(t, err)
+	_, err = iso20022.NewPain001Writer(iso20022.Account{Name: "Example", IBAN: "DE00370400440532013000"})
+	assert.Error(t, err)
+	_, err = iso20022.NewPain001Writer(iso20022.Account{Name: "Example", IBAN: "de89 3704 0044 0532 0130 00"})
+	assert.NoError(t, err)
+}
+
+func TestCreditTransfersNotConfigured(t *testing.T) {
+	repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "credittransfer.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	useCase := usecases.NewPaymentUseCase(repo)
+
+	_, err = useCase.CreateCreditTransferFile(context.Background())
+	assert.ErrorIs(t, err, usecases.ErrCreditTransfersNotConfigured)
+	_, err = useCase.GetCreditTransferFile(context.Background(), "CT1")
+	assert.ErrorIs(t, err, usecases.ErrCreditTransfersNotConfigured)
+}
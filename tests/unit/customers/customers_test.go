@@ -0,0 +1,319 @@
+package customers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"payments_app/graph/generated"
+	"payments_app/internal/domain"
+	"payments_app/internal/infrastructure/database"
+	"payments_app/internal/interfaces/graphql"
+	"payments_app/internal/usecases"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingRepository counts the batched reads of the GraphQL loaders
+type countingRepository struct {
+	*database.CustomerRepository
+	getByIDs atomic.Int32
+	payments atomic.Int32
+}
+
+func (r *countingRepository) GetByIDs(ctx context.Context, ids []string) ([]*domain.Customer, error) {
+	r.getByIDs.Add(1)
+	return r.CustomerRepository.GetByIDs(ctx, ids)
+}
+
+func (r *countingRepository) Payments(ctx context.Context, customerIDs []string, page domain.PaymentPage) (map[string]*domain.PaymentList, error) {
+	r.payments.Add(1)
+	return r.CustomerRepository.Payments(ctx, customerIDs, page)
+}
+
+type fixture struct {
+	repo      *database.PaymentRepository
+	customers *countingRepository
+	useCase   *usecases.PaymentUseCase
+}
+
+func setup(t *testing.T) *fixture {
+	repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "customers.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	customerRepo, err := database.NewCustomerRepository(repo.DB())
+	require.NoError(t, err)
+
+	customers := &countingRepository{CustomerRepository: customerRepo}
+	useCase := usecases.NewPaymentUseCase(repo, usecases.WithCustomers(customers))
+	return &fixture{repo: repo, customers: customers, useCase: useCase}
+}
+
+func ptr(s string) *string { return &s }
+
+func (f *fixture) customer(t *testing.T, name string) *domain.Customer {
+	customer, err := f.useCase.CreateCustomer(context.Background(), usecases.CustomerInput{Name: ptr(name)})
+	require.NoError(t, err)
+	return customer
+}
+
+// payment stores a payment of the customer created at the given time
+func (f *fixture) payment(t *testing.T, customerID string, createdAt time.Time) *domain.Payment {
+	payment := domain.NewPayment(10, "EUR", "Order")
+	payment.CustomerID = customerID
+	payment.CreatedAt = createdAt
+	require.NoError(t, f.repo.Create(context.Background(), payment))
+	return payment
+}
+
+func TestCreateAndUpdateCustomer(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+
+	customer, err := f.useCase.CreateCustomer(ctx, usecases.CustomerInput{
+		Name:              ptr(" Ada Lovelace "),
+		Email:             ptr("Ada@Example.com"),
+		ExternalReference: ptr("crm-1"),
+		Metadata:          map[string]string{"tier": "gold"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Ada Lovelace", customer.Name)
+	assert.Equal(t, "ada@example.com", customer.Email)
+
+	updated, err := f.useCase.UpdateCustomer(ctx, customer.ID, usecases.CustomerInput{Name: ptr("Ada King")})
+	require.NoError(t, err)
+	assert.Equal(t, "Ada King", updated.Name)
+	assert.Equal(t, "ada@example.com", updated.Email)
+
+	found, err := f.useCase.ListCustomers(ctx, domain.CustomerFilter{Email: "ADA@example.com"})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "Ada King", found[0].Name)
+	assert.Equal(t, map[string]string{"tier": "gold"}, found[0].Metadata)
+}
+
+func TestCustomerValidation(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+
+	_, err := f.useCase.CreateCustomer(ctx, usecases.CustomerInput{Email: ptr("not an email")})
+	assert.Error(t, err)
+
+	_, err = f.useCase.CreateCustomer(ctx, usecases.CustomerInput{ExternalReference: ptr("crm-1")})
+	require.NoError(t, err)
+	_, err = f.useCase.CreateCustomer(ctx, usecases.CustomerInput{ExternalReference: ptr("crm-1")})
+	assert.Error(t, err, "external references are unique")
+
+	metadata := make(map[string]string)
+	for i := 0; i <= usecases.MaxMetadataKeys; i++ {
+		metadata[string(rune('a'+i%26))+string(rune('a'+i/26))] = "x"
+	}
+	_, err = f.useCase.CreateCustomer(ctx, usecases.CustomerInput{Metadata: metadata})
+	assert.Error(t, err)
+
+	_, err = f.useCase.GetCustomer(ctx, "missing")
+	assert.ErrorIs(t, err, domain.ErrCustomerNotFound)
+}
+
+func TestPaymentRefersToCustomer(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	customer := f.customer(t, "Ada")
+
+	payment, err := f.useCase.CreatePayment(ctx, usecases.CreatePaymentInput{
+		Amount: 25, Currency: "EUR", Description: "Order", CustomerID: customer.ID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, customer.ID, payment.CustomerID)
+
+	payments, err := f.useCase.ListPayments(ctx, domain.PaymentFilter{CustomerID: customer.ID})
+	require.NoError(t, err)
+	require.Len(t, payments, 1)
+	assert.Equal(t, payment.ID, payments[0].ID)
+
+	_, err = f.useCase.CreatePayment(ctx, usecases.CreatePaymentInput{
+		Amount: 25, Currency: "EUR", Description: "Order", CustomerID: "missing",
+	})
+	var input *usecases.InputError
+	require.True(t, errors.As(err, &input))
+	assert.Equal(t, usecases.ErrorCodeInvalidCustomer, input.Code)
+}
+
+func TestCustomerPaymentsArePagedPerCustomer(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	ada := f.customer(t, "Ada")
+	bob := f.customer(t, "Bob")
+	idle := f.customer(t, "Idle")
+
+	start := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	var adaPayments []*domain.Payment
+	for i := 0; i < 5; i++ {
+		adaPayments = append(adaPayments, f.payment(t, ada.ID, start.Add(time.Duration(i)*time.Minute)))
+	}
+	bobPayment := f.payment(t, bob.ID, start)
+
+	lists, err := f.useCase.CustomerPayments(ctx, []string{ada.ID, bob.ID, idle.ID}, domain.PaymentPage{First: 2})
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, lists[ada.ID].TotalCount)
+	assert.True(t, lists[ada.ID].HasNextPage)
+	require.Len(t, lists[ada.ID].Payments, 2)
+	assert.Equal(t, adaPayments[4].ID, lists[ada.ID].Payments[0].ID, "newest first")
+	assert.Equal(t, adaPayments[3].ID, lists[ada.ID].Payments[1].ID)
+
+	assert.Equal(t, 1, lists[bob.ID].TotalCount)
+	assert.False(t, lists[bob.ID].HasNextPage)
+	require.Len(t, lists[bob.ID].Payments, 1)
+	assert.Equal(t, bobPayment.ID, lists[bob.ID].Payments[0].ID)
+
+	assert.Equal(t, 0, lists[idle.ID].TotalCount)
+	assert.Empty(t, lists[idle.ID].Payments)
+
+	last := lists[ada.ID].Payments[1]
+	next, err := f.useCase.CustomerPayments(ctx, []string{ada.ID}, domain.PaymentPage{
+		First: 2,
+		After: &domain.PaymentCursor{CreatedAt: last.CreatedAt, ID: last.ID},
+	})
+	require.NoError(t, err)
+	require.Len(t, next[ada.ID].Payments, 2)
+	assert.Equal(t, adaPayments[2].ID, next[ada.ID].Payments[0].ID)
+	assert.Equal(t, adaPayments[1].ID, next[ada.ID].Payments[1].ID)
+	assert.True(t, next[ada.ID].HasNextPage)
+}
+
+func TestDeleteCustomerWithPaymentsIsRefused(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	unused := f.customer(t, "Unused")
+	used := f.customer(t, "Used")
+	f.payment(t, used.ID, time.Now())
+
+	require.NoError(t, f.useCase.DeleteCustomer(ctx, unused.ID))
+	_, err := f.useCase.GetCustomer(ctx, unused.ID)
+	assert.ErrorIs(t, err, domain.ErrCustomerNotFound)
+
+	assert.ErrorIs(t, f.useCase.DeleteCustomer(ctx, used.ID), domain.ErrCustomerHasPayments)
+}
+
+func TestEraseCustomerKeepsPayments(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	customer, err := f.useCase.CreateCustomer(ctx, usecases.CustomerInput{
+		Name:              ptr("Ada"),
+		Email:             ptr("ada@example.com"),
+		ExternalReference: ptr("crm-1"),
+		Metadata:          map[string]string{"tier": "gold"},
+	})
+	require.NoError(t, err)
+	payment := f.payment(t, customer.ID, time.Now())
+	transfer := domain.NewPayment(20, "EUR", "Transfer")
+	transfer.CustomerID = customer.ID
+	transfer.Payer = &domain.Party{Name: "Ada Lovelace", Account: "DE89370400440532013000", Country: "DE"}
+	transfer.Method = domain.BankAccountMethod{Scheme: domain.BankSchemeSEPA, IBAN: "DE89370400440532013000", HolderName: "Ada Lovelace"}
+	require.NoError(t, f.repo.Create(ctx, transfer))
+	card := domain.NewPayment(30, "EUR", "Card")
+	card.CustomerID = customer.ID
+	card.Method = domain.CardMethod{Brand: "visa", Last4: "4242", ExpiryMonth: 12, ExpiryYear: 2030, HolderName: "Ada Lovelace"}
+	require.NoError(t, f.repo.Create(ctx, card))
+
+	erased, err := f.useCase.EraseCustomer(ctx, customer.ID)
+	require.NoError(t, err)
+	require.NotNil(t, erased.ErasedAt)
+
+	stored, err := f.useCase.GetCustomer(ctx, customer.ID)
+	require.NoError(t, err)
+	assert.Empty(t, stored.Name)
+	assert.Empty(t, stored.Email)
+	assert.Empty(t, stored.ExternalReference)
+	assert.Empty(t, stored.Metadata)
+	assert.NotNil(t, stored.ErasedAt)
+
+	kept, err := f.useCase.GetPayment(ctx, payment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, customer.ID, kept.CustomerID)
+
+	// The payer data on the customer's payments is erased with the customer
+	kept, err = f.useCase.GetPayment(ctx, transfer.ID)
+	require.NoError(t, err)
+	assert.Nil(t, kept.Payer)
+	assert.Equal(t, domain.BankAccountMethod{Scheme: domain.BankSchemeSEPA}, kept.Method)
+	kept, err = f.useCase.GetPayment(ctx, card.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.CardMethod{Brand: "visa", Last4: "4242", ExpiryMonth: 12, ExpiryYear: 2030}, kept.Method)
+
+	_, err = f.useCase.UpdateCustomer(ctx, customer.ID, usecases.CustomerInput{Name: ptr("Ada")})
+	assert.ErrorIs(t, err, domain.ErrCustomerErased)
+	_, err = f.useCase.CreatePayment(ctx, usecases.CreatePaymentInput{
+		Amount: 5, Currency: "EUR", Description: "Order", CustomerID: customer.ID,
+	})
+	assert.Error(t, err)
+
+	again, err := f.useCase.EraseCustomer(ctx, customer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, stored.ErasedAt.Unix(), again.ErasedAt.Unix())
+}
+
+func TestGraphQLBatchesCustomerLookups(t *testing.T) {
+	f := setup(t)
+	start := time.Now().Add(-time.Hour)
+	for i := 0; i < 3; i++ {
+		customer := f.customer(t, "Customer")
+		f.payment(t, customer.ID, start.Add(time.Duration(i)*time.Minute))
+		f.payment(t, customer.ID, start.Add(time.Duration(i)*time.Minute+time.Second))
+	}
+
+	srv := handler.NewDefaultServer(generated.NewExecutableSchema(generated.Config{Resolvers: graphql.NewResolver(f.useCase)}))
+	ts := httptest.NewServer(graphql.Middleware(f.useCase, srv))
+	defer ts.Close()
+
+	body, err := json.Marshal(map[string]string{
+		"query": `{ payments { id customer { id name payments(first: 1) { totalCount pageInfo { hasNextPage endCursor } nodes { id } } } } }`,
+	})
+	require.NoError(t, err)
+	resp, err := http.Post(ts.URL, "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var result struct {
+		Data struct {
+			Payments []struct {
+				ID       string
+				Customer struct {
+					ID       string
+					Name     string
+					Payments struct {
+						TotalCount int
+						PageInfo   struct {
+							HasNextPage bool
+							EndCursor   *string
+						}
+						Nodes []struct{ ID string }
+					}
+				}
+			}
+		}
+		Errors []map[string]any
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	require.Empty(t, result.Errors)
+	require.Len(t, result.Data.Payments, 6)
+	for _, payment := range result.Data.Payments {
+		assert.Equal(t, "Customer", payment.Customer.Name)
+		assert.Equal(t, 2, payment.Customer.Payments.TotalCount)
+		assert.True(t, payment.Customer.Payments.PageInfo.HasNextPage)
+		assert.NotNil(t, payment.Customer.Payments.PageInfo.EndCursor)
+		assert.Len(t, payment.Customer.Payments.Nodes, 1)
+	}
+
+	assert.Equal(t, int32(1), f.customers.getByIDs.Load(), "customers are read in one batch")
+	assert.Equal(t, int32(1), f.customers.payments.Load(), "customer payments are read in one batch")
+}
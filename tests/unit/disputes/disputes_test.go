@@ -0,0 +1,244 @@
+package disputes_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"payments_app/internal/domain"
+	"payments_app/internal/infrastructure/database"
+	"payments_app/internal/infrastructure/storage"
+	"payments_app/internal/usecases"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fixture struct {
+	repo        *database.PaymentRepository
+	ledger      *database.LedgerRepository
+	evidenceDir string
+	useCase     *usecases.PaymentUseCase
+}
+
+func setup(t *testing.T) *fixture {
+	dir := t.TempDir()
+	repo, err := database.NewPaymentRepository(filepath.Join(dir, "disputes.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+
+	disputes, err := database.NewDisputeRepository(repo.DB())
+	require.NoError(t, err)
+	ledger, err := database.NewLedgerRepository(repo.DB())
+	require.NoError(t, err)
+	evidenceDir := filepath.Join(dir, "evidence")
+	files, err := storage.NewLocalStore(evidenceDir, 1024)
+	require.NoError(t, err)
+
+	useCase := usecases.NewPaymentUseCase(repo,
+		usecases.WithDisputes(disputes, files, 0),
+		usecases.WithLedger(ledger),
+	)
+	return &fixture{repo: repo, ledger: ledger, evidenceDir: evidenceDir, useCase: useCase}
+}
+
+func (f *fixture) completedPayment(t *testing.T, amount float64) *domain.Payment {
+	payment := domain.NewPayment(amount, "EUR", "Disputed order")
+	payment.Status = domain.PaymentStatusCompleted
+	require.NoError(t, f.repo.Create(context.Background(), payment))
+	return payment
+}
+
+func TestOpenDispute(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	payment := f.completedPayment(t, 80)
+
+	dispute, err := f.useCase.OpenDispute(ctx, usecases.OpenDisputeInput{PaymentID: payment.ID, ReasonCode: "10.4"})
+	require.NoError(t, err)
+	assert.Equal(t, domain.DisputeStatusOpen, dispute.Status)
+	assert.Equal(t, 80.0, dispute.Amount)
+	assert.Equal(t, "EUR", dispute.Currency)
+	assert.WithinDuration(t, time.Now().Add(usecases.DefaultEvidenceWindow), dispute.EvidenceDueAt, time.Minute)
+
+	_, err = f.useCase.OpenDispute(ctx, usecases.OpenDisputeInput{PaymentID: payment.ID, ReasonCode: "13.1"})
+	assert.ErrorContains(t, err, "already has an active dispute")
+
+	tooMuch := 100.0
+	other := f.completedPayment(t, 50)
+	_, err = f.useCase.OpenDispute(ctx, usecases.OpenDisputeInput{PaymentID: other.ID, ReasonCode: "10.4", Amount: &tooMuch})
+	assert.ErrorContains(t, err, "exceeds the payment amount")
+
+	// Refunded money cannot be charged back again
+	refunded := f.completedPayment(t, 60)
+	refunded.RefundedAmount = 25
+	require.NoError(t, f.repo.Update(ctx, refunded))
+	dispute, err = f.useCase.OpenDispute(ctx, usecases.OpenDisputeInput{PaymentID: refunded.ID, ReasonCode: "10.4"})
+	require.NoError(t, err)
+	assert.Equal(t, 35.0, dispute.Amount)
+	partRefunded := f.completedPayment(t, 60)
+	partRefunded.RefundedAmount = 25
+	require.NoError(t, f.repo.Update(ctx, partRefunded))
+	_, err = f.useCase.OpenDispute(ctx, usecases.OpenDisputeInput{PaymentID: partRefunded.ID, ReasonCode: "10.4", Amount: &tooMuch})
+	assert.EqualError(t, err, "dispute amount exceeds the payment amount less refunds and lost disputes of 35.00")
+
+	fullyRefunded := f.completedPayment(t, 40)
+	fullyRefunded.RefundedAmount = 40
+	fullyRefunded.Status = domain.PaymentStatusRefunded
+	require.NoError(t, f.repo.Update(ctx, fullyRefunded))
+	_, err = f.useCase.OpenDispute(ctx, usecases.OpenDisputeInput{PaymentID: fullyRefunded.ID, ReasonCode: "10.4"})
+	assert.EqualError(t, err, "payment was refunded or charged back in full and cannot be disputed")
+
+	pending := domain.NewPayment(20, "EUR", "Not captured")
+	require.NoError(t, f.repo.Create(ctx, pending))
+	_, err = f.useCase.OpenDispute(ctx, usecases.OpenDisputeInput{PaymentID: pending.ID, ReasonCode: "10.4"})
+	assert.ErrorContains(t, err, "only completed payments")
+}
+
+func TestSubmitDisputeEvidence(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	payment := f.completedPayment(t, 40)
+	dispute, err := f.useCase.OpenDispute(ctx, usecases.OpenDisputeInput{PaymentID: payment.ID, ReasonCode: "13.1"})
+	require.NoError(t, err)
+
+	receipt := "signed delivery receipt"
+	updated, err := f.useCase.SubmitDisputeEvidence(ctx, usecases.SubmitDisputeEvidenceInput{
+		DisputeID:   dispute.ID,
+		Text:        "Goods were delivered",
+		SubmittedBy: "ops@example.com",
+		Files: []usecases.EvidenceUpload{
+			{Name: "../../receipt.pdf", ContentType: "application/pdf", Content: strings.NewReader(receipt)},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, domain.DisputeStatusUnderReview, updated.Status)
+	require.Len(t, updated.Evidence, 1)
+	require.Len(t, updated.Evidence[0].Files, 1)
+
+	file := updated.Evidence[0].Files[0]
+	sum := sha256.Sum256([]byte(receipt))
+	assert.Equal(t, hex.EncodeToString(sum[:]), file.SHA256)
+	assert.Equal(t, int64(len(receipt)), file.Size)
+
+	// File names cannot escape the evidence directory
+	stored, err := os.ReadFile(filepath.Join(f.evidenceDir, file.Path))
+	require.NoError(t, err)
+	assert.Equal(t, receipt, string(stored))
+	assert.True(t, strings.HasPrefix(file.Path, filepath.Join("disputes", dispute.ID)))
+
+	reloaded, err := f.useCase.GetDispute(ctx, dispute.ID)
+	require.NoError(t, err)
+	require.Len(t, reloaded.Evidence, 1)
+	assert.Equal(t, updated.Evidence[0].Files, reloaded.Evidence[0].Files)
+
+	_, err = f.useCase.SubmitDisputeEvidence(ctx, usecases.SubmitDisputeEvidenceInput{
+		DisputeID:   dispute.ID,
+		SubmittedBy: "ops@example.com",
+		Files:       []usecases.EvidenceUpload{{Name: "huge.bin", Content: strings.NewReader(strings.Repeat("x", 2048))}},
+	})
+	assert.ErrorIs(t, err, storage.ErrFileTooLarge)
+}
+
+func TestResolveDispute_LostReversesLedger(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	payment := f.completedPayment(t, 120)
+	amount := 45.5
+	dispute, err := f.useCase.OpenDispute(ctx, usecases.OpenDisputeInput{PaymentID: payment.ID, ReasonCode: "4837", Amount: &amount})
+	require.NoError(t, err)
+
+	resolved, err := f.useCase.ResolveDispute(ctx, usecases.ResolveDisputeInput{DisputeID: dispute.ID, Note: "issuer sided with cardholder"})
+	require.NoError(t, err)
+	assert.Equal(t, domain.DisputeStatusLost, resolved.Status)
+	assert.NotNil(t, resolved.ResolvedAt)
+
+	entries, err := f.useCase.LedgerEntries(ctx, payment.ID)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.NoError(t, entries[0].Validate())
+
+	chargebacks, err := f.ledger.Balance(ctx, domain.AccountChargebacks, "EUR")
+	require.NoError(t, err)
+	assert.InDelta(t, 45.5, chargebacks, 0.001)
+	processorBalance, err := f.ledger.Balance(ctx, domain.AccountProcessorBalance, "EUR")
+	require.NoError(t, err)
+	assert.InDelta(t, -45.5, processorBalance, 0.001)
+
+	_, err = f.useCase.ResolveDispute(ctx, usecases.ResolveDisputeInput{DisputeID: dispute.ID, Won: true})
+	assert.ErrorIs(t, err, domain.ErrDisputeClosed)
+}
+
+func TestLostDisputesReduceDisputableAndRefundableAmounts(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	payment := f.completedPayment(t, 100)
+	amount := 30.0
+	dispute, err := f.useCase.OpenDispute(ctx, usecases.OpenDisputeInput{PaymentID: payment.ID, ReasonCode: "4837", Amount: &amount})
+	require.NoError(t, err)
+	_, err = f.useCase.ResolveDispute(ctx, usecases.ResolveDisputeInput{DisputeID: dispute.ID})
+	require.NoError(t, err)
+
+	// Only what the chargeback left can be disputed again
+	dispute, err = f.useCase.OpenDispute(ctx, usecases.OpenDisputeInput{PaymentID: payment.ID, ReasonCode: "10.4"})
+	require.NoError(t, err)
+	assert.Equal(t, 70.0, dispute.Amount)
+	_, err = f.useCase.ResolveDispute(ctx, usecases.ResolveDisputeInput{DisputeID: dispute.ID})
+	require.NoError(t, err)
+	_, err = f.useCase.OpenDispute(ctx, usecases.OpenDisputeInput{PaymentID: payment.ID, ReasonCode: "10.4"})
+	assert.EqualError(t, err, "payment was refunded or charged back in full and cannot be disputed")
+
+	other := f.completedPayment(t, 100)
+	dispute, err = f.useCase.OpenDispute(ctx, usecases.OpenDisputeInput{PaymentID: other.ID, ReasonCode: "4837", Amount: &amount})
+	require.NoError(t, err)
+	_, err = f.useCase.ResolveDispute(ctx, usecases.ResolveDisputeInput{DisputeID: dispute.ID})
+	require.NoError(t, err)
+	refund := 80.0
+	_, err = f.useCase.RefundPayment(ctx, other.ID, &refund)
+	assert.EqualError(t, err, "refund amount exceeds refundable balance of 70.00")
+}
+
+func TestResolveDispute_WonPostsNothing(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	payment := f.completedPayment(t, 60)
+	dispute, err := f.useCase.OpenDispute(ctx, usecases.OpenDisputeInput{PaymentID: payment.ID, ReasonCode: "10.4"})
+	require.NoError(t, err)
+
+	resolved, err := f.useCase.ResolveDispute(ctx, usecases.ResolveDisputeInput{DisputeID: dispute.ID, Won: true})
+	require.NoError(t, err)
+	assert.Equal(t, domain.DisputeStatusWon, resolved.Status)
+
+	entries, err := f.useCase.LedgerEntries(ctx, payment.ID)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestDisputesNearingDeadline(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	open := func(due time.Time) *domain.Dispute {
+		payment := f.completedPayment(t, 10)
+		dispute, err := f.useCase.OpenDispute(ctx, usecases.OpenDisputeInput{PaymentID: payment.ID, ReasonCode: "10.4", EvidenceDueAt: &due})
+		require.NoError(t, err)
+		return dispute
+	}
+	overdue := open(now.Add(-time.Hour))
+	soon := open(now.Add(24 * time.Hour))
+	open(now.Add(10 * 24 * time.Hour))
+	answered := open(now.Add(12 * time.Hour))
+	_, err := f.useCase.SubmitDisputeEvidence(ctx, usecases.SubmitDisputeEvidenceInput{DisputeID: answered.ID, Text: "tracking number", SubmittedBy: "ops"})
+	require.NoError(t, err)
+
+	nearing, err := f.useCase.DisputesNearingDeadline(ctx, 3*24*time.Hour)
+	require.NoError(t, err)
+	require.Len(t, nearing, 2)
+	assert.Equal(t, overdue.ID, nearing[0].ID)
+	assert.Equal(t, soon.ID, nearing[1].ID)
+}
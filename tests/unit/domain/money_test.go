@@ -0,0 +1,69 @@
+package domain_test
+
+import (
+	"math"
+	"payments_app/internal/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoney_FromFloatAndDecimal(t *testing.T) {
+	tests := []struct {
+		amount   float64
+		currency string
+		minor    int64
+		decimal  string
+	}{
+		{19.99, "EUR", 1999, "19.99"},
+		{0.1 + 0.2, "USD", 30, "0.30"},
+		{0.05, "EUR", 5, "0.05"},
+		{-12.5, "EUR", -1250, "-12.50"},
+		{1500, "JPY", 1500, "1500"},
+		{1.234, "KWD", 1234, "1.234"},
+	}
+	for _, tt := range tests {
+		money := domain.MoneyFromFloat(tt.amount, tt.currency)
+		assert.Equal(t, tt.minor, money.MinorUnits, tt.decimal)
+		assert.Equal(t, tt.decimal, money.Decimal())
+	}
+	assert.Equal(t, "19.99 EUR", domain.MoneyFromFloat(19.99, "EUR").String())
+	assert.Equal(t, "-92233720368547758.08", domain.Money{MinorUnits: math.MinInt64, Currency: "EUR"}.Decimal())
+}
+
+func TestMoney_DivideRounded(t *testing.T) {
+	assert.Equal(t, int64(334), domain.Money{MinorUnits: 1001, Currency: "EUR"}.DivideRounded(3).MinorUnits)
+	assert.Equal(t, int64(3), domain.Money{MinorUnits: 5, Currency: "EUR"}.DivideRounded(2).MinorUnits, "halves round away from zero")
+	assert.Equal(t, int64(-3), domain.Money{MinorUnits: -5, Currency: "EUR"}.DivideRounded(2).MinorUnits)
+	assert.Zero(t, domain.Money{MinorUnits: 5, Currency: "EUR"}.DivideRounded(0).MinorUnits)
+}
+
+func TestMoney_Parse(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		minor    int64
+	}{
+		{"1234.5", "EUR", 123450},
+		{"1234.50", "EUR", 123450},
+		{"-0.05", "EUR", -5},
+		{"+7", "USD", 700},
+		{".5", "EUR", 50},
+		{"1500", "JPY", 1500},
+		{"1500.00", "JPY", 1500},
+		{"1.234", "KWD", 1234},
+	}
+	for _, tt := range tests {
+		money, err := domain.ParseMoney(tt.amount, tt.currency)
+		require.NoError(t, err, tt.amount)
+		assert.Equal(t, domain.Money{MinorUnits: tt.minor, Currency: tt.currency}, money, tt.amount)
+	}
+
+	_, err := domain.ParseMoney("1.234", "EUR")
+	assert.ErrorContains(t, err, "more than 2 decimal places")
+	for _, invalid := range []string{"", ".", "1,50", "1e3", "--1"} {
+		_, err := domain.ParseMoney(invalid, "EUR")
+		assert.Error(t, err, invalid)
+	}
+}
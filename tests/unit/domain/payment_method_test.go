@@ -0,0 +1,43 @@
+package domain_test
+
+import (
+	"payments_app/internal/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidLuhn(t *testing.T) {
+	assert.True(t, domain.ValidLuhn("4111111111111111"))
+	assert.True(t, domain.ValidLuhn("5555555555554444"))
+	assert.True(t, domain.ValidLuhn("378282246310005"))
+	assert.False(t, domain.ValidLuhn("4111111111111112"))
+	assert.False(t, domain.ValidLuhn("4111a11111111111"))
+	assert.False(t, domain.ValidLuhn("0"))
+}
+
+func TestValidIBAN(t *testing.T) {
+	assert.True(t, domain.ValidIBAN("DE89370400440532013000"))
+	assert.True(t, domain.ValidIBAN("GB82 WEST 1234 5698 7654 32"))
+	assert.True(t, domain.ValidIBAN("fr1420041010050500013m02606"))
+	assert.False(t, domain.ValidIBAN("DE89370400440532013001"))
+	assert.False(t, domain.ValidIBAN("DE8937040044"))
+	assert.False(t, domain.ValidIBAN("1289370400440532013000"))
+}
+
+func TestValidABARoutingNumber(t *testing.T) {
+	assert.True(t, domain.ValidABARoutingNumber("011000015"))
+	assert.True(t, domain.ValidABARoutingNumber("021000021"))
+	assert.False(t, domain.ValidABARoutingNumber("021000022"))
+	assert.False(t, domain.ValidABARoutingNumber("02100002"))
+	assert.False(t, domain.ValidABARoutingNumber("02100002a"))
+}
+
+func TestCardBrand(t *testing.T) {
+	assert.Equal(t, "VISA", domain.CardBrand("4111111111111111"))
+	assert.Equal(t, "MASTERCARD", domain.CardBrand("5555555555554444"))
+	assert.Equal(t, "MASTERCARD", domain.CardBrand("2223003122003222"))
+	assert.Equal(t, "AMEX", domain.CardBrand("378282246310005"))
+	assert.Equal(t, "DISCOVER", domain.CardBrand("6011111111111117"))
+	assert.Equal(t, "UNKNOWN", domain.CardBrand("9999999999999995"))
+}
@@ -0,0 +1,286 @@
+package export_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"payments_app/internal/domain"
+	"payments_app/internal/infrastructure/database"
+	"payments_app/internal/interfaces/export"
+	"payments_app/internal/usecases"
+	"payments_app/pkg/logger"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fixture struct {
+	repo    *database.PaymentRepository
+	useCase *usecases.PaymentUseCase
+}
+
+func setup(t *testing.T) *fixture {
+	repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "export.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	return &fixture{repo: repo, useCase: usecases.NewPaymentUseCase(repo)}
+}
+
+// seed stores n payments one millisecond apart, starting at base
+func (f *fixture) seed(t *testing.T, n int, base time.Time, change func(i int, payment *domain.Payment)) []*domain.Payment {
+	payments := make([]*domain.Payment, n)
+	for i := range payments {
+		payment := domain.NewPayment(float64(i+1), "EUR", fmt.Sprintf("Payment %d", i))
+		payment.CreatedAt = base.Add(time.Duration(i) * time.Millisecond)
+		payment.UpdatedAt = payment.CreatedAt
+		if change != nil {
+			change(i, payment)
+		}
+		payments[i] = payment
+	}
+	require.NoError(t, f.repo.CreateBatch(context.Background(), payments))
+	return payments
+}
+
+func (f *fixture) exportIDs(t *testing.T, filter domain.PaymentFilter, during func(seen int)) []string {
+	var ids []string
+	err := f.useCase.ExportPayments(context.Background(), filter, func(payment *domain.Payment) error {
+		ids = append(ids, payment.ID)
+		if during != nil {
+			during(len(ids))
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	return ids
+}
+
+func TestListPayments_Filter(t *testing.T) {
+	f := setup(t)
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	payments := f.seed(t, 6, base, func(i int, payment *domain.Payment) {
+		if i%2 == 1 {
+			payment.Status = domain.PaymentStatusCompleted
+		}
+		if i >= 4 {
+			payment.Currency = "USD"
+			payment.TenantID = "tenant-b"
+		}
+	})
+	ctx := context.Background()
+
+	completed, err := f.useCase.ListPayments(ctx, domain.PaymentFilter{Statuses: []domain.PaymentStatus{domain.PaymentStatusCompleted}})
+	require.NoError(t, err)
+	require.Len(t, completed, 3)
+	assert.Equal(t, payments[1].ID, completed[0].ID, "results are in creation order")
+
+	usd, err := f.useCase.ListPayments(ctx, domain.PaymentFilter{Currency: "usd", TenantID: "tenant-b"})
+	require.NoError(t, err)
+	assert.Len(t, usd, 2)
+
+	from, to := base.Add(time.Millisecond), base.Add(3*time.Millisecond)
+	window, err := f.useCase.ListPayments(ctx, domain.PaymentFilter{CreatedFrom: &from, CreatedTo: &to})
+	require.NoError(t, err)
+	require.Len(t, window, 2, "createdFrom is inclusive, createdTo exclusive")
+	assert.Equal(t, payments[1].ID, window[0].ID)
+	assert.Equal(t, payments[2].ID, window[1].ID)
+}
+
+func TestListPayments_RejectsInvalidFilter(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+
+	_, err := f.useCase.ListPayments(ctx, domain.PaymentFilter{Statuses: []domain.PaymentStatus{"LOST"}})
+	var input *usecases.InputError
+	require.ErrorAs(t, err, &input)
+	assert.Equal(t, usecases.ErrorCodeInvalidFilter, input.Code)
+
+	now := time.Now()
+	_, err = f.useCase.ListPayments(ctx, domain.PaymentFilter{CreatedFrom: &now, CreatedTo: &now})
+	assert.ErrorContains(t, err, "createdFrom must be before createdTo")
+}
+
+func TestExportPayments_PagesThroughAllRowsInOrder(t *testing.T) {
+	f := setup(t)
+	// More than two pages, with several payments sharing a creation time
+	payments := f.seed(t, 1203, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), func(i int, payment *domain.Payment) {
+		payment.CreatedAt = payment.CreatedAt.Truncate(10 * time.Millisecond)
+	})
+
+	ids := f.exportIDs(t, domain.PaymentFilter{}, nil)
+
+	require.Len(t, ids, len(payments))
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		assert.False(t, seen[id], "payment %s exported twice", id)
+		seen[id] = true
+	}
+}
+
+func TestExportPayments_ReadsConsistentSnapshot(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	base := time.Now().Add(-time.Hour)
+	payments := f.seed(t, 1100, base, nil)
+
+	ids := f.exportIDs(t, domain.PaymentFilter{Statuses: []domain.PaymentStatus{domain.PaymentStatusPending}}, func(seen int) {
+		if seen != 1 {
+			return
+		}
+		// Concurrent writes after the export started: an insert, a deletion of a later row and a
+		// status change that would move a later row out of the filter
+		f.seed(t, 1, base.Add(-time.Minute), nil)
+		require.NoError(t, f.repo.Delete(ctx, payments[900].ID))
+		changed := payments[1000]
+		changed.Status = domain.PaymentStatusCancelled
+		require.NoError(t, f.repo.Update(ctx, changed))
+	})
+
+	require.Len(t, ids, len(payments))
+	assert.Equal(t, payments[900].ID, ids[900])
+	assert.Equal(t, payments[1000].ID, ids[1000])
+
+	after := f.exportIDs(t, domain.PaymentFilter{Statuses: []domain.PaymentStatus{domain.PaymentStatusPending}}, nil)
+	assert.Len(t, after, len(payments)-1, "later exports see the writes")
+}
+
+func TestExportPayments_StopsOnCallbackError(t *testing.T) {
+	f := setup(t)
+	f.seed(t, 3, time.Now(), nil)
+	stop := errors.New("stop")
+
+	calls := 0
+	err := f.useCase.ExportPayments(context.Background(), domain.PaymentFilter{}, func(*domain.Payment) error {
+		calls++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+}
+
+func exportTo(t *testing.T, f *fixture, format export.Format) []byte {
+	var out bytes.Buffer
+	count, err := export.Run(context.Background(), f.useCase, domain.PaymentFilter{}, format, func() (io.Writer, error) {
+		return &out, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	return out.Bytes()
+}
+
+func seedExportable(t *testing.T, f *fixture) []*domain.Payment {
+	executeAt := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	return f.seed(t, 2, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), func(i int, payment *domain.Payment) {
+		payment.Description = "Invoice, \"March\""
+		if i == 1 {
+			payment.Status = domain.PaymentStatusScheduled
+			payment.ExecuteAt = &executeAt
+			payment.Payee = &domain.Party{Name: "ACME GmbH", Country: "DE"}
+			payment.Risk = &domain.RiskAssessment{Score: 40, Decision: domain.RiskDecisionAllow}
+		}
+	})
+}
+
+func TestWriter_CSV(t *testing.T) {
+	f := setup(t)
+	payments := seedExportable(t, f)
+
+	records, err := csv.NewReader(bytes.NewReader(exportTo(t, f, export.FormatCSV))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, export.Columns, records[0])
+
+	row := make(map[string]string)
+	for i, column := range records[0] {
+		row[column] = records[2][i]
+	}
+	assert.Equal(t, payments[1].ID, row["id"])
+	assert.Equal(t, "2", row["amount"])
+	assert.Equal(t, "Invoice, \"March\"", row["description"])
+	assert.Equal(t, "ACME GmbH", row["payee_name"])
+	assert.Equal(t, "40", row["risk_score"])
+	assert.Equal(t, "2026-05-01T09:00:00Z", row["execute_at"])
+	assert.Equal(t, "", records[1][21], "unscheduled payments have no execute_at")
+}
+
+func TestWriter_JSONL(t *testing.T) {
+	f := setup(t)
+	seedExportable(t, f)
+
+	lines := strings.Split(strings.TrimSpace(string(exportTo(t, f, export.FormatJSONL))), "\n")
+	require.Len(t, lines, 2)
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+	assert.Equal(t, "SCHEDULED", record["status"])
+	assert.Equal(t, float64(40), record["risk_score"])
+	assert.Equal(t, "2026-05-01T09:00:00Z", record["execute_at"])
+}
+
+func TestWriter_Parquet(t *testing.T) {
+	f := setup(t)
+	payments := seedExportable(t, f)
+
+	data := exportTo(t, f, export.FormatParquet)
+	records, err := parquet.Read[export.Record](bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, payments[0].ID, records[0].ID)
+	assert.Nil(t, records[0].RiskScore)
+	assert.Nil(t, records[0].ExecuteAt)
+	assert.Equal(t, "ACME GmbH", records[1].PayeeName)
+	require.NotNil(t, records[1].RiskScore)
+	assert.Equal(t, int64(40), *records[1].RiskScore)
+	require.NotNil(t, records[1].ExecuteAt)
+	assert.True(t, records[1].ExecuteAt.Equal(time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)))
+}
+
+func TestRun_OpensOutputOnlyForValidExports(t *testing.T) {
+	f := setup(t)
+
+	opened := false
+	_, err := export.Run(context.Background(), f.useCase, domain.PaymentFilter{Currency: "EURO"}, export.FormatCSV, func() (io.Writer, error) {
+		opened = true
+		return io.Discard, nil
+	})
+	assert.Error(t, err)
+	assert.False(t, opened)
+
+	var out bytes.Buffer
+	count, err := export.Run(context.Background(), f.useCase, domain.PaymentFilter{}, export.FormatCSV, func() (io.Writer, error) {
+		return &out, nil
+	})
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	assert.Equal(t, strings.Join(export.Columns, ",")+"\n", out.String(), "empty exports still get a header")
+}
+
+func TestHandler(t *testing.T) {
+	f := setup(t)
+	seedExportable(t, f)
+	handler := export.NewHandler(f.useCase, logger.NewLoggerTo(io.Discard))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/exports/payments?format=jsonl&status=scheduled,completed", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), ".jsonl")
+	assert.Equal(t, 1, strings.Count(rec.Body.String(), "\n"))
+
+	for _, query := range []string{"format=xlsx", "status=LOST", "createdFrom=yesterday", "currency=EURO"} {
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/exports/payments?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"), query)
+	}
+}
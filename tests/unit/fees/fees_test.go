@@ -0,0 +1,222 @@
+package fees_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"payments_app/internal/domain"
+	"payments_app/internal/fees"
+	"payments_app/internal/infrastructure/database"
+	"payments_app/internal/infrastructure/processor"
+	"payments_app/internal/usecases"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const schedulesYAML = `
+schedules:
+  - name: processing
+    fixed: 0.25
+    percent: 1.4
+    min: 0.50
+    max: 5.00
+  - name: processing
+    method: WALLET
+    percent: 2
+  - name: processing
+    tenant: acme
+    tiers:
+      - up_to: 100
+        percent: 2
+      - up_to: 1000
+        percent: 1.5
+      - percent: 1
+  - name: platform
+    event: created
+    currency: EUR
+    fixed: 0.10
+    account: revenue:platform_fees
+`
+
+func loadEngine(t *testing.T, content string) *fees.Engine {
+	path := filepath.Join(t.TempDir(), "fees.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	engine := fees.NewEngine()
+	require.NoError(t, engine.Reload(path))
+	return engine
+}
+
+func feeAmounts(t *testing.T, engine *fees.Engine, payment *domain.Payment, event domain.FeeEvent) map[string]string {
+	charged, err := engine.Fees(payment, event)
+	require.NoError(t, err)
+	amounts := make(map[string]string)
+	for _, fee := range charged {
+		amounts[fee.Name] = fee.Amount.String()
+	}
+	return amounts
+}
+
+func TestFeesPickMostSpecificSchedule(t *testing.T) {
+	engine := loadEngine(t, schedulesYAML)
+	tests := []struct {
+		name    string
+		payment *domain.Payment
+		event   domain.FeeEvent
+		want    map[string]string
+	}{
+		{"fixed plus percentage", &domain.Payment{Amount: 100, Currency: "USD"}, domain.FeeEventCaptured, map[string]string{"processing": "1.65 USD"}},
+		{"minimum", &domain.Payment{Amount: 10, Currency: "USD"}, domain.FeeEventCaptured, map[string]string{"processing": "0.50 USD"}},
+		{"maximum", &domain.Payment{Amount: 1000, Currency: "USD"}, domain.FeeEventCaptured, map[string]string{"processing": "5.00 USD"}},
+		{"half rounds away from zero", &domain.Payment{Amount: 50.25, Currency: "USD"}, domain.FeeEventCaptured, map[string]string{"processing": "0.95 USD"}},
+		{"whole yen", &domain.Payment{Amount: 250, Currency: "JPY"}, domain.FeeEventCaptured, map[string]string{"processing": "4 JPY"}},
+		{"method", &domain.Payment{Amount: 100, Currency: "USD", Method: domain.WalletMethod{Provider: "apple_pay"}}, domain.FeeEventCaptured, map[string]string{"processing": "2.00 USD"}},
+		{"tenant beats method", &domain.Payment{Amount: 100, Currency: "USD", TenantID: "acme", Method: domain.WalletMethod{Provider: "apple_pay"}}, domain.FeeEventCaptured, map[string]string{"processing": "2.00 USD"}},
+		{"second tier", &domain.Payment{Amount: 500, Currency: "USD", TenantID: "acme"}, domain.FeeEventCaptured, map[string]string{"processing": "7.50 USD"}},
+		{"last tier", &domain.Payment{Amount: 5000, Currency: "USD", TenantID: "acme"}, domain.FeeEventCaptured, map[string]string{"processing": "50.00 USD"}},
+		{"created event", &domain.Payment{Amount: 100, Currency: "EUR"}, domain.FeeEventCreated, map[string]string{"platform": "0.10 EUR"}},
+		{"no match", &domain.Payment{Amount: 100, Currency: "USD"}, domain.FeeEventCreated, map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, feeAmounts(t, engine, tt.payment, tt.event))
+		})
+	}
+}
+
+func TestInvalidSchedules(t *testing.T) {
+	tests := map[string]string{
+		"schedules:\n  - percent: 1\n":                                                "schedule 1: name is required",
+		"schedules:\n  - name: a\n    event: settled\n":                               "schedule a: event must be CREATED or CAPTURED",
+		"schedules:\n  - name: a\n    method: CHEQUE\n":                               `schedule a: unknown method "CHEQUE"`,
+		"schedules:\n  - name: a\n    percent: -1\n":                                  `schedule a: tier 1: percent "-1" is not a non-negative decimal`,
+		"schedules:\n  - name: a\n    percent: 101\n":                                 "schedule a: tier 1: percent must not exceed 100",
+		"schedules:\n  - name: a\n    min: 2\n    max: 1\n":                           "schedule a: min must not be larger than max",
+		"schedules:\n  - name: a\n    fixed: 1\n    tiers:\n      - percent: 1\n":     "fixed and percent belong in the tiers",
+		"schedules:\n  - name: a\n    tiers:\n      - percent: 1\n      - up_to: 5\n": "tier 1: only the last tier may leave up_to empty",
+		"schedules:\n  - name: a\n    tiers:\n      - up_to: 5\n      - up_to: 5\n":   "tier 2: up_to must be larger than the previous tier's",
+	}
+	for content, message := range tests {
+		path := filepath.Join(t.TempDir(), "fees.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		cfg, err := fees.LoadConfig(path)
+		require.NoError(t, err)
+		_, err = fees.BuildSchedules(cfg)
+		assert.ErrorContains(t, err, message, "content %q", content)
+	}
+}
+
+func TestReloadKeepsSchedulesOnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fees.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(schedulesYAML), 0o600))
+	engine := fees.NewEngine()
+	require.NoError(t, engine.Reload(path))
+
+	require.NoError(t, os.WriteFile(path, []byte("schedules:\n  - percent: 1\n"), 0o600))
+	assert.Error(t, engine.Reload(path))
+	assert.Len(t, engine.Schedules(), 4)
+}
+
+func TestPaymentsChargeAndPostFees(t *testing.T) {
+	repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "fees.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	ledger, err := database.NewLedgerRepository(repo.DB())
+	require.NoError(t, err)
+	useCase := usecases.NewPaymentUseCase(repo,
+		usecases.WithProcessor(processor.NewSimulator(processor.SimulatorConfig{})),
+		usecases.WithFees(loadEngine(t, schedulesYAML)),
+		usecases.WithLedger(ledger))
+	ctx := context.Background()
+
+	payment, err := useCase.CreatePayment(ctx, usecases.CreatePaymentInput{Amount: 100, Currency: "EUR", Description: "Order"})
+	require.NoError(t, err)
+	require.Equal(t, domain.PaymentStatusAuthorized, payment.Status)
+	require.Len(t, payment.Fees, 1)
+	assert.Equal(t, domain.Fee{
+		Name:      "platform",
+		Event:     domain.FeeEventCreated,
+		Amount:    domain.Money{MinorUnits: 10, Currency: "EUR"},
+		Account:   "revenue:platform_fees",
+		ChargedAt: payment.Fees[0].ChargedAt,
+	}, payment.Fees[0])
+	amount := 120.0
+	_, err = useCase.UpdatePayment(ctx, usecases.UpdatePaymentInput{ID: payment.ID, Amount: &amount})
+	assert.Error(t, err, "charged fees depend on the amount")
+
+	payment, err = useCase.CapturePayment(ctx, payment.ID)
+	require.NoError(t, err)
+	require.Len(t, payment.Fees, 2)
+	assert.Equal(t, domain.FeeEventCaptured, payment.Fees[1].Event)
+	assert.Equal(t, domain.AccountFeeRevenue, payment.Fees[1].Account)
+	assert.Equal(t, domain.Money{MinorUnits: 165, Currency: "EUR"}, payment.Fees[1].Amount)
+	assert.Equal(t, domain.Money{MinorUnits: 9825, Currency: "EUR"}, payment.NetAmount())
+
+	stored, err := repo.GetByID(ctx, payment.ID)
+	require.NoError(t, err)
+	assert.Len(t, stored.Fees, 2)
+	assert.Equal(t, payment.NetAmount(), stored.NetAmount())
+
+	entries, err := ledger.EntriesForPayment(ctx, payment.ID)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	for _, entry := range entries {
+		require.NoError(t, entry.Validate())
+	}
+	platform, err := ledger.Balance(ctx, "revenue:platform_fees", "EUR")
+	require.NoError(t, err)
+	assert.InDelta(t, -0.10, platform, 1e-9)
+	revenue, err := ledger.Balance(ctx, domain.AccountFeeRevenue, "EUR")
+	require.NoError(t, err)
+	assert.InDelta(t, -1.65, revenue, 1e-9)
+	payable, err := ledger.Balance(ctx, domain.AccountMerchantPayable, "EUR")
+	require.NoError(t, err)
+	assert.InDelta(t, 1.75, payable, 1e-9)
+}
+
+func TestPaymentsMarkedCompletedAreChargedCaptureFees(t *testing.T) {
+	repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "fees.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	ledger, err := database.NewLedgerRepository(repo.DB())
+	require.NoError(t, err)
+	useCase := usecases.NewPaymentUseCase(repo,
+		usecases.WithFees(loadEngine(t, schedulesYAML)),
+		usecases.WithLedger(ledger))
+	ctx := context.Background()
+
+	payment, err := useCase.CreatePayment(ctx, usecases.CreatePaymentInput{Amount: 100, Currency: "USD", Description: "Bank transfer"})
+	require.NoError(t, err)
+	require.Equal(t, domain.PaymentStatusPending, payment.Status)
+	completed := domain.PaymentStatusCompleted
+	payment, err = useCase.UpdatePayment(ctx, usecases.UpdatePaymentInput{ID: payment.ID, Status: &completed})
+	require.NoError(t, err)
+	require.Len(t, payment.Fees, 1)
+	assert.Equal(t, domain.FeeEventCaptured, payment.Fees[0].Event)
+
+	revenue, err := ledger.Balance(ctx, domain.AccountFeeRevenue, "USD")
+	require.NoError(t, err)
+	assert.InDelta(t, -1.65, revenue, 1e-9)
+}
+
+func TestRejectedPaymentsAreNotCharged(t *testing.T) {
+	repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "fees.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	useCase := usecases.NewPaymentUseCase(repo,
+		usecases.WithFees(loadEngine(t, schedulesYAML)),
+		usecases.WithRiskScreener(denyAll{}))
+
+	payment, err := useCase.CreatePayment(context.Background(), usecases.CreatePaymentInput{Amount: 100, Currency: "EUR", Description: "Order"})
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusRejected, payment.Status)
+	assert.Empty(t, payment.Fees)
+	assert.Equal(t, domain.Money{MinorUnits: 10000, Currency: "EUR"}, payment.NetAmount())
+}
+
+type denyAll struct{}
+
+func (denyAll) Assess(ctx context.Context, payment *domain.Payment) (*domain.RiskAssessment, error) {
+	return &domain.RiskAssessment{Score: 100, Decision: domain.RiskDecisionDeny}, nil
+}
@@ -0,0 +1,203 @@
+package fx_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"payments_app/internal/domain"
+	"payments_app/internal/fx"
+	"payments_app/internal/infrastructure/database"
+	"payments_app/internal/usecases"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var asOf = time.Date(2026, 3, 2, 16, 0, 0, 0, time.UTC)
+
+func rate(base, quote, value string) domain.FXRate {
+	return domain.FXRate{Base: base, Quote: quote, Rate: value, AsOf: asOf, Source: "test"}
+}
+
+func TestConvertRoundsToQuoteMinorUnit(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount domain.Money
+		rate   domain.FXRate
+		want   domain.Money
+	}{
+		{"cents", domain.Money{MinorUnits: 10000, Currency: "EUR"}, rate("EUR", "USD", "1.0842"), domain.Money{MinorUnits: 10842, Currency: "USD"}},
+		{"half rounds up", domain.Money{MinorUnits: 5, Currency: "EUR"}, rate("EUR", "USD", "1.1"), domain.Money{MinorUnits: 6, Currency: "USD"}},
+		{"below half rounds down", domain.Money{MinorUnits: 4, Currency: "EUR"}, rate("EUR", "USD", "1.1"), domain.Money{MinorUnits: 4, Currency: "USD"}},
+		{"negative half rounds away from zero", domain.Money{MinorUnits: -5, Currency: "EUR"}, rate("EUR", "USD", "1.1"), domain.Money{MinorUnits: -6, Currency: "USD"}},
+		{"to whole yen", domain.Money{MinorUnits: 1999, Currency: "USD"}, rate("USD", "JPY", "149.735"), domain.Money{MinorUnits: 2993, Currency: "JPY"}},
+		{"from whole yen", domain.Money{MinorUnits: 10000, Currency: "JPY"}, rate("JPY", "EUR", "0.006163"), domain.Money{MinorUnits: 6163, Currency: "EUR"}},
+		{"to three decimals", domain.Money{MinorUnits: 100, Currency: "USD"}, rate("USD", "KWD", "0.30745"), domain.Money{MinorUnits: 307, Currency: "KWD"}},
+		{"exact beyond float precision", domain.Money{MinorUnits: 123456789012345, Currency: "EUR"}, rate("EUR", "USD", "1.000000000000001"), domain.Money{MinorUnits: 123456789012345, Currency: "USD"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fx.Convert(tt.amount, tt.rate)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestConvertRejectsInvalidInput(t *testing.T) {
+	_, err := fx.Convert(domain.Money{MinorUnits: 100, Currency: "GBP"}, rate("EUR", "USD", "1.08"))
+	assert.ErrorContains(t, err, "cannot convert GBP with a EUR/USD rate")
+
+	for _, value := range []string{"", "0", "-1.2", "1,08", "1.0.8", "1e3", "abc"} {
+		_, err := fx.Convert(domain.Money{MinorUnits: 100, Currency: "EUR"}, rate("EUR", "USD", value))
+		assert.Error(t, err, "rate %q", value)
+	}
+}
+
+func TestStaticProviderPicksLatestRateAndInverts(t *testing.T) {
+	earlier := rate("EUR", "USD", "1.05")
+	earlier.AsOf = asOf.Add(-24 * time.Hour)
+	provider := fx.NewStaticProvider(rate("EUR", "USD", "1.0842"), earlier, rate("USD", "JPY", "149.735"))
+	ctx := context.Background()
+
+	got, err := provider.Rate(ctx, "EUR", "USD", asOf.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "1.0842", got.Rate)
+
+	got, err = provider.Rate(ctx, "EUR", "USD", asOf.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "1.05", got.Rate, "rates published later are not used")
+
+	_, err = provider.Rate(ctx, "EUR", "USD", asOf.Add(-48*time.Hour))
+	assert.ErrorIs(t, err, domain.ErrRateNotFound)
+
+	got, err = provider.Rate(ctx, "USD", "EUR", asOf)
+	require.NoError(t, err)
+	assert.Equal(t, domain.FXRate{Base: "USD", Quote: "EUR", Rate: "0.9223390518", AsOf: asOf, Source: "test"}, got)
+
+	_, err = provider.Rate(ctx, "EUR", "JPY", asOf)
+	assert.ErrorIs(t, err, domain.ErrRateNotFound, "rates are not chained across pairs")
+}
+
+func TestLoadRates(t *testing.T) {
+	rates, err := fx.LoadRates(strings.NewReader("quote,base,as_of,rate,note\nusd,eur,2026-03-02T17:00:00+01:00,1.0842,ecb\nUSD,GBP,2026-03-02,1.2671,\n"), "rates.csv")
+	require.NoError(t, err)
+	assert.Equal(t, []domain.FXRate{
+		{Base: "EUR", Quote: "USD", Rate: "1.0842", AsOf: asOf, Source: "rates.csv"},
+		{Base: "GBP", Quote: "USD", Rate: "1.2671", AsOf: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), Source: "rates.csv"},
+	}, rates)
+
+	tests := map[string]string{
+		"":                  "rates file is empty",
+		"base,quote,rate\n": "rates file has no as_of column",
+		"base,quote,rate,as_of\nEU,USD,1,2026-03-02\n":       "line 2: invalid currency code \"EU\"",
+		"base,quote,rate,as_of\nEUR,EUR,1,2026-03-02\n":      "line 2: rate converts EUR to itself",
+		"base,quote,rate,as_of\nEUR,USD,0,2026-03-02\n":      "line 2: exchange rate \"0\" must be greater than 0",
+		"base,quote,rate,as_of\nEUR,USD,1.08,yesterday\n":    "line 2: as_of \"yesterday\" must be",
+		"base,quote,rate,as_of\nEUR,USD,1.08,2026-03-02,x\n": "wrong number of fields",
+	}
+	for content, message := range tests {
+		_, err := fx.LoadRates(strings.NewReader(content), "rates.csv")
+		assert.ErrorContains(t, err, message, "content %q", content)
+	}
+}
+
+func TestFileProviderReloadKeepsRatesOnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.csv")
+	require.NoError(t, os.WriteFile(path, []byte("base,quote,rate,as_of\nEUR,USD,1.0842,2026-03-02\n"), 0o600))
+
+	provider := fx.NewFileProvider()
+	require.NoError(t, provider.Reload(path))
+	got, err := provider.Rate(context.Background(), "EUR", "USD", asOf)
+	require.NoError(t, err)
+	assert.Equal(t, "1.0842", got.Rate)
+	assert.Equal(t, "rates.csv", got.Source)
+
+	require.NoError(t, os.WriteFile(path, []byte("base,quote,rate,as_of\nEUR,USD,oops,2026-03-02\n"), 0o600))
+	assert.ErrorContains(t, provider.Reload(path), "invalid rates file")
+	got, err = provider.Rate(context.Background(), "EUR", "USD", asOf)
+	require.NoError(t, err)
+	assert.Equal(t, "1.0842", got.Rate)
+}
+
+func setup(t *testing.T, opts ...usecases.Option) (*database.PaymentRepository, *usecases.PaymentUseCase) {
+	repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "fx.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	return repo, usecases.NewPaymentUseCase(repo, opts...)
+}
+
+func TestPaymentsRecordSettlementSnapshot(t *testing.T) {
+	provider := fx.NewStaticProvider(rate("EUR", "USD", "1.0842"), rate("USD", "JPY", "149.735"))
+	repo, useCase := setup(t, usecases.WithFX(provider, "USD"))
+	ctx := context.Background()
+
+	payment, err := useCase.CreatePayment(ctx, usecases.CreatePaymentInput{Amount: 19.99, Currency: "EUR", Description: "Order 1"})
+	require.NoError(t, err)
+	require.NotNil(t, payment.Settlement)
+	assert.Equal(t, domain.Money{MinorUnits: 2167, Currency: "USD"}, payment.Settlement.Amount)
+
+	stored, err := repo.GetByID(ctx, payment.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.Settlement)
+	assert.Equal(t, payment.Settlement.Amount, stored.Settlement.Amount)
+	assert.Equal(t, rate("EUR", "USD", "1.0842"), stored.Settlement.Rate)
+	assert.WithinDuration(t, payment.Settlement.ConvertedAt, stored.Settlement.ConvertedAt, time.Second)
+
+	// the stored snapshot reproduces the settlement amount
+	again, err := fx.Convert(domain.MoneyFromFloat(stored.Amount, stored.Currency), stored.Settlement.Rate)
+	require.NoError(t, err)
+	assert.Equal(t, stored.Settlement.Amount, again)
+
+	same, err := useCase.CreatePayment(ctx, usecases.CreatePaymentInput{Amount: 10, Currency: "USD", Description: "Order 2"})
+	require.NoError(t, err)
+	assert.Nil(t, same.Settlement, "payments in the settlement currency are not converted")
+
+	yen, err := useCase.CreatePayment(ctx, usecases.CreatePaymentInput{Amount: 10, Currency: "usd", Description: "Order 3", SettlementCurrency: "jpy"})
+	require.NoError(t, err)
+	require.NotNil(t, yen.Settlement)
+	assert.Equal(t, domain.Money{MinorUnits: 1497, Currency: "JPY"}, yen.Settlement.Amount)
+
+	_, err = useCase.CreatePayment(ctx, usecases.CreatePaymentInput{Amount: 10, Currency: "GBP", Description: "Order 4"})
+	var inputErr *usecases.InputError
+	require.True(t, errors.As(err, &inputErr))
+	assert.Equal(t, usecases.ErrorCodeInvalidSettlementCurrency, inputErr.Code)
+	assert.ErrorIs(t, err, domain.ErrRateNotFound)
+}
+
+func TestUpdatePaymentConvertsAgain(t *testing.T) {
+	provider := fx.NewStaticProvider(rate("EUR", "USD", "1.0842"))
+	_, useCase := setup(t, usecases.WithFX(provider, "USD"))
+	ctx := context.Background()
+
+	payment, err := useCase.CreatePayment(ctx, usecases.CreatePaymentInput{Amount: 100, Currency: "EUR", Description: "Order"})
+	require.NoError(t, err)
+
+	amount := 50.0
+	updated, err := useCase.UpdatePayment(ctx, usecases.UpdatePaymentInput{ID: payment.ID, Amount: &amount})
+	require.NoError(t, err)
+	require.NotNil(t, updated.Settlement)
+	assert.Equal(t, domain.Money{MinorUnits: 5421, Currency: "USD"}, updated.Settlement.Amount)
+
+	currency := "USD"
+	updated, err = useCase.UpdatePayment(ctx, usecases.UpdatePaymentInput{ID: payment.ID, Currency: &currency})
+	require.NoError(t, err)
+	assert.Nil(t, updated.Settlement)
+	stored, err := useCase.GetPayment(ctx, payment.ID)
+	require.NoError(t, err)
+	assert.Nil(t, stored.Settlement)
+}
+
+func TestSettlementCurrencyRequiresFX(t *testing.T) {
+	_, useCase := setup(t)
+	payment, err := useCase.CreatePayment(context.Background(), usecases.CreatePaymentInput{Amount: 10, Currency: "EUR", Description: "Order"})
+	require.NoError(t, err)
+	assert.Nil(t, payment.Settlement)
+
+	_, err = useCase.CreatePayment(context.Background(), usecases.CreatePaymentInput{Amount: 10, Currency: "EUR", Description: "Order", SettlementCurrency: "USD"})
+	assert.ErrorIs(t, err, usecases.ErrFXNotConfigured)
+}
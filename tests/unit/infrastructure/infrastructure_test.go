@@ -6,6 +6,7 @@ import (
 	"payments_app/internal/domain"
 	"payments_app/internal/infrastructure/database"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -147,3 +148,54 @@ func TestPaymentRepository_Delete_NotFound(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "payment not found")
 }
+
+func TestPaymentRepository_PayerPayments(t *testing.T) {
+	repo := setupTestDB(t)
+	defer cleanupTestDB(t, repo)
+
+	recent := domain.NewPayment(50, "USD", "Recent payment")
+	recent.PayerID = "payer-1"
+	recent.ApplyRiskAssessment(&domain.RiskAssessment{Score: 30, Decision: domain.RiskDecisionAllow, Reasons: []string{"keywords: test"}})
+	require.NoError(t, repo.Create(context.Background(), recent))
+
+	old := domain.NewPayment(75, "USD", "Old payment")
+	old.PayerID = "payer-1"
+	old.CreatedAt = time.Now().Add(-48 * time.Hour)
+	require.NoError(t, repo.Create(context.Background(), old))
+
+	other := domain.NewPayment(25, "USD", "Other payer")
+	other.PayerID = "payer-2"
+	require.NoError(t, repo.Create(context.Background(), other))
+
+	payments, err := repo.PayerPayments(context.Background(), "payer-1", time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, payments, 1)
+	assert.Equal(t, recent.ID, payments[0].ID)
+	require.NotNil(t, payments[0].Risk)
+	assert.Equal(t, 30, payments[0].Risk.Score)
+	assert.Equal(t, []string{"keywords: test"}, payments[0].Risk.Reasons)
+}
+
+func TestPaymentRepository_PartiesAndScreening(t *testing.T) {
+	repo := setupTestDB(t)
+	defer cleanupTestDB(t, repo)
+
+	payment := domain.NewPayment(100, "EUR", "Held payment")
+	payment.Payer = &domain.Party{Name: "Acme Corp", Account: "DE89370400440532013000", Country: "DE"}
+	payment.Payee = &domain.Party{Name: "Sidorov Ivan"}
+	payment.ApplyScreening(&domain.ScreeningResult{
+		Status: domain.ScreeningStatusHit,
+		Hits:   []domain.ScreeningHit{{Role: domain.PartyRolePayee, Name: "Sidorov Ivan", EntryID: "OFAC-1", Score: 0.97}},
+	})
+	require.NoError(t, repo.Create(context.Background(), payment))
+
+	retrieved, err := repo.GetByID(context.Background(), payment.ID)
+	require.NoError(t, err)
+
+	assert.Equal(t, domain.PaymentStatusScreeningHold, retrieved.Status)
+	assert.Equal(t, payment.Payer, retrieved.Payer)
+	assert.Equal(t, "Sidorov Ivan", retrieved.Payee.Name)
+	require.NotNil(t, retrieved.Screening)
+	assert.Equal(t, domain.ScreeningStatusHit, retrieved.Screening.Status)
+	assert.Equal(t, "OFAC-1", retrieved.Screening.Hits[0].EntryID)
+}
@@ -0,0 +1,466 @@
+package invoices_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"payments_app/graph/generated"
+	"payments_app/internal/domain"
+	"payments_app/internal/infrastructure/database"
+	"payments_app/internal/infrastructure/processor"
+	"payments_app/internal/interfaces/graphql"
+	"payments_app/internal/usecases"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fixture struct {
+	repo    *database.PaymentRepository
+	useCase *usecases.PaymentUseCase
+}
+
+func setup(t *testing.T) *fixture {
+	repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "invoices.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	customers, err := database.NewCustomerRepository(repo.DB())
+	require.NoError(t, err)
+	invoices, err := database.NewInvoiceRepository(repo.DB())
+	require.NoError(t, err)
+	disputes, err := database.NewDisputeRepository(repo.DB())
+	require.NoError(t, err)
+
+	useCase := usecases.NewPaymentUseCase(repo,
+		usecases.WithProcessor(processor.NewSimulator(processor.SimulatorConfig{})),
+		usecases.WithCustomers(customers),
+		usecases.WithInvoices(invoices),
+		usecases.WithDisputes(disputes, nil, 0))
+	return &fixture{repo: repo, useCase: useCase}
+}
+
+var dueDate = time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+
+// completed stores a completed payment
+func (f *fixture) completed(t *testing.T, amount float64, currency string) *domain.Payment {
+	payment := domain.NewPayment(amount, currency, "Invoice payment")
+	payment.Status = domain.PaymentStatusCompleted
+	require.NoError(t, f.repo.Create(context.Background(), payment))
+	return payment
+}
+
+// invoice creates an invoice of one line with the given unit price and tax
+func (f *fixture) invoice(t *testing.T, number, currency, unitPrice, tax string) *domain.Invoice {
+	invoice, err := f.useCase.CreateInvoice(context.Background(), usecases.InvoiceInput{
+		Number:   number,
+		Currency: currency,
+		DueDate:  dueDate,
+		Lines:    []usecases.InvoiceLineInput{{Description: "Consulting", UnitPrice: unitPrice, Tax: tax}},
+	})
+	require.NoError(t, err)
+	return invoice
+}
+
+func TestCreateInvoiceComputesAmountsExactly(t *testing.T) {
+	f := setup(t)
+	_, err := f.useCase.CreateInvoice(context.Background(), usecases.InvoiceInput{
+		Number:   "INV-001",
+		Currency: "EUR",
+		DueDate:  dueDate,
+		Lines:    []usecases.InvoiceLineInput{{Description: "Setup", UnitPrice: "0.333"}},
+	})
+	require.Error(t, err, "unit prices with more decimals than the currency are rejected")
+
+	invoice, err := f.useCase.CreateInvoice(context.Background(), usecases.InvoiceInput{
+		Number:   " INV-001 ",
+		Currency: "eur",
+		DueDate:  dueDate.Add(15 * time.Hour),
+		Lines: []usecases.InvoiceLineInput{
+			{Description: "Hosting", Quantity: "3", UnitPrice: "0.10", Tax: "0.06"},
+			{Description: "Support hours", Quantity: "2.50", UnitPrice: "33.33", Tax: "15.83"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "INV-001", invoice.Number)
+	assert.Equal(t, "EUR", invoice.Currency)
+	assert.Equal(t, dueDate, invoice.DueDate, "the due date is a day")
+	assert.Equal(t, "0.30", invoice.Lines[0].Amount.Decimal(), "no binary floating point error")
+	assert.Equal(t, "2.5", invoice.Lines[1].Quantity)
+	assert.Equal(t, "83.33", invoice.Lines[1].Amount.Decimal(), "83.325 rounds half away from zero")
+	assert.Equal(t, "83.63", invoice.Subtotal.Decimal())
+	assert.Equal(t, "15.89", invoice.Tax.Decimal())
+	assert.Equal(t, "99.52", invoice.Total.Decimal())
+	assert.Equal(t, "99.52", invoice.AmountDue().Decimal())
+
+	stored, err := f.useCase.GetInvoice(context.Background(), invoice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, invoice.Lines, stored.Lines)
+	assert.Equal(t, invoice.Total, stored.Total)
+	assert.Equal(t, dueDate, stored.DueDate)
+
+	_, err = f.useCase.CreateInvoice(context.Background(), usecases.InvoiceInput{
+		Number: "INV-001", Currency: "EUR", DueDate: dueDate,
+		Lines: []usecases.InvoiceLineInput{{Description: "Again", UnitPrice: "1"}},
+	})
+	assert.ErrorIs(t, err, domain.ErrInvoiceNumberTaken)
+}
+
+func TestCreateInvoiceValidation(t *testing.T) {
+	f := setup(t)
+	valid := func() usecases.InvoiceInput {
+		return usecases.InvoiceInput{
+			Number: "INV-1", Currency: "EUR", DueDate: dueDate,
+			Lines: []usecases.InvoiceLineInput{{Description: "Item", UnitPrice: "10"}},
+		}
+	}
+
+	cases := map[string]func(*usecases.InvoiceInput){
+		"number":        func(in *usecases.InvoiceInput) { in.Number = " " },
+		"currency":      func(in *usecases.InvoiceInput) { in.Currency = "EURO" },
+		"due date":      func(in *usecases.InvoiceInput) { in.DueDate = time.Time{} },
+		"no lines":      func(in *usecases.InvoiceInput) { in.Lines = nil },
+		"description":   func(in *usecases.InvoiceInput) { in.Lines[0].Description = "" },
+		"zero quantity": func(in *usecases.InvoiceInput) { in.Lines[0].Quantity = "0" },
+		"fraction":      func(in *usecases.InvoiceInput) { in.Lines[0].Quantity = "1/3" },
+		"exponent":      func(in *usecases.InvoiceInput) { in.Lines[0].Quantity = "1e3" },
+		"negative tax":  func(in *usecases.InvoiceInput) { in.Lines[0].Tax = "-1" },
+		"zero total":    func(in *usecases.InvoiceInput) { in.Lines[0].UnitPrice = "0" },
+		"customer":      func(in *usecases.InvoiceInput) { in.CustomerID = "missing" },
+	}
+	for name, change := range cases {
+		input := valid()
+		change(&input)
+		_, err := f.useCase.CreateInvoice(context.Background(), input)
+		assert.Error(t, err, name)
+	}
+	_, err := f.useCase.CreateInvoice(context.Background(), valid())
+	assert.NoError(t, err)
+
+	_, err = usecases.NewPaymentUseCase(f.repo).CreateInvoice(context.Background(), valid())
+	assert.ErrorIs(t, err, usecases.ErrInvoicesNotConfigured)
+}
+
+func TestInvoiceStatusIsDerived(t *testing.T) {
+	invoice := &domain.Invoice{
+		Currency:   "EUR",
+		Total:      domain.Money{MinorUnits: 10000, Currency: "EUR"},
+		AmountPaid: domain.Money{Currency: "EUR"},
+		DueDate:    dueDate,
+	}
+	onDueDate := dueDate.Add(23 * time.Hour)
+	dayAfter := dueDate.Add(24 * time.Hour)
+
+	assert.Equal(t, domain.InvoiceStatusOpen, invoice.Status(onDueDate))
+	assert.Equal(t, domain.InvoiceStatusOverdue, invoice.Status(dayAfter))
+	assert.Equal(t, 1, invoice.DaysPastDue(dayAfter))
+
+	invoice.AmountPaid.MinorUnits = 2500
+	assert.Equal(t, domain.InvoiceStatusPartiallyPaid, invoice.Status(onDueDate))
+	assert.Equal(t, domain.InvoiceStatusOverdue, invoice.Status(dayAfter))
+
+	invoice.AmountPaid.MinorUnits = 10000
+	assert.Equal(t, domain.InvoiceStatusPaid, invoice.Status(dayAfter))
+}
+
+func TestApplyPaymentAcrossInvoices(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	first := f.invoice(t, "INV-1", "EUR", "60.00", "")
+	second := f.invoice(t, "INV-2", "EUR", "100.00", "")
+	payment := f.completed(t, 100, "EUR")
+
+	allocations, err := f.useCase.ApplyPayment(ctx, payment.ID, []usecases.InvoiceAllocationInput{
+		{InvoiceID: first.ID, Amount: "60"},
+		{InvoiceID: second.ID, Amount: "40"},
+	})
+	require.NoError(t, err)
+	require.Len(t, allocations, 2)
+
+	now := dueDate
+	first, err = f.useCase.GetInvoice(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.InvoiceStatusPaid, first.Status(now))
+	second, err = f.useCase.GetInvoice(ctx, second.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.InvoiceStatusPartiallyPaid, second.Status(now))
+	assert.Equal(t, "60.00", second.AmountDue().Decimal())
+	require.Len(t, second.Allocations, 1)
+	assert.Equal(t, payment.ID, second.Allocations[0].PaymentID)
+
+	_, err = f.useCase.ApplyPayment(ctx, payment.ID, []usecases.InvoiceAllocationInput{{InvoiceID: second.ID, Amount: "0.01"}})
+	assert.ErrorIs(t, err, domain.ErrPaymentOverallocated, "the whole payment is applied")
+
+	other := f.completed(t, 500, "EUR")
+	_, err = f.useCase.ApplyPayment(ctx, other.ID, []usecases.InvoiceAllocationInput{
+		{InvoiceID: second.ID, Amount: "50"},
+		{InvoiceID: second.ID, Amount: "10.01"},
+	})
+	assert.ErrorIs(t, err, domain.ErrInvoiceOverallocated)
+	second, err = f.useCase.GetInvoice(ctx, second.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "60.00", second.AmountDue().Decimal(), "failed allocations are not stored in part")
+
+	removed, err := f.useCase.RemoveInvoiceAllocation(ctx, allocations[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, removed.InvoiceID)
+	first, err = f.useCase.GetInvoice(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.InvoiceStatusOpen, first.Status(now))
+	assert.Empty(t, first.Allocations)
+
+	remaining, err := f.useCase.PaymentInvoiceAllocations(ctx, payment.ID)
+	require.NoError(t, err)
+	require.Len(t, remaining, 1)
+	assert.Equal(t, second.ID, remaining[0].InvoiceID)
+
+	_, err = f.useCase.RemoveInvoiceAllocation(ctx, allocations[0].ID)
+	assert.ErrorIs(t, err, domain.ErrInvoiceAllocationNotFound)
+}
+
+func TestApplyPaymentChecksThePayment(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	invoice := f.invoice(t, "INV-1", "EUR", "100", "")
+
+	pending := domain.NewPayment(50, "EUR", "Pending")
+	require.NoError(t, f.repo.Create(ctx, pending))
+	_, err := f.useCase.ApplyPayment(ctx, pending.ID, []usecases.InvoiceAllocationInput{{InvoiceID: invoice.ID, Amount: "50"}})
+	assert.Error(t, err, "only completed payments are applied")
+
+	dollars := f.completed(t, 50, "USD")
+	_, err = f.useCase.ApplyPayment(ctx, dollars.ID, []usecases.InvoiceAllocationInput{{InvoiceID: invoice.ID, Amount: "50"}})
+	assert.Error(t, err, "currencies must match")
+
+	refunded := f.completed(t, 50, "EUR")
+	refunded.RefundedAmount = 20
+	require.NoError(t, f.repo.Update(ctx, refunded))
+	_, err = f.useCase.ApplyPayment(ctx, refunded.ID, []usecases.InvoiceAllocationInput{{InvoiceID: invoice.ID, Amount: "30.01"}})
+	assert.ErrorIs(t, err, domain.ErrPaymentOverallocated, "refunds are not available")
+	_, err = f.useCase.ApplyPayment(ctx, refunded.ID, []usecases.InvoiceAllocationInput{{InvoiceID: invoice.ID, Amount: "30"}})
+	assert.NoError(t, err)
+
+	_, err = f.useCase.ApplyPayment(ctx, refunded.ID, []usecases.InvoiceAllocationInput{{InvoiceID: "missing", Amount: "1"}})
+	assert.ErrorIs(t, err, domain.ErrInvoiceNotFound)
+}
+
+func TestRefundsAndLostDisputesReleaseAllocations(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	first := f.invoice(t, "INV-1", "EUR", "60", "")
+	second := f.invoice(t, "INV-2", "EUR", "40", "")
+	payment, err := f.useCase.CreatePayment(ctx, usecases.CreatePaymentInput{Amount: 100, Currency: "EUR", Description: "Invoice payment"})
+	require.NoError(t, err)
+	_, err = f.useCase.CapturePayment(ctx, payment.ID)
+	require.NoError(t, err)
+	_, err = f.useCase.ApplyPayment(ctx, payment.ID, []usecases.InvoiceAllocationInput{{InvoiceID: first.ID, Amount: "60"}})
+	require.NoError(t, err)
+	_, err = f.useCase.ApplyPayment(ctx, payment.ID, []usecases.InvoiceAllocationInput{{InvoiceID: second.ID, Amount: "40"}})
+	require.NoError(t, err)
+
+	amountsDue := func() []string {
+		first, err := f.useCase.GetInvoice(ctx, first.ID)
+		require.NoError(t, err)
+		second, err := f.useCase.GetInvoice(ctx, second.ID)
+		require.NoError(t, err)
+		return []string{first.AmountDue().Decimal(), second.AmountDue().Decimal()}
+	}
+	assert.Equal(t, []string{"0.00", "0.00"}, amountsDue())
+
+	refund := 30.0
+	_, err = f.useCase.RefundPayment(ctx, payment.ID, &refund)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0.00", "30.00"}, amountsDue(), "the newest allocation is released first")
+
+	chargeback := 20.0
+	dispute, err := f.useCase.OpenDispute(ctx, usecases.OpenDisputeInput{PaymentID: payment.ID, ReasonCode: "10.4", Amount: &chargeback})
+	require.NoError(t, err)
+	_, err = f.useCase.ResolveDispute(ctx, usecases.ResolveDisputeInput{DisputeID: dispute.ID, Won: false})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.00", "40.00"}, amountsDue())
+
+	allocations, err := f.useCase.PaymentInvoiceAllocations(ctx, payment.ID)
+	require.NoError(t, err)
+	require.Len(t, allocations, 1)
+	assert.Equal(t, "50.00", allocations[0].Amount.Decimal())
+	_, err = f.useCase.ApplyPayment(ctx, payment.ID, []usecases.InvoiceAllocationInput{{InvoiceID: second.ID, Amount: "0.01"}})
+	assert.ErrorIs(t, err, domain.ErrPaymentOverallocated, "lost disputes are not available")
+
+	_, err = f.useCase.RefundPayment(ctx, payment.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"60.00", "40.00"}, amountsDue())
+}
+
+func TestConcurrentAllocationsDoNotOverpay(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	invoice := f.invoice(t, "INV-1", "EUR", "100", "")
+
+	var wg sync.WaitGroup
+	errs := make([]error, 5)
+	for i := range errs {
+		payment := f.completed(t, 40, "EUR")
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = f.useCase.ApplyPayment(ctx, payment.ID, []usecases.InvoiceAllocationInput{{InvoiceID: invoice.ID, Amount: "40"}})
+		}()
+	}
+	wg.Wait()
+
+	applied := 0
+	for _, err := range errs {
+		if err == nil {
+			applied++
+		}
+	}
+	assert.Equal(t, 2, applied)
+	invoice, err := f.useCase.GetInvoice(ctx, invoice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "80.00", invoice.AmountPaid.Decimal())
+	assert.Len(t, invoice.Allocations, 2)
+}
+
+func TestInvoiceAging(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	asOf := dueDate
+	create := func(number, currency string, due time.Time, price string) *domain.Invoice {
+		invoice, err := f.useCase.CreateInvoice(ctx, usecases.InvoiceInput{
+			Number: number, Currency: currency, DueDate: due,
+			Lines: []usecases.InvoiceLineInput{{Description: "Item", UnitPrice: price}},
+		})
+		require.NoError(t, err)
+		return invoice
+	}
+	daysAgo := func(days int) time.Time { return asOf.AddDate(0, 0, -days) }
+
+	create("current", "EUR", asOf, "10")
+	create("1", "EUR", daysAgo(1), "20")
+	create("30", "EUR", daysAgo(30), "30")
+	create("45", "EUR", daysAgo(45), "40")
+	create("90", "EUR", daysAgo(90), "50")
+	partial := create("120", "EUR", daysAgo(120), "60")
+	paid := create("paid", "EUR", daysAgo(120), "70")
+	create("usd", "USD", daysAgo(10), "5")
+
+	payment := f.completed(t, 100, "EUR")
+	_, err := f.useCase.ApplyPayment(ctx, payment.ID, []usecases.InvoiceAllocationInput{
+		{InvoiceID: partial.ID, Amount: "15"},
+		{InvoiceID: paid.ID, Amount: "70"},
+	})
+	require.NoError(t, err)
+
+	reports, err := f.useCase.InvoiceAging(ctx, asOf, "")
+	require.NoError(t, err)
+	require.Len(t, reports, 2)
+	eur := reports[0]
+	assert.Equal(t, "EUR", eur.Currency)
+	amounts := make(map[string]string)
+	counts := make(map[string]int)
+	for _, bucket := range eur.Buckets {
+		amounts[bucket.Label] = bucket.Amount.Decimal()
+		counts[bucket.Label] = bucket.Count
+	}
+	assert.Equal(t, map[string]string{"current": "10.00", "1-30": "50.00", "31-60": "40.00", "61-90": "50.00", "90+": "45.00"}, amounts)
+	assert.Equal(t, map[string]int{"current": 1, "1-30": 2, "31-60": 1, "61-90": 1, "90+": 1}, counts)
+	assert.Equal(t, "195.00", eur.Total.Decimal())
+	assert.Equal(t, 6, eur.Count)
+	assert.Equal(t, "USD", reports[1].Currency)
+	assert.Equal(t, "5.00", reports[1].Total.Decimal())
+
+	overdue, err := f.useCase.ListInvoices(ctx, domain.InvoiceFilter{Currency: "EUR"}, []domain.InvoiceStatus{domain.InvoiceStatusOverdue}, asOf)
+	require.NoError(t, err)
+	assert.Len(t, overdue, 5)
+}
+
+func TestGraphQLInvoices(t *testing.T) {
+	f := setup(t)
+	payment := f.completed(t, 150, "EUR")
+	srv := handler.NewDefaultServer(generated.NewExecutableSchema(generated.Config{Resolvers: graphql.NewResolver(f.useCase)}))
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	execute := func(query string, variables map[string]any, target any) {
+		body, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+		require.NoError(t, err)
+		resp, err := http.Post(ts.URL, "application/json", bytes.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var result struct {
+			Data   json.RawMessage
+			Errors []map[string]any
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		require.Empty(t, result.Errors)
+		require.NoError(t, json.Unmarshal(result.Data, target))
+	}
+
+	type money struct{ Amount, Currency string }
+	var created struct {
+		CreateInvoice struct {
+			ID        string
+			Total     money
+			Status    string
+			DueDate   string
+			AmountDue money
+		}
+	}
+	execute(`mutation { createInvoice(input: {number: "INV-9", currency: "EUR", dueDate: "2020-01-15", lines: [{description: "Design", quantity: "2", unitPrice: "100", tax: "38"}]}) { id total { amount currency } status dueDate amountDue { amount } } }`, nil, &created)
+	invoice := created.CreateInvoice
+	assert.Equal(t, money{"238.00", "EUR"}, invoice.Total)
+	assert.Equal(t, "OVERDUE", invoice.Status)
+	assert.Equal(t, "2020-01-15", invoice.DueDate)
+
+	var applied struct {
+		ApplyPayment []struct {
+			InvoiceID string
+			Amount    money
+		}
+	}
+	execute(`mutation($paymentId: ID!, $invoiceId: ID!) { applyPayment(paymentId: $paymentId, allocations: [{invoiceId: $invoiceId, amount: "150"}]) { invoiceId amount { amount currency } } }`,
+		map[string]any{"paymentId": payment.ID, "invoiceId": invoice.ID}, &applied)
+	require.Len(t, applied.ApplyPayment, 1)
+	assert.Equal(t, money{"150.00", "EUR"}, applied.ApplyPayment[0].Amount)
+
+	var aging struct {
+		InvoiceAging []struct {
+			Currency string
+			Total    money
+			Buckets  []struct {
+				Label  string
+				ToDays *int
+				Amount money
+				Count  int
+			}
+		}
+	}
+	execute(`{ invoiceAging(asOf: "2020-02-20") { currency total { amount } buckets { label toDays amount { amount } count } } }`, nil, &aging)
+	require.Len(t, aging.InvoiceAging, 1)
+	report := aging.InvoiceAging[0]
+	assert.Equal(t, "88.00", report.Total.Amount)
+	require.Len(t, report.Buckets, 5)
+	assert.Equal(t, "31-60", report.Buckets[2].Label)
+	assert.Equal(t, "88.00", report.Buckets[2].Amount.Amount)
+	assert.Nil(t, report.Buckets[4].ToDays)
+
+	var listed struct {
+		Invoices []struct {
+			Number      string
+			Status      string
+			Allocations []struct{ PaymentID string }
+		}
+	}
+	execute(`{ invoices(status: OVERDUE) { number status allocations { paymentId } } }`, nil, &listed)
+	require.Len(t, listed.Invoices, 1)
+	assert.Equal(t, "INV-9", listed.Invoices[0].Number)
+	require.Len(t, listed.Invoices[0].Allocations, 1)
+	assert.Equal(t, payment.ID, listed.Invoices[0].Allocations[0].PaymentID)
+}
@@ -0,0 +1,206 @@
+package metadata_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"payments_app/graph/generated"
+	"payments_app/internal/domain"
+	"payments_app/internal/infrastructure/database"
+	"payments_app/internal/interfaces/export"
+	"payments_app/internal/interfaces/graphql"
+	"payments_app/internal/usecases"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setup(t *testing.T) (*database.PaymentRepository, *usecases.PaymentUseCase) {
+	repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "metadata.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	return repo, usecases.NewPaymentUseCase(repo)
+}
+
+func create(t *testing.T, useCase *usecases.PaymentUseCase, metadata map[string]string, tags ...string) *domain.Payment {
+	payment, err := useCase.CreatePayment(context.Background(), usecases.CreatePaymentInput{
+		Amount: 10, Currency: "EUR", Description: "Order", Metadata: metadata, Tags: tags,
+	})
+	require.NoError(t, err)
+	return payment
+}
+
+func ids(payments []*domain.Payment) []string {
+	result := make([]string, len(payments))
+	for i, payment := range payments {
+		result[i] = payment.ID
+	}
+	return result
+}
+
+func TestMetadataAndTagsAreStored(t *testing.T) {
+	_, useCase := setup(t)
+	ctx := context.Background()
+
+	payment := create(t, useCase, map[string]string{" orderId ": "123", "costCenter": "marketing"}, "summer", " vip ", "summer")
+	assert.Equal(t, []string{"summer", "vip"}, payment.Tags)
+
+	stored, err := useCase.GetPayment(ctx, payment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"orderId": "123", "costCenter": "marketing"}, stored.Metadata)
+	assert.Equal(t, []string{"summer", "vip"}, stored.Tags)
+
+	updated, err := useCase.UpdatePayment(ctx, usecases.UpdatePaymentInput{ID: payment.ID, Tags: []string{"autumn"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"autumn"}, updated.Tags)
+	assert.Equal(t, "123", updated.Metadata["orderId"], "metadata is kept when not given")
+}
+
+func TestMetadataLimits(t *testing.T) {
+	_, useCase := setup(t)
+
+	tooMany := make(map[string]string)
+	for i := 0; i <= usecases.MaxMetadataKeys; i++ {
+		tooMany[fmt.Sprintf("key%d", i)] = "x"
+	}
+	manyTags := make([]string, usecases.MaxTags+1)
+	for i := range manyTags {
+		manyTags[i] = fmt.Sprintf("tag%d", i)
+	}
+
+	cases := map[string]usecases.CreatePaymentInput{
+		"too many keys":  {Metadata: tooMany},
+		"invalid key":    {Metadata: map[string]string{"order id": "1"}},
+		"quote in key":   {Metadata: map[string]string{`a"b`: "1"}},
+		"long value":     {Metadata: map[string]string{"note": strings.Repeat("x", 501)}},
+		"too many tags":  {Tags: manyTags},
+		"empty tag":      {Tags: []string{" "}},
+		"long tag":       {Tags: []string{strings.Repeat("x", 51)}},
+		"key too long":   {Metadata: map[string]string{strings.Repeat("k", 41): "1"}},
+		"empty key name": {Metadata: map[string]string{"": "1"}},
+	}
+	for name, input := range cases {
+		t.Run(name, func(t *testing.T) {
+			input.Amount, input.Currency, input.Description = 10, "EUR", "Order"
+			_, err := useCase.CreatePayment(context.Background(), input)
+			var inputErr *usecases.InputError
+			require.True(t, errors.As(err, &inputErr), "got %v", err)
+			assert.Equal(t, usecases.ErrorCodeInvalidMetadata, inputErr.Code)
+		})
+	}
+}
+
+func TestFilterByMetadataAndTags(t *testing.T) {
+	_, useCase := setup(t)
+	ctx := context.Background()
+
+	first := create(t, useCase, map[string]string{"orderId": "123", "campaign": "spring"}, "vip")
+	second := create(t, useCase, map[string]string{"orderId": "123"}, "vip", "summer")
+	create(t, useCase, map[string]string{"orderId": "456"})
+	create(t, useCase, nil)
+
+	payments, err := useCase.ListPayments(ctx, domain.PaymentFilter{Metadata: map[string]string{"orderId": "123"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{first.ID, second.ID}, ids(payments))
+
+	payments, err = useCase.ListPayments(ctx, domain.PaymentFilter{Metadata: map[string]string{"orderId": "123", "campaign": "spring"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{first.ID}, ids(payments))
+
+	payments, err = useCase.ListPayments(ctx, domain.PaymentFilter{Tags: []string{"vip", "summer"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{second.ID}, ids(payments))
+
+	for _, payment := range payments {
+		assert.True(t, domain.PaymentFilter{Tags: []string{"vip", "summer"}}.Matches(payment))
+	}
+
+	_, err = useCase.ListPayments(ctx, domain.PaymentFilter{Metadata: map[string]string{"bad key": "1"}})
+	var inputErr *usecases.InputError
+	require.True(t, errors.As(err, &inputErr))
+	assert.Equal(t, usecases.ErrorCodeInvalidFilter, inputErr.Code)
+}
+
+func TestIndexMetadataKeys(t *testing.T) {
+	repo, useCase := setup(t)
+	ctx := context.Background()
+	create(t, useCase, map[string]string{"orderId": "123"})
+
+	indexes := func() []string {
+		var names []string
+		require.NoError(t, repo.DB().Raw("SELECT name FROM sqlite_master WHERE type = 'index' AND name LIKE 'idx_payments_metadata_%' ORDER BY name").Scan(&names).Error)
+		return names
+	}
+
+	require.NoError(t, repo.IndexMetadataKeys(ctx, []string{"orderId", "costCenter"}))
+	assert.Equal(t, []string{"idx_payments_metadata_costCenter", "idx_payments_metadata_orderId"}, indexes())
+
+	var plan []struct{ Detail string }
+	require.NoError(t, repo.DB().Raw(`EXPLAIN QUERY PLAN SELECT id FROM payments WHERE json_extract(metadata, '$."orderId"') = ?`, "123").Scan(&plan).Error)
+	require.NotEmpty(t, plan)
+	assert.Contains(t, plan[0].Detail, "idx_payments_metadata_orderId")
+
+	payments, err := useCase.ListPayments(ctx, domain.PaymentFilter{Metadata: map[string]string{"orderId": "123"}})
+	require.NoError(t, err)
+	assert.Len(t, payments, 1)
+
+	require.NoError(t, repo.IndexMetadataKeys(ctx, []string{"orderId"}))
+	assert.Equal(t, []string{"idx_payments_metadata_orderId"}, indexes(), "undeclared keys lose their index")
+
+	assert.Error(t, repo.IndexMetadataKeys(ctx, []string{"order id"}))
+}
+
+func TestGraphQLMetadataFilter(t *testing.T) {
+	_, useCase := setup(t)
+	match := create(t, useCase, map[string]string{"orderId": "123"}, "vip")
+	create(t, useCase, map[string]string{"orderId": "456"})
+
+	srv := handler.NewDefaultServer(generated.NewExecutableSchema(generated.Config{Resolvers: graphql.NewResolver(useCase)}))
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	body, err := json.Marshal(map[string]string{
+		"query": `{ payments(filter: { metadata: { orderId: "123" }, tags: ["vip"] }) { id metadata tags } }`,
+	})
+	require.NoError(t, err)
+	resp, err := http.Post(ts.URL, "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var result struct {
+		Data struct {
+			Payments []struct {
+				ID       string
+				Metadata map[string]string
+				Tags     []string
+			}
+		}
+		Errors []map[string]any
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	require.Empty(t, result.Errors)
+	require.Len(t, result.Data.Payments, 1)
+	assert.Equal(t, match.ID, result.Data.Payments[0].ID)
+	assert.Equal(t, map[string]string{"orderId": "123"}, result.Data.Payments[0].Metadata)
+	assert.Equal(t, []string{"vip"}, result.Data.Payments[0].Tags)
+}
+
+func TestExportFilterParameters(t *testing.T) {
+	values, err := url.ParseQuery("metadata.orderId=123&metadata.campaign=spring&tag=vip&tag=summer&customerId=c1")
+	require.NoError(t, err)
+
+	filter, err := export.ParseFilter(values)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"orderId": "123", "campaign": "spring"}, filter.Metadata)
+	assert.Equal(t, []string{"vip", "summer"}, filter.Tags)
+	assert.Equal(t, "c1", filter.CustomerID)
+}
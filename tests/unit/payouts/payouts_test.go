@@ -0,0 +1,314 @@
+package payouts_test
+
+import (
+	"context"
+	"path/filepath"
+	"payments_app/internal/domain"
+	"payments_app/internal/infrastructure/database"
+	"payments_app/internal/usecases"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fixture struct {
+	repo    *database.PaymentRepository
+	ledger  *database.LedgerRepository
+	useCase *usecases.PaymentUseCase
+}
+
+func setup(t *testing.T) *fixture {
+	repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "payouts.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	settlements, err := database.NewSettlementRepository(repo.DB())
+	require.NoError(t, err)
+	ledger, err := database.NewLedgerRepository(repo.DB())
+	require.NoError(t, err)
+
+	useCase := usecases.NewPaymentUseCase(repo, usecases.WithPayouts(settlements), usecases.WithLedger(ledger))
+	return &fixture{repo: repo, ledger: ledger, useCase: useCase}
+}
+
+var day = time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+
+// completed stores a completed payment of a merchant created at the given time
+func (f *fixture) completed(t *testing.T, merchant string, amount float64, currency string, createdAt time.Time, fees ...int64) *domain.Payment {
+	payment := domain.NewPayment(amount, currency, "Order")
+	payment.TenantID = merchant
+	payment.Status = domain.PaymentStatusCompleted
+	payment.CreatedAt = createdAt
+	for _, fee := range fees {
+		payment.Fees = append(payment.Fees, domain.Fee{
+			Name:    "processing",
+			Event:   domain.FeeEventCreated,
+			Amount:  domain.Money{MinorUnits: fee, Currency: currency},
+			Account: domain.AccountFeeRevenue,
+		})
+	}
+	require.NoError(t, f.repo.Create(context.Background(), payment))
+	return payment
+}
+
+func (f *fixture) refund(t *testing.T, payment *domain.Payment, amount float64) {
+	payment.RefundedAmount += amount
+	if payment.RefundedAmount >= payment.Amount {
+		payment.Status = domain.PaymentStatusRefunded
+	}
+	require.NoError(t, f.repo.Update(context.Background(), payment))
+}
+
+// lose stores a lost dispute of the amount against the payment
+func (f *fixture) lose(t *testing.T, payment *domain.Payment, amount float64) {
+	disputes, err := database.NewDisputeRepository(f.repo.DB())
+	require.NoError(t, err)
+	dispute, err := domain.NewDispute(payment, 0, "4837", amount, day)
+	require.NoError(t, err)
+	dispute.Status = domain.DisputeStatusLost
+	require.NoError(t, disputes.Create(context.Background(), dispute))
+}
+
+func paymentIDs(payout *domain.Payout) []string {
+	ids := make([]string, len(payout.Items))
+	for i, item := range payout.Items {
+		ids[i] = item.PaymentID
+	}
+	return ids
+}
+
+func TestSettlementNetsPaymentsPerMerchantAndCurrency(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+
+	a1 := f.completed(t, "merchant-a", 100, "EUR", day.Add(-2*time.Hour), 290)
+	a2 := f.completed(t, "merchant-a", 50, "EUR", day.Add(-time.Hour), 175)
+	f.refund(t, a2, 20)
+	b1 := f.completed(t, "merchant-b", 80, "USD", day.Add(-time.Hour))
+	aUSD := f.completed(t, "merchant-a", 10, "USD", day.Add(-time.Hour))
+	f.completed(t, "merchant-a", 999, "EUR", day.Add(10*time.Hour)) // after the cutoff
+	f.completed(t, "", 25, "EUR", day.Add(-time.Hour))              // no merchant
+
+	batch, err := f.useCase.RunSettlement(ctx, day.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "SB20260311", batch.ID)
+	require.Len(t, batch.Payouts, 3)
+
+	eur := batch.Payouts[0]
+	assert.Equal(t, "merchant-a", eur.MerchantID)
+	assert.Equal(t, "EUR", eur.Currency)
+	assert.Equal(t, "150.00 EUR", eur.Gross.String())
+	assert.Equal(t, "20.00 EUR", eur.Refunds.String())
+	assert.Equal(t, "4.65 EUR", eur.Fees.String())
+	assert.Equal(t, "125.35 EUR", eur.Net.String())
+	assert.Equal(t, domain.PayoutStatusCreated, eur.Status)
+	assert.ElementsMatch(t, []string{a1.ID, a2.ID}, paymentIDs(eur))
+
+	assert.Equal(t, "merchant-a", batch.Payouts[1].MerchantID)
+	assert.Equal(t, []string{aUSD.ID}, paymentIDs(batch.Payouts[1]))
+	assert.Equal(t, "merchant-b", batch.Payouts[2].MerchantID)
+	assert.Equal(t, []string{b1.ID}, paymentIDs(batch.Payouts[2]))
+
+	report, err := f.useCase.GetSettlementBatch(ctx, batch.ID)
+	require.NoError(t, err)
+	require.Len(t, report.Payouts, 3)
+	assert.Equal(t, "125.35 EUR", report.Payouts[0].Net.String())
+	assert.ElementsMatch(t, []string{a1.ID, a2.ID}, paymentIDs(report.Payouts[0]))
+}
+
+func TestSettlementRunsOncePerDay(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	f.completed(t, "merchant-a", 100, "EUR", day.Add(-time.Hour))
+
+	_, err := f.useCase.RunSettlement(ctx, day.Add(24*time.Hour))
+	require.NoError(t, err)
+	_, err = f.useCase.RunSettlement(ctx, day.Add(25*time.Hour))
+	assert.ErrorIs(t, err, domain.ErrSettlementBatchExists)
+
+	// Payments are never paid out twice
+	next, err := f.useCase.RunSettlement(ctx, day.Add(48*time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, next.Payouts)
+}
+
+func TestLaterRefundsAreDeductedFromTheNextPayout(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+
+	old := f.completed(t, "merchant-a", 100, "EUR", day.Add(-time.Hour))
+	_, err := f.useCase.RunSettlement(ctx, day.Add(24*time.Hour))
+	require.NoError(t, err)
+
+	f.refund(t, old, 30)
+	fresh := f.completed(t, "merchant-a", 50, "EUR", day.Add(time.Hour))
+	batch, err := f.useCase.RunSettlement(ctx, day.Add(48*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, batch.Payouts, 1)
+	payout := batch.Payouts[0]
+	assert.Equal(t, "50.00 EUR", payout.Gross.String())
+	assert.Equal(t, "30.00 EUR", payout.Refunds.String())
+	assert.Equal(t, "20.00 EUR", payout.Net.String())
+	assert.ElementsMatch(t, []string{old.ID, fresh.ID}, paymentIDs(payout))
+}
+
+func TestLostDisputesAreDeductedOnce(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+
+	disputed := f.completed(t, "merchant-a", 100, "EUR", day.Add(-2*time.Hour))
+	f.lose(t, disputed, 25)
+	old := f.completed(t, "merchant-a", 100, "EUR", day.Add(-time.Hour))
+	batch, err := f.useCase.RunSettlement(ctx, day.Add(24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, batch.Payouts, 1)
+	assert.Equal(t, "200.00 EUR", batch.Payouts[0].Gross.String())
+	assert.Equal(t, "25.00 EUR", batch.Payouts[0].Chargebacks.String())
+	assert.Equal(t, "175.00 EUR", batch.Payouts[0].Net.String())
+
+	// A chargeback lost after the payment was paid out comes off the next payout
+	f.lose(t, old, 40)
+	fresh := f.completed(t, "merchant-a", 50, "EUR", day.Add(time.Hour))
+	batch, err = f.useCase.RunSettlement(ctx, day.Add(48*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, batch.Payouts, 1)
+	payout := batch.Payouts[0]
+	assert.Equal(t, "50.00 EUR", payout.Gross.String())
+	assert.Equal(t, "40.00 EUR", payout.Chargebacks.String())
+	assert.Equal(t, "10.00 EUR", payout.Net.String())
+	assert.ElementsMatch(t, []string{old.ID, fresh.ID}, paymentIDs(payout))
+
+	stored, err := f.useCase.GetPayout(ctx, payout.ID)
+	require.NoError(t, err)
+	assert.Equal(t, payout.Chargebacks, stored.Chargebacks)
+
+	batch, err = f.useCase.RunSettlement(ctx, day.Add(72*time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, batch.Payouts, "a lost dispute is deducted once")
+}
+
+func TestNegativeNetIsCarriedForward(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+
+	old := f.completed(t, "merchant-a", 100, "EUR", day.Add(-time.Hour))
+	_, err := f.useCase.RunSettlement(ctx, day.Add(24*time.Hour))
+	require.NoError(t, err)
+	f.refund(t, old, 100)
+
+	batch, err := f.useCase.RunSettlement(ctx, day.Add(48*time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, batch.Payouts)
+
+	fresh := f.completed(t, "merchant-a", 150, "EUR", day.Add(25*time.Hour))
+	batch, err = f.useCase.RunSettlement(ctx, day.Add(72*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, batch.Payouts, 1)
+	assert.Equal(t, "50.00 EUR", batch.Payouts[0].Net.String())
+	assert.ElementsMatch(t, []string{old.ID, fresh.ID}, paymentIDs(batch.Payouts[0]))
+}
+
+func TestSplitSharesAreNotPaidToTheMerchant(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+
+	split := f.completed(t, "merchant-a", 100, "EUR", day.Add(-2*time.Hour), 100)
+	split.Splits = []domain.Split{
+		{Recipient: "seller-1", Amount: domain.Money{MinorUnits: 6000, Currency: "EUR"}},
+		{Recipient: "seller-2", Amount: domain.Money{MinorUnits: 4000, Currency: "EUR"}},
+	}
+	require.NoError(t, f.repo.Update(ctx, split))
+	f.refund(t, split, 30) // taken back from the sellers' balances
+	plain := f.completed(t, "merchant-a", 50, "EUR", day.Add(-time.Hour))
+
+	batch, err := f.useCase.RunSettlement(ctx, day.Add(24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, batch.Payouts, 1)
+	payout := batch.Payouts[0]
+	assert.Equal(t, "50.00 EUR", payout.Gross.String())
+	assert.Equal(t, "0.00 EUR", payout.Refunds.String())
+	assert.Equal(t, "1.00 EUR", payout.Fees.String())
+	assert.Equal(t, "49.00 EUR", payout.Net.String())
+	assert.ElementsMatch(t, []string{split.ID, plain.ID}, paymentIDs(payout))
+}
+
+func TestPaymentsAreNettedInTheirSettlementCurrency(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+
+	payment := f.completed(t, "merchant-a", 100, "USD", day.Add(-time.Hour), 300)
+	payment.Settlement = &domain.Settlement{
+		Amount: domain.Money{MinorUnits: 9000, Currency: "EUR"},
+		Rate:   domain.FXRate{Base: "USD", Quote: "EUR", Rate: "0.9"},
+	}
+	require.NoError(t, f.repo.Update(ctx, payment))
+	f.refund(t, payment, 10)
+
+	batch, err := f.useCase.RunSettlement(ctx, day.Add(24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, batch.Payouts, 1)
+	payout := batch.Payouts[0]
+	assert.Equal(t, "EUR", payout.Currency)
+	assert.Equal(t, "90.00 EUR", payout.Gross.String())
+	assert.Equal(t, "9.00 EUR", payout.Refunds.String())
+	assert.Equal(t, "2.70 EUR", payout.Fees.String())
+	assert.Equal(t, "78.30 EUR", payout.Net.String())
+}
+
+func TestPayoutLifecycle(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	f.completed(t, "merchant-a", 100, "EUR", day.Add(-time.Hour), 300)
+
+	batch, err := f.useCase.RunSettlement(ctx, day.Add(24*time.Hour))
+	require.NoError(t, err)
+	id := batch.Payouts[0].ID
+
+	_, err = f.useCase.MarkPayoutPaid(ctx, id)
+	assert.Error(t, err, "a payout must be sent before it is paid")
+
+	sent, err := f.useCase.MarkPayoutSent(ctx, id, "BANK-123")
+	require.NoError(t, err)
+	assert.Equal(t, domain.PayoutStatusSent, sent.Status)
+	assert.Equal(t, "BANK-123", sent.Reference)
+	assert.NotNil(t, sent.SentAt)
+
+	paid, err := f.useCase.MarkPayoutPaid(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PayoutStatusPaid, paid.Status)
+	assert.NotNil(t, paid.PaidAt)
+
+	payable, err := f.ledger.Balance(ctx, domain.AccountMerchantPayable, "EUR")
+	require.NoError(t, err)
+	assert.InDelta(t, 97.0, payable, 0.001)
+
+	_, err = f.useCase.MarkPayoutFailed(ctx, id, "returned")
+	assert.Error(t, err, "a paid payout cannot fail")
+
+	listed, err := f.useCase.ListPayouts(ctx, domain.PayoutFilter{MerchantID: "merchant-a", Statuses: []domain.PayoutStatus{domain.PayoutStatusPaid}})
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Equal(t, "BANK-123", listed[0].Reference)
+	assert.Len(t, listed[0].Items, 1)
+}
+
+func TestFailedPayoutPaymentsAreSettledAgain(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	payment := f.completed(t, "merchant-a", 100, "EUR", day.Add(-time.Hour))
+
+	batch, err := f.useCase.RunSettlement(ctx, day.Add(24*time.Hour))
+	require.NoError(t, err)
+	_, err = f.useCase.MarkPayoutFailed(ctx, batch.Payouts[0].ID, " ")
+	assert.Error(t, err, "a reason is required")
+	failed, err := f.useCase.MarkPayoutFailed(ctx, batch.Payouts[0].ID, "account closed")
+	require.NoError(t, err)
+	assert.Equal(t, "account closed", failed.FailureReason)
+
+	retry, err := f.useCase.RunSettlement(ctx, day.Add(48*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, retry.Payouts, 1)
+	assert.Equal(t, "100.00 EUR", retry.Payouts[0].Net.String())
+	assert.Equal(t, []string{payment.ID}, paymentIDs(retry.Payouts[0]))
+}
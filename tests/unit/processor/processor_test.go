@@ -0,0 +1,349 @@
+package processor_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"payments_app/internal/domain"
+	"payments_app/internal/infrastructure/database"
+	"payments_app/internal/infrastructure/processor"
+	"payments_app/internal/usecases"
+	"payments_app/internal/vault"
+	"payments_app/tests/helpers"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func authorizeRequest(amount float64) domain.AuthorizeRequest {
+	return domain.AuthorizeRequest{PaymentID: "p-1", Amount: amount, Currency: "USD", Description: "Test"}
+}
+
+func TestSimulator_Outcomes(t *testing.T) {
+	sim := processor.NewSimulator(processor.SimulatorConfig{})
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		request domain.AuthorizeRequest
+		outcome domain.ProcessorOutcome
+		code    string
+	}{
+		{name: "approved", request: authorizeRequest(100.00), outcome: domain.ProcessorOutcomeApproved, code: "00"},
+		{name: "do not honor", request: authorizeRequest(100.02), outcome: domain.ProcessorOutcomeDeclined, code: "05"},
+		{name: "insufficient funds", request: authorizeRequest(100.05), outcome: domain.ProcessorOutcomeDeclined, code: "51"},
+		{name: "pending", request: authorizeRequest(100.07), outcome: domain.ProcessorOutcomePending, code: "09"},
+		{
+			name: "declined card",
+			request: domain.AuthorizeRequest{
+				Amount: 10, Currency: "USD",
+				Method: domain.CardMethod{Token: "tok_1", Last4: processor.SimulatorDeclinedCardLast4},
+			},
+			outcome: domain.ProcessorOutcomeDeclined,
+			code:    "05",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := sim.Authorize(ctx, tt.request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.outcome, result.Outcome)
+			assert.Equal(t, tt.code, result.Code)
+			assert.NotEmpty(t, result.Reference)
+		})
+	}
+
+	_, err := sim.Authorize(ctx, authorizeRequest(100.13))
+	assert.True(t, errors.Is(err, domain.ErrProcessorUnavailable))
+}
+
+func TestSimulator_Lifecycle(t *testing.T) {
+	sim := processor.NewSimulator(processor.SimulatorConfig{})
+	ctx := context.Background()
+
+	auth, err := sim.Authorize(ctx, authorizeRequest(50))
+	require.NoError(t, err)
+
+	refund, err := sim.Refund(ctx, auth.Reference, 10)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ProcessorOutcomeDeclined, refund.Outcome, "uncaptured payments cannot be refunded")
+
+	capture, err := sim.Capture(ctx, auth.Reference, 50)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusCompleted, capture.Status)
+
+	refund, err = sim.Refund(ctx, auth.Reference, 20)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusCompleted, refund.Status)
+
+	refund, err = sim.Refund(ctx, auth.Reference, 30)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusRefunded, refund.Status)
+
+	_, err = sim.GetStatus(ctx, "sim_unknown")
+	assert.Error(t, err)
+}
+
+func TestSimulator_Latency(t *testing.T) {
+	sim := processor.NewSimulator(processor.SimulatorConfig{Latency: 30 * time.Millisecond})
+
+	start := time.Now()
+	_, err := sim.Authorize(context.Background(), authorizeRequest(10))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	_, err = sim.Authorize(ctx, authorizeRequest(10))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+type fakeDetokenizer struct{}
+
+func (fakeDetokenizer) Detokenize(ctx context.Context, token string) (*vault.Card, error) {
+	return &vault.Card{PAN: "4111111111111111"}, nil
+}
+
+func TestHTTPConnector(t *testing.T) {
+	var authorization map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/authorizations":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&authorization))
+			json.NewEncoder(w).Encode(map[string]string{"reference": "ext-1", "result": "approved", "status": "authorized", "code": "00", "message": "ok"})
+		case "/payments/ext-1/capture":
+			json.NewEncoder(w).Encode(map[string]string{"reference": "ext-1", "result": "declined", "status": "authorized", "code": "61", "message": "limit exceeded"})
+		case "/payments/ext-1":
+			json.NewEncoder(w).Encode(map[string]string{"reference": "ext-1", "result": "approved", "status": "settled"})
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	connector, err := processor.NewHTTPConnector(processor.HTTPConfig{
+		BaseURL:     server.URL,
+		APIKey:      "secret",
+		Detokenizer: fakeDetokenizer{},
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	request := authorizeRequest(25)
+	request.Method = domain.CardMethod{Token: "tok_abc", Last4: "1111", ExpiryMonth: 12, ExpiryYear: 2030}
+	result, err := connector.Authorize(ctx, request)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ProcessorOutcomeApproved, result.Outcome)
+	assert.Equal(t, domain.PaymentStatusAuthorized, result.Status)
+	assert.Equal(t, "ext-1", result.Reference)
+	method := authorization["method"].(map[string]interface{})
+	assert.Equal(t, "4111111111111111", method["card_number"])
+	assert.Nil(t, method["card_token"])
+
+	result, err = connector.Capture(ctx, "ext-1", 25)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ProcessorOutcomeDeclined, result.Outcome)
+	assert.Equal(t, "61", result.Code)
+
+	result, err = connector.GetStatus(ctx, "ext-1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusCompleted, result.Status)
+
+	_, err = connector.Void(ctx, "ext-1")
+	assert.True(t, errors.Is(err, domain.ErrProcessorUnavailable))
+}
+
+func TestPaymentUseCase_ProcessorLifecycle(t *testing.T) {
+	repo := helpers.NewMockPaymentRepository()
+	useCase := usecases.NewPaymentUseCase(repo, usecases.WithProcessor(processor.NewSimulator(processor.SimulatorConfig{})))
+	ctx := context.Background()
+
+	payment, err := useCase.CreatePayment(ctx, usecases.CreatePaymentInput{Amount: 100, Currency: "USD", Description: "Order 1"})
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusAuthorized, payment.Status)
+	assert.Equal(t, "simulator", payment.Processor)
+	assert.NotEmpty(t, payment.ProcessorReference)
+
+	_, err = useCase.RefundPayment(ctx, payment.ID, nil)
+	assert.Error(t, err, "authorized payments cannot be refunded")
+
+	payment, err = useCase.CapturePayment(ctx, payment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusCompleted, payment.Status)
+
+	partial := 40.0
+	payment, err = useCase.RefundPayment(ctx, payment.ID, &partial)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusCompleted, payment.Status)
+	assert.Equal(t, 40.0, payment.RefundedAmount)
+
+	tooMuch := 70.0
+	_, err = useCase.RefundPayment(ctx, payment.ID, &tooMuch)
+	assert.Error(t, err)
+
+	payment, err = useCase.RefundPayment(ctx, payment.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusRefunded, payment.Status)
+	assert.Equal(t, 100.0, payment.RefundedAmount)
+
+	stored, err := repo.GetByID(ctx, payment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusRefunded, stored.Status)
+}
+
+func TestPaymentUseCase_ProcessorOutcomes(t *testing.T) {
+	repo := helpers.NewMockPaymentRepository()
+	useCase := usecases.NewPaymentUseCase(repo,
+		usecases.WithProcessor(processor.NewSimulator(processor.SimulatorConfig{})),
+		usecases.WithAutoCapture(true))
+	ctx := context.Background()
+
+	t.Run("auto capture", func(t *testing.T) {
+		payment, err := useCase.CreatePayment(ctx, usecases.CreatePaymentInput{Amount: 10, Currency: "USD", Description: "Captured"})
+		require.NoError(t, err)
+		assert.Equal(t, domain.PaymentStatusCompleted, payment.Status)
+	})
+
+	t.Run("declined", func(t *testing.T) {
+		payment, err := useCase.CreatePayment(ctx, usecases.CreatePaymentInput{Amount: 10.05, Currency: "USD", Description: "Declined"})
+		require.NoError(t, err)
+		assert.Equal(t, domain.PaymentStatusFailed, payment.Status)
+		assert.Equal(t, "51 insufficient funds", payment.ProcessorResponse)
+	})
+
+	t.Run("pending then synced", func(t *testing.T) {
+		payment, err := useCase.CreatePayment(ctx, usecases.CreatePaymentInput{Amount: 10.07, Currency: "USD", Description: "Pending"})
+		require.NoError(t, err)
+		assert.Equal(t, domain.PaymentStatusPending, payment.Status)
+
+		payment, err = useCase.SyncPaymentStatus(ctx, payment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.PaymentStatusAuthorized, payment.Status)
+
+		payment, err = useCase.VoidPayment(ctx, payment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.PaymentStatusCancelled, payment.Status)
+	})
+
+	t.Run("processor unavailable", func(t *testing.T) {
+		payment, err := useCase.CreatePayment(ctx, usecases.CreatePaymentInput{Amount: 10.13, Currency: "USD", Description: "Outage"})
+		require.NoError(t, err)
+		assert.Equal(t, domain.PaymentStatusPending, payment.Status)
+		assert.Empty(t, payment.ProcessorReference)
+		assert.Contains(t, payment.ProcessorResponse, "unavailable")
+	})
+}
+
+type failingFees struct{ event domain.FeeEvent }
+
+func (f failingFees) Fees(payment *domain.Payment, event domain.FeeEvent) ([]domain.Fee, error) {
+	if event == f.event {
+		return nil, errors.New("fee schedule unavailable")
+	}
+	return nil, nil
+}
+
+func TestPaymentUseCase_ProcessingFailureKeepsProcessorState(t *testing.T) {
+	repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "processor.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	useCase := usecases.NewPaymentUseCase(repo,
+		usecases.WithProcessor(processor.NewSimulator(processor.SimulatorConfig{})),
+		usecases.WithAutoCapture(true),
+		usecases.WithFees(failingFees{event: domain.FeeEventCaptured}))
+	ctx := context.Background()
+
+	_, err = useCase.CreatePayment(ctx, usecases.CreatePaymentInput{Amount: 10, Currency: "USD", Description: "Captured"})
+	var stored *usecases.StoredPaymentError
+	require.ErrorAs(t, err, &stored)
+	assert.ErrorContains(t, err, "fee schedule unavailable")
+
+	// The processor captured the payment, so the saved payment says so
+	payment, err := repo.GetByID(ctx, stored.PaymentID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusCompleted, payment.Status)
+	assert.Equal(t, "simulator", payment.Processor)
+	assert.NotEmpty(t, payment.ProcessorReference)
+}
+
+func TestPaymentUseCase_InvalidTransition(t *testing.T) {
+	repo := helpers.NewMockPaymentRepository()
+	useCase := usecases.NewPaymentUseCase(repo)
+	ctx := context.Background()
+
+	payment, err := useCase.CreatePayment(ctx, usecases.CreatePaymentInput{Amount: 10, Currency: "USD", Description: "Manual"})
+	require.NoError(t, err)
+
+	cancelled := domain.PaymentStatusCancelled
+	_, err = useCase.UpdatePayment(ctx, usecases.UpdatePaymentInput{ID: payment.ID, Status: &cancelled})
+	require.NoError(t, err)
+
+	completed := domain.PaymentStatusCompleted
+	_, err = useCase.UpdatePayment(ctx, usecases.UpdatePaymentInput{ID: payment.ID, Status: &completed})
+	var invalid *domain.InvalidTransitionError
+	require.True(t, errors.As(err, &invalid))
+	assert.Equal(t, domain.PaymentStatusCancelled, invalid.From)
+
+	_, err = useCase.CapturePayment(ctx, payment.ID)
+	assert.Error(t, err)
+}
+
+func TestHTTPConnector_VerifyWebhook(t *testing.T) {
+	body := []byte(`{"id":"evt_9","type":"chargeback","reference":"ext-1","amount":25}`)
+	now := time.Now()
+	header := http.Header{}
+	header.Set(processor.WebhookTimestampHeader, strconv.FormatInt(now.Unix(), 10))
+	header.Set(processor.WebhookSignatureHeader, processor.SignWebhook("secret", now, body))
+
+	unsigned, err := processor.NewHTTPConnector(processor.HTTPConfig{BaseURL: "https://example.com"})
+	require.NoError(t, err)
+	_, err = unsigned.VerifyWebhook(header, body)
+	assert.ErrorIs(t, err, processor.ErrInvalidSignature, "callbacks are refused without a webhook secret")
+
+	connector, err := processor.NewHTTPConnector(processor.HTTPConfig{BaseURL: "https://example.com", WebhookSecret: "secret"})
+	require.NoError(t, err)
+	event, err := connector.VerifyWebhook(header, body)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ProcessorEventChargeback, event.Type)
+	assert.Equal(t, "evt_9", event.CallbackID)
+	assert.Equal(t, 25.0, event.Amount)
+
+	stale := now.Add(-time.Hour)
+	header.Set(processor.WebhookTimestampHeader, strconv.FormatInt(stale.Unix(), 10))
+	header.Set(processor.WebhookSignatureHeader, processor.SignWebhook("secret", stale, body))
+	_, err = connector.VerifyWebhook(header, body)
+	assert.ErrorIs(t, err, processor.ErrInvalidSignature)
+}
+
+func TestSimulator_VerifyWebhook(t *testing.T) {
+	body := []byte(`{"id":"evt_10","reference":"sim-1","status":"settled"}`)
+	now := time.Now()
+	header := http.Header{}
+	header.Set(processor.WebhookTimestampHeader, strconv.FormatInt(now.Unix(), 10))
+	header.Set(processor.WebhookSignatureHeader, processor.SignWebhook("secret", now, body))
+
+	unsigned := processor.NewSimulator(processor.SimulatorConfig{})
+	assert.False(t, unsigned.AcceptsWebhooks())
+	_, err := unsigned.VerifyWebhook(header, body)
+	assert.ErrorIs(t, err, processor.ErrInvalidSignature, "callbacks are refused without a webhook secret")
+	_, err = unsigned.VerifyWebhook(http.Header{}, body)
+	assert.ErrorIs(t, err, processor.ErrInvalidSignature)
+
+	sim := processor.NewSimulator(processor.SimulatorConfig{WebhookSecret: "secret"})
+	assert.True(t, sim.AcceptsWebhooks())
+	event, err := sim.VerifyWebhook(header, body)
+	require.NoError(t, err)
+	assert.Equal(t, "evt_10", event.CallbackID)
+	assert.Equal(t, domain.PaymentStatusCompleted, event.Status)
+	_, err = sim.VerifyWebhook(http.Header{}, body)
+	assert.ErrorIs(t, err, processor.ErrInvalidSignature)
+}
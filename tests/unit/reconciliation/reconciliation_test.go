@@ -0,0 +1,384 @@
+package reconciliation_test
+
+import (
+	"context"
+	"path/filepath"
+	"payments_app/internal/domain"
+	"payments_app/internal/infrastructure/database"
+	"payments_app/internal/reconciliation"
+	"payments_app/internal/usecases"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const camtStatement = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.08">
+  <BkToCstmrStmt>
+    <GrpHdr><MsgId>MSG-1</MsgId><CreDtTm>2026-03-03T06:00:00</CreDtTm></GrpHdr>
+    <Stmt>
+      <Id>STMT-2026-03-02</Id>
+      <Acct><Id><IBAN>DE89370400440532013000</IBAN></Id><Ccy>EUR</Ccy></Acct>
+      <Bal><Tp><CdOrPrtry><Cd>OPBD</Cd></CdOrPrtry></Tp><Amt Ccy="EUR">1000.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Dt><Dt>2026-03-01</Dt></Dt></Bal>
+      <Bal><Tp><CdOrPrtry><Cd>CLBD</Cd></CdOrPrtry></Tp><Amt Ccy="EUR">1225.50</Amt><CdtDbtInd>CRDT</CdtDbtInd><Dt><Dt>2026-03-02</Dt></Dt></Bal>
+      <Ntry>
+        <Amt Ccy="EUR">250.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <Sts><Cd>BOOK</Cd></Sts>
+        <BookgDt><Dt>2026-03-02</Dt></BookgDt>
+        <ValDt><Dt>2026-03-02</Dt></ValDt>
+        <AcctSvcrRef>BANK-1</AcctSvcrRef>
+        <NtryDtls><TxDtls>
+          <Refs><EndToEndId>E2E-REF-1</EndToEndId></Refs>
+          <RltdPties><Dbtr><Nm>Acme GmbH</Nm></Dbtr><DbtrAcct><Id><IBAN>DE02100100109307118603</IBAN></Id></DbtrAcct></RltdPties>
+          <RmtInf><Ustrd>Invoice 1001</Ustrd></RmtInf>
+        </TxDtls></NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">24.50</Amt>
+        <CdtDbtInd>DBIT</CdtDbtInd>
+        <Sts><Cd>BOOK</Cd></Sts>
+        <BookgDt><Dt>2026-03-02</Dt></BookgDt>
+        <ValDt><Dt>2026-03-02</Dt></ValDt>
+        <AddtlNtryInf>Account fee</AddtlNtryInf>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">99.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <Sts><Cd>PDNG</Cd></Sts>
+        <BookgDt><Dt>2026-03-02</Dt></BookgDt>
+      </Ntry>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>`
+
+const mt940Statement = `{1:F01BANKDEFFAXXX0000000000}{2:O9401200260302BANKDEFFAXXX00000000002603021200N}{4:
+:20:STMT0302
+:25:DE89370400440532013000
+:28C:00061/001
+:60F:C260301EUR1000,00
+:61:2603020302C250,00NTRFNONREF//BANK-1
+:86:166?00GUTSCHRIFT?20EREF+E2E-REF-1?21SVWZ+Invoice 1001?31DE02100100109307118603?32Acme GmbH
+:61:2603020302D24,50NCHGNONREF
+:86:Account fee
+:62F:C260302EUR1225,50
+-}`
+
+func TestParseCAMT053(t *testing.T) {
+	statements, err := reconciliation.Parse(strings.NewReader(camtStatement), "")
+	require.NoError(t, err)
+	require.Len(t, statements, 1)
+
+	statement := statements[0]
+	assert.Equal(t, domain.StatementFormatCAMT053, statement.Format)
+	assert.Equal(t, "STMT-2026-03-02", statement.Reference)
+	assert.Equal(t, "DE89370400440532013000", statement.Account)
+	assert.Equal(t, "EUR", statement.Currency)
+	assert.Equal(t, "1000.00", statement.OpeningBalance.Decimal())
+	assert.Equal(t, "1225.50", statement.ClosingBalance.Decimal())
+	assert.Len(t, statement.SHA256, 64)
+
+	// The pending entry is skipped
+	require.Len(t, statement.Lines, 2)
+	credit := statement.Lines[0]
+	assert.Equal(t, 1, credit.Index)
+	assert.Equal(t, statement.ID, credit.StatementID)
+	assert.Equal(t, domain.StatementLineStatusUnmatched, credit.Status)
+	assert.Equal(t, domain.Money{MinorUnits: 25000, Currency: "EUR"}, credit.Amount)
+	assert.Equal(t, domain.EntryDirectionCredit, credit.Direction)
+	assert.Equal(t, "E2E-REF-1", credit.Reference)
+	assert.Equal(t, "BANK-1", credit.BankReference)
+	assert.Equal(t, "Acme GmbH", credit.Counterparty)
+	assert.Equal(t, "DE02100100109307118603", credit.CounterpartyAccount)
+	assert.Equal(t, "Invoice 1001", credit.Description)
+	assert.Equal(t, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), credit.ValueDate)
+
+	fee := statement.Lines[1]
+	assert.Equal(t, domain.EntryDirectionDebit, fee.Direction)
+	assert.Equal(t, int64(2450), fee.Amount.MinorUnits)
+	assert.Equal(t, "Account fee", fee.Description)
+}
+
+func TestParseMT940(t *testing.T) {
+	statements, err := reconciliation.Parse(strings.NewReader(mt940Statement), "")
+	require.NoError(t, err)
+	require.Len(t, statements, 1)
+
+	statement := statements[0]
+	assert.Equal(t, domain.StatementFormatMT940, statement.Format)
+	assert.Equal(t, "STMT0302/00061/001", statement.Reference)
+	assert.Equal(t, "DE89370400440532013000", statement.Account)
+	assert.Equal(t, "EUR", statement.Currency)
+	assert.Equal(t, "1000.00", statement.OpeningBalance.Decimal())
+	assert.Equal(t, "1225.50", statement.ClosingBalance.Decimal())
+
+	require.Len(t, statement.Lines, 2)
+	credit := statement.Lines[0]
+	assert.Equal(t, domain.Money{MinorUnits: 25000, Currency: "EUR"}, credit.Amount)
+	assert.Equal(t, domain.EntryDirectionCredit, credit.Direction)
+	assert.Equal(t, "E2E-REF-1", credit.Reference)
+	assert.Equal(t, "BANK-1", credit.BankReference)
+	assert.Equal(t, "Acme GmbH", credit.Counterparty)
+	assert.Equal(t, "DE02100100109307118603", credit.CounterpartyAccount)
+	assert.Contains(t, credit.Description, "Invoice 1001")
+	assert.Equal(t, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), credit.BookingDate)
+
+	fee := statement.Lines[1]
+	assert.Equal(t, domain.EntryDirectionDebit, fee.Direction)
+	assert.Empty(t, fee.Reference)
+	assert.Equal(t, "Account fee", fee.Description)
+}
+
+func TestParseRejectsInvalidFiles(t *testing.T) {
+	_, err := reconciliation.Parse(strings.NewReader("hello"), "")
+	assert.ErrorContains(t, err, "cannot detect the statement format")
+
+	_, err = reconciliation.Parse(strings.NewReader(":20:X\n:60F:C260301EUR10,00\n:61:garbage\n"), domain.StatementFormatMT940)
+	assert.ErrorContains(t, err, "line 3")
+
+	_, err = reconciliation.Parse(strings.NewReader("<Document><BkToCstmrStmt>"), domain.StatementFormatCAMT053)
+	assert.ErrorContains(t, err, "invalid camt.053 document")
+
+	format, err := reconciliation.ParseFormat("camt.053")
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatementFormatCAMT053, format)
+	_, err = reconciliation.ParseFormat("bai2")
+	assert.Error(t, err)
+}
+
+func TestMatcherScore(t *testing.T) {
+	matcher := reconciliation.NewMatcher(reconciliation.Config{})
+	paid := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	payment := &domain.Payment{ID: "7d0c6f3e-1b2a-4c5d-8e9f-0a1b2c3d4e5f", Amount: 250, Currency: "EUR", CreatedAt: paid, UpdatedAt: paid}
+	line := func(amount int64, reference string, valueDate time.Time) *domain.StatementLine {
+		return &domain.StatementLine{
+			Amount:    domain.Money{MinorUnits: amount, Currency: "EUR"},
+			Direction: domain.EntryDirectionCredit,
+			Reference: reference,
+			ValueDate: valueDate,
+		}
+	}
+	day := 24 * time.Hour
+
+	assert.Equal(t, 1.0, matcher.Score(line(25000, "7d0c6f3e-1b2a-4c5d-8e9f-0a1b2c3d4e5f", paid), payment))
+	// Banks often drop hyphens from references
+	assert.Equal(t, 1.0, matcher.Score(line(25000, "7D0C6F3E1B2A4C5D8E9F0A1B2C3D4E5F", paid), payment))
+	assert.Equal(t, 0.6, matcher.Score(line(25000, "", paid), payment))
+	assert.Equal(t, 0.55, matcher.Score(line(25000, "", paid.Add(day)), payment))
+	assert.Equal(t, 0.4, matcher.Score(line(25000, "", paid.Add(5*day)), payment))
+	assert.Equal(t, 0.6, matcher.Score(line(9900, "7d0c6f3e-1b2a-4c5d-8e9f-0a1b2c3d4e5f", paid), payment))
+	assert.Equal(t, 0.0, matcher.Score(line(9900, "OTHER", paid), payment))
+
+	usd := line(25000, "7d0c6f3e-1b2a-4c5d-8e9f-0a1b2c3d4e5f", paid)
+	usd.Amount.Currency = "USD"
+	assert.Equal(t, 0.0, matcher.Score(usd, payment))
+}
+
+func TestMatcherScoreChecksDirection(t *testing.T) {
+	matcher := reconciliation.NewMatcher(reconciliation.Config{})
+	paid := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	line := func(direction domain.EntryDirection) *domain.StatementLine {
+		return &domain.StatementLine{
+			Amount:    domain.Money{MinorUnits: 25000, Currency: "EUR"},
+			Direction: direction,
+			Reference: "pay-1",
+			ValueDate: paid,
+		}
+	}
+	payment := func(status domain.PaymentStatus, submissionID string) *domain.Payment {
+		return &domain.Payment{ID: "pay-1", Amount: 250, Currency: "EUR", Status: status, SubmissionID: submissionID, CreatedAt: paid, UpdatedAt: paid}
+	}
+
+	// Collected payments arrive as credits
+	collected := payment(domain.PaymentStatusCompleted, "")
+	assert.Equal(t, 1.0, matcher.Score(line(domain.EntryDirectionCredit), collected))
+	assert.Equal(t, 0.0, matcher.Score(line(domain.EntryDirectionDebit), collected))
+
+	// Sent and refunded payments leave as debits
+	for _, outgoing := range []*domain.Payment{
+		payment(domain.PaymentStatusSubmitted, "CT-1"),
+		payment(domain.PaymentStatusCompleted, "CT-1"),
+		payment(domain.PaymentStatusRefunded, ""),
+	} {
+		assert.Equal(t, 1.0, matcher.Score(line(domain.EntryDirectionDebit), outgoing), outgoing.Status)
+		assert.Equal(t, 0.0, matcher.Score(line(domain.EntryDirectionCredit), outgoing), outgoing.Status)
+	}
+}
+
+func TestMatcherPairsOneToOne(t *testing.T) {
+	matcher := reconciliation.NewMatcher(reconciliation.Config{})
+	paid := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	first := &domain.Payment{ID: "pay-1", Amount: 100, Currency: "EUR", CreatedAt: paid, UpdatedAt: paid}
+	second := &domain.Payment{ID: "pay-2", Amount: 100, Currency: "EUR", CreatedAt: paid, UpdatedAt: paid}
+	lines := []*domain.StatementLine{
+		{ID: "a", Amount: domain.Money{MinorUnits: 10000, Currency: "EUR"}, Direction: domain.EntryDirectionCredit, ValueDate: paid, Reference: "PAY-2"},
+		{ID: "b", Amount: domain.Money{MinorUnits: 10000, Currency: "EUR"}, Direction: domain.EntryDirectionCredit, ValueDate: paid},
+	}
+
+	matches := matcher.Match(lines, []*domain.Payment{first, second})
+	require.Len(t, matches, 2)
+	assert.Equal(t, "a", matches[0].Line.ID)
+	assert.Equal(t, "pay-2", matches[0].Payment.ID)
+	assert.True(t, matches[0].Auto)
+	// Only the amount and date point to pay-1, so the match needs review
+	assert.Equal(t, "b", matches[1].Line.ID)
+	assert.Equal(t, "pay-1", matches[1].Payment.ID)
+	assert.False(t, matches[1].Auto)
+
+	// A rejected payment is not proposed for the line again
+	lines[1].RejectedPaymentIDs = []string{"pay-1"}
+	matches = matcher.Match(lines, []*domain.Payment{first, second})
+	require.Len(t, matches, 1)
+	assert.Equal(t, "a", matches[0].Line.ID)
+}
+
+type fixture struct {
+	repo    *database.PaymentRepository
+	useCase *usecases.PaymentUseCase
+}
+
+func setup(t *testing.T) *fixture {
+	repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "reconciliation.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+
+	statements, err := database.NewReconciliationRepository(repo.DB())
+	require.NoError(t, err)
+
+	useCase := usecases.NewPaymentUseCase(repo,
+		usecases.WithReconciliation(statements, reconciliation.NewMatcher(reconciliation.Config{})),
+	)
+	return &fixture{repo: repo, useCase: useCase}
+}
+
+func (f *fixture) payment(t *testing.T, id string, amount float64, status domain.PaymentStatus, at time.Time) *domain.Payment {
+	payment := domain.NewPayment(amount, "EUR", "Order "+id)
+	payment.ID = id
+	payment.Status = status
+	payment.CreatedAt, payment.UpdatedAt = at, at
+	require.NoError(t, f.repo.Create(context.Background(), payment))
+	return payment
+}
+
+func (f *fixture) importStatement(t *testing.T, content string) *usecases.StatementImport {
+	statements, err := reconciliation.Parse(strings.NewReader(content), "")
+	require.NoError(t, err)
+	result, err := f.useCase.ImportStatements(context.Background(), statements)
+	require.NoError(t, err)
+	return result
+}
+
+func TestImportStatementsMatchesPayments(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	paid := time.Date(2026, 3, 1, 15, 0, 0, 0, time.UTC)
+	referenced := f.payment(t, "E2E-REF-1", 250, domain.PaymentStatusCompleted, paid)
+	fee := f.payment(t, "fee-1", 24.5, domain.PaymentStatusSubmitted, paid)
+	collected := f.payment(t, "collected-1", 24.5, domain.PaymentStatusCompleted, paid) // a credit, not the debited fee
+	f.payment(t, "pending-1", 24.5, domain.PaymentStatusPending, paid)
+	unseen := f.payment(t, "unseen-1", 12, domain.PaymentStatusRefunded, paid)
+
+	result := f.importStatement(t, camtStatement)
+	assert.Equal(t, usecases.ReconciliationRun{Matched: 1, Proposed: 1}, result.Run)
+	require.Len(t, result.Statements, 1)
+	lines := result.Statements[0].Lines
+	require.Len(t, lines, 2)
+
+	assert.Equal(t, domain.StatementLineStatusMatched, lines[0].Status)
+	assert.Equal(t, referenced.ID, lines[0].PaymentID)
+	assert.Equal(t, domain.MatchMethodAuto, lines[0].MatchMethod)
+	assert.NotNil(t, lines[0].MatchedAt)
+
+	// Amount and date alone are not enough to match without review
+	assert.Equal(t, domain.StatementLineStatusProposed, lines[1].Status)
+	assert.Equal(t, fee.ID, lines[1].PaymentID)
+	assert.Less(t, lines[1].Confidence, 0.9)
+
+	queue, err := f.useCase.UnreconciledStatementLines(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, queue, 1)
+	assert.Equal(t, lines[1].ID, queue[0].ID)
+
+	payments, err := f.useCase.UnreconciledPayments(ctx)
+	require.NoError(t, err)
+	require.Len(t, payments, 2)
+	assert.ElementsMatch(t, []string{unseen.ID, collected.ID}, []string{payments[0].ID, payments[1].ID})
+
+	// The same file cannot be imported twice
+	statements, err := reconciliation.Parse(strings.NewReader(camtStatement), "")
+	require.NoError(t, err)
+	_, err = f.useCase.ImportStatements(ctx, statements)
+	assert.ErrorIs(t, err, domain.ErrDuplicateStatement)
+}
+
+func TestReviewStatementMatches(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	paid := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	fee := f.payment(t, "fee-1", 24.5, domain.PaymentStatusSubmitted, paid)
+	other := f.payment(t, "fee-2", 24.5, domain.PaymentStatusSubmitted, paid.Add(-48*time.Hour))
+
+	result := f.importStatement(t, mt940Statement)
+	assert.Equal(t, usecases.ReconciliationRun{Proposed: 1, Unmatched: 1}, result.Run)
+	proposed := result.Statements[0].Lines[1]
+	require.Equal(t, domain.StatementLineStatusProposed, proposed.Status)
+	assert.Equal(t, fee.ID, proposed.PaymentID)
+
+	_, err := f.useCase.ConfirmStatementMatch(ctx, proposed.ID, " ")
+	assert.ErrorContains(t, err, "reviewer is required")
+
+	// Rejecting the proposal sends the line back to the queue and offers the next candidate
+	line, err := f.useCase.RejectStatementMatch(ctx, proposed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatementLineStatusUnmatched, line.Status)
+	assert.Empty(t, line.PaymentID)
+	assert.Equal(t, []string{fee.ID}, line.RejectedPaymentIDs)
+
+	run, err := f.useCase.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &usecases.ReconciliationRun{Proposed: 1, Unmatched: 1}, run)
+	line, err = f.useCase.ConfirmStatementMatch(ctx, proposed.ID, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatementLineStatusMatched, line.Status)
+	assert.Equal(t, other.ID, line.PaymentID)
+	assert.Equal(t, domain.MatchMethodConfirmed, line.MatchMethod)
+	assert.Equal(t, "alice", line.MatchedBy)
+
+	_, err = f.useCase.ConfirmStatementMatch(ctx, proposed.ID, "alice")
+	assert.ErrorContains(t, err, "only proposed matches")
+
+	// The credit line is matched by hand; a payment can only be matched once
+	credit := result.Statements[0].Lines[0]
+	_, err = f.useCase.MatchStatementLine(ctx, credit.ID, other.ID, "bob")
+	assert.ErrorIs(t, err, domain.ErrPaymentAlreadyReconciled)
+	pending := f.payment(t, "pending-1", 250, domain.PaymentStatusPending, paid)
+	_, err = f.useCase.MatchStatementLine(ctx, credit.ID, pending.ID, "bob")
+	assert.ErrorContains(t, err, "only completed, refunded or submitted")
+
+	late := f.payment(t, "late-1", 250, domain.PaymentStatusCompleted, paid.Add(30*24*time.Hour))
+	line, err = f.useCase.MatchStatementLine(ctx, credit.ID, late.ID, "bob")
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatementLineStatusMatched, line.Status)
+	assert.Equal(t, domain.MatchMethodManual, line.MatchMethod)
+	assert.Equal(t, 1.0, line.Confidence)
+
+	queue, err := f.useCase.UnreconciledStatementLines(ctx, result.Statements[0].ID)
+	require.NoError(t, err)
+	assert.Empty(t, queue)
+	payments, err := f.useCase.UnreconciledPayments(ctx)
+	require.NoError(t, err)
+	require.Len(t, payments, 1)
+	assert.Equal(t, fee.ID, payments[0].ID)
+}
+
+func TestReconciliationNotConfigured(t *testing.T) {
+	repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "plain.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+
+	_, err = usecases.NewPaymentUseCase(repo).Reconcile(context.Background())
+	assert.ErrorIs(t, err, usecases.ErrReconciliationNotConfigured)
+}
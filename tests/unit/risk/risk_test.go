@@ -0,0 +1,273 @@
+package risk_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"payments_app/internal/domain"
+	"payments_app/internal/infrastructure/database"
+	"payments_app/internal/infrastructure/processor"
+	"payments_app/internal/risk"
+	"payments_app/internal/usecases"
+	"payments_app/tests/helpers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubHistory returns a fixed set of previous payments for every payer
+type stubHistory struct {
+	payments []*domain.Payment
+}
+
+func (h *stubHistory) PayerPayments(ctx context.Context, payerID string, since time.Time) ([]*domain.Payment, error) {
+	var result []*domain.Payment
+	for _, payment := range h.payments {
+		if payment.PayerID == payerID && !payment.CreatedAt.Before(since) {
+			result = append(result, payment)
+		}
+	}
+	return result, nil
+}
+
+func newPayerPayment(payerID string, amount float64, age time.Duration) *domain.Payment {
+	payment := domain.NewPayment(amount, "USD", "Previous payment")
+	payment.PayerID = payerID
+	payment.CreatedAt = time.Now().Add(-age)
+	return payment
+}
+
+func TestEngine_AllowsCleanPayment(t *testing.T) {
+	engine := risk.NewEngine(nil, risk.Thresholds{},
+		&risk.KeywordRule{RuleName: "keywords", Keywords: []string{"crypto"}, Score: 30},
+	)
+
+	assessment, err := engine.Assess(context.Background(), domain.NewPayment(10, "USD", "Office supplies"))
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, assessment.Score)
+	assert.Equal(t, domain.RiskDecisionAllow, assessment.Decision)
+	assert.Empty(t, assessment.Reasons)
+}
+
+func TestEngine_ScoresAccumulateIntoReview(t *testing.T) {
+	engine := risk.NewEngine(nil, risk.Thresholds{Review: 50, Deny: 80},
+		&risk.KeywordRule{RuleName: "keywords", Keywords: []string{"gift card"}, Score: 30},
+		&risk.KeywordRule{RuleName: "urgent", Keywords: []string{"urgent"}, Score: 25},
+	)
+
+	assessment, err := engine.Assess(context.Background(), domain.NewPayment(10, "USD", "URGENT Gift Card order"))
+
+	require.NoError(t, err)
+	assert.Equal(t, 55, assessment.Score)
+	assert.Equal(t, domain.RiskDecisionReview, assessment.Decision)
+	assert.Len(t, assessment.Reasons, 2)
+}
+
+func TestEngine_BlockedCurrencyDenies(t *testing.T) {
+	engine := risk.NewEngine(nil, risk.Thresholds{},
+		&risk.BlockedCurrencyRule{RuleName: "blocked", Currencies: map[string]bool{"KPW": true}, Deny: true},
+	)
+
+	assessment, err := engine.Assess(context.Background(), domain.NewPayment(10, "KPW", "Transfer"))
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.RiskDecisionDeny, assessment.Decision)
+	assert.Contains(t, assessment.Reasons[0], "currency KPW is blocked")
+}
+
+func TestVelocityRule(t *testing.T) {
+	history := &stubHistory{payments: []*domain.Payment{
+		newPayerPayment("payer-1", 10, 10*time.Minute),
+		newPayerPayment("payer-1", 10, 20*time.Minute),
+		newPayerPayment("payer-1", 10, 2*time.Hour),
+	}}
+	rule := &risk.VelocityRule{RuleName: "velocity", MaxCount: 2, Window: time.Hour, Score: 40}
+
+	payment := domain.NewPayment(10, "USD", "Payment")
+	payment.PayerID = "payer-1"
+	hit, err := rule.Evaluate(context.Background(), payment, history)
+	require.NoError(t, err)
+	require.NotNil(t, hit)
+	assert.Equal(t, 40, hit.Score)
+
+	payment.PayerID = "payer-2"
+	hit, err = rule.Evaluate(context.Background(), payment, history)
+	require.NoError(t, err)
+	assert.Nil(t, hit)
+}
+
+func TestAmountSpikeRule(t *testing.T) {
+	history := &stubHistory{payments: []*domain.Payment{
+		newPayerPayment("payer-1", 100, time.Hour),
+		newPayerPayment("payer-1", 120, 2*time.Hour),
+		newPayerPayment("payer-1", 80, 3*time.Hour),
+	}}
+	rule := &risk.AmountSpikeRule{RuleName: "spike", Multiplier: 5, Lookback: 24 * time.Hour, MinHistory: 3, Score: 50}
+
+	spike := domain.NewPayment(600, "USD", "Large payment")
+	spike.PayerID = "payer-1"
+	hit, err := rule.Evaluate(context.Background(), spike, history)
+	require.NoError(t, err)
+	require.NotNil(t, hit)
+	assert.Contains(t, hit.Reason, "rolling average 100.00")
+
+	normal := domain.NewPayment(400, "USD", "Normal payment")
+	normal.PayerID = "payer-1"
+	hit, err = rule.Evaluate(context.Background(), normal, history)
+	require.NoError(t, err)
+	assert.Nil(t, hit)
+}
+
+func TestEngine_ReloadFromYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+thresholds:
+  review: 20
+  deny: 90
+rules:
+  - name: keywords
+    type: keywords
+    keywords: [crypto]
+    score: 30
+`), 0o600))
+
+	engine := risk.NewEngine(nil, risk.Thresholds{})
+	require.NoError(t, engine.Reload(path))
+	assert.Equal(t, []string{"keywords"}, engine.Rules())
+
+	assessment, err := engine.Assess(context.Background(), domain.NewPayment(10, "USD", "Buy crypto"))
+	require.NoError(t, err)
+	assert.Equal(t, domain.RiskDecisionReview, assessment.Decision)
+
+	// An invalid file keeps the previous rules active
+	require.NoError(t, os.WriteFile(path, []byte(`rules: [{type: unknown}]`), 0o600))
+	err = engine.Reload(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown rule type")
+	assert.Equal(t, []string{"keywords"}, engine.Rules())
+}
+
+func TestEngine_WatchReloadsChangedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("rules: [{name: keywords, type: keywords, keywords: [crypto], score: 30}]\n"), 0o600))
+	engine := risk.NewEngine(nil, risk.Thresholds{})
+	require.NoError(t, engine.Reload(path))
+
+	// A zero interval disables watching instead of panicking
+	engine.Watch(context.Background(), path, 0, func(err error) { t.Error(err) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		engine.Watch(ctx, path, 10*time.Millisecond, func(err error) { t.Error(err) })
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	require.NoError(t, os.WriteFile(path, []byte("rules: [{name: currencies, type: blocked_currency, currencies: [XXX], score: 100}]\n"), 0o600))
+	modified := time.Now()
+	assert.Eventually(t, func() bool {
+		// Keep moving the modification time in case the watch started after the write
+		modified = modified.Add(time.Second)
+		assert.NoError(t, os.Chtimes(path, modified, modified))
+		rules := engine.Rules()
+		return len(rules) == 1 && rules[0] == "currencies"
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestPaymentUseCase_CreatePayment_RiskScreening(t *testing.T) {
+	repo := helpers.NewMockPaymentRepository()
+	engine := risk.NewEngine(nil, risk.Thresholds{},
+		&risk.BlockedCurrencyRule{RuleName: "blocked", Currencies: map[string]bool{"IRR": true}, Deny: true},
+	)
+	useCase := usecases.NewPaymentUseCase(repo, usecases.WithRiskScreener(engine))
+
+	allowed, err := useCase.CreatePayment(context.Background(), usecases.CreatePaymentInput{
+		Amount: 10, Currency: "USD", Description: "Allowed", PayerID: "payer-1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusPending, allowed.Status)
+	assert.Equal(t, "payer-1", allowed.PayerID)
+	require.NotNil(t, allowed.Risk)
+	assert.Equal(t, domain.RiskDecisionAllow, allowed.Risk.Decision)
+
+	denied, err := useCase.CreatePayment(context.Background(), usecases.CreatePaymentInput{
+		Amount: 10, Currency: "IRR", Description: "Denied",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusRejected, denied.Status)
+
+	stored, err := repo.GetByID(context.Background(), denied.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.RiskDecisionDeny, stored.Risk.Decision)
+}
+
+func TestPaymentUseCase_RiskReviewHoldsPayment(t *testing.T) {
+	ctx := context.Background()
+	repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "risk.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	engine := risk.NewEngine(nil, risk.Thresholds{},
+		&risk.KeywordRule{RuleName: "keywords", Keywords: []string{"gift card"}, Score: 60},
+	)
+	useCase := usecases.NewPaymentUseCase(repo,
+		usecases.WithRiskScreener(engine),
+		usecases.WithProcessor(processor.NewSimulator(processor.SimulatorConfig{})),
+	)
+	create := func() *domain.Payment {
+		payment, err := useCase.CreatePayment(ctx, usecases.CreatePaymentInput{Amount: 10, Currency: "USD", Description: "Gift card order"})
+		require.NoError(t, err)
+		require.Equal(t, domain.RiskDecisionReview, payment.Risk.Decision)
+		require.Equal(t, domain.PaymentStatusRiskReview, payment.Status, "payments to review are not processed")
+		return payment
+	}
+
+	held := create()
+	pending := domain.PaymentStatusPending
+	_, err = useCase.UpdatePayment(ctx, usecases.UpdatePaymentInput{ID: held.ID, Status: &pending})
+	assert.Error(t, err, "only a review releases the payment")
+	amount := 5.0
+	_, err = useCase.UpdatePayment(ctx, usecases.UpdatePaymentInput{ID: held.ID, Amount: &amount})
+	assert.Error(t, err, "the risk score depends on the amount")
+	_, err = useCase.ResolveRiskReview(ctx, usecases.ResolveRiskReviewInput{PaymentID: held.ID, Approve: true})
+	assert.Error(t, err, "the analyst is required")
+
+	approved, err := useCase.ResolveRiskReview(ctx, usecases.ResolveRiskReviewInput{PaymentID: held.ID, Approve: true, Analyst: "jane@example.com", Note: "known customer"})
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusAuthorized, approved.Status, "approved payments are processed")
+	assert.True(t, approved.Risk.Approved())
+	assert.Equal(t, "jane@example.com", approved.Risk.ReviewedBy)
+	stored, err := repo.GetByID(ctx, held.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.Risk.Approved(), "the review is stored with the payment")
+	assert.Equal(t, "known customer", stored.Risk.ReviewNote)
+	_, err = useCase.ResolveRiskReview(ctx, usecases.ResolveRiskReviewInput{PaymentID: held.ID, Approve: true, Analyst: "jane@example.com"})
+	assert.ErrorIs(t, err, domain.ErrNotOnRiskReview)
+
+	rejected, err := useCase.ResolveRiskReview(ctx, usecases.ResolveRiskReviewInput{PaymentID: create().ID, Analyst: "jane@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusRejected, rejected.Status)
+	assert.Equal(t, domain.RiskDecisionDeny, rejected.Risk.Decision)
+	assert.False(t, rejected.Risk.Approved())
+}
+
+func TestLoadConfigValidatesThresholds(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	for content, valid := range map[string]bool{
+		"thresholds: {review: 40, deny: 60}\n": true,
+		"thresholds: {review: 60, deny: 60}\n": false,
+		"thresholds: {review: 90}\n":           false,
+		"thresholds: {deny: 30}\n":             false,
+		"thresholds: {review: -1}\n":           false,
+	} {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		_, err := risk.LoadConfig(path)
+		assert.Equal(t, valid, err == nil, "%s: %v", content, err)
+	}
+}
@@ -0,0 +1,217 @@
+package routing_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"payments_app/internal/domain"
+	"payments_app/internal/infrastructure/processor"
+	"payments_app/internal/routing"
+	"payments_app/internal/usecases"
+	"payments_app/tests/helpers"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyProcessor is a simulator that can be switched into an outage
+type flakyProcessor struct {
+	*processor.Simulator
+	down atomic.Bool
+}
+
+func newFlakyProcessor(name string) *flakyProcessor {
+	return &flakyProcessor{Simulator: processor.NewSimulator(processor.SimulatorConfig{Name: name})}
+}
+
+func (p *flakyProcessor) Authorize(ctx context.Context, req domain.AuthorizeRequest) (*domain.ProcessorResult, error) {
+	if p.down.Load() {
+		return nil, fmt.Errorf("%w: %s is down", domain.ErrProcessorUnavailable, p.Name())
+	}
+	return p.Simulator.Authorize(ctx, req)
+}
+
+func TestRule_Matches(t *testing.T) {
+	payment := domain.NewPayment(250, "EUR", "Invoice")
+	payment.TenantID = "acme"
+	payment.Method = domain.BankAccountMethod{Scheme: domain.BankSchemeSEPA, IBAN: "DE89370400440532013000"}
+
+	tests := []struct {
+		name    string
+		rule    routing.Rule
+		matches bool
+	}{
+		{name: "empty rule", rule: routing.Rule{}, matches: true},
+		{name: "currency", rule: routing.Rule{Currencies: []string{"usd", "eur"}}, matches: true},
+		{name: "other currency", rule: routing.Rule{Currencies: []string{"USD"}}, matches: false},
+		{name: "method", rule: routing.Rule{Methods: []domain.PaymentMethodType{domain.PaymentMethodTypeBankAccount}}, matches: true},
+		{name: "other method", rule: routing.Rule{Methods: []domain.PaymentMethodType{domain.PaymentMethodTypeCard}}, matches: false},
+		{name: "tenant", rule: routing.Rule{Tenants: []string{"ACME"}}, matches: true},
+		{name: "other tenant", rule: routing.Rule{Tenants: []string{"globex"}}, matches: false},
+		{name: "amount band", rule: routing.Rule{MinAmount: 100, MaxAmount: 1000}, matches: true},
+		{name: "below band", rule: routing.Rule{MinAmount: 500}, matches: false},
+		{name: "band upper bound is exclusive", rule: routing.Rule{MaxAmount: 250}, matches: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.matches, tt.rule.Matches(payment))
+		})
+	}
+}
+
+func TestRouter_RulesAndFailover(t *testing.T) {
+	primary := newFlakyProcessor("primary")
+	backup := newFlakyProcessor("backup")
+	router, err := routing.NewRouter(routing.Config{
+		Breaker: routing.BreakerSettings{Window: 4, MinCalls: 2, ErrorRate: 0.6, Cooldown: 50 * time.Millisecond},
+		Rules: []routing.Rule{
+			{Name: "gbp", Currencies: []string{"GBP"}, Processors: []string{"backup"}},
+		},
+	}, primary, backup)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	authorize := func(currency string) (domain.Processor, []domain.RouteAttempt, error) {
+		payment := domain.NewPayment(10, currency, "Test")
+		p, _, route, err := router.Authorize(ctx, payment, domain.AuthorizeRequest{PaymentID: payment.ID, Amount: 10, Currency: currency})
+		return p, route, err
+	}
+
+	p, route, err := authorize("GBP")
+	require.NoError(t, err)
+	assert.Equal(t, "backup", p.Name())
+	assert.Equal(t, "gbp", route[0].Rule)
+
+	p, route, err = authorize("USD")
+	require.NoError(t, err)
+	assert.Equal(t, "primary", p.Name())
+	require.Len(t, route, 1)
+	assert.Equal(t, domain.ProcessorOutcomeApproved, route[0].Outcome)
+
+	// An outage fails over to the backup and trips the primary's circuit
+	primary.down.Store(true)
+	for i := 0; i < 2; i++ {
+		p, route, err = authorize("USD")
+		require.NoError(t, err)
+		assert.Equal(t, "backup", p.Name())
+		require.Len(t, route, 2)
+		assert.Contains(t, route[0].Error, "unavailable")
+	}
+
+	p, route, err = authorize("USD")
+	require.NoError(t, err)
+	assert.Equal(t, "backup", p.Name())
+	assert.Equal(t, "circuit open", route[0].Error)
+	assert.Equal(t, domain.CircuitOpen, router.Stats()[0].Circuit)
+
+	// After the cooldown a successful trial call closes the circuit again
+	primary.down.Store(false)
+	time.Sleep(60 * time.Millisecond)
+	p, _, err = authorize("USD")
+	require.NoError(t, err)
+	assert.Equal(t, "primary", p.Name())
+	assert.Equal(t, domain.CircuitClosed, router.Stats()[0].Circuit)
+
+	// No failover is possible when every eligible processor is down
+	backup.down.Store(true)
+	_, route, err = authorize("GBP")
+	assert.ErrorIs(t, err, domain.ErrProcessorUnavailable)
+	assert.Len(t, route, 1)
+
+	stats := router.Stats()
+	assert.Equal(t, "primary", stats[0].Processor)
+	assert.Equal(t, 4, stats[0].Calls)
+	assert.Equal(t, 2, stats[0].Errors)
+	assert.InDelta(t, 0.5, stats[0].SuccessRate, 0.001)
+}
+
+func TestBreaker_HalfOpenFailure(t *testing.T) {
+	breaker := routing.NewBreaker(routing.BreakerSettings{Window: 2, MinCalls: 2, ErrorRate: 1, Cooldown: 20 * time.Millisecond})
+
+	breaker.Record(true)
+	assert.Equal(t, domain.CircuitClosed, breaker.State())
+	breaker.Record(true)
+	assert.Equal(t, domain.CircuitOpen, breaker.State())
+	assert.False(t, breaker.Allow())
+
+	time.Sleep(25 * time.Millisecond)
+	assert.True(t, breaker.Allow())
+	assert.False(t, breaker.Allow(), "only one trial call is allowed while half-open")
+	breaker.Record(true)
+	assert.Equal(t, domain.CircuitOpen, breaker.State())
+}
+
+func TestNewRouter_Validation(t *testing.T) {
+	sim := processor.NewSimulator(processor.SimulatorConfig{})
+
+	_, err := routing.NewRouter(routing.Config{})
+	assert.Error(t, err)
+
+	_, err = routing.NewRouter(routing.Config{Default: []string{"missing"}}, sim)
+	assert.Error(t, err)
+
+	_, err = routing.NewRouter(routing.Config{Rules: []routing.Rule{{Name: "empty"}}}, sim)
+	assert.Error(t, err)
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routing.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+processors:
+  - name: a
+    type: simulator
+    latency: 5ms
+  - name: b
+    type: http
+    url: https://example.com
+    api_key_env: B_KEY
+default: [a]
+breaker:
+  cooldown: 1m
+rules:
+  - name: large-cards
+    methods: [CARD]
+    min_amount: 1000
+    processors: [b, a]
+`), 0o600))
+
+	cfg, err := routing.LoadConfig(path)
+	require.NoError(t, err)
+	require.Len(t, cfg.Processors, 2)
+	assert.Equal(t, 5*time.Millisecond, cfg.Processors[0].Latency)
+	assert.Equal(t, "B_KEY", cfg.Processors[1].APIKeyEnv)
+	assert.Equal(t, time.Minute, cfg.Breaker.Cooldown)
+	assert.Equal(t, []domain.PaymentMethodType{domain.PaymentMethodTypeCard}, cfg.Rules[0].Methods)
+	assert.Equal(t, 1000.0, cfg.Rules[0].MinAmount)
+}
+
+func TestPaymentUseCase_RecordsRoute(t *testing.T) {
+	primary := newFlakyProcessor("primary")
+	backup := newFlakyProcessor("backup")
+	primary.down.Store(true)
+	router, err := routing.NewRouter(routing.Config{}, primary, backup)
+	require.NoError(t, err)
+
+	repo := helpers.NewMockPaymentRepository()
+	useCase := usecases.NewPaymentUseCase(repo, usecases.WithRouter(router))
+	ctx := context.Background()
+
+	payment, err := useCase.CreatePayment(ctx, usecases.CreatePaymentInput{Amount: 20, Currency: "USD", Description: "Routed", TenantID: "acme"})
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusAuthorized, payment.Status)
+	assert.Equal(t, "backup", payment.Processor)
+	require.Len(t, payment.Route, 2)
+	assert.Equal(t, "primary", payment.Route[0].Processor)
+	assert.Equal(t, "backup", payment.Route[1].Processor)
+
+	// Follow-up operations go to the processor that authorized the payment
+	payment, err = useCase.CapturePayment(ctx, payment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusCompleted, payment.Status)
+	assert.Equal(t, 2, useCase.ProcessorStats()[1].Calls)
+}
@@ -0,0 +1,176 @@
+package scheduled_test
+
+import (
+	"context"
+	"path/filepath"
+	"payments_app/internal/domain"
+	"payments_app/internal/infrastructure/database"
+	"payments_app/internal/infrastructure/processor"
+	"payments_app/internal/usecases"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fixture struct {
+	repo    *database.PaymentRepository
+	useCase *usecases.PaymentUseCase
+}
+
+func setup(t *testing.T) *fixture {
+	repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "scheduled.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	return &fixture{repo: repo, useCase: newUseCase(repo)}
+}
+
+func newUseCase(repo *database.PaymentRepository) *usecases.PaymentUseCase {
+	return usecases.NewPaymentUseCase(repo,
+		usecases.WithProcessor(processor.NewSimulator(processor.SimulatorConfig{})),
+		usecases.WithAutoCapture(true),
+		usecases.WithScheduledPayments(repo),
+	)
+}
+
+func (f *fixture) schedule(t *testing.T, executeAt time.Time) *domain.Payment {
+	payment, err := f.useCase.CreatePayment(context.Background(), usecases.CreatePaymentInput{
+		Amount:      250,
+		Currency:    "EUR",
+		Description: "Supplier invoice",
+		ExecuteAt:   &executeAt,
+	})
+	require.NoError(t, err)
+	return payment
+}
+
+func TestScheduledPayment_ExecutesWhenDue(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	executeAt := time.Now().Add(7 * 24 * time.Hour)
+
+	payment := f.schedule(t, executeAt)
+	assert.Equal(t, domain.PaymentStatusScheduled, payment.Status)
+	assert.Empty(t, payment.ProcessorReference, "scheduled payments are not processed up front")
+
+	executed, err := f.useCase.RunScheduledPayments(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Zero(t, executed)
+
+	executed, err = f.useCase.RunScheduledPayments(ctx, executeAt)
+	require.NoError(t, err)
+	assert.Equal(t, 1, executed)
+
+	stored, err := f.repo.GetByID(ctx, payment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusCompleted, stored.Status)
+	assert.NotEmpty(t, stored.ProcessorReference)
+	require.NotNil(t, stored.ExecuteAt)
+	assert.WithinDuration(t, executeAt, *stored.ExecuteAt, time.Millisecond)
+
+	executed, err = f.useCase.RunScheduledPayments(ctx, executeAt.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, executed, "a payment is executed once")
+}
+
+func TestScheduledPayment_PastExecutionRunsImmediately(t *testing.T) {
+	f := setup(t)
+	payment := f.schedule(t, time.Now().Add(-time.Hour))
+	assert.Equal(t, domain.PaymentStatusCompleted, payment.Status)
+	assert.Nil(t, payment.ExecuteAt)
+}
+
+func TestScheduledPayment_ExactlyOnceAcrossRunners(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	executeAt := time.Now().Add(time.Hour)
+	for i := 0; i < 20; i++ {
+		f.schedule(t, executeAt)
+	}
+
+	// Two instances sharing the database run at the same time
+	runners := []*usecases.PaymentUseCase{f.useCase, newUseCase(f.repo)}
+	counts := make([]int, len(runners))
+	var wg sync.WaitGroup
+	for i, runner := range runners {
+		wg.Add(1)
+		go func(i int, runner *usecases.PaymentUseCase) {
+			defer wg.Done()
+			executed, err := runner.RunScheduledPayments(ctx, executeAt)
+			assert.NoError(t, err)
+			counts[i] = executed
+		}(i, runner)
+	}
+	wg.Wait()
+	assert.Equal(t, 20, counts[0]+counts[1])
+
+	payments, err := f.repo.GetAll(ctx)
+	require.NoError(t, err)
+	for _, payment := range payments {
+		assert.Equal(t, domain.PaymentStatusCompleted, payment.Status)
+	}
+}
+
+func TestScheduledPayment_ExpiredLeaseIsReclaimed(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	executeAt := time.Now().Add(time.Hour)
+	payment := f.schedule(t, executeAt)
+
+	// A runner claims the payment and dies before executing it
+	claimed, err := f.repo.ClaimDue(ctx, "crashed-runner", executeAt, time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+
+	executed, err := f.useCase.RunScheduledPayments(ctx, executeAt.Add(30*time.Second))
+	require.NoError(t, err)
+	assert.Zero(t, executed, "the lease is still held")
+
+	_, err = f.useCase.CancelScheduledPayment(ctx, payment.ID)
+	assert.ErrorIs(t, err, domain.ErrPaymentLeased)
+
+	executed, err = f.useCase.RunScheduledPayments(ctx, executeAt.Add(2*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, executed)
+
+	// The crashed runner cannot save its stale copy afterwards
+	claimed[0].Status = domain.PaymentStatusPending
+	ok, err := f.repo.CompleteScheduled(ctx, claimed[0], "crashed-runner")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestScheduledPayment_RescheduleAndCancel(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	payment := f.schedule(t, time.Now().Add(24*time.Hour))
+
+	later := time.Now().Add(72 * time.Hour)
+	rescheduled, err := f.useCase.ReschedulePayment(ctx, payment.ID, later)
+	require.NoError(t, err)
+	assert.Equal(t, later, *rescheduled.ExecuteAt)
+
+	executed, err := f.useCase.RunScheduledPayments(ctx, time.Now().Add(48*time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, executed, "the old date no longer applies")
+
+	_, err = f.useCase.ReschedulePayment(ctx, payment.ID, time.Now().Add(-time.Minute))
+	assert.Error(t, err)
+
+	status := domain.PaymentStatusPending
+	_, err = f.useCase.UpdatePayment(ctx, usecases.UpdatePaymentInput{ID: payment.ID, Status: &status})
+	assert.ErrorContains(t, err, "payment is scheduled")
+
+	cancelled, err := f.useCase.CancelScheduledPayment(ctx, payment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusCancelled, cancelled.Status)
+
+	executed, err = f.useCase.RunScheduledPayments(ctx, later)
+	require.NoError(t, err)
+	assert.Zero(t, executed)
+
+	_, err = f.useCase.CancelScheduledPayment(ctx, payment.ID)
+	assert.ErrorIs(t, err, domain.ErrPaymentNotScheduled)
+}
@@ -0,0 +1,203 @@
+package screening_test
+
+import (
+	"context"
+	"payments_app/internal/domain"
+	"payments_app/internal/infrastructure/processor"
+	"payments_app/internal/screening"
+	"payments_app/internal/usecases"
+	"payments_app/tests/helpers"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testListCSV = `id,name,aliases,list,program
+OFAC-1,Ivan Petrovich Sidorov,Иван Сидоров;Vanya Sidorov,OFAC,SDN
+EU-7,José Müller-Øberg,,EU,CFSP
+`
+
+func loadTestList(t *testing.T) *screening.List {
+	list, err := screening.ParseCSV(strings.NewReader(testListCSV))
+	require.NoError(t, err)
+	return list
+}
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "jose muller oberg", screening.Normalize("  José MÜLLER-Øberg "))
+	assert.Equal(t, "ivan sidorov", screening.Normalize("Иван Сидоров"))
+	assert.Equal(t, "strasse co", screening.Normalize("Straße & Co."))
+}
+
+func TestJaroWinkler(t *testing.T) {
+	assert.InDelta(t, 0.961, screening.JaroWinkler("martha", "marhta"), 0.001)
+	assert.InDelta(t, 0.840, screening.JaroWinkler("dwayne", "duane"), 0.001)
+	assert.Equal(t, 1.0, screening.JaroWinkler("same", "same"))
+	assert.Equal(t, 0.0, screening.JaroWinkler("abc", ""))
+}
+
+func TestParseCSV(t *testing.T) {
+	list := loadTestList(t)
+
+	require.Len(t, list.Entries, 2)
+	assert.Equal(t, "OFAC-1", list.Entries[0].ID)
+	assert.Equal(t, "OFAC", list.Entries[0].List)
+	assert.Equal(t, []string{"Ivan Petrovich Sidorov", "Иван Сидоров", "Vanya Sidorov"}, list.Entries[0].Names)
+}
+
+func TestParseXML(t *testing.T) {
+	list, err := screening.ParseXML(strings.NewReader(`
+<watchlist name="EU">
+  <entry id="EU-1" program="CFSP">
+    <name>Example Holdings</name>
+    <alias>Example Holding Co</alias>
+  </entry>
+  <entry id="">
+    <name>Ignored without id</name>
+  </entry>
+</watchlist>`))
+
+	require.NoError(t, err)
+	require.Len(t, list.Entries, 1)
+	assert.Equal(t, "EU", list.Entries[0].List)
+	assert.Equal(t, []string{"Example Holdings", "Example Holding Co"}, list.Entries[0].Names)
+}
+
+func TestScreener_Match(t *testing.T) {
+	screener := screening.NewScreener(loadTestList(t), 0.92)
+
+	tests := []struct {
+		name    string
+		input   string
+		entryID string
+	}{
+		{name: "exact", input: "Ivan Petrovich Sidorov", entryID: "OFAC-1"},
+		{name: "transliterated alias", input: "Ivan Sidorov", entryID: "OFAC-1"},
+		{name: "reordered tokens", input: "Sidorov, Ivan", entryID: "OFAC-1"},
+		{name: "diacritics dropped", input: "Jose Muller Oberg", entryID: "EU-7"},
+		{name: "typo", input: "Jose Muler Oberg", entryID: "EU-7"},
+		{name: "unrelated", input: "Maria Garcia"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hit := screener.Match(tt.input)
+			if tt.entryID == "" {
+				assert.Nil(t, hit)
+				return
+			}
+			require.NotNil(t, hit)
+			assert.Equal(t, tt.entryID, hit.EntryID)
+			assert.GreaterOrEqual(t, hit.Score, 0.92)
+		})
+	}
+}
+
+func TestPaymentUseCase_ScreeningHoldLifecycle(t *testing.T) {
+	repo := helpers.NewMockPaymentRepository()
+	screener := screening.NewScreener(loadTestList(t), 0)
+	useCase := usecases.NewPaymentUseCase(repo, usecases.WithSanctionsScreener(screener))
+	ctx := context.Background()
+
+	clear, err := useCase.CreatePayment(ctx, usecases.CreatePaymentInput{
+		Amount: 10, Currency: "USD", Description: "Invoice 1",
+		Payee: &domain.Party{Name: "Maria Garcia", Country: "es"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusPending, clear.Status)
+	assert.Equal(t, domain.ScreeningStatusClear, clear.Screening.Status)
+	assert.Equal(t, "ES", clear.Payee.Country)
+
+	held, err := useCase.CreatePayment(ctx, usecases.CreatePaymentInput{
+		Amount: 10, Currency: "USD", Description: "Invoice 2",
+		Payer: &domain.Party{Name: "Acme Corp"},
+		Payee: &domain.Party{Name: "Sidorov Ivan"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusScreeningHold, held.Status)
+	require.Len(t, held.Screening.Hits, 1)
+	assert.Equal(t, domain.PartyRolePayee, held.Screening.Hits[0].Role)
+
+	// A held payment cannot be moved on through a plain status update
+	completed := domain.PaymentStatusCompleted
+	_, err = useCase.UpdatePayment(ctx, usecases.UpdatePaymentInput{ID: held.ID, Status: &completed})
+	require.Error(t, err)
+
+	released, err := useCase.ResolveScreeningHold(ctx, usecases.ResolveScreeningHoldInput{
+		PaymentID: held.ID, Release: true, Analyst: "analyst@example.com", Note: "false positive",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusPending, released.Status)
+	assert.Equal(t, domain.ScreeningStatusReleased, released.Screening.Status)
+	assert.Equal(t, "analyst@example.com", released.Screening.ReviewedBy)
+	assert.NotNil(t, released.Screening.ReviewedAt)
+
+	_, err = useCase.ResolveScreeningHold(ctx, usecases.ResolveScreeningHoldInput{
+		PaymentID: held.ID, Release: false, Analyst: "analyst@example.com",
+	})
+	assert.ErrorIs(t, err, domain.ErrNotOnScreeningHold)
+}
+
+func TestPaymentUseCase_ScreeningDeny(t *testing.T) {
+	repo := helpers.NewMockPaymentRepository()
+	useCase := usecases.NewPaymentUseCase(repo, usecases.WithSanctionsScreener(screening.NewScreener(loadTestList(t), 0)))
+	ctx := context.Background()
+
+	held, err := useCase.CreatePayment(ctx, usecases.CreatePaymentInput{
+		Amount: 10, Currency: "EUR", Description: "Consulting",
+		Payer: &domain.Party{Name: "José Müller-Øberg"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, domain.PaymentStatusScreeningHold, held.Status)
+
+	denied, err := useCase.ResolveScreeningHold(ctx, usecases.ResolveScreeningHoldInput{
+		PaymentID: held.ID, Release: false, Analyst: "analyst@example.com",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusRejected, denied.Status)
+	assert.Equal(t, domain.ScreeningStatusDenied, denied.Screening.Status)
+}
+
+func TestPaymentUseCase_ScreeningReleaseProcessesPayment(t *testing.T) {
+	repo := helpers.NewMockPaymentRepository()
+	useCase := usecases.NewPaymentUseCase(repo,
+		usecases.WithSanctionsScreener(screening.NewScreener(loadTestList(t), 0)),
+		usecases.WithProcessor(processor.NewSimulator(processor.SimulatorConfig{})),
+	)
+	ctx := context.Background()
+
+	held, err := useCase.CreatePayment(ctx, usecases.CreatePaymentInput{
+		Amount: 10, Currency: "USD", Description: "Invoice 3",
+		Payee: &domain.Party{Name: "Sidorov Ivan"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, domain.PaymentStatusScreeningHold, held.Status, "held payments are not processed")
+
+	released, err := useCase.ResolveScreeningHold(ctx, usecases.ResolveScreeningHoldInput{
+		PaymentID: held.ID, Release: true, Analyst: "analyst@example.com",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusAuthorized, released.Status)
+
+	stored, err := repo.GetByID(ctx, held.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusAuthorized, stored.Status)
+}
+
+func TestPaymentUseCase_PartyValidation(t *testing.T) {
+	useCase := usecases.NewPaymentUseCase(helpers.NewMockPaymentRepository())
+
+	_, err := useCase.CreatePayment(context.Background(), usecases.CreatePaymentInput{
+		Amount: 10, Currency: "USD", Description: "Test", Payee: &domain.Party{Name: "  "},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "payee name is required")
+
+	_, err = useCase.CreatePayment(context.Background(), usecases.CreatePaymentInput{
+		Amount: 10, Currency: "USD", Description: "Test", Payer: &domain.Party{Name: "Acme", Country: "USA"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "payer country must be a 2-letter ISO code")
+}
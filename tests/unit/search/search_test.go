@@ -0,0 +1,253 @@
+package search_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"payments_app/graph/generated"
+	"payments_app/internal/domain"
+	"payments_app/internal/infrastructure/database"
+	"payments_app/internal/interfaces/graphql"
+	"payments_app/internal/usecases"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fixture struct {
+	repo    *database.PaymentRepository
+	useCase *usecases.PaymentUseCase
+}
+
+func setup(t *testing.T) *fixture {
+	repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "search.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	return &fixture{repo: repo, useCase: newUseCase(t, repo)}
+}
+
+func newUseCase(t *testing.T, repo *database.PaymentRepository) *usecases.PaymentUseCase {
+	customers, err := database.NewCustomerRepository(repo.DB())
+	require.NoError(t, err)
+	search, err := database.NewPaymentSearchRepository(repo.DB())
+	require.NoError(t, err)
+	t.Logf("full-text index: %t", search.FullText())
+	return usecases.NewPaymentUseCase(repo, usecases.WithCustomers(customers), usecases.WithSearch(search))
+}
+
+func (f *fixture) create(t *testing.T, input usecases.CreatePaymentInput) *domain.Payment {
+	input.Amount, input.Currency = 10, "EUR"
+	payment, err := f.useCase.CreatePayment(context.Background(), input)
+	require.NoError(t, err)
+	return payment
+}
+
+func (f *fixture) search(t *testing.T, query string) []string {
+	results, err := f.useCase.SearchPayments(context.Background(), query, 0, 0)
+	require.NoError(t, err)
+	ids := make([]string, len(results.Hits))
+	for i, hit := range results.Hits {
+		ids[i] = hit.Payment.ID
+	}
+	return ids
+}
+
+func TestSearchMatchesWordPrefixes(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	name := "Emmy Noether"
+	customer, err := f.useCase.CreateCustomer(ctx, usecases.CustomerInput{Name: &name})
+	require.NoError(t, err)
+
+	byCustomer := f.create(t, usecases.CreatePaymentInput{Description: "Monthly plan", CustomerID: customer.ID})
+	byDescription := f.create(t, usecases.CreatePaymentInput{Description: "Replacement keyboard"})
+	byOrder := f.create(t, usecases.CreatePaymentInput{Description: "Order", Metadata: map[string]string{"orderId": "ORD-77812"}})
+	byPayer := f.create(t, usecases.CreatePaymentInput{Description: "Transfer", Payer: &domain.Party{Name: "Grace Hopper", Account: "DE89370400440532013000"}})
+
+	assert.Equal(t, []string{byCustomer.ID}, f.search(t, "noeth"))
+	assert.Equal(t, []string{byCustomer.ID}, f.search(t, "emmy noe"))
+	assert.Equal(t, []string{byDescription.ID}, f.search(t, "KEYB"))
+	assert.Equal(t, []string{byOrder.ID}, f.search(t, "ORD-778"))
+	assert.Equal(t, []string{byPayer.ID}, f.search(t, "hopper"))
+	assert.Equal(t, []string{byCustomer.ID}, f.search(t, byCustomer.ID))
+	assert.Empty(t, f.search(t, "oether"), "terms match the start of words")
+	assert.Empty(t, f.search(t, "emmy keyboard"), "every term must match")
+}
+
+func TestSearchRanksAndHighlights(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	name := "Emmy Noether"
+	customer, err := f.useCase.CreateCustomer(ctx, usecases.CustomerInput{Name: &name})
+	require.NoError(t, err)
+
+	descriptionOnly := f.create(t, usecases.CreatePaymentInput{Description: "Gift for Emmy from the engineering team"})
+	customerAndDescription := f.create(t, usecases.CreatePaymentInput{Description: "Emmy analytical engine parts", CustomerID: customer.ID})
+
+	results, err := f.useCase.SearchPayments(ctx, "emmy", 0, 0)
+	require.NoError(t, err)
+	require.Len(t, results.Hits, 2)
+	assert.Equal(t, customerAndDescription.ID, results.Hits[0].Payment.ID)
+	assert.Equal(t, descriptionOnly.ID, results.Hits[1].Payment.ID)
+	assert.Greater(t, results.Hits[0].Rank, results.Hits[1].Rank)
+	assert.Contains(t, results.Hits[1].Snippet, "<mark>Emmy</mark>")
+	assert.Contains(t, results.Hits[1].Snippet, "Gift for")
+}
+
+func TestSearchIndexFollowsChanges(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	name := "Emmy Noether"
+	customer, err := f.useCase.CreateCustomer(ctx, usecases.CustomerInput{Name: &name})
+	require.NoError(t, err)
+	payment := f.create(t, usecases.CreatePaymentInput{Description: "Blue widget", CustomerID: customer.ID})
+
+	description := "Red gadget"
+	_, err = f.useCase.UpdatePayment(ctx, usecases.UpdatePaymentInput{ID: payment.ID, Description: &description})
+	require.NoError(t, err)
+	assert.Empty(t, f.search(t, "widget"))
+	assert.Equal(t, []string{payment.ID}, f.search(t, "gadget"))
+
+	_, err = f.useCase.EraseCustomer(ctx, customer.ID)
+	require.NoError(t, err)
+	assert.Empty(t, f.search(t, "noether"), "erased names are removed from the index")
+	assert.Equal(t, []string{payment.ID}, f.search(t, "gadget"))
+
+	require.NoError(t, f.useCase.DeletePayment(ctx, payment.ID))
+	assert.Empty(t, f.search(t, "gadget"))
+}
+
+func TestErasedPayerNamesAreNotFound(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	name := "Customer 7"
+	customer, err := f.useCase.CreateCustomer(ctx, usecases.CustomerInput{Name: &name})
+	require.NoError(t, err)
+	payment := f.create(t, usecases.CreatePaymentInput{
+		Description: "Transfer",
+		CustomerID:  customer.ID,
+		Payer:       &domain.Party{Name: "Grace Hopper", Account: "DE89370400440532013000"},
+	})
+	require.Equal(t, []string{payment.ID}, f.search(t, "hopper"))
+
+	_, err = f.useCase.EraseCustomer(ctx, customer.ID)
+	require.NoError(t, err)
+	assert.Empty(t, f.search(t, "hopper"), "the payer name is erased with the customer")
+	assert.Equal(t, []string{payment.ID}, f.search(t, "transfer"))
+}
+
+func TestSearchIndexesExistingPayments(t *testing.T) {
+	repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "search.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	payment := domain.NewPayment(10, "EUR", "Imported invoice")
+	require.NoError(t, repo.Create(context.Background(), payment))
+
+	f := &fixture{repo: repo, useCase: newUseCase(t, repo)}
+	assert.Equal(t, []string{payment.ID}, f.search(t, "invoice"))
+}
+
+func TestSearchPages(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		f.create(t, usecases.CreatePaymentInput{Description: "Coffee beans"})
+	}
+	f.create(t, usecases.CreatePaymentInput{Description: "Tea"})
+
+	seen := make(map[string]bool)
+	offset := 0
+	for page := 0; ; page++ {
+		results, err := f.useCase.SearchPayments(ctx, "coffee", 2, offset)
+		require.NoError(t, err)
+		assert.Equal(t, 5, results.TotalCount)
+		for _, hit := range results.Hits {
+			assert.False(t, seen[hit.Payment.ID], "hits are not repeated across pages")
+			seen[hit.Payment.ID] = true
+		}
+		offset += len(results.Hits)
+		if !results.HasNextPage {
+			assert.Equal(t, 2, page)
+			break
+		}
+	}
+	assert.Len(t, seen, 5)
+}
+
+func TestSearchValidation(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+
+	_, err := f.useCase.SearchPayments(ctx, "  ", 0, 0)
+	assert.Error(t, err)
+	_, err = f.useCase.SearchPayments(ctx, `" * -`, 0, 0)
+	assert.Error(t, err, "queries without words are rejected")
+	_, err = f.useCase.SearchPayments(ctx, "coffee", usecases.MaxPaymentPageSize+1, 0)
+	assert.Error(t, err)
+
+	f.create(t, usecases.CreatePaymentInput{Description: `Quote "special" 100%`})
+	assert.Len(t, f.search(t, `"special`), 1, "query syntax is quoted")
+	assert.Len(t, f.search(t, "100%"), 1)
+
+	_, err = usecases.NewPaymentUseCase(f.repo).SearchPayments(ctx, "coffee", 0, 0)
+	assert.ErrorIs(t, err, usecases.ErrSearchNotConfigured)
+}
+
+func TestGraphQLSearchPayments(t *testing.T) {
+	f := setup(t)
+	for i := 0; i < 3; i++ {
+		f.create(t, usecases.CreatePaymentInput{Description: "Concert tickets"})
+	}
+
+	srv := handler.NewDefaultServer(generated.NewExecutableSchema(generated.Config{Resolvers: graphql.NewResolver(f.useCase)}))
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	type connection struct {
+		Nodes []struct {
+			Payment struct{ ID string }
+			Rank    float64
+			Snippet string
+		}
+		PageInfo struct {
+			HasNextPage bool
+			EndCursor   *string
+		}
+		TotalCount int
+	}
+	search := func(variables map[string]any) connection {
+		body, err := json.Marshal(map[string]any{
+			"query":     `query($after: String) { searchPayments(query: "conc", first: 2, after: $after) { nodes { payment { id } rank snippet } pageInfo { hasNextPage endCursor } totalCount } }`,
+			"variables": variables,
+		})
+		require.NoError(t, err)
+		resp, err := http.Post(ts.URL, "application/json", bytes.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var result struct {
+			Data   struct{ SearchPayments connection }
+			Errors []map[string]any
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		require.Empty(t, result.Errors)
+		return result.Data.SearchPayments
+	}
+
+	first := search(nil)
+	assert.Equal(t, 3, first.TotalCount)
+	require.Len(t, first.Nodes, 2)
+	assert.True(t, first.PageInfo.HasNextPage)
+	assert.Contains(t, first.Nodes[0].Snippet, "<mark>Concert</mark>")
+	require.NotNil(t, first.PageInfo.EndCursor)
+
+	second := search(map[string]any{"after": *first.PageInfo.EndCursor})
+	require.Len(t, second.Nodes, 1)
+	assert.False(t, second.PageInfo.HasNextPage)
+	assert.NotContains(t, []string{first.Nodes[0].Payment.ID, first.Nodes[1].Payment.ID}, second.Nodes[0].Payment.ID)
+}
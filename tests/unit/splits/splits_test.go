@@ -0,0 +1,250 @@
+package splits_test
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"payments_app/internal/domain"
+	"payments_app/internal/infrastructure/database"
+	"payments_app/internal/infrastructure/processor"
+	"payments_app/internal/usecases"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fixture struct {
+	repo     *database.PaymentRepository
+	balances *database.RecipientBalanceRepository
+	useCase  *usecases.PaymentUseCase
+}
+
+func setup(t *testing.T) *fixture {
+	repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "splits.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	balances, err := database.NewRecipientBalanceRepository(repo.DB())
+	require.NoError(t, err)
+
+	useCase := usecases.NewPaymentUseCase(repo,
+		usecases.WithProcessor(processor.NewSimulator(processor.SimulatorConfig{})),
+		usecases.WithSplits(balances))
+	return &fixture{repo: repo, balances: balances, useCase: useCase}
+}
+
+func (f *fixture) create(t *testing.T, amount float64, splits ...usecases.SplitInput) (*domain.Payment, error) {
+	return f.useCase.CreatePayment(context.Background(), usecases.CreatePaymentInput{
+		Amount:      amount,
+		Currency:    "EUR",
+		Description: "Marketplace order",
+		Splits:      splits,
+	})
+}
+
+func shares(payment *domain.Payment) map[string]int64 {
+	result := make(map[string]int64)
+	for _, split := range payment.Splits {
+		result[split.Recipient] = split.Amount.MinorUnits
+	}
+	return result
+}
+
+func balance(t *testing.T, f *fixture, recipient string) int64 {
+	balances, err := f.useCase.RecipientBalances(context.Background(), recipient)
+	require.NoError(t, err)
+	if len(balances) == 0 {
+		return 0
+	}
+	require.Len(t, balances, 1)
+	assert.Equal(t, "EUR", balances[0].Currency)
+	return balances[0].MinorUnits
+}
+
+func TestAllocateGivesLeftoverToLargestRemainders(t *testing.T) {
+	amount := domain.Money{MinorUnits: 1000, Currency: "EUR"}
+	parts := amount.Allocate(big.NewRat(1, 3), big.NewRat(1, 3), big.NewRat(1, 3))
+	assert.Equal(t, []int64{334, 333, 333}, []int64{parts[0].MinorUnits, parts[1].MinorUnits, parts[2].MinorUnits})
+
+	parts = amount.Allocate(big.NewRat(1, 1), big.NewRat(2, 1), big.NewRat(0, 1))
+	assert.Equal(t, []int64{333, 667, 0}, []int64{parts[0].MinorUnits, parts[1].MinorUnits, parts[2].MinorUnits})
+
+	negative := domain.Money{MinorUnits: -5, Currency: "EUR"}.Allocate(big.NewRat(1, 1), big.NewRat(1, 1))
+	assert.Equal(t, []int64{-3, -2}, []int64{negative[0].MinorUnits, negative[1].MinorUnits})
+}
+
+func TestSplitsDivideTheAmountExactly(t *testing.T) {
+	f := setup(t)
+
+	payment, err := f.create(t, 100,
+		usecases.SplitInput{Recipient: "platform", Amount: "10"},
+		usecases.SplitInput{Recipient: "seller-a", Percent: "33.3333"},
+		usecases.SplitInput{Recipient: "seller-b", Percent: "33.3333"},
+		usecases.SplitInput{Recipient: "seller-c", Percent: "33.3334"},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"platform": 1000, "seller-a": 3000, "seller-b": 3000, "seller-c": 3000}, shares(payment))
+	assert.Equal(t, "33.3334", payment.Splits[3].Percent)
+
+	payment, err = f.create(t, 0.05,
+		usecases.SplitInput{Recipient: "seller-a", Percent: "50"},
+		usecases.SplitInput{Recipient: "seller-b", Percent: "50"},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"seller-a": 3, "seller-b": 2}, shares(payment), "ties go to the earlier recipient")
+
+	payment, err = f.create(t, 25.5,
+		usecases.SplitInput{Recipient: "seller-a", Amount: "20.40"},
+		usecases.SplitInput{Recipient: "platform", Amount: "5.10"},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"seller-a": 2040, "platform": 510}, shares(payment))
+
+	stored, err := f.repo.GetByID(context.Background(), payment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, payment.Splits, stored.Splits)
+}
+
+func TestInvalidSplits(t *testing.T) {
+	f := setup(t)
+	tests := []struct {
+		name    string
+		splits  []usecases.SplitInput
+		message string
+	}{
+		{"fixed short of amount", []usecases.SplitInput{{Recipient: "a", Amount: "60"}, {Recipient: "b", Amount: "30"}}, "split amounts must add up to the payment amount of 100.00 EUR, 10.00 EUR is not allocated"},
+		{"fixed over amount", []usecases.SplitInput{{Recipient: "a", Amount: "80"}, {Recipient: "b", Amount: "30"}}, "split amounts exceed the payment amount"},
+		{"percentages short of 100", []usecases.SplitInput{{Recipient: "a", Percent: "60"}, {Recipient: "b", Percent: "30"}}, "split percentages must add up to 100, not 90.00"},
+		{"nothing left for percentages", []usecases.SplitInput{{Recipient: "a", Amount: "100"}, {Recipient: "b", Percent: "100"}}, "leave nothing for the percentage shares"},
+		{"duplicate recipient", []usecases.SplitInput{{Recipient: "a", Amount: "50"}, {Recipient: " a ", Amount: "50"}}, "split 2: recipient a appears more than once"},
+		{"both amount and percent", []usecases.SplitInput{{Recipient: "a", Amount: "100", Percent: "100"}}, "split 1: give either an amount or a percent"},
+		{"missing recipient", []usecases.SplitInput{{Amount: "100"}}, "split 1: recipient is required"},
+		{"sub-cent amount", []usecases.SplitInput{{Recipient: "a", Amount: "99.995"}, {Recipient: "b", Amount: "0.005"}}, "more than 2 decimal places"},
+		{"negative percent", []usecases.SplitInput{{Recipient: "a", Percent: "-10"}, {Recipient: "b", Percent: "110"}}, `split 1: percent "-10" must be a decimal greater than 0`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.create(t, 100, tt.splits...)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.message)
+			var inputErr *usecases.InputError
+			require.ErrorAs(t, err, &inputErr)
+			assert.Equal(t, usecases.ErrorCodeInvalidSplits, inputErr.Code)
+		})
+	}
+}
+
+func TestCaptureCreditsAndRefundsReverseProportionally(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+
+	payment, err := f.create(t, 100,
+		usecases.SplitInput{Recipient: "platform", Percent: "10"},
+		usecases.SplitInput{Recipient: "seller-a", Percent: "60"},
+		usecases.SplitInput{Recipient: "seller-b", Percent: "30"},
+	)
+	require.NoError(t, err)
+	require.Equal(t, domain.PaymentStatusAuthorized, payment.Status)
+	assert.Zero(t, balance(t, f, "seller-a"), "recipients are credited on capture")
+
+	_, err = f.useCase.CapturePayment(ctx, payment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), balance(t, f, "platform"))
+	assert.Equal(t, int64(6000), balance(t, f, "seller-a"))
+	assert.Equal(t, int64(3000), balance(t, f, "seller-b"))
+
+	refund := 33.33
+	payment, err = f.useCase.RefundPayment(ctx, payment.ID, &refund)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{333, 2000, 1000}, []int64{
+		payment.Splits[0].Reversed.MinorUnits, payment.Splits[1].Reversed.MinorUnits, payment.Splits[2].Reversed.MinorUnits,
+	})
+	assert.Equal(t, int64(667), balance(t, f, "platform"))
+	assert.Equal(t, int64(4000), balance(t, f, "seller-a"))
+	assert.Equal(t, int64(2000), balance(t, f, "seller-b"))
+
+	payment, err = f.useCase.RefundPayment(ctx, payment.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusRefunded, payment.Status)
+	for _, recipient := range []string{"platform", "seller-a", "seller-b"} {
+		assert.Zero(t, balance(t, f, recipient), recipient)
+	}
+
+	stored, err := f.repo.GetByID(ctx, payment.ID)
+	require.NoError(t, err)
+	for _, split := range stored.Splits {
+		assert.Equal(t, split.Amount, split.Reversed)
+	}
+
+	entries, err := f.useCase.RecipientBalanceEntries(ctx, "seller-a")
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, domain.BalanceEntryKindCredit, entries[0].Kind)
+	assert.Equal(t, []int64{6000, -2000, -4000}, []int64{entries[0].Amount.MinorUnits, entries[1].Amount.MinorUnits, entries[2].Amount.MinorUnits})
+}
+
+func TestEveryCompletionCreditsRecipients(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	callbacks, err := database.NewCallbackRepository(f.repo.DB())
+	require.NoError(t, err)
+	useCase := usecases.NewPaymentUseCase(f.repo,
+		usecases.WithProcessor(processor.NewSimulator(processor.SimulatorConfig{})),
+		usecases.WithSplits(f.balances),
+		usecases.WithCallbacks(callbacks, f.repo))
+	pending := func(recipient, reference string) *domain.Payment {
+		payment := domain.NewPayment(100, "EUR", "Bank transfer")
+		payment.Splits = []domain.Split{{Recipient: recipient, Amount: domain.Money{MinorUnits: 10000, Currency: "EUR"}}}
+		payment.Processor, payment.ProcessorReference = "simulator", reference
+		require.NoError(t, f.repo.Create(ctx, payment))
+		return payment
+	}
+
+	// Marked completed by hand, e.g. once the bank booked it
+	updated := pending("a", "")
+	completed := domain.PaymentStatusCompleted
+	_, err = useCase.UpdatePayment(ctx, usecases.UpdatePaymentInput{ID: updated.ID, Status: &completed})
+	require.NoError(t, err)
+	assert.Equal(t, int64(10000), balance(t, f, "a"))
+
+	// Settled by the processor without a separate authorization
+	pending("b", "sim-settled")
+	_, err = useCase.HandleProcessorCallback(ctx, "simulator", domain.ProcessorEvent{
+		CallbackID: "evt-1", Type: domain.ProcessorEventStatus, Reference: "sim-settled", Status: domain.PaymentStatusCompleted,
+	}, []byte("{}"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(10000), balance(t, f, "b"))
+}
+
+func TestBalanceEntriesAreRecordedOnce(t *testing.T) {
+	f := setup(t)
+	entry := domain.BalanceEntry{ID: "credit:p1:a", Recipient: "a", PaymentID: "p1", Kind: domain.BalanceEntryKindCredit, Amount: domain.Money{MinorUnits: 500, Currency: "EUR"}}
+	require.NoError(t, f.balances.Record(context.Background(), []domain.BalanceEntry{entry}))
+	require.NoError(t, f.balances.Record(context.Background(), []domain.BalanceEntry{entry}))
+	assert.Equal(t, int64(500), balance(t, f, "a"))
+}
+
+func TestSplitPaymentAmountIsFixed(t *testing.T) {
+	f := setup(t)
+	payment, err := f.create(t, 100, usecases.SplitInput{Recipient: "a", Percent: "100"})
+	require.NoError(t, err)
+
+	amount := 50.0
+	_, err = f.useCase.UpdatePayment(context.Background(), usecases.UpdatePaymentInput{ID: payment.ID, Amount: &amount})
+	assert.EqualError(t, err, "the amount and currency of a split payment cannot change")
+}
+
+func TestSplitsRequireConfiguration(t *testing.T) {
+	repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "splits.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	useCase := usecases.NewPaymentUseCase(repo)
+
+	_, err = useCase.CreatePayment(context.Background(), usecases.CreatePaymentInput{
+		Amount: 10, Currency: "EUR", Description: "Order",
+		Splits: []usecases.SplitInput{{Recipient: "a", Percent: "100"}},
+	})
+	assert.ErrorIs(t, err, usecases.ErrSplitsNotConfigured)
+	_, err = useCase.RecipientBalances(context.Background(), "a")
+	assert.ErrorIs(t, err, usecases.ErrSplitsNotConfigured)
+}
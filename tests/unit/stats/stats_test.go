@@ -0,0 +1,161 @@
+package stats_test
+
+import (
+	"context"
+	"path/filepath"
+	"payments_app/internal/domain"
+	"payments_app/internal/infrastructure/database"
+	"payments_app/internal/usecases"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fixture struct {
+	repo    *database.PaymentRepository
+	useCase *usecases.PaymentUseCase
+}
+
+func setup(t *testing.T) *fixture {
+	repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "stats.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	return &fixture{repo: repo, useCase: usecases.NewPaymentUseCase(repo)}
+}
+
+// add stores a payment created at the given time
+func (f *fixture) add(t *testing.T, amount float64, currency string, status domain.PaymentStatus, createdAt time.Time) *domain.Payment {
+	payment := domain.NewPayment(amount, currency, "Stats payment")
+	payment.Status = status
+	payment.CreatedAt, payment.UpdatedAt = createdAt, createdAt
+	require.NoError(t, f.repo.Create(context.Background(), payment))
+	return payment
+}
+
+func decimals(stats *domain.AmountStats) []string {
+	return []string{stats.Sum.Decimal(), stats.Average.Decimal(), stats.Min.Decimal(), stats.Max.Decimal(),
+		stats.P50.Decimal(), stats.P90.Decimal(), stats.P95.Decimal(), stats.P99.Decimal()}
+}
+
+func TestPaymentStats_GroupsByCurrencyAndStatus(t *testing.T) {
+	f := setup(t)
+	now := time.Now()
+	for i := 1; i <= 10; i++ {
+		f.add(t, 0.1, "EUR", domain.PaymentStatusCompleted, now)
+		f.add(t, float64(i), "USD", domain.PaymentStatusCompleted, now)
+	}
+	f.add(t, 1500, "JPY", domain.PaymentStatusFailed, now)
+	f.add(t, 2.5, "EUR", domain.PaymentStatusFailed, now)
+
+	groups, err := f.useCase.PaymentStats(context.Background(), domain.PaymentFilter{},
+		[]domain.StatsDimension{domain.StatsDimensionCurrency, domain.StatsDimensionStatus}, "")
+	require.NoError(t, err)
+	require.Len(t, groups, 4)
+
+	eur := groups[0]
+	assert.Equal(t, "EUR", eur.Currency)
+	assert.Equal(t, domain.PaymentStatusCompleted, eur.Status)
+	assert.Equal(t, int64(10), eur.Count)
+	require.Len(t, eur.Amounts, 1)
+	assert.Equal(t, []string{"1.00", "0.10", "0.10", "0.10", "0.10", "0.10", "0.10", "0.10"}, decimals(eur.Amounts[0]), "sums are exact")
+
+	assert.Equal(t, domain.PaymentStatusFailed, groups[1].Status)
+	assert.Equal(t, "JPY", groups[2].Currency)
+	assert.Equal(t, "1500", groups[2].Amounts[0].Sum.Decimal())
+
+	usd := groups[3]
+	assert.Equal(t, []string{"55.00", "5.50", "1.00", "10.00", "5.00", "9.00", "10.00", "10.00"}, decimals(usd.Amounts[0]))
+}
+
+func TestPaymentStats_SplitsAmountsByCurrencyWithoutCurrencyGrouping(t *testing.T) {
+	f := setup(t)
+	now := time.Now()
+	f.add(t, 10, "USD", domain.PaymentStatusPending, now)
+	f.add(t, 20, "EUR", domain.PaymentStatusPending, now)
+	f.add(t, 30, "EUR", domain.PaymentStatusPending, now)
+	deleted := f.add(t, 1000, "EUR", domain.PaymentStatusPending, now)
+	require.NoError(t, f.repo.Delete(context.Background(), deleted.ID))
+
+	groups, err := f.useCase.PaymentStats(context.Background(), domain.PaymentFilter{}, nil, "")
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Equal(t, int64(3), groups[0].Count)
+	assert.Empty(t, groups[0].Currency)
+	require.Len(t, groups[0].Amounts, 2)
+	assert.Equal(t, "EUR", groups[0].Amounts[0].Currency)
+	assert.Equal(t, "50.00", groups[0].Amounts[0].Sum.Decimal(), "deleted payments are left out")
+	assert.Equal(t, "USD", groups[0].Amounts[1].Currency)
+
+	filtered, err := f.useCase.PaymentStats(context.Background(), domain.PaymentFilter{Currency: "usd"}, nil, "")
+	require.NoError(t, err)
+	require.Len(t, filtered, 1)
+	assert.Equal(t, int64(1), filtered[0].Count)
+
+	none, err := f.useCase.PaymentStats(context.Background(), domain.PaymentFilter{Currency: "GBP"},
+		[]domain.StatsDimension{domain.StatsDimensionDay}, "")
+	require.NoError(t, err)
+	assert.Empty(t, none)
+}
+
+func TestPaymentStats_BucketsDaysInTimeZone(t *testing.T) {
+	f := setup(t)
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	// Daylight saving time starts in New York on 2026-03-08 at 07:00 UTC
+	f.add(t, 1, "USD", domain.PaymentStatusCompleted, time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC))   // 2026-02-28 21:00 EST
+	f.add(t, 2, "USD", domain.PaymentStatusCompleted, time.Date(2026, 3, 8, 4, 30, 0, 0, time.UTC))  // 2026-03-07 23:30 EST
+	f.add(t, 4, "USD", domain.PaymentStatusCompleted, time.Date(2026, 3, 9, 22, 30, 0, 0, newYork))  // stored with its -04:00 offset
+	f.add(t, 8, "USD", domain.PaymentStatusCompleted, time.Date(2026, 3, 9, 4, 30, 0, 0, time.UTC))  // 2026-03-09 00:30 EDT
+	f.add(t, 16, "USD", domain.PaymentStatusCompleted, time.Date(2026, 7, 1, 3, 59, 0, 0, time.UTC)) // 2026-06-30 23:59 EDT
+
+	days, err := f.useCase.PaymentStats(context.Background(), domain.PaymentFilter{},
+		[]domain.StatsDimension{domain.StatsDimensionDay}, "America/New_York")
+	require.NoError(t, err)
+	sums := make(map[string]string)
+	for _, group := range days {
+		sums[group.Day] = group.Amounts[0].Sum.Decimal()
+	}
+	assert.Equal(t, map[string]string{
+		"2026-02-28": "1.00",
+		"2026-03-07": "2.00",
+		"2026-03-09": "12.00",
+		"2026-06-30": "16.00",
+	}, sums)
+
+	months, err := f.useCase.PaymentStats(context.Background(), domain.PaymentFilter{},
+		[]domain.StatsDimension{domain.StatsDimensionMonth}, "America/New_York")
+	require.NoError(t, err)
+	require.Len(t, months, 3)
+	assert.Equal(t, "2026-02", months[0].Month)
+	assert.Equal(t, "2026-03", months[1].Month)
+	assert.Equal(t, int64(3), months[1].Count)
+
+	utc, err := f.useCase.PaymentStats(context.Background(), domain.PaymentFilter{},
+		[]domain.StatsDimension{domain.StatsDimensionMonth, domain.StatsDimensionDay}, "")
+	require.NoError(t, err)
+	require.Len(t, utc, 5)
+	assert.Equal(t, "2026-03", utc[0].Month)
+	assert.Equal(t, "2026-03-01", utc[0].Day)
+	assert.Equal(t, "2026-03-10", utc[3].Day)
+	assert.Equal(t, "4.00", utc[3].Amounts[0].Sum.Decimal(), "the New York payment falls on 2026-03-10 02:30 UTC")
+}
+
+func TestPaymentStats_RejectsInvalidInput(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+
+	_, err := f.useCase.PaymentStats(ctx, domain.PaymentFilter{}, nil, "Mars/Olympus_Mons")
+	var input *usecases.InputError
+	require.ErrorAs(t, err, &input)
+	assert.Equal(t, usecases.ErrorCodeInvalidTimezone, input.Code)
+
+	_, err = f.useCase.PaymentStats(ctx, domain.PaymentFilter{}, []domain.StatsDimension{"WEEK"}, "")
+	require.ErrorAs(t, err, &input)
+	assert.Equal(t, usecases.ErrorCodeInvalidGroupBy, input.Code)
+
+	_, err = f.useCase.PaymentStats(ctx, domain.PaymentFilter{Statuses: []domain.PaymentStatus{"LOST"}}, nil, "")
+	require.ErrorAs(t, err, &input)
+	assert.Equal(t, usecases.ErrorCodeInvalidFilter, input.Code)
+}
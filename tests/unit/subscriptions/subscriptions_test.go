@@ -0,0 +1,332 @@
+package subscriptions_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"payments_app/internal/domain"
+	"payments_app/internal/infrastructure/database"
+	"payments_app/internal/infrastructure/processor"
+	"payments_app/internal/scheduler"
+	"payments_app/internal/usecases"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 9, 0, 0, 0, time.UTC)
+}
+
+func TestPaymentSchedule_Occurrences(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule domain.PaymentSchedule
+		expected []time.Time
+	}{
+		{
+			name:     "daily",
+			schedule: domain.PaymentSchedule{Frequency: domain.FrequencyDaily, StartAt: date(2024, 2, 28)},
+			expected: []time.Time{date(2024, 2, 28), date(2024, 2, 29), date(2024, 3, 1)},
+		},
+		{
+			name:     "every second week",
+			schedule: domain.PaymentSchedule{Frequency: domain.FrequencyWeekly, Interval: 2, StartAt: date(2024, 1, 1)},
+			expected: []time.Time{date(2024, 1, 1), date(2024, 1, 15), date(2024, 1, 29)},
+		},
+		{
+			name:     "month end anchor is clamped without drifting",
+			schedule: domain.PaymentSchedule{Frequency: domain.FrequencyMonthly, StartAt: date(2023, 1, 31)},
+			expected: []time.Time{date(2023, 1, 31), date(2023, 2, 28), date(2023, 3, 31), date(2023, 4, 30)},
+		},
+		{
+			name:     "leap year february",
+			schedule: domain.PaymentSchedule{Frequency: domain.FrequencyMonthly, AnchorDay: 30, StartAt: date(2024, 1, 15)},
+			expected: []time.Time{date(2024, 1, 30), date(2024, 2, 29), date(2024, 3, 30)},
+		},
+		{
+			name:     "anchor before start day begins next month",
+			schedule: domain.PaymentSchedule{Frequency: domain.FrequencyMonthly, AnchorDay: 5, StartAt: date(2024, 1, 20)},
+			expected: []time.Time{date(2024, 2, 5), date(2024, 3, 5)},
+		},
+		{
+			name:     "quarterly across year end",
+			schedule: domain.PaymentSchedule{Frequency: domain.FrequencyMonthly, Interval: 3, StartAt: date(2024, 11, 30)},
+			expected: []time.Time{date(2024, 11, 30), date(2025, 2, 28), date(2025, 5, 30)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := tt.schedule
+			require.NoError(t, schedule.Validate())
+			for i, expected := range tt.expected {
+				assert.Equal(t, expected, schedule.Occurrence(i), "occurrence %d", i)
+			}
+		})
+	}
+}
+
+func TestPaymentSchedule_Validate(t *testing.T) {
+	invalid := []domain.PaymentSchedule{
+		{Frequency: "YEARLY", StartAt: date(2024, 1, 1)},
+		{Frequency: domain.FrequencyDaily, Interval: -1, StartAt: date(2024, 1, 1)},
+		{Frequency: domain.FrequencyMonthly, AnchorDay: 32, StartAt: date(2024, 1, 1)},
+		{Frequency: domain.FrequencyWeekly},
+	}
+	for _, schedule := range invalid {
+		assert.Error(t, schedule.Validate(), "%+v", schedule)
+	}
+}
+
+type fixture struct {
+	repo          *database.PaymentRepository
+	subscriptions *database.SubscriptionRepository
+	useCase       *usecases.PaymentUseCase
+}
+
+var backoff = []time.Duration{time.Hour, 4 * time.Hour}
+
+func setup(t *testing.T, opts ...usecases.Option) *fixture {
+	repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "subscriptions.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	subscriptions, err := database.NewSubscriptionRepository(repo.DB())
+	require.NoError(t, err)
+
+	useCase := usecases.NewPaymentUseCase(repo, append([]usecases.Option{
+		usecases.WithProcessor(processor.NewSimulator(processor.SimulatorConfig{})),
+		usecases.WithAutoCapture(true),
+		usecases.WithSubscriptions(subscriptions, backoff),
+	}, opts...)...)
+	return &fixture{repo: repo, subscriptions: subscriptions, useCase: useCase}
+}
+
+func (f *fixture) subscribe(t *testing.T, amount float64, start time.Time) *domain.Subscription {
+	subscription, err := f.useCase.CreateSubscription(context.Background(), usecases.CreateSubscriptionInput{
+		Amount:      amount,
+		Currency:    "usd",
+		Description: "Pro plan",
+		PayerID:     "customer-1",
+		Frequency:   domain.FrequencyMonthly,
+		StartAt:     &start,
+	})
+	require.NoError(t, err)
+	return subscription
+}
+
+func TestRunDueSubscriptions_BillsAndAdvances(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	start := date(2024, 1, 31)
+	subscription := f.subscribe(t, 49, start)
+	assert.Equal(t, "USD", subscription.Currency)
+	assert.Equal(t, start, subscription.NextRunAt)
+
+	billed, err := f.useCase.RunDueSubscriptions(ctx, start.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Zero(t, billed, "nothing is due before the start")
+
+	billed, err = f.useCase.RunDueSubscriptions(ctx, start)
+	require.NoError(t, err)
+	assert.Equal(t, 1, billed)
+
+	stored, err := f.useCase.GetSubscription(ctx, subscription.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.Cycle)
+	assert.Equal(t, date(2024, 2, 29), stored.NextRunAt)
+	assert.Equal(t, domain.SubscriptionStatusActive, stored.Status)
+
+	payment, err := f.useCase.GetPayment(ctx, stored.LastPaymentID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusCompleted, payment.Status)
+	assert.Equal(t, subscription.ID, payment.SubscriptionID)
+	assert.Equal(t, "customer-1", payment.PayerID)
+	assert.Equal(t, "Pro plan (period 1)", payment.Description)
+
+	billed, err = f.useCase.RunDueSubscriptions(ctx, start.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, billed, "a period is billed once")
+}
+
+func TestRunDueSubscriptions_Dunning(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	start := date(2024, 3, 1)
+	// The simulator declines amounts ending in .02
+	subscription := f.subscribe(t, 10.02, start)
+
+	_, err := f.useCase.RunDueSubscriptions(ctx, start)
+	require.NoError(t, err)
+	stored, err := f.useCase.GetSubscription(ctx, subscription.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.SubscriptionStatusPastDue, stored.Status)
+	assert.Equal(t, 1, stored.RetryAttempt)
+	assert.Equal(t, start.Add(time.Hour), stored.NextRunAt)
+	assert.Contains(t, stored.LastError, "do not honor")
+	assert.Equal(t, 0, stored.Cycle)
+
+	_, err = f.useCase.RunDueSubscriptions(ctx, start.Add(time.Hour))
+	require.NoError(t, err)
+	stored, err = f.useCase.GetSubscription(ctx, subscription.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.RetryAttempt)
+	assert.Equal(t, start.Add(5*time.Hour), stored.NextRunAt)
+
+	_, err = f.useCase.RunDueSubscriptions(ctx, start.Add(5*time.Hour))
+	require.NoError(t, err)
+	stored, err = f.useCase.GetSubscription(ctx, subscription.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.SubscriptionStatusUnpaid, stored.Status, "retries are exhausted")
+
+	billed, err := f.useCase.RunDueSubscriptions(ctx, start.AddDate(1, 0, 0))
+	require.NoError(t, err)
+	assert.Zero(t, billed, "unpaid subscriptions are not billed")
+
+	resumed, err := f.useCase.ResumeSubscription(ctx, subscription.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.SubscriptionStatusActive, resumed.Status)
+	assert.Zero(t, resumed.RetryAttempt)
+	assert.False(t, resumed.NextRunAt.Before(time.Now()), "missed periods are skipped")
+}
+
+type failingFees struct{ event domain.FeeEvent }
+
+func (f failingFees) Fees(payment *domain.Payment, event domain.FeeEvent) ([]domain.Fee, error) {
+	if event == f.event {
+		return nil, errors.New("fee schedule unavailable")
+	}
+	return nil, nil
+}
+
+func TestRunDueSubscriptions_StoredPaymentBillsCycle(t *testing.T) {
+	f := setup(t, usecases.WithFees(failingFees{event: domain.FeeEventCaptured}))
+	ctx := context.Background()
+	start := date(2024, 5, 1)
+	subscription := f.subscribe(t, 30, start)
+
+	// The payment is stored and captured before the capture fees fail
+	billed, err := f.useCase.RunDueSubscriptions(ctx, start)
+	assert.ErrorContains(t, err, "fee schedule unavailable")
+	assert.Equal(t, 1, billed)
+
+	stored, err := f.useCase.GetSubscription(ctx, subscription.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.Cycle)
+	assert.Zero(t, stored.RetryAttempt, "a stored payment does not start dunning")
+	require.NotEmpty(t, stored.LastPaymentID)
+	payment, err := f.useCase.GetPayment(ctx, stored.LastPaymentID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusCompleted, payment.Status)
+
+	billed, err = f.useCase.RunDueSubscriptions(ctx, start.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, billed, "the payer is not charged twice")
+}
+
+func TestRunDueSubscriptions_Leases(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	now := time.Now()
+	subscription := f.subscribe(t, 15, now.Add(-time.Hour))
+
+	// Another instance claims the subscription and dies before billing it
+	claimed, err := f.subscriptions.ClaimDue(ctx, "other-runner", now, 5*time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+
+	billed, err := f.useCase.RunDueSubscriptions(ctx, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Zero(t, billed, "leased subscriptions are not billed")
+
+	_, err = f.useCase.PauseSubscription(ctx, subscription.ID)
+	assert.ErrorIs(t, err, domain.ErrSubscriptionBilling)
+
+	done, err := f.subscriptions.CompleteBilling(ctx, subscription, "someone-else")
+	require.NoError(t, err)
+	assert.False(t, done, "only the lease owner may complete billing")
+
+	billed, err = f.useCase.RunDueSubscriptions(ctx, now.Add(6*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, billed, "an expired lease is claimed again")
+
+	_, err = f.useCase.PauseSubscription(ctx, subscription.ID)
+	assert.NoError(t, err, "billing releases the lease")
+}
+
+func TestSubscription_PauseResumeCancel(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	subscription := f.subscribe(t, 20, time.Now().Add(-time.Hour))
+
+	paused, err := f.useCase.PauseSubscription(ctx, subscription.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.SubscriptionStatusPaused, paused.Status)
+
+	billed, err := f.useCase.RunDueSubscriptions(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Zero(t, billed, "paused subscriptions are not billed")
+
+	_, err = f.useCase.PauseSubscription(ctx, subscription.ID)
+	assert.Error(t, err)
+
+	resumed, err := f.useCase.ResumeSubscription(ctx, subscription.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.SubscriptionStatusActive, resumed.Status)
+	assert.Equal(t, 1, resumed.Cycle, "the period missed while paused is skipped")
+
+	cancelled, err := f.useCase.CancelSubscription(ctx, subscription.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.SubscriptionStatusCancelled, cancelled.Status)
+	assert.NotNil(t, cancelled.CancelledAt)
+
+	_, err = f.useCase.ResumeSubscription(ctx, subscription.ID)
+	assert.ErrorIs(t, err, domain.ErrSubscriptionCancelled)
+}
+
+func TestCreateSubscription_Validation(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+
+	_, err := f.useCase.CreateSubscription(ctx, usecases.CreateSubscriptionInput{Amount: 10, Currency: "USD", Description: "Plan", Frequency: "HOURLY"})
+	assert.ErrorContains(t, err, "unsupported frequency")
+
+	_, err = f.useCase.CreateSubscription(ctx, usecases.CreateSubscriptionInput{
+		Amount: 10, Currency: "USD", Description: "Plan", Frequency: domain.FrequencyMonthly,
+		Method: &usecases.PaymentMethodInput{
+			Type: domain.PaymentMethodTypeCard,
+			Card: &usecases.CardInput{Number: "4242424242424242", ExpiryMonth: 12, ExpiryYear: time.Now().Year() + 2},
+		},
+	})
+	assert.ErrorContains(t, err, "vaulted card token")
+
+	_, err = usecases.NewPaymentUseCase(f.repo).CreateSubscription(ctx, usecases.CreateSubscriptionInput{Amount: 10, Currency: "USD", Description: "Plan", Frequency: domain.FrequencyDaily})
+	assert.ErrorIs(t, err, usecases.ErrSubscriptionsNotConfigured)
+}
+
+func TestSchedulerRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var runs, failures atomic.Int32
+
+	done := make(chan struct{})
+	go func() {
+		scheduler.Run(ctx, 5*time.Millisecond, func(ctx context.Context, now time.Time) error {
+			if runs.Add(1) >= 3 {
+				cancel()
+			}
+			return errors.New("boom")
+		}, func(error) { failures.Add(1) })
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("scheduler did not stop after cancellation")
+	}
+	assert.Equal(t, int32(3), runs.Load())
+	assert.Equal(t, int32(2), failures.Load(), "errors after cancellation are not reported")
+}
@@ -0,0 +1,410 @@
+package tax_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"payments_app/graph/generated"
+	"payments_app/internal/domain"
+	"payments_app/internal/infrastructure/database"
+	"payments_app/internal/infrastructure/processor"
+	"payments_app/internal/interfaces/graphql"
+	"payments_app/internal/tax"
+	"payments_app/internal/usecases"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ratesYAML = `
+seller_country: DE
+jurisdictions:
+  - country: DE
+    pricing: inclusive
+    reverse_charge: true
+    rates:
+      standard: 19
+      reduced: 7
+  - country: FR
+    reverse_charge: true
+    rates:
+      standard: 20
+      reduced: 5.5
+  - country: GB
+    rounding: half_even
+    round_per: total
+    rates:
+      standard: 20
+      hospitality: 12.5
+  - country: JP
+    rounding: down
+    rates:
+      standard: 10
+`
+
+func loadEngine(t *testing.T, content string) *tax.Engine {
+	path := filepath.Join(t.TempDir(), "tax_rates.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	engine := tax.NewEngine(nil)
+	require.NoError(t, engine.Reload(path))
+	return engine
+}
+
+func money(t *testing.T, amount, currency string) domain.Money {
+	value, err := domain.ParseMoney(amount, currency)
+	require.NoError(t, err)
+	return value
+}
+
+func TestCalculateRoundsByJurisdiction(t *testing.T) {
+	engine := loadEngine(t, ratesYAML)
+	tests := []struct {
+		name                    string
+		country, category       string
+		pricing                 domain.TaxPricing
+		amount, currency        string
+		wantRate                string
+		wantNet, wantTax, gross string
+	}{
+		{"exclusive adds tax", "FR", "", "", "10.00", "EUR", "20", "10.00 EUR", "2.00 EUR", "12.00 EUR"},
+		{"decimal rate rounds half up", "FR", "reduced", "", "0.10", "EUR", "5.5", "0.10 EUR", "0.01 EUR", "0.11 EUR"},
+		{"inclusive takes tax out", "DE", "", "", "119.00", "EUR", "19", "100.00 EUR", "19.00 EUR", "119.00 EUR"},
+		{"inclusive rounds the contained tax", "DE", "standard", "", "1.00", "EUR", "19", "0.84 EUR", "0.16 EUR", "1.00 EUR"},
+		{"request overrides pricing", "DE", "reduced", domain.TaxPricingExclusive, "100.00", "EUR", "7", "100.00 EUR", "7.00 EUR", "107.00 EUR"},
+		{"half even rounds down to even", "GB", "hospitality", "", "0.04", "GBP", "12.5", "0.04 GBP", "0.00 GBP", "0.04 GBP"},
+		{"half even rounds up to even", "GB", "hospitality", "", "0.12", "GBP", "12.5", "0.12 GBP", "0.02 GBP", "0.14 GBP"},
+		{"down truncates", "JP", "", "", "15", "JPY", "10", "15 JPY", "1 JPY", "16 JPY"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breakdown, err := engine.Calculate(domain.TaxRequest{
+				Country: tt.country,
+				Pricing: tt.pricing,
+				Lines:   []domain.TaxableLine{{Category: tt.category, Amount: money(t, tt.amount, tt.currency)}},
+			})
+			require.NoError(t, err)
+			require.Len(t, breakdown.Lines, 1)
+			assert.Equal(t, tt.wantRate, breakdown.Lines[0].Rate)
+			assert.Equal(t, tt.wantNet, breakdown.Net.String())
+			assert.Equal(t, tt.wantTax, breakdown.Tax.String())
+			assert.Equal(t, tt.gross, breakdown.Gross.String())
+			assert.False(t, breakdown.ReverseCharge)
+		})
+	}
+}
+
+func TestTotalRoundingSharesTaxAmongLines(t *testing.T) {
+	engine := loadEngine(t, ratesYAML)
+	lines := []domain.TaxableLine{
+		{Category: "hospitality", Amount: money(t, "0.04", "GBP")},
+		{Category: "hospitality", Amount: money(t, "0.04", "GBP")},
+		{Category: "hospitality", Amount: money(t, "0.04", "GBP")},
+		{Amount: money(t, "1.00", "GBP")},
+	}
+	breakdown, err := engine.Calculate(domain.TaxRequest{Country: "gb", Lines: lines})
+	require.NoError(t, err)
+
+	// Rounded per line each 0.005 would be 0.00; rounded once, the 0.015 is 0.02
+	assert.Equal(t, domain.TaxPricingExclusive, breakdown.Pricing)
+	assert.Equal(t, "0.22 GBP", breakdown.Tax.String())
+	assert.Equal(t, "1.12 GBP", breakdown.Net.String())
+	assert.Equal(t, "1.34 GBP", breakdown.Gross.String())
+	hospitality := int64(0)
+	for i, line := range breakdown.Lines {
+		assert.Equal(t, line.Net.MinorUnits+line.Tax.MinorUnits, line.Gross.MinorUnits, "line %d", i)
+		if line.Category == "hospitality" {
+			hospitality += line.Tax.MinorUnits
+		}
+	}
+	assert.Equal(t, int64(2), hospitality)
+	assert.Equal(t, "standard", breakdown.Lines[3].Category)
+	assert.Equal(t, "0.20 GBP", breakdown.Lines[3].Tax.String())
+}
+
+func TestReverseCharge(t *testing.T) {
+	engine := loadEngine(t, ratesYAML)
+	line := []domain.TaxableLine{{Amount: money(t, "100.00", "EUR")}}
+
+	breakdown, err := engine.Calculate(domain.TaxRequest{Country: "FR", VATNumber: "fr 12 345678901", Lines: line})
+	require.NoError(t, err)
+	assert.True(t, breakdown.ReverseCharge)
+	assert.Equal(t, "FR12345678901", breakdown.VATNumber)
+	assert.Equal(t, "0", breakdown.Lines[0].Rate)
+	assert.Equal(t, "0.00 EUR", breakdown.Tax.String())
+	assert.Equal(t, "100.00 EUR", breakdown.Gross.String())
+
+	// Domestic business sales are taxed
+	breakdown, err = engine.Calculate(domain.TaxRequest{Country: "DE", VATNumber: "DE123456789", Lines: line})
+	require.NoError(t, err)
+	assert.False(t, breakdown.ReverseCharge)
+	assert.Equal(t, "15.97 EUR", breakdown.Tax.String())
+
+	// So are sales to countries without reverse charge
+	breakdown, err = engine.Calculate(domain.TaxRequest{Country: "GB", VATNumber: "GB123456789", Lines: []domain.TaxableLine{{Amount: money(t, "100.00", "GBP")}}})
+	require.NoError(t, err)
+	assert.False(t, breakdown.ReverseCharge)
+	assert.Equal(t, "20.00 GBP", breakdown.Tax.String())
+
+	_, err = engine.Calculate(domain.TaxRequest{Country: "FR", VATNumber: "DE123456789", Lines: line})
+	assert.EqualError(t, err, "VAT number DE123456789 was not issued in FR")
+	_, err = engine.Calculate(domain.TaxRequest{Country: "FR", VATNumber: "FR123", Lines: line})
+	assert.Error(t, err)
+}
+
+func TestCalculateValidation(t *testing.T) {
+	engine := loadEngine(t, ratesYAML)
+	eur := money(t, "10.00", "EUR")
+	tests := map[string]domain.TaxRequest{
+		"no tax rates are configured for US":     {Country: "US", Lines: []domain.TaxableLine{{Amount: eur}}},
+		"no books tax rate is configured for FR": {Country: "FR", Lines: []domain.TaxableLine{{Category: "books", Amount: eur}}},
+		"at least one line is required":          {Country: "FR"},
+		"all lines must be in the same currency": {Country: "FR", Lines: []domain.TaxableLine{{Amount: eur}, {Amount: money(t, "1", "USD")}}},
+		"taxable amounts must not be negative":   {Country: "FR", Lines: []domain.TaxableLine{{Amount: money(t, "-1", "EUR")}}},
+		`invalid tax pricing "GROSS"`:            {Country: "FR", Pricing: "GROSS", Lines: []domain.TaxableLine{{Amount: eur}}},
+	}
+	for message, request := range tests {
+		_, err := engine.Calculate(request)
+		assert.EqualError(t, err, message)
+	}
+}
+
+func TestValidateVATNumber(t *testing.T) {
+	valid := map[string][2]string{
+		"DE 123.456-789":   {"DE", "DE123456789"},
+		"EL123456789":      {"GR", "EL123456789"},
+		"XI123456789":      {"GB", "XI123456789"},
+		"nl123456789b01":   {"NL", "NL123456789B01"},
+		"ATU12345678":      {"AT", "ATU12345678"},
+		"CHE123456789MWST": {"CH", "CHE123456789MWST"},
+	}
+	for number, want := range valid {
+		country, normalized, err := tax.ValidateVATNumber(number)
+		require.NoError(t, err, number)
+		assert.Equal(t, want, [2]string{country, normalized}, number)
+	}
+
+	invalid := map[string]string{
+		"DE1":            "is too short",
+		"DE12345678":     "is not in the format of DE",
+		"NL123456789A01": "is not in the format of NL",
+		"AT12345678":     "is not in the format of AT",
+		"US123456789":    "does not start with a supported country prefix",
+	}
+	for number, message := range invalid {
+		_, _, err := tax.ValidateVATNumber(number)
+		assert.ErrorContains(t, err, message, number)
+	}
+}
+
+func TestInvalidRates(t *testing.T) {
+	tests := map[string]string{
+		"jurisdictions:\n  - country: DEU\n    rates:\n      standard: 19\n":                                                 "country must be a 2-letter ISO code",
+		"jurisdictions:\n  - country: DE\n    rates:\n      reduced: 7\n":                                                    "jurisdiction DE: a standard rate is required",
+		"jurisdictions:\n  - country: DE\n    rates:\n      standard: -1\n":                                                  `rate standard: "-1" is not a non-negative decimal`,
+		"jurisdictions:\n  - country: DE\n    rates:\n      standard: 101\n":                                                 "rate must not exceed 100",
+		"jurisdictions:\n  - country: DE\n    rounding: ceiling\n    rates:\n      standard: 19\n":                           "rounding must be half_up, half_even or down",
+		"jurisdictions:\n  - country: DE\n    round_per: invoice\n    rates:\n      standard: 19\n":                          "round_per must be line or total",
+		"jurisdictions:\n  - country: DE\n    pricing: net\n    rates:\n      standard: 19\n":                                "pricing must be inclusive or exclusive",
+		"jurisdictions:\n  - country: DE\n    reverse_charge: true\n    rates:\n      standard: 19\n":                        "jurisdiction DE: reverse_charge requires seller_country",
+		"jurisdictions:\n  - country: DE\n    rates:\n      standard: 19\n  - country: de\n    rates:\n      standard: 19\n": "jurisdiction DE is configured twice",
+		"seller_country: Germany\n": `invalid seller country "Germany"`,
+	}
+	for content, message := range tests {
+		path := filepath.Join(t.TempDir(), "tax_rates.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		cfg, err := tax.LoadConfig(path)
+		require.NoError(t, err)
+		_, err = tax.BuildRates(cfg)
+		assert.ErrorContains(t, err, message, "content %q", content)
+	}
+}
+
+func TestReloadKeepsRatesOnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tax_rates.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(ratesYAML), 0o600))
+	engine := tax.NewEngine(nil)
+	require.NoError(t, engine.Reload(path))
+
+	require.NoError(t, os.WriteFile(path, []byte("jurisdictions:\n  - country: DE\n"), 0o600))
+	assert.Error(t, engine.Reload(path))
+	assert.Len(t, engine.Rates().Jurisdictions, 4)
+	assert.Equal(t, "DE", engine.Rates().SellerCountry)
+}
+
+func TestSampleRatesFileLoads(t *testing.T) {
+	engine := tax.NewEngine(nil)
+	require.NoError(t, engine.Reload(filepath.Join("..", "..", "..", "configs", "tax_rates.yaml")))
+	assert.NotEmpty(t, engine.Rates().Jurisdictions)
+}
+
+func newUseCase(t *testing.T, options ...usecases.Option) (*database.PaymentRepository, *usecases.PaymentUseCase) {
+	repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "tax.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	options = append([]usecases.Option{usecases.WithProcessor(processor.NewSimulator(processor.SimulatorConfig{}))}, options...)
+	return repo, usecases.NewPaymentUseCase(repo, options...)
+}
+
+func TestPaymentsStoreTaxBreakdown(t *testing.T) {
+	repo, useCase := newUseCase(t, usecases.WithTax(loadEngine(t, ratesYAML)))
+	ctx := context.Background()
+
+	// Exclusive pricing charges the net amount plus tax, in the payer's country by default
+	payment, err := useCase.CreatePayment(ctx, usecases.CreatePaymentInput{
+		Amount:      100,
+		Currency:    "EUR",
+		Description: "Cookbook",
+		Payer:       &domain.Party{Name: "Marie", Country: "FR"},
+		Tax:         &usecases.TaxInput{Category: "reduced"},
+	})
+	require.NoError(t, err)
+	assert.InDelta(t, 105.50, payment.Amount, 1e-9)
+	require.NotNil(t, payment.Tax)
+	assert.Equal(t, "FR", payment.Tax.Country)
+	assert.Equal(t, "5.50 EUR", payment.Tax.Tax.String())
+
+	stored, err := repo.GetByID(ctx, payment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, payment.Tax, stored.Tax)
+
+	amount := 90.0
+	_, err = useCase.UpdatePayment(ctx, usecases.UpdatePaymentInput{ID: payment.ID, Amount: &amount})
+	assert.EqualError(t, err, "the amount and currency of a taxed payment cannot change")
+
+	// Inclusive pricing keeps the amount
+	payment, err = useCase.CreatePayment(ctx, usecases.CreatePaymentInput{
+		Amount:      119,
+		Currency:    "EUR",
+		Description: "Subscription",
+		Tax:         &usecases.TaxInput{Country: "de"},
+	})
+	require.NoError(t, err)
+	assert.InDelta(t, 119.0, payment.Amount, 1e-9)
+	assert.Equal(t, "100.00 EUR", payment.Tax.Net.String())
+
+	_, err = useCase.CreatePayment(ctx, usecases.CreatePaymentInput{Amount: 10, Currency: "EUR", Description: "Order", Tax: &usecases.TaxInput{}})
+	var inputErr *usecases.InputError
+	require.ErrorAs(t, err, &inputErr)
+	assert.Equal(t, usecases.ErrorCodeInvalidTax, inputErr.Code)
+	assert.EqualError(t, err, "tax country is required")
+}
+
+func TestTaxRequiresRates(t *testing.T) {
+	_, useCase := newUseCase(t)
+	_, err := useCase.CreatePayment(context.Background(), usecases.CreatePaymentInput{
+		Amount: 10, Currency: "EUR", Description: "Order", Tax: &usecases.TaxInput{Country: "FR"},
+	})
+	assert.True(t, errors.Is(err, usecases.ErrTaxNotConfigured))
+}
+
+func TestInvoicesCalculateTaxPerLine(t *testing.T) {
+	repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "tax.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	invoices, err := database.NewInvoiceRepository(repo.DB())
+	require.NoError(t, err)
+	useCase := usecases.NewPaymentUseCase(repo, usecases.WithInvoices(invoices), usecases.WithTax(loadEngine(t, ratesYAML)))
+	ctx := context.Background()
+	dueDate := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+
+	invoice, err := useCase.CreateInvoice(ctx, usecases.InvoiceInput{
+		Number:   "INV-1",
+		Currency: "EUR",
+		DueDate:  dueDate,
+		Lines: []usecases.InvoiceLineInput{
+			{Description: "Consulting", Quantity: "2", UnitPrice: "100"},
+			{Description: "Handbook", Category: "reduced", UnitPrice: "10"},
+		},
+		Tax: &usecases.TaxInput{Country: "FR"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "210.00 EUR", invoice.Subtotal.String())
+	assert.Equal(t, "40.55 EUR", invoice.Tax.String())
+	assert.Equal(t, "250.55 EUR", invoice.Total.String())
+	assert.Equal(t, "standard", invoice.Lines[0].Category)
+	assert.Equal(t, "0.55 EUR", invoice.Lines[1].Tax.String())
+	require.NotNil(t, invoice.TaxBreakdown)
+	assert.Equal(t, invoice.Total, invoice.TaxBreakdown.Gross)
+
+	stored, err := useCase.GetInvoice(ctx, invoice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, invoice.TaxBreakdown, stored.TaxBreakdown)
+
+	// Inclusive prices keep the total and split off the tax
+	invoice, err = useCase.CreateInvoice(ctx, usecases.InvoiceInput{
+		Number:   "INV-2",
+		Currency: "EUR",
+		DueDate:  dueDate,
+		Lines:    []usecases.InvoiceLineInput{{Description: "Consulting", UnitPrice: "119"}},
+		Tax:      &usecases.TaxInput{Country: "DE"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "100.00 EUR", invoice.Subtotal.String())
+	assert.Equal(t, "19.00 EUR", invoice.Tax.String())
+	assert.Equal(t, "119.00 EUR", invoice.Total.String())
+
+	_, err = useCase.CreateInvoice(ctx, usecases.InvoiceInput{
+		Number:   "INV-3",
+		Currency: "EUR",
+		DueDate:  dueDate,
+		Lines:    []usecases.InvoiceLineInput{{Description: "Consulting", UnitPrice: "100", Tax: "20"}},
+		Tax:      &usecases.TaxInput{Country: "FR"},
+	})
+	assert.EqualError(t, err, "line 1: tax is calculated and cannot be given")
+}
+
+func TestGraphQLTax(t *testing.T) {
+	_, useCase := newUseCase(t, usecases.WithTax(loadEngine(t, ratesYAML)))
+	srv := handler.NewDefaultServer(generated.NewExecutableSchema(generated.Config{Resolvers: graphql.NewResolver(useCase)}))
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	body, err := json.Marshal(map[string]any{"query": `mutation { createPayment(input: {amount: 100, currency: "EUR", description: "Licence", tax: {country: "FR", vatNumber: "FR12345678901", pricing: EXCLUSIVE}}) { amount tax { country pricing reverseCharge vatNumber lines { category rate tax { amount } } gross { amount currency } } } }`})
+	require.NoError(t, err)
+	resp, err := http.Post(ts.URL, "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	type money struct{ Amount, Currency string }
+	var result struct {
+		Data struct {
+			CreatePayment struct {
+				Amount float64
+				Tax    struct {
+					Country       string
+					Pricing       string
+					ReverseCharge bool
+					VatNumber     string
+					Lines         []struct {
+						Category string
+						Rate     string
+						Tax      money
+					}
+					Gross money
+				}
+			}
+		}
+		Errors []map[string]any
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	require.Empty(t, result.Errors)
+	payment := result.Data.CreatePayment
+	assert.InDelta(t, 100.0, payment.Amount, 1e-9)
+	assert.Equal(t, "FR", payment.Tax.Country)
+	assert.Equal(t, "EXCLUSIVE", payment.Tax.Pricing)
+	assert.True(t, payment.Tax.ReverseCharge)
+	assert.Equal(t, "FR12345678901", payment.Tax.VatNumber)
+	require.Len(t, payment.Tax.Lines, 1)
+	assert.Equal(t, "standard", payment.Tax.Lines[0].Category)
+	assert.Equal(t, "0", payment.Tax.Lines[0].Rate)
+	assert.Equal(t, "0.00", payment.Tax.Lines[0].Tax.Amount)
+	assert.Equal(t, money{"100.00", "EUR"}, payment.Tax.Gross)
+}
@@ -0,0 +1,142 @@
+package usecases_test
+
+import (
+	"context"
+	"payments_app/internal/domain"
+	"payments_app/internal/usecases"
+	"payments_app/tests/helpers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentUseCase_CreatePayment_CardMethod(t *testing.T) {
+	useCase := usecases.NewPaymentUseCase(helpers.NewMockPaymentRepository())
+
+	payment, err := useCase.CreatePayment(context.Background(), usecases.CreatePaymentInput{
+		Amount: 25, Currency: "USD", Description: "Card payment",
+		Method: &usecases.PaymentMethodInput{
+			Type: domain.PaymentMethodTypeCard,
+			Card: &usecases.CardInput{Number: "4111 1111 1111 1111", ExpiryMonth: 12, ExpiryYear: time.Now().Year() + 2},
+		},
+	})
+
+	require.NoError(t, err)
+	card, ok := payment.Method.(domain.CardMethod)
+	require.True(t, ok)
+	assert.Equal(t, "VISA", card.Brand)
+	assert.Equal(t, "1111", card.Last4)
+}
+
+func TestPaymentUseCase_CreatePayment_BankAccountMethods(t *testing.T) {
+	useCase := usecases.NewPaymentUseCase(helpers.NewMockPaymentRepository())
+
+	sepa, err := useCase.CreatePayment(context.Background(), usecases.CreatePaymentInput{
+		Amount: 25, Currency: "EUR", Description: "SEPA payment",
+		Method: &usecases.PaymentMethodInput{
+			Type:        domain.PaymentMethodTypeBankAccount,
+			BankAccount: &usecases.BankAccountInput{Scheme: domain.BankSchemeSEPA, IBAN: "de89 3704 0044 0532 0130 00", BIC: "cobadeffxxx"},
+		},
+	})
+	require.NoError(t, err)
+	account := sepa.Method.(domain.BankAccountMethod)
+	assert.Equal(t, "DE89370400440532013000", account.IBAN)
+	assert.Equal(t, "COBADEFFXXX", account.BIC)
+
+	ach, err := useCase.CreatePayment(context.Background(), usecases.CreatePaymentInput{
+		Amount: 25, Currency: "USD", Description: "ACH payment",
+		Method: &usecases.PaymentMethodInput{
+			Type:        domain.PaymentMethodTypeBankAccount,
+			BankAccount: &usecases.BankAccountInput{Scheme: domain.BankSchemeACH, RoutingNumber: "021000021", AccountNumber: "123456789"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "021000021", ach.Method.(domain.BankAccountMethod).RoutingNumber)
+}
+
+func TestPaymentUseCase_CreatePayment_MethodValidation(t *testing.T) {
+	useCase := usecases.NewPaymentUseCase(helpers.NewMockPaymentRepository())
+	nextYear := time.Now().Year() + 1
+
+	tests := []struct {
+		name        string
+		currency    string
+		method      *usecases.PaymentMethodInput
+		expectedErr string
+	}{
+		{
+			name:        "luhn failure",
+			currency:    "USD",
+			method:      &usecases.PaymentMethodInput{Type: domain.PaymentMethodTypeCard, Card: &usecases.CardInput{Number: "4111111111111112", ExpiryMonth: 1, ExpiryYear: nextYear}},
+			expectedErr: "card number is invalid",
+		},
+		{
+			name:        "expired card",
+			currency:    "USD",
+			method:      &usecases.PaymentMethodInput{Type: domain.PaymentMethodTypeCard, Card: &usecases.CardInput{Number: "4111111111111111", ExpiryMonth: 1, ExpiryYear: 2020}},
+			expectedErr: "card is expired",
+		},
+		{
+			name:        "bad IBAN checksum",
+			currency:    "EUR",
+			method:      &usecases.PaymentMethodInput{Type: domain.PaymentMethodTypeBankAccount, BankAccount: &usecases.BankAccountInput{Scheme: domain.BankSchemeSEPA, IBAN: "DE89370400440532013001"}},
+			expectedErr: "IBAN is invalid",
+		},
+		{
+			name:        "SEPA in wrong currency",
+			currency:    "USD",
+			method:      &usecases.PaymentMethodInput{Type: domain.PaymentMethodTypeBankAccount, BankAccount: &usecases.BankAccountInput{Scheme: domain.BankSchemeSEPA, IBAN: "DE89370400440532013000"}},
+			expectedErr: "SEPA transfers must be in EUR",
+		},
+		{
+			name:        "bad routing checksum",
+			currency:    "USD",
+			method:      &usecases.PaymentMethodInput{Type: domain.PaymentMethodTypeBankAccount, BankAccount: &usecases.BankAccountInput{Scheme: domain.BankSchemeACH, RoutingNumber: "021000022", AccountNumber: "12345"}},
+			expectedErr: "routing number is invalid",
+		},
+		{
+			name:        "missing details",
+			currency:    "USD",
+			method:      &usecases.PaymentMethodInput{Type: domain.PaymentMethodTypeWallet},
+			expectedErr: "wallet details are required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := useCase.CreatePayment(context.Background(), usecases.CreatePaymentInput{
+				Amount: 10, Currency: tt.currency, Description: "Test", Method: tt.method,
+			})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedErr)
+		})
+	}
+}
+
+func TestPaymentUseCase_UpdatePayment_MethodFixesAmountAndCurrency(t *testing.T) {
+	ctx := context.Background()
+	useCase := usecases.NewPaymentUseCase(helpers.NewMockPaymentRepository())
+
+	payment, err := useCase.CreatePayment(ctx, usecases.CreatePaymentInput{
+		Amount: 25, Currency: "EUR", Description: "SEPA payment",
+		Method: &usecases.PaymentMethodInput{
+			Type:        domain.PaymentMethodTypeBankAccount,
+			BankAccount: &usecases.BankAccountInput{Scheme: domain.BankSchemeSEPA, IBAN: "DE89370400440532013000"},
+		},
+	})
+	require.NoError(t, err)
+
+	amount, currency := 30.0, "USD"
+	_, err = useCase.UpdatePayment(ctx, usecases.UpdatePaymentInput{ID: payment.ID, Amount: &amount})
+	assert.Error(t, err)
+	_, err = useCase.UpdatePayment(ctx, usecases.UpdatePaymentInput{ID: payment.ID, Currency: &currency})
+	assert.Error(t, err, "a SEPA account cannot be paid in USD")
+
+	description := "SEPA payment for invoice 7"
+	updated, err := useCase.UpdatePayment(ctx, usecases.UpdatePaymentInput{ID: payment.ID, Description: &description})
+	require.NoError(t, err)
+	assert.Equal(t, 25.0, updated.Amount)
+	assert.Equal(t, "EUR", updated.Currency)
+}
@@ -0,0 +1,152 @@
+package vault_test
+
+import (
+	"context"
+	"encoding/base64"
+	"path/filepath"
+	"payments_app/internal/domain"
+	"payments_app/internal/infrastructure/database"
+	"payments_app/internal/usecases"
+	"payments_app/internal/vault"
+	"payments_app/tests/helpers"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(fill byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(fill), 32)))
+}
+
+func setupVaultStore(t *testing.T) *database.VaultRepository {
+	repo, err := database.NewPaymentRepository(filepath.Join(t.TempDir(), "vault_test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+
+	store, err := database.NewVaultRepository(repo.DB())
+	require.NoError(t, err)
+	return store
+}
+
+func TestParseKeyRing(t *testing.T) {
+	ring, err := vault.ParseKeyRing("k1:"+testKey('a'), "")
+	require.NoError(t, err)
+	assert.Equal(t, "k1", ring.ActiveID())
+
+	_, err = vault.ParseKeyRing("k1:"+testKey('a')+",k2:"+testKey('b'), "")
+	assert.Error(t, err)
+
+	_, err = vault.ParseKeyRing("k1:"+base64.StdEncoding.EncodeToString([]byte("short")), "")
+	assert.ErrorContains(t, err, "must be 32 bytes")
+
+	_, err = vault.ParseKeyRing("k1:"+testKey('a'), "k9")
+	assert.ErrorContains(t, err, "active key k9 is not configured")
+}
+
+func TestVault_TokenizeAndDetokenize(t *testing.T) {
+	store := setupVaultStore(t)
+	ring, err := vault.ParseKeyRing("k1:"+testKey('a'), "k1")
+	require.NoError(t, err)
+	v := vault.NewVault(store, ring)
+	ctx := context.Background()
+
+	tokenized, err := v.Tokenize(ctx, vault.Card{PAN: "4111111111111111", ExpiryMonth: 12, ExpiryYear: 2099})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(tokenized.Token, "tok_"))
+	assert.Equal(t, "VISA", tokenized.Brand)
+	assert.Equal(t, "1111", tokenized.Last4)
+
+	record, err := store.Get(ctx, tokenized.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "k1", record.KeyID)
+	assert.NotContains(t, string(record.Ciphertext), "4111111111111111")
+
+	card, err := v.Detokenize(ctx, tokenized.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "4111111111111111", card.PAN)
+
+	_, err = v.Detokenize(ctx, "tok_missing")
+	assert.ErrorIs(t, err, vault.ErrTokenNotFound)
+
+	// A vault holding a different key cannot decrypt the record
+	otherRing, err := vault.ParseKeyRing("k1:"+testKey('z'), "k1")
+	require.NoError(t, err)
+	_, err = vault.NewVault(store, otherRing).Detokenize(ctx, tokenized.Token)
+	assert.Error(t, err)
+}
+
+func TestVault_RotateKeys(t *testing.T) {
+	store := setupVaultStore(t)
+	ctx := context.Background()
+
+	oldRing, err := vault.ParseKeyRing("k1:"+testKey('a'), "k1")
+	require.NoError(t, err)
+	oldVault := vault.NewVault(store, oldRing)
+
+	var tokens []string
+	for _, pan := range []string{"4111111111111111", "5555555555554444", "378282246310005"} {
+		tokenized, err := oldVault.Tokenize(ctx, vault.Card{PAN: pan, ExpiryMonth: 1, ExpiryYear: 2099})
+		require.NoError(t, err)
+		tokens = append(tokens, tokenized.Token)
+	}
+
+	// Both keys are loaded during rotation so existing cards stay readable
+	rotatingRing, err := vault.ParseKeyRing("k1:"+testKey('a')+",k2:"+testKey('b'), "k2")
+	require.NoError(t, err)
+	rotatingVault := vault.NewVault(store, rotatingRing)
+
+	rotated, err := rotatingVault.RotateKeys(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, rotated)
+
+	rotated, err = rotatingVault.RotateKeys(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 0, rotated)
+
+	// After rotation the old key can be retired
+	newRing, err := vault.ParseKeyRing("k2:"+testKey('b'), "k2")
+	require.NoError(t, err)
+	card, err := vault.NewVault(store, newRing).Detokenize(ctx, tokens[1])
+	require.NoError(t, err)
+	assert.Equal(t, "5555555555554444", card.PAN)
+}
+
+func TestPaymentUseCase_CardTokens(t *testing.T) {
+	ring, err := vault.ParseKeyRing("k1:"+testKey('a'), "k1")
+	require.NoError(t, err)
+	v := vault.NewVault(setupVaultStore(t), ring)
+	useCase := usecases.NewPaymentUseCase(helpers.NewMockPaymentRepository(), usecases.WithCardVault(v))
+	ctx := context.Background()
+	expiryYear := time.Now().Year() + 1
+
+	tokenized, err := useCase.TokenizeCard(ctx, usecases.CardInput{Number: "5555 5555 5555 4444", ExpiryMonth: 6, ExpiryYear: expiryYear})
+	require.NoError(t, err)
+
+	payment, err := useCase.CreatePayment(ctx, usecases.CreatePaymentInput{
+		Amount: 10, Currency: "USD", Description: "Tokenized card",
+		Method: &usecases.PaymentMethodInput{Type: domain.PaymentMethodTypeCard, Card: &usecases.CardInput{Token: tokenized.Token}},
+	})
+	require.NoError(t, err)
+	card := payment.Method.(domain.CardMethod)
+	assert.Equal(t, tokenized.Token, card.Token)
+	assert.Equal(t, "MASTERCARD", card.Brand)
+	assert.Equal(t, 6, card.ExpiryMonth)
+
+	// Raw card numbers are vaulted on the way in
+	raw, err := useCase.CreatePayment(ctx, usecases.CreatePaymentInput{
+		Amount: 10, Currency: "USD", Description: "Raw card",
+		Method: &usecases.PaymentMethodInput{Type: domain.PaymentMethodTypeCard, Card: &usecases.CardInput{Number: "4111111111111111", ExpiryMonth: 6, ExpiryYear: expiryYear}},
+	})
+	require.NoError(t, err)
+	rawCard := raw.Method.(domain.CardMethod)
+	require.NotEmpty(t, rawCard.Token)
+	detokenized, err := v.Detokenize(ctx, rawCard.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "4111111111111111", detokenized.PAN)
+
+	_, err = usecases.NewPaymentUseCase(helpers.NewMockPaymentRepository()).TokenizeCard(ctx, usecases.CardInput{Number: "4111111111111111", ExpiryMonth: 6, ExpiryYear: expiryYear})
+	assert.ErrorIs(t, err, usecases.ErrVaultNotConfigured)
+}